@@ -19,7 +19,8 @@ import (
 )
 
 // The registration binary contains both the hub-side controllers for the
-// registration API and the spoke agent.
+// registration API and the spoke agent, as well as the singleton klusterlet
+// agent running the registration and work controllers in one process.
 
 func main() {
 	rand.Seed(time.Now().UTC().UnixNano())
@@ -58,6 +59,7 @@ func newRegistrationCommand() *cobra.Command {
 
 	cmd.AddCommand(hub.NewRegistrationController())
 	cmd.AddCommand(spoke.NewRegistrationAgent())
+	cmd.AddCommand(spoke.NewKlusterletAgent())
 	cmd.AddCommand(webhook.NewRegistrationWebhook())
 	return cmd
 }
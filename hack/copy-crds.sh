@@ -19,3 +19,4 @@ done
 
 cp $CLUSTER_MANAGER_CRD_FILE ./deploy/cluster-manager/config/crds/
 cp $KLUSTERLET_CRD_FILE ./deploy/klusterlet/config/crds/
+cp $KLUSTERLET_CRD_FILE ./manifests/klusterlet/import/
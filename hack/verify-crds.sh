@@ -19,4 +19,5 @@ done
 
 diff -N $CLUSTER_MANAGER_CRD_FILE ./deploy/cluster-manager/config/crds/$(basename $CLUSTER_MANAGER_CRD_FILE) || ( echo 'crd content is incorrect' && false )
 diff -N $KLUSTERLET_CRD_FILE ./deploy/klusterlet/config/crds/$(basename $KLUSTERLET_CRD_FILE) || ( echo 'crd content is incorrect' && false )
+diff -N $KLUSTERLET_CRD_FILE ./manifests/klusterlet/import/$(basename $KLUSTERLET_CRD_FILE) || ( echo 'crd content is incorrect' && false )
 
@@ -14,10 +14,26 @@ type HubConfig struct {
 	WorkWebhook                    Webhook
 	RegistrationFeatureGates       []string
 	WorkFeatureGates               []string
+	PlacementFeatureGates          []string
 	AddOnManagerImage              string
 	AddOnManagerEnabled            bool
 	MWReplicaSetEnabled            bool
-	AutoApproveUsers               string
+	// ImportManifestsEnabled is true if the registration controller generates the import manifests of the
+	// managed clusters.
+	ImportManifestsEnabled bool
+	// ImportOperatorImage is the klusterlet operator image in the import manifests.
+	ImportOperatorImage string
+	AutoApproveUsers    string
+	// AutoApprovalSelector is the label selector of the clusters auto approved for the AutoApproveUsers.
+	AutoApprovalSelector string
+	// RegistrationLogLevel, WorkLogLevel, PlacementLogLevel and AddOnManagerLogLevel are the log verbosity of
+	// the hub components, the default verbosity is used if it is empty.
+	RegistrationLogLevel string
+	WorkLogLevel         string
+	PlacementLogLevel    string
+	AddOnManagerLogLevel string
+	// LeasePerControllerGroup elects each controller group of the hub controllers with a lease of its own.
+	LeasePerControllerGroup bool
 }
 
 type Webhook struct {
@@ -9,3 +9,6 @@ var ClusterManagerManifestFiles embed.FS
 //go:embed klusterlet/managed
 //go:embed klusterletkube111
 var KlusterletManifestFiles embed.FS
+
+//go:embed klusterlet/import
+var KlusterletImportManifestFiles embed.FS
@@ -19,5 +19,8 @@ func NewHubOperatorCmd() *cobra.Command {
 	cmd.Short = "Start the cluster manager operator"
 
 	cmd.Flags().BoolVar(&options.SkipRemoveCRDs, "skip-remove-crds", false, "Skip removing CRDs while ClusterManager is deleting.")
+	cmd.Flags().StringVar(&options.ImportOperatorImage, "import-operator-image", "",
+		"The klusterlet operator image in the import manifests of the managed clusters, the import manifests are not "+
+			"generated if it is empty.")
 	return cmd
 }
@@ -1,19 +1,26 @@
 package hub
 
 import (
-	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/spf13/cobra"
 
+	"open-cluster-management.io/ocm/pkg/common/leadership"
+	"open-cluster-management.io/ocm/pkg/features"
 	controllers "open-cluster-management.io/ocm/pkg/placement/controllers"
 	"open-cluster-management.io/ocm/pkg/version"
 )
 
 func NewPlacementController() *cobra.Command {
-	cmd := controllercmd.
-		NewControllerCommandConfig("placement", version.Get(), controllers.RunControllerManager).
+	o := controllers.NewPlacementManagerOptions()
+	leaderElection := leadership.NewOptions()
+	cmd := leaderElection.NewControllerCommandConfig("placement", version.Get(),
+		leadership.SingleGroup("scheduling", o.RunControllerManager)).
 		NewCommand()
 	cmd.Use = "controller"
 	cmd.Short = "Start the Placement Scheduling Controller"
 
+	leaderElection.AddFlags(cmd.Flags())
+	o.AddFlags(cmd.Flags())
+	features.DefaultHubPlacementMutableFeatureGate.AddFlag(cmd.Flags())
+
 	return cmd
 }
@@ -1,20 +1,28 @@
 package hub
 
 import (
-	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/spf13/cobra"
 
+	"open-cluster-management.io/ocm/pkg/common/leadership"
 	"open-cluster-management.io/ocm/pkg/version"
 	"open-cluster-management.io/ocm/pkg/work/hub"
+	"open-cluster-management.io/ocm/pkg/work/hub/metrics"
 )
 
 // NewHubManager generates a command to start hub manager
 func NewWorkController() *cobra.Command {
-	cmdConfig := controllercmd.
-		NewControllerCommandConfig("work-manager", version.Get(), hub.RunWorkHubManager)
+	o := hub.NewWorkHubManagerOptions()
+	leaderElection := leadership.NewOptions()
+	cmdConfig := leaderElection.NewControllerCommandConfig("work-manager", version.Get(),
+		// the metrics of the manifestWorkReplicaSets are only reported by the leader
+		leadership.SingleGroup("manifestworkreplicasets", o.RunWorkHubManager,
+			metrics.ManifestWorkReplicaSetTargetClusters.Reset, metrics.ManifestWorkReplicaSetNotAvailableWorks.Reset))
 	cmd := cmdConfig.NewCommand()
 	cmd.Use = "manager"
 	cmd.Short = "Start the Work Hub Manager"
 
+	leaderElection.AddFlags(cmd.Flags())
+	o.AddFlags(cmd.Flags())
+
 	return cmd
 }
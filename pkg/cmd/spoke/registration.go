@@ -21,5 +21,8 @@ func NewRegistrationAgent() *cobra.Command {
 	agentOptions.AddFlags(flags)
 
 	flags.BoolVar(&cmdConfig.DisableLeaderElection, "disable-leader-election", false, "Disable leader election for the agent.")
+
+	// the TLS options also restrict the metrics and health listeners of the agent
+	agentOptions.AgentOptions.TLSOptions.ApplyToControllerCommand(cmd)
 	return cmd
 }
@@ -0,0 +1,30 @@
+package spoke
+
+import (
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/spf13/cobra"
+
+	"open-cluster-management.io/ocm/pkg/singleton/spoke"
+	"open-cluster-management.io/ocm/pkg/version"
+)
+
+// NewKlusterletAgent generates a command to start the singleton agent running the registration and work
+// controllers in one process.
+func NewKlusterletAgent() *cobra.Command {
+	agentOptions := spoke.NewAgentOptions()
+	cmdConfig := controllercmd.
+		NewControllerCommandConfig("klusterlet-agent", version.Get(), agentOptions.RunSpokeAgent)
+
+	cmd := cmdConfig.NewCommand()
+	cmd.Use = "klusterlet-agent"
+	cmd.Short = "Start the Klusterlet Agent running the registration and work controllers"
+
+	flags := cmd.Flags()
+	agentOptions.AddFlags(flags)
+
+	flags.BoolVar(&cmdConfig.DisableLeaderElection, "disable-leader-election", false, "Disable leader election for the agent.")
+
+	// the TLS options also restrict the metrics and health listeners of the agent
+	agentOptions.RegistrationOptions.AgentOptions.TLSOptions.ApplyToControllerCommand(cmd)
+	return cmd
+}
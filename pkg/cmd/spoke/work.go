@@ -23,5 +23,8 @@ func NewWorkAgent() *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolVar(&cmdConfig.DisableLeaderElection, "disable-leader-election", false, "Disable leader election for the agent.")
 
+	// the TLS options also restrict the metrics and health listeners of the agent
+	o.AgentOptions.TLSOptions.ApplyToControllerCommand(cmd)
+
 	return cmd
 }
@@ -0,0 +1,142 @@
+package agentevents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// CriticalReasonPrefix is the well-known prefix of the reason of the critical events reported by the agents
+	// in the cluster namespace on the hub. The reason is the prefix followed by the category, e.g.
+	// AgentCriticalWorkApplyFailed.
+	CriticalReasonPrefix = "AgentCritical"
+
+	// CategoryWorkApplyFailed is the category of the critical events reported when a manifestwork fails to apply.
+	CategoryWorkApplyFailed = "WorkApplyFailed"
+
+	// CategoryClaimCollectionFailed is the category of the critical events reported when the cluster claims fail
+	// to be collected.
+	CategoryClaimCollectionFailed = "ClaimCollectionFailed"
+
+	// MaxMessageLength is the max length of the message of a critical event, a longer message is truncated.
+	MaxMessageLength = 1024
+)
+
+var (
+	// ReportQPS and ReportBurst limit the rate of the critical events reported in each category, the events
+	// exceeding the rate are dropped and only logged on the managed cluster.
+	ReportQPS   float32 = 1.0 / 60
+	ReportBurst         = 3
+)
+
+// Reporter reports the critical failures of an agent as warning events on the ManagedCluster in the cluster
+// namespace on the hub, so they are summarized on the ManagedCluster by the hub. A nil Reporter reports nothing.
+type Reporter struct {
+	client      corev1client.EventsGetter
+	clusterName string
+	component   string
+	clock       clock.Clock
+
+	lock     sync.Mutex
+	limiters map[string]flowcontrol.PassiveRateLimiter
+}
+
+// NewReporter returns a Reporter writing the events with the hub client of the agent.
+func NewReporter(client corev1client.EventsGetter, clusterName, component string) *Reporter {
+	return &Reporter{
+		client:      client,
+		clusterName: clusterName,
+		component:   component,
+		clock:       clock.RealClock{},
+		limiters:    map[string]flowcontrol.PassiveRateLimiter{},
+	}
+}
+
+// Reportf reports a critical event in the category. It returns false if the event is dropped by the rate limiter
+// or fails to be created.
+func (r *Reporter) Reportf(ctx context.Context, category, messageFmt string, args ...interface{}) bool {
+	if r == nil {
+		return false
+	}
+
+	if !r.limiter(category).TryAccept() {
+		klog.V(4).Infof("Critical event %s of cluster %s is dropped by the rate limiter", category, r.clusterName)
+		return false
+	}
+
+	now := metav1.NewTime(r.clock.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", r.clusterName, now.UnixNano()),
+			Namespace: r.clusterName,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "cluster.open-cluster-management.io/v1",
+			Kind:       "ManagedCluster",
+			Name:       r.clusterName,
+		},
+		Reason:              CriticalReasonPrefix + category,
+		Message:             truncate(fmt.Sprintf(messageFmt, args...), MaxMessageLength),
+		Type:                corev1.EventTypeWarning,
+		Source:              corev1.EventSource{Component: r.component},
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+		ReportingController: r.component,
+	}
+	if _, err := r.client.Events(r.clusterName).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.Warningf("Failed to report critical event %s of cluster %s: %v", category, r.clusterName, err)
+		return false
+	}
+	return true
+}
+
+func (r *Reporter) limiter(category string) flowcontrol.PassiveRateLimiter {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	limiter, ok := r.limiters[category]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(ReportQPS, ReportBurst, r.clock)
+		r.limiters[category] = limiter
+	}
+	return limiter
+}
+
+// CategoryOf returns the category of a critical event reason, it returns false if the reason is not a critical
+// event reason.
+func CategoryOf(reason string) (string, bool) {
+	if !strings.HasPrefix(reason, CriticalReasonPrefix) || len(reason) == len(CriticalReasonPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(reason, CriticalReasonPrefix), true
+}
+
+// EventTime returns the last time the event is observed.
+func EventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func truncate(message string, length int) string {
+	if len(message) <= length {
+		return message
+	}
+	return message[:length-3] + "..."
+}
@@ -0,0 +1,74 @@
+package agentevents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	testingclock "k8s.io/utils/clock/testing"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestReportf(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	reporter := NewReporter(kubeClient.CoreV1(), "cluster1", "work-agent")
+	reporter.clock = fakeClock
+
+	// the events exceeding the burst are dropped
+	for i := 0; i < ReportBurst+2; i++ {
+		reported := reporter.Reportf(context.TODO(), CategoryWorkApplyFailed, "failed to apply work%d", i)
+		if reported != (i < ReportBurst) {
+			t.Errorf("expected the event %d reported %v, but got %v", i, i < ReportBurst, reported)
+		}
+		fakeClock.Step(time.Millisecond)
+	}
+	// the rate is limited per category
+	if !reporter.Reportf(context.TODO(), CategoryClaimCollectionFailed, "%s", strings.Repeat("x", 2*MaxMessageLength)) {
+		t.Errorf("expected the event of another category is reported")
+	}
+	// the events are reported again once the token is refilled
+	fakeClock.Step(time.Duration(float32(time.Second) / ReportQPS))
+	if !reporter.Reportf(context.TODO(), CategoryWorkApplyFailed, "failed to apply work") {
+		t.Errorf("expected the event is reported after the rate limit")
+	}
+
+	actions := kubeClient.Actions()
+	testingcommon.AssertActions(t, actions, "create", "create", "create", "create", "create")
+	event := actions[0].(clienttesting.CreateActionImpl).Object.(*corev1.Event)
+	if event.Namespace != "cluster1" || event.InvolvedObject.Kind != "ManagedCluster" || event.InvolvedObject.Name != "cluster1" {
+		t.Errorf("unexpected event %v", event)
+	}
+	if event.Reason != "AgentCriticalWorkApplyFailed" || event.Type != corev1.EventTypeWarning || event.Message != "failed to apply work0" {
+		t.Errorf("unexpected event %v", event)
+	}
+	truncated := actions[ReportBurst].(clienttesting.CreateActionImpl).Object.(*corev1.Event)
+	if len(truncated.Message) != MaxMessageLength || !strings.HasSuffix(truncated.Message, "...") {
+		t.Errorf("expected the message is truncated, but got length %d", len(truncated.Message))
+	}
+
+	// a nil reporter reports nothing
+	var nilReporter *Reporter
+	if nilReporter.Reportf(context.TODO(), CategoryWorkApplyFailed, "failed") {
+		t.Errorf("expected a nil reporter reports nothing")
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	cases := map[string]string{
+		"AgentCriticalWorkApplyFailed": "WorkApplyFailed",
+		"AgentCritical":                "",
+		"WorkApplyFailed":              "",
+	}
+	for reason, expected := range cases {
+		category, ok := CategoryOf(reason)
+		if category != expected || ok != (len(expected) > 0) {
+			t.Errorf("expected category %q of %q, but got %q", expected, reason, category)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package leadership
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// Leader is 1 on the instance leading the controllers of a group, e.g. registration-controller-clusters, and
+	// turns to 0 once the instance stops leading. The identity is the name of the pod of the instance.
+	Leader = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "hub_controller",
+			Name:           "leader",
+			Help:           "Whether the instance is leading the controllers of the group, 1 for the leader.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "identity"},
+	)
+
+	// LeaderTransitions is the number of the times the instance acquires the leadership of the controllers of a
+	// group. Each replica runs the controllers only after it acquires the lease of the group, so the sum over the
+	// replicas is the number of the leadership changes.
+	LeaderTransitions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "hub_controller",
+			Name:           "leader_transitions_total",
+			Help:           "Number of the times the instance acquires the leadership of the controllers of the group.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group"},
+	)
+
+	registerMetrics sync.Once
+
+	// identity returns the identity of the instance reported in the metrics and held in the leases.
+	identity = os.Hostname
+)
+
+// RegisterMetrics registers the leader election metrics of the hub controllers.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(Leader, LeaderTransitions)
+	})
+}
+
+// Group is the controllers of a hub component led by the same instance.
+type Group struct {
+	// Name is the name of the group, e.g. clusters, it is unique in the component.
+	Name string
+	// Run runs the controllers of the group, and returns once the controllers stop after the context is done.
+	Run func(ctx context.Context) error
+	// Handovers are called after the controllers of the group stop, e.g. to reset the metrics only reported
+	// by the leader, before the lease is released to another instance.
+	Handovers []func()
+}
+
+// GroupsFunc builds the controller groups of a hub component. The informers shared by the groups are started
+// with the context, so the caches are warm on all the instances.
+type GroupsFunc func(ctx context.Context, controllerContext *controllercmd.ControllerContext) ([]Group, error)
+
+// SingleGroup returns the GroupsFunc of a hub component whose controllers are led as a single group, the
+// controllers and their informers are created each time the group is led.
+func SingleGroup(name string, startFunc controllercmd.StartFunc, handovers ...func()) GroupsFunc {
+	return func(_ context.Context, controllerContext *controllercmd.ControllerContext) ([]Group, error) {
+		return []Group{{
+			Name:      name,
+			Run:       func(ctx context.Context) error { return startFunc(ctx, controllerContext) },
+			Handovers: handovers,
+		}}, nil
+	}
+}
+
+// Options is the leader election of the controller groups of a hub component.
+//
+// The controller groups are elected by the component itself rather than the controller command, which exits the
+// process once the lease is lost. Once an instance loses a lease, the controllers of the group stop, the handover
+// funcs are called and the instance campaigns for the lease again, so the controllers never run on two replicas
+// at a time.
+type Options struct {
+	// LeasePerGroup elects each controller group with a lease of its own named <component>-<group>-lock, so the
+	// groups could be led by different replicas to spread the load. Otherwise, all the groups are led by the
+	// instance holding the lease of the component named <component>-lock.
+	LeasePerGroup bool
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// NewOptions returns the Options with the default durations of the leader election of the controller command.
+func NewOptions() *Options {
+	defaulted := leaderelectionconverter.LeaderElectionDefaulting(configv1.LeaderElection{}, "", "")
+	return &Options{
+		LeaseDuration: defaulted.LeaseDuration.Duration,
+		RenewDeadline: defaulted.RenewDeadline.Duration,
+		RetryPeriod:   defaulted.RetryPeriod.Duration,
+	}
+}
+
+// AddFlags adds the flags of the leader election of the controller groups.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.LeasePerGroup, "lease-per-controller-group", o.LeasePerGroup,
+		"Elect each controller group with a lease of its own, so the groups could be led by different replicas.")
+	fs.DurationVar(&o.LeaseDuration, "leader-election-lease-duration", o.LeaseDuration, ""+
+		"The duration that non-leader candidates will wait after observing a leadership "+
+		"renewal until attempting to acquire leadership of a led but unrenewed leader "+
+		"slot. This is effectively the maximum duration that a leader can be stopped "+
+		"before it is replaced by another candidate.")
+	fs.DurationVar(&o.RenewDeadline, "leader-election-renew-deadline", o.RenewDeadline, ""+
+		"The interval between attempts by the acting master to renew a leadership slot "+
+		"before it stops leading. This must be less than or equal to the lease duration.")
+	fs.DurationVar(&o.RetryPeriod, "leader-election-retry-period", o.RetryPeriod, ""+
+		"The duration the clients should wait between attempting acquisition and renewal "+
+		"of a leadership.")
+}
+
+// NewControllerCommandConfig returns the controller command config of a hub component, whose controller groups
+// are elected with the options instead of the leader election of the controller command.
+func (o *Options) NewControllerCommandConfig(component string, version version.Info,
+	groupsFunc GroupsFunc) *controllercmd.ControllerCommandConfig {
+	RegisterMetrics()
+	cmdConfig := controllercmd.NewControllerCommandConfig(component, version,
+		func(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+			return o.Run(ctx, component, controllerContext, groupsFunc)
+		})
+	cmdConfig.DisableLeaderElection = true
+	return cmdConfig
+}
+
+// Run builds the controller groups of the component and leads them until the context is done. The groups are
+// reported in the metrics as <component>-<group>.
+func (o *Options) Run(ctx context.Context, component string, controllerContext *controllercmd.ControllerContext,
+	groupsFunc GroupsFunc) error {
+	kubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+	if err != nil {
+		return err
+	}
+	id, err := identity()
+	if err != nil {
+		return err
+	}
+	groups, err := groupsFunc(ctx, controllerContext)
+	if err != nil {
+		return err
+	}
+
+	e := &elector{
+		options:   o,
+		client:    kubeClient,
+		namespace: controllerContext.OperatorNamespace,
+		identity:  id,
+	}
+	var groupLeases []string
+	for i := range groups {
+		groups[i].Name = fmt.Sprintf("%s-%s", component, groups[i].Name)
+		groupLeases = append(groupLeases, groups[i].Name+"-lock")
+	}
+	componentLease := component + "-lock"
+
+	if !o.LeasePerGroup {
+		// the instances started before the lease split is disabled may still lead the groups with the leases of
+		// their own, wait until the leases are released or expired.
+		if e.waitForReleased(ctx, groupLeases...) != nil {
+			return nil
+		}
+		e.campaign(ctx, componentLease, groups...)
+		return nil
+	}
+
+	// the instances started before the lease split is enabled may still lead the groups with the lease of the
+	// component, wait until the lease is released or expired.
+	if e.waitForReleased(ctx, componentLease) != nil {
+		return nil
+	}
+	var wg sync.WaitGroup
+	for i := range groups {
+		wg.Add(1)
+		go func(lease string, group Group) {
+			defer wg.Done()
+			e.campaign(ctx, lease, group)
+		}(groupLeases[i], groups[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+// elector leads the controller groups of a component with the leases in the namespace.
+type elector struct {
+	options   *Options
+	client    kubernetes.Interface
+	namespace string
+	identity  string
+}
+
+// campaign campaigns for the lease and leads the groups until the context is done. Once the lease is lost, the
+// instance campaigns again after the controllers of the groups stop.
+func (e *elector) campaign(ctx context.Context, lease string, groups ...Group) {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: e.namespace, Name: lease},
+		Client:     e.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: e.identity},
+	}
+
+	for ctx.Err() == nil {
+		stopped := make(chan struct{})
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:          lock,
+			Name:          lease,
+			LeaseDuration: e.options.LeaseDuration,
+			RenewDeadline: e.options.RenewDeadline,
+			RetryPeriod:   e.options.RetryPeriod,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					defer close(stopped)
+					e.lead(leaderCtx, groups...)
+				},
+				// the lease is neither renewed nor released until the controllers stop, so the next leader
+				// acquires it only after the controllers stop or the lease expires.
+				OnStoppedLeading: func() {},
+			},
+		})
+		if err != nil {
+			klog.Errorf("Failed to elect the lease %s/%s: %v", e.namespace, lease, err)
+			return
+		}
+		le.Run(ctx)
+
+		// Run returns right after the lease is lost or the context is done, wait for the controllers to stop if
+		// the lease is acquired.
+		if ctx.Err() == nil || le.IsLeader() {
+			<-stopped
+		}
+		if ctx.Err() != nil && le.IsLeader() {
+			e.release(lock)
+		}
+	}
+}
+
+// lead runs the controllers of the groups until the context is done, the handover funcs of the groups are called
+// once all the controllers stop.
+func (e *elector) lead(ctx context.Context, groups ...Group) {
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		klog.Infof("Instance %q starts leading the controllers of %s", e.identity, group.Name)
+		Leader.WithLabelValues(group.Name, e.identity).Set(1)
+		LeaderTransitions.WithLabelValues(group.Name).Inc()
+
+		wg.Add(1)
+		go func(group Group) {
+			defer wg.Done()
+			if err := group.Run(ctx); err != nil {
+				klog.Errorf("The controllers of %s failed: %v", group.Name, err)
+			}
+		}(group)
+	}
+	wg.Wait()
+
+	for _, group := range groups {
+		for _, handover := range group.Handovers {
+			handover()
+		}
+		Leader.WithLabelValues(group.Name, e.identity).Set(0)
+		klog.Infof("Instance %q stops leading the controllers of %s", e.identity, group.Name)
+	}
+}
+
+// release releases the lease held by the instance once the controllers stop, so another instance acquires it
+// without waiting for the lease to expire.
+func (e *elector) release(lock *resourcelock.LeaseLock) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.options.RenewDeadline)
+	defer cancel()
+	record, _, err := lock.Get(ctx)
+	if err != nil || record.HolderIdentity != e.identity {
+		return
+	}
+	now := metav1.Now()
+	if err := lock.Update(ctx, resourcelock.LeaderElectionRecord{
+		LeaderTransitions:    record.LeaderTransitions,
+		LeaseDurationSeconds: 1,
+		RenewTime:            now,
+		AcquireTime:          now,
+	}); err != nil {
+		klog.Warningf("Failed to release the lease %s/%s: %v", e.namespace, lock.LeaseMeta.Name, err)
+	}
+}
+
+// waitForReleased waits until none of the leases is held by another instance, it returns an error only if the
+// context is done.
+func (e *elector) waitForReleased(ctx context.Context, leases ...string) error {
+	return wait.PollUntilContextCancel(ctx, e.options.RetryPeriod, true, func(ctx context.Context) (bool, error) {
+		for _, name := range leases {
+			lease, err := e.client.CoordinationV1().Leases(e.namespace).Get(ctx, name, metav1.GetOptions{})
+			switch {
+			case errors.IsNotFound(err):
+				continue
+			case err != nil:
+				klog.Warningf("Failed to get the lease %s/%s: %v", e.namespace, name, err)
+				return false, nil
+			}
+			holder := lease.Spec.HolderIdentity
+			if holder == nil || len(*holder) == 0 || *holder == e.identity ||
+				lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+				continue
+			}
+			expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+			if time.Now().Before(expiry) {
+				klog.Infof("Waiting for the lease %s/%s held by %q to be released", e.namespace, name, *holder)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
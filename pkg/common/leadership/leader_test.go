@@ -0,0 +1,237 @@
+package leadership
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/pointer"
+)
+
+const testNamespace = "open-cluster-management-hub"
+
+// newTestElector returns an elector with the durations short enough to lose and acquire the leases in the tests
+// within a second.
+func newTestElector(kubeClient *fakekube.Clientset, id string) *elector {
+	RegisterMetrics()
+	return &elector{
+		options: &Options{
+			LeaseDuration: 300 * time.Millisecond,
+			RenewDeadline: 200 * time.Millisecond,
+			RetryPeriod:   50 * time.Millisecond,
+		},
+		client:    kubeClient,
+		namespace: testNamespace,
+		identity:  id,
+	}
+}
+
+// waitFor waits for the channel to be closed, the timeout is only reached if the test fails.
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for %s", what)
+	}
+}
+
+func assertLeader(t *testing.T, group, id string, expected float64) {
+	actual, err := testutil.GetGaugeMetricValue(Leader.WithLabelValues(group, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != expected {
+		t.Errorf("expected leader %v of %s, but got %v", expected, id, actual)
+	}
+}
+
+// testGroup is a controller group which records the running controllers and the handovers.
+type testGroup struct {
+	running   atomic.Int32
+	overlaps  atomic.Int32
+	started   chan struct{}
+	handedOff chan struct{}
+}
+
+func newTestGroup() *testGroup {
+	return &testGroup{started: make(chan struct{}, 10), handedOff: make(chan struct{}, 10)}
+}
+
+func (g *testGroup) group(name string) Group {
+	return Group{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			if g.running.Add(1) > 1 {
+				g.overlaps.Add(1)
+			}
+			g.started <- struct{}{}
+			<-ctx.Done()
+			// the controllers take a while to stop after the context is done
+			time.Sleep(50 * time.Millisecond)
+			g.running.Add(-1)
+			return nil
+		},
+		Handovers: []func(){func() {
+			if g.running.Load() != 0 {
+				g.overlaps.Add(1)
+			}
+			g.handedOff <- struct{}{}
+		}},
+	}
+}
+
+func TestCampaignOnLeaseLoss(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	var renewFails atomic.Bool
+	kubeClient.PrependReactor("update", "leases", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if renewFails.Load() {
+			return true, nil, fmt.Errorf("the hub apiserver is not available")
+		}
+		return false, nil, nil
+	})
+
+	// the metrics are registered globally, count the transitions of this test only
+	initialTransitions, err := testutil.GetCounterMetricValue(LeaderTransitions.WithLabelValues("lease-loss"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g := newTestGroup()
+	campaigned := make(chan struct{})
+	go func() {
+		defer close(campaigned)
+		newTestElector(kubeClient, "hub-0").campaign(ctx, "lease-loss-lock", g.group("lease-loss"))
+	}()
+
+	waitFor(t, g.started, "the controllers to start")
+	assertLeader(t, "lease-loss", "hub-0", 1)
+
+	// the lease is not renewed within the renew deadline, the controllers stop and are handed over
+	renewFails.Store(true)
+	waitFor(t, g.handedOff, "the handover")
+	assertLeader(t, "lease-loss", "hub-0", 0)
+
+	// the instance campaigns again and leads the controllers once the lease is acquired
+	renewFails.Store(false)
+	waitFor(t, g.started, "the controllers to start again")
+	transitions, err := testutil.GetCounterMetricValue(LeaderTransitions.WithLabelValues("lease-loss"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transitions-initialTransitions != 2 {
+		t.Errorf("expected 2 leader transitions, but got %v", transitions-initialTransitions)
+	}
+
+	cancel()
+	waitFor(t, g.handedOff, "the handover on shutdown")
+	waitFor(t, campaigned, "the campaign to return")
+	if overlaps := g.overlaps.Load(); overlaps != 0 {
+		t.Errorf("expected the controllers never overlap, but got %d overlaps", overlaps)
+	}
+}
+
+func TestCampaignReleasesOnShutdown(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+	g := newTestGroup()
+	campaigned := make(chan struct{})
+	go func() {
+		defer close(campaigned)
+		newTestElector(kubeClient, "hub-0").campaign(ctx, "shutdown-lock", g.group("shutdown"))
+	}()
+	waitFor(t, g.started, "the controllers to start")
+
+	cancel()
+	waitFor(t, campaigned, "the campaign to return")
+	select {
+	case <-g.handedOff:
+	default:
+		t.Errorf("expected the handover before the campaign returns")
+	}
+	assertLeader(t, "shutdown", "hub-0", 0)
+
+	// the lease is released after the controllers stop, so the next leader does not wait for it to expire
+	lease, err := kubeClient.CoordinationV1().Leases(testNamespace).Get(context.TODO(), "shutdown-lock", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder := pointer.StringDeref(lease.Spec.HolderIdentity, ""); holder != "" {
+		t.Errorf("expected the lease released, but it is held by %q", holder)
+	}
+}
+
+func TestWaitForReleased(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		lease    *coordinationv1.Lease
+		released bool
+	}{
+		{
+			name:     "not found",
+			released: true,
+		},
+		{
+			name: "held by another instance",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String("hub-1"),
+				LeaseDurationSeconds: pointer.Int32(137),
+				RenewTime:            &metav1.MicroTime{Time: now},
+			}},
+		},
+		{
+			name: "expired",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String("hub-1"),
+				LeaseDurationSeconds: pointer.Int32(137),
+				RenewTime:            &metav1.MicroTime{Time: now.Add(-time.Hour)},
+			}},
+			released: true,
+		},
+		{
+			name: "released",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String(""),
+				LeaseDurationSeconds: pointer.Int32(1),
+				RenewTime:            &metav1.MicroTime{Time: now},
+			}},
+			released: true,
+		},
+		{
+			name: "held by the instance",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String("hub-0"),
+				LeaseDurationSeconds: pointer.Int32(137),
+				RenewTime:            &metav1.MicroTime{Time: now},
+			}},
+			released: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if c.lease != nil {
+				c.lease.Namespace, c.lease.Name = testNamespace, "component-lock"
+				objects = append(objects, c.lease)
+			}
+			e := newTestElector(fakekube.NewSimpleClientset(objects...), "hub-0")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := e.waitForReleased(ctx, "component-lock")
+			if released := err == nil; released != c.released {
+				t.Errorf("expected released %v, but got %v", c.released, err)
+			}
+		})
+	}
+}
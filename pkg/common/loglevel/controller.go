@@ -0,0 +1,118 @@
+package loglevel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/logs"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// VerbosityKey is the key of the klog verbosity in the log level ConfigMap.
+	VerbosityKey = "verbosity"
+
+	// MaxVerbosity is the max klog verbosity accepted.
+	MaxVerbosity = 10
+)
+
+// ParseVerbosity parses the klog verbosity, it returns an error if the verbosity is not an integer in [0, MaxVerbosity].
+func ParseVerbosity(value string) (int, error) {
+	verbosity, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || verbosity < 0 || verbosity > MaxVerbosity {
+		return 0, fmt.Errorf("invalid verbosity %q, it should be an integer between 0 and %d", value, MaxVerbosity)
+	}
+	return verbosity, nil
+}
+
+// logLevelController sets the klog verbosity of the process from a ConfigMap, so the verbosity can be changed
+// without restarting the process. The verbosity set by the flag is restored once the ConfigMap or the verbosity
+// key is removed, or the verbosity is invalid.
+type logLevelController struct {
+	namespace       string
+	name            string
+	configMapLister corev1listers.ConfigMapLister
+	// defaultVerbosity is the verbosity set by the flag when the process starts.
+	defaultVerbosity string
+	// currentVerbosity is the verbosity set by the controller last time.
+	currentVerbosity string
+	setVerbosity     func(string) (string, error)
+}
+
+// NewLogLevelController creates a controller to set the klog verbosity from the ConfigMap namespace/name. The
+// informer should watch the namespace of the ConfigMap.
+func NewLogLevelController(
+	namespace, name string,
+	configMapInformer corev1informers.ConfigMapInformer,
+	recorder events.Recorder) factory.Controller {
+	defaultVerbosity := "0"
+	if flag := pflag.CommandLine.Lookup("v"); flag != nil {
+		defaultVerbosity = flag.Value.String()
+	}
+
+	c := &logLevelController{
+		namespace:        namespace,
+		name:             name,
+		configMapLister:  configMapInformer.Lister(),
+		defaultVerbosity: defaultVerbosity,
+		currentVerbosity: defaultVerbosity,
+		setVerbosity:     logs.GlogSetter,
+	}
+
+	return factory.New().
+		WithFilteredEventsInformers(func(obj interface{}) bool {
+			return isLogLevelConfigMap(obj, namespace, name)
+		}, configMapInformer.Informer()).
+		WithSync(c.sync).
+		ToController("LogLevelController", recorder)
+}
+
+func (c *logLevelController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	verbosity := c.defaultVerbosity
+	configMap, err := c.configMapLister.ConfigMaps(c.namespace).Get(c.name)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	default:
+		if value, ok := configMap.Data[VerbosityKey]; ok {
+			// do not requeue an invalid verbosity, it is synced again once the ConfigMap is changed
+			if parsed, err := ParseVerbosity(value); err != nil {
+				klog.Errorf("Restore the log verbosity %s, the verbosity in configmap %s/%s is invalid: %v",
+					c.defaultVerbosity, c.namespace, c.name, err)
+				syncCtx.Recorder().Warningf("InvalidVerbosity", "restore the verbosity %s, the verbosity in configmap %s/%s is invalid: %v",
+					c.defaultVerbosity, c.namespace, c.name, err)
+			} else {
+				verbosity = strconv.Itoa(parsed)
+			}
+		}
+	}
+
+	if verbosity == c.currentVerbosity {
+		return nil
+	}
+	if _, err := c.setVerbosity(verbosity); err != nil {
+		return err
+	}
+	klog.Infof("The log verbosity is changed from %s to %s", c.currentVerbosity, verbosity)
+	c.currentVerbosity = verbosity
+	return nil
+}
+
+func isLogLevelConfigMap(obj interface{}, namespace, name string) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	return err == nil && accessor.GetNamespace() == namespace && accessor.GetName() == name
+}
@@ -0,0 +1,114 @@
+package loglevel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestParseVerbosity(t *testing.T) {
+	cases := []struct {
+		value       string
+		expected    int
+		expectedErr bool
+	}{
+		{value: "0", expected: 0},
+		{value: " 4 ", expected: 4},
+		{value: "10", expected: 10},
+		{value: "11", expectedErr: true},
+		{value: "-1", expectedErr: true},
+		{value: "debug", expectedErr: true},
+		{value: "", expectedErr: true},
+	}
+
+	for _, c := range cases {
+		verbosity, err := ParseVerbosity(c.value)
+		if (err != nil) != c.expectedErr {
+			t.Errorf("%q: expected error %v, but got %v", c.value, c.expectedErr, err)
+		}
+		if err == nil && verbosity != c.expected {
+			t.Errorf("%q: expected verbosity %d, but got %d", c.value, c.expected, verbosity)
+		}
+	}
+}
+
+func TestSyncLogLevel(t *testing.T) {
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "agent", Name: "log-level"},
+			Data:       data,
+		}
+	}
+
+	steps := []struct {
+		name              string
+		configMap         *corev1.ConfigMap
+		expectedVerbosity []string
+	}{
+		{
+			name: "configmap does not exist",
+		},
+		{
+			name:              "verbosity is raised",
+			configMap:         newConfigMap(map[string]string{VerbosityKey: "6"}),
+			expectedVerbosity: []string{"6"},
+		},
+		{
+			name:              "default verbosity is restored if the verbosity is invalid",
+			configMap:         newConfigMap(map[string]string{VerbosityKey: "100"}),
+			expectedVerbosity: []string{"2"},
+		},
+		{
+			name:              "verbosity is lowered",
+			configMap:         newConfigMap(map[string]string{VerbosityKey: "4"}),
+			expectedVerbosity: []string{"4"},
+		},
+		{
+			name:      "verbosity is not changed",
+			configMap: newConfigMap(map[string]string{VerbosityKey: "4", "other": "value"}),
+		},
+		{
+			name:              "default verbosity is restored once the key is removed",
+			configMap:         newConfigMap(map[string]string{}),
+			expectedVerbosity: []string{"2"},
+		},
+	}
+
+	informerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute)
+	store := informerFactory.Core().V1().ConfigMaps().Informer().GetStore()
+	var verbosity []string
+	controller := &logLevelController{
+		namespace:        "agent",
+		name:             "log-level",
+		configMapLister:  informerFactory.Core().V1().ConfigMaps().Lister(),
+		defaultVerbosity: "2",
+		currentVerbosity: "2",
+		setVerbosity: func(v string) (string, error) {
+			verbosity = append(verbosity, v)
+			return "", nil
+		},
+	}
+
+	for _, step := range steps {
+		verbosity = nil
+		if step.configMap != nil {
+			if err := store.Update(step.configMap); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, ""))
+		testingcommon.AssertError(t, err, "")
+		if len(verbosity) != len(step.expectedVerbosity) ||
+			(len(verbosity) > 0 && verbosity[0] != step.expectedVerbosity[0]) {
+			t.Errorf("%s: expected verbosity %v is set, but got %v", step.name, step.expectedVerbosity, verbosity)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package namespaces
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matcher matches the namespaces by a list of patterns, each pattern is either a namespace name, e.g. kube-system,
+// or a regular expression matching the whole name, e.g. openshift-.*.
+type Matcher struct {
+	patterns []*regexp.Regexp
+}
+
+// NewMatcher returns a Matcher of the patterns, an error is returned if any of the patterns is not a valid regular
+// expression.
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		if err := ValidatePattern(pattern); err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, regexp.MustCompile(anchor(pattern)))
+	}
+	return m, nil
+}
+
+// ValidatePattern returns an error if the pattern is empty or not a valid regular expression.
+func ValidatePattern(pattern string) error {
+	if len(strings.TrimSpace(pattern)) == 0 {
+		return fmt.Errorf("the namespace pattern is empty")
+	}
+	if _, err := regexp.Compile(anchor(pattern)); err != nil {
+		return fmt.Errorf("invalid namespace pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// Match returns true if the namespace matches any of the patterns. A nil Matcher matches no namespace.
+func (m *Matcher) Match(namespace string) bool {
+	if m == nil {
+		return false
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// anchor makes the pattern match the whole namespace name, so kube-system does not match kube-system-apps.
+func anchor(pattern string) string {
+	return fmt.Sprintf("^(?:%s)$", strings.TrimSpace(pattern))
+}
@@ -0,0 +1,44 @@
+package namespaces
+
+import (
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	matcher, err := NewMatcher([]string{"kube-system", "openshift-.*", " ocm-(hub|agent) "})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"kube-system":        true,
+		"kube-system-apps":   false,
+		"my-kube-system":     false,
+		"openshift-monitor":  true,
+		"openshift":          false,
+		"ocm-hub":            true,
+		"ocm-agent":          true,
+		"ocm-agent-addon":    false,
+		"default":            false,
+		"":                   false,
+		"openshift-.*-infra": true,
+	}
+	for namespace, expected := range cases {
+		if actual := matcher.Match(namespace); actual != expected {
+			t.Errorf("expected %q matched %v, but got %v", namespace, expected, actual)
+		}
+	}
+
+	var nilMatcher *Matcher
+	if nilMatcher.Match("kube-system") {
+		t.Errorf("expected nil matcher matches no namespace")
+	}
+}
+
+func TestNewMatcherInvalid(t *testing.T) {
+	for _, patterns := range [][]string{{"kube-system", "openshift-(.*"}, {""}, {"  "}} {
+		if _, err := NewMatcher(patterns); err == nil {
+			t.Errorf("expected error of the patterns %q", patterns)
+		}
+	}
+}
@@ -16,6 +16,10 @@ type AgentOptions struct {
 	SpokeClusterName    string
 	Burst               int
 	QPS                 float32
+	TLSOptions          TLSOptions
+	// LogLevelConfigMap is the name of the ConfigMap in the agent namespace to change the log verbosity of the
+	// agent at runtime.
+	LogLevelConfigMap string
 }
 
 // NewWorkloadAgentOptions returns the flags with default value set
@@ -35,6 +39,10 @@ func (o *AgentOptions) AddFlags(flags *pflag.FlagSet) {
 		"Name of the spoke cluster.")
 	flags.Float32Var(&o.QPS, "spoke-kube-api-qps", o.QPS, "QPS to use while talking with apiserver on spoke cluster.")
 	flags.IntVar(&o.Burst, "spoke-kube-api-burst", o.Burst, "Burst to use while talking with apiserver on spoke cluster.")
+	flags.StringVar(&o.LogLevelConfigMap, "log-level-configmap", o.LogLevelConfigMap,
+		"Name of the ConfigMap in the agent namespace to change the log verbosity at runtime. The verbosity is read "+
+			"from the key \"verbosity\" of the ConfigMap, and the verbosity set by --v is restored once it is removed.")
+	o.TLSOptions.AddFlags(flags)
 }
 
 // spokeKubeConfig builds kubeconfig for the spoke/managed cluster
@@ -60,5 +68,5 @@ func (o *AgentOptions) Validate() error {
 		return fmt.Errorf("metadata.name format is not correct: %s", strings.Join(errMsgs, ","))
 	}
 
-	return nil
+	return o.TLSOptions.Validate()
 }
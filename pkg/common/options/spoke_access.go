@@ -0,0 +1,72 @@
+package options
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/klog/v2"
+)
+
+// FallbackOperatorNamespace is the namespace library-go falls back to when the agent has neither the --namespace
+// flag nor the service account namespace file, it is never the namespace of the agent.
+const FallbackOperatorNamespace = "openshift-config-managed"
+
+// SpokeAccess is a permission on the managed cluster required by a feature of the agent.
+type SpokeAccess struct {
+	Feature    string
+	Attributes authorizationv1.ResourceAttributes
+}
+
+// CheckSpokeAccess reviews the permissions of the agent on the managed cluster, and returns the messages of the
+// denied ones. It is used when the agent runs outside of the managed cluster with the spoke kubeconfig, which could
+// be restricted, e.g. read-only. The features depending on the denied permissions fail with forbidden errors while
+// the others keep working, so the agent still starts and only reports the denied permissions.
+func CheckSpokeAccess(ctx context.Context, client authorizationv1client.SelfSubjectAccessReviewsGetter,
+	accesses []SpokeAccess) ([]string, error) {
+	var denied []string
+	for _, access := range accesses {
+		attributes := access.Attributes
+		review, err := client.SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to review the access of %q: %w", access.Feature, err)
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, fmt.Sprintf("%s requires %s on %s", access.Feature, attributes.Verb, resourceString(attributes)))
+		}
+	}
+	return denied, nil
+}
+
+func resourceString(attributes authorizationv1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if len(attributes.Group) > 0 && attributes.Group != "*" {
+		resource = fmt.Sprintf("%s.%s", attributes.Resource, attributes.Group)
+	}
+	if len(attributes.Namespace) > 0 {
+		return fmt.Sprintf("%s in namespace %s", resource, attributes.Namespace)
+	}
+	return resource
+}
+
+// ReportSpokeAccess checks the permissions of the agent on the managed cluster, and reports the denied ones with
+// warning events. The failure of the check is only logged since it does not block the agent.
+func ReportSpokeAccess(ctx context.Context, client authorizationv1client.SelfSubjectAccessReviewsGetter,
+	accesses []SpokeAccess, recorder events.Recorder) {
+	denied, err := CheckSpokeAccess(ctx, client, accesses)
+	if err != nil {
+		klog.Warningf("unable to check the access of the spoke kubeconfig: %v", err)
+		return
+	}
+	for _, message := range denied {
+		klog.Warningf("the spoke kubeconfig is not permitted: %s", message)
+		recorder.Warningf("SpokeAccessDenied", "the spoke kubeconfig is not permitted: %s", message)
+	}
+}
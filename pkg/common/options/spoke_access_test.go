@@ -0,0 +1,87 @@
+package options
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCheckSpokeAccess(t *testing.T) {
+	accesses := []SpokeAccess{
+		{
+			Feature:    "cluster capacity",
+			Attributes: authorizationv1.ResourceAttributes{Verb: "list", Resource: "nodes"},
+		},
+		{
+			Feature: "cluster claims",
+			Attributes: authorizationv1.ResourceAttributes{
+				Verb: "list", Group: "cluster.open-cluster-management.io", Resource: "clusterclaims"},
+		},
+		{
+			Feature:    "manifest apply",
+			Attributes: authorizationv1.ResourceAttributes{Verb: "create", Group: "*", Resource: "*"},
+		},
+		{
+			Feature: "ca bundle",
+			Attributes: authorizationv1.ResourceAttributes{
+				Verb: "update", Namespace: "kube-public", Resource: "configmaps"},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		allowedVerbs   []string
+		expectedDenied []string
+	}{
+		{
+			name:         "all allowed",
+			allowedVerbs: []string{"get", "list", "watch", "create", "update", "delete"},
+		},
+		{
+			name:         "read-only",
+			allowedVerbs: []string{"get", "list", "watch"},
+			expectedDenied: []string{
+				"manifest apply requires create on *",
+				"ca bundle requires update on configmaps in namespace kube-public",
+			},
+		},
+		{
+			name: "nothing allowed",
+			expectedDenied: []string{
+				"cluster capacity requires list on nodes",
+				"cluster claims requires list on clusterclaims.cluster.open-cluster-management.io",
+				"manifest apply requires create on *",
+				"ca bundle requires update on configmaps in namespace kube-public",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+					for _, verb := range c.allowedVerbs {
+						if review.Spec.ResourceAttributes.Verb == verb {
+							review.Status.Allowed = true
+						}
+					}
+					return true, review, nil
+				})
+
+			denied, err := CheckSpokeAccess(context.TODO(), kubeClient.AuthorizationV1(), accesses)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(denied, c.expectedDenied) {
+				t.Errorf("expected denied %v, but got %v", c.expectedDenied, denied)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package options
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	cliflag "k8s.io/component-base/cli/flag"
+	"sigs.k8s.io/yaml"
+)
+
+// TLSOptions restricts the TLS version and the cipher suites of the servers and the clients of a component,
+// e.g. to the FIPS approved cipher suites. The default of each server or client is kept if they are not set.
+type TLSOptions struct {
+	MinVersion   string
+	CipherSuites []string
+}
+
+func (o *TLSOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.MinVersion, "tls-min-version", o.MinVersion,
+		"Minimum TLS version supported. Possible values: "+strings.Join(cliflag.TLSPossibleVersions(), ", ")+".")
+	flags.StringSliceVar(&o.CipherSuites, "tls-cipher-suites", o.CipherSuites,
+		"Comma-separated list of cipher suites for the servers and the clients. If omitted, the default Go cipher "+
+			"suites will be used. Preferred values: "+strings.Join(cliflag.PreferredTLSCipherNames(), ", ")+". "+
+			"Insecure values: "+strings.Join(cliflag.InsecureTLSCipherNames(), ", ")+".")
+}
+
+func (o *TLSOptions) Validate() error {
+	_, _, err := o.parse()
+	return err
+}
+
+// ApplyToTLSConfig sets the minimum version and the cipher suites of the tls config if they are specified. The
+// minimum version of the tls config is only raised, it never goes below the minimum the server or client sets.
+func (o *TLSOptions) ApplyToTLSConfig(config *tls.Config) error {
+	minVersion, cipherSuites, err := o.parse()
+	if err != nil {
+		return err
+	}
+	if minVersion > config.MinVersion {
+		config.MinVersion = minVersion
+	}
+	if len(cipherSuites) > 0 {
+		config.CipherSuites = cipherSuites
+	}
+	return nil
+}
+
+// ApplyToRestConfig restricts the TLS of the client built from the rest config. The rest config does not
+// support the TLS version and the cipher suites, so the transport is built from the TLS client config of the rest
+// config, the client certificate is reloaded from the files in each handshake to keep the certificate rotation.
+func (o *TLSOptions) ApplyToRestConfig(config *rest.Config) error {
+	if len(o.MinVersion) == 0 && len(o.CipherSuites) == 0 {
+		return nil
+	}
+
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		// the client does not connect over https
+		return nil
+	}
+	if err := o.ApplyToTLSConfig(tlsConfig); err != nil {
+		return err
+	}
+
+	if certFile, keyFile := config.CertFile, config.KeyFile; len(certFile) > 0 && len(keyFile) > 0 {
+		tlsConfig.Certificates = nil
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = config.Proxy
+	}
+	config.Transport = utilnet.SetTransportDefaults(&http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+	})
+	// the TLS client config must be empty if the transport is set
+	config.TLSClientConfig = rest.TLSClientConfig{}
+	return nil
+}
+
+// ApplyToControllerCommand restricts the TLS of the metrics and health listeners of the controller command. The
+// listeners are configured by the serving info of the --config file, so the options are set on the serving info
+// of a copy of the config file, and the command is run with the copy. The minimum version of the serving info is
+// only raised.
+func (o *TLSOptions) ApplyToControllerCommand(cmd *cobra.Command) {
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if preRunE != nil {
+			if err := preRunE(cmd, args); err != nil {
+				return err
+			}
+		}
+		if len(o.MinVersion) == 0 && len(o.CipherSuites) == 0 {
+			return nil
+		}
+
+		configFlag := cmd.Flags().Lookup("config")
+		if configFlag == nil {
+			return fmt.Errorf("the command %s has no flag --config", cmd.Name())
+		}
+		content, err := o.applyToControllerConfig(configFlag.Value.String())
+		if err != nil {
+			return err
+		}
+		configFile, err := os.CreateTemp("", cmd.Name()+"-config-*.yaml")
+		if err != nil {
+			return err
+		}
+		defer configFile.Close()
+		if _, err := configFile.Write(content); err != nil {
+			return err
+		}
+		return configFlag.Value.Set(configFile.Name())
+	}
+}
+
+// applyToControllerConfig returns the content of the controller config file with the options set on the serving
+// info, an empty config is used if the file is not specified.
+func (o *TLSOptions) applyToControllerConfig(configFile string) ([]byte, error) {
+	minVersion, cipherSuites, err := o.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	config := map[string]interface{}{}
+	if len(configFile) > 0 {
+		content, err := os.ReadFile(filepath.Clean(configFile))
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return nil, fmt.Errorf("unable to parse the config file %s: %w", configFile, err)
+		}
+		if config == nil {
+			config = map[string]interface{}{}
+		}
+	}
+	if _, ok := config["kind"]; !ok {
+		config["apiVersion"] = "operator.openshift.io/v1alpha1"
+		config["kind"] = "GenericOperatorConfig"
+	}
+	servingInfo, _ := config["servingInfo"].(map[string]interface{})
+	if servingInfo == nil {
+		servingInfo = map[string]interface{}{}
+	}
+
+	if minVersion != 0 {
+		current := uint16(0)
+		if name, ok := servingInfo["minTLSVersion"].(string); ok && len(name) > 0 {
+			if current, err = cliflag.TLSVersion(name); err != nil {
+				return nil, fmt.Errorf("invalid servingInfo.minTLSVersion in the config file %s: %w", configFile, err)
+			}
+		}
+		if minVersion > current {
+			servingInfo["minTLSVersion"] = o.MinVersion
+		}
+	}
+	if len(cipherSuites) > 0 {
+		// the serving info only accepts the names of the cipher suites known by library-go
+		for _, name := range o.CipherSuites {
+			if _, err := crypto.CipherSuite(name); err != nil {
+				return nil, fmt.Errorf("invalid tls-cipher-suites for the serving info: %w", err)
+			}
+		}
+		servingInfo["cipherSuites"] = o.CipherSuites
+	}
+	config["servingInfo"] = servingInfo
+	return yaml.Marshal(config)
+}
+
+func (o *TLSOptions) parse() (uint16, []uint16, error) {
+	var minVersion uint16
+	if len(o.MinVersion) > 0 {
+		version, err := cliflag.TLSVersion(o.MinVersion)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid tls-min-version: %w", err)
+		}
+		minVersion = version
+	}
+	cipherSuites, err := cliflag.TLSCipherSuites(o.CipherSuites)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid tls-cipher-suites: %w", err)
+	}
+	return minVersion, cipherSuites, nil
+}
@@ -0,0 +1,252 @@
+package options
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplyToTLSConfig(t *testing.T) {
+	cases := []struct {
+		name                 string
+		args                 []string
+		expectedMinVersion   uint16
+		expectedCipherSuites []uint16
+		expectedErr          bool
+	}{
+		{
+			name:               "default",
+			expectedMinVersion: tls.VersionTLS12,
+		},
+		{
+			name:               "min version is raised",
+			args:               []string{"--tls-min-version=VersionTLS13"},
+			expectedMinVersion: tls.VersionTLS13,
+		},
+		{
+			name:               "min version is not lowered",
+			args:               []string{"--tls-min-version=VersionTLS10"},
+			expectedMinVersion: tls.VersionTLS12,
+		},
+		{
+			name: "fips",
+			args: []string{
+				"--tls-min-version=VersionTLS12",
+				"--tls-cipher-suites=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			},
+			expectedMinVersion: tls.VersionTLS12,
+			expectedCipherSuites: []uint16{
+				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			},
+		},
+		{
+			name:        "unknown version",
+			args:        []string{"--tls-min-version=VersionTLS14"},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown cipher suite",
+			args:        []string{"--tls-cipher-suites=TLS_UNKNOWN"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := &TLSOptions{}
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			o.AddFlags(flags)
+			if err := flags.Parse(c.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := o.Validate(); c.expectedErr != (err != nil) {
+				t.Fatalf("expected validation error %v, but got %v", c.expectedErr, err)
+			}
+
+			// the default of the server is kept if the options are not set
+			config := &tls.Config{MinVersion: tls.VersionTLS12}
+			err := o.ApplyToTLSConfig(config)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.MinVersion != c.expectedMinVersion {
+				t.Errorf("expected min version %x, but got %x", c.expectedMinVersion, config.MinVersion)
+			}
+			if !reflect.DeepEqual(config.CipherSuites, c.expectedCipherSuites) {
+				t.Errorf("expected cipher suites %v, but got %v", c.expectedCipherSuites, config.CipherSuites)
+			}
+		})
+	}
+}
+
+func TestApplyToControllerConfig(t *testing.T) {
+	cases := []struct {
+		name                string
+		args                []string
+		config              string
+		expectedServingInfo map[string]interface{}
+		expectedErr         bool
+	}{
+		{
+			name: "no config file",
+			args: []string{
+				"--tls-min-version=VersionTLS12",
+				"--tls-cipher-suites=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			},
+			expectedServingInfo: map[string]interface{}{
+				"minTLSVersion": "VersionTLS12",
+				"cipherSuites": []interface{}{
+					"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			},
+		},
+		{
+			name:   "serving info of the config file is kept",
+			args:   []string{"--tls-min-version=VersionTLS12"},
+			config: "servingInfo:\n  bindAddress: 0.0.0.0:9443\n",
+			expectedServingInfo: map[string]interface{}{
+				"bindAddress":   "0.0.0.0:9443",
+				"minTLSVersion": "VersionTLS12",
+			},
+		},
+		{
+			name:   "min version of the config file is not lowered",
+			args:   []string{"--tls-min-version=VersionTLS12"},
+			config: "servingInfo:\n  minTLSVersion: VersionTLS13\n",
+			expectedServingInfo: map[string]interface{}{
+				"minTLSVersion": "VersionTLS13",
+			},
+		},
+		{
+			name:        "invalid config file",
+			args:        []string{"--tls-min-version=VersionTLS12"},
+			config:      "servingInfo: [",
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := &TLSOptions{}
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			o.AddFlags(flags)
+			if err := flags.Parse(c.args); err != nil {
+				t.Fatal(err)
+			}
+
+			configFile := ""
+			if len(c.config) > 0 {
+				configFile = path.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(configFile, []byte(c.config), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			content, err := o.applyToControllerConfig(configFile)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, but got %v", c.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			config := map[string]interface{}{}
+			if err := yaml.Unmarshal(content, &config); err != nil {
+				t.Fatal(err)
+			}
+			if config["kind"] != "GenericOperatorConfig" {
+				t.Errorf("expected kind GenericOperatorConfig, but got %v", config["kind"])
+			}
+			if !reflect.DeepEqual(config["servingInfo"], c.expectedServingInfo) {
+				t.Errorf("expected serving info %v, but got %v", c.expectedServingInfo, config["servingInfo"])
+			}
+		})
+	}
+}
+
+func TestApplyToRestConfig(t *testing.T) {
+	dir := t.TempDir()
+	certData, keyData, err := certutil.GenerateSelfSignedCertKey("test", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := path.Join(dir, "tls.crt"), path.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, certData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	newConfig := func() *rest.Config {
+		return &rest.Config{
+			Host: "https://hub.example.com:6443",
+			TLSClientConfig: rest.TLSClientConfig{
+				CAData:   certData,
+				CertFile: certFile,
+				KeyFile:  keyFile,
+			},
+		}
+	}
+
+	// the rest config is not changed if the options are not set
+	config := newConfig()
+	if err := (&TLSOptions{}).ApplyToRestConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config, newConfig()) {
+		t.Errorf("expected the rest config unchanged, but got %v", config)
+	}
+
+	o := &TLSOptions{
+		MinVersion:   "VersionTLS12",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	if err := o.ApplyToRestConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := config.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected the transport is set, but got %T", config.Transport)
+	}
+	if !reflect.DeepEqual(config.TLSClientConfig, rest.TLSClientConfig{}) {
+		t.Errorf("expected the tls client config is cleared, but got %v", config.TLSClientConfig)
+	}
+
+	tlsConfig := transport.TLSClientConfig
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected min version %x, but got %x", tls.VersionTLS12, tlsConfig.MinVersion)
+	}
+	if !reflect.DeepEqual(tlsConfig.CipherSuites, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}) {
+		t.Errorf("unexpected cipher suites %v", tlsConfig.CipherSuites)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Errorf("expected the root CAs are set")
+	}
+	cert, err := tlsConfig.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.Certificate) == 0 {
+		t.Errorf("expected the client certificate is loaded from the files")
+	}
+
+	// the rest config is still usable to build the clients
+	if _, err := rest.HTTPClientFor(config); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
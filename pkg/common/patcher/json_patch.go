@@ -0,0 +1,87 @@
+package patcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// createJSONPatch returns the operations of the JSON patch (RFC 6902) from the old to the new value under the path.
+// The objects are compared by the fields, and the arrays with the same length are compared by the entries, so only
+// the changed entries are touched. The arrays with different lengths and the other values are replaced as a whole.
+func createJSONPatch(path string, oldValue, newValue interface{}) []map[string]interface{} {
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		newTyped, ok := newValue.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		var operations []map[string]interface{}
+		for _, key := range sortedKeys(oldTyped, newTyped) {
+			fieldPath := path + "/" + escapeJSONPointer(key)
+			oldField, inOld := oldTyped[key]
+			newField, inNew := newTyped[key]
+			switch {
+			case !inNew:
+				operations = append(operations, map[string]interface{}{"op": "remove", "path": fieldPath})
+			case !inOld:
+				operations = append(operations, map[string]interface{}{"op": "add", "path": fieldPath, "value": newField})
+			default:
+				operations = append(operations, createJSONPatch(fieldPath, oldField, newField)...)
+			}
+		}
+		return operations
+	case []interface{}:
+		newTyped, ok := newValue.([]interface{})
+		if !ok || len(oldTyped) != len(newTyped) {
+			break
+		}
+
+		var operations []map[string]interface{}
+		for i := range oldTyped {
+			operations = append(operations, createJSONPatch(path+"/"+strconv.Itoa(i), oldTyped[i], newTyped[i])...)
+		}
+		return operations
+	}
+
+	if reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+	return []map[string]interface{}{{"op": "replace", "path": path, "value": newValue}}
+}
+
+// toJSONValue converts the value to the generic json value to compare.
+func toJSONValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal data: %w", err)
+	}
+	var jsonValue interface{}
+	if err := json.Unmarshal(data, &jsonValue); err != nil {
+		return nil, fmt.Errorf("failed to Unmarshal data: %w", err)
+	}
+	return jsonValue, nil
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 )
@@ -26,6 +27,7 @@ type Patcher[R runtime.Object, Sp any, St any] interface {
 	AddFinalizer(context.Context, R, ...string) (bool, error)
 	RemoveFinalizer(context.Context, R, ...string) error
 	PatchStatus(context.Context, R, St, St) (bool, error)
+	PatchStatusWithJSONPatch(context.Context, R, St, St) (bool, error)
 	PatchSpec(context.Context, R, Sp, Sp) (bool, error)
 	PatchLabelAnnotations(context.Context, R, metav1.ObjectMeta, metav1.ObjectMeta) (bool, error)
 }
@@ -169,6 +171,95 @@ func (p *patcher[R, Sp, St]) PatchStatus(ctx context.Context, object R, newStatu
 	return true, p.patch(ctx, object, newObject, oldObject, "status")
 }
 
+// PatchStatusWithJSONPatch patches the status with a JSON patch touching only the changed fields and entries, rather
+// than a merge patch replacing the whole arrays, e.g. the status of all the manifests of a manifestwork are sent on
+// any change of one manifest with the merge patch. The patch is rejected if the resourceVersion of the object is
+// changed, since the indexes of the entries in the arrays may be changed as well. The object passed in is usually
+// from the informer cache, so the patch is retried once on the latest object if its status is still the old one,
+// otherwise a conflict error is returned to sync the status again.
+func (p *patcher[R, Sp, St]) PatchStatusWithJSONPatch(ctx context.Context, object R, newStatus, oldStatus St) (bool, error) {
+	statusChanged := !equality.Semantic.DeepEqual(oldStatus, newStatus)
+	if !statusChanged {
+		return false, nil
+	}
+
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+
+	oldValue, err := toJSONValue(oldStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert old status for %s: %w", accessor.GetName(), err)
+	}
+	newValue, err := toJSONValue(newStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert new status for %s: %w", accessor.GetName(), err)
+	}
+
+	operations := createJSONPatch("/status", oldValue, newValue)
+	if len(operations) == 0 {
+		return false, nil
+	}
+
+	resourceVersion := accessor.GetResourceVersion()
+	err = p.jsonPatchStatus(ctx, accessor.GetName(), resourceVersion, operations)
+	// the test of the resourceVersion fails with an invalid error
+	getter, ok := p.client.(getClient[R])
+	if !errors.IsInvalid(err) || len(resourceVersion) == 0 || !ok {
+		return true, err
+	}
+
+	latest, err := getter.Get(ctx, accessor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return true, err
+	}
+	latestAccessor, err := meta.Accessor(latest)
+	if err != nil {
+		return true, err
+	}
+	latestValue, err := toJSONValue(latest)
+	if err != nil {
+		return true, fmt.Errorf("failed to convert latest object for %s: %w", accessor.GetName(), err)
+	}
+	var latestStatus interface{}
+	if latestObject, ok := latestValue.(map[string]interface{}); ok {
+		latestStatus = latestObject["status"]
+	}
+	if latestAccessor.GetResourceVersion() == resourceVersion || !equality.Semantic.DeepEqual(latestStatus, oldValue) {
+		return true, errors.NewConflict(schema.GroupResource{}, accessor.GetName(),
+			fmt.Errorf("the status is changed since resourceVersion %s", resourceVersion))
+	}
+	return true, p.jsonPatchStatus(ctx, accessor.GetName(), latestAccessor.GetResourceVersion(), operations)
+}
+
+// getClient is implemented by the typed clients besides the PatchClient, to get the latest object.
+type getClient[R runtime.Object] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (R, error)
+}
+
+// jsonPatchStatus sends the JSON patch operations of the status, which are applied only if the resourceVersion of
+// the object is not changed.
+func (p *patcher[R, Sp, St]) jsonPatchStatus(ctx context.Context, name, resourceVersion string,
+	operations []map[string]interface{}) error {
+	if len(resourceVersion) > 0 {
+		operations = append([]map[string]interface{}{
+			{"op": "test", "path": "/metadata/resourceVersion", "value": resourceVersion},
+		}, operations...)
+	}
+
+	patchBytes, err := json.Marshal(operations)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for %s: %w", name, err)
+	}
+
+	_, err = p.client.Patch(ctx, name, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
+	if err != nil {
+		klog.V(2).Infof("Object with name %s is patched with patch %s", name, string(patchBytes))
+	}
+	return err
+}
+
 func (p *patcher[R, Sp, St]) PatchSpec(ctx context.Context, object R, newSpec, oldSpec Sp) (bool, error) {
 	specChanged := !equality.Semantic.DeepEqual(newSpec, oldSpec)
 	if !specChanged {
@@ -3,10 +3,14 @@ package patcher
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	clienttesting "k8s.io/client-go/testing"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
@@ -253,6 +257,143 @@ func TestPatchStatus(t *testing.T) {
 	}
 }
 
+func TestPatchStatusWithJSONPatch(t *testing.T) {
+	cases := []struct {
+		name          string
+		obj           *clusterv1.ManagedCluster
+		newObj        *clusterv1.ManagedCluster
+		expectedPatch string
+	}{
+		{
+			name: "patch the changed entry",
+			obj: newManagedClusterWithConditions(
+				metav1.Condition{Type: "Type1", Message: "message1"}, metav1.Condition{Type: "Type2", Message: "message2"}),
+			newObj: newManagedClusterWithConditions(
+				metav1.Condition{Type: "Type1", Message: "message1"}, metav1.Condition{Type: "Type2", Message: "changed"}),
+			expectedPatch: `[{"op":"test","path":"/metadata/resourceVersion","value":"1"},` +
+				`{"op":"replace","path":"/status/conditions/1/message","value":"changed"}]`,
+		},
+		{
+			name:   "replace the array with a different length",
+			obj:    newManagedClusterWithConditions(metav1.Condition{Type: "Type1"}),
+			newObj: newManagedClusterWithConditions(metav1.Condition{Type: "Type1"}, metav1.Condition{Type: "Type2"}),
+			expectedPatch: `[{"op":"test","path":"/metadata/resourceVersion","value":"1"},` +
+				`{"op":"replace","path":"/status/conditions","value":[` +
+				`{"lastTransitionTime":null,"message":"","reason":"","status":"","type":"Type1"},` +
+				`{"lastTransitionTime":null,"message":"","reason":"","status":"","type":"Type2"}]}]`,
+		},
+		{
+			name:   "replace the null field",
+			obj:    newManagedClusterWithConditions(),
+			newObj: newManagedClusterWithConditions(metav1.Condition{Type: "Type1"}),
+			expectedPatch: `[{"op":"test","path":"/metadata/resourceVersion","value":"1"},` +
+				`{"op":"replace","path":"/status/conditions","value":[` +
+				`{"lastTransitionTime":null,"message":"","reason":"","status":"","type":"Type1"}]}]`,
+		},
+		{
+			name:   "no patch",
+			obj:    newManagedClusterWithConditions(metav1.Condition{Type: "Type1"}),
+			newObj: newManagedClusterWithConditions(metav1.Condition{Type: "Type1"}),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.obj.ResourceVersion = "1"
+			clusterClient := clusterfake.NewSimpleClientset(c.obj)
+			patcher := NewPatcher[
+				*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+				clusterClient.ClusterV1().ManagedClusters())
+			if _, err := patcher.PatchStatusWithJSONPatch(context.TODO(), c.obj, c.newObj.Status, c.obj.Status); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(c.expectedPatch) == 0 {
+				testingcommon.AssertNoActions(t, clusterClient.Actions())
+				return
+			}
+			testingcommon.AssertActions(t, clusterClient.Actions(), "patch")
+			patch := clusterClient.Actions()[0].(clienttesting.PatchAction).GetPatch()
+			if string(patch) != c.expectedPatch {
+				t.Errorf("expected patch %s, but got %s", c.expectedPatch, string(patch))
+			}
+
+			// the patch is applied to the object
+			managedCluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), c.obj.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(managedCluster.Status, c.newObj.Status) {
+				t.Errorf("not patched correctly got %v", managedCluster.Status)
+			}
+		})
+	}
+}
+
+func TestPatchStatusWithJSONPatchStaleObject(t *testing.T) {
+	cases := []struct {
+		name            string
+		latest          *clusterv1.ManagedCluster
+		expectedPatches []string
+		expectConflict  bool
+	}{
+		{
+			name:   "retry on the latest object with the same status",
+			latest: newManagedClusterWithConditions(metav1.Condition{Type: "Type1", Message: "message1"}),
+			expectedPatches: []string{
+				`[{"op":"test","path":"/metadata/resourceVersion","value":"1"},` +
+					`{"op":"replace","path":"/status/conditions/0/message","value":"changed"}]`,
+				`[{"op":"test","path":"/metadata/resourceVersion","value":"2"},` +
+					`{"op":"replace","path":"/status/conditions/0/message","value":"changed"}]`,
+			},
+		},
+		{
+			name:   "conflict if the status is changed",
+			latest: newManagedClusterWithConditions(metav1.Condition{Type: "Type1", Message: "message2"}),
+			expectedPatches: []string{
+				`[{"op":"test","path":"/metadata/resourceVersion","value":"1"},` +
+					`{"op":"replace","path":"/status/conditions/0/message","value":"changed"}]`,
+			},
+			expectConflict: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			obj := newManagedClusterWithConditions(metav1.Condition{Type: "Type1", Message: "message1"})
+			obj.ResourceVersion = "1"
+			newObj := newManagedClusterWithConditions(metav1.Condition{Type: "Type1", Message: "changed"})
+			c.latest.ResourceVersion = "2"
+			clusterClient := clusterfake.NewSimpleClientset(c.latest)
+			// the test of the resourceVersion fails on the stale object
+			clusterClient.PrependReactor("patch", "managedclusters", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if strings.Contains(string(action.(clienttesting.PatchAction).GetPatch()), `"value":"1"`) {
+					return true, nil, errors.NewInvalid(schema.GroupKind{}, obj.Name, nil)
+				}
+				return false, nil, nil
+			})
+			patcher := NewPatcher[
+				*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+				clusterClient.ClusterV1().ManagedClusters())
+
+			_, err := patcher.PatchStatusWithJSONPatch(context.TODO(), obj, newObj.Status, obj.Status)
+			if c.expectConflict != errors.IsConflict(err) || (!c.expectConflict && err != nil) {
+				t.Fatalf("expected conflict %v, but got %v", c.expectConflict, err)
+			}
+
+			var patches []string
+			for _, action := range clusterClient.Actions() {
+				if patch, ok := action.(clienttesting.PatchAction); ok {
+					patches = append(patches, string(patch.GetPatch()))
+				}
+			}
+			if strings.Join(patches, "\n") != strings.Join(c.expectedPatches, "\n") {
+				t.Errorf("expected patches %v, but got %v", c.expectedPatches, patches)
+			}
+		})
+	}
+}
+
 func TestPatchLabelAnnotations(t *testing.T) {
 	cases := []struct {
 		name            string
@@ -0,0 +1,108 @@
+// Package toleration contains the helpers to check whether the taints of the managed clusters are tolerated by
+// the tolerations of the placements, shared by the components evaluating the tolerations.
+package toleration
+
+import (
+	"time"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+// The toleration window of a toleration with TolerationSeconds starts at the TimeAdded of the taint, the helpers
+// below take the current time as a parameter so the callers decide which clock to use. The edge cases are:
+//   - a toleration without TolerationSeconds tolerates the taint forever;
+//   - a negative TolerationSeconds is treated as 0, the taint is not tolerated once it is added;
+//   - a taint with a zero TimeAdded is treated as added at the zero time, so any toleration window of it has
+//     expired. TimeAdded is set by the webhook, a zero one means the time the taint was added is unknown.
+
+// Matches returns true if the toleration matches the taint by its key, value and effect, regardless of
+// the toleration window. An empty effect matches all the effects, and an empty key with the Exists operator
+// matches all the taints.
+func Matches(taint clusterv1.Taint, toleration clusterv1beta1.Toleration) bool {
+	if len(toleration.Effect) > 0 && toleration.Effect != taint.Effect {
+		return false
+	}
+	if len(toleration.Key) > 0 && toleration.Key != taint.Key {
+		return false
+	}
+
+	switch toleration.Operator {
+	// empty operator means Equal
+	case "", clusterv1beta1.TolerationOpEqual:
+		return toleration.Value == taint.Value
+	case clusterv1beta1.TolerationOpExists:
+		return true
+	}
+	return false
+}
+
+// Expiry returns the time when the toleration window of the toleration on the taint ends, it returns
+// nil if the toleration has no TolerationSeconds and never expires.
+func Expiry(taint clusterv1.Taint, toleration clusterv1beta1.Toleration) *time.Time {
+	if toleration.TolerationSeconds == nil {
+		return nil
+	}
+	seconds := *toleration.TolerationSeconds
+	if seconds < 0 {
+		seconds = 0
+	}
+	expiry := taint.TimeAdded.Add(time.Duration(seconds) * time.Second)
+	return &expiry
+}
+
+// IsExpired returns true if the toleration window of the toleration on the taint has ended at now, it
+// returns false if the toleration never expires.
+func IsExpired(taint clusterv1.Taint, toleration clusterv1beta1.Toleration, now time.Time) bool {
+	expiry := Expiry(taint, toleration)
+	return expiry != nil && !now.Before(*expiry)
+}
+
+// Tolerates returns true if the toleration matches the taint and its toleration window has not ended
+// at now.
+func Tolerates(taint clusterv1.Taint, toleration clusterv1beta1.Toleration, now time.Time) bool {
+	return Matches(taint, toleration) && !IsExpired(taint, toleration, now)
+}
+
+// IsTaintTolerated returns true if any of the tolerations tolerates the taint at now.
+func IsTaintTolerated(taint clusterv1.Taint, tolerations []clusterv1beta1.Toleration, now time.Time) bool {
+	for _, toleration := range tolerations {
+		if Tolerates(taint, toleration, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// EarliestExpiry returns the earliest time when one of the taints tolerated at now is no longer tolerated by the
+// tolerations. A taint is tolerated until the latest expiry of the tolerations tolerating it, so it never expires
+// if one of them has no TolerationSeconds. The taints not tolerated at now are ignored. It returns nil if none of
+// the tolerated taints expires.
+func EarliestExpiry(taints []clusterv1.Taint, tolerations []clusterv1beta1.Toleration, now time.Time) *time.Time {
+	var earliest *time.Time
+	for _, taint := range taints {
+		var latest *time.Time
+		tolerated, forever := false, false
+		for _, toleration := range tolerations {
+			if !Tolerates(taint, toleration, now) {
+				continue
+			}
+			tolerated = true
+			expiry := Expiry(taint, toleration)
+			if expiry == nil {
+				forever = true
+				break
+			}
+			if latest == nil || expiry.After(*latest) {
+				latest = expiry
+			}
+		}
+		if !tolerated || forever {
+			continue
+		}
+		if earliest == nil || latest.Before(*earliest) {
+			earliest = latest
+		}
+	}
+	return earliest
+}
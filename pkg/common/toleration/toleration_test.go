@@ -0,0 +1,280 @@
+package toleration
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+func newTaint(key, value string, effect clusterv1.TaintEffect, timeAdded time.Time) clusterv1.Taint {
+	return clusterv1.Taint{Key: key, Value: value, Effect: effect, TimeAdded: metav1.NewTime(timeAdded)}
+}
+
+func newToleration(key string, operator clusterv1beta1.TolerationOperator, value string,
+	effect clusterv1.TaintEffect, seconds *int64) clusterv1beta1.Toleration {
+	return clusterv1beta1.Toleration{
+		Key: key, Operator: operator, Value: value, Effect: effect, TolerationSeconds: seconds,
+	}
+}
+
+func seconds(s int64) *int64 {
+	return &s
+}
+
+func TestTolerates(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	taint := newTaint("key1", "value1", clusterv1.TaintEffectNoSelect, now.Add(-10*time.Second))
+
+	cases := []struct {
+		name              string
+		taint             clusterv1.Taint
+		toleration        clusterv1beta1.Toleration
+		expectedMatched   bool
+		expectedExpired   bool
+		expectedTolerated bool
+	}{
+		{
+			name:              "equal operator",
+			taint:             taint,
+			toleration:        newToleration("key1", clusterv1beta1.TolerationOpEqual, "value1", "", nil),
+			expectedMatched:   true,
+			expectedTolerated: true,
+		},
+		{
+			name:              "empty operator means equal",
+			taint:             taint,
+			toleration:        newToleration("key1", "", "value1", "", nil),
+			expectedMatched:   true,
+			expectedTolerated: true,
+		},
+		{
+			name:       "value not equal",
+			taint:      taint,
+			toleration: newToleration("key1", clusterv1beta1.TolerationOpEqual, "value2", "", nil),
+		},
+		{
+			name:              "exists operator",
+			taint:             taint,
+			toleration:        newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", nil),
+			expectedMatched:   true,
+			expectedTolerated: true,
+		},
+		{
+			name:              "empty key with exists operator matches all",
+			taint:             taint,
+			toleration:        newToleration("", clusterv1beta1.TolerationOpExists, "", "", nil),
+			expectedMatched:   true,
+			expectedTolerated: true,
+		},
+		{
+			name:       "key not equal",
+			taint:      taint,
+			toleration: newToleration("key2", clusterv1beta1.TolerationOpExists, "", "", nil),
+		},
+		{
+			name:       "effect not equal",
+			taint:      taint,
+			toleration: newToleration("key1", clusterv1beta1.TolerationOpExists, "", clusterv1.TaintEffectPreferNoSelect, nil),
+		},
+		{
+			name:       "unknown operator",
+			taint:      taint,
+			toleration: newToleration("key1", "Unknown", "value1", "", nil),
+		},
+		{
+			name:              "toleration window not ended",
+			taint:             taint,
+			toleration:        newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(11)),
+			expectedMatched:   true,
+			expectedTolerated: true,
+		},
+		{
+			name:            "toleration window ends at now",
+			taint:           taint,
+			toleration:      newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(10)),
+			expectedMatched: true,
+			expectedExpired: true,
+		},
+		{
+			name:            "zero toleration seconds",
+			taint:           newTaint("key1", "value1", clusterv1.TaintEffectNoSelect, now),
+			toleration:      newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(0)),
+			expectedMatched: true,
+			expectedExpired: true,
+		},
+		{
+			name:            "negative toleration seconds are treated as zero",
+			taint:           newTaint("key1", "value1", clusterv1.TaintEffectNoSelect, now),
+			toleration:      newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(-10)),
+			expectedMatched: true,
+			expectedExpired: true,
+		},
+		{
+			name:            "zero time added with toleration seconds is expired",
+			taint:           newTaint("key1", "value1", clusterv1.TaintEffectNoSelect, time.Time{}),
+			toleration:      newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(3600)),
+			expectedMatched: true,
+			expectedExpired: true,
+		},
+		{
+			name:              "zero time added without toleration seconds is tolerated",
+			taint:             newTaint("key1", "value1", clusterv1.TaintEffectNoSelect, time.Time{}),
+			toleration:        newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", nil),
+			expectedMatched:   true,
+			expectedTolerated: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if matched := Matches(c.taint, c.toleration); matched != c.expectedMatched {
+				t.Errorf("expected matched %v, but got %v", c.expectedMatched, matched)
+			}
+			if expired := IsExpired(c.taint, c.toleration, now); expired != c.expectedExpired {
+				t.Errorf("expected expired %v, but got %v", c.expectedExpired, expired)
+			}
+			if tolerated := Tolerates(c.taint, c.toleration, now); tolerated != c.expectedTolerated {
+				t.Errorf("expected tolerated %v, but got %v", c.expectedTolerated, tolerated)
+			}
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	timeAdded := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	taint := newTaint("key1", "", clusterv1.TaintEffectNoSelect, timeAdded)
+
+	cases := []struct {
+		name           string
+		taint          clusterv1.Taint
+		seconds        *int64
+		expectedExpiry *time.Time
+	}{
+		{
+			name:  "never expires without toleration seconds",
+			taint: taint,
+		},
+		{
+			name:           "expires after toleration seconds",
+			taint:          taint,
+			seconds:        seconds(10),
+			expectedExpiry: timePtr(timeAdded.Add(10 * time.Second)),
+		},
+		{
+			name:           "negative toleration seconds expire at time added",
+			taint:          taint,
+			seconds:        seconds(-10),
+			expectedExpiry: timePtr(timeAdded),
+		},
+		{
+			name:           "zero time added expires from the zero time",
+			taint:          newTaint("key1", "", clusterv1.TaintEffectNoSelect, time.Time{}),
+			seconds:        seconds(10),
+			expectedExpiry: timePtr(time.Time{}.Add(10 * time.Second)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			toleration := newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", c.seconds)
+			assertTime(t, Expiry(c.taint, toleration), c.expectedExpiry)
+		})
+	}
+}
+
+func TestEarliestExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	taint1 := newTaint("key1", "", clusterv1.TaintEffectNoSelect, now.Add(-10*time.Second))
+	taint2 := newTaint("key2", "", clusterv1.TaintEffectNoSelect, now.Add(-5*time.Second))
+
+	cases := []struct {
+		name           string
+		taints         []clusterv1.Taint
+		tolerations    []clusterv1beta1.Toleration
+		expectedExpiry *time.Time
+	}{
+		{
+			name:   "no tolerations",
+			taints: []clusterv1.Taint{taint1, taint2},
+		},
+		{
+			name:   "tolerated forever",
+			taints: []clusterv1.Taint{taint1},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", nil),
+			},
+		},
+		{
+			name:   "earliest of the taints",
+			taints: []clusterv1.Taint{taint1, taint2},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(30)),
+				newToleration("key2", clusterv1beta1.TolerationOpExists, "", "", seconds(20)),
+			},
+			expectedExpiry: timePtr(now.Add(15 * time.Second)),
+		},
+		{
+			name:   "latest of the tolerations of a taint",
+			taints: []clusterv1.Taint{taint1},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(20)),
+				newToleration("", clusterv1beta1.TolerationOpExists, "", "", seconds(30)),
+			},
+			expectedExpiry: timePtr(now.Add(20 * time.Second)),
+		},
+		{
+			name:   "a toleration without seconds tolerates the taint forever",
+			taints: []clusterv1.Taint{taint1},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(20)),
+				newToleration("", clusterv1beta1.TolerationOpExists, "", "", nil),
+			},
+		},
+		{
+			name:   "expired taints are ignored",
+			taints: []clusterv1.Taint{taint1, taint2},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(5)),
+				newToleration("key2", clusterv1beta1.TolerationOpExists, "", "", seconds(20)),
+			},
+			expectedExpiry: timePtr(now.Add(15 * time.Second)),
+		},
+		{
+			name:   "negative toleration seconds are expired",
+			taints: []clusterv1.Taint{taint1},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(-1)),
+			},
+		},
+		{
+			name:   "zero time added is expired",
+			taints: []clusterv1.Taint{newTaint("key1", "", clusterv1.TaintEffectNoSelect, time.Time{})},
+			tolerations: []clusterv1beta1.Toleration{
+				newToleration("key1", clusterv1beta1.TolerationOpExists, "", "", seconds(3600)),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assertTime(t, EarliestExpiry(c.taints, c.tolerations, now), c.expectedExpiry)
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func assertTime(t *testing.T, actual, expected *time.Time) {
+	if actual == nil && expected == nil {
+		return
+	}
+	if actual == nil || expected == nil || !actual.Equal(*expected) {
+		t.Errorf("expected time %v, but got %v", expected, actual)
+	}
+}
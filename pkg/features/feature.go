@@ -22,11 +22,61 @@ var (
 
 	// DefaultHubRegistrationMutableFeatureGate made up of multiple mutable feature-gates for registration hub controller.
 	DefaultHubRegistrationMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+
+	// DefaultHubPlacementMutableFeatureGate made up of multiple mutable feature-gates for placement controller.
+	DefaultHubPlacementMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+)
+
+// TODO move the placement feature gates to the api repo
+const (
+	// PlacementSatisfiedConditionCounts reports the number of the selected clusters and the number of the
+	// clusters filtered out by each filter in the PlacementConditionSatisfied condition of the placements without
+	// numberOfClusters, and sets the condition to False with reason NoClusterMatched if no cluster is selected.
+	PlacementSatisfiedConditionCounts featuregate.Feature = "PlacementSatisfiedConditionCounts"
+
+	// PlacementPrioritizerScores records the total and the per-prioritizer scores of the top selected clusters in
+	// an annotation of the placements, so the users are able to verify the weights of the prioritizers.
+	PlacementPrioritizerScores featuregate.Feature = "PlacementPrioritizerScores"
+)
+
+// TODO move the registration feature gates to the api repo
+const (
+	// ClusterImportManifests generates the import manifests of the managed clusters with the
+	// cluster.open-cluster-management.io/generate-import-manifests annotation on the hub.
+	ClusterImportManifests featuregate.Feature = "ClusterImportManifests"
 )
 
+// DefaultHubRegistrationFeatureGates consists of the registration hub feature keys which are not in the api
+// repo yet. To add a new feature, define a key for it above and add it here.
+var DefaultHubRegistrationFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	ClusterImportManifests: {Default: false, PreRelease: featuregate.Alpha},
+}
+
+// DefaultHubPlacementFeatureGates consists of all known placement feature keys.
+// To add a new feature, define a key for it above and add it here.
+var DefaultHubPlacementFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	PlacementSatisfiedConditionCounts: {Default: false, PreRelease: featuregate.Alpha},
+	PlacementPrioritizerScores:        {Default: false, PreRelease: featuregate.Alpha},
+}
+
 func init() {
 	runtime.Must(DefaultHubWorkMutableFeatureGate.Add(ocmfeature.DefaultHubWorkFeatureGates))
 	runtime.Must(DefaultSpokeWorkMutableFeatureGate.Add(ocmfeature.DefaultSpokeWorkFeatureGates))
 	runtime.Must(DefaultSpokeRegistrationMutableFeatureGate.Add(ocmfeature.DefaultSpokeRegistrationFeatureGates))
 	runtime.Must(DefaultHubRegistrationMutableFeatureGate.Add(ocmfeature.DefaultHubRegistrationFeatureGates))
+	runtime.Must(DefaultHubRegistrationMutableFeatureGate.Add(DefaultHubRegistrationFeatureGates))
+	runtime.Must(DefaultHubPlacementMutableFeatureGate.Add(DefaultHubPlacementFeatureGates))
+}
+
+// KnownHubRegistrationFeatureGates returns the registration hub feature gates in the api repo together with the
+// ones in DefaultHubRegistrationFeatureGates.
+func KnownHubRegistrationFeatureGates() map[featuregate.Feature]featuregate.FeatureSpec {
+	known := map[featuregate.Feature]featuregate.FeatureSpec{}
+	for feature, spec := range ocmfeature.DefaultHubRegistrationFeatureGates {
+		known[feature] = spec
+	}
+	for feature, spec := range DefaultHubRegistrationFeatureGates {
+		known[feature] = spec
+	}
+	return known
 }
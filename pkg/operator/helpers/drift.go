@@ -0,0 +1,303 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// UserManagedFieldsAnnotation is the annotation on a klusterlet or clustermanager to set the fields of the
+	// deployments, separated by commas, which are managed by the users and excluded from the reconciliation, e.g.
+	// "replicas". Only the fields in AllowedUserManagedFields are allowed.
+	// TODO move this to the api repo as a field of the deploy option
+	UserManagedFieldsAnnotation = "operator.open-cluster-management.io/user-managed-fields"
+
+	// UserManagedFieldReplicas is the replicas of the deployment.
+	UserManagedFieldReplicas = "replicas"
+	// UserManagedFieldResources is the resource requirements of the containers of the deployment.
+	UserManagedFieldResources = "resources"
+
+	UserManagedFieldsTypeValid             = "ValidUserManagedFields"
+	UserManagedFieldsReasonAllValid        = "UserManagedFieldsAllValid"
+	UserManagedFieldsReasonInvalidExisting = "InvalidUserManagedFieldsExisting"
+
+	// DriftRevertedType is the condition type of a klusterlet or clustermanager recording the last drift of the
+	// deployments modified by the users and reverted by the operator.
+	DriftRevertedType              = "DriftReverted"
+	DriftRevertedReasonDeployments = "DeploymentsDriftReverted"
+	DriftRevertedReasonNoDrift     = "NoDriftReverted"
+
+	// DriftRevertedConditionDuration is how long the DriftReverted condition stays true after the last drift is
+	// reverted.
+	DriftRevertedConditionDuration = 10 * time.Minute
+)
+
+// defaultedPodSpecFields and defaultedContainerFields are the fields of the pod spec and the containers which are
+// defaulted by the api server, so they are not drift if they are set on the existing deployment only.
+var (
+	defaultedPodSpecFields = sets.New[string]("dnsPolicy", "restartPolicy", "schedulerName", "securityContext",
+		"terminationGracePeriodSeconds", "enableServiceLinks", "priority", "preemptionPolicy", "serviceAccount")
+	defaultedContainerFields = sets.New[string]("terminationMessagePath", "terminationMessagePolicy",
+		"imagePullPolicy", "resources")
+)
+
+// AllowedUserManagedFields are the fields of the deployments which are safe to be managed by the users, since
+// they do not change the behavior of the components.
+var AllowedUserManagedFields = sets.New[string](UserManagedFieldReplicas, UserManagedFieldResources)
+
+// DeploymentDrift is a deployment modified by the users, with the fields reverted by the operator.
+type DeploymentDrift struct {
+	Namespace string
+	Name      string
+	Fields    []string
+}
+
+func (d DeploymentDrift) String() string {
+	return fmt.Sprintf("%s/%s (%s)", d.Namespace, d.Name, strings.Join(d.Fields, ", "))
+}
+
+// ConvertToUserManagedFields returns the valid user managed fields set by the annotation, and a message if some
+// of the fields are not in AllowedUserManagedFields.
+func ConvertToUserManagedFields(annotations map[string]string) (sets.Set[string], string) {
+	fields := sets.New[string]()
+	value, ok := annotations[UserManagedFieldsAnnotation]
+	if !ok {
+		return fields, ""
+	}
+
+	var invalid []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if len(field) == 0 {
+			continue
+		}
+		if !AllowedUserManagedFields.Has(field) {
+			invalid = append(invalid, fmt.Sprintf("%q", field))
+			continue
+		}
+		fields.Insert(field)
+	}
+	if len(invalid) == 0 {
+		return fields, ""
+	}
+	return fields, strings.Join(invalid, ", ")
+}
+
+func BuildUserManagedFieldsCondition(invalidMsg string) metav1.Condition {
+	if len(invalidMsg) == 0 {
+		return metav1.Condition{
+			Type:    UserManagedFieldsTypeValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  UserManagedFieldsReasonAllValid,
+			Message: "User managed fields are all valid",
+		}
+	}
+
+	return metav1.Condition{
+		Type:   UserManagedFieldsTypeValid,
+		Status: metav1.ConditionFalse,
+		Reason: UserManagedFieldsReasonInvalidExisting,
+		Message: fmt.Sprintf("The user managed fields %s are not allowed and are still reconciled, the allowed fields are %s",
+			invalidMsg, strings.Join(sets.List(AllowedUserManagedFields), ", ")),
+	}
+}
+
+// BuildDriftRevertedCondition returns the condition recording the deployments whose drift is reverted.
+func BuildDriftRevertedCondition(drifts []DeploymentDrift) metav1.Condition {
+	msgs := make([]string, 0, len(drifts))
+	for _, drift := range drifts {
+		msgs = append(msgs, drift.String())
+	}
+	return metav1.Condition{
+		Type:   DriftRevertedType,
+		Status: metav1.ConditionTrue,
+		Reason: DriftRevertedReasonDeployments,
+		Message: fmt.Sprintf("The fields of the deployments modified by the users are reverted: %s",
+			strings.Join(msgs, "; ")),
+	}
+}
+
+// SetDriftRevertedCondition sets the DriftReverted condition to true with the drifts reverted, so the condition
+// starts over from now. Without drift, the condition is set to false once it has been true for
+// DriftRevertedConditionDuration.
+func SetDriftRevertedCondition(conditions *[]metav1.Condition, drifts []DeploymentDrift) {
+	if len(drifts) > 0 {
+		meta.RemoveStatusCondition(conditions, DriftRevertedType)
+		meta.SetStatusCondition(conditions, BuildDriftRevertedCondition(drifts))
+		return
+	}
+
+	if DriftRevertedExpiresIn(*conditions) > 0 || !meta.IsStatusConditionTrue(*conditions, DriftRevertedType) {
+		return
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    DriftRevertedType,
+		Status:  metav1.ConditionFalse,
+		Reason:  DriftRevertedReasonNoDrift,
+		Message: fmt.Sprintf("No drift of the deployments is reverted in the last %s", DriftRevertedConditionDuration),
+	})
+}
+
+// DriftRevertedExpiresIn returns the duration after which the true DriftReverted condition is expired, or 0 if
+// the condition is not true or already expired.
+func DriftRevertedExpiresIn(conditions []metav1.Condition) time.Duration {
+	cond := meta.FindStatusCondition(conditions, DriftRevertedType)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return 0
+	}
+	if left := time.Until(cond.LastTransitionTime.Add(DriftRevertedConditionDuration)); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// keepUserManagedFields copies the user managed fields of the existing deployment to the required one, so they
+// are not reconciled.
+func keepUserManagedFields(required, existing *appsv1.Deployment, fields sets.Set[string]) {
+	if fields.Has(UserManagedFieldReplicas) {
+		required.Spec.Replicas = existing.Spec.Replicas
+	}
+	if fields.Has(UserManagedFieldResources) {
+		existingContainers := containersByName(existing.Spec.Template.Spec.Containers)
+		for i := range required.Spec.Template.Spec.Containers {
+			if container, ok := existingContainers[required.Spec.Template.Spec.Containers[i].Name]; ok {
+				required.Spec.Template.Spec.Containers[i].Resources = container.Resources
+			}
+		}
+	}
+}
+
+// driftedFields returns the sorted fields of the existing deployment which are different from the required one.
+// The fields set by the operator are compared with the existing ones while ignoring the nested fields defaulted
+// by the api server, e.g. the apiVersion of a fieldRef. The fields of the pod spec and the containers set only on
+// the existing deployment, e.g. the volumes added by the users, are drift as well unless they are defaulted.
+func driftedFields(required, existing *appsv1.Deployment) []string {
+	fields := sets.New[string]()
+	requiredSpec, existingSpec := toUnstructured(required.Spec), toUnstructured(existing.Spec)
+	for key, value := range requiredSpec {
+		if key != "template" && !containedIn(value, existingSpec[key]) {
+			fields.Insert(key)
+		}
+	}
+
+	requiredTemplate, existingTemplate := required.Spec.Template, existing.Spec.Template
+	if !equality.Semantic.DeepEqual(nilIfEmpty(requiredTemplate.Labels), nilIfEmpty(existingTemplate.Labels)) {
+		fields.Insert("template.labels")
+	}
+	if !equality.Semantic.DeepEqual(nilIfEmpty(requiredTemplate.Annotations), nilIfEmpty(existingTemplate.Annotations)) {
+		fields.Insert("template.annotations")
+	}
+
+	requiredPodSpec, existingPodSpec := toUnstructured(requiredTemplate.Spec), toUnstructured(existingTemplate.Spec)
+	delete(requiredPodSpec, "containers")
+	delete(existingPodSpec, "containers")
+	fields.Insert(objectDrift("", requiredPodSpec, existingPodSpec, defaultedPodSpecFields)...)
+
+	existingContainers := containersByName(existingTemplate.Spec.Containers)
+	for _, container := range requiredTemplate.Spec.Containers {
+		existingContainer, ok := existingContainers[container.Name]
+		if !ok {
+			fields.Insert(fmt.Sprintf("containers[%s]", container.Name))
+			continue
+		}
+		fields.Insert(objectDrift(fmt.Sprintf("containers[%s].", container.Name),
+			toUnstructured(container), toUnstructured(existingContainer), defaultedContainerFields)...)
+	}
+	if len(existingTemplate.Spec.Containers) > len(requiredTemplate.Spec.Containers) {
+		fields.Insert("containers")
+	}
+
+	return sets.List(fields)
+}
+
+// objectDrift returns the fields, with the prefix, of the required object which are not contained in the existing
+// one, and the fields set only on the existing object except the ones with the default values.
+func objectDrift(prefix string, required, existing map[string]interface{}, defaulted sets.Set[string]) []string {
+	var fields []string
+	for key, value := range required {
+		if !containedIn(value, existing[key]) {
+			fields = append(fields, prefix+key)
+		}
+	}
+	for key, value := range existing {
+		if _, ok := required[key]; ok {
+			continue
+		}
+		// the defaulted structs, e.g. the securityContext, are empty unless they are set by the users.
+		if value, isMap := value.(map[string]interface{}); defaulted.Has(key) && (!isMap || len(value) == 0) {
+			continue
+		}
+		fields = append(fields, prefix+key)
+	}
+	return fields
+}
+
+// containedIn returns true if the required value is equal to the existing one, except the keys of the maps set
+// only on the existing value, which are defaulted by the api server.
+func containedIn(required, existing interface{}) bool {
+	switch requiredValue := required.(type) {
+	case map[string]interface{}:
+		existingValue, ok := existing.(map[string]interface{})
+		if !ok {
+			return len(requiredValue) == 0 && existing == nil
+		}
+		for key, value := range requiredValue {
+			if !containedIn(value, existingValue[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		existingValue, ok := existing.([]interface{})
+		if !ok {
+			return len(requiredValue) == 0 && existing == nil
+		}
+		if len(requiredValue) != len(existingValue) {
+			return false
+		}
+		for i := range requiredValue {
+			if !containedIn(requiredValue[i], existingValue[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return equality.Semantic.DeepEqual(required, existing)
+}
+
+// toUnstructured returns the fields of the object as they are serialized, so the empty fields are omitted.
+func toUnstructured(obj interface{}) map[string]interface{} {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return map[string]interface{}{}
+	}
+	return result
+}
+
+func nilIfEmpty(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func containersByName(containers []corev1.Container) map[string]corev1.Container {
+	result := make(map[string]corev1.Container, len(containers))
+	for _, container := range containers {
+		result[container.Name] = container
+	}
+	return result
+}
@@ -0,0 +1,307 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+)
+
+func TestConvertToUserManagedFields(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedFields []string
+		expectedMsg    string
+	}{
+		{
+			name:           "no annotation",
+			expectedFields: []string{},
+		},
+		{
+			name:           "valid fields",
+			annotations:    map[string]string{UserManagedFieldsAnnotation: "replicas, resources,"},
+			expectedFields: []string{"replicas", "resources"},
+		},
+		{
+			name:           "fields not allowed",
+			annotations:    map[string]string{UserManagedFieldsAnnotation: "replicas,env,image"},
+			expectedFields: []string{"replicas"},
+			expectedMsg:    `"env", "image"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fields, msg := ConvertToUserManagedFields(c.annotations)
+			if !reflect.DeepEqual(sets.List(fields), c.expectedFields) {
+				t.Errorf("expected fields %v, but got %v", c.expectedFields, sets.List(fields))
+			}
+			if msg != c.expectedMsg {
+				t.Errorf("expected message %q, but got %q", c.expectedMsg, msg)
+			}
+
+			cond := BuildUserManagedFieldsCondition(msg)
+			if expected := len(c.expectedMsg) == 0; (cond.Status == metav1.ConditionTrue) != expected {
+				t.Errorf("expected the condition valid %v, but got %v", expected, cond)
+			}
+		})
+	}
+}
+
+func newDriftTestDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "registration", Namespace: ClusterManagerDefaultNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(3),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "registration",
+							Image: "quay.io/open-cluster-management/registration:latest",
+							Env:   []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyDeploymentDrift(t *testing.T) {
+	cases := []struct {
+		name              string
+		userManagedFields sets.Set[string]
+		modify            func(deployment *appsv1.Deployment)
+		expectedDrift     []string
+		expectedReplicas  int32
+	}{
+		{
+			name:             "not modified",
+			modify:           func(deployment *appsv1.Deployment) {},
+			expectedReplicas: 3,
+		},
+		{
+			name: "revert the modified fields",
+			modify: func(deployment *appsv1.Deployment) {
+				deployment.Generation++
+				deployment.Spec.Replicas = pointer.Int32(5)
+				deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "DEBUG", Value: "true"})
+			},
+			expectedDrift:    []string{"containers[registration].env", "replicas"},
+			expectedReplicas: 3,
+		},
+		{
+			name:              "keep the user managed fields",
+			userManagedFields: sets.New[string](UserManagedFieldReplicas),
+			modify: func(deployment *appsv1.Deployment) {
+				deployment.Generation++
+				deployment.Spec.Replicas = pointer.Int32(5)
+				deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "DEBUG", Value: "true"})
+			},
+			expectedDrift:    []string{"containers[registration].env"},
+			expectedReplicas: 5,
+		},
+		{
+			name:              "only the user managed fields are modified",
+			userManagedFields: sets.New[string](UserManagedFieldReplicas, UserManagedFieldResources),
+			modify: func(deployment *appsv1.Deployment) {
+				deployment.Generation++
+				deployment.Spec.Replicas = pointer.Int32(1)
+			},
+			expectedReplicas: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := fakekube.NewSimpleClientset()
+			manifests := func(name string) ([]byte, error) {
+				return json.Marshal(newDriftTestDeployment())
+			}
+
+			_, generationStatus, drift, err := ApplyDeployment(context.TODO(), kubeClient, nil,
+				operatorapiv1.NodePlacement{}, c.userManagedFields, manifests, events.NewInMemoryRecorder(""), "registration")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if drift != nil {
+				t.Errorf("expected no drift on create, but got %v", drift)
+			}
+
+			// the deployment is modified by the user
+			existing, err := kubeClient.AppsV1().Deployments(ClusterManagerDefaultNamespace).Get(
+				context.TODO(), "registration", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.modify(existing)
+			if _, err := kubeClient.AppsV1().Deployments(ClusterManagerDefaultNamespace).Update(
+				context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := events.NewInMemoryRecorder("")
+			_, _, drift, err = ApplyDeployment(context.TODO(), kubeClient,
+				[]operatorapiv1.GenerationStatus{generationStatus}, operatorapiv1.NodePlacement{}, c.userManagedFields,
+				manifests, recorder, "registration")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var driftFields []string
+			if drift != nil {
+				driftFields = drift.Fields
+			}
+			if !reflect.DeepEqual(driftFields, c.expectedDrift) {
+				t.Errorf("expected drift %v, but got %v", c.expectedDrift, driftFields)
+			}
+			driftEvents := 0
+			for _, event := range recorder.Events() {
+				if event.Reason == "DeploymentDriftReverted" {
+					driftEvents++
+				}
+			}
+			if expected := len(c.expectedDrift) > 0; (driftEvents == 1) != expected {
+				t.Errorf("expected drift event %v, but got %d events", expected, driftEvents)
+			}
+
+			actual, err := kubeClient.AppsV1().Deployments(ClusterManagerDefaultNamespace).Get(
+				context.TODO(), "registration", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *actual.Spec.Replicas != c.expectedReplicas {
+				t.Errorf("expected replicas %d, but got %d", c.expectedReplicas, *actual.Spec.Replicas)
+			}
+			if env := actual.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, newDriftTestDeployment().Spec.Template.Spec.Containers[0].Env) {
+				t.Errorf("expected the env reverted, but got %v", env)
+			}
+		})
+	}
+}
+
+func TestDriftedFields(t *testing.T) {
+	cases := []struct {
+		name          string
+		required      func(deployment *appsv1.Deployment)
+		existing      func(deployment *appsv1.Deployment)
+		expectedDrift []string
+	}{
+		{
+			name: "fields defaulted by the api server",
+			required: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}})
+			},
+			existing: func(deployment *appsv1.Deployment) {
+				deployment.Spec.RevisionHistoryLimit = pointer.Int32(10)
+				deployment.Spec.Template.Spec.DNSPolicy = corev1.DNSClusterFirst
+				deployment.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+				deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullAlways
+				deployment.Spec.Template.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+				deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"}}})
+			},
+		},
+		{
+			name:     "fields added by the users",
+			required: func(deployment *appsv1.Deployment) {},
+			existing: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "now"}
+				deployment.Spec.Template.Spec.PriorityClassName = "high"
+				deployment.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true)}
+				deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "data"}}
+				deployment.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}
+			},
+			expectedDrift: []string{"containers[registration].volumeMounts", "priorityClassName", "securityContext",
+				"template.annotations", "volumes"},
+		},
+		{
+			name: "fields changed by the users",
+			required: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "data"}}
+			},
+			existing: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "cache"}}
+				deployment.Spec.Template.Spec.Containers[0].Image = "quay.io/open-cluster-management/registration:dev"
+			},
+			expectedDrift: []string{"containers[registration].image", "volumes"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			required, existing := newDriftTestDeployment(), newDriftTestDeployment()
+			c.required(required)
+			c.existing(existing)
+			if drift := driftedFields(required, existing); (len(drift) > 0 || len(c.expectedDrift) > 0) &&
+				!reflect.DeepEqual(drift, c.expectedDrift) {
+				t.Errorf("expected drift %v, but got %v", c.expectedDrift, drift)
+			}
+		})
+	}
+}
+
+func TestSetDriftRevertedCondition(t *testing.T) {
+	drifts := []DeploymentDrift{{Namespace: "ns", Name: "registration", Fields: []string{"replicas"}}}
+
+	var conditions []metav1.Condition
+	SetDriftRevertedCondition(&conditions, nil)
+	if len(conditions) != 0 {
+		t.Errorf("expected no condition without drift, but got %v", conditions)
+	}
+
+	SetDriftRevertedCondition(&conditions, drifts)
+	if !meta.IsStatusConditionTrue(conditions, DriftRevertedType) || DriftRevertedExpiresIn(conditions) <= 0 {
+		t.Errorf("expected the condition true, but got %v", conditions)
+	}
+
+	// the condition is kept true within the duration
+	SetDriftRevertedCondition(&conditions, nil)
+	if !meta.IsStatusConditionTrue(conditions, DriftRevertedType) {
+		t.Errorf("expected the condition true, but got %v", conditions)
+	}
+
+	conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-DriftRevertedConditionDuration))
+	SetDriftRevertedCondition(&conditions, nil)
+	if !meta.IsStatusConditionFalse(conditions, DriftRevertedType) || DriftRevertedExpiresIn(conditions) != 0 {
+		t.Errorf("expected the condition false once expired, but got %v", conditions)
+	}
+
+	// a new drift starts the condition over
+	SetDriftRevertedCondition(&conditions, drifts)
+	if !meta.IsStatusConditionTrue(conditions, DriftRevertedType) || DriftRevertedExpiresIn(conditions) <= 0 {
+		t.Errorf("expected the condition true, but got %v", conditions)
+	}
+}
+
+func TestBuildDriftRevertedCondition(t *testing.T) {
+	cond := BuildDriftRevertedCondition([]DeploymentDrift{
+		{Namespace: "ns", Name: "registration", Fields: []string{"containers[registration].env", "replicas"}},
+		{Namespace: "ns", Name: "work", Fields: []string{"containers[work].image"}},
+	})
+	expected := "The fields of the deployments modified by the users are reverted: " +
+		"ns/registration (containers[registration].env, replicas); ns/work (containers[work].image)"
+	if cond.Type != DriftRevertedType || cond.Status != metav1.ConditionTrue || cond.Message != expected {
+		t.Errorf("unexpected condition %v", cond)
+	}
+}
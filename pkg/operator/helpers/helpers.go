@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/kubernetes"
 	admissionclient "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
@@ -191,20 +192,24 @@ func ApplyMutatingWebhookConfiguration(
 	return actual, true, err
 }
 
+// ApplyDeployment applies the deployment rendered from the file. The user managed fields are kept as they are on
+// the existing deployment. If the existing deployment is modified by the users since it was applied, the drifted
+// fields are reported in an event and returned before they are reverted.
 func ApplyDeployment(
 	ctx context.Context,
 	client kubernetes.Interface,
 	generationStatuses []operatorapiv1.GenerationStatus,
 	nodePlacement operatorapiv1.NodePlacement,
+	userManagedFields sets.Set[string],
 	manifests resourceapply.AssetFunc,
-	recorder events.Recorder, file string) (*appsv1.Deployment, operatorapiv1.GenerationStatus, error) {
+	recorder events.Recorder, file string) (*appsv1.Deployment, operatorapiv1.GenerationStatus, *DeploymentDrift, error) {
 	deploymentBytes, err := manifests(file)
 	if err != nil {
-		return nil, operatorapiv1.GenerationStatus{}, err
+		return nil, operatorapiv1.GenerationStatus{}, nil, err
 	}
 	deployment, _, err := genericCodec.Decode(deploymentBytes, nil, nil)
 	if err != nil {
-		return nil, operatorapiv1.GenerationStatus{}, fmt.Errorf("%q: %v", file, err)
+		return nil, operatorapiv1.GenerationStatus{}, nil, fmt.Errorf("%q: %v", file, err)
 	}
 	generationStatus := NewGenerationStatus(appsv1.SchemeGroupVersion.WithResource("deployments"), deployment)
 	currentGenerationStatus := FindGenerationStatus(generationStatuses, generationStatus)
@@ -213,23 +218,45 @@ func ApplyDeployment(
 		generationStatus.LastGeneration = currentGenerationStatus.LastGeneration
 	}
 
-	deployment.(*appsv1.Deployment).Spec.Template.Spec.NodeSelector = nodePlacement.NodeSelector
-	deployment.(*appsv1.Deployment).Spec.Template.Spec.Tolerations = nodePlacement.Tolerations
+	required := deployment.(*appsv1.Deployment)
+	required.Spec.Template.Spec.NodeSelector = nodePlacement.NodeSelector
+	required.Spec.Template.Spec.Tolerations = nodePlacement.Tolerations
+
+	existing, err := client.AppsV1().Deployments(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return nil, generationStatus, nil, fmt.Errorf("%q (%T): %v", file, deployment, err)
+	}
+
+	var drift *DeploymentDrift
+	if existing != nil {
+		keepUserManagedFields(required, existing, userManagedFields)
+		// the deployment is modified by others if its generation is changed since it was applied
+		if currentGenerationStatus != nil && existing.Generation != currentGenerationStatus.LastGeneration {
+			if fields := driftedFields(required, existing); len(fields) > 0 {
+				drift = &DeploymentDrift{Namespace: existing.Namespace, Name: existing.Name, Fields: fields}
+				recorder.Warningf("DeploymentDriftReverted", "deployment %s is modified, reverting the fields %s",
+					fmt.Sprintf("%s/%s", existing.Namespace, existing.Name), strings.Join(fields, ", "))
+			}
+		}
+	}
 
 	updatedDeployment, updated, err := resourceapply.ApplyDeployment(
 		ctx,
 		client.AppsV1(),
 		recorder,
-		deployment.(*appsv1.Deployment), generationStatus.LastGeneration)
+		required, generationStatus.LastGeneration)
 	if err != nil {
-		return updatedDeployment, generationStatus, fmt.Errorf("%q (%T): %v", file, deployment, err)
+		return updatedDeployment, generationStatus, nil, fmt.Errorf("%q (%T): %v", file, deployment, err)
 	}
 
 	if updated {
 		generationStatus.LastGeneration = updatedDeployment.ObjectMeta.Generation
 	}
 
-	return updatedDeployment, generationStatus, nil
+	return updatedDeployment, generationStatus, drift, nil
 }
 
 func ApplyEndpoints(ctx context.Context, client coreclientv1.EndpointsGetter, required *corev1.Endpoints) (*corev1.Endpoints, bool, error) {
@@ -591,9 +591,9 @@ func TestApplyDeployment(t *testing.T) {
 	for _, c := range testcases {
 		t.Run(c.name, func(t *testing.T) {
 			fakeKubeClient := fakekube.NewSimpleClientset()
-			_, _, err := ApplyDeployment(
+			_, _, _, err := ApplyDeployment(
 				context.TODO(),
-				fakeKubeClient, []operatorapiv1.GenerationStatus{}, c.nodePlacement,
+				fakeKubeClient, []operatorapiv1.GenerationStatus{}, c.nodePlacement, nil,
 				func(name string) ([]byte, error) {
 					return json.Marshal(newDeploymentUnstructured(c.deploymentName, c.deploymentNamespace))
 				},
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// MinimumKlusterletKubeVersion is the minimum kube version of the managed clusters supported by the klusterlet.
+	// The fallback manifests are rendered for the clusters older than v1.16, e.g. the v1beta1 CRDs.
+	MinimumKlusterletKubeVersion = "v1.11.0"
+	// MinimumHubKubeVersion is the minimum kube version of the hub clusters supported by the cluster manager.
+	// The hub manifests require the v1 CRDs and the v1 admission webhooks.
+	MinimumHubKubeVersion = "v1.16.0"
+
+	// KubeVersionDegraded is the condition type set on the klusterlet and the cluster manager if the kube version
+	// of the cluster is not supported.
+	KubeVersionDegraded = "KubeVersionDegraded"
+	// KubeVersionReasonUnsupported is the reason of the KubeVersionDegraded condition.
+	KubeVersionReasonUnsupported = "KubeVersionUnsupported"
+)
+
+// GetKubeVersion discovers the kube version of the cluster.
+func GetKubeVersion(kubeClient kubernetes.Interface) (*version.Version, error) {
+	serverVersion, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	return version.ParseGeneric(serverVersion.String())
+}
+
+// CheckKubeVersion returns an error if the kube version is older than the minimum version.
+func CheckKubeVersion(kubeVersion *version.Version, minimum string) error {
+	minimumVersion := version.MustParseGeneric(minimum)
+	if kubeVersion.LessThan(minimumVersion) {
+		return fmt.Errorf("kubernetes %s < minimum %s", kubeVersion, minimumVersion)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetKubeVersion(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &apimachineryversion.Info{
+		GitVersion: "v1.19.16+k3s1",
+	}
+
+	kubeVersion, err := GetKubeVersion(kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kubeVersion.String() != "1.19.16" {
+		t.Errorf("unexpected kube version %s", kubeVersion)
+	}
+}
+
+func TestCheckKubeVersion(t *testing.T) {
+	cases := []struct {
+		name        string
+		kubeVersion string
+		minimum     string
+		expectedErr string
+	}{
+		{
+			name:        "older than the minimum version",
+			kubeVersion: "v1.19.0",
+			minimum:     "v1.23.0",
+			expectedErr: "kubernetes 1.19.0 < minimum 1.23.0",
+		},
+		{
+			name:        "equal to the minimum version",
+			kubeVersion: "v1.23.0",
+			minimum:     "v1.23.0",
+		},
+		{
+			name:        "newer than the minimum version",
+			kubeVersion: "v1.27.3+k3s1",
+			minimum:     "v1.23.0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CheckKubeVersion(version.MustParseGeneric(c.kubeVersion), c.minimum)
+			switch {
+			case len(c.expectedErr) == 0 && err != nil:
+				t.Errorf("expected no error, but got %v", err)
+			case len(c.expectedErr) > 0 && (err == nil || err.Error() != c.expectedErr):
+				t.Errorf("expected error %q, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package helpers
+
+import (
+	"strconv"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+)
+
+// LeasePerControllerGroupAnnotation is the annotation on a clustermanager to elect each controller group of the
+// registration, work and placement controllers with a lease of its own, e.g.
+// operator.open-cluster-management.io/lease-per-controller-group: "true", so the groups could be led by different
+// replicas to spread the load. It is rendered as the flag --lease-per-controller-group of the hub controllers.
+// TODO move this to the api repo as a field of the ClusterManagerSpec
+const LeasePerControllerGroupAnnotation = "operator.open-cluster-management.io/lease-per-controller-group"
+
+// LeasePerControllerGroup returns true if the controller groups of the hub controllers are elected with the
+// leases of their own. It is false if the annotation is not a boolean.
+func LeasePerControllerGroup(clusterManager *operatorapiv1.ClusterManager) bool {
+	enabled, err := strconv.ParseBool(clusterManager.Annotations[LeasePerControllerGroupAnnotation])
+	return err == nil && enabled
+}
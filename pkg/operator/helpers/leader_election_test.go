@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/openshift/library-go/pkg/assets"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	"open-cluster-management.io/ocm/manifests"
+)
+
+func TestLeasePerControllerGroup(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:        "enabled",
+			annotations: map[string]string{LeasePerControllerGroupAnnotation: "true"},
+			expected:    true,
+		},
+		{
+			name:        "disabled",
+			annotations: map[string]string{LeasePerControllerGroupAnnotation: "false"},
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{LeasePerControllerGroupAnnotation: "yes"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterManager := &operatorapiv1.ClusterManager{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-manager", Annotations: c.annotations},
+			}
+			if actual := LeasePerControllerGroup(clusterManager); actual != c.expected {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLeasePerControllerGroupFlag(t *testing.T) {
+	files := []string{
+		registrationManifestFile,
+		"cluster-manager/management/cluster-manager-placement-deployment.yaml",
+		"cluster-manager/management/cluster-manager-manifestworkreplicaset-deployment.yaml",
+	}
+	for _, file := range files {
+		for _, enabled := range []bool{true, false} {
+			config := manifests.HubConfig{ClusterManagerName: "cluster-manager", Replica: 1, LeasePerControllerGroup: enabled}
+			template, err := manifests.ClusterManagerManifestFiles.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, _, err := genericCodec.Decode(assets.MustCreateAssetFromTemplate(file, template, config).Data, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := sets.New[string](obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Args...)
+			if args.Has("--lease-per-controller-group") != enabled {
+				t.Errorf("expected the flag --lease-per-controller-group %v in %s, but got %v", enabled, file, args.UnsortedList())
+			}
+		}
+	}
+}
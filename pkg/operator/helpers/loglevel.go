@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/loglevel"
+)
+
+const (
+	LogLevelsTypeValid             = "ValidLogLevels"
+	LogLevelsReasonAllValid        = "LogLevelsAllValid"
+	LogLevelsReasonInvalidExisting = "InvalidLogLevelsExisting"
+
+	// Components whose log verbosity can be set by the LogLevelAnnotation.
+	RegistrationComponent = "registration"
+	WorkComponent         = "work"
+	PlacementComponent    = "placement"
+	AddOnManagerComponent = "addon-manager"
+
+	// KlusterletLogLevelConfigMap is the ConfigMap in the agent namespace watched by the agents to change the log
+	// verbosity at runtime, see the flag --log-level-configmap of the agents.
+	KlusterletLogLevelConfigMap = "klusterlet-log-level"
+)
+
+// LogLevelAnnotation returns the annotation on a klusterlet or clustermanager to set the log verbosity of the
+// component, e.g. operator.open-cluster-management.io/registration-log-level: "4". The verbosity is rendered as
+// the flag --v of the component.
+// TODO move this to the api repo as a field of the component configurations
+func LogLevelAnnotation(component string) string {
+	return fmt.Sprintf("operator.open-cluster-management.io/%s-log-level", component)
+}
+
+// ConvertToLogLevel returns the log verbosity of the component set by the annotation, it is empty if the annotation
+// is not set or invalid. A message is returned if the verbosity is invalid.
+func ConvertToLogLevel(component string, annotations map[string]string) (string, string) {
+	value, ok := annotations[LogLevelAnnotation(component)]
+	if !ok {
+		return "", ""
+	}
+	verbosity, err := loglevel.ParseVerbosity(value)
+	if err != nil {
+		return "", fmt.Sprintf("%s: %q", component, value)
+	}
+	return strconv.Itoa(verbosity), ""
+}
+
+func BuildLogLevelCondition(invalidMsgs ...string) metav1.Condition {
+	var msgs []string
+	for _, msg := range invalidMsgs {
+		if len(msg) > 0 {
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) == 0 {
+		return metav1.Condition{
+			Type:    LogLevelsTypeValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  LogLevelsReasonAllValid,
+			Message: "Log levels are all valid",
+		}
+	}
+
+	return metav1.Condition{
+		Type:   LogLevelsTypeValid,
+		Status: metav1.ConditionFalse,
+		Reason: LogLevelsReasonInvalidExisting,
+		Message: fmt.Sprintf("There are some invalid log levels of %s, the log levels should be integers between 0 and %d, "+
+			"will process them with default values", strings.Join(msgs, ", "), loglevel.MaxVerbosity),
+	}
+}
+
+// HasLogLevelAnnotation returns true if the log verbosity of any of the components is set by the annotation.
+func HasLogLevelAnnotation(annotations map[string]string, components ...string) bool {
+	for _, component := range components {
+		if _, ok := annotations[LogLevelAnnotation(component)]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/assets"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/ocm/manifests"
+)
+
+func TestConvertToLogLevel(t *testing.T) {
+	annotations := map[string]string{
+		LogLevelAnnotation(RegistrationComponent): "4",
+		LogLevelAnnotation(WorkComponent):         "11",
+		LogLevelAnnotation(PlacementComponent):    " 2",
+	}
+
+	cases := []struct {
+		component   string
+		expected    string
+		expectedMsg string
+	}{
+		{component: RegistrationComponent, expected: "4"},
+		{component: WorkComponent, expectedMsg: `work: "11"`},
+		{component: PlacementComponent, expected: "2"},
+		{component: AddOnManagerComponent},
+	}
+	var msgs []string
+	for _, c := range cases {
+		logLevel, msg := ConvertToLogLevel(c.component, annotations)
+		if logLevel != c.expected || msg != c.expectedMsg {
+			t.Errorf("%s: expected log level %q and message %q, but got %q and %q",
+				c.component, c.expected, c.expectedMsg, logLevel, msg)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if cond := BuildLogLevelCondition(msgs...); cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, `work: "11"`) {
+		t.Errorf("unexpected condition %v", cond)
+	}
+	if cond := BuildLogLevelCondition("", ""); cond.Status != metav1.ConditionTrue {
+		t.Errorf("unexpected condition %v", cond)
+	}
+	if HasLogLevelAnnotation(annotations, AddOnManagerComponent) || !HasLogLevelAnnotation(annotations, AddOnManagerComponent, WorkComponent) {
+		t.Errorf("unexpected result of HasLogLevelAnnotation")
+	}
+}
+
+func TestRenderLogLevel(t *testing.T) {
+	cases := []struct {
+		name         string
+		manifestFile string
+		config       manifests.HubConfig
+		expectedArg  string
+	}{
+		{
+			name:         "log level is not set",
+			manifestFile: "cluster-manager/management/cluster-manager-registration-deployment.yaml",
+			config:       manifests.HubConfig{ClusterManagerName: "test", Replica: 1},
+		},
+		{
+			name:         "registration log level",
+			manifestFile: "cluster-manager/management/cluster-manager-registration-deployment.yaml",
+			config:       manifests.HubConfig{ClusterManagerName: "test", Replica: 1, RegistrationLogLevel: "4"},
+			expectedArg:  "--v=4",
+		},
+		{
+			name:         "placement log level",
+			manifestFile: "cluster-manager/management/cluster-manager-placement-deployment.yaml",
+			config:       manifests.HubConfig{ClusterManagerName: "test", Replica: 1, PlacementLogLevel: "0"},
+			expectedArg:  "--v=0",
+		},
+		{
+			name:         "addon manager log level",
+			manifestFile: "cluster-manager/management/cluster-manager-addon-manager-deployment.yaml",
+			config:       manifests.HubConfig{ClusterManagerName: "test", Replica: 1, AddOnManagerLogLevel: "6"},
+			expectedArg:  "--v=6",
+		},
+		{
+			name:         "work log level",
+			manifestFile: "cluster-manager/management/cluster-manager-manifestworkreplicaset-deployment.yaml",
+			config:       manifests.HubConfig{ClusterManagerName: "test", Replica: 1, WorkLogLevel: "2"},
+			expectedArg:  "--v=2",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			template, err := manifests.ClusterManagerManifestFiles.ReadFile(c.manifestFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			objData := assets.MustCreateAssetFromTemplate(c.manifestFile, template, c.config).Data
+			obj, _, err := genericCodec.Decode(objData, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var verbosityArgs []string
+			for _, arg := range obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Args {
+				if strings.HasPrefix(arg, "--v=") {
+					verbosityArgs = append(verbosityArgs, arg)
+				}
+			}
+			switch {
+			case len(c.expectedArg) == 0 && len(verbosityArgs) > 0:
+				t.Errorf("expected no verbosity arg, but got %v", verbosityArgs)
+			case len(c.expectedArg) > 0 && (len(verbosityArgs) != 1 || verbosityArgs[0] != c.expectedArg):
+				t.Errorf("expected verbosity arg %s, but got %v", c.expectedArg, verbosityArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+)
+
+// PlacementFeatureGatesAnnotation is the annotation on a clustermanager to set the feature gates of the placement
+// controller, e.g. operator.open-cluster-management.io/placement-feature-gates:
+// "PlacementPrioritizerScores=true,PlacementSatisfiedConditionCounts=false". The feature gates are rendered as the
+// flags --feature-gates of the placement controller.
+// TODO move this to the api repo as a PlacementConfiguration of the ClusterManagerSpec
+const PlacementFeatureGatesAnnotation = "operator.open-cluster-management.io/placement-feature-gates"
+
+// PlacementFeatureGates returns the feature gates of the placement controller set by the annotation. An entry
+// which is not in the form of <feature>=<bool> is returned as a feature named by the entry itself, so it is
+// reported as an invalid feature gate by ConvertToFeatureGateFlags.
+func PlacementFeatureGates(clusterManager *operatorapiv1.ClusterManager) []operatorapiv1.FeatureGate {
+	value, ok := clusterManager.Annotations[PlacementFeatureGatesAnnotation]
+	if !ok {
+		return nil
+	}
+
+	var featureGates []operatorapiv1.FeatureGate
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		feature, enabled, found := strings.Cut(entry, "=")
+		mode, err := strconv.ParseBool(strings.TrimSpace(enabled))
+		if !found || err != nil {
+			featureGates = append(featureGates, operatorapiv1.FeatureGate{Feature: entry})
+			continue
+		}
+		featureGate := operatorapiv1.FeatureGate{
+			Feature: strings.TrimSpace(feature),
+			Mode:    operatorapiv1.FeatureGateModeTypeDisable,
+		}
+		if mode {
+			featureGate.Mode = operatorapiv1.FeatureGateModeTypeEnable
+		}
+		featureGates = append(featureGates, featureGate)
+	}
+	return featureGates
+}
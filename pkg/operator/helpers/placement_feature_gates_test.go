@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/assets"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	"open-cluster-management.io/ocm/manifests"
+	"open-cluster-management.io/ocm/pkg/features"
+)
+
+func TestPlacementFeatureGates(t *testing.T) {
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedFlags   []string
+		expectedInvalid string
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name: "enabled and disabled",
+			annotations: map[string]string{
+				PlacementFeatureGatesAnnotation: "PlacementPrioritizerScores=true, PlacementSatisfiedConditionCounts=false",
+			},
+			expectedFlags: []string{"--feature-gates=PlacementPrioritizerScores=true"},
+		},
+		{
+			name: "invalid entries",
+			annotations: map[string]string{
+				PlacementFeatureGatesAnnotation: "PlacementPrioritizerScores=yes,Foo=true,,PlacementSatisfiedConditionCounts=true",
+			},
+			expectedFlags:   []string{"--feature-gates=PlacementSatisfiedConditionCounts=true"},
+			expectedInvalid: "Placement: [PlacementPrioritizerScores=yes Foo]",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterManager := &operatorapiv1.ClusterManager{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-manager", Annotations: c.annotations},
+			}
+			flags, invalid := ConvertToFeatureGateFlags("Placement",
+				PlacementFeatureGates(clusterManager), features.DefaultHubPlacementFeatureGates)
+			if !reflect.DeepEqual(flags, c.expectedFlags) {
+				t.Errorf("expected flags %v, but got %v", c.expectedFlags, flags)
+			}
+			if invalid != c.expectedInvalid {
+				t.Errorf("expected invalid message %q, but got %q", c.expectedInvalid, invalid)
+			}
+		})
+	}
+}
+
+func TestPlacementFeatureGatesFlag(t *testing.T) {
+	file := "cluster-manager/management/cluster-manager-placement-deployment.yaml"
+	config := manifests.HubConfig{
+		ClusterManagerName:    "cluster-manager",
+		Replica:               1,
+		PlacementFeatureGates: []string{"--feature-gates=PlacementPrioritizerScores=true"},
+	}
+	template, err := manifests.ClusterManagerManifestFiles.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, _, err := genericCodec.Decode(assets.MustCreateAssetFromTemplate(file, template, config).Data, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Args
+	for _, arg := range args {
+		if arg == "--feature-gates=PlacementPrioritizerScores=true" {
+			return
+		}
+	}
+	t.Errorf("expected the placement feature gate flag, but got %v", args)
+}
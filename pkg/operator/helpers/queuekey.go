@@ -41,6 +41,12 @@ const (
 
 	SignerSecret      = "signer-secret"
 	CaBundleConfigmap = "ca-bundle-configmap"
+
+	// InstallModeSingleton is the install mode of the klusterlet to run the registration and work agents in a
+	// single deployment named <klusterlet name>-agent. It is the same as the Default mode except that the agents
+	// are combined into one process.
+	// TODO move this to the api repo
+	InstallModeSingleton operatorapiv1.InstallMode = "Singleton"
 )
 
 func ClusterManagerNamespace(clustermanagername string, mode operatorapiv1.InstallMode) string {
@@ -82,7 +88,8 @@ func KlusterletDeploymentQueueKeyFunc(klusterletLister operatorlister.Klusterlet
 		namespace := accessor.GetNamespace()
 		name := accessor.GetName()
 		interestedObjectFound := false
-		if strings.HasSuffix(name, "registration-agent") || strings.HasSuffix(name, "work-agent") {
+		// the registration, work and singleton agent deployments are named with the suffix "-agent".
+		if strings.HasSuffix(name, "-agent") {
 			interestedObjectFound = true
 		}
 		if !interestedObjectFound {
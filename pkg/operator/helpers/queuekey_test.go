@@ -121,6 +121,12 @@ func TestKlusterletDeploymentQueueKeyFunc(t *testing.T) {
 			klusterlet:  newKlusterlet("testklusterlet", "test", ""),
 			expectedKey: "testklusterlet",
 		},
+		{
+			name:        "key by singleton agent",
+			object:      newDeployment("testklusterlet-agent", "test", 0),
+			klusterlet:  newKlusterlet("testklusterlet", "test", ""),
+			expectedKey: "testklusterlet",
+		},
 		{
 			name:        "key by wrong deployment",
 			object:      newDeployment("dummy", "test", 0),
@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+const (
+	// ClusterAutoApprovalSelectorAnnotation is the annotation on a clustermanager to set the label selector of the
+	// clusters which are auto approved, e.g. operator.open-cluster-management.io/cluster-auto-approval-selector:
+	// "cluster.open-cluster-management.io/clusterset=dev". The selector may only refer to the labels controlled
+	// by the hub, which is the clusterset label. It is rendered as the flag --cluster-auto-approval-selector of the registration controller,
+	// and takes effect only with the autoApproveUsers in the registration configuration.
+	// TODO move this to the api repo as a field of the RegistrationHubConfiguration
+	ClusterAutoApprovalSelectorAnnotation = "operator.open-cluster-management.io/cluster-auto-approval-selector"
+
+	RegistrationConfigurationTypeValid           = "ValidRegistrationConfiguration"
+	RegistrationConfigurationReasonValid         = "RegistrationConfigurationValid"
+	RegistrationConfigurationReasonInvalidExists = "InvalidRegistrationConfigurationExisting"
+)
+
+// ConvertToAutoApprovalConfig returns the auto approval users and the cluster selector of the clustermanager
+// rendered into the registration controller. The invalid users are discarded, and the auto approval is disabled
+// if the selector is invalid, so the clusters out of the intended selector are not approved. The messages of the
+// invalid configurations are returned.
+func ConvertToAutoApprovalConfig(clusterManager *operatorapiv1.ClusterManager) (string, string, []string) {
+	var users []string
+	var msgs []string
+	if clusterManager.Spec.RegistrationConfiguration != nil {
+		for _, user := range clusterManager.Spec.RegistrationConfiguration.AutoApproveUsers {
+			if err := validateAutoApproveUser(user); err != nil {
+				msgs = append(msgs, fmt.Sprintf("autoApproveUsers %q: %v", user, err))
+				continue
+			}
+			users = append(users, user)
+		}
+	}
+
+	selector, ok := clusterManager.Annotations[ClusterAutoApprovalSelectorAnnotation]
+	if !ok {
+		return strings.Join(users, ","), "", msgs
+	}
+	if _, err := registrationhelpers.ParseClusterAutoApprovalSelector(selector); err != nil {
+		msgs = append(msgs, fmt.Sprintf("annotation %s %q: %v, the auto approval is disabled",
+			ClusterAutoApprovalSelectorAnnotation, selector, err))
+		return "", "", msgs
+	}
+	if len(users) == 0 {
+		return "", "", msgs
+	}
+	return strings.Join(users, ","), selector, msgs
+}
+
+// validateAutoApproveUser checks the user is a valid username of the flag --cluster-auto-approval-users.
+func validateAutoApproveUser(user string) error {
+	if len(user) == 0 {
+		return fmt.Errorf("the user is empty")
+	}
+	if strings.ContainsRune(user, ',') || strings.IndexFunc(user, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("the user should not contain commas or spaces")
+	}
+	if strings.HasPrefix(user, serviceaccount.ServiceAccountUsernamePrefix) {
+		if _, _, err := serviceaccount.SplitUsername(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasRegistrationConfiguration returns true if the auto approval of the clustermanager is configured.
+func HasRegistrationConfiguration(clusterManager *operatorapiv1.ClusterManager) bool {
+	if _, ok := clusterManager.Annotations[ClusterAutoApprovalSelectorAnnotation]; ok {
+		return true
+	}
+	return clusterManager.Spec.RegistrationConfiguration != nil &&
+		len(clusterManager.Spec.RegistrationConfiguration.AutoApproveUsers) > 0
+}
+
+// SetRegistrationConfigurationCondition sets the condition of the registration configuration of the
+// clustermanager, the condition is removed if the auto approval is not configured.
+func SetRegistrationConfigurationCondition(clusterManager *operatorapiv1.ClusterManager, invalidMsgs []string) {
+	switch {
+	case !HasRegistrationConfiguration(clusterManager):
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, RegistrationConfigurationTypeValid)
+	case len(invalidMsgs) == 0:
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+			Type:    RegistrationConfigurationTypeValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  RegistrationConfigurationReasonValid,
+			Message: "Registration configuration is valid",
+		})
+	default:
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+			Type:    RegistrationConfigurationTypeValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  RegistrationConfigurationReasonInvalidExists,
+			Message: fmt.Sprintf("There are some invalid registration configurations: %s", strings.Join(invalidMsgs, "; ")),
+		})
+	}
+}
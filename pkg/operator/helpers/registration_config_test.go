@@ -0,0 +1,216 @@
+package helpers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/assets"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	"open-cluster-management.io/ocm/manifests"
+)
+
+const registrationManifestFile = "cluster-manager/management/cluster-manager-registration-deployment.yaml"
+
+func newClusterManagerWithRegistrationConfig(selector *string, users ...string) *operatorapiv1.ClusterManager {
+	clusterManager := &operatorapiv1.ClusterManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: operatorapiv1.ClusterManagerSpec{
+			RegistrationConfiguration: &operatorapiv1.RegistrationHubConfiguration{AutoApproveUsers: users},
+		},
+	}
+	if selector != nil {
+		clusterManager.Annotations = map[string]string{ClusterAutoApprovalSelectorAnnotation: *selector}
+	}
+	return clusterManager
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestConvertToAutoApprovalConfig(t *testing.T) {
+	cases := []struct {
+		name              string
+		clusterManager    *operatorapiv1.ClusterManager
+		expectedUsers     string
+		expectedSelector  string
+		expectedMsgs      []string
+		expectedCondition metav1.ConditionStatus
+	}{
+		{
+			name:           "no registration configuration",
+			clusterManager: &operatorapiv1.ClusterManager{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		},
+		{
+			name: "auto approve users",
+			clusterManager: newClusterManagerWithRegistrationConfig(nil,
+				"system:serviceaccount:open-cluster-management:cluster-bootstrap", "admin"),
+			expectedUsers:     "system:serviceaccount:open-cluster-management:cluster-bootstrap,admin",
+			expectedCondition: metav1.ConditionTrue,
+		},
+		{
+			name:              "auto approve users with selector",
+			clusterManager:    newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset in (dev,test)"), "admin"),
+			expectedUsers:     "admin",
+			expectedSelector:  "cluster.open-cluster-management.io/clusterset in (dev,test)",
+			expectedCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "invalid users",
+			clusterManager: newClusterManagerWithRegistrationConfig(nil,
+				"admin", "", "user1,user2", "user 3", "system:serviceaccount:Invalid_NS:bootstrap"),
+			expectedUsers: "admin",
+			expectedMsgs: []string{
+				`autoApproveUsers "": the user is empty`,
+				`autoApproveUsers "user1,user2": the user should not contain commas or spaces`,
+				`autoApproveUsers "user 3": the user should not contain commas or spaces`,
+				`autoApproveUsers "system:serviceaccount:Invalid_NS:bootstrap"`,
+			},
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:           "malformed selector",
+			clusterManager: newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset in (dev"), "admin"),
+			expectedMsgs: []string{
+				`annotation operator.open-cluster-management.io/cluster-auto-approval-selector "cluster.open-cluster-management.io/clusterset in (dev"`,
+			},
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:           "malformed set based selector",
+			clusterManager: newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset notin dev"), "admin"),
+			expectedMsgs: []string{
+				"the auto approval is disabled",
+			},
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:           "selector of the labels set by the agents",
+			clusterManager: newClusterManagerWithRegistrationConfig(stringPtr("environment=dev"), "admin"),
+			expectedMsgs: []string{
+				`the label "environment" is not controlled by the hub`,
+			},
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:              "selector without users",
+			clusterManager:    newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset=dev")),
+			expectedCondition: metav1.ConditionTrue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			users, selector, msgs := ConvertToAutoApprovalConfig(c.clusterManager)
+			if users != c.expectedUsers || selector != c.expectedSelector {
+				t.Errorf("expected users %q and selector %q, but got %q and %q",
+					c.expectedUsers, c.expectedSelector, users, selector)
+			}
+			if len(msgs) != len(c.expectedMsgs) {
+				t.Fatalf("expected messages %v, but got %v", c.expectedMsgs, msgs)
+			}
+			for i := range msgs {
+				if !strings.Contains(msgs[i], c.expectedMsgs[i]) {
+					t.Errorf("expected message %q, but got %q", c.expectedMsgs[i], msgs[i])
+				}
+			}
+
+			SetRegistrationConfigurationCondition(c.clusterManager, msgs)
+			cond := meta.FindStatusCondition(c.clusterManager.Status.Conditions, RegistrationConfigurationTypeValid)
+			switch {
+			case len(c.expectedCondition) == 0 && cond != nil:
+				t.Errorf("expected no condition, but got %v", cond)
+			case len(c.expectedCondition) > 0 && (cond == nil || cond.Status != c.expectedCondition):
+				t.Errorf("expected condition status %q, but got %v", c.expectedCondition, cond)
+			}
+		})
+	}
+}
+
+func TestRenderRegistrationConfiguration(t *testing.T) {
+	cases := []struct {
+		name           string
+		clusterManager *operatorapiv1.ClusterManager
+		expectedArgs   []string
+	}{
+		{
+			name:           "no registration configuration",
+			clusterManager: &operatorapiv1.ClusterManager{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		},
+		{
+			name:           "auto approve users",
+			clusterManager: newClusterManagerWithRegistrationConfig(nil, "admin", "user1"),
+			expectedArgs:   []string{"--cluster-auto-approval-users=admin,user1"},
+		},
+		{
+			name:           "auto approve users with selector",
+			clusterManager: newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset in (dev,test)"), "admin"),
+			expectedArgs: []string{
+				"--cluster-auto-approval-users=admin",
+				"--cluster-auto-approval-selector=cluster.open-cluster-management.io/clusterset in (dev,test)",
+			},
+		},
+		{
+			name:           "invalid users are not rendered",
+			clusterManager: newClusterManagerWithRegistrationConfig(nil, "admin", "user 1"),
+			expectedArgs:   []string{"--cluster-auto-approval-users=admin"},
+		},
+		{
+			name:           "auto approval is disabled with a malformed selector",
+			clusterManager: newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset in (dev"), "admin"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deployment := renderRegistrationDeployment(t, c.clusterManager)
+			var autoApprovalArgs []string
+			for _, arg := range deployment.Spec.Template.Spec.Containers[0].Args {
+				if strings.HasPrefix(arg, "--cluster-auto-approval-") {
+					autoApprovalArgs = append(autoApprovalArgs, arg)
+				}
+			}
+			if !reflect.DeepEqual(autoApprovalArgs, c.expectedArgs) {
+				t.Errorf("expected args %v, but got %v", c.expectedArgs, autoApprovalArgs)
+			}
+		})
+	}
+}
+
+func TestRegistrationConfigurationRollsDeployment(t *testing.T) {
+	origin := renderRegistrationDeployment(t, newClusterManagerWithRegistrationConfig(nil, "admin"))
+
+	// the pod template is changed with the registration configuration, so the deployment is rolled out
+	updated := renderRegistrationDeployment(t, newClusterManagerWithRegistrationConfig(stringPtr("cluster.open-cluster-management.io/clusterset=dev"), "admin"))
+	if equality.Semantic.DeepEqual(origin.Spec.Template, updated.Spec.Template) {
+		t.Errorf("expected the pod template changed with the auto approval selector")
+	}
+
+	unchanged := renderRegistrationDeployment(t, newClusterManagerWithRegistrationConfig(nil, "admin"))
+	if !equality.Semantic.DeepEqual(origin.Spec.Template, unchanged.Spec.Template) {
+		t.Errorf("expected the pod template unchanged with the same registration configuration")
+	}
+}
+
+func renderRegistrationDeployment(t *testing.T, clusterManager *operatorapiv1.ClusterManager) *appsv1.Deployment {
+	config := manifests.HubConfig{ClusterManagerName: clusterManager.Name, Replica: 1}
+	config.AutoApproveUsers, config.AutoApprovalSelector, _ = ConvertToAutoApprovalConfig(clusterManager)
+
+	template, err := manifests.ClusterManagerManifestFiles.ReadFile(registrationManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objData := assets.MustCreateAssetFromTemplate(registrationManifestFile, template, config).Data
+	obj, _, err := genericCodec.Decode(objData, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj.(*appsv1.Deployment)
+}
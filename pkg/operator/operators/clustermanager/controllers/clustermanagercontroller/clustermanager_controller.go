@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/base64"
 	errorhelpers "errors"
-	"strings"
 	"time"
 
 	"github.com/openshift/library-go/pkg/assets"
@@ -17,6 +16,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -33,7 +33,9 @@ import (
 
 	"open-cluster-management.io/ocm/manifests"
 	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
+	"open-cluster-management.io/ocm/pkg/version"
 )
 
 const (
@@ -58,7 +60,14 @@ type clusterManagerController struct {
 		hubConfig *rest.Config, hubClient, managementClient kubernetes.Interface, recorder events.Recorder) error
 	generateHubClusterClients func(hubConfig *rest.Config) (kubernetes.Interface, apiextensionsclient.Interface,
 		migrationclient.StorageVersionMigrationsGetter, error)
+	// getKubeVersion discovers the kube version of the hub cluster before each apply pass, the kube version is
+	// not checked if it is not set.
+	getKubeVersion func(kubeClient kubernetes.Interface) (*utilversion.Version, error)
 	skipRemoveCRDs bool
+	// importOperatorImage is the klusterlet operator image in the import manifests of the managed clusters.
+	importOperatorImage string
+	// bundleVersion is published on the hub cluster once the hub components finish upgrading.
+	bundleVersion string
 }
 
 type clusterManagerReconcile interface {
@@ -83,6 +92,7 @@ func NewClusterManagerController(
 	configMapInformer corev1informers.ConfigMapInformer,
 	recorder events.Recorder,
 	skipRemoveCRDs bool,
+	importOperatorImage string,
 ) factory.Controller {
 	controller := &clusterManagerController{
 		operatorKubeClient: operatorKubeClient,
@@ -95,8 +105,11 @@ func NewClusterManagerController(
 		recorder:                  recorder,
 		generateHubClusterClients: generateHubClients,
 		ensureSAKubeconfigs:       ensureSAKubeconfigs,
+		getKubeVersion:            helpers.GetKubeVersion,
 		cache:                     resourceapply.NewResourceCache(),
 		skipRemoveCRDs:            skipRemoveCRDs,
+		importOperatorImage:       importOperatorImage,
+		bundleVersion:             version.Get().GitVersion,
 	}
 
 	return factory.New().WithSync(controller.sync).
@@ -153,16 +166,22 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 		},
 	}
 
-	var registrationFeatureMsgs, workFeatureMsgs, addonFeatureMsgs string
+	var registrationFeatureMsgs, workFeatureMsgs, placementFeatureMsgs, addonFeatureMsgs string
 	// If there are some invalid feature gates of registration or work, will output
 	// condition `ValidFeatureGates` False in ClusterManager.
 	registrationFeatureGates := helpers.DefaultHubRegistrationFeatureGates
 	if clusterManager.Spec.RegistrationConfiguration != nil {
 		registrationFeatureGates = clusterManager.Spec.RegistrationConfiguration.FeatureGates
-		config.AutoApproveUsers = strings.Join(clusterManager.Spec.RegistrationConfiguration.AutoApproveUsers, ",")
 	}
+	// Invalid auto approval users and selector are ignored and reported in the condition `ValidRegistrationConfiguration`.
+	var registrationConfigMsgs []string
+	config.AutoApproveUsers, config.AutoApprovalSelector, registrationConfigMsgs = helpers.ConvertToAutoApprovalConfig(clusterManager)
 	config.RegistrationFeatureGates, registrationFeatureMsgs = helpers.ConvertToFeatureGateFlags("Registration",
-		registrationFeatureGates, ocmfeature.DefaultHubRegistrationFeatureGates)
+		registrationFeatureGates, features.KnownHubRegistrationFeatureGates())
+	config.ImportManifestsEnabled = helpers.FeatureGateEnabled(registrationFeatureGates,
+		features.KnownHubRegistrationFeatureGates(), features.ClusterImportManifests)
+	config.ImportOperatorImage = n.importOperatorImage
+	config.LeasePerControllerGroup = helpers.LeasePerControllerGroup(clusterManager)
 
 	workFeatureGates := []operatorapiv1.FeatureGate{}
 	if clusterManager.Spec.WorkConfiguration != nil {
@@ -171,12 +190,24 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 	config.WorkFeatureGates, workFeatureMsgs = helpers.ConvertToFeatureGateFlags("Work", workFeatureGates, ocmfeature.DefaultHubWorkFeatureGates)
 	config.MWReplicaSetEnabled = helpers.FeatureGateEnabled(workFeatureGates, ocmfeature.DefaultHubWorkFeatureGates, ocmfeature.ManifestWorkReplicaSet)
 
+	config.PlacementFeatureGates, placementFeatureMsgs = helpers.ConvertToFeatureGateFlags("Placement",
+		helpers.PlacementFeatureGates(clusterManager), features.DefaultHubPlacementFeatureGates)
+
 	addonFeatureGates := []operatorapiv1.FeatureGate{}
 	if clusterManager.Spec.AddOnManagerConfiguration != nil {
 		addonFeatureGates = clusterManager.Spec.AddOnManagerConfiguration.FeatureGates
 	}
 	_, addonFeatureMsgs = helpers.ConvertToFeatureGateFlags("Addon", addonFeatureGates, ocmfeature.DefaultHubAddonManagerFeatureGates)
-	featureGateCondition := helpers.BuildFeatureCondition(registrationFeatureMsgs, workFeatureMsgs, addonFeatureMsgs)
+	featureGateCondition := helpers.BuildFeatureCondition(registrationFeatureMsgs, workFeatureMsgs, placementFeatureMsgs, addonFeatureMsgs)
+
+	// Invalid log levels are replaced by the default verbosity and reported in the condition `ValidLogLevels`.
+	logLevelComponents := []string{
+		helpers.RegistrationComponent, helpers.WorkComponent, helpers.PlacementComponent, helpers.AddOnManagerComponent}
+	logLevelMsgs := make([]string, len(logLevelComponents))
+	config.RegistrationLogLevel, logLevelMsgs[0] = helpers.ConvertToLogLevel(helpers.RegistrationComponent, clusterManager.Annotations)
+	config.WorkLogLevel, logLevelMsgs[1] = helpers.ConvertToLogLevel(helpers.WorkComponent, clusterManager.Annotations)
+	config.PlacementLogLevel, logLevelMsgs[2] = helpers.ConvertToLogLevel(helpers.PlacementComponent, clusterManager.Annotations)
+	config.AddOnManagerLogLevel, logLevelMsgs[3] = helpers.ConvertToLogLevel(helpers.AddOnManagerComponent, clusterManager.Annotations)
 
 	// Check if addon management is enabled by the feature gate
 	config.AddOnManagerEnabled = helpers.FeatureGateEnabled(addonFeatureGates, ocmfeature.DefaultHubAddonManagerFeatureGates, ocmfeature.AddonManagement)
@@ -216,6 +247,7 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 		&runtimeReconcile{cache: n.cache, recorder: n.recorder, hubKubeConfig: hubKubeConfig, hubKubeClient: hubClient,
 			kubeClient: managementClient, ensureSAKubeconfigs: n.ensureSAKubeconfigs},
 		&webhookReconcile{cache: n.cache, recorder: n.recorder, hubKubeClient: hubClient, kubeClient: managementClient},
+		&versionReconcile{recorder: n.recorder, hubKubeClient: hubClient, bundleVersion: n.bundleVersion},
 	}
 
 	// If the ClusterManager is deleting, we remove its related resources on hub
@@ -230,6 +262,26 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 		return n.patcher.RemoveFinalizer(ctx, clusterManager, clusterManagerFinalizer)
 	}
 
+	// Do not apply the manifests which would fail on the unsupported hub cluster, the kube version is checked again
+	// in the next resync.
+	if n.getKubeVersion != nil {
+		kubeVersion, err := n.getKubeVersion(hubClient)
+		if err != nil {
+			return err
+		}
+		if err := helpers.CheckKubeVersion(kubeVersion, helpers.MinimumHubKubeVersion); err != nil {
+			meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+				Type:    helpers.KubeVersionDegraded,
+				Status:  metav1.ConditionTrue,
+				Reason:  helpers.KubeVersionReasonUnsupported,
+				Message: err.Error(),
+			})
+			_, updatedErr := n.patcher.PatchStatus(ctx, clusterManager, clusterManager.Status, originalClusterManager.Status)
+			return updatedErr
+		}
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, helpers.KubeVersionDegraded)
+	}
+
 	// get caBundle
 	caBundle := "placeholder"
 	configmap, err := n.configMapLister.ConfigMaps(clusterManagerNamespace).Get(helpers.CaBundleConfigmap)
@@ -263,6 +315,16 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 
 	// Update status
 	meta.SetStatusCondition(&clusterManager.Status.Conditions, featureGateCondition)
+	helpers.SetRegistrationConfigurationCondition(clusterManager, registrationConfigMsgs)
+	if helpers.HasLogLevelAnnotation(clusterManager.Annotations, logLevelComponents...) {
+		logLevelCondition := helpers.BuildLogLevelCondition(logLevelMsgs...)
+		if logLevelCondition.Status == metav1.ConditionFalse {
+			controllerContext.Recorder().Warning(logLevelCondition.Reason, logLevelCondition.Message)
+		}
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, logLevelCondition)
+	} else {
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, helpers.LogLevelsTypeValid)
+	}
 	clusterManager.Status.ObservedGeneration = clusterManager.Generation
 	if len(errs) == 0 {
 		meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
@@ -1,7 +1,9 @@
 package clustermanagercontroller
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -9,6 +11,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -18,11 +21,15 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/jsonpath"
 	fakemigrationclient "sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset/fake"
 	migrationclient "sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset/typed/migration/v1alpha1"
 
@@ -33,6 +40,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 var (
@@ -316,7 +324,7 @@ func TestSyncDeploy(t *testing.T) {
 
 	// Check if resources are created as expected
 	// We expect create the namespace twice respectively in the management cluster and the hub cluster.
-	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 27)
+	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 28)
 	for _, object := range createKubeObjects {
 		ensureObject(t, object, clusterManager)
 	}
@@ -373,6 +381,155 @@ func TestSyncDeployNoWebhook(t *testing.T) {
 	testingcommon.AssertEqualNumber(t, len(createCRDObjects), 12)
 }
 
+// TestSyncDeployBundleVersion tests the bundle version is published only after the hub components are upgraded
+func TestSyncDeployBundleVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		ready           bool
+		expectedVersion string
+	}{
+		{
+			name:            "hub components are upgraded",
+			ready:           true,
+			expectedVersion: "v0.13.0",
+		},
+		{
+			name:  "hub components are upgrading",
+			ready: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterManager := newClusterManager("testhub")
+			tc := newTestController(t, clusterManager)
+			tc.clusterManagerController.bundleVersion = "v0.13.0"
+			clusterManagerNamespace := helpers.ClusterManagerNamespace(clusterManager.Name, clusterManager.Spec.DeployOption.Mode)
+			var cd []runtime.Object
+			if c.ready {
+				cd = setDeployment(clusterManager.Name, clusterManagerNamespace)
+			}
+			setup(t, tc, cd)
+
+			syncContext := testingcommon.NewFakeSyncContext(t, "testhub")
+			if err := tc.clusterManagerController.sync(ctx, syncContext); err != nil {
+				t.Fatalf("Expected no error when sync, %v", err)
+			}
+
+			version := ""
+			for _, action := range tc.hubKubeClient.Actions() {
+				if action.GetVerb() != "create" {
+					continue
+				}
+				if cm, ok := action.(clienttesting.CreateActionImpl).Object.(*corev1.ConfigMap); ok && cm.Name == registrationhelpers.HubVersionConfigMap {
+					testingcommon.AssertEqualNameNamespace(t, cm.Name, cm.Namespace, registrationhelpers.HubVersionConfigMap, clusterManagerNamespace)
+					version = cm.Data[registrationhelpers.HubBundleVersionKey]
+				}
+			}
+			if version != c.expectedVersion {
+				t.Errorf("Expected bundle version %q, but got %q", c.expectedVersion, version)
+			}
+		})
+	}
+}
+
+// TestSyncDeployPlacementFeatureGates tests the placement feature gates in the annotation are rendered as the flags
+// of the placement controller
+func TestSyncDeployPlacementFeatureGates(t *testing.T) {
+	clusterManager := newClusterManager("testhub")
+	clusterManager.Annotations = map[string]string{
+		helpers.PlacementFeatureGatesAnnotation: "PlacementSatisfiedConditionCounts=true,Foo=true",
+	}
+	tc := newTestController(t, clusterManager)
+	clusterManagerNamespace := helpers.ClusterManagerNamespace(clusterManager.Name, clusterManager.Spec.DeployOption.Mode)
+	setup(t, tc, setDeployment(clusterManager.Name, clusterManagerNamespace))
+
+	syncContext := testingcommon.NewFakeSyncContext(t, "testhub")
+	if err := tc.clusterManagerController.sync(ctx, syncContext); err != nil {
+		t.Fatalf("Expected no error when sync, %v", err)
+	}
+
+	var args []string
+	for _, action := range tc.managementKubeClient.Actions() {
+		if action.GetVerb() != "update" {
+			continue
+		}
+		deployment, ok := action.(clienttesting.UpdateActionImpl).Object.(*appsv1.Deployment)
+		if ok && deployment.Name == clusterManager.Name+"-placement-controller" {
+			args = deployment.Spec.Template.Spec.Containers[0].Args
+		}
+	}
+	if !sets.New[string](args...).Has("--feature-gates=PlacementSatisfiedConditionCounts=true") {
+		t.Errorf("Expected the placement feature gate flag, but got %v", args)
+	}
+
+	actual, err := tc.operatorClient.OperatorV1().ClusterManagers().Get(ctx, clusterManager.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(actual.Status.Conditions, helpers.FeatureGatesTypeValid)
+	if condition == nil || condition.Status != metav1.ConditionFalse || !strings.Contains(condition.Message, "Placement: [Foo]") {
+		t.Errorf("Expected the invalid placement feature gate reported, but got %v", condition)
+	}
+}
+
+// TestSyncDeployUnsupportedKubeVersion tests the manifests are not applied on the unsupported hub cluster
+func TestSyncDeployUnsupportedKubeVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		kubeVersion     string
+		expectedMessage string
+	}{
+		{
+			name:        "kube version is supported",
+			kubeVersion: "v1.23.0",
+		},
+		{
+			name:            "kube version is older than the minimum version",
+			kubeVersion:     "v1.15.3",
+			expectedMessage: "kubernetes 1.15.3 < minimum 1.16.0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterManager := newClusterManager("testhub")
+			tc := newTestController(t, clusterManager)
+			setup(t, tc, nil)
+			tc.hubKubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &apimachineryversion.Info{
+				GitVersion: c.kubeVersion,
+			}
+			tc.clusterManagerController.getKubeVersion = helpers.GetKubeVersion
+
+			syncContext := testingcommon.NewFakeSyncContext(t, "testhub")
+			if err := tc.clusterManagerController.sync(ctx, syncContext); err != nil {
+				t.Fatalf("Expected no error when sync, %v", err)
+			}
+
+			crdActions := tc.apiExtensionClient.Actions()
+			if len(c.expectedMessage) > 0 && len(crdActions) != 0 {
+				t.Errorf("Expected no crds applied, but got %v", crdActions)
+			}
+			if len(c.expectedMessage) == 0 && len(crdActions) == 0 {
+				t.Errorf("Expected crds applied")
+			}
+
+			clusterManager, err := tc.operatorClient.OperatorV1().ClusterManagers().Get(ctx, "testhub", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(clusterManager.Status.Conditions, helpers.KubeVersionDegraded)
+			switch {
+			case len(c.expectedMessage) == 0 && condition != nil:
+				t.Errorf("Expected no %s condition, but got %v", helpers.KubeVersionDegraded, condition)
+			case len(c.expectedMessage) > 0 && (condition == nil || condition.Status != metav1.ConditionTrue ||
+				condition.Reason != helpers.KubeVersionReasonUnsupported || condition.Message != c.expectedMessage):
+				t.Errorf("Expected %s condition with message %q, but got %v", helpers.KubeVersionDegraded, c.expectedMessage, condition)
+			}
+		})
+	}
+}
+
 // TestSyncDelete test cleanup hub deploy
 func TestSyncDelete(t *testing.T) {
 	clusterManager := newClusterManager("testhub")
@@ -398,7 +555,7 @@ func TestSyncDelete(t *testing.T) {
 			deleteKubeActions = append(deleteKubeActions, deleteKubeAction)
 		}
 	}
-	testingcommon.AssertEqualNumber(t, len(deleteKubeActions), 27) // delete namespace both from the hub cluster and the mangement cluster
+	testingcommon.AssertEqualNumber(t, len(deleteKubeActions), 28) // delete namespace both from the hub cluster and the mangement cluster
 
 	deleteCRDActions := []clienttesting.DeleteActionImpl{}
 	crdActions := tc.apiExtensionClient.Actions()
@@ -476,3 +633,131 @@ func TestIsIPFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestManagedClusterPrinterColumns(t *testing.T) {
+	clusterManager := newClusterManager("testhub")
+	tc := newTestController(t, clusterManager)
+	clusterManagerNamespace := helpers.ClusterManagerNamespace(clusterManager.Name, clusterManager.Spec.DeployOption.Mode)
+	setup(t, tc, setDeployment(clusterManager.Name, clusterManagerNamespace))
+
+	if err := tc.clusterManagerController.sync(ctx, testingcommon.NewFakeSyncContext(t, "testhub")); err != nil {
+		t.Fatalf("Expected no error when sync, %v", err)
+	}
+
+	var crd *apiextensionsv1.CustomResourceDefinition
+	for _, action := range tc.apiExtensionClient.Actions() {
+		if action.GetVerb() != "create" {
+			continue
+		}
+		object := action.(clienttesting.CreateActionImpl).Object.(*apiextensionsv1.CustomResourceDefinition)
+		if object.Name == "managedclusters.cluster.open-cluster-management.io" {
+			crd = object
+		}
+	}
+	if crd == nil {
+		t.Fatalf("expected the managedcluster crd created")
+	}
+
+	cluster := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "cluster1",
+			"annotations": map[string]interface{}{
+				"cluster.open-cluster-management.io/taints": "cluster.open-cluster-management.io/unreachable",
+			},
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "ManagedClusterConditionAvailable",
+					"status":             "Unknown",
+					"lastTransitionTime": "2023-06-01T00:00:00Z",
+				},
+			},
+			"version": map[string]interface{}{"kubernetes": "v1.27.2"},
+		},
+	}
+	expectedValues := map[string]string{
+		"Taints":        "cluster.open-cluster-management.io/unreachable",
+		"Available Age": "2023-06-01T00:00:00Z",
+		"Version":       "v1.27.2",
+	}
+
+	for _, column := range crd.Spec.Versions[0].AdditionalPrinterColumns {
+		expected, ok := expectedValues[column.Name]
+		if !ok {
+			continue
+		}
+		delete(expectedValues, column.Name)
+
+		parser := jsonpath.New(column.Name)
+		if err := parser.Parse(fmt.Sprintf("{%s}", column.JSONPath)); err != nil {
+			t.Errorf("invalid json path of column %q: %v", column.Name, err)
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err := parser.Execute(buf, cluster); err != nil {
+			t.Errorf("failed to get column %q: %v", column.Name, err)
+			continue
+		}
+		if buf.String() != expected {
+			t.Errorf("expected column %q is %q, but got %q", column.Name, expected, buf.String())
+		}
+	}
+	if len(expectedValues) > 0 {
+		t.Errorf("expected printer columns %v", expectedValues)
+	}
+}
+
+func TestWorkMutatingWebhookConfiguration(t *testing.T) {
+	cases := []struct {
+		name          string
+		featureGates  []operatorapiv1.FeatureGate
+		expectedPaths []string
+	}{
+		{
+			name: "manifestworkreplicaset enabled",
+			featureGates: []operatorapiv1.FeatureGate{
+				{Feature: "ManifestWorkReplicaSet", Mode: operatorapiv1.FeatureGateModeTypeEnable},
+			},
+			expectedPaths: []string{
+				"/mutate-work-open-cluster-management-io-v1-manifestwork",
+				"/mutate-work-open-cluster-management-io-v1alpha1-manifestworkreplicaset",
+			},
+		},
+		{
+			name:          "manifestworkreplicaset disabled",
+			expectedPaths: []string{"/mutate-work-open-cluster-management-io-v1-manifestwork"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterManager := newClusterManager("testhub")
+			clusterManager.Spec.WorkConfiguration.FeatureGates = c.featureGates
+			tc := newTestController(t, clusterManager)
+			clusterManagerNamespace := helpers.ClusterManagerNamespace(clusterManager.Name, clusterManager.Spec.DeployOption.Mode)
+			setup(t, tc, setDeployment(clusterManager.Name, clusterManagerNamespace))
+
+			if err := tc.clusterManagerController.sync(ctx, testingcommon.NewFakeSyncContext(t, "testhub")); err != nil {
+				t.Fatalf("Expected no error when sync, %v", err)
+			}
+
+			var paths []string
+			for _, action := range tc.hubKubeClient.Actions() {
+				if action.GetVerb() != "create" {
+					continue
+				}
+				config, ok := action.(clienttesting.CreateActionImpl).Object.(*admissionregistrationv1.MutatingWebhookConfiguration)
+				if !ok || config.Name != "manifestworkmutators.admission.work.open-cluster-management.io" {
+					continue
+				}
+				for _, webhook := range config.Webhooks {
+					paths = append(paths, *webhook.ClientConfig.Service.Path)
+				}
+			}
+			if strings.Join(paths, ",") != strings.Join(c.expectedPaths, ",") {
+				t.Errorf("expected the webhook paths %v, but got %v", c.expectedPaths, paths)
+			}
+		})
+	}
+}
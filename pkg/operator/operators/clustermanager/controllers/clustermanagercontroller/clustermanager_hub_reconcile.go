@@ -52,6 +52,16 @@ var (
 		"cluster-manager/hub/cluster-manager-manifestworkreplicaset-serviceaccount.yaml",
 	}
 
+	importManifestsResourceFiles = []string{
+		// import manifests
+		"cluster-manager/hub/cluster-manager-registration-import-clusterrole.yaml",
+		"cluster-manager/hub/cluster-manager-registration-import-role.yaml",
+		"cluster-manager/hub/cluster-manager-registration-import-rolebinding.yaml",
+		"cluster-manager/hub/cluster-manager-registration-import-bootstrap-clusterrole.yaml",
+		"cluster-manager/hub/cluster-manager-registration-import-bootstrap-clusterrolebinding.yaml",
+		"cluster-manager/hub/cluster-manager-registration-import-bootstrap-serviceaccount.yaml",
+	}
+
 	hubAddOnManagerRbacResourceFiles = []string{
 		// addon-manager
 		"cluster-manager/hub/cluster-manager-addon-manager-clusterrole.yaml",
@@ -98,6 +108,14 @@ func (c *hubReoncile) reconcile(ctx context.Context, cm *operatorapiv1.ClusterMa
 		}
 	}
 
+	// Remove the import manifests resources if feature not enabled
+	if !config.ImportManifestsEnabled {
+		_, _, err := cleanResources(ctx, c.hubKubeClient, cm, config, importManifestsResourceFiles...)
+		if err != nil {
+			return cm, reconcileStop, err
+		}
+	}
+
 	hubResources := getHubResources(cm.Spec.DeployOption.Mode, config)
 	var appliedErrs []error
 
@@ -153,6 +171,10 @@ func getHubResources(mode operatorapiv1.InstallMode, config manifests.HubConfig)
 	if config.MWReplicaSetEnabled {
 		hubResources = append(hubResources, mwReplicaSetResourceFiles...)
 	}
+
+	if config.ImportManifestsEnabled {
+		hubResources = append(hubResources, importManifestsResourceFiles...)
+	}
 	// the hubHostedWebhookServiceFiles are only used in hosted mode
 	if mode == operatorapiv1.InstallModeHosted {
 		hubResources = append(hubResources, hubHostedWebhookServiceFiles...)
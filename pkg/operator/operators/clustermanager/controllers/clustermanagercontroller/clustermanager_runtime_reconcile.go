@@ -117,7 +117,16 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		}
 	}
 
+	// Invalid user managed fields are ignored and reported in the condition `ValidUserManagedFields`.
+	userManagedFields, userManagedFieldsMsg := helpers.ConvertToUserManagedFields(cm.Annotations)
+	if _, ok := cm.Annotations[helpers.UserManagedFieldsAnnotation]; ok {
+		meta.SetStatusCondition(&cm.Status.Conditions, helpers.BuildUserManagedFieldsCondition(userManagedFieldsMsg))
+	} else {
+		meta.RemoveStatusCondition(&cm.Status.Conditions, helpers.UserManagedFieldsTypeValid)
+	}
+
 	var progressingDeployments []string
+	var drifts []helpers.DeploymentDrift
 	deployResources := deploymentFiles
 	if config.AddOnManagerEnabled {
 		deployResources = append(deployResources, addOnManagerDeploymentFiles...)
@@ -126,11 +135,12 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		deployResources = append(deployResources, mwReplicaSetDeploymentFiles...)
 	}
 	for _, file := range deployResources {
-		updatedDeployment, currentGeneration, err := helpers.ApplyDeployment(
+		updatedDeployment, currentGeneration, drift, err := helpers.ApplyDeployment(
 			ctx,
 			c.kubeClient,
 			cm.Status.Generations,
 			cm.Spec.NodePlacement,
+			userManagedFields,
 			func(name string) ([]byte, error) {
 				template, err := manifests.ClusterManagerManifestFiles.ReadFile(name)
 				if err != nil {
@@ -147,12 +157,18 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 			continue
 		}
 		helpers.SetGenerationStatuses(&cm.Status.Generations, currentGeneration)
+		if drift != nil {
+			drifts = append(drifts, *drift)
+		}
 
 		if updatedDeployment.Generation != updatedDeployment.Status.ObservedGeneration || *updatedDeployment.Spec.Replicas != updatedDeployment.Status.ReadyReplicas {
 			progressingDeployments = append(progressingDeployments, updatedDeployment.Name)
 		}
 	}
 
+	// the condition records the last drift reverted, it is true for a while after the drift is reverted.
+	helpers.SetDriftRevertedCondition(&cm.Status.Conditions, drifts)
+
 	if len(progressingDeployments) > 0 {
 		meta.SetStatusCondition(&cm.Status.Conditions, metav1.Condition{
 			Type:    clusterManagerProgressing,
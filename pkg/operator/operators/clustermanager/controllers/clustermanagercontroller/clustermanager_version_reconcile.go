@@ -0,0 +1,61 @@
+/*
+ * Copyright 2022 Contributors to the Open Cluster Management project
+ */
+
+package clustermanagercontroller
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	"open-cluster-management.io/ocm/manifests"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// versionReconcile publishes the bundle version of the hub components on the hub cluster once all of them
+// finish upgrading. The klusterlet operators hold the agents from upgrading to a newer bundle version than it.
+type versionReconcile struct {
+	hubKubeClient kubernetes.Interface
+	bundleVersion string
+	recorder      events.Recorder
+}
+
+func (c *versionReconcile) reconcile(ctx context.Context, cm *operatorapiv1.ClusterManager,
+	config manifests.HubConfig) (*operatorapiv1.ClusterManager, reconcileState, error) {
+	if len(c.bundleVersion) == 0 {
+		return cm, reconcileContinue, nil
+	}
+
+	// the deployments are still rolling, the hub components are not upgraded yet.
+	if !meta.IsStatusConditionFalse(cm.Status.Conditions, clusterManagerProgressing) {
+		return cm, reconcileContinue, nil
+	}
+
+	_, _, err := resourceapply.ApplyConfigMap(ctx, c.hubKubeClient.CoreV1(), c.recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      registrationhelpers.HubVersionConfigMap,
+			Namespace: config.ClusterManagerNamespace,
+		},
+		Data: map[string]string{
+			registrationhelpers.HubBundleVersionKey: c.bundleVersion,
+		},
+	})
+	if err != nil {
+		return cm, reconcileStop, err
+	}
+	return cm, reconcileContinue, nil
+}
+
+func (c *versionReconcile) clean(ctx context.Context, cm *operatorapiv1.ClusterManager,
+	config manifests.HubConfig) (*operatorapiv1.ClusterManager, reconcileState, error) {
+	// the configmap is removed together with the namespace on the hub cluster
+	return cm, reconcileContinue, nil
+}
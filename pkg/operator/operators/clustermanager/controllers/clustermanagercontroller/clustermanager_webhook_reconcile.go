@@ -33,6 +33,7 @@ var (
 	}
 	hubWorkWebhookResourceFiles = []string{
 		"cluster-manager/hub/cluster-manager-work-webhook-validatingconfiguration.yaml",
+		"cluster-manager/hub/cluster-manager-work-webhook-mutatingconfiguration.yaml",
 	}
 )
 
@@ -24,6 +24,8 @@ import (
 
 type Options struct {
 	SkipRemoveCRDs bool
+	// ImportOperatorImage is the klusterlet operator image in the import manifests generated on the hub.
+	ImportOperatorImage string
 }
 
 // RunClusterManagerOperator starts a new cluster manager operator
@@ -74,7 +76,8 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 		kubeInformer.Apps().V1().Deployments(),
 		kubeInformer.Core().V1().ConfigMaps(),
 		controllerContext.EventRecorder,
-		o.SkipRemoveCRDs)
+		o.SkipRemoveCRDs,
+		o.ImportOperatorImage)
 
 	statusController := clustermanagerstatuscontroller.NewClusterManagerStatusController(
 		operatorClient.OperatorV1().ClusterManagers(),
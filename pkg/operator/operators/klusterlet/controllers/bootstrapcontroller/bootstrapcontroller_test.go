@@ -101,6 +101,20 @@ func TestSync(t *testing.T) {
 				testingcommon.AssertDelete(t, actions[2], "deployments", "test", "test-work-agent")
 			},
 		},
+		{
+			name:     "the bootstrap secret is changed in singleton mode",
+			queueKey: "test/test",
+			objects: []runtime.Object{
+				newSecret("bootstrap-hub-kubeconfig", "test", newKubeConfig("https://10.0.118.48:6443")),
+				newHubKubeConfigSecret("test", time.Now().Add(60*time.Second).UTC()),
+				newDeployment("test-agent", "test"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete", "delete", "delete", "delete")
+				testingcommon.AssertDelete(t, actions[0], "secrets", "test", "hub-kubeconfig-secret")
+				testingcommon.AssertDelete(t, actions[3], "deployments", "test", "test-agent")
+			},
+		},
 	}
 
 	for _, c := range cases {
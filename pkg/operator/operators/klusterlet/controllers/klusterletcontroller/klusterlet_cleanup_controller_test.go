@@ -46,9 +46,9 @@ func TestSyncDelete(t *testing.T) {
 		}
 	}
 
-	// 11 managed static manifests + 11 management static manifests + 1 hub kubeconfig + 2 namespaces + 2 deployments
-	if len(deleteActions) != 27 {
-		t.Errorf("Expected 27 delete actions, but got %d", len(deleteActions))
+	// 11 managed static manifests + 11 management static manifests + 1 hub kubeconfig + 2 namespaces + 3 deployments
+	if len(deleteActions) != 30 {
+		t.Errorf("Expected 30 delete actions, but got %d", len(deleteActions))
 	}
 
 	var updateWorkActions []clienttesting.PatchActionImpl
@@ -105,9 +105,9 @@ func TestSyncDeleteHosted(t *testing.T) {
 	}
 
 	// 11 static manifests + 3 secrets(hub-kubeconfig-secret, external-managed-kubeconfig-registration,external-managed-kubeconfig-work)
-	// + 2 deployments(registration-agent,work-agent) + 1 namespace
-	if len(deleteActionsManagement) != 17 {
-		t.Errorf("Expected 17 delete actions, but got %d", len(deleteActionsManagement))
+	// + 3 deployments(registration-agent,work-agent,agent) + 1 namespace
+	if len(deleteActionsManagement) != 18 {
+		t.Errorf("Expected 18 delete actions, but got %d", len(deleteActionsManagement))
 	}
 
 	var deleteActionsManaged []clienttesting.DeleteActionImpl
@@ -120,8 +120,8 @@ func TestSyncDeleteHosted(t *testing.T) {
 	}
 
 	// 11 static manifests + 2 namespaces
-	if len(deleteActionsManaged) != 13 {
-		t.Errorf("Expected 13 delete actions, but got %d", len(deleteActionsManaged))
+	if len(deleteActionsManaged) != 15 {
+		t.Errorf("Expected 15 delete actions, but got %d", len(deleteActionsManaged))
 	}
 
 	var updateWorkActions []clienttesting.PatchActionImpl
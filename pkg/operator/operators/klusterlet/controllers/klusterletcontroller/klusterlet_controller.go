@@ -3,7 +3,9 @@ package klusterletcontroller
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -15,6 +17,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/version"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
@@ -29,8 +32,10 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 	operatorapiv1 "open-cluster-management.io/api/operator/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/namespaces"
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
+	ocmversion "open-cluster-management.io/ocm/pkg/version"
 )
 
 const (
@@ -45,6 +50,54 @@ const (
 	hubKubeConfigSecretMissing            = "HubKubeConfigSecretMissing" // #nosec G101
 	appliedManifestWorkFinalizer          = "cluster.open-cluster-management.io/applied-manifest-work-cleanup"
 	managedResourcesEvictionTimestampAnno = "operator.open-cluster-management.io/managed-resources-eviction-timestamp"
+
+	// hubCABundleConfigMapAnno is the annotation on the klusterlet to reference a configmap in the agent namespace.
+	// The CA bundle in the configmap is mounted into the registration agent and appended to the CA data of the
+	// bootstrap kubeconfig to verify the hub kube-apiserver.
+	hubCABundleConfigMapAnno = "operator.open-cluster-management.io/hub-ca-bundle-configmap"
+	// hubCABundleKey is the key of the CA bundle in the configmap referenced by hubCABundleConfigMapAnno
+	hubCABundleKey = "ca-bundle.crt"
+
+	// clusterLeaseNamespaceAnno and clusterLeaseNameAnno are the annotations on the klusterlet to set the namespace
+	// and name of the cluster lease renewed by the registration agent on the hub. The agent must be granted to get,
+	// create and update leases in the namespace on the hub.
+	clusterLeaseNamespaceAnno = "operator.open-cluster-management.io/cluster-lease-namespace"
+	clusterLeaseNameAnno      = "operator.open-cluster-management.io/cluster-lease-name"
+
+	// nodeLabelClaimKeysAnno is the annotation on the klusterlet to set the node label keys, separated by commas,
+	// whose values on the nodes are exposed as cluster claims by the registration agent.
+	nodeLabelClaimKeysAnno = "operator.open-cluster-management.io/node-label-claim-keys"
+	// klusterletNodeLabelClaimKeysValid is the condition type of the klusterlet indicating whether the node label
+	// claim keys are valid, the invalid ones are not rendered to the registration agent.
+	klusterletNodeLabelClaimKeysValid = "ValidNodeLabelClaimKeys"
+
+	// protectedNamespacesAnno is the annotation on the klusterlet to set the names or the regular expressions,
+	// separated by commas, of the namespaces the work agent never applies the manifests into.
+	protectedNamespacesAnno = "operator.open-cluster-management.io/protected-namespaces"
+	// klusterletProtectedNamespacesValid is the condition type of the klusterlet indicating whether the protected
+	// namespaces are valid, the invalid ones are not rendered to the work agent.
+	klusterletProtectedNamespacesValid = "ValidProtectedNamespaces"
+
+	// klusterletHoldingUpgrade is the condition type of the klusterlet indicating whether the agents are held
+	// from upgrading to a newer bundle version than the hub components.
+	klusterletHoldingUpgrade = "HoldingUpgrade"
+	// forceUpgradeAnno is the annotation on the klusterlet to bypass the upgrade hold when it is set to "true".
+	forceUpgradeAnno = "operator.open-cluster-management.io/force-upgrade"
+	// agentBundleVersionAnno is the annotation on the agent deployments with the bundle version they are
+	// rendered with, so the upgrade hold knows the bundle version the agents are running.
+	agentBundleVersionAnno = "operator.open-cluster-management.io/bundle-version"
+
+	// observeOnlyAnno is the annotation on the klusterlet to run the cluster observe-only when it is set to "true".
+	// The work agent is not deployed, and the registration agent annotates the managed cluster so the hub never
+	// grants the agent to access the manifestworks of the cluster.
+	observeOnlyAnno = "operator.open-cluster-management.io/observe-only"
+
+	// kubeVersionRecheckInterval is the interval to check the kube version of the managed cluster again
+	// if it is not supported.
+	kubeVersionRecheckInterval = 5 * time.Minute
+
+	// hubClusterAnnotationsTTL is the duration the annotations of the managed cluster on the hub are cached.
+	hubClusterAnnotationsTTL = 5 * time.Minute
 )
 
 type klusterletController struct {
@@ -56,6 +109,13 @@ type klusterletController struct {
 	skipHubSecretPlaceholder     bool
 	cache                        resourceapply.ResourceCache
 	managedClusterClientsBuilder managedClusterClientsBuilderInterface
+	// bundleVersion is the version of the bundle the agents are rolled to.
+	bundleVersion string
+	// For testcases which don't need to connect to the hub, we could set a fake func
+	getHubClusterAnnotations func(ctx context.Context, kubeClient kubernetes.Interface, namespace string) (map[string]string, error)
+	// getKubeVersion discovers the kube version of the managed cluster before each apply pass, the kubeVersion
+	// discovered at startup is used if it is not set.
+	getKubeVersion func(kubeClient kubernetes.Interface) (*version.Version, error)
 }
 
 type klusterletReconcile interface {
@@ -89,10 +149,13 @@ func NewKlusterletController(
 			*operatorapiv1.Klusterlet, operatorapiv1.KlusterletSpec, operatorapiv1.KlusterletStatus](klusterletClient),
 		klusterletLister:             klusterletInformer.Lister(),
 		kubeVersion:                  kubeVersion,
+		getKubeVersion:               helpers.GetKubeVersion,
 		operatorNamespace:            operatorNamespace,
 		skipHubSecretPlaceholder:     skipHubSecretPlaceholder,
 		cache:                        resourceapply.NewResourceCache(),
 		managedClusterClientsBuilder: newManagedClusterClientsBuilder(kubeClient, apiExtensionClient, appliedManifestWorkClient),
+		bundleVersion:                ocmversion.Get().GitVersion,
+		getHubClusterAnnotations:     newHubClusterAnnotationsCache(hubClusterAnnotationsTTL).get,
 	}
 
 	return factory.New().WithSync(controller.sync).
@@ -141,8 +204,51 @@ type klusterletConfig struct {
 
 	RegistrationFeatureGates []string
 	WorkFeatureGates         []string
+	// SingletonWorkFeatureGates are the work feature gates set with the flag --work-feature-gates of the
+	// singleton agent.
+	SingletonWorkFeatureGates []string
 
 	HubApiServerHostAlias *operatorapiv1.HubApiServerHostAlias
+
+	// HubCABundleConfigMap is the name of the configmap containing a customized CA bundle of the hub, and
+	// HubCABundleHash is the hash of the CA bundle to roll the agents once the CA bundle is changed.
+	HubCABundleConfigMap string
+	HubCABundleHash      string
+
+	// ClusterLeaseNamespace and ClusterLeaseName are the location of the cluster lease on the hub, the cluster
+	// namespace and the default lease name are used if they are empty.
+	ClusterLeaseNamespace string
+	ClusterLeaseName      string
+
+	// NodeLabelClaimKeys are the node label keys separated by commas whose values are exposed as cluster claims.
+	NodeLabelClaimKeys string
+
+	// ObserveOnly is true if the work agent is not deployed and the registration agent runs observe-only.
+	ObserveOnly bool
+
+	// ProtectedNamespaces are the valid namespace patterns the work agent never applies the manifests into, each
+	// of them is rendered in a separate arg.
+	ProtectedNamespaces []string
+
+	// AppliedManifestWorkEvictionGracePeriod is the appliedmanifestwork eviction grace period of the work agent
+	// tuned from the hub in the annotation of the managed cluster. The default of the work agent is used if it is
+	// empty.
+	AppliedManifestWorkEvictionGracePeriod string
+
+	// KlusterletGeneration is the generation of the klusterlet the agents are rendered from.
+	KlusterletGeneration int64
+
+	// BundleVersion is the bundle version the agents are rendered with, it is the bundle version the running
+	// agents are rendered with while the upgrade is held.
+	BundleVersion string
+
+	// RegistrationLogLevel and WorkLogLevel are the log verbosity of the agents, and AgentLogLevel is the higher
+	// one of them for the singleton agent. The default verbosity is used if it is empty.
+	RegistrationLogLevel string
+	WorkLogLevel         string
+	AgentLogLevel        string
+	// LogLevelConfigMap is the ConfigMap in the agent namespace to change the log verbosity of the agents at runtime.
+	LogLevelConfigMap string
 }
 
 func (n *klusterletController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
@@ -177,6 +283,12 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		ExternalManagedKubeConfigWorkSecret:         helpers.ExternalManagedKubeConfigWork,
 		InstallMode:                                 klusterlet.Spec.DeployOption.Mode,
 		HubApiServerHostAlias:                       klusterlet.Spec.HubApiServerHostAlias,
+		HubCABundleConfigMap:                        klusterlet.Annotations[hubCABundleConfigMapAnno],
+		ClusterLeaseNamespace:                       klusterlet.Annotations[clusterLeaseNamespaceAnno],
+		ClusterLeaseName:                            klusterlet.Annotations[clusterLeaseNameAnno],
+		ObserveOnly:                                 klusterlet.Annotations[observeOnlyAnno] == "true",
+		KlusterletGeneration:                        klusterlet.Generation,
+		LogLevelConfigMap:                           helpers.KlusterletLogLevelConfigMap,
 	}
 
 	managedClusterClients, err := n.managedClusterClientsBuilder.
@@ -238,18 +350,88 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		workFeatureGates = klusterlet.Spec.WorkConfiguration.FeatureGates
 	}
 	config.WorkFeatureGates, workFeatureMsgs = helpers.ConvertToFeatureGateFlags("Work", workFeatureGates, ocmfeature.DefaultSpokeWorkFeatureGates)
+	for _, flag := range config.WorkFeatureGates {
+		config.SingletonWorkFeatureGates = append(config.SingletonWorkFeatureGates,
+			strings.Replace(flag, "--feature-gates=", "--work-feature-gates=", 1))
+	}
 	meta.SetStatusCondition(&klusterlet.Status.Conditions, helpers.BuildFeatureCondition(registrationFeatureMsgs, workFeatureMsgs))
 
+	// Invalid log levels are replaced by the default verbosity and reported in the condition `ValidLogLevels`.
+	var registrationLogLevelMsg, workLogLevelMsg string
+	config.RegistrationLogLevel, registrationLogLevelMsg = helpers.ConvertToLogLevel(helpers.RegistrationComponent, klusterlet.Annotations)
+	config.WorkLogLevel, workLogLevelMsg = helpers.ConvertToLogLevel(helpers.WorkComponent, klusterlet.Annotations)
+	config.AgentLogLevel = higherLogLevel(config.RegistrationLogLevel, config.WorkLogLevel)
+	if helpers.HasLogLevelAnnotation(klusterlet.Annotations, helpers.RegistrationComponent, helpers.WorkComponent) {
+		logLevelCondition := helpers.BuildLogLevelCondition(registrationLogLevelMsg, workLogLevelMsg)
+		if logLevelCondition.Status == metav1.ConditionFalse {
+			controllerContext.Recorder().Warning(logLevelCondition.Reason, logLevelCondition.Message)
+		}
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, logLevelCondition)
+	} else {
+		meta.RemoveStatusCondition(&klusterlet.Status.Conditions, helpers.LogLevelsTypeValid)
+	}
+
+	// Invalid user managed fields are ignored and reported in the condition `ValidUserManagedFields`.
+	if _, ok := klusterlet.Annotations[helpers.UserManagedFieldsAnnotation]; ok {
+		_, userManagedFieldsMsg := helpers.ConvertToUserManagedFields(klusterlet.Annotations)
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, helpers.BuildUserManagedFieldsCondition(userManagedFieldsMsg))
+	} else {
+		meta.RemoveStatusCondition(&klusterlet.Status.Conditions, helpers.UserManagedFieldsTypeValid)
+	}
+
+	// Invalid protected namespaces are ignored and reported in the condition `ValidProtectedNamespaces`.
+	if value, ok := klusterlet.Annotations[protectedNamespacesAnno]; ok {
+		var cond metav1.Condition
+		config.ProtectedNamespaces, cond = convertProtectedNamespaces(value)
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, cond)
+	} else {
+		meta.RemoveStatusCondition(&klusterlet.Status.Conditions, klusterletProtectedNamespacesValid)
+	}
+
+	// Invalid node label claim keys are ignored and reported in the condition `ValidNodeLabelClaimKeys`, since the
+	// registration agent refuses to start with them.
+	if value, ok := klusterlet.Annotations[nodeLabelClaimKeysAnno]; ok {
+		var cond metav1.Condition
+		config.NodeLabelClaimKeys, cond = convertNodeLabelClaimKeys(value)
+		if cond.Status == metav1.ConditionFalse {
+			controllerContext.Recorder().Warning(cond.Reason, cond.Message)
+		}
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, cond)
+	} else {
+		meta.RemoveStatusCondition(&klusterlet.Status.Conditions, klusterletNodeLabelClaimKeysValid)
+	}
+
+	// The manifests are rendered with the kube version of the managed cluster, it is discovered before each apply
+	// pass since the managed cluster could be upgraded, or be a different cluster in the hosted mode.
+	kubeVersion := n.kubeVersion
+	if n.getKubeVersion != nil {
+		kubeVersion, err = n.getKubeVersion(managedClusterClients.kubeClient)
+		if err != nil {
+			return err
+		}
+	}
+	if err := helpers.CheckKubeVersion(kubeVersion, helpers.MinimumKlusterletKubeVersion); err != nil {
+		// Do not apply the manifests which would fail on the unsupported cluster, and check again later.
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: helpers.KubeVersionDegraded, Status: metav1.ConditionTrue, Reason: helpers.KubeVersionReasonUnsupported,
+			Message: err.Error(),
+		})
+		controllerContext.Queue().AddAfter(klusterletName, kubeVersionRecheckInterval)
+		_, updatedErr := n.patcher.PatchStatus(ctx, klusterlet, klusterlet.Status, originalKlusterlet.Status)
+		return updatedErr
+	}
+	meta.RemoveStatusCondition(&klusterlet.Status.Conditions, helpers.KubeVersionDegraded)
+
 	reconcilers := []klusterletReconcile{
 		&crdReconcile{
 			managedClusterClients: managedClusterClients,
-			kubeVersion:           n.kubeVersion,
+			kubeVersion:           kubeVersion,
 			recorder:              controllerContext.Recorder(),
 			cache:                 n.cache},
 		&managedReconcile{
 			managedClusterClients: managedClusterClients,
 			kubeClient:            n.kubeClient,
-			kubeVersion:           n.kubeVersion,
+			kubeVersion:           kubeVersion,
 			opratorNamespace:      n.operatorNamespace,
 			recorder:              controllerContext.Recorder(),
 			cache:                 n.cache},
@@ -259,10 +441,12 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 			recorder:          controllerContext.Recorder(),
 			cache:             n.cache},
 		&runtimeReconcile{
-			managedClusterClients: managedClusterClients,
-			kubeClient:            n.kubeClient,
-			recorder:              controllerContext.Recorder(),
-			cache:                 n.cache},
+			managedClusterClients:    managedClusterClients,
+			kubeClient:               n.kubeClient,
+			recorder:                 controllerContext.Recorder(),
+			cache:                    n.cache,
+			bundleVersion:            n.bundleVersion,
+			getHubClusterAnnotations: n.getHubClusterAnnotations},
 	}
 
 	var errs []error
@@ -289,6 +473,11 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		klusterlet.Status.Generations = originalKlusterlet.Status.Generations
 	}
 
+	// the klusterlet is not resynced, so it is requeued to set the DriftReverted condition to false once it expires.
+	if expiresIn := helpers.DriftRevertedExpiresIn(klusterlet.Status.Conditions); expiresIn > 0 {
+		controllerContext.Queue().AddAfter(klusterletName, expiresIn)
+	}
+
 	// If we get here, we have successfully applied everything.
 	_, updatedErr := n.patcher.PatchStatus(ctx, klusterlet, klusterlet.Status, originalKlusterlet.Status)
 	if updatedErr != nil {
@@ -387,3 +576,71 @@ func ensureNamespace(ctx context.Context, kubeClient kubernetes.Interface, klust
 
 	return nil
 }
+
+// convertProtectedNamespaces returns the valid namespace patterns of the annotation, and the condition reporting
+// the invalid ones. The quotes are not allowed since the patterns are rendered in a quoted arg.
+func convertProtectedNamespaces(value string) ([]string, metav1.Condition) {
+	var valid, invalid []string
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if err := namespaces.ValidatePattern(pattern); err != nil || strings.ContainsAny(pattern, `'"`) {
+			invalid = append(invalid, fmt.Sprintf("%q", pattern))
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+
+	if len(invalid) > 0 {
+		return valid, metav1.Condition{
+			Type: klusterletProtectedNamespacesValid, Status: metav1.ConditionFalse, Reason: "InvalidProtectedNamespaces",
+			Message: fmt.Sprintf("The protected namespaces %s are not valid regular expressions and are ignored",
+				strings.Join(invalid, ", ")),
+		}
+	}
+	return valid, metav1.Condition{
+		Type: klusterletProtectedNamespacesValid, Status: metav1.ConditionTrue, Reason: "ProtectedNamespacesValid",
+		Message: "The protected namespaces are all valid",
+	}
+}
+
+// convertNodeLabelClaimKeys returns the valid node label keys of the annotation separated by commas, and the
+// condition reporting the invalid ones.
+func convertNodeLabelClaimKeys(value string) (string, metav1.Condition) {
+	var valid, invalid []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if len(validation.IsQualifiedName(key)) > 0 {
+			invalid = append(invalid, fmt.Sprintf("%q", key))
+			continue
+		}
+		valid = append(valid, key)
+	}
+
+	if len(invalid) > 0 {
+		return strings.Join(valid, ","), metav1.Condition{
+			Type: klusterletNodeLabelClaimKeysValid, Status: metav1.ConditionFalse, Reason: "InvalidNodeLabelClaimKeys",
+			Message: fmt.Sprintf("The node label claim keys %s are not valid label keys and are ignored",
+				strings.Join(invalid, ", ")),
+		}
+	}
+	return strings.Join(valid, ","), metav1.Condition{
+		Type: klusterletNodeLabelClaimKeysValid, Status: metav1.ConditionTrue, Reason: "NodeLabelClaimKeysValid",
+		Message: "The node label claim keys are all valid",
+	}
+}
+
+// higherLogLevel returns the higher one of the valid log levels, the empty log level is the lowest.
+func higherLogLevel(a, b string) string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	aLevel, _ := strconv.Atoi(a)
+	bLevel, _ := strconv.Atoi(b)
+	if aLevel >= bLevel {
+		return a
+	}
+	return b
+}
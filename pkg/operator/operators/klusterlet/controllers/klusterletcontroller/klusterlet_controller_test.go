@@ -19,7 +19,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	clienttesting "k8s.io/client-go/testing"
@@ -27,6 +31,7 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
 	"k8s.io/klog/v2"
+	testingclock "k8s.io/utils/clock/testing"
 
 	fakeoperatorclient "open-cluster-management.io/api/client/operator/clientset/versioned/fake"
 	operatorinformers "open-cluster-management.io/api/client/operator/informers/externalversions"
@@ -38,6 +43,7 @@ import (
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
 	testinghelper "open-cluster-management.io/ocm/pkg/operator/helpers/testing"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 type testController struct {
@@ -356,6 +362,7 @@ func assertRegistrationDeployment(t *testing.T, actions []clienttesting.Action,
 	expectedArgs := []string{
 		"/registration",
 		"agent",
+		"--log-level-configmap=" + helpers.KlusterletLogLevelConfigMap,
 		fmt.Sprintf("--spoke-cluster-name=%s", clusterName),
 		"--bootstrap-kubeconfig=/spoke/bootstrap/kubeconfig",
 		"--feature-gates=AddonManagement=true",
@@ -396,6 +403,7 @@ func assertWorkDeployment(t *testing.T, actions []clienttesting.Action, verb, cl
 	expectArgs := []string{
 		"/work",
 		"agent",
+		"--log-level-configmap=" + helpers.KlusterletLogLevelConfigMap,
 		fmt.Sprintf("--spoke-cluster-name=%s", clusterName),
 		"--hub-kubeconfig=/spoke/hub-kubeconfig/kubeconfig",
 		"--agent-id=",
@@ -447,6 +455,14 @@ func ensureObject(t *testing.T, object runtime.Object, klusterlet *operatorapiv1
 				t.Errorf("Image does not match to the expected.")
 				return
 			}
+		} else if access.GetName() == fmt.Sprintf("%s-agent", klusterlet.Name) {
+			testingcommon.AssertEqualNameNamespace(
+				t, access.GetName(), access.GetNamespace(),
+				fmt.Sprintf("%s-agent", klusterlet.Name), namespace)
+			if klusterlet.Spec.RegistrationImagePullSpec != o.Spec.Template.Spec.Containers[0].Image {
+				t.Errorf("Image does not match to the expected.")
+				return
+			}
 		} else {
 			t.Errorf("Unexpected deployment")
 			return
@@ -481,8 +497,8 @@ func TestSyncDeploy(t *testing.T) {
 
 	// Check if resources are created as expected
 	// 11 managed static manifests + 11 management static manifests - 2 duplicated service account manifests + 1 addon namespace + 2 deployments
-	if len(createObjects) != 23 {
-		t.Errorf("Expect 23 objects created in the sync loop, actual %d", len(createObjects))
+	if len(createObjects) != 25 {
+		t.Errorf("Expect 25 objects created in the sync loop, actual %d", len(createObjects))
 	}
 	for _, object := range createObjects {
 		ensureObject(t, object, klusterlet)
@@ -568,8 +584,8 @@ func TestSyncDeployHosted(t *testing.T) {
 	}
 	// Check if resources are created as expected on the managed cluster
 	// 11 static manifests + 2 namespaces + 1 pull secret in the addon namespace
-	if len(createObjectsManaged) != 14 {
-		t.Errorf("Expect 14 objects created in the sync loop, actual %d", len(createObjectsManaged))
+	if len(createObjectsManaged) != 16 {
+		t.Errorf("Expect 16 objects created in the sync loop, actual %d", len(createObjectsManaged))
 	}
 	for _, object := range createObjectsManaged {
 		ensureObject(t, object, klusterlet)
@@ -841,6 +857,712 @@ func TestClusterNameChange(t *testing.T) {
 	assertWorkDeployment(t, controller.kubeClient.Actions(), "update", "cluster3", "", 0)
 }
 
+func TestSyncDeployWithHubCABundle(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{hubCABundleConfigMapAnno: "hub-ca"}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	namespace := newNamespace("testns")
+	caBundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub-ca", Namespace: "testns"},
+		Data:       map[string]string{hubCABundleKey: "custom-ca"},
+	}
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace, caBundle)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	deployment := getDeployments(controller.kubeClient.Actions(), "create", "registration-agent")
+	if deployment == nil {
+		t.Fatalf("registration deployment not found")
+	}
+	podSpec := deployment.Spec.Template.Spec
+
+	expectedHostAliases := []corev1.HostAlias{{IP: "11.22.33.44", Hostnames: []string{"open-cluster-management.io"}}}
+	if !equality.Semantic.DeepEqual(podSpec.HostAliases, expectedHostAliases) {
+		t.Errorf("Expect host aliases %v, but got %v", expectedHostAliases, podSpec.HostAliases)
+	}
+
+	hash := sha256.Sum256([]byte("custom-ca"))
+	if deployment.Spec.Template.Annotations["operator.open-cluster-management.io/hub-ca-bundle-hash"] != fmt.Sprintf("%x", hash) {
+		t.Errorf("Unexpected hub ca bundle hash annotation %v", deployment.Spec.Template.Annotations)
+	}
+
+	found := false
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == "hub-ca-bundle" && volume.ConfigMap != nil && volume.ConfigMap.Name == "hub-ca" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expect hub ca bundle volume, but got %v", podSpec.Volumes)
+	}
+
+	found = false
+	for _, mount := range podSpec.Containers[0].VolumeMounts {
+		if mount.Name == "hub-ca-bundle" && mount.MountPath == "/spoke/hub-ca-bundle" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expect hub ca bundle volume mount, but got %v", podSpec.Containers[0].VolumeMounts)
+	}
+
+	found = false
+	for _, arg := range podSpec.Containers[0].Args {
+		if arg == "--hub-ca-bundle-file=/spoke/hub-ca-bundle/ca-bundle.crt" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expect hub ca bundle file arg, but got %v", podSpec.Containers[0].Args)
+	}
+}
+
+func TestSyncDeployWithClusterLease(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{
+		clusterLeaseNamespaceAnno: "cloud-managed",
+		clusterLeaseNameAnno:      "cluster-heartbeat",
+	}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	namespace := newNamespace("testns")
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	deployment := getDeployments(controller.kubeClient.Actions(), "create", "registration-agent")
+	if deployment == nil {
+		t.Fatalf("registration deployment not found")
+	}
+	args := sets.New[string](deployment.Spec.Template.Spec.Containers[0].Args...)
+	if !args.HasAll("--cluster-lease-namespace=cloud-managed", "--cluster-lease-name=cluster-heartbeat") {
+		t.Errorf("Expect cluster lease args, but got %v", deployment.Spec.Template.Spec.Containers[0].Args)
+	}
+}
+
+func TestSyncDeployWithNodeLabelClaimKeys(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{
+		nodeLabelClaimKeysAnno: "topology.kubernetes.io/zone, node.kubernetes.io/instance-type,zone/",
+	}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	namespace := newNamespace("testns")
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	deployment := getDeployments(controller.kubeClient.Actions(), "create", "registration-agent")
+	if deployment == nil {
+		t.Fatalf("registration deployment not found")
+	}
+	args := sets.New[string](deployment.Spec.Template.Spec.Containers[0].Args...)
+	// the invalid key is ignored
+	if !args.Has("--node-label-claim-keys=topology.kubernetes.io/zone,node.kubernetes.io/instance-type") {
+		t.Errorf("Expect node label claim keys arg, but got %v", deployment.Spec.Template.Spec.Containers[0].Args)
+	}
+
+	operatorAction := controller.operatorClient.Actions()
+	testingcommon.AssertActions(t, operatorAction, "patch")
+	klusterlet = &operatorapiv1.Klusterlet{}
+	if err := json.Unmarshal(operatorAction[0].(clienttesting.PatchActionImpl).Patch, klusterlet); err != nil {
+		t.Fatal(err)
+	}
+	testinghelper.AssertOnlyConditions(
+		t, klusterlet,
+		testinghelper.NamedCondition(klusterletApplied, "KlusterletApplied", metav1.ConditionTrue),
+		testinghelper.NamedCondition(helpers.FeatureGatesTypeValid, helpers.FeatureGatesReasonAllValid, metav1.ConditionTrue),
+		testinghelper.NamedCondition(klusterletNodeLabelClaimKeysValid, "InvalidNodeLabelClaimKeys", metav1.ConditionFalse),
+	)
+}
+
+func TestSyncDeployObserveOnly(t *testing.T) {
+	cases := []struct {
+		name                string
+		observeOnly         bool
+		expectedWorkDeleted bool
+		expectedWorkCreated bool
+	}{
+		{
+			name:                "switch to observe-only",
+			observeOnly:         true,
+			expectedWorkDeleted: true,
+		},
+		{
+			name:                "switch from observe-only",
+			expectedWorkCreated: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if c.observeOnly {
+				klusterlet.Annotations = map[string]string{observeOnlyAnno: "true"}
+			}
+			bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			namespace := newNamespace("testns")
+			objects := []runtime.Object{bootStrapSecret, hubKubeConfigSecret, namespace}
+			if c.observeOnly {
+				objects = append(objects, newAgentDeployment("klusterlet-work-agent", "testns"))
+			}
+			controller := newTestController(t, klusterlet, nil, objects...)
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+			if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			kubeActions := controller.kubeClient.Actions()
+			deployment := getDeployments(kubeActions, "create", "registration-agent")
+			if deployment == nil {
+				t.Fatalf("registration deployment not found")
+			}
+			args := sets.New[string](deployment.Spec.Template.Spec.Containers[0].Args...)
+			if args.Has("--observe-only") != c.observeOnly {
+				t.Errorf("Expect observe-only arg %v, but got %v", c.observeOnly, deployment.Spec.Template.Spec.Containers[0].Args)
+			}
+
+			if created := getDeployments(kubeActions, "create", "work-agent") != nil; created != c.expectedWorkCreated {
+				t.Errorf("Expect work deployment created %v, but got %v", c.expectedWorkCreated, created)
+			}
+			_, err := controller.kubeClient.AppsV1().Deployments("testns").Get(
+				context.TODO(), "klusterlet-work-agent", metav1.GetOptions{})
+			if deleted := errors.IsNotFound(err); c.expectedWorkDeleted && !deleted {
+				t.Errorf("Expect work deployment deleted, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncDeployWithHubCABundleMissing(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{hubCABundleConfigMapAnno: "hub-ca"}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	namespace := newNamespace("testns")
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, namespace)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err == nil {
+		t.Errorf("Expected error when the hub ca bundle configmap is missing")
+	}
+
+	if deployment := getDeployments(controller.kubeClient.Actions(), "create", "registration-agent"); deployment != nil {
+		t.Errorf("Expect registration deployment is not created")
+	}
+}
+
+func TestSyncDeployWithLogLevels(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{
+		helpers.LogLevelAnnotation(helpers.RegistrationComponent): "4",
+		helpers.LogLevelAnnotation(helpers.WorkComponent):         "debug",
+	}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	namespace := newNamespace("testns")
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	hasVerbosityArg := func(deployment *appsv1.Deployment, arg string) bool {
+		for _, a := range deployment.Spec.Template.Spec.Containers[0].Args {
+			if strings.HasPrefix(a, "--v=") {
+				return a == arg
+			}
+		}
+		return false
+	}
+	registrationDeployment := getDeployments(controller.kubeClient.Actions(), "create", "registration-agent")
+	if registrationDeployment == nil || !hasVerbosityArg(registrationDeployment, "--v=4") {
+		t.Errorf("Expect registration deployment with arg --v=4, but got %v", registrationDeployment)
+	}
+	// the invalid log level of the work agent is ignored
+	workDeployment := getDeployments(controller.kubeClient.Actions(), "create", "work-agent")
+	if workDeployment == nil {
+		t.Fatalf("work deployment not found")
+	}
+	for _, arg := range workDeployment.Spec.Template.Spec.Containers[0].Args {
+		if strings.HasPrefix(arg, "--v=") {
+			t.Errorf("Expect no verbosity arg of the work agent, but got %s", arg)
+		}
+	}
+
+	operatorAction := controller.operatorClient.Actions()
+	testingcommon.AssertActions(t, operatorAction, "patch")
+	klusterlet = &operatorapiv1.Klusterlet{}
+	if err := json.Unmarshal(operatorAction[0].(clienttesting.PatchActionImpl).Patch, klusterlet); err != nil {
+		t.Fatal(err)
+	}
+	testinghelper.AssertOnlyConditions(
+		t, klusterlet,
+		testinghelper.NamedCondition(klusterletApplied, "KlusterletApplied", metav1.ConditionTrue),
+		testinghelper.NamedCondition(helpers.FeatureGatesTypeValid, helpers.FeatureGatesReasonAllValid, metav1.ConditionTrue),
+		testinghelper.NamedCondition(helpers.LogLevelsTypeValid, helpers.LogLevelsReasonInvalidExisting, metav1.ConditionFalse),
+	)
+}
+
+func TestSyncDeployWithProtectedNamespaces(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{
+		protectedNamespacesAnno: "kube-system, openshift-.*,openshift-(.*",
+	}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	namespace := newNamespace("testns")
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	// the invalid pattern is ignored
+	workDeployment := getDeployments(controller.kubeClient.Actions(), "create", "work-agent")
+	if workDeployment == nil {
+		t.Fatalf("work deployment not found")
+	}
+	args := sets.New[string](workDeployment.Spec.Template.Spec.Containers[0].Args...)
+	if !args.HasAll("--protected-namespaces=kube-system", "--protected-namespaces=openshift-.*") {
+		t.Errorf("Expect protected namespaces arg, but got %v", workDeployment.Spec.Template.Spec.Containers[0].Args)
+	}
+
+	operatorAction := controller.operatorClient.Actions()
+	testingcommon.AssertActions(t, operatorAction, "patch")
+	klusterlet = &operatorapiv1.Klusterlet{}
+	if err := json.Unmarshal(operatorAction[0].(clienttesting.PatchActionImpl).Patch, klusterlet); err != nil {
+		t.Fatal(err)
+	}
+	testinghelper.AssertOnlyConditions(
+		t, klusterlet,
+		testinghelper.NamedCondition(klusterletApplied, "KlusterletApplied", metav1.ConditionTrue),
+		testinghelper.NamedCondition(helpers.FeatureGatesTypeValid, helpers.FeatureGatesReasonAllValid, metav1.ConditionTrue),
+		testinghelper.NamedCondition(klusterletProtectedNamespacesValid, "InvalidProtectedNamespaces", metav1.ConditionFalse),
+	)
+}
+
+func TestSyncDeployWithHubEvictionGracePeriod(t *testing.T) {
+	cases := []struct {
+		name                  string
+		hubClusterAnnotations map[string]string
+		hubErr                error
+		expectedArg           string
+	}{
+		{
+			name:                  "eviction grace period is propagated",
+			hubClusterAnnotations: map[string]string{registrationhelpers.WorkAgentEvictionGracePeriodAnnotation: "1h30m0s"},
+			expectedArg:           "--appliedmanifestwork-eviction-grace-period=1h30m0s",
+		},
+		{
+			name: "eviction grace period is not set",
+		},
+		{
+			name:                  "invalid eviction grace period is ignored",
+			hubClusterAnnotations: map[string]string{registrationhelpers.WorkAgentEvictionGracePeriodAnnotation: "1h\" --v=10"},
+		},
+		{
+			name:                  "eviction grace period out of range is ignored",
+			hubClusterAnnotations: map[string]string{registrationhelpers.WorkAgentEvictionGracePeriodAnnotation: "1s"},
+		},
+		{
+			name:   "hub is not reachable",
+			hubErr: fmt.Errorf("connection refused"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			namespace := newNamespace("testns")
+			controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace)
+			controller.controller.getHubClusterAnnotations = func(
+				ctx context.Context, kubeClient kubernetes.Interface, namespace string) (map[string]string, error) {
+				return c.hubClusterAnnotations, c.hubErr
+			}
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+			err := controller.controller.sync(context.TODO(), syncContext)
+			if err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			workDeployment := getDeployments(controller.kubeClient.Actions(), "create", "work-agent")
+			if workDeployment == nil {
+				t.Fatalf("work deployment not found")
+			}
+			var gracePeriodArgs []string
+			for _, arg := range workDeployment.Spec.Template.Spec.Containers[0].Args {
+				if strings.HasPrefix(arg, "--appliedmanifestwork-eviction-grace-period") {
+					gracePeriodArgs = append(gracePeriodArgs, arg)
+				}
+			}
+			switch {
+			case len(c.expectedArg) == 0 && len(gracePeriodArgs) > 0:
+				t.Errorf("Expect no eviction grace period arg, but got %v", gracePeriodArgs)
+			case len(c.expectedArg) > 0 && (len(gracePeriodArgs) != 1 || gracePeriodArgs[0] != c.expectedArg):
+				t.Errorf("Expect arg %s, but got %v", c.expectedArg, gracePeriodArgs)
+			}
+		})
+	}
+}
+
+func newKlusterletSingleton(name, namespace, clustername string) *operatorapiv1.Klusterlet {
+	klusterlet := newKlusterlet(name, namespace, clustername)
+	klusterlet.Spec.DeployOption.Mode = helpers.InstallModeSingleton
+	klusterlet.Spec.WorkConfiguration = &operatorapiv1.WorkConfiguration{
+		FeatureGates: []operatorapiv1.FeatureGate{
+			{
+				Feature: "ExecutorValidatingCaches",
+				Mode:    "Enable",
+			},
+		},
+	}
+	return klusterlet
+}
+
+func newAgentDeployment(name, namespace string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+// TestSyncDeploySingleton test deployment of klusterlet components in singleton mode
+func TestSyncDeploySingleton(t *testing.T) {
+	klusterlet := newKlusterletSingleton("klusterlet", "testns", "cluster1")
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	namespace := newNamespace("testns")
+	controller := newTestController(t, klusterlet, nil, bootStrapSecret, namespace)
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	kubeActions := controller.kubeClient.Actions()
+	for _, action := range kubeActions {
+		if action.GetVerb() == "create" {
+			ensureObject(t, action.(clienttesting.CreateActionImpl).Object, klusterlet)
+		}
+	}
+
+	if deployment := getDeployments(kubeActions, "create", "registration-agent"); deployment != nil {
+		t.Errorf("Expect registration deployment is not created in singleton mode")
+	}
+	if deployment := getDeployments(kubeActions, "create", "work-agent"); deployment != nil {
+		t.Errorf("Expect work deployment is not created in singleton mode")
+	}
+
+	deployment := getDeployments(kubeActions, "create", "klusterlet-agent")
+	if deployment == nil {
+		t.Fatalf("singleton deployment not found")
+	}
+	if deployment.Spec.Template.Spec.ServiceAccountName != "klusterlet-registration-sa" {
+		t.Errorf("Unexpected service account %q", deployment.Spec.Template.Spec.ServiceAccountName)
+	}
+	expectedArgs := []string{
+		"/registration",
+		"klusterlet-agent",
+		"--log-level-configmap=" + helpers.KlusterletLogLevelConfigMap,
+		"--spoke-cluster-name=cluster1",
+		"--bootstrap-kubeconfig=/spoke/bootstrap/kubeconfig",
+		"--agent-id=",
+		"--feature-gates=AddonManagement=true",
+		"--work-feature-gates=ExecutorValidatingCaches=true",
+		"--terminate-on-files=/spoke/hub-kubeconfig/kubeconfig",
+	}
+	if *deployment.Spec.Replicas == 1 {
+		expectedArgs = append(expectedArgs, "--disable-leader-election")
+	}
+	if args := deployment.Spec.Template.Spec.Containers[0].Args; !equality.Semantic.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expect args %v, but got %v", expectedArgs, args)
+	}
+
+	// the work permissions are granted to the registration service account
+	binding, err := controller.kubeClient.RbacV1().ClusterRoleBindings().Get(
+		context.TODO(), "open-cluster-management:klusterlet-work:agent", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, subject := range binding.Subjects {
+		if subject.Name == "klusterlet-registration-sa" && subject.Namespace == "testns" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expect the registration service account is bound, but got %v", binding.Subjects)
+	}
+}
+
+func TestSyncSingletonTransition(t *testing.T) {
+	cases := []struct {
+		name               string
+		klusterlet         *operatorapiv1.Klusterlet
+		existing           []runtime.Object
+		expectedDeleted    []string
+		expectedCreated    []string
+		expectedNotCreated []string
+	}{
+		{
+			name:       "switch from default mode to singleton mode",
+			klusterlet: newKlusterletSingleton("klusterlet", "testns", "cluster1"),
+			existing: []runtime.Object{
+				newAgentDeployment("klusterlet-registration-agent", "testns"),
+				newAgentDeployment("klusterlet-work-agent", "testns"),
+			},
+			expectedDeleted:    []string{"klusterlet-registration-agent", "klusterlet-work-agent"},
+			expectedCreated:    []string{"klusterlet-agent"},
+			expectedNotCreated: []string{"registration-agent", "work-agent"},
+		},
+		{
+			name:       "switch from singleton mode to default mode",
+			klusterlet: newKlusterlet("klusterlet", "testns", "cluster1"),
+			existing: []runtime.Object{
+				newAgentDeployment("klusterlet-agent", "testns"),
+			},
+			expectedDeleted:    []string{"klusterlet-agent"},
+			expectedCreated:    []string{"registration-agent", "work-agent"},
+			expectedNotCreated: []string{"klusterlet-agent"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			hubKubeConfigSecret.Data["cluster-name"] = []byte("cluster1")
+			objects := append([]runtime.Object{bootStrapSecret, hubKubeConfigSecret, newNamespace("testns")}, c.existing...)
+			controller := newTestController(t, c.klusterlet, nil, objects...)
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+			if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			kubeActions := controller.kubeClient.Actions()
+			deleted := []string{}
+			for _, action := range kubeActions {
+				if action.GetVerb() != "delete" {
+					continue
+				}
+				deleteAction := action.(clienttesting.DeleteActionImpl)
+				if deleteAction.GetResource().Resource == "secrets" {
+					t.Errorf("Expect no secret is deleted, but %s is deleted", deleteAction.Name)
+				}
+				if deleteAction.GetResource().Resource == "deployments" {
+					if _, err := controller.kubeClient.AppsV1().Deployments("testns").Get(
+						context.TODO(), deleteAction.Name, metav1.GetOptions{}); errors.IsNotFound(err) {
+						deleted = append(deleted, deleteAction.Name)
+					}
+				}
+			}
+			for _, name := range c.expectedDeleted {
+				found := false
+				for _, d := range deleted {
+					if d == name {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("Expect deployment %s is deleted, but deleted %v", name, deleted)
+				}
+			}
+
+			for _, suffix := range c.expectedCreated {
+				if deployment := getDeployments(kubeActions, "create", suffix); deployment == nil {
+					t.Errorf("Expect deployment %s is created", suffix)
+				}
+			}
+			for _, suffix := range c.expectedNotCreated {
+				if deployment := getDeployments(kubeActions, "create", suffix); deployment != nil {
+					t.Errorf("Expect deployment %s is not created", suffix)
+				}
+			}
+
+			if _, err := controller.kubeClient.CoreV1().Secrets("testns").Get(
+				context.TODO(), helpers.HubKubeConfig, metav1.GetOptions{}); err != nil {
+				t.Errorf("Expect the hub kubeconfig secret is kept, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncDeployHoldUpgrade(t *testing.T) {
+	newRunningAgent := func(name, image, bundleVersion string) *appsv1.Deployment {
+		deployment := newAgentDeployment(name, "testns")
+		deployment.Annotations = map[string]string{agentBundleVersionAnno: bundleVersion}
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: name, Image: image}}
+		return deployment
+	}
+
+	cases := []struct {
+		name                      string
+		hubBundleVersion          string
+		agentBundleVersion        string
+		forceUpgrade              bool
+		expectedHold              bool
+		expectedReason            string
+		expectedVerb              string
+		expectedBundleVersion     string
+		expectedRegistrationImage string
+		expectedWorkImage         string
+	}{
+		{
+			name:                      "hub is behind",
+			hubBundleVersion:          "v0.12.0",
+			agentBundleVersion:        "v0.12.0",
+			expectedHold:              true,
+			expectedReason:            "HubBehind",
+			expectedVerb:              "update",
+			expectedBundleVersion:     "v0.12.0",
+			expectedRegistrationImage: "oldregistration",
+			expectedWorkImage:         "oldwork",
+		},
+		{
+			name:                      "hub is behind and the agents are not deployed",
+			hubBundleVersion:          "v0.12.0",
+			expectedReason:            "AgentsNotDeployed",
+			expectedVerb:              "create",
+			expectedBundleVersion:     "v0.13.0",
+			expectedRegistrationImage: "testregistration",
+			expectedWorkImage:         "testwork",
+		},
+		{
+			name:                      "hub is behind and the agents are upgraded",
+			hubBundleVersion:          "v0.12.0",
+			agentBundleVersion:        "v0.13.0",
+			expectedReason:            "AgentsUpToDate",
+			expectedVerb:              "update",
+			expectedBundleVersion:     "v0.13.0",
+			expectedRegistrationImage: "testregistration",
+			expectedWorkImage:         "testwork",
+		},
+		{
+			name:                      "hub is ahead",
+			hubBundleVersion:          "v0.14.0",
+			agentBundleVersion:        "v0.12.0",
+			expectedReason:            "HubUpToDate",
+			expectedVerb:              "update",
+			expectedBundleVersion:     "v0.13.0",
+			expectedRegistrationImage: "testregistration",
+			expectedWorkImage:         "testwork",
+		},
+		{
+			name:                      "hub bundle version is unknown",
+			agentBundleVersion:        "v0.12.0",
+			expectedReason:            "HubBundleVersionUnknown",
+			expectedVerb:              "update",
+			expectedBundleVersion:     "v0.13.0",
+			expectedRegistrationImage: "testregistration",
+			expectedWorkImage:         "testwork",
+		},
+		{
+			name:                      "force upgrade when hub is behind",
+			hubBundleVersion:          "v0.12.0",
+			agentBundleVersion:        "v0.12.0",
+			forceUpgrade:              true,
+			expectedReason:            "UpgradeForced",
+			expectedVerb:              "update",
+			expectedBundleVersion:     "v0.13.0",
+			expectedRegistrationImage: "testregistration",
+			expectedWorkImage:         "testwork",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if c.forceUpgrade {
+				klusterlet.Annotations = map[string]string{forceUpgradeAnno: "true"}
+			}
+			// the spec changed during the hold is still applied to the agents
+			klusterlet.Spec.NodePlacement = operatorapiv1.NodePlacement{NodeSelector: map[string]string{"node": "infra"}}
+			bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			namespace := newNamespace("testns")
+			objects := []runtime.Object{bootStrapSecret, hubKubeConfigSecret, namespace}
+			if len(c.agentBundleVersion) > 0 {
+				objects = append(objects,
+					newRunningAgent("klusterlet-registration-agent", "oldregistration", c.agentBundleVersion),
+					newRunningAgent("klusterlet-work-agent", "oldwork", c.agentBundleVersion))
+			}
+			controller := newTestController(t, klusterlet, nil, objects...)
+			controller.controller.bundleVersion = "v0.13.0"
+			controller.controller.getHubClusterAnnotations = func(
+				ctx context.Context, kubeClient kubernetes.Interface, namespace string) (map[string]string, error) {
+				return map[string]string{registrationhelpers.HubBundleVersionAnnotation: c.hubBundleVersion}, nil
+			}
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+			if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			for suffix, expectedImage := range map[string]string{
+				"registration-agent": c.expectedRegistrationImage,
+				"work-agent":         c.expectedWorkImage,
+			} {
+				deployment := getDeployments(controller.kubeClient.Actions(), c.expectedVerb, suffix)
+				if deployment == nil {
+					t.Fatalf("Expect %s deployment is %sd", suffix, c.expectedVerb)
+				}
+				if image := deployment.Spec.Template.Spec.Containers[0].Image; image != expectedImage {
+					t.Errorf("Expect %s image %s, but got %s", suffix, expectedImage, image)
+				}
+				if version := deployment.Annotations[agentBundleVersionAnno]; version != c.expectedBundleVersion {
+					t.Errorf("Expect %s bundle version %s, but got %s", suffix, c.expectedBundleVersion, version)
+				}
+				if deployment.Spec.Template.Spec.NodeSelector["node"] != "infra" {
+					t.Errorf("Expect %s node selector is applied, but got %v", suffix, deployment.Spec.Template.Spec.NodeSelector)
+				}
+			}
+
+			operatorAction := controller.operatorClient.Actions()
+			testingcommon.AssertActions(t, operatorAction, "patch")
+			klusterlet = &operatorapiv1.Klusterlet{}
+			patchData := operatorAction[0].(clienttesting.PatchActionImpl).Patch
+			if err := json.Unmarshal(patchData, klusterlet); err != nil {
+				t.Fatal(err)
+			}
+			expectedStatus := metav1.ConditionFalse
+			if c.expectedHold {
+				expectedStatus = metav1.ConditionTrue
+			}
+			testinghelper.AssertOnlyConditions(
+				t, klusterlet,
+				testinghelper.NamedCondition(klusterletApplied, "KlusterletApplied", metav1.ConditionTrue),
+				testinghelper.NamedCondition(helpers.FeatureGatesTypeValid, helpers.FeatureGatesReasonAllValid, metav1.ConditionTrue),
+				testinghelper.NamedCondition(klusterletHoldingUpgrade, c.expectedReason, expectedStatus),
+			)
+		})
+	}
+}
+
 func TestSyncWithPullSecret(t *testing.T) {
 	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
 	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
@@ -900,8 +1622,8 @@ func TestDeployOnKube111(t *testing.T) {
 
 	// Check if resources are created as expected
 	// 11 managed static manifests + 11 management static manifests - 2 duplicated service account manifests + 1 addon namespace + 2 deployments + 2 kube111 clusterrolebindings
-	if len(createObjects) != 25 {
-		t.Errorf("Expect 25 objects created in the sync loop, actual %d", len(createObjects))
+	if len(createObjects) != 27 {
+		t.Errorf("Expect 27 objects created in the sync loop, actual %d", len(createObjects))
 	}
 	for _, object := range createObjects {
 		ensureObject(t, object, klusterlet)
@@ -943,9 +1665,84 @@ func TestDeployOnKube111(t *testing.T) {
 		}
 	}
 
-	// 11 managed static manifests + 11 management static manifests + 1 hub kubeconfig + 2 namespaces + 2 deployments + 2 kube111 clusterrolebindings
-	if len(deleteActions) != 29 {
-		t.Errorf("Expected 29 delete actions, but got %d", len(deleteActions))
+	// 11 managed static manifests + 11 management static manifests + 1 hub kubeconfig + 2 namespaces + 3 deployments + 2 kube111 clusterrolebindings
+	if len(deleteActions) != 32 {
+		t.Errorf("Expected 32 delete actions, but got %d", len(deleteActions))
+	}
+}
+
+func TestSyncUnsupportedKubeVersion(t *testing.T) {
+	cases := []struct {
+		name              string
+		kubeVersion       string
+		expectedApplied   bool
+		expectedCondition *metav1.Condition
+	}{
+		{
+			name:            "kube version is supported",
+			kubeVersion:     "v1.18.0",
+			expectedApplied: true,
+		},
+		{
+			name:        "kube version is older than the minimum version",
+			kubeVersion: "v1.10.0",
+			expectedCondition: &metav1.Condition{
+				Type:    helpers.KubeVersionDegraded,
+				Status:  metav1.ConditionTrue,
+				Reason:  helpers.KubeVersionReasonUnsupported,
+				Message: "kubernetes 1.10.0 < minimum 1.11.0",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			namespace := newNamespace("testns")
+			controller := newTestController(t, klusterlet, nil, bootStrapSecret, hubKubeConfigSecret, namespace)
+			controller.kubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &apimachineryversion.Info{
+				GitVersion: c.kubeVersion,
+			}
+			controller.controller.getKubeVersion = helpers.GetKubeVersion
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+
+			if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			deployment := getDeployments(controller.kubeClient.Actions(), "create", "registration-agent")
+			if c.expectedApplied && deployment == nil {
+				t.Errorf("Expect registration deployment is created")
+			}
+			if !c.expectedApplied && deployment != nil {
+				t.Errorf("Expect registration deployment is not created")
+			}
+			if !c.expectedApplied && len(controller.apiExtensionClient.Actions()) != 0 {
+				t.Errorf("Expect no crds applied, but got %v", controller.apiExtensionClient.Actions())
+			}
+
+			operatorAction := controller.operatorClient.Actions()
+			testingcommon.AssertActions(t, operatorAction, "patch")
+			klusterlet = &operatorapiv1.Klusterlet{}
+			patchData := operatorAction[0].(clienttesting.PatchActionImpl).Patch
+			if err := json.Unmarshal(patchData, klusterlet); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(klusterlet.Status.Conditions, helpers.KubeVersionDegraded)
+			switch {
+			case c.expectedCondition == nil && condition != nil:
+				t.Errorf("Expect no %s condition, but got %v", helpers.KubeVersionDegraded, condition)
+			case c.expectedCondition != nil && (condition == nil || condition.Status != c.expectedCondition.Status ||
+				condition.Reason != c.expectedCondition.Reason || condition.Message != c.expectedCondition.Message):
+				t.Errorf("Expect condition %v, but got %v", c.expectedCondition, condition)
+			}
+			if applied := meta.IsStatusConditionTrue(klusterlet.Status.Conditions, klusterletApplied); applied != c.expectedApplied {
+				t.Errorf("Expect applied %v, but got %v", c.expectedApplied, applied)
+			}
+		})
 	}
 }
 
@@ -1006,3 +1803,47 @@ func (m *fakeManagedClusterBuilder) build(ctx context.Context) (*managedClusterC
 		},
 	}, nil
 }
+
+func TestHubClusterAnnotationsCache(t *testing.T) {
+	hubSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubSecret.ResourceVersion = "1"
+	hubSecret.Data["cluster-name"] = []byte("cluster1")
+	hubSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	kubeClient := fakekube.NewSimpleClientset(hubSecret)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	hubGets := 0
+	annotationsCache := newHubClusterAnnotationsCache(5 * time.Minute)
+	annotationsCache.clock = fakeClock
+	annotationsCache.getClusterAnnotations = func(_ context.Context, _ *corev1.Secret, clusterName string) (map[string]string, error) {
+		hubGets++
+		return map[string]string{registrationhelpers.HubBundleVersionAnnotation: clusterName}, nil
+	}
+
+	get := func(name string, expectedHubGets int) {
+		annotations, err := annotationsCache.get(context.TODO(), kubeClient, "testns")
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if hubGets != expectedHubGets {
+			t.Errorf("%s: expected %d gets on the hub, but got %d", name, expectedHubGets, hubGets)
+		}
+		if len(annotations) != 1 {
+			t.Errorf("%s: unexpected annotations %v", name, annotations)
+		}
+	}
+
+	get("first sync", 1)
+	fakeClock.Step(time.Minute)
+	get("cached", 1)
+	fakeClock.Step(5 * time.Minute)
+	get("expired", 2)
+
+	// the agent is bootstrapped again, the annotations are read from the hub again
+	hubSecret = hubSecret.DeepCopy()
+	hubSecret.ResourceVersion = "2"
+	if _, err := kubeClient.CoreV1().Secrets("testns").Update(context.TODO(), hubSecret, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	get("hub kubeconfig secret changed", 3)
+}
@@ -33,6 +33,8 @@ var (
 		"klusterlet/managed/klusterlet-registration-clusterrole-addon-management.yaml",
 		"klusterlet/managed/klusterlet-registration-clusterrolebinding.yaml",
 		"klusterlet/managed/klusterlet-registration-clusterrolebinding-addon-management.yaml",
+		"klusterlet/managed/klusterlet-registration-role-cluster-info.yaml",
+		"klusterlet/managed/klusterlet-registration-rolebinding-cluster-info.yaml",
 		"klusterlet/managed/klusterlet-work-serviceaccount.yaml",
 		"klusterlet/managed/klusterlet-work-clusterrole.yaml",
 		"klusterlet/managed/klusterlet-work-clusterrole-execution.yaml",
@@ -6,21 +6,32 @@ package klusterletcontroller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	operatorapiv1 "open-cluster-management.io/api/operator/v1"
 
 	"open-cluster-management.io/ocm/manifests"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 // runtimeReconcile ensure all runtime of klusterlet is applied
@@ -29,6 +40,9 @@ type runtimeReconcile struct {
 	kubeClient            kubernetes.Interface
 	recorder              events.Recorder
 	cache                 resourceapply.ResourceCache
+	bundleVersion         string
+	// getHubClusterAnnotations returns the annotations of the managed cluster on the hub
+	getHubClusterAnnotations func(ctx context.Context, kubeClient kubernetes.Interface, namespace string) (map[string]string, error)
 }
 
 func (r *runtimeReconcile) reconcile(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
@@ -47,30 +61,87 @@ func (r *runtimeReconcile) reconcile(ctx context.Context, klusterlet *operatorap
 		}
 	}
 
-	// Deploy registration agent
-	_, generationStatus, err := helpers.ApplyDeployment(
-		ctx,
-		r.kubeClient,
-		klusterlet.Status.Generations,
-		klusterlet.Spec.NodePlacement,
-		func(name string) ([]byte, error) {
-			template, err := manifests.KlusterletManifestFiles.ReadFile(name)
-			if err != nil {
-				return nil, err
-			}
-			objData := assets.MustCreateAssetFromTemplate(name, template, config).Data
-			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
-			return objData, nil
-		},
-		r.recorder,
-		"klusterlet/management/klusterlet-registration-deployment.yaml")
+	// the annotations of the managed cluster on the hub propagate the bundle version of the hub components and
+	// the agent configurations tuned from the hub.
+	var hubClusterAnnotations map[string]string
+	var hubErr error
+	if r.getHubClusterAnnotations != nil {
+		hubClusterAnnotations, hubErr = r.getHubClusterAnnotations(ctx, r.kubeClient, config.AgentNamespace)
+	}
 
+	// keep the agents running with the current bundle until the hub components are upgraded, the other changes of
+	// the klusterlet are still applied to the agents.
+	config.BundleVersion = r.bundleVersion
+	heldBundle, err := r.holdUpgrade(ctx, klusterlet, config, hubClusterAnnotations, hubErr)
+	if err != nil {
+		return klusterlet, reconcileStop, err
+	}
+	if heldBundle != nil {
+		config.BundleVersion = heldBundle.version
+		if len(heldBundle.registrationImage) > 0 {
+			config.RegistrationImage = heldBundle.registrationImage
+		}
+		if len(heldBundle.workImage) > 0 {
+			config.WorkImage = heldBundle.workImage
+		}
+	}
+	// the agents fall back to the default configurations if the hub is not reachable, so the agents are still
+	// applied on a managed cluster which cannot reach the hub.
+	if hubErr != nil {
+		klog.Warningf("Failed to get the agent configurations from the hub, the defaults are used: %v", hubErr)
+	}
+	if value := hubClusterAnnotations[registrationhelpers.WorkAgentEvictionGracePeriodAnnotation]; len(value) > 0 {
+		// the value is validated by the registration controller on the hub, it is checked again in case the
+		// annotation is set by others.
+		if gracePeriod, err := registrationhelpers.ValidateEvictionGracePeriod(value); err == nil {
+			config.AppliedManifestWorkEvictionGracePeriod = gracePeriod.String()
+		} else {
+			klog.Warningf("Ignore the eviction grace period from the hub: %v", err)
+		}
+	}
+
+	if len(config.HubCABundleConfigMap) > 0 {
+		hash, err := r.getHubCABundleHash(ctx, config.AgentNamespace, config.HubCABundleConfigMap, klusterlet)
+		if err != nil {
+			return klusterlet, reconcileStop, err
+		}
+		config.HubCABundleHash = hash
+	}
+
+	if config.InstallMode == helpers.InstallModeSingleton {
+		return r.installSingletonAgent(ctx, klusterlet, config)
+	}
+
+	// remove the singleton agent once the klusterlet is switched from the Singleton mode, the hub kubeconfig
+	// secret is kept so the registration agent starts with the existing hub kubeconfig.
+	if err := r.deleteAgentDeployments(ctx, config.AgentNamespace, singletonAgentDeploymentName(config.KlusterletName)); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
+	// the drifts of the agent deployments reverted are recorded in the condition `DriftReverted`.
+	var drifts []helpers.DeploymentDrift
+	defer func() {
+		helpers.SetDriftRevertedCondition(&klusterlet.Status.Conditions, drifts)
+	}()
+
+	// Deploy registration agent
+	drift, err := r.applyDeployment(ctx, klusterlet, config, "klusterlet/management/klusterlet-registration-deployment.yaml")
 	if err != nil {
 		// TODO update condition
 		return klusterlet, reconcileStop, err
 	}
+	if drift != nil {
+		drifts = append(drifts, *drift)
+	}
 
-	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
+	// the work agent is not deployed on an observe-only cluster, and is removed once the klusterlet is switched
+	// to observe-only.
+	if config.ObserveOnly {
+		if err := r.deleteAgentDeployments(ctx, config.AgentNamespace, fmt.Sprintf("%s-work-agent", config.KlusterletName)); err != nil {
+			return klusterlet, reconcileStop, err
+		}
+		return klusterlet, reconcileContinue, nil
+	}
 
 	// If cluster name is empty, read cluster name from hub config secret.
 	// registration-agent generated the cluster name and set it into hub config secret.
@@ -97,33 +168,89 @@ func (r *runtimeReconcile) reconcile(ctx context.Context, klusterlet *operatorap
 	}
 
 	// Deploy work agent
-	_, generationStatus, err = helpers.ApplyDeployment(
+	drift, err = r.applyDeployment(ctx, klusterlet, workConfig, "klusterlet/management/klusterlet-work-deployment.yaml")
+	if err != nil {
+		// TODO update condition
+		return klusterlet, reconcileStop, err
+	}
+	if drift != nil {
+		drifts = append(drifts, *drift)
+	}
+
+	// TODO check progressing condition
+
+	return klusterlet, reconcileContinue, nil
+}
+
+// installSingletonAgent deploys the singleton agent running the registration and work controllers in one
+// process. The registration and work agents are removed before the singleton agent is deployed, so the hub
+// kubeconfig secret is not written by two registration agents at the same time. The hub kubeconfig secret is
+// kept so the singleton agent starts with the existing hub kubeconfig instead of bootstrapping again.
+func (r *runtimeReconcile) installSingletonAgent(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
+	config klusterletConfig) (*operatorapiv1.Klusterlet, reconcileState, error) {
+	if err := r.deleteAgentDeployments(ctx, config.AgentNamespace,
+		fmt.Sprintf("%s-registration-agent", config.KlusterletName),
+		fmt.Sprintf("%s-work-agent", config.KlusterletName)); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
+	drift, err := r.applyDeployment(ctx, klusterlet, config, "klusterlet/management/klusterlet-agent-deployment.yaml")
+	if err != nil {
+		// TODO update condition
+		return klusterlet, reconcileStop, err
+	}
+	var drifts []helpers.DeploymentDrift
+	if drift != nil {
+		drifts = append(drifts, *drift)
+	}
+	helpers.SetDriftRevertedCondition(&klusterlet.Status.Conditions, drifts)
+
+	return klusterlet, reconcileContinue, nil
+}
+
+// applyDeployment applies the agent deployment rendered with the config, the fields of the deployment set in the
+// user managed fields annotation of the klusterlet are not reconciled. The drift reverted is returned.
+func (r *runtimeReconcile) applyDeployment(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
+	config klusterletConfig, file string) (*helpers.DeploymentDrift, error) {
+	userManagedFields, _ := helpers.ConvertToUserManagedFields(klusterlet.Annotations)
+	_, generationStatus, drift, err := helpers.ApplyDeployment(
 		ctx,
 		r.kubeClient,
 		klusterlet.Status.Generations,
 		klusterlet.Spec.NodePlacement,
+		userManagedFields,
 		func(name string) ([]byte, error) {
 			template, err := manifests.KlusterletManifestFiles.ReadFile(name)
 			if err != nil {
 				return nil, err
 			}
-			objData := assets.MustCreateAssetFromTemplate(name, template, workConfig).Data
+			objData := assets.MustCreateAssetFromTemplate(name, template, config).Data
 			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
 			return objData, nil
 		},
 		r.recorder,
-		"klusterlet/management/klusterlet-work-deployment.yaml")
-
+		file)
 	if err != nil {
-		// TODO update condition
-		return klusterlet, reconcileStop, err
+		return nil, err
 	}
 
 	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
+	return drift, nil
+}
 
-	// TODO check progressing condition
-
-	return klusterlet, reconcileContinue, nil
+// deleteAgentDeployments deletes the agent deployments which are not used in the current install mode.
+func (r *runtimeReconcile) deleteAgentDeployments(ctx context.Context, namespace string, names ...string) error {
+	for _, name := range names {
+		err := r.kubeClient.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		r.recorder.Eventf("DeploymentDeleted", "deployment %s is deleted", name)
+	}
+	return nil
 }
 
 func (r *runtimeReconcile) createManagedClusterKubeconfig(
@@ -164,11 +291,256 @@ func (r *runtimeReconcile) getClusterNameFromHubKubeConfigSecret(ctx context.Con
 	return string(clusterName), nil
 }
 
+// getHubCABundleHash returns the hash of the customized hub CA bundle, it is set on the pod template of the
+// registration agent so the agent is rolled out once the CA bundle is changed.
+func (r *runtimeReconcile) getHubCABundleHash(ctx context.Context, namespace, name string, klusterlet *operatorapiv1.Klusterlet) (string, error) {
+	cm, err := r.kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil && len(cm.Data[hubCABundleKey]) == 0 {
+		err = fmt.Errorf("the key %q is not found in the configmap", hubCABundleKey)
+	}
+	if err != nil {
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletApplied, Status: metav1.ConditionFalse, Reason: "KlusterletApplyFailed",
+			Message: fmt.Sprintf("Failed to get hub CA bundle from configmap %s/%s with error %v", namespace, name, err),
+		})
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(cm.Data[hubCABundleKey]))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// agentBundle is the bundle version and the images the running agent deployments are rendered with.
+type agentBundle struct {
+	version           string
+	registrationImage string
+	workImage         string
+}
+
+// getAgentBundle returns the bundle of the agent deployments in the agent namespace, or nil if none of the agent
+// deployments exists. The bundle version is empty if the agents are rendered before it is recorded.
+func (r *runtimeReconcile) getAgentBundle(ctx context.Context, config klusterletConfig) (*agentBundle, error) {
+	getDeployment := func(name string) (*appsv1.Deployment, string, error) {
+		deployment, err := r.kubeClient.AppsV1().Deployments(config.AgentNamespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			return nil, "", nil
+		case err != nil:
+			return nil, "", err
+		case len(deployment.Spec.Template.Spec.Containers) == 0:
+			return deployment, "", nil
+		}
+		return deployment, deployment.Spec.Template.Spec.Containers[0].Image, nil
+	}
+
+	var bundle *agentBundle
+	// the singleton agent runs both the registration and work controllers with the registration image
+	for _, agent := range []struct {
+		name         string
+		registration bool
+		work         bool
+	}{
+		{name: singletonAgentDeploymentName(config.KlusterletName), registration: true, work: true},
+		{name: fmt.Sprintf("%s-registration-agent", config.KlusterletName), registration: true},
+		{name: fmt.Sprintf("%s-work-agent", config.KlusterletName), work: true},
+	} {
+		deployment, image, err := getDeployment(agent.name)
+		if err != nil {
+			return nil, err
+		}
+		if deployment == nil {
+			continue
+		}
+		if bundle == nil {
+			bundle = &agentBundle{version: deployment.Annotations[agentBundleVersionAnno]}
+		}
+		if agent.registration {
+			bundle.registrationImage = image
+		}
+		if agent.work {
+			bundle.workImage = image
+		}
+	}
+	return bundle, nil
+}
+
+// holdUpgrade returns the bundle of the running agents if they should be held from upgrading since the bundle
+// version of the operator is newer than the bundle version of the hub components, and sets the HoldingUpgrade
+// condition accordingly. The upgrade is not held if the bundle version of the hub is unknown, the agents are not
+// deployed yet or already run the bundle version of the operator, or the klusterlet has the force upgrade
+// annotation.
+func (r *runtimeReconcile) holdUpgrade(ctx context.Context, klusterlet *operatorapiv1.Klusterlet, config klusterletConfig,
+	hubClusterAnnotations map[string]string, err error) (*agentBundle, error) {
+	if len(r.bundleVersion) == 0 || r.getHubClusterAnnotations == nil {
+		return nil, nil
+	}
+
+	hubBundleVersion := hubClusterAnnotations[registrationhelpers.HubBundleVersionAnnotation]
+	switch {
+	case err != nil:
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletHoldingUpgrade, Status: metav1.ConditionFalse, Reason: "HubBundleVersionUnknown",
+			Message: fmt.Sprintf("Failed to get the bundle version of the hub with error %v", err),
+		})
+		return nil, nil
+	case len(hubBundleVersion) == 0:
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletHoldingUpgrade, Status: metav1.ConditionFalse, Reason: "HubBundleVersionUnknown",
+			Message: "The bundle version of the hub is not published",
+		})
+		return nil, nil
+	case !isNewerBundleVersion(r.bundleVersion, hubBundleVersion):
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletHoldingUpgrade, Status: metav1.ConditionFalse, Reason: "HubUpToDate",
+			Message: fmt.Sprintf("The bundle version %s of the hub is not behind the bundle version %s of the agents",
+				hubBundleVersion, r.bundleVersion),
+		})
+		return nil, nil
+	case klusterlet.Annotations[forceUpgradeAnno] == "true":
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletHoldingUpgrade, Status: metav1.ConditionFalse, Reason: "UpgradeForced",
+			Message: fmt.Sprintf("The agents are forced to upgrade to bundle version %s ahead of the hub bundle version %s",
+				r.bundleVersion, hubBundleVersion),
+		})
+		return nil, nil
+	}
+
+	bundle, err := r.getAgentBundle(ctx, config)
+	switch {
+	case err != nil:
+		return nil, err
+	case bundle == nil:
+		// nothing to hold, the agents are installed with the bundle of the operator
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletHoldingUpgrade, Status: metav1.ConditionFalse, Reason: "AgentsNotDeployed",
+			Message: fmt.Sprintf("The agents are deployed with bundle version %s ahead of the hub bundle version %s",
+				r.bundleVersion, hubBundleVersion),
+		})
+		return nil, nil
+	case bundle.version == r.bundleVersion:
+		meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+			Type: klusterletHoldingUpgrade, Status: metav1.ConditionFalse, Reason: "AgentsUpToDate",
+			Message: fmt.Sprintf("The agents already run bundle version %s ahead of the hub bundle version %s",
+				r.bundleVersion, hubBundleVersion),
+		})
+		return nil, nil
+	}
+
+	meta.SetStatusCondition(&klusterlet.Status.Conditions, metav1.Condition{
+		Type: klusterletHoldingUpgrade, Status: metav1.ConditionTrue, Reason: "HubBehind",
+		Message: fmt.Sprintf("The agents are held from upgrading to bundle version %s until the hub is upgraded from bundle version %s",
+			r.bundleVersion, hubBundleVersion),
+	})
+	return bundle, nil
+}
+
+// isNewerBundleVersion returns true if the bundle version is newer than the other one. The bundle versions which
+// cannot be parsed, e.g. the versions of the development builds, are not comparable.
+func isNewerBundleVersion(bundleVersion, other string) bool {
+	v, err := version.ParseGeneric(bundleVersion)
+	if err != nil {
+		return false
+	}
+	o, err := version.ParseGeneric(other)
+	if err != nil {
+		return false
+	}
+	return o.LessThan(v)
+}
+
+// hubClusterAnnotationsCache caches the annotations of the managed clusters on the hub per agent namespace, so the
+// klusterlets are not synced with a GET on the hub each time. The annotations are read from the hub again once the
+// ttl expires or the hub kubeconfig secret is changed, e.g. the agent is bootstrapped to another hub.
+type hubClusterAnnotationsCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+	// getClusterAnnotations reads the annotations of the managed cluster on the hub with the hub kubeconfig secret.
+	getClusterAnnotations func(ctx context.Context, hubSecret *corev1.Secret, clusterName string) (map[string]string, error)
+
+	lock    sync.Mutex
+	entries map[string]hubClusterAnnotationsEntry
+}
+
+type hubClusterAnnotationsEntry struct {
+	secretResourceVersion string
+	annotations           map[string]string
+	expiresAt             time.Time
+}
+
+func newHubClusterAnnotationsCache(ttl time.Duration) *hubClusterAnnotationsCache {
+	return &hubClusterAnnotationsCache{
+		ttl:                   ttl,
+		clock:                 clock.RealClock{},
+		getClusterAnnotations: getHubClusterAnnotations,
+		entries:               map[string]hubClusterAnnotationsEntry{},
+	}
+}
+
+// get returns the annotations of the managed cluster on the hub, e.g. the bundle version of the hub components.
+// No annotation is returned if the cluster is not registered yet.
+func (c *hubClusterAnnotationsCache) get(ctx context.Context, kubeClient kubernetes.Interface, namespace string) (map[string]string, error) {
+	hubSecret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, helpers.HubKubeConfig, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	clusterName := string(hubSecret.Data["cluster-name"])
+	if len(clusterName) == 0 || len(hubSecret.Data["kubeconfig"]) == 0 {
+		return nil, nil
+	}
+
+	c.lock.Lock()
+	entry, ok := c.entries[namespace]
+	c.lock.Unlock()
+	if ok && entry.secretResourceVersion == hubSecret.ResourceVersion && c.clock.Now().Before(entry.expiresAt) {
+		return entry.annotations, nil
+	}
+
+	annotations, err := c.getClusterAnnotations(ctx, hubSecret, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[namespace] = hubClusterAnnotationsEntry{
+		secretResourceVersion: hubSecret.ResourceVersion,
+		annotations:           annotations,
+		expiresAt:             c.clock.Now().Add(c.ttl),
+	}
+	return annotations, nil
+}
+
+// getHubClusterAnnotations reads the annotations of the managed cluster on the hub with the hub kubeconfig secret.
+// No annotation is returned if the cluster is not found.
+func getHubClusterAnnotations(ctx context.Context, hubSecret *corev1.Secret, clusterName string) (map[string]string, error) {
+	hubConfig, err := helpers.LoadClientConfigFromSecret(hubSecret)
+	if err != nil {
+		return nil, err
+	}
+	hubClusterClient, err := clusterclientset.NewForConfig(hubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := hubClusterClient.ClusterV1().ManagedClusters().Get(ctx, clusterName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Annotations, nil
+}
+
 func (r *runtimeReconcile) clean(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
 	config klusterletConfig) (*operatorapiv1.Klusterlet, reconcileState, error) {
 	deployments := []string{
 		fmt.Sprintf("%s-registration-agent", config.KlusterletName),
 		fmt.Sprintf("%s-work-agent", config.KlusterletName),
+		singletonAgentDeploymentName(config.KlusterletName),
 	}
 	for _, deployment := range deployments {
 		err := r.kubeClient.AppsV1().Deployments(config.AgentNamespace).Delete(ctx, deployment, metav1.DeleteOptions{})
@@ -186,6 +558,11 @@ func registrationServiceAccountName(klusterletName string) string {
 	return fmt.Sprintf("%s-registration-sa", klusterletName)
 }
 
+// singletonAgentDeploymentName splices the name of the singleton agent deployment
+func singletonAgentDeploymentName(klusterletName string) string {
+	return fmt.Sprintf("%s-agent", klusterletName)
+}
+
 // workServiceAccountName splices the name of work service account
 func workServiceAccountName(klusterletName string) string {
 	return fmt.Sprintf("%s-work-sa", klusterletName)
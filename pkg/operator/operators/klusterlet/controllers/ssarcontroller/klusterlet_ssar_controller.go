@@ -25,6 +25,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 // SSARReSyncTime is exposed so that integration tests can crank up the controller sync speed.
@@ -224,6 +225,14 @@ func checkBootstrapSecret(ctx context.Context, kubeClient kubernetes.Interface,
 
 	// Check the bootstrap client permissions by creating SelfSubjectAccessReviews
 	allowed, failedReview, err := createSelfSubjectAccessReviews(ctx, bootstrapClient, getBootstrapSSARs())
+	if errors.IsUnauthorized(err) && hasBootstrapToken(bootstrapSecret) {
+		return metav1.Condition{
+			Status: metav1.ConditionTrue,
+			Reason: registrationhelpers.BootstrapTokenExpiredReason,
+			Message: fmt.Sprintf("The token in bootstrap secret %q/%q is rejected by apiserver %s, it may be expired "+
+				"before the csr of the agent is approved: %v", agent.namespace, helpers.BootstrapHubKubeConfig, host, err),
+		}
+	}
 	if err != nil {
 		return metav1.Condition{
 			Status: metav1.ConditionTrue,
@@ -249,6 +258,15 @@ func checkBootstrapSecret(ctx context.Context, kubeClient kubernetes.Interface,
 	}
 }
 
+// hasBootstrapToken returns true if the bootstrap secret authenticates to the hub with a token only.
+func hasBootstrapToken(secret *corev1.Secret) bool {
+	restConfig, err := helpers.LoadClientConfigFromSecret(secret)
+	if err != nil {
+		return false
+	}
+	return registrationhelpers.IsTokenBasedBootstrap(restConfig)
+}
+
 func getBootstrapSSARs() []authorizationv1.SelfSubjectAccessReview {
 	reviews := []authorizationv1.SelfSubjectAccessReview{}
 	clusterResource := authorizationv1.ResourceAttributes{
@@ -66,6 +66,24 @@ func newKubeConfig(host string) []byte {
 	return configData
 }
 
+func newTokenKubeConfig(host, token string) []byte {
+	configData, _ := runtime.Encode(clientcmdlatest.Codec, &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"default-cluster": {
+			Server:                host,
+			InsecureSkipTLSVerify: true,
+		}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"default-auth": {
+			Token: token,
+		}},
+		Contexts: map[string]*clientcmdapi.Context{"default-context": {
+			Cluster:  "default-cluster",
+			AuthInfo: "default-auth",
+		}},
+		CurrentContext: "default-context",
+	})
+	return configData
+}
+
 func newSecretWithKubeConfig(name, namespace string, kubeConfig []byte) *corev1.Secret {
 	secret := newSecret(name, namespace)
 	secret.Data["kubeconfig"] = kubeConfig
@@ -128,11 +146,15 @@ func newTestController(t *testing.T, klusterlet *operatorapiv1.Klusterlet, objec
 
 func TestSync(t *testing.T) {
 	response := &serverResponse{}
-	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.URL.Path != "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews" {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		if req.Header.Get("Authorization") == "Bearer expired-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 
 		data, err := io.ReadAll(req.Body)
 		if err != nil {
@@ -160,8 +182,12 @@ func TestSync(t *testing.T) {
 		if err := json.NewEncoder(w).Encode(ssar); err != nil {
 			t.Fatal(err)
 		}
-	}))
+	})
+	apiServer := httptest.NewServer(handler)
 	defer apiServer.Close()
+	// the token in a kubeconfig is only sent over tls
+	tlsAPIServer := httptest.NewTLSServer(handler)
+	defer tlsAPIServer.Close()
 
 	apiServerHost := apiServer.URL
 
@@ -242,6 +268,29 @@ func TestSync(t *testing.T) {
 				testinghelper.NamedCondition(hubConnectionDegraded, "BootstrapSecretUnauthorized,HubKubeConfigUnauthorized", metav1.ConditionTrue),
 			},
 		},
+		{
+			name: "Bootstrap token expired",
+			object: []runtime.Object{
+				newSecretWithKubeConfig(helpers.BootstrapHubKubeConfig, "test", newTokenKubeConfig(tlsAPIServer.URL, "expired-token")),
+			},
+			klusterlet: newKlusterlet("testklusterlet", "test", "cluster1"),
+			expectedConditions: []metav1.Condition{
+				testinghelper.NamedCondition(hubConnectionDegraded, "BootstrapTokenExpired,HubKubeConfigSecretMissing", metav1.ConditionTrue),
+			},
+		},
+		{
+			name: "Bootstrap token functional",
+			object: []runtime.Object{
+				newSecretWithKubeConfig(helpers.BootstrapHubKubeConfig, "test", newTokenKubeConfig(tlsAPIServer.URL, "token")),
+			},
+			allowToOperateManagedClusters:      true,
+			allowToOperateManagedClusterStatus: true,
+			allowToOperateManifestWorks:        true,
+			klusterlet:                         newKlusterlet("testklusterlet", "test", "cluster1"),
+			expectedConditions: []metav1.Condition{
+				testinghelper.NamedCondition(hubConnectionDegraded, "BootstrapSecretFunctional,HubKubeConfigSecretMissing", metav1.ConditionTrue),
+			},
+		},
 		{
 			name: "Operator functional",
 			object: []runtime.Object{
@@ -87,20 +87,30 @@ func (k *klusterletStatusController) sync(ctx context.Context, controllerContext
 	agentNamespace := helpers.AgentNamespace(klusterlet)
 	registrationDeploymentName := fmt.Sprintf("%s-registration-agent", klusterlet.Name)
 	workDeploymentName := fmt.Sprintf("%s-work-agent", klusterlet.Name)
+	agents := []klusterletAgent{
+		{
+			deploymentName: registrationDeploymentName,
+			namespace:      agentNamespace,
+		},
+		{
+			deploymentName: workDeploymentName,
+			namespace:      agentNamespace,
+		},
+	}
 
-	availableCondition := checkAgentsDeploymentAvailable(
-		ctx, k.kubeClient,
-		[]klusterletAgent{
+	// the registration and work controllers run in the singleton agent deployment in the Singleton mode.
+	if klusterlet.Spec.DeployOption.Mode == helpers.InstallModeSingleton {
+		registrationDeploymentName = fmt.Sprintf("%s-agent", klusterlet.Name)
+		workDeploymentName = registrationDeploymentName
+		agents = []klusterletAgent{
 			{
 				deploymentName: registrationDeploymentName,
 				namespace:      agentNamespace,
 			},
-			{
-				deploymentName: workDeploymentName,
-				namespace:      agentNamespace,
-			},
-		},
-	)
+		}
+	}
+
+	availableCondition := checkAgentsDeploymentAvailable(ctx, k.kubeClient, agents)
 	availableCondition.ObservedGeneration = klusterlet.Generation
 	meta.SetStatusCondition(&newKlusterlet.Status.Conditions, availableCondition)
 
@@ -20,6 +20,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/operator/helpers"
 	testinghelper "open-cluster-management.io/ocm/pkg/operator/helpers/testing"
 )
 
@@ -51,6 +52,12 @@ func newKlusterlet(name, namespace, clustername string) *operatorapiv1.Klusterle
 	}
 }
 
+func newSingletonKlusterlet(name, namespace, clustername string) *operatorapiv1.Klusterlet {
+	klusterlet := newKlusterlet(name, namespace, clustername)
+	klusterlet.Spec.DeployOption.Mode = helpers.InstallModeSingleton
+	return klusterlet
+}
+
 func newDeployment(name, namespace string, desiredReplica, availableReplica int32) *appsv1.Deployment {
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -165,6 +172,32 @@ func TestSync(t *testing.T) {
 				testinghelper.NamedCondition(klusterletWorkDesiredDegraded, "DeploymentsFunctional", metav1.ConditionFalse),
 			},
 		},
+		{
+			name: "Singleton Unavailable & Undesired",
+			object: []runtime.Object{
+				newDeployment("testklusterlet-agent", "test", 3, 0),
+			},
+			klusterlet: newSingletonKlusterlet("testklusterlet", "test", "cluster1"),
+			expectedConditions: []metav1.Condition{
+				testinghelper.NamedCondition(klusterletAvailable, "NoAvailablePods", metav1.ConditionFalse),
+				testinghelper.NamedCondition(klusterletRegistrationDesiredDegraded, "UnavailablePods", metav1.ConditionTrue),
+				testinghelper.NamedCondition(klusterletWorkDesiredDegraded, "UnavailablePods", metav1.ConditionTrue),
+			},
+		},
+		{
+			name: "Singleton Available & Desired",
+			object: []runtime.Object{
+				newDeployment("testklusterlet-agent", "test", 3, 3),
+				// the registration and work agents left by the Default mode are not checked
+				newDeployment("testklusterlet-registration-agent", "test", 3, 0),
+			},
+			klusterlet: newSingletonKlusterlet("testklusterlet", "test", "cluster1"),
+			expectedConditions: []metav1.Condition{
+				testinghelper.NamedCondition(klusterletAvailable, "klusterletAvailable", metav1.ConditionTrue),
+				testinghelper.NamedCondition(klusterletRegistrationDesiredDegraded, "DeploymentsFunctional", metav1.ConditionFalse),
+				testinghelper.NamedCondition(klusterletWorkDesiredDegraded, "DeploymentsFunctional", metav1.ConditionFalse),
+			},
+		},
 	}
 
 	for _, c := range cases {
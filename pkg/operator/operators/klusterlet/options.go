@@ -9,7 +9,6 @@ import (
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
-	versionutil "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -45,11 +44,7 @@ func (o *Options) RunKlusterletOperator(ctx context.Context, controllerContext *
 		return err
 	}
 
-	version, err := kubeClient.ServerVersion()
-	if err != nil {
-		return err
-	}
-	kubeVersion, err := versionutil.ParseGeneric(version.String())
+	kubeVersion, err := helpers.GetKubeVersion(kubeClient)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/spf13/pflag"
 	"k8s.io/apiserver/pkg/server/mux"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/events"
@@ -16,10 +17,43 @@ import (
 
 	scheduling "open-cluster-management.io/ocm/pkg/placement/controllers/scheduling"
 	"open-cluster-management.io/ocm/pkg/placement/debugger"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
 )
 
-// RunControllerManager starts the controllers on hub to make placement decisions.
+// PlacementManagerOptions holds the configuration of the placement controllers.
+type PlacementManagerOptions struct {
+	// SchedulingWorkers is the number of the workers reconciling the placements.
+	SchedulingWorkers int
+	// SchedulingTimeBudget is the time after which the scheduling of a placement yields and continues after the
+	// other placements queued, it is disabled if it is 0.
+	SchedulingTimeBudget time.Duration
+}
+
+// NewPlacementManagerOptions returns the options with default value set.
+func NewPlacementManagerOptions() *PlacementManagerOptions {
+	return &PlacementManagerOptions{
+		SchedulingWorkers:    4,
+		SchedulingTimeBudget: time.Second,
+	}
+}
+
+// AddFlags registers the flags of the placement controllers.
+func (o *PlacementManagerOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.SchedulingWorkers, "scheduling-workers", o.SchedulingWorkers,
+		"The number of the workers reconciling the placements. The workers take the placements of the namespaces in turn.")
+	fs.DurationVar(&o.SchedulingTimeBudget, "scheduling-time-budget", o.SchedulingTimeBudget,
+		"The time after which the scheduling of a placement yields between two prioritizers and continues after the "+
+			"other placements queued. The scheduling restarts if the inputs of the placement change in the meantime. "+
+			"Set it to 0 to schedule each placement in one reconcile.")
+}
+
+// RunControllerManager starts the controllers on hub to make placement decisions with the default options.
 func RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	return NewPlacementManagerOptions().RunControllerManager(ctx, controllerContext)
+}
+
+// RunControllerManager starts the controllers on hub to make placement decisions.
+func (o *PlacementManagerOptions) RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
 	kubeConf := controllerContext.KubeConfig
 	kubeConf.QPS = 50
 	kubeConf.Burst = 100
@@ -41,10 +75,18 @@ func RunControllerManager(ctx context.Context, controllerContext *controllercmd.
 
 	recorder := broadcaster.NewRecorder(clusterscheme.Scheme, "placementController")
 
+	clusterDecisionIndex := plugins.NewClusterDecisionIndex()
+	if _, err := clusterInformers.Cluster().V1beta1().PlacementDecisions().Informer().AddEventHandler(
+		clusterDecisionIndex); err != nil {
+		return err
+	}
+
 	scheduler := scheduling.NewPluginScheduler(
 		scheduling.NewSchedulerHandler(
 			clusterClient,
 			clusterInformers.Cluster().V1beta1().PlacementDecisions().Lister(),
+			clusterInformers.Cluster().V1beta1().Placements().Lister(),
+			clusterDecisionIndex,
 			clusterInformers.Cluster().V1alpha1().AddOnPlacementScores().Lister(),
 			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
 			recorder),
@@ -70,13 +112,13 @@ func RunControllerManager(ctx context.Context, controllerContext *controllercmd.
 		clusterInformers.Cluster().V1alpha1().AddOnPlacementScores(),
 		scheduler,
 		controllerContext.EventRecorder, recorder,
+		o.SchedulingTimeBudget,
 	)
 
 	go clusterInformers.Start(ctx.Done())
 
-	go schedulingController.Run(ctx, 1)
-
-	<-ctx.Done()
+	// the controller is waited for to stop, so it never runs on two replicas once the leadership is handed over
+	schedulingController.Run(ctx, o.SchedulingWorkers)
 	return nil
 }
 
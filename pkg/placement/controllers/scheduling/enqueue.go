@@ -78,6 +78,10 @@ func enqueuePlacement(obj interface{}, queue workqueue.RateLimitingInterface) {
 	queue.Add(key)
 }
 
+func (e *enqueuer) enqueuePlacement(obj interface{}) {
+	e.enqueuePlacementFunc(obj, e.queue)
+}
+
 func (e *enqueuer) enqueueClusterSetBinding(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
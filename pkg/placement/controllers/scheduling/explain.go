@@ -0,0 +1,114 @@
+package scheduling
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/helpers"
+)
+
+// newExplainedCondition returns the PlacementConditionClusterExplained condition with the reason why the cluster
+// is selected or not. It is computed from the result of the schedule of the placement, so the explanation is
+// always consistent with the decisions.
+//  1. the cluster does not exist;
+//  2. the cluster is not in any of the eligible clustersets;
+//  3. the cluster is filtered out by a filter, e.g. the predicate or the taint/toleration;
+//  4. the cluster is selected, or outranked by the selected clusters with its score.
+func (c *schedulingController) newExplainedCondition(
+	clusterName string,
+	eligibleClusterSets []string,
+	availableClusters []*clusterapiv1.ManagedCluster,
+	scheduleResult ScheduleResult,
+	status *framework.Status,
+) (metav1.Condition, error) {
+	condition := metav1.Condition{
+		Type:   helpers.PlacementConditionClusterExplained,
+		Status: metav1.ConditionFalse,
+	}
+
+	_, err := c.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		condition.Reason = "ClusterNotFound"
+		condition.Message = fmt.Sprintf("Cluster %q is not found", clusterName)
+		return condition, nil
+	case err != nil:
+		return condition, err
+	}
+
+	available := false
+	for _, cluster := range availableClusters {
+		if cluster.Name == clusterName {
+			available = true
+			break
+		}
+	}
+	if !available {
+		condition.Reason = "NotInClusterSets"
+		condition.Message = fmt.Sprintf("Cluster %q is not a member of the eligible ManagedClusterSets [%s]",
+			clusterName, strings.Join(eligibleClusterSets, ","))
+		return condition, nil
+	}
+
+	for _, result := range scheduleResult.FilterResults() {
+		if !containsCluster(result.FilteredClusters, clusterName) {
+			// the name of the result is the filter pipeline, the last filter filters out the cluster
+			pipeline := strings.Split(result.Name, ",")
+			condition.Reason = "Filtered"
+			condition.Message = fmt.Sprintf("Cluster %q is filtered out by %s", clusterName, pipeline[len(pipeline)-1])
+			return condition, nil
+		}
+	}
+
+	if status.IsError() {
+		condition.Reason = "ScheduleFailed"
+		condition.Message = fmt.Sprintf("Cluster %q is not scheduled: %v", clusterName, status.AsError())
+		return condition, nil
+	}
+
+	score := fmt.Sprintf("%d", scheduleResult.PrioritizerScores()[clusterName])
+	var breakdown []string
+	for _, result := range scheduleResult.PrioritizerResults() {
+		if s, ok := result.Scores[clusterName]; ok {
+			breakdown = append(breakdown, fmt.Sprintf("%s=%d*%d", result.Name, s, result.Weight))
+		}
+	}
+	if len(breakdown) > 0 {
+		score += fmt.Sprintf(" (%s)", strings.Join(breakdown, ", "))
+	}
+
+	var selected []string
+	for _, decision := range scheduleResult.Decisions() {
+		if decision.ClusterName == clusterName {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "Selected"
+			condition.Message = fmt.Sprintf("Cluster %q is selected with score %s", clusterName, score)
+			return condition, nil
+		}
+		selected = append(selected, fmt.Sprintf("%s=%d", decision.ClusterName, scheduleResult.PrioritizerScores()[decision.ClusterName]))
+	}
+
+	numOfSelected := len(selected)
+	if len(selected) > helpers.MaxClusterScoresInAnnotation {
+		selected = append(selected[:helpers.MaxClusterScoresInAnnotation], "...")
+	}
+	condition.Reason = "Outranked"
+	condition.Message = fmt.Sprintf("Cluster %q with score %s is outranked by the %d selected clusters [%s]",
+		clusterName, score, numOfSelected, strings.Join(selected, ", "))
+	return condition, nil
+}
+
+func containsCluster(clusterNames []string, clusterName string) bool {
+	for _, name := range clusterNames {
+		if name == clusterName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// fairController runs the sync of the placements on multiple workers. It is like the controller built by the
+// library-go factory, except that the placements are taken from a fairQueue, which the factory does not support.
+type fairController struct {
+	name         string
+	queue        workqueue.RateLimitingInterface
+	recorder     events.Recorder
+	cachesToSync []cache.InformerSynced
+	sync         factory.SyncFunc
+}
+
+var _ factory.Controller = &fairController{}
+
+// fairSyncContext is the sync context of a placement taken from the fairQueue.
+type fairSyncContext struct {
+	queue    workqueue.RateLimitingInterface
+	queueKey string
+	recorder events.Recorder
+}
+
+func (c fairSyncContext) Queue() workqueue.RateLimitingInterface { return c.queue }
+func (c fairSyncContext) QueueKey() string                       { return c.queueKey }
+func (c fairSyncContext) Recorder() events.Recorder              { return c.recorder }
+
+func newFairController(name string, queue workqueue.RateLimitingInterface, recorder events.Recorder,
+	sync factory.SyncFunc, informers ...cache.SharedIndexInformer) *fairController {
+	c := &fairController{
+		name:     name,
+		queue:    queue,
+		recorder: recorder.WithComponentSuffix(strings.ToLower(name)),
+		sync:     sync,
+	}
+	for _, informer := range informers {
+		c.cachesToSync = append(c.cachesToSync, informer.HasSynced)
+	}
+	return c
+}
+
+func (c *fairController) Run(ctx context.Context, workers int) {
+	defer utilruntime.HandleCrash()
+
+	klog.Infof("Waiting for caches to sync for %s", c.name)
+	if !cache.WaitForNamedCacheSync(c.name, ctx.Done(), c.cachesToSync...) {
+		c.queue.ShutDown()
+		return
+	}
+
+	var workerWg sync.WaitGroup
+	for i := 1; i <= workers; i++ {
+		klog.Infof("Starting #%d worker of %s controller ...", i, c.name)
+		workerWg.Add(1)
+		go func() {
+			defer workerWg.Done()
+			wait.UntilWithContext(ctx, c.runWorker, time.Second)
+		}()
+	}
+
+	<-ctx.Done()
+	c.queue.ShutDown()
+	klog.Infof("Shutting down %s ...", c.name)
+	workerWg.Wait()
+}
+
+func (c *fairController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	return c.sync(ctx, syncCtx)
+}
+
+func (c *fairController) Name() string {
+	return c.name
+}
+
+func (c *fairController) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *fairController) processNextWorkItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	queueKey, ok := key.(string)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("%q controller failed to process key %q (not a string)", c.name, key))
+		return true
+	}
+
+	if err := c.sync(ctx, fairSyncContext{queue: c.queue, queueKey: queueKey, recorder: c.recorder}); err != nil {
+		utilruntime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", c.name, queueKey, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
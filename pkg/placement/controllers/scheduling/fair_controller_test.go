@@ -0,0 +1,192 @@
+package scheduling
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/runtime"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/workqueue"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+// slowScheduler is a budgeted scheduler whose prioritizers take the step duration each, the giant placements
+// have more prioritizers than the others.
+type slowScheduler struct {
+	step  time.Duration
+	steps func(placement *clusterapiv1beta1.Placement) int
+	lock  sync.Mutex
+	// resumed is the steps done before each call, it is -1 if the call starts without a checkpoint.
+	resumed   []int
+	completed map[string]time.Time
+}
+
+func (s *slowScheduler) Schedule(ctx context.Context,
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster,
+) (ScheduleResult, *framework.Status) {
+	result, _, status := s.scheduleWithBudget(ctx, placement, clusters, time.Time{}, nil)
+	return result, status
+}
+
+func (s *slowScheduler) scheduleWithBudget(ctx context.Context,
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster,
+	deadline time.Time,
+	checkpoint *scheduleCheckpoint,
+) (ScheduleResult, *scheduleCheckpoint, *framework.Status) {
+	s.lock.Lock()
+	if checkpoint == nil {
+		s.resumed = append(s.resumed, -1)
+		checkpoint = &scheduleCheckpoint{}
+	} else {
+		s.resumed = append(s.resumed, checkpoint.scored)
+	}
+	s.lock.Unlock()
+
+	for checkpoint.scored < s.steps(placement) {
+		time.Sleep(s.step)
+		checkpoint.scored++
+		if budgetExceeded(deadline) && checkpoint.scored < s.steps(placement) {
+			return nil, checkpoint, nil
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.completed == nil {
+		s.completed = map[string]time.Time{}
+	}
+	s.completed[placement.Namespace+"/"+placement.Name] = time.Now()
+	return &scheduleResult{}, nil, nil
+}
+
+func newSlowSchedulingController(t *testing.T, scheduler Scheduler, timeBudget time.Duration,
+	objs ...runtime.Object) *schedulingController {
+	clusterClient := clusterfake.NewSimpleClientset(objs...)
+	clusterInformerFactory := newClusterInformerFactory(clusterClient, objs...)
+	return &schedulingController{
+		clusterClient:           clusterClient,
+		clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+		clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+		placementLister:         clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+		placementDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+		scheduler:               scheduler,
+		recorder:                kevents.NewFakeRecorder(100),
+		timeBudget:              timeBudget,
+		checkpoints:             newScheduleCheckpoints(),
+	}
+}
+
+// TestFairControllerSlowPlacement reconciles a giant placement with a slow prioritizer on one worker, the small
+// placements enqueued after it should be reconciled while the giant placement yields.
+func TestFairControllerSlowPlacement(t *testing.T) {
+	objs := []runtime.Object{
+		testinghelpers.NewPlacement("giant", "placement").Build(),
+		testinghelpers.NewPlacement("ns1", "placement").Build(),
+		testinghelpers.NewPlacement("ns2", "placement").Build(),
+		testinghelpers.NewPlacement("ns3", "placement").Build(),
+	}
+	scheduler := &slowScheduler{
+		step: 50 * time.Millisecond,
+		steps: func(placement *clusterapiv1beta1.Placement) int {
+			if placement.Namespace == "giant" {
+				return 20
+			}
+			return 1
+		},
+	}
+	ctrl := newSlowSchedulingController(t, scheduler, 50*time.Millisecond, objs...)
+
+	queue := newFairQueue("test")
+	controller := newFairController("test", queue, eventstesting.NewTestingEventRecorder(t), ctrl.sync)
+	start := time.Now()
+	for _, key := range []string{"giant/placement", "ns1/placement", "ns2/placement", "ns3/placement"} {
+		queue.Add(key)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Run(ctx, 1)
+
+	// the giant placement takes one second in total
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		scheduler.lock.Lock()
+		completed := len(scheduler.completed)
+		scheduler.lock.Unlock()
+		if completed == len(objs) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected all the placements reconciled, but got %v", scheduler.completed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	giant := scheduler.completed["giant/placement"]
+	for _, key := range []string{"ns1/placement", "ns2/placement", "ns3/placement"} {
+		if latency := scheduler.completed[key].Sub(start); latency > 500*time.Millisecond {
+			t.Errorf("expected placement %s reconciled promptly, but got it after %v", key, latency)
+		}
+		if !scheduler.completed[key].Before(giant) {
+			t.Errorf("expected placement %s reconciled before the giant placement", key)
+		}
+	}
+}
+
+func TestSchedulingControllerRestartYieldedPlacement(t *testing.T) {
+	key := "ns1/placement"
+	scheduler := &slowScheduler{
+		steps: func(placement *clusterapiv1beta1.Placement) int { return 3 },
+	}
+	// every step exceeds the time budget
+	ctrl := newSlowSchedulingController(t, scheduler, time.Nanosecond,
+		testinghelpers.NewPlacement("ns1", "placement").Build())
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, key)
+	sync := func() {
+		if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the placement yields after the first step and is requeued
+	sync()
+	if syncCtx.Queue().Len() != 1 {
+		t.Errorf("expected the yielded placement requeued, but got %d queued", syncCtx.Queue().Len())
+	}
+
+	// the placement continues from the checkpoint
+	sync()
+	if scheduler.resumed[1] != 1 {
+		t.Errorf("expected the scheduling continued after one step, but got %d", scheduler.resumed[1])
+	}
+
+	// the scheduling restarts once the placement is enqueued by a change of its inputs
+	ctrl.enqueue(workqueue.New(), key)
+	sync()
+	if scheduler.resumed[2] != -1 {
+		t.Errorf("expected the scheduling restarted, but got the checkpoint after %d steps", scheduler.resumed[2])
+	}
+
+	// a checkpoint taken before the inputs change is not kept
+	_, generation := ctrl.checkpoints.take(key)
+	ctrl.checkpoints.invalidate(key)
+	if ctrl.checkpoints.save(key, generation, &scheduleCheckpoint{}) {
+		t.Errorf("expected the checkpoint of the outdated inputs not kept")
+	}
+}
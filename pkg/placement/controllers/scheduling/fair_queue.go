@@ -0,0 +1,173 @@
+package scheduling
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+
+	"open-cluster-management.io/ocm/pkg/placement/metrics"
+)
+
+// fairQueue is a workqueue taking the placements of the namespaces in turn. The placements of a namespace are
+// queued in the order they are added, and each Get takes the first placement of the next namespace, so a
+// placement requeued after a long reconcile does not delay the placements of the other namespaces.
+//
+// Like the workqueue.Type, a placement is queued only once, and a placement added while it is processed is
+// queued again once it is done, so a placement is never processed by two workers at a time.
+type fairQueue struct {
+	cond  *sync.Cond
+	clock clock.PassiveClock
+
+	// queues is the placements queued of each namespace, and namespaces is the namespaces with the placements
+	// queued, in the order they are taken.
+	queues     map[string][]interface{}
+	namespaces []string
+
+	// dirty is the placements to be processed with the time they are added, and processing is the placements
+	// being processed.
+	dirty      map[interface{}]time.Time
+	processing map[interface{}]struct{}
+
+	shuttingDown bool
+	drain        bool
+}
+
+var _ workqueue.Interface = &fairQueue{}
+
+// newFairQueue returns a rate limiting queue taking the placements of the namespaces in turn.
+func newFairQueue(name string) workqueue.RateLimitingInterface {
+	return workqueue.NewRateLimitingQueueWithDelayingInterface(
+		workqueue.NewDelayingQueueWithCustomQueue(newFairQueueWithClock(clock.RealClock{}), name),
+		workqueue.DefaultControllerRateLimiter())
+}
+
+func newFairQueueWithClock(clock clock.PassiveClock) *fairQueue {
+	return &fairQueue{
+		cond:       sync.NewCond(&sync.Mutex{}),
+		clock:      clock,
+		queues:     map[string][]interface{}{},
+		dirty:      map[interface{}]time.Time{},
+		processing: map[interface{}]struct{}{},
+	}
+}
+
+func (q *fairQueue) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+
+	q.dirty[item] = q.clock.Now()
+	if _, ok := q.processing[item]; ok {
+		return
+	}
+	q.push(item)
+	q.cond.Signal()
+}
+
+func (q *fairQueue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	length := 0
+	for _, items := range q.queues {
+		length += len(items)
+	}
+	return length
+}
+
+func (q *fairQueue) Get() (interface{}, bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for len(q.namespaces) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if len(q.namespaces) == 0 {
+		// the queue is shutting down
+		return nil, true
+	}
+
+	// take the first placement of the next namespace, the namespace is moved to the end if it has more
+	// placements queued
+	namespace := q.namespaces[0]
+	q.namespaces = q.namespaces[1:]
+	item := q.queues[namespace][0]
+	if len(q.queues[namespace]) > 1 {
+		q.queues[namespace] = q.queues[namespace][1:]
+		q.namespaces = append(q.namespaces, namespace)
+	} else {
+		delete(q.queues, namespace)
+	}
+
+	metrics.SchedulingQueueWaitDuration.Observe(q.clock.Since(q.dirty[item]).Seconds())
+	q.processing[item] = struct{}{}
+	delete(q.dirty, item)
+	return item, false
+}
+
+func (q *fairQueue) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.push(item)
+		q.cond.Signal()
+	} else if len(q.processing) == 0 {
+		q.cond.Broadcast()
+	}
+}
+
+func (q *fairQueue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.drain = false
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts down the queue and waits until all the placements being processed are done.
+func (q *fairQueue) ShutDownWithDrain() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.drain = true
+	q.shuttingDown = true
+	q.cond.Broadcast()
+	for len(q.processing) != 0 && q.drain {
+		q.cond.Wait()
+	}
+}
+
+func (q *fairQueue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.shuttingDown
+}
+
+// push queues the placement after the other placements of its namespace, the lock must be held.
+func (q *fairQueue) push(item interface{}) {
+	namespace := fairQueueNamespace(item)
+	if _, ok := q.queues[namespace]; !ok {
+		q.namespaces = append(q.namespaces, namespace)
+	}
+	q.queues[namespace] = append(q.queues[namespace], item)
+}
+
+// fairQueueNamespace returns the namespace of the placement key, the keys not in format namespace/name share
+// the empty namespace.
+func fairQueueNamespace(item interface{}) string {
+	key, ok := item.(string)
+	if !ok {
+		return ""
+	}
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return ""
+	}
+	return namespace
+}
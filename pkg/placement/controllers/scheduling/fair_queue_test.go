@@ -0,0 +1,61 @@
+package scheduling
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/clock"
+)
+
+func TestFairQueueRoundRobin(t *testing.T) {
+	queue := newFairQueueWithClock(clock.RealClock{})
+	for _, key := range []string{"ns1/p1", "ns1/p2", "ns1/p3", "ns2/p1", "ns1/p1", "ns3/p1", "ns2/p2"} {
+		queue.Add(key)
+	}
+	if queue.Len() != 6 {
+		t.Errorf("expected 6 placements queued, but got %d", queue.Len())
+	}
+
+	actual := []string{}
+	for queue.Len() > 0 {
+		item, _ := queue.Get()
+		actual = append(actual, item.(string))
+		queue.Done(item)
+	}
+
+	expected := []string{"ns1/p1", "ns2/p1", "ns3/p1", "ns1/p2", "ns2/p2", "ns1/p3"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected placements taken in order %v, but got %v", expected, actual)
+	}
+}
+
+func TestFairQueueRequeueWhileProcessing(t *testing.T) {
+	queue := newFairQueueWithClock(clock.RealClock{})
+	queue.Add("ns1/p1")
+	queue.Add("ns2/p1")
+
+	item, _ := queue.Get()
+	if item != "ns1/p1" {
+		t.Fatalf("expected ns1/p1, but got %v", item)
+	}
+
+	// the placement is requeued after the other namespaces once it is done
+	queue.Add("ns1/p1")
+	if queue.Len() != 1 {
+		t.Errorf("expected the placement being processed not queued, but got %d queued", queue.Len())
+	}
+	queue.Done(item)
+
+	for _, expected := range []string{"ns2/p1", "ns1/p1"} {
+		item, _ := queue.Get()
+		if item != expected {
+			t.Errorf("expected %s, but got %v", expected, item)
+		}
+		queue.Done(item)
+	}
+
+	queue.ShutDown()
+	if _, quit := queue.Get(); !quit {
+		t.Errorf("expected the queue shut down")
+	}
+}
@@ -22,7 +22,9 @@ import (
 	"open-cluster-management.io/ocm/pkg/placement/plugins/addon"
 	"open-cluster-management.io/ocm/pkg/placement/plugins/balance"
 	"open-cluster-management.io/ocm/pkg/placement/plugins/predicate"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/preferredclusterselector"
 	"open-cluster-management.io/ocm/pkg/placement/plugins/resource"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/spread"
 	"open-cluster-management.io/ocm/pkg/placement/plugins/steady"
 	"open-cluster-management.io/ocm/pkg/placement/plugins/tainttoleration"
 )
@@ -32,6 +34,17 @@ const (
 	PrioritizerSteady                    string = "Steady"
 	PrioritizerResourceAllocatableCPU    string = "ResourceAllocatableCPU"
 	PrioritizerResourceAllocatableMemory string = "ResourceAllocatableMemory"
+	PrioritizerPreferredClusterSelector  string = "PreferredClusterSelector"
+	PrioritizerTaintToleration           string = "TaintToleration"
+	PrioritizerSpread                    string = "Spread"
+)
+
+const (
+	// MinPrioritizerWeight and MaxPrioritizerWeight are the bounds of the prioritizer weights in both the
+	// Additive and the Exact mode. With the scores normalized into [MinClusterScore, MaxClusterScore], a weight
+	// means the same regardless of the prioritizer.
+	MinPrioritizerWeight int32 = -10
+	MaxPrioritizerWeight int32 = 10
 )
 
 // PrioritizerScore defines the score for each cluster
@@ -64,6 +77,10 @@ type ScheduleResult interface {
 
 	// RequeueAfter returns the requeue time interval of the placement
 	RequeueAfter() *time.Duration
+
+	// EvictionSuspension returns the message why the evictions of the clusters in the decisions are suspended,
+	// it is empty if no eviction is suspended.
+	EvictionSuspension() string
 }
 
 type FilterResult struct {
@@ -89,11 +106,15 @@ type scheduleResult struct {
 	scoreRecords    []PrioritizerResult
 	scoreSum        PrioritizerScore
 	requeueAfter    *time.Duration
+
+	evictionSuspension string
 }
 
 type schedulerHandler struct {
 	recorder                kevents.EventRecorder
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister
+	placementLister         clusterlisterv1beta1.PlacementLister
+	clusterDecisionIndex    *plugins.ClusterDecisionIndex
 	scoreLister             clusterlisterv1alpha1.AddOnPlacementScoreLister
 	clusterLister           clusterlisterv1.ManagedClusterLister
 	clusterClient           clusterclient.Interface
@@ -102,6 +123,8 @@ type schedulerHandler struct {
 func NewSchedulerHandler(
 	clusterClient clusterclient.Interface,
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister,
+	placementLister clusterlisterv1beta1.PlacementLister,
+	clusterDecisionIndex *plugins.ClusterDecisionIndex,
 	scoreLister clusterlisterv1alpha1.AddOnPlacementScoreLister,
 	clusterLister clusterlisterv1.ManagedClusterLister,
 	recorder kevents.EventRecorder) plugins.Handle {
@@ -109,6 +132,8 @@ func NewSchedulerHandler(
 	return &schedulerHandler{
 		recorder:                recorder,
 		placementDecisionLister: placementDecisionLister,
+		placementLister:         placementLister,
+		clusterDecisionIndex:    clusterDecisionIndex,
 		scoreLister:             scoreLister,
 		clusterLister:           clusterLister,
 		clusterClient:           clusterClient,
@@ -123,6 +148,14 @@ func (s *schedulerHandler) DecisionLister() clusterlisterv1beta1.PlacementDecisi
 	return s.placementDecisionLister
 }
 
+func (s *schedulerHandler) PlacementLister() clusterlisterv1beta1.PlacementLister {
+	return s.placementLister
+}
+
+func (s *schedulerHandler) ClusterDecisionIndex() *plugins.ClusterDecisionIndex {
+	return s.clusterDecisionIndex
+}
+
 func (s *schedulerHandler) ScoreLister() clusterlisterv1alpha1.AddOnPlacementScoreLister {
 	return s.scoreLister
 }
@@ -149,6 +182,19 @@ var defaultPrioritizerConfig = map[clusterapiv1beta1.ScoreCoordinate]int32{
 	}: 1,
 }
 
+// scheduleCheckpoint is the progress of the scheduling of a placement yielded once the time budget is exceeded.
+type scheduleCheckpoint struct {
+	filtered         []*clusterapiv1.ManagedCluster
+	weights          map[clusterapiv1beta1.ScoreCoordinate]int32
+	prioritizers     map[clusterapiv1beta1.ScoreCoordinate]plugins.Prioritizer
+	scoreCoordinates []clusterapiv1beta1.ScoreCoordinate
+	// scored is the number of the prioritizers in scoreCoordinates which already score the clusters.
+	scored   int
+	scoreSum PrioritizerScore
+	results  *scheduleResult
+	status   *framework.Status
+}
+
 type pluginScheduler struct {
 	handle             plugins.Handle
 	filters            []plugins.Filter
@@ -171,76 +217,52 @@ func (s *pluginScheduler) Schedule(
 	placement *clusterapiv1beta1.Placement,
 	clusters []*clusterapiv1.ManagedCluster,
 ) (ScheduleResult, *framework.Status) {
-	filtered := clusters
-	finalStatus := framework.NewStatus("", framework.Success, "")
-
-	results := &scheduleResult{
-		filteredRecords: map[string][]*clusterapiv1.ManagedCluster{},
-		scoreRecords:    []PrioritizerResult{},
-	}
-
-	// filter clusters
-	filterPipline := []string{}
-
-	for _, f := range s.filters {
-		filterResult, status := f.Filter(ctx, placement, filtered)
-		filtered = filterResult.Filtered
+	results, _, status := s.scheduleWithBudget(ctx, placement, clusters, time.Time{}, nil)
+	return results, status
+}
 
-		switch {
-		case status.IsError():
-			return results, status
-		case status.Code() == framework.Warning:
-			klog.Warningf("%v", status.Message())
-			finalStatus = status
+// scheduleWithBudget schedules the placement like Schedule, but yields once the deadline is passed. The filters,
+// and each prioritizer afterwards, are a step of the scheduling. If the deadline is passed after a step and
+// more prioritizers are left, no result is returned but a checkpoint of the filtered clusters and the scores so
+// far, and the scheduling continues from the checkpoint in the next call. At least one step is run in each call,
+// so the scheduling always completes. The zero deadline means no time budget.
+func (s *pluginScheduler) scheduleWithBudget(
+	ctx context.Context,
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster,
+	deadline time.Time,
+	checkpoint *scheduleCheckpoint,
+) (ScheduleResult, *scheduleCheckpoint, *framework.Status) {
+	if checkpoint == nil {
+		var status *framework.Status
+		checkpoint, status = s.filter(ctx, placement, clusters)
+		if status.IsError() {
+			return checkpoint.results, nil, status
+		}
+		if budgetExceeded(deadline) && len(checkpoint.scoreCoordinates) > 0 {
+			return nil, checkpoint, checkpoint.status
 		}
-
-		filterPipline = append(filterPipline, f.Name())
-
-		results.filteredRecords[strings.Join(filterPipline, ",")] = filtered
-	}
-
-	// Prioritize clusters
-	// 1. Get weight for each prioritizers.
-	// For example, weights is {"Steady": 1, "Balance":1, "AddOn/default/ratio":3}.
-	weights, status := getWeights(s.prioritizerWeights, placement)
-	switch {
-	case status.IsError():
-		return results, status
-	case status.Code() == framework.Warning:
-		klog.Warningf("%v", status.Message())
-		finalStatus = status
-	}
-
-	// 2. Generate prioritizers for each placement whose weight != 0.
-	prioritizers, status := getPrioritizers(weights, s.handle)
-	switch {
-	case status.IsError():
-		return results, status
-	case status.Code() == framework.Warning:
-		klog.Warningf("%v", status.Message())
-		finalStatus = status
 	}
 
 	// 3. Calculate clusters scores.
-	scoreSum := PrioritizerScore{}
-	for _, cluster := range filtered {
-		scoreSum[cluster.Name] = 0
-	}
-	for sc, p := range prioritizers {
+	filtered, results, scoreSum := checkpoint.filtered, checkpoint.results, checkpoint.scoreSum
+	for checkpoint.scored < len(checkpoint.scoreCoordinates) {
+		sc := checkpoint.scoreCoordinates[checkpoint.scored]
+		p := checkpoint.prioritizers[sc]
 		// Get cluster score.
 		scoreResult, status := p.Score(ctx, placement, filtered)
 		score := scoreResult.Scores
 
 		switch {
 		case status.IsError():
-			return results, status
+			return results, nil, status
 		case status.Code() == framework.Warning:
 			klog.Warningf("%v", status.Message())
-			finalStatus = status
+			checkpoint.status = status
 		}
 
 		// Record prioritizer score and weight
-		weight := weights[sc]
+		weight := checkpoint.weights[sc]
 		results.scoreRecords = append(results.scoreRecords, PrioritizerResult{Name: p.Name(), Weight: weight, Scores: score})
 
 		// The final score is a sum of each prioritizer score * weight.
@@ -250,6 +272,10 @@ func (s *pluginScheduler) Schedule(
 			scoreSum[name] = scoreSum[name] + val*int64(weight)
 		}
 
+		checkpoint.scored++
+		if budgetExceeded(deadline) && checkpoint.scored < len(checkpoint.scoreCoordinates) {
+			return nil, checkpoint, checkpoint.status
+		}
 	}
 
 	// 4. Sort clusters by score, if score is equal, sort by name
@@ -280,14 +306,114 @@ func (s *pluginScheduler) Schedule(
 			results.requeueAfter = setRequeueAfter(results.requeueAfter, &newRequeueAfter)
 		}
 	}
-	for _, p := range prioritizers {
-		if r, _ := p.RequeueAfter(ctx, placement); r.RequeueTime != nil {
+	for _, sc := range checkpoint.scoreCoordinates {
+		if r, _ := checkpoint.prioritizers[sc].RequeueAfter(ctx, placement); r.RequeueTime != nil {
 			newRequeueAfter := time.Until(*r.RequeueTime)
 			results.requeueAfter = setRequeueAfter(results.requeueAfter, &newRequeueAfter)
 		}
 	}
 
-	return results, finalStatus
+	return results, nil, checkpoint.status
+}
+
+// filter runs the filters and resolves the prioritizers of the placement, it returns the checkpoint to
+// calculate the scores of the clusters from.
+func (s *pluginScheduler) filter(
+	ctx context.Context,
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster,
+) (*scheduleCheckpoint, *framework.Status) {
+	filtered := clusters
+	checkpoint := &scheduleCheckpoint{
+		results: &scheduleResult{
+			filteredRecords: map[string][]*clusterapiv1.ManagedCluster{},
+			scoreRecords:    []PrioritizerResult{},
+		},
+		status: framework.NewStatus("", framework.Success, ""),
+	}
+	results := checkpoint.results
+
+	// filter clusters
+	filterPipline := []string{}
+
+	for _, f := range s.filters {
+		filterResult, status := f.Filter(ctx, placement, filtered)
+		filtered = filterResult.Filtered
+		if len(filterResult.EvictionSuspension) > 0 {
+			results.evictionSuspension = filterResult.EvictionSuspension
+		}
+
+		switch {
+		case status.IsError():
+			return checkpoint, status
+		case status.Code() == framework.Warning:
+			klog.Warningf("%v", status.Message())
+			checkpoint.status = status
+		}
+
+		filterPipline = append(filterPipline, f.Name())
+
+		results.filteredRecords[strings.Join(filterPipline, ",")] = filtered
+	}
+
+	// Prioritize clusters
+	// 1. Get weight for each prioritizers.
+	// For example, weights is {"Steady": 1, "Balance":1, "AddOn/default/ratio":3}.
+	weights, status := getWeights(s.prioritizerWeights, placement, filtered)
+	switch {
+	case status.IsError():
+		return checkpoint, status
+	case status.Code() == framework.Warning:
+		klog.Warningf("%v", status.Message())
+		checkpoint.status = status
+	}
+
+	// 2. Generate prioritizers for each placement whose weight != 0.
+	prioritizers, status := getPrioritizers(weights, s.handle)
+	switch {
+	case status.IsError():
+		return checkpoint, status
+	case status.Code() == framework.Warning:
+		klog.Warningf("%v", status.Message())
+		checkpoint.status = status
+	}
+
+	scoreSum := PrioritizerScore{}
+	for _, cluster := range filtered {
+		scoreSum[cluster.Name] = 0
+	}
+
+	checkpoint.filtered = filtered
+	checkpoint.weights = weights
+	checkpoint.prioritizers = prioritizers
+	checkpoint.scoreCoordinates = sortedScoreCoordinates(prioritizers)
+	checkpoint.scoreSum = scoreSum
+	return checkpoint, checkpoint.status
+}
+
+func budgetExceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+// sortedScoreCoordinates returns the score coordinates of the map sorted by the builtin prioritizer name or the
+// addon resource and score name. The prioritizers are iterated in this order, so the scheduling result and the
+// reported status do not depend on the map iteration order.
+func sortedScoreCoordinates[V any](m map[clusterapiv1beta1.ScoreCoordinate]V) []clusterapiv1beta1.ScoreCoordinate {
+	keys := make([]clusterapiv1beta1.ScoreCoordinate, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return scoreCoordinateKey(keys[i]) < scoreCoordinateKey(keys[j])
+	})
+	return keys
+}
+
+func scoreCoordinateKey(sc clusterapiv1beta1.ScoreCoordinate) string {
+	if sc.Type == clusterapiv1beta1.ScoreCoordinateTypeBuiltIn || sc.AddOn == nil {
+		return fmt.Sprintf("%s/%s", sc.Type, sc.BuiltIn)
+	}
+	return fmt.Sprintf("%s/%s/%s", sc.Type, sc.AddOn.ResourceName, sc.AddOn.ScoreName)
 }
 
 // makeClusterDecisions selects clusters based on given cluster slice and then creates
@@ -328,22 +454,48 @@ func setRequeueAfter(requeueAfter, newRequeueAfter *time.Duration) *time.Duratio
 
 // Get prioritizer weight for the placement.
 // In Additive and "" mode, will override defaultWeight with what placement has defined and return.
+// The PreferredClusterSelector prioritizer weights 1 by default if the placement has preferred cluster selectors,
+// and the TaintToleration prioritizer weights 1 by default if any cluster has PreferNoSelect taints not tolerated
+// by the placement.
 // In Exact mode, will return the name and weight defined in placement.
 func getWeights(defaultWeight map[clusterapiv1beta1.ScoreCoordinate]int32,
-	placement *clusterapiv1beta1.Placement) (map[clusterapiv1beta1.ScoreCoordinate]int32, *framework.Status) {
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (map[clusterapiv1beta1.ScoreCoordinate]int32, *framework.Status) {
 	mode := placement.Spec.PrioritizerPolicy.Mode
 	switch {
 	case mode == clusterapiv1beta1.PrioritizerPolicyModeExact:
-		return mergeWeights(nil, placement.Spec.PrioritizerPolicy.Configurations)
+		return mergeWeights(mode, nil, placement.Spec.PrioritizerPolicy.Configurations)
 	case mode == clusterapiv1beta1.PrioritizerPolicyModeAdditive || mode == "":
-		return mergeWeights(defaultWeight, placement.Spec.PrioritizerPolicy.Configurations)
+		weights := map[clusterapiv1beta1.ScoreCoordinate]int32{}
+		if _, ok := placement.Annotations[preferredclusterselector.PreferredClusterSelectorsAnnotation]; ok {
+			weights[clusterapiv1beta1.ScoreCoordinate{
+				Type:    clusterapiv1beta1.ScoreCoordinateTypeBuiltIn,
+				BuiltIn: PrioritizerPreferredClusterSelector,
+			}] = 1
+		}
+		for _, cluster := range clusters {
+			if tainttoleration.HasUntoleratedPreferNoSelectTaint(cluster, placement.Spec.Tolerations) {
+				weights[clusterapiv1beta1.ScoreCoordinate{
+					Type:    clusterapiv1beta1.ScoreCoordinateTypeBuiltIn,
+					BuiltIn: PrioritizerTaintToleration,
+				}] = 1
+				break
+			}
+		}
+		for sc, w := range defaultWeight {
+			weights[sc] = w
+		}
+		return mergeWeights(mode, weights, placement.Spec.PrioritizerPolicy.Configurations)
 	default:
 		msg := fmt.Sprintf("incorrect prioritizer policy mode: %s", mode)
 		return nil, framework.NewStatus("", framework.Misconfigured, msg)
 	}
 }
 
-func mergeWeights(defaultWeight map[clusterapiv1beta1.ScoreCoordinate]int32,
+// mergeWeights overrides the default weights with the customized ones, a customized weight out of
+// [MinPrioritizerWeight, MaxPrioritizerWeight] is misconfigured.
+func mergeWeights(mode clusterapiv1beta1.PrioritizerPolicyModeType,
+	defaultWeight map[clusterapiv1beta1.ScoreCoordinate]int32,
 	customizedWeight []clusterapiv1beta1.PrioritizerConfig,
 ) (map[clusterapiv1beta1.ScoreCoordinate]int32, *framework.Status) {
 	weights := make(map[clusterapiv1beta1.ScoreCoordinate]int32)
@@ -355,11 +507,18 @@ func mergeWeights(defaultWeight map[clusterapiv1beta1.ScoreCoordinate]int32,
 
 	// override default weight
 	for _, c := range customizedWeight {
-		if c.ScoreCoordinate != nil {
-			weights[*c.ScoreCoordinate] = c.Weight
-		} else {
+		if c.ScoreCoordinate == nil {
 			return nil, framework.NewStatus("", framework.Misconfigured, "scoreCoordinate field is required")
 		}
+		if c.Weight < MinPrioritizerWeight || c.Weight > MaxPrioritizerWeight {
+			if len(mode) == 0 {
+				mode = clusterapiv1beta1.PrioritizerPolicyModeAdditive
+			}
+			msg := fmt.Sprintf("the weight %d of prioritizer %s should be in [%d, %d] in %s mode",
+				c.Weight, scoreCoordinateKey(*c.ScoreCoordinate), MinPrioritizerWeight, MaxPrioritizerWeight, mode)
+			return nil, framework.NewStatus("", framework.Misconfigured, msg)
+		}
+		weights[*c.ScoreCoordinate] = c.Weight
 	}
 	return weights, status
 }
@@ -369,8 +528,8 @@ func getPrioritizers(weights map[clusterapiv1beta1.ScoreCoordinate]int32, handle
 ) (map[clusterapiv1beta1.ScoreCoordinate]plugins.Prioritizer, *framework.Status) {
 	result := make(map[clusterapiv1beta1.ScoreCoordinate]plugins.Prioritizer)
 	status := framework.NewStatus("", framework.Success, "")
-	for k, v := range weights {
-		if v == 0 {
+	for _, k := range sortedScoreCoordinates(weights) {
+		if weights[k] == 0 {
 			continue
 		}
 		if k.Type == clusterapiv1beta1.ScoreCoordinateTypeBuiltIn {
@@ -381,6 +540,12 @@ func getPrioritizers(weights map[clusterapiv1beta1.ScoreCoordinate]int32, handle
 				result[k] = steady.New(handle)
 			case k.BuiltIn == PrioritizerResourceAllocatableCPU || k.BuiltIn == PrioritizerResourceAllocatableMemory:
 				result[k] = resource.NewResourcePrioritizerBuilder(handle).WithPrioritizerName(k.BuiltIn).Build()
+			case k.BuiltIn == PrioritizerPreferredClusterSelector:
+				result[k] = preferredclusterselector.New(handle)
+			case k.BuiltIn == PrioritizerTaintToleration:
+				result[k] = tainttoleration.New(handle)
+			case k.BuiltIn == PrioritizerSpread:
+				result[k] = spread.New(handle)
 			default:
 				msg := fmt.Sprintf("incorrect builtin prioritizer: %s", k.BuiltIn)
 				return nil, framework.NewStatus("", framework.Misconfigured, msg)
@@ -438,3 +603,7 @@ func (r *scheduleResult) NumOfUnscheduled() int {
 func (r *scheduleResult) RequeueAfter() *time.Duration {
 	return r.requeueAfter
 }
+
+func (r *scheduleResult) EvictionSuspension() string {
+	return r.evictionSuspension
+}
@@ -0,0 +1,81 @@
+package scheduling
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/metrics"
+)
+
+// budgetedScheduler is a Scheduler able to yield once the time budget of a reconcile is exceeded, and to continue
+// the scheduling from the checkpoint returned.
+type budgetedScheduler interface {
+	scheduleWithBudget(
+		ctx context.Context,
+		placement *clusterapiv1beta1.Placement,
+		clusters []*clusterapiv1.ManagedCluster,
+		deadline time.Time,
+		checkpoint *scheduleCheckpoint,
+	) (ScheduleResult, *scheduleCheckpoint, *framework.Status)
+}
+
+// scheduleCheckpoints keeps the checkpoints of the yielded placements by the key. The checkpoint of a placement
+// is dropped once the placement is enqueued by a change of its inputs, e.g. the placement, the clusters, the
+// clustersets, the bindings or the scores, so the scheduling restarts with the new inputs. The generation of a
+// placement is increased each time, so the checkpoint of a scheduling started before the change is not kept.
+type scheduleCheckpoints struct {
+	lock        sync.Mutex
+	checkpoints map[string]*scheduleCheckpoint
+	generations map[string]int64
+}
+
+func newScheduleCheckpoints() *scheduleCheckpoints {
+	return &scheduleCheckpoints{
+		checkpoints: map[string]*scheduleCheckpoint{},
+		generations: map[string]int64{},
+	}
+}
+
+// take removes and returns the checkpoint of the placement, with the generation of the placement.
+func (s *scheduleCheckpoints) take(key string) (*scheduleCheckpoint, int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	checkpoint := s.checkpoints[key]
+	delete(s.checkpoints, key)
+	return checkpoint, s.generations[key]
+}
+
+// save keeps the checkpoint of the placement if the generation is not changed since the checkpoint is taken.
+func (s *scheduleCheckpoints) save(key string, generation int64, checkpoint *scheduleCheckpoint) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.generations[key] != generation {
+		return false
+	}
+	s.checkpoints[key] = checkpoint
+	return true
+}
+
+// invalidate drops the checkpoint of the placement since its inputs are changed.
+func (s *scheduleCheckpoints) invalidate(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.checkpoints[key]; ok {
+		metrics.SchedulingRestarts.Inc()
+		delete(s.checkpoints, key)
+	}
+	s.generations[key]++
+}
+
+// forget removes the placement once the scheduling is completed or the placement is deleted.
+func (s *scheduleCheckpoints) forget(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.checkpoints, key)
+	delete(s.generations, key)
+}
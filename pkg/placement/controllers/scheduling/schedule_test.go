@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -18,6 +19,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
 	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/preferredclusterselector"
 )
 
 func TestSchedule(t *testing.T) {
@@ -392,6 +394,218 @@ func TestSchedule(t *testing.T) {
 			expectedUnScheduled: 0,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
 		},
+		{
+			name: "placement with preferred cluster selectors in additive mode",
+			placement: testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName, map[string]string{
+				preferredclusterselector.PreferredClusterSelectorsAnnotation: `[{"weight": 10, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]`,
+			}).WithNOC(1).WithPrioritizerPolicy("Additive").Build(),
+			initObjs: []runtime.Object{
+				testinghelpers.NewClusterSet(clusterSetName).Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+			},
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, clusterSetName).Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, clusterSetName).WithLabel("ssd", "true").Build(),
+			},
+			decisions: []runtime.Object{},
+			expectedDecisions: []clusterapiv1beta1.ClusterDecision{
+				{ClusterName: "cluster2"},
+			},
+			expectedFilterResult: []FilterResult{
+				{
+					Name:             "Predicate",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration",
+					FilteredClusters: []string{"cluster2", "cluster1"},
+				},
+			},
+			expectedScoreResult: []PrioritizerResult{
+				{
+					Name:   "Balance",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 100, "cluster2": 100},
+				},
+				{
+					Name:   "Steady",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0},
+				},
+				{
+					Name:   "PreferredClusterSelector",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 100},
+				},
+			},
+			expectedUnScheduled: 0,
+			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
+		},
+		{
+			name: "placement with preferred cluster selectors in exact mode without the prioritizer",
+			placement: testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName, map[string]string{
+				preferredclusterselector.PreferredClusterSelectorsAnnotation: `[{"weight": 10, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]`,
+			}).WithNOC(1).WithPrioritizerPolicy("Exact").WithPrioritizerConfig("Balance", 1).Build(),
+			initObjs: []runtime.Object{
+				testinghelpers.NewClusterSet(clusterSetName).Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+			},
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, clusterSetName).Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, clusterSetName).WithLabel("ssd", "true").Build(),
+			},
+			decisions: []runtime.Object{},
+			expectedDecisions: []clusterapiv1beta1.ClusterDecision{
+				{ClusterName: "cluster1"},
+			},
+			expectedFilterResult: []FilterResult{
+				{
+					Name:             "Predicate",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
+			},
+			expectedScoreResult: []PrioritizerResult{
+				{
+					Name:   "Balance",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 100, "cluster2": 100},
+				},
+			},
+			expectedUnScheduled: 0,
+			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
+		},
+		{
+			name: "placement with preferred cluster selectors in exact mode",
+			placement: testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName, map[string]string{
+				preferredclusterselector.PreferredClusterSelectorsAnnotation: `[{"weight": 10, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]`,
+			}).WithNOC(1).WithPrioritizerPolicy("Exact").WithPrioritizerConfig("PreferredClusterSelector", 2).Build(),
+			initObjs: []runtime.Object{
+				testinghelpers.NewClusterSet(clusterSetName).Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+			},
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, clusterSetName).Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, clusterSetName).WithLabel("ssd", "true").Build(),
+			},
+			decisions: []runtime.Object{},
+			expectedDecisions: []clusterapiv1beta1.ClusterDecision{
+				{ClusterName: "cluster2"},
+			},
+			expectedFilterResult: []FilterResult{
+				{
+					Name:             "Predicate",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration",
+					FilteredClusters: []string{"cluster2", "cluster1"},
+				},
+			},
+			expectedScoreResult: []PrioritizerResult{
+				{
+					Name:   "PreferredClusterSelector",
+					Weight: 2,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 100},
+				},
+			},
+			expectedUnScheduled: 0,
+			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
+		},
+		{
+			name:      "placement with the only cluster tainted by PreferNoSelect",
+			placement: testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(1).Build(),
+			initObjs: []runtime.Object{
+				testinghelpers.NewClusterSet(clusterSetName).Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+			},
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, clusterSetName).WithTaint(
+					&clusterapiv1.Taint{Key: "key1", Value: "value1", Effect: clusterapiv1.TaintEffectPreferNoSelect}).Build(),
+			},
+			decisions: []runtime.Object{},
+			expectedDecisions: []clusterapiv1beta1.ClusterDecision{
+				{ClusterName: "cluster1"},
+			},
+			expectedFilterResult: []FilterResult{
+				{
+					Name:             "Predicate",
+					FilteredClusters: []string{"cluster1"},
+				},
+				{
+					Name:             "Predicate,TaintToleration",
+					FilteredClusters: []string{"cluster1"},
+				},
+			},
+			expectedScoreResult: []PrioritizerResult{
+				{
+					Name:   "Balance",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 100},
+				},
+				{
+					Name:   "Steady",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0},
+				},
+				{
+					Name:   "TaintToleration",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": -100},
+				},
+			},
+			expectedUnScheduled: 0,
+			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
+		},
+		{
+			name:      "placement avoids the cluster tainted by PreferNoSelect",
+			placement: testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(1).Build(),
+			initObjs: []runtime.Object{
+				testinghelpers.NewClusterSet(clusterSetName).Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+			},
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, clusterSetName).WithTaint(
+					&clusterapiv1.Taint{Key: "key1", Value: "value1", Effect: clusterapiv1.TaintEffectPreferNoSelect}).Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, clusterSetName).Build(),
+			},
+			decisions: []runtime.Object{},
+			expectedDecisions: []clusterapiv1beta1.ClusterDecision{
+				{ClusterName: "cluster2"},
+			},
+			expectedFilterResult: []FilterResult{
+				{
+					Name:             "Predicate",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration",
+					FilteredClusters: []string{"cluster2", "cluster1"},
+				},
+			},
+			expectedScoreResult: []PrioritizerResult{
+				{
+					Name:   "Balance",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 100, "cluster2": 100},
+				},
+				{
+					Name:   "Steady",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0},
+				},
+				{
+					Name:   "TaintToleration",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": -100, "cluster2": 0},
+				},
+			},
+			expectedUnScheduled: 0,
+			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
+		},
 		{
 			name:      "placement with part of decisions scheduled",
 			placement: testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(4).Build(),
@@ -589,6 +803,123 @@ func TestSchedule(t *testing.T) {
 	}
 }
 
+// TestScheduleSpread schedules the placements sequentially, the selections spread across the clusters
+// rather than stack on the same clusters.
+func TestScheduleSpread(t *testing.T) {
+	clusterSetName := "clusterSets"
+	placementNamespace := "ns1"
+
+	clusters := []*clusterapiv1.ManagedCluster{}
+	for i := 1; i <= 4; i++ {
+		clusters = append(clusters, testinghelpers.NewManagedCluster(fmt.Sprintf("cluster%d", i)).
+			WithLabel(clusterSetLabel, clusterSetName).Build())
+	}
+	objects := []runtime.Object{
+		testinghelpers.NewClusterSet(clusterSetName).Build(),
+		testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+	}
+
+	handle := testinghelpers.NewFakePluginHandle(t, clusterfake.NewSimpleClientset(objects...), objects...)
+	s := NewPluginScheduler(handle)
+
+	selected := map[string]int{}
+	var previous []string
+	for i := 1; i <= 4; i++ {
+		placementName := fmt.Sprintf("placement%d", i)
+		placement := testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(2).
+			WithPrioritizerPolicy(clusterapiv1beta1.PrioritizerPolicyModeExact).
+			WithPrioritizerConfig(PrioritizerSpread, 1).Build()
+
+		result, status := s.Schedule(context.TODO(), placement, clusters)
+		if err := status.AsError(); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		current := []string{}
+		for _, d := range result.Decisions() {
+			current = append(current, d.ClusterName)
+			selected[d.ClusterName]++
+		}
+		for _, cluster := range previous {
+			for _, c := range current {
+				if c == cluster {
+					t.Errorf("expected %s to select different clusters than the previous placement, but got %v", placementName, current)
+				}
+			}
+		}
+		previous = current
+
+		handle.ClusterDecisionIndex().OnAdd(testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 1)).
+			WithLabel(clusterapiv1beta1.PlacementLabel, placementName).WithDecisions(current...).Build(), false)
+	}
+
+	for _, cluster := range clusters {
+		if selected[cluster.Name] != 2 {
+			t.Errorf("expected cluster %s selected by 2 placements, but got %d", cluster.Name, selected[cluster.Name])
+		}
+	}
+}
+
+// TestScheduleWithBudget schedules a placement yielding after every step, the result continued from the
+// checkpoints should be the same as the one scheduled at once.
+func TestScheduleWithBudget(t *testing.T) {
+	clusterSetName := "clusterSets"
+	placementNamespace := "ns1"
+
+	clusters := []*clusterapiv1.ManagedCluster{}
+	for i := 1; i <= 4; i++ {
+		clusters = append(clusters, testinghelpers.NewManagedCluster(fmt.Sprintf("cluster%d", i)).
+			WithLabel(clusterSetLabel, clusterSetName).Build())
+	}
+	placement := testinghelpers.NewPlacement(placementNamespace, "placement1").WithNOC(2).
+		WithPrioritizerConfig(PrioritizerSpread, 1).Build()
+	objects := []runtime.Object{
+		testinghelpers.NewClusterSet(clusterSetName).Build(),
+		testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+		placement,
+	}
+	s := NewPluginScheduler(testinghelpers.NewFakePluginHandle(t, clusterfake.NewSimpleClientset(objects...), objects...))
+
+	expected, status := s.Schedule(context.TODO(), placement, clusters)
+	if err := status.AsError(); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	// the deadline is always passed, so the scheduling yields after the filters and each prioritizer but the last
+	var result ScheduleResult
+	var checkpoint *scheduleCheckpoint
+	yields := 0
+	for {
+		result, checkpoint, status = s.scheduleWithBudget(context.TODO(), placement, clusters, time.Now().Add(-time.Second), checkpoint)
+		if err := status.AsError(); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if checkpoint == nil {
+			break
+		}
+		if result != nil {
+			t.Errorf("expected no result returned with the checkpoint")
+		}
+		yields++
+	}
+
+	// Balance, Spread and Steady
+	if yields != 3 {
+		t.Errorf("expected the scheduling yields 3 times, but got %d", yields)
+	}
+	if !reflect.DeepEqual(result.Decisions(), expected.Decisions()) {
+		t.Errorf("expected decisions %v, but got %v", expected.Decisions(), result.Decisions())
+	}
+	if !reflect.DeepEqual(result.PrioritizerScores(), expected.PrioritizerScores()) {
+		t.Errorf("expected scores %v, but got %v", expected.PrioritizerScores(), result.PrioritizerScores())
+	}
+	actual, _ := json.Marshal(result.PrioritizerResults())
+	expectedResults, _ := json.Marshal(expected.PrioritizerResults())
+	if string(actual) != string(expectedResults) {
+		t.Errorf("expected prioritizer results %s, but got %s", expectedResults, actual)
+	}
+}
+
 func placementDecisionName(placementName string, index int) string {
 	return fmt.Sprintf("%s-decision-%d", placementName, index)
 }
@@ -596,3 +927,61 @@ func placementDecisionName(placementName string, index int) string {
 func TestFilterResults(t *testing.T) {
 
 }
+
+func TestGetWeights(t *testing.T) {
+	cases := []struct {
+		name            string
+		placement       *clusterapiv1beta1.Placement
+		expectedWeights map[string]int32
+		expectedMessage string
+	}{
+		{
+			name:      "additive mode with weights in bounds",
+			placement: testinghelpers.NewPlacement("ns1", "test").WithPrioritizerConfig(PrioritizerSpread, 10).Build(),
+			expectedWeights: map[string]int32{
+				PrioritizerBalance: 1, PrioritizerSteady: 1, PrioritizerSpread: 10,
+			},
+		},
+		{
+			name: "exact mode with weights in bounds",
+			placement: testinghelpers.NewPlacement("ns1", "test").
+				WithPrioritizerPolicy(clusterapiv1beta1.PrioritizerPolicyModeExact).
+				WithPrioritizerConfig(PrioritizerBalance, -10).Build(),
+			expectedWeights: map[string]int32{PrioritizerBalance: -10},
+		},
+		{
+			name:            "additive mode with weight out of bounds",
+			placement:       testinghelpers.NewPlacement("ns1", "test").WithPrioritizerConfig(PrioritizerSpread, 11).Build(),
+			expectedMessage: "the weight 11 of prioritizer BuiltIn/Spread should be in [-10, 10] in Additive mode",
+		},
+		{
+			name: "exact mode with weight out of bounds",
+			placement: testinghelpers.NewPlacement("ns1", "test").
+				WithPrioritizerPolicy(clusterapiv1beta1.PrioritizerPolicyModeExact).
+				WithScoreCoordinateAddOn("demo", "score", -20).Build(),
+			expectedMessage: "the weight -20 of prioritizer AddOn/demo/score should be in [-10, 10] in Exact mode",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			weights, status := getWeights(defaultPrioritizerConfig, c.placement, nil)
+			if len(c.expectedMessage) > 0 {
+				if status.Code() != framework.Misconfigured || status.Message() != c.expectedMessage {
+					t.Errorf("expected misconfigured status %q, but got %v", c.expectedMessage, status)
+				}
+				return
+			}
+			if err := status.AsError(); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			actual := map[string]int32{}
+			for sc, w := range weights {
+				actual[sc.BuiltIn] = w
+			}
+			if !reflect.DeepEqual(actual, c.expectedWeights) {
+				t.Errorf("expected weights %v, but got %v", c.expectedWeights, actual)
+			}
+		})
+	}
+}
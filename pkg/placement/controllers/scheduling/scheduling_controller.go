@@ -2,9 +2,11 @@ package scheduling
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,12 +19,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	cache "k8s.io/client-go/tools/cache"
 	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
@@ -37,7 +40,11 @@ import (
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 
+	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/placement/metrics"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/tainttoleration"
 )
 
 const (
@@ -61,6 +68,11 @@ type schedulingController struct {
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister
 	scheduler               Scheduler
 	recorder                kevents.EventRecorder
+
+	// timeBudget is the time after which the scheduling of a placement yields and the placement is requeued to
+	// continue from the checkpoint, it is disabled if it is 0.
+	timeBudget  time.Duration
+	checkpoints *scheduleCheckpoints
 }
 
 // NewSchedulingController return an instance of schedulingController
@@ -74,10 +86,10 @@ func NewSchedulingController(
 	placementScoreInformer clusterinformerv1alpha1.AddOnPlacementScoreInformer,
 	scheduler Scheduler,
 	recorder events.Recorder, krecorder kevents.EventRecorder,
+	timeBudget time.Duration,
 ) factory.Controller {
-	syncCtx := factory.NewSyncContext(schedulingControllerName, recorder)
-
-	enQueuer := newEnqueuer(syncCtx.Queue(), clusterInformer, clusterSetInformer, placementInformer, clusterSetBindingInformer)
+	metrics.Register()
+	queue := newFairQueue(schedulingControllerName)
 
 	// build controller
 	c := &schedulingController{
@@ -89,6 +101,20 @@ func NewSchedulingController(
 		placementDecisionLister: placementDecisionInformer.Lister(),
 		recorder:                krecorder,
 		scheduler:               scheduler,
+		timeBudget:              timeBudget,
+		checkpoints:             newScheduleCheckpoints(),
+	}
+
+	// the placements are enqueued by the changes of their inputs, the scheduling progress of a yielded placement
+	// is dropped then.
+	enQueuer := newEnqueuer(queue, clusterInformer, clusterSetInformer, placementInformer, clusterSetBindingInformer)
+	enQueuer.enqueuePlacementFunc = func(obj interface{}, queue workqueue.RateLimitingInterface) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		c.enqueue(queue, key)
 	}
 
 	// setup event handler for cluster informer.
@@ -150,31 +176,71 @@ func NewSchedulingController(
 		utilruntime.HandleError(err)
 	}
 
-	return factory.New().
-		WithSyncContext(syncCtx).
-		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
-			key, _ := cache.MetaNamespaceKeyFunc(obj)
-			return key
-		}, placementInformer.Informer()).
-		WithFilteredEventsInformersQueueKeyFunc(func(obj runtime.Object) string {
-			accessor, _ := meta.Accessor(obj)
-			labels := accessor.GetLabels()
-			placementName := labels[placementLabel]
-			return fmt.Sprintf("%s/%s", accessor.GetNamespace(), placementName)
-		}, func(obj interface{}) bool {
+	// setup event handler for placement informer
+	_, err = placementInformer.Informer().AddEventHandler(&cache.ResourceEventHandlerFuncs{
+		AddFunc: enQueuer.enqueuePlacement,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// the scores annotation is written by the controller itself after a scheduling, it does not
+			// require the placement to be scheduled again
+			if scoresAnnotationChangedOnly(oldObj, newObj) {
+				return
+			}
+			enQueuer.enqueuePlacement(newObj)
+		},
+		DeleteFunc: enQueuer.enqueuePlacement,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	// setup event handler for placementdecision informer, the placement of a changed placementdecision is
+	// enqueued
+	_, err = placementDecisionInformer.Informer().AddEventHandler(&cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
 			accessor, err := meta.Accessor(obj)
 			if err != nil {
 				return false
 			}
-			labels := accessor.GetLabels()
-			if _, ok := labels[placementLabel]; ok {
-				return true
-			}
-			return false
-		}, placementDecisionInformer.Informer()).
-		WithBareInformers(clusterInformer.Informer(), clusterSetInformer.Informer(), clusterSetBindingInformer.Informer(), placementScoreInformer.Informer()).
-		WithSync(c.sync).
-		ToController(schedulingControllerName, recorder)
+			_, ok := accessor.GetLabels()[placementLabel]
+			return ok
+		},
+		Handler: &cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				accessor, _ := meta.Accessor(obj)
+				c.enqueue(queue, fmt.Sprintf("%s/%s", accessor.GetNamespace(), accessor.GetLabels()[placementLabel]))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				accessor, _ := meta.Accessor(newObj)
+				c.enqueue(queue, fmt.Sprintf("%s/%s", accessor.GetNamespace(), accessor.GetLabels()[placementLabel]))
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					utilruntime.HandleError(err)
+					return
+				}
+				c.enqueue(queue, fmt.Sprintf("%s/%s", accessor.GetNamespace(), accessor.GetLabels()[placementLabel]))
+			},
+		},
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	// the placements are reconciled by multiple workers, and the workers take the placements of the namespaces
+	// in turn, so a placement selecting thousands of clusters does not delay the other placements.
+	return newFairController(schedulingControllerName, queue, recorder, c.sync,
+		placementInformer.Informer(), placementDecisionInformer.Informer(), clusterInformer.Informer(),
+		clusterSetInformer.Informer(), clusterSetBindingInformer.Informer(), placementScoreInformer.Informer())
+}
+
+// enqueue drops the scheduling progress of the placement since its inputs are changed, and enqueues it.
+func (c *schedulingController) enqueue(queue workqueue.Interface, key string) {
+	c.checkpoints.invalidate(key)
+	queue.Add(key)
 }
 
 func (c *schedulingController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
@@ -184,6 +250,9 @@ func (c *schedulingController) sync(ctx context.Context, syncCtx factory.SyncCon
 	placement, err := c.getPlacement(queueKey)
 	if errors.IsNotFound(err) {
 		// no work if placement is deleted
+		if c.checkpoints != nil {
+			c.checkpoints.forget(queueKey)
+		}
 		return nil
 	}
 	if err != nil {
@@ -235,8 +304,11 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 		return err
 	}
 
-	// schedule placement with scheduler
-	scheduleResult, status := c.scheduler.Schedule(ctx, placement, clusters)
+	// schedule placement with scheduler, the placement is requeued to continue if it yields
+	scheduleResult, status, completed := c.schedule(ctx, syncCtx, placement, clusters)
+	if !completed {
+		return nil
+	}
 	misconfiguredCondition := newMisconfiguredCondition(status)
 	satisfiedCondition := newSatisfiedCondition(
 		placement.Spec.ClusterSets,
@@ -247,6 +319,11 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 		scheduleResult.NumOfUnscheduled(),
 		status,
 	)
+	if placement.Spec.NumberOfClusters == nil &&
+		features.DefaultHubPlacementMutableFeatureGate.Enabled(features.PlacementSatisfiedConditionCounts) {
+		satisfiedCondition = withSelectedClusterCounts(
+			satisfiedCondition, len(clusters), len(scheduleResult.Decisions()), scheduleResult.FilterResults())
+	}
 
 	// requeue placement if requeueAfter is defined in scheduleResult
 	if syncCtx != nil && scheduleResult.RequeueAfter() != nil {
@@ -256,18 +333,77 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 		syncCtx.Queue().AddAfter(key, *t)
 	}
 
+	conditions := []metav1.Condition{misconfiguredCondition, satisfiedCondition}
+	if _, ok := placement.GetAnnotations()[tainttoleration.MinSelectedClustersAnnotation]; ok {
+		conditions = append(conditions, newEvictionSuspendedCondition(placement, scheduleResult.EvictionSuspension()))
+	}
+	if explainCluster, ok := placement.GetAnnotations()[helpers.ExplainClusterAnnotation]; ok {
+		explainedCondition, err := c.newExplainedCondition(explainCluster, clusterSetNames, clusters, scheduleResult, status)
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, explainedCondition)
+	}
+
 	if err := c.bind(ctx, placement, scheduleResult.Decisions(), scheduleResult.PrioritizerScores(), status); err != nil {
 		return err
 	}
 
 	// update placement status if necessary to signal no bindings
-	if err := c.updateStatus(ctx, placement, int32(len(scheduleResult.Decisions())), misconfiguredCondition, satisfiedCondition); err != nil {
+	if err := c.updateStatus(ctx, placement, int32(len(scheduleResult.Decisions())), conditions...); err != nil {
 		return err
 	}
 
+	// the annotations are patched after the status is updated, since the status is updated with the resourceVersion
+	if err := c.updateScoresAnnotation(ctx, placement, scheduleResult); err != nil {
+		return err
+	}
+	// the evictions are forced once only, the safeguard applies again to the next scheduling
+	if _, ok := placement.GetAnnotations()[tainttoleration.ForceEvictionAnnotation]; ok {
+		if err := c.removeAnnotation(ctx, placement, tainttoleration.ForceEvictionAnnotation); err != nil {
+			return err
+		}
+	}
+
 	return status.AsError()
 }
 
+// schedule schedules the placement within the time budget. If the time budget is exceeded, the checkpoint of the
+// scheduling is kept and the placement is requeued to continue, so the placements queued behind are reconciled
+// in the meantime. It returns false if the placement yields.
+func (c *schedulingController) schedule(
+	ctx context.Context,
+	syncCtx factory.SyncContext,
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster,
+) (ScheduleResult, *framework.Status, bool) {
+	scheduler, ok := c.scheduler.(budgetedScheduler)
+	if !ok || c.timeBudget <= 0 || c.checkpoints == nil || syncCtx == nil {
+		scheduleResult, status := c.scheduler.Schedule(ctx, placement, clusters)
+		return scheduleResult, status, true
+	}
+
+	key, _ := cache.MetaNamespaceKeyFunc(placement)
+	checkpoint, generation := c.checkpoints.take(key)
+	scheduleResult, checkpoint, status := scheduler.scheduleWithBudget(
+		ctx, placement, clusters, time.Now().Add(c.timeBudget), checkpoint)
+	if checkpoint == nil {
+		c.checkpoints.forget(key)
+		return scheduleResult, status, true
+	}
+
+	// the checkpoint is not kept if the inputs are changed in the meantime, the placement is enqueued by the
+	// change already and the scheduling restarts.
+	if c.checkpoints.save(key, generation, checkpoint) {
+		klog.V(4).Infof("Placement %s yields after %v, the scheduling continues after the other placements", key, c.timeBudget)
+		metrics.SchedulingYields.Inc()
+		syncCtx.Queue().Add(key)
+	} else {
+		metrics.SchedulingRestarts.Inc()
+	}
+	return nil, nil, false
+}
+
 // getManagedClusterSetBindings returns all bindings found in the placement namespace.
 func (c *schedulingController) getValidManagedClusterSetBindings(placementNamespace string) ([]*clusterapiv1beta2.ManagedClusterSetBinding, error) {
 	// get all clusterset bindings under the placement namespace
@@ -348,6 +484,10 @@ func (c *schedulingController) getAvailableClusters(clusterSetNames []string) ([
 	for _, c := range availableClusters {
 		result = append(result, c)
 	}
+	// sort the clusters by name, so the scheduling does not depend on the map iteration order.
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
 
 	return result, nil
 }
@@ -365,6 +505,14 @@ func (c *schedulingController) updateStatus(
 	for _, c := range conditions {
 		meta.SetStatusCondition(&newPlacement.Status.Conditions, c)
 	}
+	// the EvictionSuspended condition is only maintained with the eviction safeguard
+	if _, ok := placement.GetAnnotations()[tainttoleration.MinSelectedClustersAnnotation]; !ok {
+		meta.RemoveStatusCondition(&newPlacement.Status.Conditions, tainttoleration.PlacementConditionEvictionSuspended)
+	}
+	// the ClusterExplained condition is only maintained while a cluster is explained
+	if _, ok := placement.GetAnnotations()[helpers.ExplainClusterAnnotation]; !ok {
+		meta.RemoveStatusCondition(&newPlacement.Status.Conditions, helpers.PlacementConditionClusterExplained)
+	}
 	if reflect.DeepEqual(newPlacement.Status, placement.Status) {
 		return nil
 	}
@@ -375,6 +523,103 @@ func (c *schedulingController) updateStatus(
 	return err
 }
 
+// updateScoresAnnotation records the scores of the top selected clusters in the PrioritizerScoresAnnotation of
+// the placement if the PlacementPrioritizerScores feature is enabled, otherwise the annotation is removed.
+func (c *schedulingController) updateScoresAnnotation(
+	ctx context.Context,
+	placement *clusterapiv1beta1.Placement,
+	scheduleResult ScheduleResult,
+) error {
+	existing, ok := placement.Annotations[helpers.PrioritizerScoresAnnotation]
+	var value interface{}
+	if features.DefaultHubPlacementMutableFeatureGate.Enabled(features.PlacementPrioritizerScores) {
+		scores := make([]helpers.ClusterScore, 0, len(scheduleResult.Decisions()))
+		for _, decision := range scheduleResult.Decisions() {
+			score := helpers.ClusterScore{
+				ClusterName: decision.ClusterName,
+				Score:       scheduleResult.PrioritizerScores()[decision.ClusterName],
+			}
+			for _, result := range scheduleResult.PrioritizerResults() {
+				if s, ok := result.Scores[decision.ClusterName]; ok {
+					if score.Prioritizers == nil {
+						score.Prioritizers = map[string]int64{}
+					}
+					score.Prioritizers[result.Name] = s
+				}
+			}
+			scores = append(scores, score)
+		}
+		desired, err := helpers.TopClusterScores(scores, helpers.MaxClusterScoresInAnnotation)
+		if err != nil {
+			return err
+		}
+		if ok && existing == desired {
+			return nil
+		}
+		value = desired
+	} else if !ok {
+		return nil
+	}
+
+	// a nil value removes the annotation
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{helpers.PrioritizerScoresAnnotation: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.clusterClient.ClusterV1beta1().Placements(placement.Namespace).Patch(
+		ctx, placement.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// scoresAnnotationChangedOnly returns true if the PrioritizerScoresAnnotation is the only difference between the
+// two placements.
+func scoresAnnotationChangedOnly(oldObj, newObj interface{}) bool {
+	oldPlacement, ok := oldObj.(*clusterapiv1beta1.Placement)
+	if !ok {
+		return false
+	}
+	newPlacement, ok := newObj.(*clusterapiv1beta1.Placement)
+	if !ok {
+		return false
+	}
+	if oldPlacement.Annotations[helpers.PrioritizerScoresAnnotation] ==
+		newPlacement.Annotations[helpers.PrioritizerScoresAnnotation] {
+		return false
+	}
+
+	oldPlacement, newPlacement = oldPlacement.DeepCopy(), newPlacement.DeepCopy()
+	for _, placement := range []*clusterapiv1beta1.Placement{oldPlacement, newPlacement} {
+		delete(placement.Annotations, helpers.PrioritizerScoresAnnotation)
+		if len(placement.Annotations) == 0 {
+			placement.Annotations = nil
+		}
+		placement.ResourceVersion = ""
+		placement.ManagedFields = nil
+	}
+	return apiequality.Semantic.DeepEqual(oldPlacement, newPlacement)
+}
+
+// removeAnnotation removes the one-shot annotation of the placement once it is handled, e.g. the
+// ForceEvictionAnnotation once the evictions are forced, so it is handled again only if the annotation is set
+// again.
+func (c *schedulingController) removeAnnotation(ctx context.Context, placement *clusterapiv1beta1.Placement, key string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{key: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.clusterClient.ClusterV1beta1().Placements(placement.Namespace).Patch(
+		ctx, placement.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
 // newSatisfiedCondition returns a new condition with type PlacementConditionSatisfied
 func newSatisfiedCondition(
 	clusterSetsInSpec []string,
@@ -421,6 +666,68 @@ func newSatisfiedCondition(
 	return condition
 }
 
+// withSelectedClusterCounts reports the number of the selected clusters and the number of the clusters filtered
+// out by each filter in the satisfied condition of a placement without numberOfClusters. The condition is False
+// with reason NoClusterMatched if none of the available clusters is selected.
+func withSelectedClusterCounts(
+	condition metav1.Condition,
+	numOfAvailableClusters,
+	numOfSelectedClusters int,
+	filterResults []FilterResult,
+) metav1.Condition {
+	switch condition.Reason {
+	case "NoManagedClusterMatched":
+		condition.Reason = "NoClusterMatched"
+	case "AllDecisionsScheduled":
+	default:
+		// the condition is not about the cluster selection, e.g. no clusterset is bound.
+		return condition
+	}
+
+	condition.Message = fmt.Sprintf("%d of %d clusters selected", numOfSelectedClusters, numOfAvailableClusters)
+	var filteredOut []string
+	numOfClusters := numOfAvailableClusters
+	for _, result := range filterResults {
+		// the name of the result is the filter pipeline, the last filter filters out the clusters
+		pipeline := strings.Split(result.Name, ",")
+		if n := numOfClusters - len(result.FilteredClusters); n > 0 {
+			filteredOut = append(filteredOut, fmt.Sprintf("%s=%d", pipeline[len(pipeline)-1], n))
+		}
+		numOfClusters = len(result.FilteredClusters)
+	}
+	if len(filteredOut) > 0 {
+		condition.Message += fmt.Sprintf(", filtered out: %s", strings.Join(filteredOut, ", "))
+	}
+	return condition
+}
+
+// newEvictionSuspendedCondition returns the PlacementConditionEvictionSuspended condition with the message of
+// the suspension, it is False if no eviction is suspended or the safeguard is invalid.
+func newEvictionSuspendedCondition(placement *clusterapiv1beta1.Placement, suspension string) metav1.Condition {
+	if err := tainttoleration.ValidateMinSelectedClusters(placement); err != nil {
+		return metav1.Condition{
+			Type:    tainttoleration.PlacementConditionEvictionSuspended,
+			Status:  metav1.ConditionFalse,
+			Reason:  "InvalidMinSelectedClusters",
+			Message: fmt.Sprintf("The eviction safeguard is ignored: %v", err),
+		}
+	}
+	if len(suspension) > 0 {
+		return metav1.Condition{
+			Type:    tainttoleration.PlacementConditionEvictionSuspended,
+			Status:  metav1.ConditionTrue,
+			Reason:  "MinSelectedClusters",
+			Message: suspension,
+		}
+	}
+	return metav1.Condition{
+		Type:    tainttoleration.PlacementConditionEvictionSuspended,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoEvictionSuspended",
+		Message: "No eviction is suspended",
+	}
+}
+
 func newMisconfiguredCondition(status *framework.Status) metav1.Condition {
 	if status.Code() == framework.Misconfigured {
 		return metav1.Condition{
@@ -475,32 +782,57 @@ func (c *schedulingController) bind(
 		decisionSlices = append(decisionSlices, []clusterapiv1beta1.ClusterDecision{})
 	}
 
+	// the checksum of all the clusters is set on each placementdecision, so the consumers are able to detect
+	// the intermediate states before the redundant placementdecisions are deleted.
+	clusterNames := sets.New[string]()
+	for _, decision := range clusterDecisions {
+		clusterNames.Insert(decision.ClusterName)
+	}
+	checksum := helpers.DecisionChecksum(clusterNames)
+
+	// observe the placementdecisions before writing, nothing is written if the decisions are not changed, e.g.
+	// when the placement is resynced after the controller restarts.
+	requirement, err := labels.NewRequirement(placementLabel, selection.Equals, []string{placement.Name})
+	if err != nil {
+		return err
+	}
+	labelSelector := labels.NewSelector().Add(*requirement)
+	placementDecisions, err := c.placementDecisionLister.PlacementDecisions(placement.Namespace).List(labelSelector)
+	if err != nil {
+		return err
+	}
+	if decisionsUnchanged(placement, placementDecisions, decisionSlices, checksum) {
+		return nil
+	}
+
 	// bind cluster decision slices to placementdecisions.
 	errs := []error{}
 
 	placementDecisionNames := sets.NewString()
+	boundPlacementDecisions := []*clusterapiv1beta1.PlacementDecision{}
 	for index, decisionSlice := range decisionSlices {
 		placementDecisionName := fmt.Sprintf("%s-decision-%d", placement.Name, index+1)
 		placementDecisionNames.Insert(placementDecisionName)
-		err := c.createOrUpdatePlacementDecision(
-			ctx, placement, placementDecisionName, decisionSlice, clusterScores, status)
+		placementDecision, err := c.createOrUpdatePlacementDecision(
+			ctx, placement, placementDecisionName, index+1, checksum, decisionSlice, clusterScores, status)
 		if err != nil {
 			errs = append(errs, err)
+			continue
 		}
+		boundPlacementDecisions = append(boundPlacementDecisions, placementDecision)
 	}
 	if len(errs) != 0 {
 		return errorhelpers.NewMultiLineAggregate(errs)
 	}
 
-	// query all placementdecisions of the placement
-	requirement, err := labels.NewRequirement(placementLabel, selection.Equals, []string{placement.Name})
-	if err != nil {
-		return err
+	// update the index label and the checksum annotation once all the cluster decisions are bound.
+	for index, placementDecision := range boundPlacementDecisions {
+		if err := c.updatePlacementDecisionChecksum(ctx, placementDecision, index+1, checksum); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	labelSelector := labels.NewSelector().Add(*requirement)
-	placementDecisions, err := c.placementDecisionLister.PlacementDecisions(placement.Namespace).List(labelSelector)
-	if err != nil {
-		return err
+	if len(errs) != 0 {
+		return errorhelpers.NewMultiLineAggregate(errs)
 	}
 
 	// delete redundant placementdecisions
@@ -526,17 +858,20 @@ func (c *schedulingController) bind(
 }
 
 // createOrUpdatePlacementDecision creates a new PlacementDecision if it does not exist and
-// then updates the status with the given ClusterDecision slice if necessary
+// then updates the status with the given ClusterDecision slice if necessary. A new PlacementDecision
+// is created with the index label and the checksum annotation.
 func (c *schedulingController) createOrUpdatePlacementDecision(
 	ctx context.Context,
 	placement *clusterapiv1beta1.Placement,
 	placementDecisionName string,
+	index int,
+	checksum string,
 	clusterDecisions []clusterapiv1beta1.ClusterDecision,
 	clusterScores PrioritizerScore,
 	status *framework.Status,
-) error {
+) (*clusterapiv1beta1.PlacementDecision, error) {
 	if len(clusterDecisions) > maxNumOfClusterDecisions {
-		return fmt.Errorf("the number of clusterdecisions %q exceeds the max limitation %q", len(clusterDecisions), maxNumOfClusterDecisions)
+		return nil, fmt.Errorf("the number of clusterdecisions %q exceeds the max limitation %q", len(clusterDecisions), maxNumOfClusterDecisions)
 	}
 
 	placementDecision, err := c.placementDecisionLister.PlacementDecisions(placement.Namespace).Get(placementDecisionName)
@@ -549,7 +884,11 @@ func (c *schedulingController) createOrUpdatePlacementDecision(
 				Name:      placementDecisionName,
 				Namespace: placement.Namespace,
 				Labels: map[string]string{
-					placementLabel: placement.Name,
+					placementLabel:             placement.Name,
+					helpers.DecisionIndexLabel: strconv.Itoa(index),
+				},
+				Annotations: map[string]string{
+					helpers.DecisionChecksumAnnotation: checksum,
 				},
 				OwnerReferences: []metav1.OwnerReference{*owner},
 			},
@@ -558,30 +897,65 @@ func (c *schedulingController) createOrUpdatePlacementDecision(
 		placementDecision, err = c.clusterClient.ClusterV1beta1().PlacementDecisions(
 			placement.Namespace).Create(ctx, placementDecision, metav1.CreateOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
 		c.recorder.Eventf(
 			placement, placementDecision, corev1.EventTypeNormal,
 			"DecisionCreate", "DecisionCreated",
 			"Decision %s is created with placement %s in namespace %s", placementDecision.Name, placement.Name, placement.Namespace)
 	case err != nil:
-		return err
+		return nil, err
 	}
 
 	// update the status of the placementdecision if decisions change
-	if apiequality.Semantic.DeepEqual(placementDecision.Status.Decisions, clusterDecisions) {
-		return nil
+	if sameClusterDecisions(placementDecision.Status.Decisions, clusterDecisions) {
+		return placementDecision, nil
 	}
 
 	newPlacementDecision := placementDecision.DeepCopy()
 	newPlacementDecision.Status.Decisions = clusterDecisions
 	newPlacementDecision, err = c.clusterClient.ClusterV1beta1().PlacementDecisions(newPlacementDecision.Namespace).
 		UpdateStatus(ctx, newPlacementDecision, metav1.UpdateOptions{})
-
 	if err != nil {
-		return err
+		return nil, err
 	}
+	c.recordDecisionUpdatedEvents(placement, newPlacementDecision, clusterScores, status)
+	return newPlacementDecision, nil
+}
+
+// updatePlacementDecisionChecksum updates the index label and the checksum annotation of the
+// PlacementDecision if necessary.
+func (c *schedulingController) updatePlacementDecisionChecksum(
+	ctx context.Context,
+	placementDecision *clusterapiv1beta1.PlacementDecision,
+	index int,
+	checksum string,
+) error {
+	if placementDecision.Labels[helpers.DecisionIndexLabel] == strconv.Itoa(index) &&
+		placementDecision.Annotations[helpers.DecisionChecksumAnnotation] == checksum {
+		return nil
+	}
+	newPlacementDecision := placementDecision.DeepCopy()
+	if newPlacementDecision.Labels == nil {
+		newPlacementDecision.Labels = map[string]string{}
+	}
+	if newPlacementDecision.Annotations == nil {
+		newPlacementDecision.Annotations = map[string]string{}
+	}
+	newPlacementDecision.Labels[helpers.DecisionIndexLabel] = strconv.Itoa(index)
+	newPlacementDecision.Annotations[helpers.DecisionChecksumAnnotation] = checksum
+	_, err := c.clusterClient.ClusterV1beta1().PlacementDecisions(newPlacementDecision.Namespace).
+		Update(ctx, newPlacementDecision, metav1.UpdateOptions{})
+	return err
+}
 
+// recordDecisionUpdatedEvents records the events of the updated placementdecision and the prioritizer scores.
+func (c *schedulingController) recordDecisionUpdatedEvents(
+	placement *clusterapiv1beta1.Placement,
+	placementDecision *clusterapiv1beta1.PlacementDecision,
+	clusterScores PrioritizerScore,
+	status *framework.Status,
+) {
 	// update the event with warning
 	if status.Code() == framework.Warning {
 		c.recorder.Eventf(
@@ -598,9 +972,14 @@ func (c *schedulingController) createOrUpdatePlacementDecision(
 	}
 
 	// update the event with prioritizer score.
+	clusterNames := make([]string, 0, len(clusterScores))
+	for k := range clusterScores {
+		clusterNames = append(clusterNames, k)
+	}
+	sort.Strings(clusterNames)
 	scoreStr := ""
-	for k, v := range clusterScores {
-		tmpScore := fmt.Sprintf("%s:%d ", k, v)
+	for _, k := range clusterNames {
+		tmpScore := fmt.Sprintf("%s:%d ", k, clusterScores[k])
 		if len(scoreStr)+len(tmpScore) > maxEventMessageLength {
 			scoreStr += "......"
 			break
@@ -613,6 +992,50 @@ func (c *schedulingController) createOrUpdatePlacementDecision(
 		placement, placementDecision, corev1.EventTypeNormal,
 		"ScoreUpdate", "ScoreUpdated",
 		scoreStr)
+}
 
-	return nil
+// decisionsUnchanged returns true if the placementdecisions of the placement are exactly the decision slices with
+// the index labels and the checksum annotation, and there is no redundant placementdecision.
+func decisionsUnchanged(
+	placement *clusterapiv1beta1.Placement,
+	placementDecisions []*clusterapiv1beta1.PlacementDecision,
+	decisionSlices [][]clusterapiv1beta1.ClusterDecision,
+	checksum string,
+) bool {
+	if len(placementDecisions) != len(decisionSlices) {
+		return false
+	}
+
+	existing := map[string]*clusterapiv1beta1.PlacementDecision{}
+	for _, placementDecision := range placementDecisions {
+		existing[placementDecision.Name] = placementDecision
+	}
+	for index, decisionSlice := range decisionSlices {
+		placementDecision, ok := existing[fmt.Sprintf("%s-decision-%d", placement.Name, index+1)]
+		if !ok {
+			return false
+		}
+		if placementDecision.Labels[helpers.DecisionIndexLabel] != strconv.Itoa(index+1) ||
+			placementDecision.Annotations[helpers.DecisionChecksumAnnotation] != checksum {
+			return false
+		}
+		if !sameClusterDecisions(placementDecision.Status.Decisions, decisionSlice) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameClusterDecisions compares the cluster decisions regardless of the order, so the decisions written in a
+// different order are not rewritten.
+func sameClusterDecisions(existing, desired []clusterapiv1beta1.ClusterDecision) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	sortedExisting := append([]clusterapiv1beta1.ClusterDecision{}, existing...)
+	sort.SliceStable(sortedExisting, func(i, j int) bool {
+		return sortedExisting[i].ClusterName < sortedExisting[j].ClusterName
+	})
+	// the desired decisions are sorted by cluster name already
+	return apiequality.Semantic.DeepEqual(sortedExisting, desired)
 }
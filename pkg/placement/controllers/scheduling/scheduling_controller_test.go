@@ -7,9 +7,11 @@ import (
 	"strings"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/rand"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	clienttesting "k8s.io/client-go/testing"
 	kevents "k8s.io/client-go/tools/events"
@@ -20,8 +22,11 @@ import (
 	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/tainttoleration"
 	"open-cluster-management.io/ocm/test/integration/util"
 )
 
@@ -264,6 +269,9 @@ func TestSchedulingController_sync(t *testing.T) {
 				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, "clusterset1").Build(),
 				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 1)).
 					WithLabel(placementLabel, placementName).
+					WithLabel(helpers.DecisionIndexLabel, "1").
+					WithAnnotation(helpers.DecisionChecksumAnnotation,
+						helpers.DecisionChecksum(sets.New[string]("cluster1", "cluster2", "cluster3"))).
 					WithDecisions("cluster1", "cluster2", "cluster3").Build(),
 			},
 			scheduleResult: &scheduleResult{
@@ -328,6 +336,517 @@ func TestSchedulingController_sync(t *testing.T) {
 	}
 }
 
+func TestSchedulingControllerSelectedClusterCounts(t *testing.T) {
+	placementNamespace := "ns1"
+	placementName := "placement1"
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, "clusterset1").Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, "clusterset1").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithLabel(clusterSetLabel, "clusterset1").Build(),
+	}
+	newScheduleResult := func(afterPredicate, afterTaintToleration int) *scheduleResult {
+		result := &scheduleResult{
+			filteredRecords: map[string][]*clusterapiv1.ManagedCluster{
+				"Predicate":                 clusters[:afterPredicate],
+				"Predicate,TaintToleration": clusters[:afterTaintToleration],
+			},
+			scheduledDecisions: []clusterapiv1beta1.ClusterDecision{},
+		}
+		for _, cluster := range clusters[:afterTaintToleration] {
+			result.feasibleClusters = append(result.feasibleClusters, cluster)
+			result.scheduledDecisions = append(result.scheduledDecisions,
+				clusterapiv1beta1.ClusterDecision{ClusterName: cluster.Name})
+		}
+		return result
+	}
+
+	cases := []struct {
+		name            string
+		placement       *clusterapiv1beta1.Placement
+		scheduleResult  *scheduleResult
+		enabled         bool
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "zero clusters selected",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			scheduleResult:  newScheduleResult(1, 0),
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "NoManagedClusterMatched",
+			expectedMessage: "No ManagedCluster matches any of the cluster predicate",
+		},
+		{
+			name:            "zero clusters selected with counts",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			scheduleResult:  newScheduleResult(1, 0),
+			enabled:         true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "NoClusterMatched",
+			expectedMessage: "0 of 3 clusters selected, filtered out: Predicate=2, TaintToleration=1",
+		},
+		{
+			name:            "some clusters selected",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			scheduleResult:  newScheduleResult(2, 2),
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AllDecisionsScheduled",
+			expectedMessage: "All cluster decisions scheduled",
+		},
+		{
+			name:            "some clusters selected with counts",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			scheduleResult:  newScheduleResult(2, 2),
+			enabled:         true,
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AllDecisionsScheduled",
+			expectedMessage: "2 of 3 clusters selected, filtered out: Predicate=1",
+		},
+		{
+			name:            "all clusters selected",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			scheduleResult:  newScheduleResult(3, 3),
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AllDecisionsScheduled",
+			expectedMessage: "All cluster decisions scheduled",
+		},
+		{
+			name:            "all clusters selected with counts",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			scheduleResult:  newScheduleResult(3, 3),
+			enabled:         true,
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AllDecisionsScheduled",
+			expectedMessage: "3 of 3 clusters selected",
+		},
+		{
+			name:            "zero clusters selected with numberOfClusters",
+			placement:       testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(2).Build(),
+			scheduleResult:  newScheduleResult(1, 0),
+			enabled:         true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "NoManagedClusterMatched",
+			expectedMessage: "No ManagedCluster matches any of the cluster predicate",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			utilruntime.Must(features.DefaultHubPlacementMutableFeatureGate.Set(
+				fmt.Sprintf("%s=%t", features.PlacementSatisfiedConditionCounts, c.enabled)))
+			defer func() {
+				utilruntime.Must(features.DefaultHubPlacementMutableFeatureGate.Set(
+					fmt.Sprintf("%s=false", features.PlacementSatisfiedConditionCounts)))
+			}()
+
+			initObjs := []runtime.Object{
+				c.placement,
+				testinghelpers.NewClusterSet("clusterset1").Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, "clusterset1"),
+			}
+			for _, cluster := range clusters {
+				initObjs = append(initObjs, cluster)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(initObjs...)
+			clusterInformerFactory := newClusterInformerFactory(clusterClient, initObjs...)
+
+			ctrl := schedulingController{
+				clusterClient:           clusterClient,
+				clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+				placementLister:         clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+				placementDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+				scheduler:               &testScheduler{result: c.scheduleResult},
+				recorder:                kevents.NewFakeRecorder(100),
+			}
+
+			sysCtx := testingcommon.NewFakeSyncContext(t, c.placement.Namespace+"/"+c.placement.Name)
+			if err := ctrl.sync(context.TODO(), sysCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			var placement *clusterapiv1beta1.Placement
+			for _, action := range clusterClient.Actions() {
+				if action.GetResource().Resource == "placements" && action.GetVerb() == "update" {
+					placement = action.(clienttesting.UpdateActionImpl).Object.(*clusterapiv1beta1.Placement)
+				}
+			}
+			if placement == nil {
+				t.Fatalf("expected the placement status updated")
+			}
+			if placement.Status.NumberOfSelectedClusters != int32(len(c.scheduleResult.scheduledDecisions)) {
+				t.Errorf("expected %d clusters selected, but got %d",
+					len(c.scheduleResult.scheduledDecisions), placement.Status.NumberOfSelectedClusters)
+			}
+			cond := meta.FindStatusCondition(placement.Status.Conditions, clusterapiv1beta1.PlacementConditionSatisfied)
+			if cond == nil {
+				t.Fatalf("expected the satisfied condition set")
+			}
+			if cond.Status != c.expectedStatus || cond.Reason != c.expectedReason || cond.Message != c.expectedMessage {
+				t.Errorf("expected condition %s/%s %q, but got %s/%s %q", c.expectedStatus, c.expectedReason,
+					c.expectedMessage, cond.Status, cond.Reason, cond.Message)
+			}
+		})
+	}
+}
+
+func TestSchedulingControllerEvictionSuspended(t *testing.T) {
+	placementNamespace := "ns1"
+	placementName := "placement1"
+	suspended := testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName,
+		map[string]string{tainttoleration.MinSelectedClustersAnnotation: "1"}).Build()
+	suspended.Status.Conditions = []metav1.Condition{{
+		Type:   tainttoleration.PlacementConditionEvictionSuspended,
+		Status: metav1.ConditionTrue,
+		Reason: "MinSelectedClusters",
+	}}
+	unannotated := suspended.DeepCopy()
+	unannotated.Annotations = nil
+	invalid := suspended.DeepCopy()
+	invalid.Annotations = map[string]string{tainttoleration.MinSelectedClustersAnnotation: "half"}
+	forced := suspended.DeepCopy()
+	forced.Annotations = map[string]string{
+		tainttoleration.MinSelectedClustersAnnotation: "1",
+		tainttoleration.ForceEvictionAnnotation:       "true",
+	}
+
+	cases := []struct {
+		name           string
+		placement      *clusterapiv1beta1.Placement
+		suspension     string
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "evictions suspended",
+			placement:      testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName, suspended.Annotations).Build(),
+			suspension:     "suspended",
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:           "evictions resumed",
+			placement:      suspended,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:      "safeguard removed",
+			placement: unannotated,
+		},
+		{
+			name:           "invalid safeguard",
+			placement:      invalid,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "InvalidMinSelectedClusters",
+		},
+		{
+			name:           "evictions forced once",
+			placement:      forced,
+			expectedStatus: metav1.ConditionFalse,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			initObjs := []runtime.Object{
+				c.placement,
+				testinghelpers.NewClusterSet("clusterset1").Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, "clusterset1"),
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, "clusterset1").Build(),
+			}
+			clusterClient := clusterfake.NewSimpleClientset(initObjs...)
+			clusterInformerFactory := newClusterInformerFactory(clusterClient, initObjs...)
+
+			ctrl := schedulingController{
+				clusterClient:           clusterClient,
+				clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+				placementLister:         clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+				placementDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+				scheduler: &testScheduler{result: &scheduleResult{
+					scheduledDecisions: []clusterapiv1beta1.ClusterDecision{{ClusterName: "cluster1"}},
+					evictionSuspension: c.suspension,
+				}},
+				recorder: kevents.NewFakeRecorder(100),
+			}
+
+			sysCtx := testingcommon.NewFakeSyncContext(t, c.placement.Namespace+"/"+c.placement.Name)
+			if err := ctrl.sync(context.TODO(), sysCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			placement, err := clusterClient.ClusterV1beta1().Placements(placementNamespace).Get(
+				context.TODO(), placementName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cond := meta.FindStatusCondition(placement.Status.Conditions, tainttoleration.PlacementConditionEvictionSuspended)
+			switch {
+			case len(c.expectedStatus) == 0 && cond != nil:
+				t.Errorf("expected the condition removed, but got %v", cond)
+			case len(c.expectedStatus) == 0:
+			case cond == nil:
+				t.Errorf("expected the condition %s, but got nil", c.expectedStatus)
+			case cond.Status != c.expectedStatus || (c.suspension != "" && cond.Message != c.suspension):
+				t.Errorf("expected the condition %s %q, but got %v", c.expectedStatus, c.suspension, cond)
+			case c.expectedReason != "" && cond.Reason != c.expectedReason:
+				t.Errorf("expected the condition reason %q, but got %v", c.expectedReason, cond)
+			}
+			if _, ok := placement.Annotations[tainttoleration.ForceEvictionAnnotation]; ok {
+				t.Errorf("expected the force eviction annotation removed, but got %v", placement.Annotations)
+			}
+		})
+	}
+}
+
+func TestSchedulingControllerPrioritizerScores(t *testing.T) {
+	placementNamespace := "ns1"
+	placementName := "placement1"
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, "clusterset1").Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, "clusterset1").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithLabel(clusterSetLabel, "clusterset1").Build(),
+	}
+	result := &scheduleResult{
+		feasibleClusters: clusters,
+		scheduledDecisions: []clusterapiv1beta1.ClusterDecision{
+			{ClusterName: "cluster1"}, {ClusterName: "cluster2"},
+		},
+		scoreRecords: []PrioritizerResult{
+			{Name: "Balance", Weight: 1, Scores: PrioritizerScore{"cluster1": 100, "cluster2": -33, "cluster3": -100}},
+			{Name: "Steady", Weight: 2, Scores: PrioritizerScore{"cluster1": 0, "cluster2": 100, "cluster3": 0}},
+		},
+		scoreSum: PrioritizerScore{"cluster1": 100, "cluster2": 167, "cluster3": -100},
+	}
+	expectedAnnotation := `[{"clusterName":"cluster2","score":167,"prioritizers":{"Balance":-33,"Steady":100}},` +
+		`{"clusterName":"cluster1","score":100,"prioritizers":{"Balance":100,"Steady":0}}]`
+
+	cases := []struct {
+		name               string
+		placement          *clusterapiv1beta1.Placement
+		enabled            bool
+		expectedPatch      bool
+		expectedAnnotation *string
+	}{
+		{
+			name:      "disabled",
+			placement: testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+		},
+		{
+			name:               "enabled",
+			placement:          testinghelpers.NewPlacement(placementNamespace, placementName).Build(),
+			enabled:            true,
+			expectedPatch:      true,
+			expectedAnnotation: &expectedAnnotation,
+		},
+		{
+			name: "annotation unchanged",
+			placement: func() *clusterapiv1beta1.Placement {
+				p := testinghelpers.NewPlacement(placementNamespace, placementName).Build()
+				p.Annotations = map[string]string{helpers.PrioritizerScoresAnnotation: expectedAnnotation}
+				return p
+			}(),
+			enabled: true,
+		},
+		{
+			name: "annotation removed once disabled",
+			placement: func() *clusterapiv1beta1.Placement {
+				p := testinghelpers.NewPlacement(placementNamespace, placementName).Build()
+				p.Annotations = map[string]string{helpers.PrioritizerScoresAnnotation: expectedAnnotation}
+				return p
+			}(),
+			expectedPatch: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			utilruntime.Must(features.DefaultHubPlacementMutableFeatureGate.Set(
+				fmt.Sprintf("%s=%t", features.PlacementPrioritizerScores, c.enabled)))
+			defer func() {
+				utilruntime.Must(features.DefaultHubPlacementMutableFeatureGate.Set(
+					fmt.Sprintf("%s=false", features.PlacementPrioritizerScores)))
+			}()
+
+			initObjs := []runtime.Object{
+				c.placement,
+				testinghelpers.NewClusterSet("clusterset1").Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, "clusterset1"),
+			}
+			for _, cluster := range clusters {
+				initObjs = append(initObjs, cluster)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(initObjs...)
+			clusterInformerFactory := newClusterInformerFactory(clusterClient, initObjs...)
+
+			ctrl := schedulingController{
+				clusterClient:           clusterClient,
+				clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+				placementLister:         clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+				placementDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+				scheduler:               &testScheduler{result: result},
+				recorder:                kevents.NewFakeRecorder(100),
+			}
+
+			sysCtx := testingcommon.NewFakeSyncContext(t, c.placement.Namespace+"/"+c.placement.Name)
+			if err := ctrl.sync(context.TODO(), sysCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			var patched bool
+			for _, action := range clusterClient.Actions() {
+				if action.GetResource().Resource == "placements" && action.GetVerb() == "patch" {
+					patched = true
+				}
+			}
+			if patched != c.expectedPatch {
+				t.Fatalf("expected placement patched %t, but got %t", c.expectedPatch, patched)
+			}
+			if !patched {
+				return
+			}
+
+			placement, err := clusterClient.ClusterV1beta1().Placements(placementNamespace).Get(
+				context.TODO(), placementName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			annotation, ok := placement.Annotations[helpers.PrioritizerScoresAnnotation]
+			switch {
+			case c.expectedAnnotation == nil && ok:
+				t.Errorf("expected the annotation removed, but got %s", annotation)
+			case c.expectedAnnotation != nil && annotation != *c.expectedAnnotation:
+				t.Errorf("expected annotation %s, but got %s", *c.expectedAnnotation, annotation)
+			}
+		})
+	}
+}
+
+func TestScoresAnnotationChangedOnly(t *testing.T) {
+	placement := testinghelpers.NewPlacement("ns1", "placement1").Build()
+	placement.ResourceVersion = "1"
+
+	cases := []struct {
+		name     string
+		update   func(p *clusterapiv1beta1.Placement)
+		expected bool
+	}{
+		{
+			name:   "nothing changed",
+			update: func(p *clusterapiv1beta1.Placement) {},
+		},
+		{
+			name: "scores annotation added",
+			update: func(p *clusterapiv1beta1.Placement) {
+				p.Annotations = map[string]string{helpers.PrioritizerScoresAnnotation: "[]"}
+			},
+			expected: true,
+		},
+		{
+			name: "scores annotation and spec changed",
+			update: func(p *clusterapiv1beta1.Placement) {
+				p.Annotations = map[string]string{helpers.PrioritizerScoresAnnotation: "[]"}
+				p.Spec.NumberOfClusters = new(int32)
+			},
+		},
+		{
+			name: "scores annotation and another annotation changed",
+			update: func(p *clusterapiv1beta1.Placement) {
+				p.Annotations = map[string]string{
+					helpers.PrioritizerScoresAnnotation:          "[]",
+					clusterapiv1beta1.PlacementDisableAnnotation: "true",
+				}
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			newPlacement := placement.DeepCopy()
+			newPlacement.ResourceVersion = "2"
+			c.update(newPlacement)
+			if actual := scoresAnnotationChangedOnly(placement, newPlacement); actual != c.expected {
+				t.Errorf("expected %t, but got %t", c.expected, actual)
+			}
+		})
+	}
+}
+
+// TestSchedulingControllerRestart schedules a placement, and then restarts the controller over the same state. The
+// new controller should compute the same decisions and write nothing.
+func TestSchedulingControllerRestart(t *testing.T) {
+	placementNamespace := "ns1"
+	clusterSetName := "clusterset1"
+
+	objs := []runtime.Object{
+		testinghelpers.NewClusterSet(clusterSetName).Build(),
+		testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+		testinghelpers.NewPlacement(placementNamespace, "placement1").WithNOC(3).Build(),
+	}
+	// the clusters have the same scores, so the ties are broken by the cluster names
+	for _, name := range []string{"cluster5", "cluster3", "cluster1", "cluster4", "cluster2"} {
+		objs = append(objs, testinghelpers.NewManagedCluster(name).WithLabel(clusterSetLabel, clusterSetName).Build())
+	}
+
+	newController := func(objs ...runtime.Object) (*clusterfake.Clientset, *schedulingController) {
+		clusterClient := clusterfake.NewSimpleClientset(objs...)
+		clusterInformerFactory := newClusterInformerFactory(clusterClient, objs...)
+		return clusterClient, &schedulingController{
+			clusterClient:           clusterClient,
+			clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+			clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+			placementLister:         clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			placementDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+			scheduler:               NewPluginScheduler(testinghelpers.NewFakePluginHandle(t, clusterClient, objs...)),
+			recorder:                kevents.NewFakeRecorder(100),
+		}
+	}
+
+	clusterClient, ctrl := newController(objs...)
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, placementNamespace+"/placement1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// collect the state written by the first controller, the decisions are reordered as written by an older
+	// version.
+	state := []runtime.Object{}
+	for _, obj := range objs {
+		if _, ok := obj.(*clusterapiv1beta1.Placement); !ok {
+			state = append(state, obj)
+		}
+	}
+	placement, err := clusterClient.ClusterV1beta1().Placements(placementNamespace).Get(context.TODO(), "placement1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = append(state, placement)
+	placementDecisions, err := clusterClient.ClusterV1beta1().PlacementDecisions(placementNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placementDecisions.Items) != 1 {
+		t.Fatalf("expected one placementdecision, but got %d", len(placementDecisions.Items))
+	}
+	placementDecision := placementDecisions.Items[0].DeepCopy()
+	assertClustersSelected(t, placementDecision.Status.Decisions, "cluster1", "cluster2", "cluster3")
+	decisions := placementDecision.Status.Decisions
+	for i, j := 0, len(decisions)-1; i < j; i, j = i+1, j-1 {
+		decisions[i], decisions[j] = decisions[j], decisions[i]
+	}
+	state = append(state, placementDecision)
+
+	// restart the controller over the same state
+	clusterClient, ctrl = newController(state...)
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, placementNamespace+"/placement1")); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+}
+
 func TestGetValidManagedClusterSetBindings(t *testing.T) {
 	placementNamespace := "ns1"
 	cases := []struct {
@@ -788,6 +1307,15 @@ func TestBind(t *testing.T) {
 		{
 			name:             "no change",
 			clusterDecisions: newClusterDecisions(128),
+			initObjs: []runtime.Object{
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 1, 128, newSelectedClusters(128)[:100]...),
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 2, 128, newSelectedClusters(128)[100:]...),
+			},
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:             "set checksum on placementdecisions created by older controller",
+			clusterDecisions: newClusterDecisions(128),
 			initObjs: []runtime.Object{
 				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 1)).
 					WithLabel(placementLabel, placementName).
@@ -796,18 +1324,23 @@ func TestBind(t *testing.T) {
 					WithLabel(placementLabel, placementName).
 					WithDecisions(newSelectedClusters(128)[100:]...).Build(),
 			},
-			validateActions: testingcommon.AssertNoActions,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update", "update")
+				for index, action := range actions {
+					placementDecision := action.(clienttesting.UpdateActionImpl).Object.(*clusterapiv1beta1.PlacementDecision)
+					assertPlacementDecisionChecksum(t, placementDecision, index+1, 128)
+				}
+			},
 		},
 		{
 			name:             "update one of placementdecisions",
 			clusterDecisions: newClusterDecisions(128),
 			initObjs: []runtime.Object{
-				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 1)).
-					WithLabel(placementLabel, placementName).
-					WithDecisions(newSelectedClusters(128)[:100]...).Build(),
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 1, 100, newSelectedClusters(128)[:100]...),
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testingcommon.AssertActions(t, actions, "create", "update")
+				// the new placementdecision is bound before the checksum of the existing one is updated
+				testingcommon.AssertActions(t, actions, "create", "update", "update")
 				selectedClusters := newSelectedClusters(128)
 				actual := actions[1].(clienttesting.UpdateActionImpl).Object
 				placementDecision, ok := actual.(*clusterapiv1beta1.PlacementDecision)
@@ -815,42 +1348,41 @@ func TestBind(t *testing.T) {
 					t.Errorf("expected PlacementDecision was updated")
 				}
 				assertClustersSelected(t, placementDecision.Status.Decisions, selectedClusters[100:]...)
+				assertPlacementDecisionChecksum(t, placementDecision, 2, 128)
+
+				placementDecision = actions[2].(clienttesting.UpdateActionImpl).Object.(*clusterapiv1beta1.PlacementDecision)
+				assertPlacementDecisionChecksum(t, placementDecision, 1, 128)
 			},
 		},
 		{
 			name:             "delete redundant placementdecisions",
 			clusterDecisions: newClusterDecisions(10),
 			initObjs: []runtime.Object{
-				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 1)).
-					WithLabel(placementLabel, placementName).
-					WithDecisions(newSelectedClusters(128)[:100]...).Build(),
-				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 2)).
-					WithLabel(placementLabel, placementName).
-					WithDecisions(newSelectedClusters(128)[100:]...).Build(),
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 1, 128, newSelectedClusters(128)[:100]...),
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 2, 128, newSelectedClusters(128)[100:]...),
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testingcommon.AssertActions(t, actions, "update", "delete")
+				// the redundant placementdecision is deleted after the checksum is updated
+				testingcommon.AssertActions(t, actions, "update", "update", "delete")
 				actual := actions[0].(clienttesting.UpdateActionImpl).Object
 				placementDecision, ok := actual.(*clusterapiv1beta1.PlacementDecision)
 				if !ok {
 					t.Errorf("expected PlacementDecision was updated")
 				}
 				assertClustersSelected(t, placementDecision.Status.Decisions, newSelectedClusters(10)...)
+				placementDecision = actions[1].(clienttesting.UpdateActionImpl).Object.(*clusterapiv1beta1.PlacementDecision)
+				assertPlacementDecisionChecksum(t, placementDecision, 1, 10)
 			},
 		},
 		{
 			name:             "delete all placementdecisions and leave one empty placementdecision",
 			clusterDecisions: newClusterDecisions(0),
 			initObjs: []runtime.Object{
-				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 1)).
-					WithLabel(placementLabel, placementName).
-					WithDecisions(newSelectedClusters(128)[:100]...).Build(),
-				testinghelpers.NewPlacementDecision(placementNamespace, placementDecisionName(placementName, 2)).
-					WithLabel(placementLabel, placementName).
-					WithDecisions(newSelectedClusters(128)[100:]...).Build(),
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 1, 128, newSelectedClusters(128)[:100]...),
+				newPlacementDecisionWithChecksum(placementNamespace, placementName, 2, 128, newSelectedClusters(128)[100:]...),
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testingcommon.AssertActions(t, actions, "update", "delete")
+				testingcommon.AssertActions(t, actions, "update", "update", "delete")
 				actual := actions[0].(clienttesting.UpdateActionImpl).Object
 				placementDecision, ok := actual.(*clusterapiv1beta1.PlacementDecision)
 				if !ok {
@@ -909,6 +1441,28 @@ func TestBind(t *testing.T) {
 	}
 }
 
+// newPlacementDecisionWithChecksum returns a placementdecision with the index label and the checksum
+// annotation of the first numOfClusters selected clusters.
+func newPlacementDecisionWithChecksum(
+	namespace, placementName string, index, numOfClusters int, clusterNames ...string) *clusterapiv1beta1.PlacementDecision {
+	return testinghelpers.NewPlacementDecision(namespace, placementDecisionName(placementName, index)).
+		WithLabel(placementLabel, placementName).
+		WithLabel(helpers.DecisionIndexLabel, fmt.Sprintf("%d", index)).
+		WithAnnotation(helpers.DecisionChecksumAnnotation,
+			helpers.DecisionChecksum(sets.New[string](newSelectedClusters(numOfClusters)...))).
+		WithDecisions(clusterNames...).Build()
+}
+
+func assertPlacementDecisionChecksum(t *testing.T, placementDecision *clusterapiv1beta1.PlacementDecision, index, numOfClusters int) {
+	if placementDecision.Labels[helpers.DecisionIndexLabel] != fmt.Sprintf("%d", index) {
+		t.Errorf("expected index %d, but got %v", index, placementDecision.Labels)
+	}
+	checksum := helpers.DecisionChecksum(sets.New[string](newSelectedClusters(numOfClusters)...))
+	if placementDecision.Annotations[helpers.DecisionChecksumAnnotation] != checksum {
+		t.Errorf("expected checksum of %d clusters, but got %v", numOfClusters, placementDecision.Annotations)
+	}
+}
+
 func assertClustersSelected(t *testing.T, decisons []clusterapiv1beta1.ClusterDecision, clusterNames ...string) {
 	names := sets.NewString(clusterNames...)
 	for _, decision := range decisons {
@@ -942,3 +1496,123 @@ func newSelectedClusters(num int) (clusters []string) {
 
 	return clusters
 }
+
+func TestSchedulingControllerExplainCluster(t *testing.T) {
+	placementNamespace := "ns1"
+	placementName := "placement1"
+	clusterSetName := "clusterset1"
+
+	cases := []struct {
+		name            string
+		cluster         string
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "selected",
+			cluster:         "cluster1",
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "Selected",
+			expectedMessage: `Cluster "cluster1" is selected with score 100 (Balance=100*1, Steady=0*1)`,
+		},
+		{
+			name:            "outranked",
+			cluster:         "cluster2",
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "Outranked",
+			expectedMessage: `Cluster "cluster2" with score 100 (Balance=100*1, Steady=0*1) is outranked by the 1 selected clusters [cluster1=100]`,
+		},
+		{
+			name:            "filtered",
+			cluster:         "cluster3",
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "Filtered",
+			expectedMessage: `Cluster "cluster3" is filtered out by Predicate`,
+		},
+		{
+			name:            "not in clustersets",
+			cluster:         "cluster4",
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "NotInClusterSets",
+			expectedMessage: `Cluster "cluster4" is not a member of the eligible ManagedClusterSets [clusterset1]`,
+		},
+		{
+			name:            "unknown cluster",
+			cluster:         "cluster5",
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "ClusterNotFound",
+			expectedMessage: `Cluster "cluster5" is not found`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			placement := testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName,
+				map[string]string{helpers.ExplainClusterAnnotation: c.cluster}).
+				WithNOC(1).
+				AddPredicate(&metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}, nil).
+				Build()
+			initObjs := []runtime.Object{
+				placement,
+				testinghelpers.NewClusterSet(clusterSetName).Build(),
+				testinghelpers.NewClusterSetBinding(placementNamespace, clusterSetName),
+				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterSetLabel, clusterSetName).WithLabel("env", "prod").Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithLabel(clusterSetLabel, clusterSetName).WithLabel("env", "prod").Build(),
+				testinghelpers.NewManagedCluster("cluster3").WithLabel(clusterSetLabel, clusterSetName).WithLabel("env", "dev").Build(),
+				testinghelpers.NewManagedCluster("cluster4").WithLabel("env", "prod").Build(),
+			}
+			clusterClient := clusterfake.NewSimpleClientset(initObjs...)
+			clusterInformerFactory := newClusterInformerFactory(clusterClient, initObjs...)
+
+			ctrl := schedulingController{
+				clusterClient:           clusterClient,
+				clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+				placementLister:         clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+				placementDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+				scheduler:               NewPluginScheduler(testinghelpers.NewFakePluginHandle(t, clusterClient, initObjs...)),
+				recorder:                kevents.NewFakeRecorder(100),
+			}
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, placementNamespace+"/"+placementName)); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := clusterClient.ClusterV1beta1().Placements(placementNamespace).Get(
+				context.TODO(), placementName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := actual.Annotations[helpers.ExplainClusterAnnotation]; !ok {
+				t.Errorf("expected the explain annotation kept")
+			}
+			condition := meta.FindStatusCondition(actual.Status.Conditions, helpers.PlacementConditionClusterExplained)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", helpers.PlacementConditionClusterExplained)
+			}
+			if condition.Status != c.expectedStatus || condition.Reason != c.expectedReason {
+				t.Errorf("expected status %s and reason %s, but got %s and %s",
+					c.expectedStatus, c.expectedReason, condition.Status, condition.Reason)
+			}
+			if condition.Message != c.expectedMessage {
+				t.Errorf("expected message %q, but got %q", c.expectedMessage, condition.Message)
+			}
+
+			// the condition is removed once the annotation is removed
+			delete(actual.Annotations, helpers.ExplainClusterAnnotation)
+			if err := ctrl.updateStatus(context.TODO(), actual, actual.Status.NumberOfSelectedClusters); err != nil {
+				t.Fatal(err)
+			}
+			actual, err = clusterClient.ClusterV1beta1().Placements(placementNamespace).Get(
+				context.TODO(), placementName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if meta.FindStatusCondition(actual.Status.Conditions, helpers.PlacementConditionClusterExplained) != nil {
+				t.Errorf("expected the %s condition removed", helpers.PlacementConditionClusterExplained)
+			}
+		})
+	}
+}
@@ -63,6 +63,10 @@ func (r *testResult) RequeueAfter() *time.Duration {
 	return nil
 }
 
+func (r *testResult) EvictionSuspension() string {
+	return ""
+}
+
 func TestDebugger(t *testing.T) {
 	placementNamespace := "test"
 
@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+const (
+	// DecisionIndexLabel is the label on each PlacementDecision of a placement to indicate the index of the
+	// PlacementDecision, starting from 1.
+	// TODO move this to the api repo
+	DecisionIndexLabel = "cluster.open-cluster-management.io/decision-index"
+
+	// DecisionChecksumAnnotation is the annotation on each PlacementDecision of a placement with the checksum
+	// of the clusters in all the PlacementDecisions of the placement. The consumers listing the
+	// PlacementDecisions by the placement label compare it with the clusters they merged to detect the
+	// intermediate states when the decisions are re-chunked.
+	// TODO move this to the api repo
+	DecisionChecksumAnnotation = "cluster.open-cluster-management.io/decision-checksum"
+)
+
+// ErrInconsistentDecisions is returned when the PlacementDecisions of a placement are being updated by the
+// placement controller, the consumers should wait until the next update of the PlacementDecisions.
+var ErrInconsistentDecisions = errors.New("placement decisions are inconsistent")
+
+// ErrDecisionsNotCreated is returned when the placement exists but its PlacementDecisions are not created by the
+// placement controller yet, the consumers should wait until the PlacementDecisions are created.
+var ErrDecisionsNotCreated = errors.New("placement decisions are not created yet")
+
+// DecisionChecksum returns the checksum of the cluster names regardless of the order or the chunking of the
+// decisions.
+func DecisionChecksum(clusterNames sets.Set[string]) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(sets.List(clusterNames), ","))))
+}
+
+// ConsistentDecisionClusters merges the clusters in the PlacementDecisions of a placement, and returns
+// ErrInconsistentDecisions if the PlacementDecisions are not a consistent snapshot, which happens if
+//   - some of the PlacementDecisions have no checksum,
+//   - the PlacementDecisions do not have the same checksum,
+//   - the indexes of the PlacementDecisions are duplicated,
+//   - a cluster is in more than one PlacementDecision, or
+//   - the merged clusters do not match the checksum.
+//
+// If none of the PlacementDecisions has the checksum annotation, they are created by an older placement
+// controller and always considered consistent.
+func ConsistentDecisionClusters(decisions []*clusterapiv1beta1.PlacementDecision) (sets.Set[string], error) {
+	clusters := sets.New[string]()
+	duplicated := false
+	for _, decision := range decisions {
+		for _, clusterDecision := range decision.Status.Decisions {
+			if len(clusterDecision.ClusterName) == 0 {
+				continue
+			}
+			if clusters.Has(clusterDecision.ClusterName) {
+				duplicated = true
+			}
+			clusters.Insert(clusterDecision.ClusterName)
+		}
+	}
+
+	checksums := sets.New[string]()
+	indexes := sets.New[int]()
+	for _, decision := range decisions {
+		checksum, ok := decision.Annotations[DecisionChecksumAnnotation]
+		if !ok {
+			continue
+		}
+		checksums.Insert(checksum)
+
+		index, err := strconv.Atoi(decision.Labels[DecisionIndexLabel])
+		if err != nil || indexes.Has(index) {
+			return nil, fmt.Errorf("%w: decision %s has an invalid or duplicated index", ErrInconsistentDecisions, decision.Name)
+		}
+		indexes.Insert(index)
+	}
+
+	switch {
+	case checksums.Len() == 0:
+		return clusters, nil
+	case indexes.Len() != len(decisions):
+		return nil, fmt.Errorf("%w: some decisions have no checksum", ErrInconsistentDecisions)
+	case checksums.Len() > 1:
+		return nil, fmt.Errorf("%w: decisions have different checksums", ErrInconsistentDecisions)
+	case duplicated:
+		return nil, fmt.Errorf("%w: a cluster is in more than one decision", ErrInconsistentDecisions)
+	case sets.List(checksums)[0] != DecisionChecksum(clusters):
+		return nil, fmt.Errorf("%w: the clusters do not match the checksum", ErrInconsistentDecisions)
+	}
+	return clusters, nil
+}
@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func newDecision(index int, checksumOf []string, clusterNames ...string) *clusterapiv1beta1.PlacementDecision {
+	builder := testinghelpers.NewPlacementDecision("ns1", fmt.Sprintf("placement1-decision-%d", index)).
+		WithLabel(clusterapiv1beta1.PlacementLabel, "placement1").
+		WithDecisions(clusterNames...)
+	if checksumOf != nil {
+		builder = builder.
+			WithLabel(DecisionIndexLabel, fmt.Sprintf("%d", index)).
+			WithAnnotation(DecisionChecksumAnnotation, DecisionChecksum(sets.New[string](checksumOf...)))
+	}
+	return builder.Build()
+}
+
+func TestConsistentDecisionClusters(t *testing.T) {
+	oldClusters := []string{"cluster1", "cluster2", "cluster3"}
+	newClusters := []string{"cluster1", "cluster2", "cluster3", "cluster4"}
+
+	cases := []struct {
+		name             string
+		decisions        []*clusterapiv1beta1.PlacementDecision
+		expectedClusters []string
+		expectedErr      bool
+	}{
+		{
+			name:             "no decisions",
+			expectedClusters: []string{},
+		},
+		{
+			name: "decisions without checksum",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, nil, "cluster1", "cluster2"),
+				newDecision(2, nil, "cluster3"),
+			},
+			expectedClusters: oldClusters,
+		},
+		{
+			name: "consistent decisions",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, oldClusters, "cluster1", "cluster2"),
+				newDecision(2, oldClusters, "cluster3"),
+			},
+			expectedClusters: oldClusters,
+		},
+		{
+			name: "consistent decisions listed in a different order",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(2, oldClusters, "cluster3"),
+				newDecision(1, oldClusters, "cluster2", "cluster1"),
+			},
+			expectedClusters: oldClusters,
+		},
+		{
+			name: "re-chunking: the new decision is created before the existing one is updated",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, oldClusters, "cluster1", "cluster2", "cluster3"),
+				newDecision(2, newClusters, "cluster3", "cluster4"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "re-chunking: the status is updated but the checksum is not",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, oldClusters, "cluster1", "cluster2"),
+				newDecision(2, newClusters, "cluster3", "cluster4"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "re-chunking: the redundant decision is not deleted yet",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, newClusters, "cluster1", "cluster2", "cluster3", "cluster4"),
+				newDecision(2, oldClusters, "cluster3"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "re-chunking: a cluster is moved to another decision",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, newClusters, "cluster1", "cluster2", "cluster3"),
+				newDecision(2, newClusters, "cluster3", "cluster4"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "re-chunking: some decisions have no checksum",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, nil, "cluster1", "cluster2"),
+				newDecision(2, newClusters, "cluster3", "cluster4"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "re-chunking is finished",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, newClusters, "cluster1", "cluster2"),
+				newDecision(2, newClusters, "cluster3", "cluster4"),
+			},
+			expectedClusters: newClusters,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusters, err := ConsistentDecisionClusters(c.decisions)
+			if c.expectedErr {
+				if !errors.Is(err, ErrInconsistentDecisions) {
+					t.Errorf("expected inconsistent decisions, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if !clusters.Equal(sets.New[string](c.expectedClusters...)) {
+				t.Errorf("expected clusters %v, but got %v", c.expectedClusters, sets.List(clusters))
+			}
+		})
+	}
+}
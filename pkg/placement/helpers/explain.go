@@ -0,0 +1,15 @@
+package helpers
+
+const (
+	// ExplainClusterAnnotation is the annotation on a placement with the name of a managed cluster. While it is
+	// set, the scheduling controller explains why the cluster is selected or not in the
+	// PlacementConditionClusterExplained condition of the placement each time the placement is scheduled, and the
+	// condition is removed once the annotation is removed.
+	// TODO move this to the api repo
+	ExplainClusterAnnotation = "cluster.open-cluster-management.io/explain-cluster"
+
+	// PlacementConditionClusterExplained is the condition of a placement with the result of the explained
+	// cluster. It is True if the cluster is selected, otherwise the reason tells why the cluster is not selected.
+	// TODO move this to the api repo
+	PlacementConditionClusterExplained = "ClusterExplained"
+)
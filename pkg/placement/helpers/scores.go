@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	// PrioritizerScoresAnnotation is the annotation on a placement with the scores of the top selected clusters,
+	// so the users are able to verify the weights of the prioritizers. The value is a JSON list of ClusterScore
+	// sorted by the total score, it has at most MaxClusterScoresInAnnotation clusters.
+	// TODO move this to the api repo
+	PrioritizerScoresAnnotation = "cluster.open-cluster-management.io/prioritizer-scores"
+
+	// MaxClusterScoresInAnnotation is the max number of the clusters in the PrioritizerScoresAnnotation.
+	MaxClusterScoresInAnnotation = 10
+)
+
+// ClusterScore is the score of a selected cluster in the PrioritizerScoresAnnotation.
+type ClusterScore struct {
+	// ClusterName is the name of the selected cluster.
+	ClusterName string `json:"clusterName"`
+	// Score is the total score of the cluster, the sum of the prioritizer scores multiplied by the weights.
+	Score int64 `json:"score"`
+	// Prioritizers are the normalized scores in [-100, 100] given by each prioritizer, before multiplied by
+	// the weights.
+	Prioritizers map[string]int64 `json:"prioritizers,omitempty"`
+}
+
+// TopClusterScores returns the value of the PrioritizerScoresAnnotation with the scores of the top k clusters,
+// sorted by the total score and then the cluster name.
+func TopClusterScores(scores []ClusterScore, k int) (string, error) {
+	sorted := append([]ClusterScore{}, scores...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Score == sorted[j].Score {
+			return sorted[i].ClusterName < sorted[j].ClusterName
+		}
+		return sorted[i].Score > sorted[j].Score
+	})
+	if len(sorted) > k {
+		sorted = sorted[:k]
+	}
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTopClusterScores(t *testing.T) {
+	scores := []ClusterScore{
+		{ClusterName: "cluster1", Score: 100, Prioritizers: map[string]int64{"Balance": 100}},
+		{ClusterName: "cluster2", Score: 300},
+		{ClusterName: "cluster3", Score: 100},
+		{ClusterName: "cluster4", Score: -200},
+	}
+
+	value, err := TopClusterScores(scores, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []ClusterScore
+	if err := json.Unmarshal([]byte(value), &actual); err != nil {
+		t.Fatal(err)
+	}
+	expected := []ClusterScore{scores[1], scores[0], scores[2]}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected scores %v, but got %v", expected, actual)
+	}
+
+	if value, err := TopClusterScores(nil, 3); err != nil || value != "[]" {
+		t.Errorf("expected empty scores, but got %q with %v", value, err)
+	}
+}
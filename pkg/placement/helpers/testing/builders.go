@@ -204,6 +204,14 @@ func (b *placementDecisionBuilder) WithLabel(name, value string) *placementDecis
 	return b
 }
 
+func (b *placementDecisionBuilder) WithAnnotation(name, value string) *placementDecisionBuilder {
+	if b.placementDecision.Annotations == nil {
+		b.placementDecision.Annotations = map[string]string{}
+	}
+	b.placementDecision.Annotations[name] = value
+	return b
+}
+
 func (b *placementDecisionBuilder) WithDeletionTimestamp() *placementDecisionBuilder {
 	now := metav1.Now()
 	b.placementDecision.DeletionTimestamp = &now
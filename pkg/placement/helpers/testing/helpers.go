@@ -11,11 +11,16 @@ import (
 	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterlisterv1alpha1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
 	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
 )
 
 type FakePluginHandle struct {
 	recorder                kevents.EventRecorder
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister
+	placementLister         clusterlisterv1beta1.PlacementLister
+	clusterDecisionIndex    *plugins.ClusterDecisionIndex
 	scoreLister             clusterlisterv1alpha1.AddOnPlacementScoreLister
 	clusterLister           clusterlisterv1.ManagedClusterLister
 	client                  clusterclient.Interface
@@ -25,6 +30,12 @@ func (f *FakePluginHandle) EventRecorder() kevents.EventRecorder { return f.reco
 func (f *FakePluginHandle) DecisionLister() clusterlisterv1beta1.PlacementDecisionLister {
 	return f.placementDecisionLister
 }
+func (f *FakePluginHandle) PlacementLister() clusterlisterv1beta1.PlacementLister {
+	return f.placementLister
+}
+func (f *FakePluginHandle) ClusterDecisionIndex() *plugins.ClusterDecisionIndex {
+	return f.clusterDecisionIndex
+}
 func (f *FakePluginHandle) ScoreLister() clusterlisterv1alpha1.AddOnPlacementScoreLister {
 	return f.scoreLister
 }
@@ -38,10 +49,18 @@ func (f *FakePluginHandle) ClusterClient() clusterclient.Interface {
 func NewFakePluginHandle(
 	t *testing.T, client *clusterfake.Clientset, objects ...runtime.Object) *FakePluginHandle {
 	informers := NewClusterInformerFactory(client, objects...)
+	clusterDecisionIndex := plugins.NewClusterDecisionIndex()
+	for _, obj := range objects {
+		if decision, ok := obj.(*clusterapiv1beta1.PlacementDecision); ok {
+			clusterDecisionIndex.OnAdd(decision, true)
+		}
+	}
 	return &FakePluginHandle{
 		recorder:                kevents.NewFakeRecorder(100),
 		client:                  client,
 		placementDecisionLister: informers.Cluster().V1beta1().PlacementDecisions().Lister(),
+		placementLister:         informers.Cluster().V1beta1().Placements().Lister(),
+		clusterDecisionIndex:    clusterDecisionIndex,
 		scoreLister:             informers.Cluster().V1alpha1().AddOnPlacementScores().Lister(),
 		clusterLister:           informers.Cluster().V1().ManagedClusters().Lister(),
 	}
@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+// PlacementDecisionTracker resolves the clusters selected by the placements from their PlacementDecisions, so the
+// consumers of the placements do not need to list and merge the PlacementDecisions by themselves.
+//
+// The clusters are merged with ConsistentDecisionClusters, an error wrapping ErrInconsistentDecisions is returned
+// while the PlacementDecisions are being re-chunked, and the consumers should retry on the next update of the
+// PlacementDecisions instead of acting on the intermediate clusters.
+type PlacementDecisionTracker struct {
+	placementLister clusterlisterv1beta1.PlacementLister
+	decisionLister  clusterlisterv1beta1.PlacementDecisionLister
+
+	lock sync.Mutex
+	// existing is the clusters of the placements by the key, returned by the last call of Deltas.
+	existing map[string]sets.Set[string]
+}
+
+// NewPlacementDecisionTracker creates a PlacementDecisionTracker with the listers of the placements and the
+// PlacementDecisions.
+func NewPlacementDecisionTracker(
+	placementLister clusterlisterv1beta1.PlacementLister,
+	decisionLister clusterlisterv1beta1.PlacementDecisionLister) *PlacementDecisionTracker {
+	return &PlacementDecisionTracker{
+		placementLister: placementLister,
+		decisionLister:  decisionLister,
+		existing:        map[string]sets.Set[string]{},
+	}
+}
+
+// Clusters returns the clusters selected by the placement. A not found error is returned if the placement
+// does not exist, and an error wrapping ErrDecisionsNotCreated if the placement has no PlacementDecision yet.
+func (t *PlacementDecisionTracker) Clusters(namespace, name string) (sets.Set[string], error) {
+	decisions, err := t.listDecisions(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(decisions) == 0 {
+		return nil, fmt.Errorf("%w: placement %s/%s", ErrDecisionsNotCreated, namespace, name)
+	}
+
+	clusters, err := ConsistentDecisionClusters(decisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clusters of placement %s/%s: %w", namespace, name, err)
+	}
+	return clusters, nil
+}
+
+// Deltas returns the clusters selected by the placement, and the clusters added and deleted since the last call
+// of Deltas for the placement. If the placement does not exist, all the clusters returned by the last call are
+// deleted. The tracked clusters are not changed if an error is returned.
+func (t *PlacementDecisionTracker) Deltas(namespace, name string) (clusters, added, deleted sets.Set[string], err error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+
+	clusters, err = t.Clusters(namespace, name)
+	switch {
+	case errors.IsNotFound(err):
+		clusters = sets.New[string]()
+	case err != nil:
+		return nil, nil, nil, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	existing, ok := t.existing[key]
+	if !ok {
+		existing = sets.New[string]()
+	}
+	if clusters.Len() == 0 {
+		delete(t.existing, key)
+	} else {
+		t.existing[key] = clusters
+	}
+	return clusters, clusters.Difference(existing), existing.Difference(clusters), nil
+}
+
+// Groups returns the clusters of each PlacementDecision of the placement, ordered by the index of the
+// PlacementDecisions. The PlacementDecisions without an index are ordered by the name after the others.
+func (t *PlacementDecisionTracker) Groups(namespace, name string) ([]sets.Set[string], error) {
+	decisions, err := t.listDecisions(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ConsistentDecisionClusters(decisions); err != nil {
+		return nil, fmt.Errorf("failed to get clusters of placement %s/%s: %w", namespace, name, err)
+	}
+
+	sort.SliceStable(decisions, func(i, j int) bool {
+		iIndex, iErr := strconv.Atoi(decisions[i].Labels[DecisionIndexLabel])
+		jIndex, jErr := strconv.Atoi(decisions[j].Labels[DecisionIndexLabel])
+		switch {
+		case iErr == nil && jErr == nil:
+			return iIndex < jIndex
+		case iErr == nil || jErr == nil:
+			return iErr == nil
+		}
+		return decisions[i].Name < decisions[j].Name
+	})
+
+	var groups []sets.Set[string]
+	for _, decision := range decisions {
+		group := sets.New[string]()
+		for _, clusterDecision := range decision.Status.Decisions {
+			if len(clusterDecision.ClusterName) > 0 {
+				group.Insert(clusterDecision.ClusterName)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// Forget stops tracking the clusters of the placement, so the next call of Deltas returns all the clusters
+// as added.
+func (t *PlacementDecisionTracker) Forget(namespace, name string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.existing, fmt.Sprintf("%s/%s", namespace, name))
+}
+
+func (t *PlacementDecisionTracker) listDecisions(namespace, name string) ([]*clusterapiv1beta1.PlacementDecision, error) {
+	if _, err := t.placementLister.Placements(namespace).Get(name); err != nil {
+		return nil, err
+	}
+
+	decisionSelector := labels.SelectorFromSet(labels.Set{
+		clusterapiv1beta1.PlacementLabel: name,
+	})
+	decisions, err := t.decisionLister.PlacementDecisions(namespace).List(decisionSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PlacementDecisions: %w", err)
+	}
+	return decisions, nil
+}
+
+// PlacementKeyOfDecision returns the key of the placement of the PlacementDecision in the format of
+// namespace/name, it returns false if the object is not a PlacementDecision of a placement.
+func PlacementKeyOfDecision(obj interface{}) (string, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", false
+	}
+	placementName, ok := accessor.GetLabels()[clusterapiv1beta1.PlacementLabel]
+	if !ok || len(placementName) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s", accessor.GetNamespace(), placementName), true
+}
+
+// DecisionEventHandler returns an event handler of the PlacementDecision informer, which calls the handler with
+// the key of the placement once any of its PlacementDecisions is changed.
+func DecisionEventHandler(handler func(placementKey string)) cache.ResourceEventHandler {
+	handle := func(obj interface{}) {
+		if key, ok := PlacementKeyOfDecision(obj); ok {
+			handler(key)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(_, newObj interface{}) { handle(newObj) },
+		DeleteFunc: handle,
+	}
+}
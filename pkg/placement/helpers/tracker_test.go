@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func newTestTracker(t *testing.T) (*PlacementDecisionTracker, cache.Indexer, cache.Indexer) {
+	placementIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	decisionIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := placementIndexer.Add(testinghelpers.NewPlacement("ns1", "placement1").Build()); err != nil {
+		t.Fatal(err)
+	}
+	tracker := NewPlacementDecisionTracker(
+		clusterlisterv1beta1.NewPlacementLister(placementIndexer),
+		clusterlisterv1beta1.NewPlacementDecisionLister(decisionIndexer))
+	return tracker, placementIndexer, decisionIndexer
+}
+
+func TestPlacementDecisionTrackerDeltas(t *testing.T) {
+	oldClusters := []string{"cluster1", "cluster2", "cluster3"}
+	newClusters := []string{"cluster1", "cluster2", "cluster4"}
+
+	steps := []struct {
+		name             string
+		decisions        []*clusterapiv1beta1.PlacementDecision
+		expectedAdded    []string
+		expectedDeleted  []string
+		expectedErr      bool
+		deletePlacement  bool
+		expectedClusters []string
+	}{
+		{
+			name: "initial decisions",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, oldClusters, "cluster1", "cluster2"),
+				newDecision(2, oldClusters, "cluster3"),
+			},
+			expectedAdded:    oldClusters,
+			expectedDeleted:  []string{},
+			expectedClusters: oldClusters,
+		},
+		{
+			name: "decisions are re-chunked without change",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, oldClusters, "cluster1"),
+				newDecision(2, oldClusters, "cluster3", "cluster2"),
+			},
+			expectedAdded:    []string{},
+			expectedDeleted:  []string{},
+			expectedClusters: oldClusters,
+		},
+		{
+			name: "decisions are being re-chunked",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, oldClusters, "cluster1"),
+				newDecision(2, newClusters, "cluster2", "cluster4"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "decisions are re-chunked with change",
+			decisions: []*clusterapiv1beta1.PlacementDecision{
+				newDecision(1, newClusters, "cluster1"),
+				newDecision(2, newClusters, "cluster2", "cluster4"),
+			},
+			expectedAdded:    []string{"cluster4"},
+			expectedDeleted:  []string{"cluster3"},
+			expectedClusters: newClusters,
+		},
+		{
+			name:             "placement is deleted",
+			deletePlacement:  true,
+			expectedAdded:    []string{},
+			expectedDeleted:  newClusters,
+			expectedClusters: []string{},
+		},
+	}
+
+	tracker, placementIndexer, decisionIndexer := newTestTracker(t)
+	for _, step := range steps {
+		var objs []interface{}
+		for _, decision := range step.decisions {
+			objs = append(objs, decision)
+		}
+		if err := decisionIndexer.Replace(objs, ""); err != nil {
+			t.Fatal(err)
+		}
+		if step.deletePlacement {
+			if err := placementIndexer.Replace(nil, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		clusters, added, deleted, err := tracker.Deltas("ns1", "placement1")
+		if step.expectedErr {
+			if !errors.Is(err, ErrInconsistentDecisions) {
+				t.Errorf("%s: expected inconsistent decisions error, but got %v", step.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", step.name, err)
+		}
+		if !clusters.Equal(sets.New[string](step.expectedClusters...)) {
+			t.Errorf("%s: expected clusters %v, but got %v", step.name, step.expectedClusters, sets.List(clusters))
+		}
+		if !added.Equal(sets.New[string](step.expectedAdded...)) {
+			t.Errorf("%s: expected added %v, but got %v", step.name, step.expectedAdded, sets.List(added))
+		}
+		if !deleted.Equal(sets.New[string](step.expectedDeleted...)) {
+			t.Errorf("%s: expected deleted %v, but got %v", step.name, step.expectedDeleted, sets.List(deleted))
+		}
+	}
+}
+
+func TestPlacementDecisionTrackerClusters(t *testing.T) {
+	tracker, placementIndexer, decisionIndexer := newTestTracker(t)
+
+	// the decisions are not created yet
+	if _, err := tracker.Clusters("ns1", "placement1"); !errors.Is(err, ErrDecisionsNotCreated) {
+		t.Errorf("expected decisions not created error, but got %v", err)
+	}
+
+	clusters := []string{"cluster1", "cluster2", "cluster3"}
+	for _, decision := range []*clusterapiv1beta1.PlacementDecision{
+		newDecision(2, clusters, "cluster3"),
+		newDecision(1, clusters, "cluster2", "cluster1"),
+	} {
+		if err := decisionIndexer.Add(decision); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := tracker.Clusters("ns1", "placement1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !actual.Equal(sets.New[string](clusters...)) {
+		t.Errorf("expected clusters %v, but got %v", clusters, sets.List(actual))
+	}
+
+	groups, err := tracker.Groups("ns1", "placement1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedGroups := []sets.Set[string]{sets.New[string]("cluster1", "cluster2"), sets.New[string]("cluster3")}
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Errorf("expected groups %v, but got %v", expectedGroups, groups)
+	}
+
+	// the placement is deleted
+	if err := placementIndexer.Replace(nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.Clusters("ns1", "placement1"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found error, but got %v", err)
+	}
+}
+
+func TestPlacementKeyOfDecision(t *testing.T) {
+	decision := newDecision(1, nil, "cluster1")
+	cases := []struct {
+		name        string
+		obj         interface{}
+		expectedKey string
+	}{
+		{
+			name:        "decision of a placement",
+			obj:         decision,
+			expectedKey: "ns1/placement1",
+		},
+		{
+			name:        "deleted decision",
+			obj:         cache.DeletedFinalStateUnknown{Key: "ns1/placement1-decision-1", Obj: decision},
+			expectedKey: "ns1/placement1",
+		},
+		{
+			name: "decision without placement label",
+			obj:  testinghelpers.NewPlacementDecision("ns1", "decision1").Build(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key, ok := PlacementKeyOfDecision(c.obj)
+			if ok != (len(c.expectedKey) > 0) || key != c.expectedKey {
+				t.Errorf("expected key %q, but got %q", c.expectedKey, key)
+			}
+		})
+	}
+}
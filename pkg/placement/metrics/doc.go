@@ -0,0 +1,2 @@
+// package metrics contains the metrics of the placement scheduling
+package metrics
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	// SchedulingQueueWaitDuration is the time the placements wait in the scheduling queue before they are
+	// reconciled, from the time they are enqueued.
+	SchedulingQueueWaitDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      "placement",
+			Name:           "scheduling_queue_wait_duration_seconds",
+			Help:           "Time the placements wait in the scheduling queue before they are reconciled.",
+			Buckets:        []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// SchedulingYields is the number of the times the placements yield once the time budget of a reconcile is
+	// exceeded before the scheduling completes.
+	SchedulingYields = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      "placement",
+			Name:           "scheduling_yields_total",
+			Help:           "Number of the times the placements yield once the time budget of a reconcile is exceeded.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// SchedulingRestarts is the number of the times the scheduling progress of a yielded placement is dropped,
+	// since the inputs of the placement change, and the scheduling restarts.
+	SchedulingRestarts = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      "placement",
+			Name:           "scheduling_restarts_total",
+			Help:           "Number of the times the scheduling of a yielded placement restarts since its inputs change.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	registerMetrics sync.Once
+)
+
+// Register registers the metrics of the placement scheduling.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(SchedulingQueueWaitDuration)
+		legacyregistry.MustRegister(SchedulingYields)
+		legacyregistry.MustRegister(SchedulingRestarts)
+	})
+}
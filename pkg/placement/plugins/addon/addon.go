@@ -19,7 +19,8 @@ const (
 	description    = `
 	Customize prioritizer get cluster scores from AddOnPlacementScores with sepcific
 	resource name and score name. The clusters which doesn't have corresponding
-	AddOnPlacementScores resource or has expired score is given score 0.
+	AddOnPlacementScores resource or has expired score is given score 0. The score out of
+	[-100, 100] is clamped into the range.
 	`
 )
 
@@ -92,10 +93,10 @@ func (c *AddOn) Score(ctx context.Context, placement *clusterapiv1beta1.Placemen
 			continue
 		}
 
-		// get AddOnPlacementScores score with scoreName
+		// get AddOnPlacementScores score with scoreName, the score out of [-100, 100] is clamped
 		for _, v := range addOnScores.Status.Scores {
 			if v.Name == c.scoreName {
-				scores[cluster.Name] = int64(v.Value)
+				scores[cluster.Name] = plugins.ClampScore(int64(v.Value))
 			}
 		}
 	}
@@ -83,6 +83,21 @@ func TestScoreClusterWithAddOn(t *testing.T) {
 			},
 			expectedScores: map[string]int64{"cluster1": 30, "cluster2": 40, "cluster3": 50},
 		},
+		{
+			name:      "addon scores out of range",
+			placement: testinghelpers.NewPlacement("test", "test").WithScoreCoordinateAddOn("test", "score1", 1).Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").Build(),
+				testinghelpers.NewManagedCluster("cluster2").Build(),
+				testinghelpers.NewManagedCluster("cluster3").Build(),
+			},
+			existingAddOnScores: []runtime.Object{
+				testinghelpers.NewAddOnPlacementScore("cluster1", "test").WithScore("score1", 1000).Build(),
+				testinghelpers.NewAddOnPlacementScore("cluster2", "test").WithScore("score1", -101).Build(),
+				testinghelpers.NewAddOnPlacementScore("cluster3", "test").WithScore("score1", 100).Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": -100, "cluster3": 100},
+		},
 	}
 
 	AddOnClock = testingclock.NewFakeClock(fakeTime)
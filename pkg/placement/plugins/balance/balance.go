@@ -77,9 +77,8 @@ func (b *Balance) Score(ctx context.Context, placement *clusterapiv1beta1.Placem
 		if count, ok := decisionCount[clusterName]; ok {
 			usage := float64(count) / float64(maxCount)
 
-			// Negate the usage and subtracted by 0.5, then we double it and muliply by maxCount,
-			// which normalize the score to value between 100 and -100
-			scores[clusterName] = 2 * int64(float64(plugins.MaxClusterScore)*(0.5-usage))
+			// Normalize the score to value between 100 and -100, the cluster with the max count is given -100.
+			scores[clusterName] = plugins.ScoreByRatio(1 - usage)
 		}
 	}
 
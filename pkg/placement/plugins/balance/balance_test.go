@@ -72,6 +72,21 @@ func TestScoreClusterWithSteady(t *testing.T) {
 			},
 			expectedScores: map[string]int64{"cluster1": -100, "cluster2": 0, "cluster3": 0},
 		},
+		{
+			name:      "decisions with fractional usages",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").Build(),
+				testinghelpers.NewManagedCluster("cluster2").Build(),
+				testinghelpers.NewManagedCluster("cluster3").Build(),
+			},
+			existingDecisions: []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test1").WithLabel(placementLabel, "test1").WithDecisions("cluster1", "cluster2", "cluster3").Build(),
+				testinghelpers.NewPlacementDecision("test", "test2").WithLabel(placementLabel, "test2").WithDecisions("cluster1", "cluster2").Build(),
+				testinghelpers.NewPlacementDecision("test", "test3").WithLabel(placementLabel, "test3").WithDecisions("cluster1").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": -33, "cluster3": 33},
+		},
 	}
 
 	for _, c := range cases {
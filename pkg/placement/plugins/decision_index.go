@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+// ClusterDecisionIndex indexes the clusters selected by each placement. It is maintained incrementally by the
+// events of the PlacementDecision informer, so the prioritizers do not need to list all the PlacementDecisions
+// in each schedule.
+type ClusterDecisionIndex struct {
+	lock sync.RWMutex
+	// decisions is the placement and the clusters of each PlacementDecision.
+	decisions map[types.NamespacedName]indexedDecision
+	// placements is the number of the PlacementDecisions of each placement selecting a cluster, a cluster
+	// is selected by a placement if the number is larger than 0.
+	placements map[types.NamespacedName]map[string]int
+}
+
+type indexedDecision struct {
+	placement types.NamespacedName
+	clusters  sets.Set[string]
+}
+
+var _ cache.ResourceEventHandler = &ClusterDecisionIndex{}
+
+// NewClusterDecisionIndex returns an empty index, it should be registered as an event handler of the
+// PlacementDecision informer.
+func NewClusterDecisionIndex() *ClusterDecisionIndex {
+	return &ClusterDecisionIndex{
+		decisions:  map[types.NamespacedName]indexedDecision{},
+		placements: map[types.NamespacedName]map[string]int{},
+	}
+}
+
+func (i *ClusterDecisionIndex) OnAdd(obj interface{}, _ bool) {
+	if decision, ok := obj.(*clusterapiv1beta1.PlacementDecision); ok {
+		i.set(decision)
+	}
+}
+
+func (i *ClusterDecisionIndex) OnUpdate(_, newObj interface{}) {
+	if decision, ok := newObj.(*clusterapiv1beta1.PlacementDecision); ok {
+		i.set(decision)
+	}
+}
+
+func (i *ClusterDecisionIndex) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if decision, ok := obj.(*clusterapiv1beta1.PlacementDecision); ok {
+		i.lock.Lock()
+		defer i.lock.Unlock()
+		i.remove(types.NamespacedName{Namespace: decision.Namespace, Name: decision.Name})
+	}
+}
+
+// set replaces the clusters of the PlacementDecision in the index.
+func (i *ClusterDecisionIndex) set(decision *clusterapiv1beta1.PlacementDecision) {
+	key := types.NamespacedName{Namespace: decision.Namespace, Name: decision.Name}
+	placementName, ok := decision.Labels[clusterapiv1beta1.PlacementLabel]
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.remove(key)
+	if !ok {
+		return
+	}
+
+	entry := indexedDecision{
+		placement: types.NamespacedName{Namespace: decision.Namespace, Name: placementName},
+		clusters:  sets.New[string](),
+	}
+	for _, d := range decision.Status.Decisions {
+		entry.clusters.Insert(d.ClusterName)
+	}
+	i.decisions[key] = entry
+
+	counts, ok := i.placements[entry.placement]
+	if !ok {
+		counts = map[string]int{}
+		i.placements[entry.placement] = counts
+	}
+	for cluster := range entry.clusters {
+		counts[cluster]++
+	}
+}
+
+// remove deletes the PlacementDecision from the index, the caller must hold the lock.
+func (i *ClusterDecisionIndex) remove(key types.NamespacedName) {
+	entry, ok := i.decisions[key]
+	if !ok {
+		return
+	}
+	delete(i.decisions, key)
+
+	counts := i.placements[entry.placement]
+	for cluster := range entry.clusters {
+		counts[cluster]--
+		if counts[cluster] <= 0 {
+			delete(counts, cluster)
+		}
+	}
+	if len(counts) == 0 {
+		delete(i.placements, entry.placement)
+	}
+}
+
+// PlacementCounts returns the number of the placements selecting each cluster. Only the placements accepted
+// by the filter are counted, all the placements are counted if the filter is nil.
+func (i *ClusterDecisionIndex) PlacementCounts(filter func(placement types.NamespacedName) bool) map[string]int64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	counts := map[string]int64{}
+	for placement, clusters := range i.placements {
+		if filter != nil && !filter(placement) {
+			continue
+		}
+		for cluster := range clusters {
+			counts[cluster]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+func TestClusterDecisionIndex(t *testing.T) {
+	newDecision := func(name, placement string, clusters ...string) *clusterapiv1beta1.PlacementDecision {
+		decision := &clusterapiv1beta1.PlacementDecision{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name},
+		}
+		if len(placement) > 0 {
+			decision.Labels = map[string]string{clusterapiv1beta1.PlacementLabel: placement}
+		}
+		for _, cluster := range clusters {
+			decision.Status.Decisions = append(decision.Status.Decisions, clusterapiv1beta1.ClusterDecision{ClusterName: cluster})
+		}
+		return decision
+	}
+
+	index := NewClusterDecisionIndex()
+	assertCounts := func(filter func(types.NamespacedName) bool, expected map[string]int64) {
+		t.Helper()
+		if actual := index.PlacementCounts(filter); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected counts %v, but got %v", expected, actual)
+		}
+	}
+
+	// a placement with two decisions selecting the clusters is counted once for each cluster
+	index.OnAdd(newDecision("placement1-decision-1", "placement1", "cluster1", "cluster2"), true)
+	index.OnAdd(newDecision("placement1-decision-2", "placement1", "cluster3"), true)
+	index.OnAdd(newDecision("placement2-decision-1", "placement2", "cluster1"), false)
+	// the decision without the placement label is ignored
+	index.OnAdd(newDecision("orphan", "", "cluster1"), false)
+	assertCounts(nil, map[string]int64{"cluster1": 2, "cluster2": 1, "cluster3": 1})
+
+	// filter the placements
+	assertCounts(func(p types.NamespacedName) bool {
+		return p.Name == "placement2"
+	}, map[string]int64{"cluster1": 1})
+
+	// the decision is updated
+	index.OnUpdate(nil, newDecision("placement2-decision-1", "placement2", "cluster2"))
+	assertCounts(nil, map[string]int64{"cluster1": 1, "cluster2": 2, "cluster3": 1})
+
+	// the decision is deleted
+	index.OnDelete(newDecision("placement1-decision-2", "placement1", "cluster3"))
+	assertCounts(nil, map[string]int64{"cluster1": 1, "cluster2": 2})
+
+	// the decision is deleted with a tombstone
+	index.OnDelete(cache.DeletedFinalStateUnknown{
+		Key: "ns1/placement2-decision-1",
+		Obj: newDecision("placement2-decision-1", "placement2", "cluster2"),
+	})
+	assertCounts(nil, map[string]int64{"cluster1": 1, "cluster2": 1})
+}
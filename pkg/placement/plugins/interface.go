@@ -46,7 +46,7 @@ type Filter interface {
 }
 
 // Prioritizer defines a prioritizer plugin that score each cluster. The score is normalized
-// as a floating between 0 and 1.
+// into [MinClusterScore, MaxClusterScore] following the contract in normalize.go.
 type Prioritizer interface {
 	Plugin
 
@@ -61,6 +61,12 @@ type Handle interface {
 	// DecisionLister lists all decisions
 	DecisionLister() clusterlisterv1beta1.PlacementDecisionLister
 
+	// PlacementLister lists all placements
+	PlacementLister() clusterlisterv1beta1.PlacementLister
+
+	// ClusterDecisionIndex returns the index of the clusters selected by each placement
+	ClusterDecisionIndex() *ClusterDecisionIndex
+
 	// ScoreLister lists all AddOnPlacementScores
 	ScoreLister() clusterlisterv1alpha1.AddOnPlacementScoreLister
 
@@ -78,6 +84,9 @@ type Handle interface {
 type PluginFilterResult struct {
 	// Filtered contains the filtered ManagedCluster.
 	Filtered []*clusterapiv1.ManagedCluster
+	// EvictionSuspension is the message why the clusters in the decisions are kept in the filtered clusters
+	// although they do not pass the filter, it is empty if no eviction is suspended.
+	EvictionSuspension string
 }
 
 // PluginScoreResult contains the details of a score plugin result.
@@ -0,0 +1,31 @@
+package plugins
+
+import "math"
+
+// The scores of the Prioritizer plugins follow the normalization contract below, so the weight of a prioritizer
+// means the same regardless of how the prioritizer scores the clusters:
+//   - the score of each cluster is in the range of [MinClusterScore, MaxClusterScore];
+//   - a prioritizer comparing the clusters with each other, e.g. Balance, Spread and ResourceAllocatableCPU, maps
+//     the ratio of each cluster in [0, 1] linearly into the range by ScoreByRatio, so the cluster preferred the most
+//     is given MaxClusterScore and the one preferred the least is given MinClusterScore;
+//   - a score provided by the users, e.g. the AddOnPlacementScore, is clamped into the range by ClampScore.
+//
+// The scheduler sums the scores multiplied by the weights of the prioritizers.
+
+// ScoreByRatio normalizes the ratio in [0, 1] into [MinClusterScore, MaxClusterScore] linearly, the ratio out of
+// [0, 1] is clamped.
+func ScoreByRatio(ratio float64) int64 {
+	return ClampScore(MinClusterScore + int64(math.Round(ratio*float64(MaxClusterScore-MinClusterScore))))
+}
+
+// ClampScore clamps the score into [MinClusterScore, MaxClusterScore].
+func ClampScore(score int64) int64 {
+	switch {
+	case score > MaxClusterScore:
+		return MaxClusterScore
+	case score < MinClusterScore:
+		return MinClusterScore
+	default:
+		return score
+	}
+}
@@ -0,0 +1,32 @@
+package plugins
+
+import "testing"
+
+func TestScoreByRatio(t *testing.T) {
+	cases := map[float64]int64{
+		-0.5:     MinClusterScore,
+		0:        MinClusterScore,
+		1.0 / 3:  -33,
+		0.5:      0,
+		0.7:      40,
+		2.0 / 3:  33,
+		0.999:    100,
+		1:        MaxClusterScore,
+		1.5:      MaxClusterScore,
+		0.123456: -75,
+	}
+	for ratio, expected := range cases {
+		if score := ScoreByRatio(ratio); score != expected {
+			t.Errorf("expected score %d of ratio %v, but got %d", expected, ratio, score)
+		}
+	}
+}
+
+func TestClampScore(t *testing.T) {
+	cases := map[int64]int64{-1000: MinClusterScore, -100: -100, 0: 0, 42: 42, 100: 100, 101: MaxClusterScore}
+	for score, expected := range cases {
+		if clamped := ClampScore(score); clamped != expected {
+			t.Errorf("expected score %d clamped to %d, but got %d", score, expected, clamped)
+		}
+	}
+}
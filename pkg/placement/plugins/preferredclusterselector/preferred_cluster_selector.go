@@ -0,0 +1,165 @@
+package preferredclusterselector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+)
+
+const (
+	// PreferredClusterSelectorsAnnotation is the annotation on a placement to specify a list of weighted
+	// cluster selectors in json, the clusters matching the selectors are preferred but not required, e.g.
+	// [{"weight": 50, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]
+	PreferredClusterSelectorsAnnotation = "cluster.open-cluster-management.io/preferred-cluster-selectors"
+
+	// MinWeight and MaxWeight are the range of the weight of a preferred cluster selector.
+	MinWeight int32 = 1
+	MaxWeight int32 = 100
+
+	description = `
+	PreferredClusterSelector prioritizer gives a higher score to the clusters matching more preferred cluster
+	selectors of the placement. Each matching selector adds its weight to the score of the cluster, and the
+	score is normalized by the total weight of the selectors.
+	`
+)
+
+// WeightedClusterSelector is a cluster selector with a weight in the range of 1-100.
+type WeightedClusterSelector struct {
+	Weight          int32                             `json:"weight"`
+	ClusterSelector clusterapiv1beta1.ClusterSelector `json:"clusterSelector"`
+}
+
+type weightedSelector struct {
+	weight        int64
+	labelSelector labels.Selector
+	claimSelector labels.Selector
+}
+
+var _ plugins.Prioritizer = &PreferredClusterSelector{}
+
+type PreferredClusterSelector struct {
+	handle plugins.Handle
+}
+
+func New(handle plugins.Handle) *PreferredClusterSelector {
+	return &PreferredClusterSelector{
+		handle: handle,
+	}
+}
+
+func (p *PreferredClusterSelector) Name() string {
+	return reflect.TypeOf(*p).Name()
+}
+
+func (p *PreferredClusterSelector) Description() string {
+	return description
+}
+
+func (p *PreferredClusterSelector) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	scores := map[string]int64{}
+	for _, cluster := range clusters {
+		scores[cluster.Name] = 0
+	}
+
+	preferredSelectors, err := GetPreferredClusterSelectors(placement)
+	if err != nil {
+		return plugins.PluginScoreResult{}, framework.NewStatus(
+			p.Name(),
+			framework.Misconfigured,
+			err.Error(),
+		)
+	}
+
+	var totalWeight int64
+	selectors := []weightedSelector{}
+	for _, preferredSelector := range preferredSelectors {
+		labelSelector, err := metav1.LabelSelectorAsSelector(&preferredSelector.ClusterSelector.LabelSelector)
+		if err != nil {
+			return plugins.PluginScoreResult{}, framework.NewStatus(
+				p.Name(),
+				framework.Misconfigured,
+				err.Error(),
+			)
+		}
+		claimSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+			MatchExpressions: preferredSelector.ClusterSelector.ClaimSelector.MatchExpressions,
+		})
+		if err != nil {
+			return plugins.PluginScoreResult{}, framework.NewStatus(
+				p.Name(),
+				framework.Misconfigured,
+				err.Error(),
+			)
+		}
+		selectors = append(selectors, weightedSelector{
+			weight:        int64(preferredSelector.Weight),
+			labelSelector: labelSelector,
+			claimSelector: claimSelector,
+		})
+		totalWeight += int64(preferredSelector.Weight)
+	}
+
+	if totalWeight == 0 {
+		return plugins.PluginScoreResult{
+			Scores: scores,
+		}, framework.NewStatus(p.Name(), framework.Success, "")
+	}
+
+	for _, cluster := range clusters {
+		claims := map[string]string{}
+		for _, claim := range cluster.Status.ClusterClaims {
+			claims[claim.Name] = claim.Value
+		}
+
+		var weight int64
+		for _, s := range selectors {
+			if s.labelSelector.Matches(labels.Set(cluster.Labels)) && s.claimSelector.Matches(labels.Set(claims)) {
+				weight += s.weight
+			}
+		}
+
+		// normalize the sum of the weights into the range of [0, MaxClusterScore]
+		scores[cluster.Name] = weight * plugins.MaxClusterScore / totalWeight
+	}
+
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, framework.NewStatus(p.Name(), framework.Success, "")
+}
+
+func (p *PreferredClusterSelector) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(p.Name(), framework.Success, "")
+}
+
+// GetPreferredClusterSelectors returns the preferred cluster selectors of the placement. An error is
+// returned if the annotation cannot be parsed or any weight is out of the range of 1-100.
+func GetPreferredClusterSelectors(placement *clusterapiv1beta1.Placement) ([]WeightedClusterSelector, error) {
+	value, ok := placement.Annotations[PreferredClusterSelectorsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	selectors := []WeightedClusterSelector{}
+	if err := json.Unmarshal([]byte(value), &selectors); err != nil {
+		return nil, fmt.Errorf("failed to parse preferred cluster selectors: %w", err)
+	}
+
+	for _, s := range selectors {
+		if s.Weight < MinWeight || s.Weight > MaxWeight {
+			return nil, fmt.Errorf("the weight of preferred cluster selector should be in the range of %d-%d, but got %d",
+				MinWeight, MaxWeight, s.Weight)
+		}
+	}
+	return selectors, nil
+}
@@ -0,0 +1,94 @@
+package preferredclusterselector
+
+import (
+	"context"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func newPlacement(selectors string) *clusterapiv1beta1.Placement {
+	if len(selectors) == 0 {
+		return testinghelpers.NewPlacement("test", "test").Build()
+	}
+	return testinghelpers.NewPlacementWithAnnotations("test", "test", map[string]string{
+		PreferredClusterSelectorsAnnotation: selectors,
+	}).Build()
+}
+
+func TestScoreClusterWithPreferredClusterSelector(t *testing.T) {
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").WithLabel("ssd", "true").WithClaim("region", "us-east-1").Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithLabel("ssd", "true").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithClaim("region", "us-east-1").Build(),
+		testinghelpers.NewManagedCluster("cluster4").Build(),
+	}
+
+	cases := []struct {
+		name           string
+		placement      *clusterapiv1beta1.Placement
+		expectedScores map[string]int64
+		expectedCode   framework.Code
+	}{
+		{
+			name:           "no preferred cluster selectors",
+			placement:      newPlacement(""),
+			expectedScores: map[string]int64{"cluster1": 0, "cluster2": 0, "cluster3": 0, "cluster4": 0},
+			expectedCode:   framework.Success,
+		},
+		{
+			name:           "prefer clusters by label",
+			placement:      newPlacement(`[{"weight": 50, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]`),
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 100, "cluster3": 0, "cluster4": 0},
+			expectedCode:   framework.Success,
+		},
+		{
+			name: "prefer clusters by weighted label and claim",
+			placement: newPlacement(`[
+				{"weight": 60, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}},
+				{"weight": 40, "clusterSelector": {"claimSelector": {"matchExpressions": [{"key": "region", "operator": "In", "values": ["us-east-1"]}]}}}
+			]`),
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 60, "cluster3": 40, "cluster4": 0},
+			expectedCode:   framework.Success,
+		},
+		{
+			name:         "weight out of range",
+			placement:    newPlacement(`[{"weight": 101, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]`),
+			expectedCode: framework.Misconfigured,
+		},
+		{
+			name:         "zero weight",
+			placement:    newPlacement(`[{"weight": 0, "clusterSelector": {"labelSelector": {"matchLabels": {"ssd": "true"}}}}]`),
+			expectedCode: framework.Misconfigured,
+		},
+		{
+			name:         "invalid json",
+			placement:    newPlacement(`{"weight": 1}`),
+			expectedCode: framework.Misconfigured,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := New(testinghelpers.NewFakePluginHandle(t, nil))
+
+			scoreResult, status := p.Score(context.TODO(), c.placement, clusters)
+			if status.Code() != c.expectedCode {
+				t.Errorf("Expect code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+			if c.expectedCode != framework.Success {
+				return
+			}
+
+			if !apiequality.Semantic.DeepEqual(scoreResult.Scores, c.expectedScores) {
+				t.Errorf("Expect score %v, but got %v", c.expectedScores, scoreResult.Scores)
+			}
+		})
+	}
+}
@@ -113,13 +113,12 @@ func mostResourceAllocatableScores(resourceName clusterapiv1.ResourceName, clust
 			continue
 		}
 
-		// score = ((resource_x_allocatable - min(resource_x_allocatable)) / (max(resource_x_allocatable) - min(resource_x_allocatable)) - 0.5) * 2 * 100
+		// ratio = (resource_x_allocatable - min(resource_x_allocatable)) / (max(resource_x_allocatable) - min(resource_x_allocatable))
+		ratio := 1.0
 		if (maxAllocatable - minAllocatable) != 0 {
-			ratio := (allocatable - minAllocatable) / (maxAllocatable - minAllocatable)
-			scores[cluster.Name] = int64((ratio - 0.5) * 2.0 * 100.0)
-		} else {
-			scores[cluster.Name] = 100.0
+			ratio = (allocatable - minAllocatable) / (maxAllocatable - minAllocatable)
 		}
+		scores[cluster.Name] = plugins.ScoreByRatio(ratio)
 	}
 
 	return plugins.PluginScoreResult{
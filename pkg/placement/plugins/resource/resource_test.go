@@ -83,6 +83,19 @@ func TestScoreClusterWithResource(t *testing.T) {
 			},
 			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 0, "cluster3": -100},
 		},
+		{
+			name:      "scores of ResourceAllocatableCPU with fractional ratios",
+			resource:  clusterapiv1.ResourceCPU,
+			algorithm: "Allocatable",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithResource(clusterapiv1.ResourceCPU, "9", "10").Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithResource(clusterapiv1.ResourceCPU, "4", "10").Build(),
+				testinghelpers.NewManagedCluster("cluster3").WithResource(clusterapiv1.ResourceCPU, "3", "10").Build(),
+				testinghelpers.NewManagedCluster("cluster4").WithResource(clusterapiv1.ResourceCPU, "2", "10").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": -43, "cluster3": -71, "cluster4": -100},
+		},
 		{
 			name:      "scores of ResourceAllocatableCPU with same resource value",
 			resource:  clusterapiv1.ResourceCPU,
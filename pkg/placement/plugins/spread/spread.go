@@ -0,0 +1,105 @@
+package spread
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+)
+
+const (
+	// SpreadGroupLabel is the label on the placements to spread among each other. If a placement has the label,
+	// only the decisions of the placements with the same label value are counted, otherwise the decisions of
+	// all the placements are counted.
+	// TODO move this to the api repo
+	SpreadGroupLabel = "cluster.open-cluster-management.io/spread-group"
+
+	description = `
+	Spread prioritizer spreads the placements across the clusters. The score of a cluster is penalized
+	proportionally to the number of the other placements selecting it, so the cluster selected by the
+	most placements is given the lowest score, while the cluster not selected is given the highest score.
+	`
+)
+
+var _ plugins.Prioritizer = &Spread{}
+
+type Spread struct {
+	handle plugins.Handle
+}
+
+func New(handle plugins.Handle) *Spread {
+	return &Spread{
+		handle: handle,
+	}
+}
+
+func (s *Spread) Name() string {
+	return reflect.TypeOf(*s).Name()
+}
+
+func (s *Spread) Description() string {
+	return description
+}
+
+func (s *Spread) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	current := types.NamespacedName{Namespace: placement.Namespace, Name: placement.Name}
+
+	// Do not count the decisions of the placement being scheduled.
+	filter := func(p types.NamespacedName) bool {
+		return p != current
+	}
+	if group, ok := placement.Labels[SpreadGroupLabel]; ok {
+		placements, err := s.handle.PlacementLister().List(labels.SelectorFromSet(labels.Set{SpreadGroupLabel: group}))
+		if err != nil {
+			return plugins.PluginScoreResult{}, framework.NewStatus(
+				s.Name(),
+				framework.Error,
+				err.Error(),
+			)
+		}
+		members := sets.New[types.NamespacedName]()
+		for _, p := range placements {
+			members.Insert(types.NamespacedName{Namespace: p.Namespace, Name: p.Name})
+		}
+		filter = func(p types.NamespacedName) bool {
+			return p != current && members.Has(p)
+		}
+	}
+
+	counts := s.handle.ClusterDecisionIndex().PlacementCounts(filter)
+
+	var maxCount int64
+	for _, cluster := range clusters {
+		if counts[cluster.Name] > maxCount {
+			maxCount = counts[cluster.Name]
+		}
+	}
+
+	scores := map[string]int64{}
+	for _, cluster := range clusters {
+		scores[cluster.Name] = plugins.MaxClusterScore
+		if maxCount == 0 {
+			continue
+		}
+		// Normalize the score to value between 100 and -100, the cluster with the max count is given -100.
+		usage := float64(counts[cluster.Name]) / float64(maxCount)
+		scores[cluster.Name] = plugins.ScoreByRatio(1 - usage)
+	}
+
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, framework.NewStatus(s.Name(), framework.Success, "")
+}
+
+func (s *Spread) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(s.Name(), framework.Success, "")
+}
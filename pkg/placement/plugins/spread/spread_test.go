@@ -0,0 +1,101 @@
+package spread
+
+import (
+	"context"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func TestScoreClusterWithSpread(t *testing.T) {
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").Build(),
+		testinghelpers.NewManagedCluster("cluster2").Build(),
+		testinghelpers.NewManagedCluster("cluster3").Build(),
+	}
+	grouped := testinghelpers.NewPlacement("test", "test").Build()
+	grouped.Labels = map[string]string{SpreadGroupLabel: "group1"}
+	groupMember := testinghelpers.NewPlacement("test", "test1").Build()
+	groupMember.Labels = map[string]string{SpreadGroupLabel: "group1"}
+
+	cases := []struct {
+		name           string
+		placement      *clusterapiv1beta1.Placement
+		objects        []runtime.Object
+		expectedScores map[string]int64
+	}{
+		{
+			name:           "no decisions",
+			placement:      testinghelpers.NewPlacement("test", "test").Build(),
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 100, "cluster3": 100},
+		},
+		{
+			name:      "decisions of current placement are not counted",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			objects: []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test").WithDecisions("cluster1").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 100, "cluster3": 100},
+		},
+		{
+			name:      "clusters are penalized proportionally",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			objects: []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test1-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test1").WithDecisions("cluster1", "cluster2").Build(),
+				testinghelpers.NewPlacementDecision("test", "test2-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test2").WithDecisions("cluster1").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": 0, "cluster3": 100},
+		},
+		{
+			name:      "clusters are penalized with fractional usages",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			objects: []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test1-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test1").WithDecisions("cluster1", "cluster2", "cluster3").Build(),
+				testinghelpers.NewPlacementDecision("test", "test2-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test2").WithDecisions("cluster1", "cluster2").Build(),
+				testinghelpers.NewPlacementDecision("test", "test3-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test3").WithDecisions("cluster1").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": -33, "cluster3": 33},
+		},
+		{
+			name:      "only the placements in the same group are counted",
+			placement: grouped,
+			objects: []runtime.Object{
+				grouped,
+				groupMember,
+				testinghelpers.NewPlacement("test", "test2").Build(),
+				testinghelpers.NewPlacementDecision("test", "test1-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test1").WithDecisions("cluster2").Build(),
+				testinghelpers.NewPlacementDecision("test", "test2-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "test2").WithDecisions("cluster1").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": -100, "cluster3": 100},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			spread := New(testinghelpers.NewFakePluginHandle(t, nil, c.objects...))
+
+			scoreResult, status := spread.Score(context.TODO(), c.placement, clusters)
+			if err := status.AsError(); err != nil {
+				t.Errorf("Expect no error, but got %v", err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(scoreResult.Scores, c.expectedScores) {
+				t.Errorf("Expect score %v, but got %v", c.expectedScores, scoreResult.Scores)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package tainttoleration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+const (
+	// MinSelectedClustersAnnotation is the annotation on a placement with the minimum number of the selected
+	// clusters, or the minimum percentage of the currently selected clusters, e.g. "3" or "60%". The clusters
+	// are not removed from the decisions by the taints not tolerated if the selected clusters left would be
+	// fewer than it, so the workloads do not stampede onto the remaining clusters on a regional outage. The
+	// evictions are resumed once enough clusters recover. An invalid value is ignored with a warning.
+	// TODO move this to the api repo
+	MinSelectedClustersAnnotation = "cluster.open-cluster-management.io/min-selected-clusters"
+
+	// ForceEvictionAnnotation is the annotation on a placement to evict the clusters with the taints not tolerated
+	// regardless of the MinSelectedClustersAnnotation when it is "true", it is set to reschedule the placement
+	// manually. It is removed once the placement is rescheduled, so it applies to one scheduling only.
+	// TODO move this to the api repo
+	ForceEvictionAnnotation = "cluster.open-cluster-management.io/force-eviction"
+
+	// PlacementConditionEvictionSuspended is the condition of a placement with the MinSelectedClustersAnnotation.
+	// It is True while the evictions by the taints are suspended, with the clusters kept in the decisions.
+	// TODO move this to the api repo
+	PlacementConditionEvictionSuspended = "EvictionSuspended"
+
+	// maxSuspendedClustersInMessage is the max number of the clusters listed in the suspension message.
+	maxSuspendedClustersInMessage = 10
+)
+
+// ValidateMinSelectedClusters returns an error if the MinSelectedClustersAnnotation of the placement is invalid.
+func ValidateMinSelectedClusters(placement *clusterapiv1beta1.Placement) error {
+	_, _, err := minSelectedClusters(placement, 0)
+	return err
+}
+
+// minSelectedClusters returns the minimum number of the selected clusters of the placement, the percentage is
+// rounded up with the number of the decision clusters. It returns false if the safeguard is not set.
+func minSelectedClusters(placement *clusterapiv1beta1.Placement, numOfDecisionClusters int) (int, bool, error) {
+	value, ok := placement.Annotations[MinSelectedClustersAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+
+	minValue := intstr.Parse(value)
+	minClusters, err := intstr.GetScaledValueFromIntOrPercent(&minValue, numOfDecisionClusters, true)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s annotation %q: %v", MinSelectedClustersAnnotation, value, err)
+	}
+	if minClusters < 0 {
+		return 0, false, fmt.Errorf("invalid %s annotation %q: must not be negative", MinSelectedClustersAnnotation, value)
+	}
+	return minClusters, true, nil
+}
+
+// suspendEvictions returns the message of the suspension if the evicted clusters should be kept in the
+// decisions, since the decision clusters left would be fewer than the minimum selected clusters. It returns an
+// empty message if the evictions are not suspended.
+func suspendEvictions(placement *clusterapiv1beta1.Placement, numOfDecisionClusters, numOfRemainingClusters int,
+	evicted []*clusterapiv1.ManagedCluster) (string, error) {
+	minClusters, ok, err := minSelectedClusters(placement, numOfDecisionClusters)
+	if err != nil || !ok || len(evicted) == 0 {
+		return "", err
+	}
+	if placement.Annotations[ForceEvictionAnnotation] == "true" || numOfRemainingClusters >= minClusters {
+		return "", nil
+	}
+
+	names := make([]string, 0, len(evicted))
+	for _, cluster := range evicted {
+		names = append(names, cluster.Name)
+	}
+	sort.Strings(names)
+	if len(names) > maxSuspendedClustersInMessage {
+		names = append(names[:maxSuspendedClustersInMessage],
+			fmt.Sprintf("and %d more", len(evicted)-maxSuspendedClustersInMessage))
+	}
+	return fmt.Sprintf("The eviction of %d clusters by the taints is suspended, %d of %d selected clusters would "+
+		"be left, fewer than the minimum %d: %s", len(evicted), numOfRemainingClusters, numOfDecisionClusters,
+		minClusters, strings.Join(names, ", ")), nil
+}
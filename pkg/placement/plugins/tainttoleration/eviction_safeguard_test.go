@@ -0,0 +1,119 @@
+package tainttoleration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func TestEvictionSafeguard(t *testing.T) {
+	newClusters := func(tainted ...string) []*clusterapiv1.ManagedCluster {
+		var clusters []*clusterapiv1.ManagedCluster
+		for _, name := range []string{"cluster1", "cluster2", "cluster3", "cluster4", "cluster5"} {
+			builder := testinghelpers.NewManagedCluster(name)
+			for _, t := range tainted {
+				if t == name {
+					builder = builder.WithTaint(&clusterapiv1.Taint{
+						Key:       "outage",
+						Effect:    clusterapiv1.TaintEffectNoSelect,
+						TimeAdded: metav1.Now(),
+					})
+				}
+			}
+			clusters = append(clusters, builder.Build())
+		}
+		return clusters
+	}
+
+	cases := []struct {
+		name                 string
+		annotations          map[string]string
+		clusters             []*clusterapiv1.ManagedCluster
+		expectedClusterNames []string
+		expectedSuspension   string
+		expectedCode         framework.Code
+	}{
+		{
+			name:                 "evict above the minimum",
+			annotations:          map[string]string{MinSelectedClustersAnnotation: "60%"},
+			clusters:             newClusters("cluster1", "cluster2"),
+			expectedClusterNames: []string{"cluster3", "cluster4", "cluster5"},
+		},
+		{
+			name:                 "suspend the evictions below the minimum",
+			annotations:          map[string]string{MinSelectedClustersAnnotation: "60%"},
+			clusters:             newClusters("cluster1", "cluster2", "cluster3"),
+			expectedClusterNames: []string{"cluster1", "cluster2", "cluster3", "cluster4", "cluster5"},
+			expectedSuspension: "The eviction of 3 clusters by the taints is suspended, 2 of 5 selected clusters " +
+				"would be left, fewer than the minimum 3: cluster1, cluster2, cluster3",
+		},
+		{
+			name:                 "resume the evictions once the clusters recover",
+			annotations:          map[string]string{MinSelectedClustersAnnotation: "3"},
+			clusters:             newClusters("cluster1", "cluster2"),
+			expectedClusterNames: []string{"cluster3", "cluster4", "cluster5"},
+		},
+		{
+			name: "force the evictions below the minimum",
+			annotations: map[string]string{
+				MinSelectedClustersAnnotation: "3",
+				ForceEvictionAnnotation:       "true",
+			},
+			clusters:             newClusters("cluster1", "cluster2", "cluster3"),
+			expectedClusterNames: []string{"cluster4", "cluster5"},
+		},
+		{
+			name:                 "evict without the safeguard",
+			clusters:             newClusters("cluster1", "cluster2", "cluster3"),
+			expectedClusterNames: []string{"cluster4", "cluster5"},
+		},
+		{
+			name:                 "invalid minimum is ignored",
+			annotations:          map[string]string{MinSelectedClustersAnnotation: "half"},
+			clusters:             newClusters("cluster1", "cluster2", "cluster3"),
+			expectedClusterNames: []string{"cluster4", "cluster5"},
+			expectedCode:         framework.Warning,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			placement := testinghelpers.NewPlacementWithAnnotations("test", "test", c.annotations).Build()
+			initObjs := []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test").
+					WithLabel(placementLabel, "test").
+					WithDecisions("cluster1", "cluster2", "cluster3", "cluster4", "cluster5").
+					Build(),
+			}
+			for _, cluster := range c.clusters {
+				initObjs = append(initObjs, cluster)
+			}
+			p := &TaintToleration{
+				handle: testinghelpers.NewFakePluginHandle(t, nil, initObjs...),
+			}
+
+			result, status := p.Filter(context.TODO(), placement, c.clusters)
+			if status.Code() != c.expectedCode {
+				t.Fatalf("expected code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+			var names []string
+			for _, cluster := range result.Filtered {
+				names = append(names, cluster.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(c.expectedClusterNames, ",") {
+				t.Errorf("expected clusters %v, but got %v", c.expectedClusterNames, names)
+			}
+			if result.EvictionSuspension != c.expectedSuspension {
+				t.Errorf("expected suspension %q, but got %q", c.expectedSuspension, result.EvictionSuspension)
+			}
+		})
+	}
+}
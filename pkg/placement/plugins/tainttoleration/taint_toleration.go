@@ -15,16 +15,23 @@ import (
 	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 
+	"open-cluster-management.io/ocm/pkg/common/toleration"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
 	"open-cluster-management.io/ocm/pkg/placement/plugins"
 )
 
 var _ plugins.Filter = &TaintToleration{}
+var _ plugins.Prioritizer = &TaintToleration{}
 var TolerationClock = clock.Clock(clock.RealClock{})
 
 const (
 	placementLabel = "cluster.open-cluster-management.io/placement"
-	description    = "TaintToleration is a plugin that checks if a placement tolerates a managed cluster's taints"
+	description    = `
+	TaintToleration is a plugin that checks if a placement tolerates a managed cluster's taints. The clusters
+	with taints of NoSelect or NoSelectIfNew effect which are not tolerated are filtered, and the clusters with
+	taints of PreferNoSelect effect which are not tolerated are deprioritized. The clusters in the decisions
+	are kept if the selected clusters left would be fewer than the minimum of the placement.
+	`
 )
 
 type TaintToleration struct {
@@ -75,19 +82,60 @@ func (pl *TaintToleration) Filter(ctx context.Context, placement *clusterapiv1be
 
 	decisionClusterNames := getDecisionClusterNames(pl.handle, placement)
 
-	// filter the clusters
+	// filter the clusters, the clusters in the decisions which are not tolerated are evicted
 	matched := []*clusterapiv1.ManagedCluster{}
+	// matchedWithEvicted are the matched clusters and the evicted clusters in the order of the clusters
+	matchedWithEvicted := []*clusterapiv1.ManagedCluster{}
+	evicted := []*clusterapiv1.ManagedCluster{}
+	numOfRemainingClusters := 0
 	for _, cluster := range clusters {
-		if tolerated, _, _ := isClusterTolerated(cluster, placement.Spec.Tolerations, decisionClusterNames.Has(cluster.Name)); tolerated {
+		inDecision := decisionClusterNames.Has(cluster.Name)
+		switch tolerated, _, _ := isClusterTolerated(cluster, placement.Spec.Tolerations, inDecision); {
+		case tolerated:
 			matched = append(matched, cluster)
+			matchedWithEvicted = append(matchedWithEvicted, cluster)
+			if inDecision {
+				numOfRemainingClusters++
+			}
+		case inDecision:
+			evicted = append(evicted, cluster)
+			matchedWithEvicted = append(matchedWithEvicted, cluster)
 		}
 	}
 
+	// the evicted clusters are kept if the selected clusters left would be fewer than the minimum, the safeguard
+	// is ignored with a warning if it is invalid.
+	suspension, err := suspendEvictions(placement, decisionClusterNames.Len(), numOfRemainingClusters, evicted)
+	if err != nil {
+		status = framework.NewStatus(pl.Name(), framework.Warning, err.Error())
+	}
+	if len(suspension) > 0 {
+		matched = matchedWithEvicted
+	}
+
 	return plugins.PluginFilterResult{
-		Filtered: matched,
+		Filtered:           matched,
+		EvictionSuspension: suspension,
 	}, status
 }
 
+// Score gives the clusters with PreferNoSelect taints not tolerated by the placement the minimum score as a
+// penalty, and the other clusters a score of 0. The penalty is configured by the weight of the prioritizer.
+func (pl *TaintToleration) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	scores := map[string]int64{}
+	for _, cluster := range clusters {
+		scores[cluster.Name] = 0
+		if HasUntoleratedPreferNoSelectTaint(cluster, placement.Spec.Tolerations) {
+			scores[cluster.Name] = plugins.MinClusterScore
+		}
+	}
+
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, framework.NewStatus(pl.Name(), framework.Success, "")
+}
+
 func (pl *TaintToleration) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
 	status := framework.NewStatus(pl.Name(), framework.Success, "")
 	// get exist decisions clusters
@@ -113,93 +161,66 @@ func (pl *TaintToleration) RequeueAfter(ctx context.Context, placement *clustera
 	return *minRequeue, status
 }
 
-// isClusterTolerated returns true if a cluster is tolerated by the given toleration array
-func isClusterTolerated(cluster *clusterapiv1.ManagedCluster, tolerations []clusterapiv1beta1.Toleration,
-	inDecision bool) (bool, *plugins.PluginRequeueResult, string) {
-	var minRequeue *plugins.PluginRequeueResult
+// HasUntoleratedPreferNoSelectTaint returns true if the cluster has a taint of PreferNoSelect effect which is not
+// tolerated by the given toleration array.
+func HasUntoleratedPreferNoSelectTaint(cluster *clusterapiv1.ManagedCluster, tolerations []clusterapiv1beta1.Toleration) bool {
+	now := TolerationClock.Now()
 	for _, taint := range cluster.Spec.Taints {
-		tolerated, requeue, message := isTaintTolerated(taint, tolerations, inDecision)
-		if !tolerated {
-			return false, nil, message
+		if taint.Effect != clusterapiv1.TaintEffectPreferNoSelect {
+			continue
 		}
-		minRequeue = minRequeueTime(minRequeue, requeue)
-	}
-
-	return true, minRequeue, ""
-}
-
-// isTaintTolerated returns true if a taint is tolerated by the given toleration array
-func isTaintTolerated(taint clusterapiv1.Taint, tolerations []clusterapiv1beta1.Toleration, inDecision bool) (bool, *plugins.PluginRequeueResult, string) {
-	message := ""
-	if taint.Effect == clusterapiv1.TaintEffectPreferNoSelect {
-		return true, nil, message
-	}
-
-	if (taint.Effect == clusterapiv1.TaintEffectNoSelectIfNew) && inDecision {
-		return true, nil, message
-	}
-
-	for _, toleration := range tolerations {
-		if tolerated, requeue, msg := isTolerated(taint, toleration); tolerated {
-			return true, requeue, msg
-		} else {
-			message = msg
+		if !toleration.IsTaintTolerated(taint, tolerations, now) {
+			return true
 		}
 	}
-
-	return false, nil, message
+	return false
 }
 
-// isTolerated returns true if a taint is tolerated by the given toleration
-func isTolerated(taint clusterapiv1.Taint, toleration clusterapiv1beta1.Toleration) (bool, *plugins.PluginRequeueResult, string) {
-	if len(toleration.Effect) > 0 && toleration.Effect != taint.Effect {
-		return false, nil, ""
-	}
-
-	if len(toleration.Key) > 0 && toleration.Key != taint.Key {
-		return false, nil, ""
-	}
-
-	taintMatched := false
-	switch toleration.Operator {
-	// empty operator means Equal
-	case "", clusterapiv1beta1.TolerationOpEqual:
-		taintMatched = (toleration.Value == taint.Value)
-	case clusterapiv1beta1.TolerationOpExists:
-		taintMatched = true
-	}
-
-	if taintMatched {
-		return isTolerationTimeExpired(taint, toleration)
+// isClusterTolerated returns true if a cluster is tolerated by the given toleration array, and the requeue result
+// at the earliest time one of its taints is no longer tolerated.
+func isClusterTolerated(cluster *clusterapiv1.ManagedCluster, tolerations []clusterapiv1beta1.Toleration,
+	inDecision bool) (bool, *plugins.PluginRequeueResult, string) {
+	now := TolerationClock.Now()
+	taints := []clusterapiv1.Taint{}
+	for _, taint := range cluster.Spec.Taints {
+		// the clusters with PreferNoSelect taints are not filtered but deprioritized, see Score.
+		if taint.Effect == clusterapiv1.TaintEffectPreferNoSelect {
+			continue
+		}
+		if taint.Effect == clusterapiv1.TaintEffectNoSelectIfNew && inDecision {
+			continue
+		}
+		if !toleration.IsTaintTolerated(taint, tolerations, now) {
+			return false, nil, notToleratedMessage(cluster.Name, taint, tolerations)
+		}
+		taints = append(taints, taint)
 	}
 
-	return false, nil, ""
-
-}
-
-// isTolerationTimeExpired returns true if TolerationSeconds is nil or not expired
-func isTolerationTimeExpired(taint clusterapiv1.Taint, toleration clusterapiv1beta1.Toleration) (bool, *plugins.PluginRequeueResult, string) {
-	// TolerationSeconds is nil means it never expire
-	if toleration.TolerationSeconds == nil {
+	expiry := toleration.EarliestExpiry(taints, tolerations, now)
+	if expiry == nil {
 		return true, nil, ""
 	}
+	return true, &plugins.PluginRequeueResult{RequeueTime: expiry}, ""
+}
 
-	requeueTime := taint.TimeAdded.Add(time.Duration(*toleration.TolerationSeconds) * time.Second)
-
-	if TolerationClock.Now().Before(requeueTime) {
-		message := fmt.Sprintf(
-			"Cluster %s taint is added at %v, placement toleration seconds is %d",
-			"clustername",
-			taint.TimeAdded,
-			*toleration.TolerationSeconds,
-		)
-		p := plugins.PluginRequeueResult{
-			RequeueTime: &requeueTime,
+// notToleratedMessage returns the reason the taint of the cluster is not tolerated, with the toleration window
+// which has expired if the taint is matched by any toleration with TolerationSeconds.
+func notToleratedMessage(clusterName string, taint clusterapiv1.Taint, tolerations []clusterapiv1beta1.Toleration) string {
+	var expired *clusterapiv1beta1.Toleration
+	for i := range tolerations {
+		if !toleration.Matches(taint, tolerations[i]) {
+			continue
+		}
+		if expired == nil || toleration.Expiry(taint, tolerations[i]).After(*toleration.Expiry(taint, *expired)) {
+			expired = &tolerations[i]
 		}
-		return true, &p, message
 	}
-
-	return false, nil, ""
+	if expired == nil {
+		return fmt.Sprintf("Cluster %s taint %s is not tolerated", clusterName, taint.Key)
+	}
+	return fmt.Sprintf("Cluster %s taint %s is added at %s, placement toleration seconds is %d, the toleration "+
+		"expired at %s", clusterName, taint.Key, taint.TimeAdded.UTC().Format(time.RFC3339),
+		*expired.TolerationSeconds, toleration.Expiry(taint, *expired).UTC().Format(time.RFC3339))
 }
 
 func getDecisionClusterNames(handle plugins.Handle, placement *clusterapiv1beta1.Placement) sets.String {
@@ -3,6 +3,7 @@ package tainttoleration
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +18,7 @@ import (
 
 	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
 	"open-cluster-management.io/ocm/pkg/placement/plugins"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 var fakeTime = time.Date(2022, time.January, 01, 0, 0, 0, 0, time.UTC)
@@ -733,6 +735,61 @@ func TestMatchWithClusterTaintToleration(t *testing.T) {
 				RequeueTime: &requeueTime_1,
 			},
 		},
+		// testing the maintenance taint
+		{
+			name:      "cluster in maintenance is removed from the decision",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithTaint(
+					&clusterapiv1.Taint{
+						Key:       registrationhelpers.ManagedClusterTaintMaintenance,
+						Effect:    clusterapiv1.TaintEffectNoSelect,
+						TimeAdded: metav1.NewTime(addedTime_8),
+					}).Build(),
+				testinghelpers.NewManagedCluster("cluster2").Build(),
+			},
+			initObjs: []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test").
+					WithLabel(placementLabel, "test").
+					WithDecisions("cluster1", "cluster2").
+					Build(),
+			},
+			expectedClusterNames:  []string{"cluster2"},
+			expectedRequeueResult: plugins.PluginRequeueResult{},
+		},
+		{
+			name: "cluster in maintenance drains after toleration.TolerationSeconds",
+			placement: testinghelpers.NewPlacement("test", "test").AddToleration(
+				&clusterapiv1beta1.Toleration{
+					Key:               registrationhelpers.ManagedClusterTaintMaintenance,
+					Operator:          clusterapiv1beta1.TolerationOpExists,
+					TolerationSeconds: &tolerationSeconds_10,
+				}).Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithTaint(
+					&clusterapiv1.Taint{
+						Key:       registrationhelpers.ManagedClusterTaintMaintenance,
+						Effect:    clusterapiv1.TaintEffectNoSelect,
+						TimeAdded: metav1.NewTime(addedTime_9),
+					}).Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithTaint(
+					&clusterapiv1.Taint{
+						Key:       registrationhelpers.ManagedClusterTaintMaintenance,
+						Effect:    clusterapiv1.TaintEffectNoSelect,
+						TimeAdded: metav1.NewTime(addedTime_10),
+					}).Build(),
+			},
+			initObjs: []runtime.Object{
+				testinghelpers.NewPlacementDecision("test", "test").
+					WithLabel(placementLabel, "test").
+					WithDecisions("cluster1", "cluster2").
+					Build(),
+			},
+			expectedClusterNames: []string{"cluster1"},
+			expectedRequeueResult: plugins.PluginRequeueResult{
+				RequeueTime: &requeueTime_1,
+			},
+		},
 	}
 
 	TolerationClock = testingclock.NewFakeClock(fakeTime)
@@ -786,3 +843,83 @@ func TestMatchWithClusterTaintToleration(t *testing.T) {
 	}
 
 }
+
+func TestScoreClusterWithPreferNoSelectTaint(t *testing.T) {
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").WithTaint(
+			&clusterapiv1.Taint{
+				Key:    "key1",
+				Value:  "value1",
+				Effect: clusterapiv1.TaintEffectPreferNoSelect,
+			}).Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithTaint(
+			&clusterapiv1.Taint{
+				Key:    "key2",
+				Value:  "value2",
+				Effect: clusterapiv1.TaintEffectNoSelect,
+			}).Build(),
+		testinghelpers.NewManagedCluster("cluster3").Build(),
+	}
+
+	cases := []struct {
+		name           string
+		placement      *clusterapiv1beta1.Placement
+		expectedScores map[string]int64
+	}{
+		{
+			name:           "PreferNoSelect taint is not tolerated",
+			placement:      testinghelpers.NewPlacement("test", "test").Build(),
+			expectedScores: map[string]int64{"cluster1": plugins.MinClusterScore, "cluster2": 0, "cluster3": 0},
+		},
+		{
+			name: "PreferNoSelect taint is tolerated",
+			placement: testinghelpers.NewPlacement("test", "test").AddToleration(
+				&clusterapiv1beta1.Toleration{
+					Key:      "key1",
+					Operator: clusterapiv1beta1.TolerationOpExists,
+				}).Build(),
+			expectedScores: map[string]int64{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &TaintToleration{
+				handle: testinghelpers.NewFakePluginHandle(t, nil),
+			}
+
+			scoreResult, status := p.Score(context.TODO(), c.placement, clusters)
+			if err := status.AsError(); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if !reflect.DeepEqual(scoreResult.Scores, c.expectedScores) {
+				t.Errorf("expected scores %v, but got %v", c.expectedScores, scoreResult.Scores)
+			}
+		})
+	}
+}
+
+func TestNotToleratedMessage(t *testing.T) {
+	taint := clusterapiv1.Taint{
+		Key:       "key1",
+		Effect:    clusterapiv1.TaintEffectNoSelect,
+		TimeAdded: metav1.NewTime(addedTime_10),
+	}
+
+	message := notToleratedMessage("cluster1", taint, nil)
+	if message != "Cluster cluster1 taint key1 is not tolerated" {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	// the toleration window is reported if the matched toleration has expired
+	tolerations := []clusterapiv1beta1.Toleration{
+		{Key: "key1", Operator: clusterapiv1beta1.TolerationOpExists, TolerationSeconds: &tolerationSeconds_10},
+		{Key: "key2", Operator: clusterapiv1beta1.TolerationOpExists},
+	}
+	message = notToleratedMessage("cluster1", taint, tolerations)
+	expected := "Cluster cluster1 taint key1 is added at 2021-12-31T23:59:50Z, placement toleration seconds is 10, " +
+		"the toleration expired at 2022-01-01T00:00:00Z"
+	if message != expected {
+		t.Errorf("expected %q, but got %q", expected, message)
+	}
+}
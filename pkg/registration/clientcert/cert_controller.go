@@ -19,6 +19,7 @@ import (
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/keyutil"
 	"k8s.io/klog/v2"
 )
@@ -44,11 +45,23 @@ const (
 	// ClientCertificateUpdatedReason is a reason of condition ClusterCertificateRotatedCondition that
 	// the the client certificate succeeds
 	ClientCertificateUpdatedReason = "ClientCertificateUpdated"
+
+	// ClientCertificateApprovalPendingReason is a reason of condition ClusterCertificateRotatedCondition that
+	// the csr of the client certificate is waiting for approval.
+	ClientCertificateApprovalPendingReason = "ClientCertificateApprovalPending"
 )
 
 // ControllerResyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 var ControllerResyncInterval = 5 * time.Minute
 
+var (
+	// ApprovalPollInitialInterval and ApprovalPollMaxInterval are the initial and the max interval to check the
+	// approval of a pending csr. The interval is doubled after each check, and a watch event of the csr triggers
+	// the check immediately.
+	ApprovalPollInitialInterval = 5 * time.Second
+	ApprovalPollMaxInterval     = 3 * time.Minute
+)
+
 // CSROption includes options that is used to create and monitor csrs
 type CSROption struct {
 	// ObjectMeta is the ObjectMeta shared by all created csrs. It should use GenerateName instead of Name
@@ -121,6 +134,12 @@ type clientCertificateController struct {
 	//   4. csrName empty, keydata set: the CSR failed to create, this shouldn't happen, it's a bug.
 	keyData []byte
 
+	// pendingSince is the time when the pending csr is created.
+	pendingSince time.Time
+
+	// approvalBackoff tracks the interval to check the approval of the pending csr.
+	approvalBackoff *flowcontrol.Backoff
+
 	statusUpdater StatusUpdateFunc
 }
 
@@ -141,6 +160,7 @@ func NewClientCertificateController(
 		csrControl:           csrControl,
 		managementCoreClient: managementCoreClient,
 		controllerName:       controllerName,
+		approvalBackoff:      flowcontrol.NewBackOff(ApprovalPollInitialInterval, ApprovalPollMaxInterval),
 		statusUpdater:        statusUpdater,
 	}
 
@@ -183,21 +203,22 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 
 	// reconcile pending csr if exists
 	if len(c.csrName) > 0 {
+		isApproved, err := c.csrControl.isApproved(c.csrName)
+		switch {
+		case err != nil && !apierrors.IsNotFound(err):
+			// keep the pending csr on a transient error, otherwise a second csr would be created for the
+			// same client certificate.
+			return err
+		case err == nil && !isApproved:
+			return c.waitForApproval(ctx, syncCtx)
+		}
+
 		// build a secret data map if the csr is approved
 		newSecretConfig, err := func() (map[string][]byte, error) {
-			// skip if there is no ongoing csr
-			if len(c.csrName) == 0 {
-				return nil, fmt.Errorf("no ongoing csr")
-			}
-
-			// skip if csr is not approved yet
-			isApproved, err := c.csrControl.isApproved(c.csrName)
+			// the pending csr is deleted
 			if err != nil {
 				return nil, err
 			}
-			if !isApproved {
-				return nil, nil
-			}
 
 			// skip if csr is not issued
 			certData, err := c.csrControl.getIssuedCertificate(c.csrName)
@@ -341,6 +362,26 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 	}
 	c.keyData = keyData
 	c.csrName = createdCSRName
+	c.pendingSince = time.Now()
+	return nil
+}
+
+// waitForApproval reports the pending csr in the status and checks its approval again with an exponential
+// backoff, so no new csr is created until the pending one is approved, denied or deleted.
+func (c *clientCertificateController) waitForApproval(ctx context.Context, syncCtx factory.SyncContext) error {
+	if updateErr := c.statusUpdater(ctx, metav1.Condition{
+		Type:    ClusterCertificateRotatedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  ClientCertificateApprovalPendingReason,
+		Message: fmt.Sprintf("Waiting for approval of csr %s since %s", c.csrName, c.pendingSince.Format(time.RFC3339)),
+	}); updateErr != nil {
+		return updateErr
+	}
+
+	c.approvalBackoff.Next(c.csrName, c.approvalBackoff.Clock.Now())
+	delay := c.approvalBackoff.Get(c.csrName)
+	klog.V(4).Infof("Csr %s is not approved yet, check it again after %v", c.csrName, delay)
+	syncCtx.Queue().AddAfter(syncCtx.QueueKey(), delay)
 	return nil
 }
 
@@ -355,8 +396,12 @@ func saveSecret(spokeCoreClient corev1client.CoreV1Interface, secretNamespace st
 }
 
 func (c *clientCertificateController) reset() {
+	if c.approvalBackoff != nil {
+		c.approvalBackoff.Reset(c.csrName)
+	}
 	c.csrName = ""
 	c.keyData = nil
+	c.pendingSince = time.Time{}
 }
 
 func shouldCreateCSR(
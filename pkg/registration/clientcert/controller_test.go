@@ -10,6 +10,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	certificates "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,6 +18,8 @@ import (
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	testingclock "k8s.io/utils/clock/testing"
 
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
@@ -200,6 +203,7 @@ func TestSync(t *testing.T) {
 				csrControl:           ctrl,
 				managementCoreClient: agentKubeClient.CoreV1(),
 				controllerName:       "test-agent",
+				approvalBackoff:      flowcontrol.NewBackOff(ApprovalPollInitialInterval, ApprovalPollMaxInterval),
 				statusUpdater:        updater.update,
 			}
 
@@ -232,6 +236,90 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestSyncPendingCSR(t *testing.T) {
+	hubKubeClient := kubefake.NewSimpleClientset()
+	hubKubeClient.PrependReactor(
+		"create",
+		"certificatesigningrequests",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			return true, testinghelpers.NewCSR(testinghelpers.CSRHolder{Name: testCSRName}), nil
+		},
+	)
+	hubKubeClient.PrependReactor(
+		"get",
+		"certificatesigningrequests",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			return true, testinghelpers.NewCSR(testinghelpers.CSRHolder{Name: testCSRName}), nil
+		},
+	)
+	ctrl := &mockCSRControl{csrClient: &hubKubeClient.Fake}
+	agentKubeClient := kubefake.NewSimpleClientset()
+	updater := &fakeStatusUpdater{}
+	fakeClock := testingclock.NewFakeClock(time.Now())
+
+	controller := &clientCertificateController{
+		ClientCertOption: ClientCertOption{
+			SecretNamespace: testNamespace,
+			SecretName:      testSecretName,
+		},
+		CSROption: CSROption{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Subject:         &pkix.Name{CommonName: commonName},
+			SignerName:      certificates.KubeAPIServerClientSignerName,
+			HaltCSRCreation: func() bool { return false },
+		},
+		csrControl:           ctrl,
+		managementCoreClient: agentKubeClient.CoreV1(),
+		controllerName:       "test-agent",
+		approvalBackoff:      flowcontrol.NewFakeBackOff(ApprovalPollInitialInterval, ApprovalPollMaxInterval, fakeClock),
+		statusUpdater:        updater.update,
+	}
+
+	// the csr is not approved for a long time
+	expectedDelay := ApprovalPollInitialInterval
+	for i := 0; i < 20; i++ {
+		if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "key")); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if i == 0 {
+			continue
+		}
+
+		if updater.cond == nil || updater.cond.Reason != ClientCertificateApprovalPendingReason {
+			t.Errorf("expected the approval pending condition, but got %v", updater.cond)
+		}
+		if delay := controller.approvalBackoff.Get(controller.csrName); delay != expectedDelay {
+			t.Errorf("expected the approval to be checked after %v, but got %v", expectedDelay, delay)
+		}
+		expectedDelay *= 2
+		if expectedDelay > ApprovalPollMaxInterval {
+			expectedDelay = ApprovalPollMaxInterval
+		}
+		fakeClock.Step(expectedDelay)
+	}
+
+	creates := 0
+	for _, action := range hubKubeClient.Actions() {
+		if action.GetVerb() == "create" {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Errorf("expected exactly one csr created, but got %d", creates)
+	}
+
+	// a new csr is created once the pending one is deleted
+	ctrl.getErr = apierrors.NewNotFound(certificates.Resource("certificatesigningrequests"), controller.csrName)
+	if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "key")); err == nil {
+		t.Errorf("expected error when the pending csr is deleted")
+	}
+	if controller.csrName != "" || !controller.pendingSince.IsZero() {
+		t.Errorf("expected the pending csr to be reset")
+	}
+}
+
 var _ CSRControl = &mockCSRControl{}
 
 func conditionEqual(expected, actual *metav1.Condition) bool {
@@ -266,6 +354,7 @@ func (f *fakeStatusUpdater) update(ctx context.Context, cond metav1.Condition) e
 type mockCSRControl struct {
 	approved       bool
 	issuedCertData []byte
+	getErr         error
 	csrClient      *clienttesting.Fake
 }
 
@@ -288,6 +377,9 @@ func (m *mockCSRControl) isApproved(name string) (bool, error) {
 		},
 		Name: name,
 	}, nil)
+	if m.getErr != nil {
+		return false, m.getErr
+	}
 
 	return m.approved, err
 }
@@ -4,7 +4,11 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"net"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/openshift/api"
 	"github.com/openshift/library-go/pkg/assets"
@@ -16,19 +20,140 @@ import (
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 )
 
+// Annotations set by the registration agent on its ManagedCluster to identify the running agent.
+const (
+	AgentVersionAnnotation              = "agent.open-cluster-management.io/version"
+	AgentGitCommitAnnotation            = "agent.open-cluster-management.io/git-commit"
+	AgentKlusterletGenerationAnnotation = "agent.open-cluster-management.io/klusterlet-generation"
+	AgentPodNamespaceAnnotation         = "agent.open-cluster-management.io/pod-namespace"
+	AgentPodNameAnnotation              = "agent.open-cluster-management.io/pod-name"
+)
+
+// ObserveOnlyAnnotation is set to "true" by the registration agent on its ManagedCluster when the cluster is
+// registered for the inventory only and runs no work agent. The hub does not grant the agent the permission on the
+// manifestworks in the cluster namespace, and the manifestworks of the ManifestWorkReplicaSets are not created
+// on the cluster.
+// TODO move this to the api repo
+const ObserveOnlyAnnotation = "agent.open-cluster-management.io/observe-only"
+
+// CABundleRotationTimestampAnnotation is set by the registration agent on its ManagedCluster with the time the
+// rotated CA bundle of the managed cluster kube-apiserver is published in the ManagedClusterClientConfigs.
+const CABundleRotationTimestampAnnotation = "agent.open-cluster-management.io/ca-bundle-rotation-timestamp"
+
+const (
+	// HubVersionConfigMap is the configmap published by the cluster manager operator in the namespace of the
+	// hub components once they finish upgrading, the bundle version is set with the key HubBundleVersionKey.
+	HubVersionConfigMap = "cluster-manager-version"
+	HubBundleVersionKey = "bundleVersion"
+
+	// HubBundleVersionAnnotation is set by the registration controller on each ManagedCluster to propagate the
+	// bundle version of the hub components published by the cluster manager operator.
+	HubBundleVersionAnnotation = "cluster.open-cluster-management.io/hub-bundle-version"
+)
+
+// AppliedManifestWorkEvictionGracePeriodAnnotation is set by the fleet operators on a ManagedCluster to override
+// the appliedmanifestwork eviction grace period of the work agent of the cluster, e.g. "30m". The value is validated
+// by the registration controller and propagated in the WorkAgentEvictionGracePeriodAnnotation.
+const AppliedManifestWorkEvictionGracePeriodAnnotation = "cluster.open-cluster-management.io/appliedmanifestwork-eviction-grace-period"
+
+// WorkAgentEvictionGracePeriodAnnotation is set by the registration controller on a ManagedCluster with the valid
+// appliedmanifestwork eviction grace period of the AppliedManifestWorkEvictionGracePeriodAnnotation. The klusterlet
+// operator reads it from the hub and sets the flag of the work agent accordingly.
+const WorkAgentEvictionGracePeriodAnnotation = "cluster.open-cluster-management.io/work-agent-eviction-grace-period"
+
+// MinAppliedManifestWorkEvictionGracePeriod and MaxAppliedManifestWorkEvictionGracePeriod are the bounds of the
+// appliedmanifestwork eviction grace period set on a managed cluster.
+const (
+	MinAppliedManifestWorkEvictionGracePeriod = time.Minute
+	MaxAppliedManifestWorkEvictionGracePeriod = 7 * 24 * time.Hour
+)
+
+// ValidateEvictionGracePeriod returns the appliedmanifestwork eviction grace period of the value, or an error if it
+// cannot be parsed or it is out of the bounds.
+func ValidateEvictionGracePeriod(value string) (time.Duration, error) {
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("the appliedmanifestwork eviction grace period %q is invalid: %v", value, err)
+	}
+	if gracePeriod < MinAppliedManifestWorkEvictionGracePeriod || gracePeriod > MaxAppliedManifestWorkEvictionGracePeriod {
+		return 0, fmt.Errorf("the appliedmanifestwork eviction grace period %q is out of range [%s, %s]",
+			value, MinAppliedManifestWorkEvictionGracePeriod, MaxAppliedManifestWorkEvictionGracePeriod)
+	}
+	return gracePeriod, nil
+}
+
+// ClusterClaimLabelsAnnotation is set by the registration controller on each ManagedCluster to record the
+// comma separated keys of the labels derived from the cluster claims, only these labels are owned by the
+// controller.
+const ClusterClaimLabelsAnnotation = "cluster.open-cluster-management.io/claim-labels"
+
+// ClusterLeaseNamespaceAnnotation and ClusterLeaseNameAnnotation are set by the registration agent on its
+// ManagedCluster when the agent renews the cluster lease in a location other than the default one, e.g. in a
+// namespace managed by a cloud provider. The hub checks the lease at the annotated location.
+// TODO move this to the api repo
+const (
+	ClusterLeaseNamespaceAnnotation = "cluster.open-cluster-management.io/lease-namespace"
+	ClusterLeaseNameAnnotation      = "cluster.open-cluster-management.io/lease-name"
+)
+
+// DefaultClusterLeaseName is the name of the cluster lease in the cluster namespace on the hub.
+const DefaultClusterLeaseName = "managed-cluster-lease"
+
+// ClusterLeaseLocation returns the namespace and name of the lease of a managed cluster observed by the hub. It
+// is the default lease in the cluster namespace unless the lease annotations are set on the cluster.
+func ClusterLeaseLocation(cluster *clusterv1.ManagedCluster) (string, string) {
+	namespace, name := cluster.Name, DefaultClusterLeaseName
+	if ns := cluster.Annotations[ClusterLeaseNamespaceAnnotation]; len(ns) > 0 {
+		namespace = ns
+	}
+	if n := cluster.Annotations[ClusterLeaseNameAnnotation]; len(n) > 0 {
+		name = n
+	}
+	return namespace, name
+}
+
+// ManagedClusterMaintenanceAnnotation is set by the hub admin on a ManagedCluster to put the cluster in maintenance,
+// e.g. before patching it. The registration controller translates it into the maintenance taint while the value is
+// "true".
+// TODO move this to the api repo
+const ManagedClusterMaintenanceAnnotation = "cluster.open-cluster-management.io/maintenance"
+
+// ManagedClusterTaintMaintenance is the key of the taint with NoSelect effect added to a ManagedCluster in
+// maintenance. The cluster is not selected by new placement decisions, and is removed from the existing decisions
+// once the tolerationSeconds of the placement tolerating the taint expire.
+// TODO move this to the api repo
+const ManagedClusterTaintMaintenance = "cluster.open-cluster-management.io/maintenance"
+
+// ManagedClusterDeletionProtectionLabel is set to "true" by the hub admin on a ManagedCluster to protect it from
+// being deleted by mistake. A protected cluster can only be deleted once the ManagedClusterDeletionConfirmedAnnotation
+// is set with the name of the cluster.
+// TODO move this to the api repo
+const ManagedClusterDeletionProtectionLabel = "cluster.open-cluster-management.io/deletion-protection"
+
+// ManagedClusterDeletionConfirmedAnnotation is set by the hub admin on a protected ManagedCluster with the name of
+// the cluster to confirm its deletion.
+// TODO move this to the api repo
+const ManagedClusterDeletionConfirmedAnnotation = "cluster.open-cluster-management.io/confirm-deletion"
+
 var (
 	genericScheme = runtime.NewScheme()
 	genericCodecs = serializer.NewCodecFactory(genericScheme)
@@ -37,6 +162,7 @@ var (
 
 func init() {
 	utilruntime.Must(api.InstallKube(genericScheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(genericScheme))
 }
 
 // Check whether a CSR is in terminal state
@@ -65,28 +191,123 @@ func Isv1beta1CSRInTerminalState(status *certificatesv1beta1.CertificateSigningR
 	return false
 }
 
-// IsValidHTTPSURL validate whether a URL is https URL
+// hubControlledClusterLabels are the labels of a managed cluster whose values are authorized by the hub, the
+// clusterset label can only be set by the users permitted to join the clusterset. The other labels of a managed
+// cluster can be set by its registration agent with the bootstrap credential.
+var hubControlledClusterLabels = sets.New[string](clusterv1beta2.ClusterSetLabel)
+
+// ParseClusterAutoApprovalSelector parses the label selector of the clusters auto approved. The selector may
+// only refer to the labels controlled by the hub, so an agent cannot get its cluster approved by its own labels.
+func ParseClusterAutoApprovalSelector(value string) (labels.Selector, error) {
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		if !hubControlledClusterLabels.Has(requirement.Key()) {
+			return nil, fmt.Errorf("the label %q is not controlled by the hub, only %s are supported",
+				requirement.Key(), strings.Join(sets.List(hubControlledClusterLabels), ", "))
+		}
+	}
+	return selector, nil
+}
+
+// ClusterAutoApprovalLabels returns the labels of the managed cluster matched by the auto approval selector.
+func ClusterAutoApprovalLabels(managedCluster *clusterv1.ManagedCluster) labels.Set {
+	set := labels.Set{}
+	for key, value := range managedCluster.Labels {
+		if hubControlledClusterLabels.Has(key) {
+			set[key] = value
+		}
+	}
+	return set
+}
+
+// BootstrapTokenExpiredReason is the reason reported when the bootstrap token is rejected by the hub before the
+// csr of the agent is approved.
+const BootstrapTokenExpiredReason = "BootstrapTokenExpired"
+
+// IsTokenBasedBootstrap returns true if the bootstrap client config authenticates to the hub with a token only.
+func IsTokenBasedBootstrap(config *rest.Config) bool {
+	if len(config.BearerToken) == 0 && len(config.BearerTokenFile) == 0 {
+		return false
+	}
+	return len(config.CertData) == 0 && len(config.CertFile) == 0
+}
+
+// IsBootstrapTokenDiscarded returns true if the bootstrap client config has no credential left, e.g. the token
+// is discarded from the bootstrap kubeconfig once the client certificate of the agent is issued.
+func IsBootstrapTokenDiscarded(config *rest.Config) bool {
+	return len(config.BearerToken) == 0 && len(config.BearerTokenFile) == 0 &&
+		len(config.CertData) == 0 && len(config.CertFile) == 0 &&
+		len(config.Username) == 0 && config.ExecProvider == nil && config.AuthProvider == nil
+}
+
+// IsValidHTTPSURL validate whether a URL is a valid https URL, see ValidateHTTPSURL.
 func IsValidHTTPSURL(serverURL string) bool {
+	return ValidateHTTPSURL(serverURL) == nil
+}
+
+// ValidateHTTPSURL validates whether a URL is a https URL of an apiserver. The URL should have a host, which is
+// bracketed if it is an IPv6 address, and an optional port. The user info, query and fragment are not allowed.
+func ValidateHTTPSURL(serverURL string) error {
 	if serverURL == "" {
-		return false
+		return fmt.Errorf("the url is empty")
 	}
 
 	parsedServerURL, err := url.Parse(serverURL)
 	if err != nil {
-		return false
+		return err
 	}
 
 	if parsedServerURL.Scheme != "https" {
-		return false
+		return fmt.Errorf("the scheme should be https")
 	}
 
-	return true
+	if parsedServerURL.Hostname() == "" {
+		return fmt.Errorf("the host is empty")
+	}
+
+	if parsedServerURL.User != nil {
+		return fmt.Errorf("the user info is not allowed")
+	}
+
+	if parsedServerURL.ForceQuery || len(parsedServerURL.RawQuery) > 0 {
+		return fmt.Errorf("the query is not allowed")
+	}
+
+	if strings.Contains(serverURL, "#") {
+		return fmt.Errorf("the fragment is not allowed")
+	}
+
+	host := parsedServerURL.Hostname()
+	if strings.Contains(host, ":") {
+		// an IPv6 address should be bracketed, otherwise its last segment is taken as the port
+		if !strings.HasPrefix(parsedServerURL.Host, "[") || net.ParseIP(host) == nil {
+			return fmt.Errorf("the IPv6 address %q should be bracketed", host)
+		}
+	}
+
+	if port := parsedServerURL.Port(); len(port) > 0 {
+		if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+			return fmt.Errorf("the port %q is invalid", port)
+		}
+	} else if strings.HasSuffix(parsedServerURL.Host, ":") {
+		return fmt.Errorf("the port is empty")
+	}
+
+	return nil
 }
 
-// CleanUpManagedClusterManifests clean up managed cluster resources from its manifest files
+// CleanUpManagedClusterManifests clean up managed cluster resources from its manifest files. The apiExtensionsClient
+// is only required to clean up the CustomResourceDefinitions, it could be nil otherwise. Every file is attempted even
+// if some deletions fail, e.g. the cluster scoped rbac is cleaned up when the namespace cannot be deleted, and the
+// errors are aggregated. The resources not found are treated as deleted.
 func CleanUpManagedClusterManifests(
 	ctx context.Context,
 	client kubernetes.Interface,
+	apiExtensionsClient apiextensionsclient.Interface,
 	recorder events.Recorder,
 	assetFunc resourceapply.AssetFunc,
 	files ...string) error {
@@ -113,6 +334,19 @@ func CleanUpManagedClusterManifests(
 			err = client.RbacV1().ClusterRoles().Delete(ctx, t.Name, metav1.DeleteOptions{})
 		case *rbacv1.ClusterRoleBinding:
 			err = client.RbacV1().ClusterRoleBindings().Delete(ctx, t.Name, metav1.DeleteOptions{})
+		case *corev1.ServiceAccount:
+			err = client.CoreV1().ServiceAccounts(t.Namespace).Delete(ctx, t.Name, metav1.DeleteOptions{})
+		case *corev1.Secret:
+			err = client.CoreV1().Secrets(t.Namespace).Delete(ctx, t.Name, metav1.DeleteOptions{})
+		case *corev1.ConfigMap:
+			err = client.CoreV1().ConfigMaps(t.Namespace).Delete(ctx, t.Name, metav1.DeleteOptions{})
+		case *apiextensionsv1.CustomResourceDefinition:
+			if apiExtensionsClient == nil {
+				err = fmt.Errorf("no apiextensions client to delete customresourcedefinition %s", t.Name)
+				break
+			}
+			err = apiExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().Delete(
+				ctx, t.Name, metav1.DeleteOptions{})
 		default:
 			err = fmt.Errorf("unhandled type %T", object)
 		}
@@ -164,7 +398,9 @@ func IsTaintEqual(taint1, taint2 clusterv1.Taint) bool {
 	return taint1.Key == taint2.Key && taint1.Value == taint2.Value && taint1.Effect == taint2.Effect
 }
 
-// AddTaints add taints to the specified slice, if it did not already exist.
+// AddTaints add taints to the specified slice, if it did not already exist. If a taint with the same key and
+// value exists with a different effect, its effect is updated in place and its TimeAdded is kept. A taint with
+// a zero TimeAdded is added at the current time.
 // Return a boolean indicating whether the slice has been updated.
 func AddTaints(taints *[]clusterv1.Taint, taint clusterv1.Taint) bool {
 	if taints == nil || *taints == nil {
@@ -173,6 +409,15 @@ func AddTaints(taints *[]clusterv1.Taint, taint clusterv1.Taint) bool {
 	if FindTaint(*taints, taint) != nil {
 		return false
 	}
+	for i := range *taints {
+		if (*taints)[i].Key == taint.Key && (*taints)[i].Value == taint.Value {
+			(*taints)[i].Effect = taint.Effect
+			return true
+		}
+	}
+	if taint.TimeAdded.IsZero() {
+		taint.TimeAdded = metav1.Now()
+	}
 	*taints = append(*taints, taint)
 	return true
 }
@@ -193,6 +438,63 @@ func RemoveTaints(taints *[]clusterv1.Taint, targets ...clusterv1.Taint) (update
 	return updated
 }
 
+// RemoveTaintsByKey removes all the taints with any of the keys regardless of their values and effects, the
+// remaining taints keep their order. Return a boolean indicating whether the slice has been updated.
+func RemoveTaintsByKey(taints *[]clusterv1.Taint, keys ...string) (updated bool) {
+	if taints == nil || len(*taints) == 0 || len(keys) == 0 {
+		return false
+	}
+
+	targets := sets.New[string](keys...)
+	newTaints := make([]clusterv1.Taint, 0)
+	for _, v := range *taints {
+		if !targets.Has(v.Key) {
+			newTaints = append(newTaints, v)
+		}
+	}
+	updated = len(*taints) != len(newTaints)
+	*taints = newTaints
+	return updated
+}
+
+// IsClusterInMaintenance returns true if the managed cluster has the maintenance taint.
+func IsClusterInMaintenance(managedCluster *clusterv1.ManagedCluster) bool {
+	return FindTaintByKey(managedCluster, ManagedClusterTaintMaintenance) != nil
+}
+
+// IsClusterObserveOnly returns true if the managed cluster is registered by an observe-only agent.
+func IsClusterObserveOnly(managedCluster *clusterv1.ManagedCluster) bool {
+	return managedCluster.Annotations[ObserveOnlyAnnotation] == "true"
+}
+
+// IsClusterDeletionProtected returns true if the managed cluster is protected from deletion and its deletion is not
+// confirmed yet.
+func IsClusterDeletionProtected(managedCluster *clusterv1.ManagedCluster) bool {
+	if managedCluster.Labels[ManagedClusterDeletionProtectionLabel] != "true" {
+		return false
+	}
+	return managedCluster.Annotations[ManagedClusterDeletionConfirmedAnnotation] != managedCluster.Name
+}
+
+// SetMaintenanceTaint adds the maintenance taint added at timeAdded to the taints, the taint added before is kept
+// with its TimeAdded. Return a boolean indicating whether the slice has been updated.
+func SetMaintenanceTaint(taints *[]clusterv1.Taint, timeAdded metav1.Time) bool {
+	return AddTaints(taints, clusterv1.Taint{
+		Key:       ManagedClusterTaintMaintenance,
+		Effect:    clusterv1.TaintEffectNoSelect,
+		TimeAdded: timeAdded,
+	})
+}
+
+// UnsetMaintenanceTaint removes the maintenance taint from the taints. Return a boolean indicating whether the
+// slice has been updated.
+func UnsetMaintenanceTaint(taints *[]clusterv1.Taint) bool {
+	return RemoveTaints(taints, clusterv1.Taint{
+		Key:    ManagedClusterTaintMaintenance,
+		Effect: clusterv1.TaintEffectNoSelect,
+	})
+}
+
 func FindTaint(taints []clusterv1.Taint, taint clusterv1.Taint) *clusterv1.Taint {
 	for i := range taints {
 		if IsTaintEqual(taints[i], taint) {
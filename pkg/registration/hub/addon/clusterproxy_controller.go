@@ -0,0 +1,184 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+const (
+	// ClusterProxyAddOnName is the name of the cluster-proxy addon.
+	ClusterProxyAddOnName = "cluster-proxy"
+
+	// ClusterProxyServiceURLAnnotation is the annotation on the cluster-proxy ClusterManagementAddOn to override
+	// the url of the user service of cluster-proxy on the hub, the DefaultClusterProxyServiceURL is used if it is
+	// not set.
+	// TODO move this to the api repo
+	ClusterProxyServiceURLAnnotation = "cluster.open-cluster-management.io/proxy-service-url"
+
+	// DefaultClusterProxyServiceURL is the url of the user service of cluster-proxy installed by default.
+	DefaultClusterProxyServiceURL = "https://cluster-proxy-addon-user.open-cluster-management-addon.svc:9092"
+
+	// ClusterProxyEndpointAnnotation is the annotation on the managed cluster with the endpoint to reach the cluster
+	// through cluster-proxy. It is set only while the cluster-proxy addon of the cluster is available.
+	// TODO move this to the api repo
+	ClusterProxyEndpointAnnotation = "cluster.open-cluster-management.io/proxy-endpoint"
+
+	// ClusterProxyHealthAnnotation is the annotation on the managed cluster with the health of the cluster-proxy
+	// addon of the cluster, the value is available, unhealthy or unreachable.
+	// TODO move this to the api repo
+	ClusterProxyHealthAnnotation = "cluster.open-cluster-management.io/proxy-health"
+)
+
+// clusterProxyController publishes the cluster-proxy endpoint of each managed cluster with the annotations of the
+// cluster, so the hub components and the users are able to discover it. The annotations are removed once
+// cluster-proxy is uninstalled, and the endpoint annotation is removed while the addon is not available.
+type clusterProxyController struct {
+	patcher       patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
+	clusterLister clusterv1listers.ManagedClusterLister
+	cmaLister     addonlisterv1alpha1.ClusterManagementAddOnLister
+	addOnLister   addonlisterv1alpha1.ManagedClusterAddOnLister
+}
+
+// NewClusterProxyController returns an instance of clusterProxyController
+func NewClusterProxyController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	cmaInformer addoninformerv1alpha1.ClusterManagementAddOnInformer,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &clusterProxyController{
+		patcher: patcher.NewPatcher[
+			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformer.Lister(),
+		cmaLister:     cmaInformer.Lister(),
+		addOnLister:   addOnInformer.Lister(),
+	}
+
+	isClusterProxy := func(obj interface{}) bool {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return false
+		}
+		return accessor.GetName() == ClusterProxyAddOnName
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetNamespace()
+		}, isClusterProxy, addOnInformer.Informer()).
+		// the cluster-proxy is installed or uninstalled, or its service url is changed, sync all the clusters
+		WithFilteredEventsInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			clusters, err := c.clusterLister.List(labels.Everything())
+			if err != nil {
+				return nil
+			}
+			keys := make([]string, 0, len(clusters))
+			for _, cluster := range clusters {
+				keys = append(keys, cluster.Name)
+			}
+			return keys
+		}, isClusterProxy, cmaInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterProxyController", recorder)
+}
+
+func (c *clusterProxyController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling cluster-proxy endpoint of ManagedCluster %s", clusterName)
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// cluster is deleted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Do not update the annotations if cluster is deleting
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	endpoint, health, err := c.clusterProxyEndpoint(cluster)
+	if err != nil {
+		return err
+	}
+
+	newCluster := cluster.DeepCopy()
+	if newCluster.Annotations == nil {
+		newCluster.Annotations = map[string]string{}
+	}
+	setOrDelete(newCluster.Annotations, ClusterProxyEndpointAnnotation, endpoint)
+	setOrDelete(newCluster.Annotations, ClusterProxyHealthAnnotation, health)
+	_, err = c.patcher.PatchLabelAnnotations(ctx, newCluster, newCluster.ObjectMeta, cluster.ObjectMeta)
+	return err
+}
+
+// clusterProxyEndpoint returns the cluster-proxy endpoint and the addon health of the cluster. The endpoint is
+// empty if the addon is not available, and both are empty if cluster-proxy is not installed for the cluster.
+func (c *clusterProxyController) clusterProxyEndpoint(cluster *clusterv1.ManagedCluster) (string, string, error) {
+	cma, err := c.cmaLister.Get(ClusterProxyAddOnName)
+	switch {
+	case errors.IsNotFound(err):
+		return "", "", nil
+	case err != nil:
+		return "", "", err
+	case !cma.DeletionTimestamp.IsZero():
+		return "", "", nil
+	}
+
+	addOn, err := c.addOnLister.ManagedClusterAddOns(cluster.Name).Get(ClusterProxyAddOnName)
+	switch {
+	case errors.IsNotFound(err):
+		return "", "", nil
+	case err != nil:
+		return "", "", fmt.Errorf("unable to get cluster-proxy addon of cluster %q: %w", cluster.Name, err)
+	case !addOn.DeletionTimestamp.IsZero():
+		return "", "", nil
+	}
+
+	health := getAddOnLabelValue(addOn)
+	if health != addOnStatusAvailable {
+		return "", health, nil
+	}
+
+	serviceURL := DefaultClusterProxyServiceURL
+	if url := cma.Annotations[ClusterProxyServiceURLAnnotation]; len(url) > 0 {
+		serviceURL = url
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(serviceURL, "/"), cluster.Name), health, nil
+}
+
+func setOrDelete(annotations map[string]string, key, value string) {
+	if len(value) == 0 {
+		delete(annotations, key)
+		return
+	}
+	annotations[key] = value
+}
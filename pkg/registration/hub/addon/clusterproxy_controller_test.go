@@ -0,0 +1,231 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestClusterProxyController_Sync(t *testing.T) {
+	clusterName := "cluster1"
+	deleteTime := metav1.Now()
+
+	newClusterProxyAddOn := func(status metav1.ConditionStatus) *addonv1alpha1.ManagedClusterAddOn {
+		return &addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ClusterProxyAddOnName,
+				Namespace: clusterName,
+			},
+			Status: addonv1alpha1.ManagedClusterAddOnStatus{
+				Conditions: []metav1.Condition{
+					{
+						Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+						Status: status,
+					},
+				},
+			},
+		}
+	}
+	newClusterProxyCMA := func(annotations map[string]string) *addonv1alpha1.ClusterManagementAddOn {
+		return &addonv1alpha1.ClusterManagementAddOn{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        ClusterProxyAddOnName,
+				Annotations: annotations,
+			},
+		}
+	}
+	proxyAnnotatedCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterName,
+			Annotations: map[string]string{
+				ClusterProxyEndpointAnnotation: DefaultClusterProxyServiceURL + "/" + clusterName,
+				ClusterProxyHealthAnnotation:   addOnStatusAvailable,
+			},
+		},
+	}
+
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		cma             *addonv1alpha1.ClusterManagementAddOn
+		addOn           *addonv1alpha1.ManagedClusterAddOn
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "cluster is not found",
+			cma:             newClusterProxyCMA(nil),
+			addOn:           newClusterProxyAddOn(metav1.ConditionTrue),
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name: "cluster is deleting",
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              clusterName,
+					DeletionTimestamp: &deleteTime,
+				},
+			},
+			cma:             newClusterProxyCMA(nil),
+			addOn:           newClusterProxyAddOn(metav1.ConditionTrue),
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:            "cluster-proxy is not installed",
+			cluster:         &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterName}},
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:    "cluster-proxy is installed and available",
+			cluster: &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterName}},
+			cma:     newClusterProxyCMA(nil),
+			addOn:   newClusterProxyAddOn(metav1.ConditionTrue),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertClusterProxyAnnotations(t, actions[0], map[string]interface{}{
+					ClusterProxyEndpointAnnotation: DefaultClusterProxyServiceURL + "/" + clusterName,
+					ClusterProxyHealthAnnotation:   addOnStatusAvailable,
+				})
+			},
+		},
+		{
+			name:    "cluster-proxy is installed with a custom service url",
+			cluster: &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterName}},
+			cma: newClusterProxyCMA(map[string]string{
+				ClusterProxyServiceURLAnnotation: "https://proxy.example.com/",
+			}),
+			addOn: newClusterProxyAddOn(metav1.ConditionTrue),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertClusterProxyAnnotations(t, actions[0], map[string]interface{}{
+					ClusterProxyEndpointAnnotation: "https://proxy.example.com/" + clusterName,
+					ClusterProxyHealthAnnotation:   addOnStatusAvailable,
+				})
+			},
+		},
+		{
+			name:            "cluster-proxy endpoint is up to date",
+			cluster:         proxyAnnotatedCluster,
+			cma:             newClusterProxyCMA(nil),
+			addOn:           newClusterProxyAddOn(metav1.ConditionTrue),
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:    "cluster-proxy addon is unhealthy",
+			cluster: proxyAnnotatedCluster,
+			cma:     newClusterProxyCMA(nil),
+			addOn:   newClusterProxyAddOn(metav1.ConditionFalse),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertClusterProxyAnnotations(t, actions[0], map[string]interface{}{
+					ClusterProxyEndpointAnnotation: nil,
+					ClusterProxyHealthAnnotation:   addOnStatusUnhealthy,
+				})
+			},
+		},
+		{
+			name:    "cluster-proxy is uninstalled",
+			cluster: proxyAnnotatedCluster,
+			addOn:   newClusterProxyAddOn(metav1.ConditionTrue),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertClusterProxyAnnotations(t, actions[0], map[string]interface{}{
+					ClusterProxyEndpointAnnotation: nil,
+					ClusterProxyHealthAnnotation:   nil,
+				})
+			},
+		},
+		{
+			name:    "cluster-proxy addon is removed from the cluster",
+			cluster: proxyAnnotatedCluster,
+			cma:     newClusterProxyCMA(nil),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertClusterProxyAnnotations(t, actions[0], map[string]interface{}{
+					ClusterProxyEndpointAnnotation: nil,
+					ClusterProxyHealthAnnotation:   nil,
+				})
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objs := []runtime.Object{}
+			if c.cluster != nil {
+				objs = append(objs, c.cluster)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(objs...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if c.cluster != nil {
+				clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+				if err := clusterStore.Add(c.cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			addOnClient := addonfake.NewSimpleClientset()
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+			if c.cma != nil {
+				cmaStore := addOnInformerFactory.Addon().V1alpha1().ClusterManagementAddOns().Informer().GetStore()
+				if err := cmaStore.Add(c.cma); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if c.addOn != nil {
+				addOnStore := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore()
+				if err := addOnStore.Add(c.addOn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := &clusterProxyController{
+				patcher: patcher.NewPatcher[
+					*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				cmaLister:     addOnInformerFactory.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
+				addOnLister:   addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+			}
+			clusterClient.ClearActions()
+
+			err := controller.sync(context.Background(), testingcommon.NewFakeSyncContext(t, clusterName))
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func assertClusterProxyAnnotations(t *testing.T, action clienttesting.Action, expected map[string]interface{}) {
+	patch := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(action.(clienttesting.PatchActionImpl).Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	annotations, _ := patch["metadata"]["annotations"].(map[string]interface{})
+	for key, value := range expected {
+		actual, ok := annotations[key]
+		if !ok {
+			t.Errorf("expected annotation %q in the patch, but got %v", key, annotations)
+			continue
+		}
+		if actual != value {
+			t.Errorf("expected annotation %q to be %v, but got %v", key, value, actual)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package agentconfig
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+const (
+	// AgentConfigValidCondition is the condition type of a managed cluster indicating whether the agent
+	// configurations in the annotations of the managed cluster are valid. It is removed once none of the
+	// annotations is set.
+	AgentConfigValidCondition = "ManagedClusterAgentConfigValid"
+)
+
+// agentConfigController validates the agent configurations set by the fleet operators in the annotations of the
+// managed clusters, and propagates the valid ones in the annotations read by the klusterlet operators, so the
+// agents of a single cluster are tuned from the hub without changing the klusterlet on the managed cluster.
+type agentConfigController struct {
+	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister listerv1.ManagedClusterLister
+	eventRecorder events.Recorder
+}
+
+// NewAgentConfigController creates a new agent config controller
+func NewAgentConfigController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &agentConfigController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("agent-config-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("AgentConfigController", recorder)
+}
+
+func (c *agentConfigController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling agent config of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	newManagedCluster := managedCluster.DeepCopy()
+	value, ok := managedCluster.Annotations[helpers.AppliedManifestWorkEvictionGracePeriodAnnotation]
+	if !ok {
+		// the work agent falls back to the eviction grace period of its flag
+		delete(newManagedCluster.Annotations, helpers.WorkAgentEvictionGracePeriodAnnotation)
+		meta.RemoveStatusCondition(&newManagedCluster.Status.Conditions, AgentConfigValidCondition)
+	} else {
+		cond := metav1.Condition{
+			Type:    AgentConfigValidCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "AgentConfigValid",
+			Message: "The agent configurations of the managed cluster are valid",
+		}
+		gracePeriod, err := helpers.ValidateEvictionGracePeriod(value)
+		if err != nil {
+			// the last valid eviction grace period is kept, so a typo does not restart the work agent
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "InvalidAppliedManifestWorkEvictionGracePeriod"
+			cond.Message = err.Error()
+		} else {
+			newManagedCluster.Annotations[helpers.WorkAgentEvictionGracePeriodAnnotation] = gracePeriod.String()
+		}
+		meta.SetStatusCondition(&newManagedCluster.Status.Conditions, cond)
+	}
+
+	updated, err := c.patcher.PatchLabelAnnotations(ctx, newManagedCluster, newManagedCluster.ObjectMeta, managedCluster.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	if updated {
+		c.eventRecorder.Eventf("ManagedClusterAgentConfigPropagated",
+			"The agent configurations of managed cluster %s are propagated", managedClusterName)
+	}
+
+	_, err = c.patcher.PatchStatus(ctx, newManagedCluster, newManagedCluster.Status, managedCluster.Status)
+	return err
+}
@@ -0,0 +1,131 @@
+package agentconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncAgentConfig(t *testing.T) {
+	cases := []struct {
+		name                string
+		annotations         map[string]string
+		conditions          []metav1.Condition
+		expectedGracePeriod string
+		expectedCondition   *metav1.Condition
+	}{
+		{
+			name: "no agent config",
+		},
+		{
+			name: "eviction grace period is propagated",
+			annotations: map[string]string{
+				helpers.AppliedManifestWorkEvictionGracePeriodAnnotation: "90m",
+			},
+			expectedGracePeriod: "1h30m0s",
+			expectedCondition: &metav1.Condition{
+				Type: AgentConfigValidCondition, Status: metav1.ConditionTrue, Reason: "AgentConfigValid",
+				Message: "The agent configurations of the managed cluster are valid",
+			},
+		},
+		{
+			name: "eviction grace period is below the min",
+			annotations: map[string]string{
+				helpers.AppliedManifestWorkEvictionGracePeriodAnnotation: "30s",
+			},
+			expectedCondition: &metav1.Condition{
+				Type: AgentConfigValidCondition, Status: metav1.ConditionFalse,
+				Reason:  "InvalidAppliedManifestWorkEvictionGracePeriod",
+				Message: `the appliedmanifestwork eviction grace period "30s" is out of range [1m0s, 168h0m0s]`,
+			},
+		},
+		{
+			name: "eviction grace period is above the max and the last valid one is kept",
+			annotations: map[string]string{
+				helpers.AppliedManifestWorkEvictionGracePeriodAnnotation: "200h",
+				helpers.WorkAgentEvictionGracePeriodAnnotation:           "1h0m0s",
+			},
+			expectedGracePeriod: "1h0m0s",
+			expectedCondition: &metav1.Condition{
+				Type: AgentConfigValidCondition, Status: metav1.ConditionFalse,
+				Reason:  "InvalidAppliedManifestWorkEvictionGracePeriod",
+				Message: `the appliedmanifestwork eviction grace period "200h" is out of range [1m0s, 168h0m0s]`,
+			},
+		},
+		{
+			name: "eviction grace period cannot be parsed",
+			annotations: map[string]string{
+				helpers.AppliedManifestWorkEvictionGracePeriodAnnotation: "ten minutes",
+			},
+			expectedCondition: &metav1.Condition{
+				Type: AgentConfigValidCondition, Status: metav1.ConditionFalse,
+				Reason:  "InvalidAppliedManifestWorkEvictionGracePeriod",
+				Message: `the appliedmanifestwork eviction grace period "ten minutes" is invalid: time: invalid duration "ten minutes"`,
+			},
+		},
+		{
+			name: "eviction grace period is removed",
+			annotations: map[string]string{
+				helpers.WorkAgentEvictionGracePeriodAnnotation: "1h0m0s",
+			},
+			conditions: []metav1.Condition{
+				{Type: AgentConfigValidCondition, Status: metav1.ConditionTrue, Reason: "AgentConfigValid"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAcceptedManagedCluster()
+			cluster.Annotations = c.annotations
+			cluster.Status.Conditions = c.conditions
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &agentConfigController{
+				patcher: patcher.NewPatcher[
+					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testingcommon.AssertError(t, syncErr, "")
+
+			actual, err := clusterClient.ClusterV1().ManagedClusters().Get(
+				context.TODO(), testinghelpers.TestManagedClusterName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gracePeriod := actual.Annotations[helpers.WorkAgentEvictionGracePeriodAnnotation]; gracePeriod != c.expectedGracePeriod {
+				t.Errorf("expected eviction grace period %q, but got %q", c.expectedGracePeriod, gracePeriod)
+			}
+			cond := meta.FindStatusCondition(actual.Status.Conditions, AgentConfigValidCondition)
+			switch {
+			case c.expectedCondition == nil && cond != nil:
+				t.Errorf("expected no condition, but got %v", cond)
+			case c.expectedCondition != nil && cond == nil:
+				t.Errorf("expected condition %v, but got nil", c.expectedCondition)
+			case c.expectedCondition != nil && (cond.Status != c.expectedCondition.Status ||
+				cond.Reason != c.expectedCondition.Reason || cond.Message != c.expectedCondition.Message):
+				t.Errorf("expected condition %v, but got %v", c.expectedCondition, cond)
+			}
+		})
+	}
+}
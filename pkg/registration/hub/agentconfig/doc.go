@@ -0,0 +1,3 @@
+// package agentconfig contains the hub-side controller validating the agent configurations set in the annotations
+// of managed clusters and propagating them to the klusterlet operators
+package agentconfig
@@ -0,0 +1,213 @@
+package agentfailures
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+// AgentFailuresAnnotation is the annotation on the managed cluster summarizing the critical events reported by
+// the agents of the cluster in the last SummaryWindow. The value is a json object keyed by the category of the
+// events, e.g. {"WorkApplyFailed":{"count":5,"lastTimestamp":"...","recentMessages":["..."]}}.
+// TODO move this to the api repo
+const AgentFailuresAnnotation = "cluster.open-cluster-management.io/agent-failures"
+
+var (
+	// SummaryWindow is the period in which the critical events are summarized, the older events are expired.
+	SummaryWindow = time.Hour
+
+	// MaxCategories, MaxRecentMessages and MaxSummaryMessageLength bound the size of the summary. Only the most
+	// recent categories and the most recent messages in each category are kept.
+	MaxCategories           = 10
+	MaxRecentMessages       = 3
+	MaxSummaryMessageLength = 256
+)
+
+// CategorySummary summarizes the critical events of a category.
+type CategorySummary struct {
+	// Count is the number of the events in the summary window.
+	Count int32 `json:"count"`
+	// LastTimestamp is the time of the most recent event.
+	LastTimestamp metav1.Time `json:"lastTimestamp"`
+	// RecentMessages are the messages of the most recent events, the most recent message is the first.
+	RecentMessages []string `json:"recentMessages"`
+}
+
+// agentFailuresController summarizes the critical events reported by the agents in the cluster namespace into the
+// AgentFailuresAnnotation of the managed cluster. The annotation is removed once all the events are expired.
+type agentFailuresController struct {
+	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister listerv1.ManagedClusterLister
+	eventLister   corev1listers.EventLister
+	clock         clock.Clock
+}
+
+// NewAgentFailuresController creates a new agent failures controller. The event informer is expected to be
+// filtered by the events on the managed clusters.
+func NewAgentFailuresController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	eventInformer corev1informers.EventInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &agentFailuresController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformer.Lister(),
+		eventLister:   eventInformer.Lister(),
+		clock:         clock.RealClock{},
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetNamespace()
+		}, func(obj interface{}) bool {
+			event, ok := obj.(*corev1.Event)
+			if !ok {
+				return false
+			}
+			_, ok = agentevents.CategoryOf(event.Reason)
+			return ok
+		}, eventInformer.Informer()).
+		WithSync(c.sync).
+		ToController("AgentFailuresController", recorder)
+}
+
+func (c *agentFailuresController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling agent failures of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	clusterEvents, err := c.eventLister.Events(managedClusterName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	summary, nextExpiry := summarize(clusterEvents, c.clock.Now())
+
+	newManagedCluster := managedCluster.DeepCopy()
+	if len(summary) == 0 {
+		delete(newManagedCluster.Annotations, AgentFailuresAnnotation)
+	} else {
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		if newManagedCluster.Annotations == nil {
+			newManagedCluster.Annotations = map[string]string{}
+		}
+		newManagedCluster.Annotations[AgentFailuresAnnotation] = string(data)
+	}
+	if _, err := c.patcher.PatchLabelAnnotations(
+		ctx, newManagedCluster, newManagedCluster.ObjectMeta, managedCluster.ObjectMeta); err != nil {
+		return err
+	}
+
+	// resync once the oldest event in the summary is expired, so the stale summary is removed.
+	if nextExpiry > 0 {
+		syncCtx.Queue().AddAfter(managedClusterName, nextExpiry)
+	}
+	return nil
+}
+
+// summarize summarizes the critical events in the summary window by category. It returns the duration after
+// which the oldest event in the summary is expired, or 0 if the summary is empty.
+func summarize(clusterEvents []*corev1.Event, now time.Time) (map[string]CategorySummary, time.Duration) {
+	eventsByCategory := map[string][]*corev1.Event{}
+	var nextExpiry time.Duration
+	for _, event := range clusterEvents {
+		category, ok := agentevents.CategoryOf(event.Reason)
+		if !ok {
+			continue
+		}
+		expiry := agentevents.EventTime(event).Add(SummaryWindow).Sub(now)
+		if expiry <= 0 {
+			continue
+		}
+		if nextExpiry == 0 || expiry < nextExpiry {
+			nextExpiry = expiry
+		}
+		eventsByCategory[category] = append(eventsByCategory[category], event)
+	}
+
+	summaries := map[string]CategorySummary{}
+	for category, categoryEvents := range eventsByCategory {
+		// the most recent event first
+		sort.SliceStable(categoryEvents, func(i, j int) bool {
+			return agentevents.EventTime(categoryEvents[i]).After(agentevents.EventTime(categoryEvents[j]))
+		})
+
+		summary := CategorySummary{
+			LastTimestamp: metav1.NewTime(agentevents.EventTime(categoryEvents[0]).UTC()),
+		}
+		for _, event := range categoryEvents {
+			count := event.Count
+			if count < 1 {
+				count = 1
+			}
+			summary.Count += count
+			if len(summary.RecentMessages) < MaxRecentMessages {
+				summary.RecentMessages = append(summary.RecentMessages, truncate(event.Message, MaxSummaryMessageLength))
+			}
+		}
+		summaries[category] = summary
+	}
+
+	// keep the most recent categories only
+	if len(summaries) > MaxCategories {
+		categories := []string{}
+		for category := range summaries {
+			categories = append(categories, category)
+		}
+		sort.Slice(categories, func(i, j int) bool {
+			return summaries[categories[i]].LastTimestamp.After(summaries[categories[j]].LastTimestamp.Time)
+		})
+		for _, category := range categories[MaxCategories:] {
+			delete(summaries, category)
+		}
+	}
+
+	return summaries, nextExpiry
+}
+
+func truncate(message string, length int) string {
+	if len(message) <= length {
+		return message
+	}
+	return message[:length-3] + "..."
+}
@@ -0,0 +1,243 @@
+package agentfailures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	testingclock "k8s.io/utils/clock/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+var now = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newEvent(name, reason, message string, age time.Duration, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testinghelpers.TestManagedClusterName,
+		},
+		Reason:        reason,
+		Message:       message,
+		Type:          corev1.EventTypeWarning,
+		LastTimestamp: metav1.NewTime(now.Add(-age)),
+		Count:         count,
+	}
+}
+
+func newManagedCluster(summary string) *v1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	if len(summary) > 0 {
+		cluster.Annotations = map[string]string{AgentFailuresAnnotation: summary}
+	}
+	return cluster
+}
+
+func TestSync(t *testing.T) {
+	workApplyFailed := agentevents.CriticalReasonPrefix + agentevents.CategoryWorkApplyFailed
+	claimCollectionFailed := agentevents.CriticalReasonPrefix + agentevents.CategoryClaimCollectionFailed
+
+	cases := []struct {
+		name               string
+		clusters           []runtime.Object
+		events             []runtime.Object
+		expectedSummary    map[string]CategorySummary
+		expectedNoActions  bool
+		expectedNextExpiry time.Duration
+	}{
+		{
+			name:              "sync a deleted spoke cluster",
+			events:            []runtime.Object{newEvent("e1", workApplyFailed, "failed", time.Minute, 1)},
+			expectedNoActions: true,
+		},
+		{
+			name:     "no critical events",
+			clusters: []runtime.Object{newManagedCluster("")},
+			events: []runtime.Object{
+				newEvent("e1", "ManagedClusterCreated", "created", time.Minute, 1),
+			},
+			expectedNoActions: true,
+		},
+		{
+			name:     "aggregate the critical events by category",
+			clusters: []runtime.Object{newManagedCluster("")},
+			events: []runtime.Object{
+				newEvent("e1", workApplyFailed, "work1 failed", 40*time.Minute, 2),
+				newEvent("e2", workApplyFailed, "work2 failed", 30*time.Minute, 1),
+				newEvent("e3", workApplyFailed, "work3 failed", 20*time.Minute, 1),
+				newEvent("e4", workApplyFailed, "work4 failed", 10*time.Minute, 1),
+				newEvent("e5", claimCollectionFailed, "claims failed", 5*time.Minute, 1),
+				newEvent("e6", "ManagedClusterCreated", "created", time.Minute, 1),
+			},
+			expectedSummary: map[string]CategorySummary{
+				agentevents.CategoryWorkApplyFailed: {
+					Count:          5,
+					LastTimestamp:  metav1.NewTime(now.Add(-10 * time.Minute)),
+					RecentMessages: []string{"work4 failed", "work3 failed", "work2 failed"},
+				},
+				agentevents.CategoryClaimCollectionFailed: {
+					Count:          1,
+					LastTimestamp:  metav1.NewTime(now.Add(-5 * time.Minute)),
+					RecentMessages: []string{"claims failed"},
+				},
+			},
+			expectedNextExpiry: 20 * time.Minute,
+		},
+		{
+			name:     "the expired events are not summarized",
+			clusters: []runtime.Object{newManagedCluster("")},
+			events: []runtime.Object{
+				newEvent("e1", workApplyFailed, "work1 failed", 2*time.Hour, 1),
+				newEvent("e2", claimCollectionFailed, "claims failed", 50*time.Minute, 1),
+			},
+			expectedSummary: map[string]CategorySummary{
+				agentevents.CategoryClaimCollectionFailed: {
+					Count:          1,
+					LastTimestamp:  metav1.NewTime(now.Add(-50 * time.Minute)),
+					RecentMessages: []string{"claims failed"},
+				},
+			},
+			expectedNextExpiry: 10 * time.Minute,
+		},
+		{
+			name:     "remove the stale summary once all the events are expired",
+			clusters: []runtime.Object{newManagedCluster(`{"WorkApplyFailed":{"count":1}}`)},
+			events: []runtime.Object{
+				newEvent("e1", workApplyFailed, "work1 failed", 2*time.Hour, 1),
+			},
+		},
+		{
+			name: "the summary is synced",
+			clusters: []runtime.Object{newManagedCluster(
+				`{"WorkApplyFailed":{"count":1,"lastTimestamp":"2023-06-01T11:50:00Z","recentMessages":["work1 failed"]}}`)},
+			events: []runtime.Object{
+				newEvent("e1", workApplyFailed, "work1 failed", 10*time.Minute, 1),
+			},
+			expectedNoActions:  true,
+			expectedNextExpiry: 50 * time.Minute,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), time.Minute*10)
+			eventStore := kubeInformerFactory.Core().V1().Events().Informer().GetStore()
+			for _, event := range c.events {
+				if err := eventStore.Add(event); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := agentFailuresController{
+				patcher: patcher.NewPatcher[
+					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventLister:   kubeInformerFactory.Core().V1().Events().Lister(),
+				clock:         testingclock.NewFakeClock(now),
+			}
+			syncCtx := testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			if c.expectedNoActions {
+				testingcommon.AssertNoActions(t, clusterClient.Actions())
+			} else {
+				assertSummaryPatch(t, clusterClient.Actions(), c.expectedSummary)
+			}
+
+			if len(c.clusters) == 0 {
+				return
+			}
+			_, nextExpiry := summarize(eventsOf(c.events), now)
+			if nextExpiry != c.expectedNextExpiry {
+				t.Errorf("expected next expiry %v, but got %v", c.expectedNextExpiry, nextExpiry)
+			}
+		})
+	}
+}
+
+func TestSummaryBounds(t *testing.T) {
+	clusterEvents := []*corev1.Event{}
+	for i := 0; i < MaxCategories+2; i++ {
+		clusterEvents = append(clusterEvents, newEvent(fmt.Sprintf("e%d", i),
+			fmt.Sprintf("%sCategory%d", agentevents.CriticalReasonPrefix, i),
+			string(make([]byte, 2*MaxSummaryMessageLength)), time.Duration(i)*time.Minute, 1))
+	}
+
+	summary, _ := summarize(clusterEvents, now)
+	if len(summary) != MaxCategories {
+		t.Fatalf("expected %d categories, but got %d", MaxCategories, len(summary))
+	}
+	// the oldest categories are dropped
+	for i := MaxCategories; i < MaxCategories+2; i++ {
+		if _, ok := summary[fmt.Sprintf("Category%d", i)]; ok {
+			t.Errorf("expected the category %d is dropped", i)
+		}
+	}
+	for category, categorySummary := range summary {
+		if len(categorySummary.RecentMessages[0]) != MaxSummaryMessageLength {
+			t.Errorf("expected the message of %s is truncated, but got length %d",
+				category, len(categorySummary.RecentMessages[0]))
+		}
+	}
+}
+
+func eventsOf(objects []runtime.Object) []*corev1.Event {
+	clusterEvents := []*corev1.Event{}
+	for _, obj := range objects {
+		clusterEvents = append(clusterEvents, obj.(*corev1.Event))
+	}
+	return clusterEvents
+}
+
+func assertSummaryPatch(t *testing.T, actions []clienttesting.Action, expectedSummary map[string]CategorySummary) {
+	testingcommon.AssertActions(t, actions, "patch")
+	patch := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	annotations, _ := patch["metadata"]["annotations"].(map[string]interface{})
+	value := annotations[AgentFailuresAnnotation]
+	if expectedSummary == nil {
+		if value != nil {
+			t.Errorf("expected the summary is removed, but got %v", value)
+		}
+		return
+	}
+
+	summary := map[string]CategorySummary{}
+	if err := json.Unmarshal([]byte(value.(string)), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(summary, expectedSummary) {
+		t.Errorf("expected summary %v, but got %v", expectedSummary, summary)
+	}
+}
@@ -0,0 +1,3 @@
+// package agentfailures contains the hub-side controller summarizing the critical events reported by the agents
+// on the managed clusters
+package agentfailures
@@ -0,0 +1,167 @@
+package claimlabel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// ClusterClaimLabelsConfigMap is the configmap in the namespace of the hub components to map the cluster
+// claims to the labels of the managed clusters. Each key of the data is the name of a cluster claim, and the
+// value is the key of the label the claim value is copied to.
+const ClusterClaimLabelsConfigMap = "cluster-claim-labels"
+
+// claimLabelController syncs the labels derived from the cluster claims on the accepted managed clusters.
+// Only the labels recorded in the ClusterClaimLabelsAnnotation are owned by the controller, a label set by
+// others with the same key is never overwritten.
+type claimLabelController struct {
+	patcher         patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister   listerv1.ManagedClusterLister
+	configMapLister corev1listers.ConfigMapLister
+	namespace       string
+	eventRecorder   events.Recorder
+}
+
+// NewClaimLabelController creates a new claim label controller
+func NewClaimLabelController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	namespace string,
+	recorder events.Recorder) factory.Controller {
+	c := &claimLabelController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister:   clusterInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		namespace:       namespace,
+		eventRecorder:   recorder.WithComponentSuffix("claim-label-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			c.clusterQueueKeysFunc,
+			func(obj interface{}) bool {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetNamespace() == namespace && accessor.GetName() == ClusterClaimLabelsConfigMap
+			},
+			configMapInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClaimLabelController", recorder)
+}
+
+// clusterQueueKeysFunc enqueues all the managed clusters once the mapping configmap is changed.
+func (c *claimLabelController) clusterQueueKeysFunc(_ runtime.Object) []string {
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		keys = append(keys, cluster.Name)
+	}
+	return keys
+}
+
+func (c *claimLabelController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling claim labels of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() || !managedCluster.Spec.HubAcceptsClient {
+		return nil
+	}
+
+	// the mapping is empty if the configmap does not exist, so the derived labels are removed.
+	mapping := map[string]string{}
+	configMap, err := c.configMapLister.ConfigMaps(c.namespace).Get(ClusterClaimLabelsConfigMap)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	default:
+		mapping = configMap.Data
+	}
+
+	claims := map[string]string{}
+	for _, claim := range managedCluster.Status.ClusterClaims {
+		claims[claim.Name] = claim.Value
+	}
+
+	newManagedCluster := managedCluster.DeepCopy()
+	if newManagedCluster.Labels == nil {
+		newManagedCluster.Labels = map[string]string{}
+	}
+	if newManagedCluster.Annotations == nil {
+		newManagedCluster.Annotations = map[string]string{}
+	}
+
+	ownedKeys := sets.New[string]()
+	if owned := managedCluster.Annotations[helpers.ClusterClaimLabelsAnnotation]; len(owned) > 0 {
+		ownedKeys.Insert(strings.Split(owned, ",")...)
+	}
+
+	derivedLabels := map[string]string{}
+	for claimName, labelKey := range mapping {
+		if value, ok := claims[claimName]; ok && len(labelKey) > 0 {
+			derivedLabels[labelKey] = value
+		}
+	}
+
+	newOwnedKeys := sets.New[string]()
+	for key, value := range derivedLabels {
+		if existing, ok := managedCluster.Labels[key]; ok && !ownedKeys.Has(key) {
+			// the label is set by others, leave it as it is.
+			if existing != value {
+				c.eventRecorder.Warningf("ClusterClaimLabelConflict",
+					"label %q of managed cluster %s is not owned by the claim label controller, skip it", key, managedClusterName)
+			}
+			continue
+		}
+		newManagedCluster.Labels[key] = value
+		newOwnedKeys.Insert(key)
+	}
+
+	// remove the derived labels whose claims or mapping entries are removed.
+	for key := range ownedKeys.Difference(newOwnedKeys) {
+		delete(newManagedCluster.Labels, key)
+	}
+
+	if newOwnedKeys.Len() == 0 {
+		delete(newManagedCluster.Annotations, helpers.ClusterClaimLabelsAnnotation)
+	} else {
+		newManagedCluster.Annotations[helpers.ClusterClaimLabelsAnnotation] = strings.Join(sets.List(newOwnedKeys), ",")
+	}
+
+	_, err = c.patcher.PatchLabelAnnotations(ctx, newManagedCluster, newManagedCluster.ObjectMeta, managedCluster.ObjectMeta)
+	return err
+}
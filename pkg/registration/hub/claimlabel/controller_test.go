@@ -0,0 +1,217 @@
+package claimlabel
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+const testNamespace = "open-cluster-management-hub"
+
+func newManagedCluster(labels map[string]string, ownedKeys string, claims ...v1.ManagedClusterClaim) *v1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Labels = labels
+	if len(ownedKeys) > 0 {
+		cluster.Annotations = map[string]string{helpers.ClusterClaimLabelsAnnotation: ownedKeys}
+	}
+	cluster.Status.ClusterClaims = claims
+	return cluster
+}
+
+func newClaimLabelsConfigMap(mapping map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterClaimLabelsConfigMap, Namespace: testNamespace},
+		Data:       mapping,
+	}
+}
+
+func assertMetadataPatch(t *testing.T, actions []clienttesting.Action, expectedLabels, expectedAnnotations map[string]interface{}) {
+	testingcommon.AssertActions(t, actions, "patch")
+	patch := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	labels, _ := patch["metadata"]["labels"].(map[string]interface{})
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("expected labels patch %v, but got %v", expectedLabels, labels)
+	}
+	annotations, _ := patch["metadata"]["annotations"].(map[string]interface{})
+	if !reflect.DeepEqual(annotations, expectedAnnotations) {
+		t.Errorf("expected annotations patch %v, but got %v", expectedAnnotations, annotations)
+	}
+}
+
+func TestSyncClaimLabels(t *testing.T) {
+	regionMapping := map[string]string{"region.open-cluster-management.io": "region"}
+	regionClaim := v1.ManagedClusterClaim{Name: "region.open-cluster-management.io", Value: "us-east-1"}
+
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		configMaps      []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:       "sync a deleted spoke cluster",
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "cluster is not accepted",
+			clusters: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := newManagedCluster(nil, "", regionClaim)
+				cluster.Spec.HubAcceptsClient = false
+				return cluster
+			}()},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "no mapping",
+			clusters: []runtime.Object{newManagedCluster(map[string]string{"env": "dev"}, "", regionClaim)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:       "add the derived label",
+			clusters:   []runtime.Object{newManagedCluster(map[string]string{"env": "dev"}, "", regionClaim)},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertMetadataPatch(t, actions,
+					map[string]interface{}{"region": "us-east-1"},
+					map[string]interface{}{helpers.ClusterClaimLabelsAnnotation: "region"})
+			},
+		},
+		{
+			name:       "the derived label is synced",
+			clusters:   []runtime.Object{newManagedCluster(map[string]string{"region": "us-east-1"}, "region", regionClaim)},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "update the derived label once the claim is updated",
+			clusters: []runtime.Object{newManagedCluster(map[string]string{"region": "us-east-1"}, "region",
+				v1.ManagedClusterClaim{Name: "region.open-cluster-management.io", Value: "us-west-1"})},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertMetadataPatch(t, actions, map[string]interface{}{"region": "us-west-1"}, nil)
+			},
+		},
+		{
+			name:       "remove the derived label once the claim is removed",
+			clusters:   []runtime.Object{newManagedCluster(map[string]string{"region": "us-east-1", "env": "dev"}, "region")},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertMetadataPatch(t, actions,
+					map[string]interface{}{"region": nil},
+					map[string]interface{}{helpers.ClusterClaimLabelsAnnotation: nil})
+			},
+		},
+		{
+			name: "remove the derived label once the mapping entry is removed",
+			clusters: []runtime.Object{newManagedCluster(map[string]string{"region": "us-east-1", "zone": "a"}, "region,zone",
+				regionClaim, v1.ManagedClusterClaim{Name: "zone.open-cluster-management.io", Value: "a"})},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(map[string]string{"zone.open-cluster-management.io": "zone"})},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertMetadataPatch(t, actions,
+					map[string]interface{}{"region": nil},
+					map[string]interface{}{helpers.ClusterClaimLabelsAnnotation: "zone"})
+			},
+		},
+		{
+			name: "add the derived label once the mapping entry is added",
+			clusters: []runtime.Object{newManagedCluster(map[string]string{"region": "us-east-1"}, "region",
+				regionClaim, v1.ManagedClusterClaim{Name: "zone.open-cluster-management.io", Value: "a"})},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(map[string]string{
+				"region.open-cluster-management.io": "region",
+				"zone.open-cluster-management.io":   "zone",
+			})},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertMetadataPatch(t, actions,
+					map[string]interface{}{"zone": "a"},
+					map[string]interface{}{helpers.ClusterClaimLabelsAnnotation: "region,zone"})
+			},
+		},
+		{
+			name:       "do not overwrite the label set manually",
+			clusters:   []runtime.Object{newManagedCluster(map[string]string{"region": "eu"}, "", regionClaim)},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(regionMapping)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "do not remove the label set manually once the mapping entry is removed",
+			clusters: []runtime.Object{newManagedCluster(map[string]string{"region": "eu"}, "",
+				regionClaim)},
+			configMaps: []runtime.Object{newClaimLabelsConfigMap(map[string]string{})},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.configMaps...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			configMapStore := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore()
+			for _, configMap := range c.configMaps {
+				if err := configMapStore.Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := claimLabelController{
+				patcher: patcher.NewPatcher[
+					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				configMapLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				namespace:       testNamespace,
+				eventRecorder:   eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
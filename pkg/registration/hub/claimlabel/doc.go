@@ -0,0 +1,3 @@
+// package claimlabel contains the hub-side controller syncing the labels derived from the cluster claims
+// on the managed clusters
+package claimlabel
@@ -0,0 +1,217 @@
+package clientconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kevents "k8s.io/client-go/tools/events"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+const (
+	// StaleClientConfigsAnnotation is the annotation on a managed cluster to mark client configs as stale,
+	// the value is a comma separated list of the urls of the stale client configs. The stale client configs
+	// are pruned from the managed cluster.
+	StaleClientConfigsAnnotation = "cluster.open-cluster-management.io/stale-client-config-urls"
+
+	// ClientConfigsValidCondition is the condition type of a managed cluster indicating whether the managed
+	// cluster has at least one valid client config.
+	ClientConfigsValidCondition = "ManagedClusterClientConfigsValid"
+)
+
+// clientConfigController validates, deduplicates and prunes the client configs of managed clusters.
+type clientConfigController struct {
+	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister listerv1.ManagedClusterLister
+	eventRecorder events.Recorder
+	// krecorder emits the events on the managed clusters
+	krecorder kevents.EventRecorder
+}
+
+// NewClientConfigController creates a new client config controller
+func NewClientConfigController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	recorder events.Recorder,
+	krecorder kevents.EventRecorder) factory.Controller {
+	c := &clientConfigController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("client-config-controller"),
+		krecorder:     krecorder,
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClientConfigController", recorder)
+}
+
+func (c *clientConfigController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling client configs of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	newManagedCluster := managedCluster.DeepCopy()
+	clientConfigs, invalidMessages, rotatedURLs := reconcileClientConfigs(
+		managedCluster.Spec.ManagedClusterClientConfigs, staleURLs(managedCluster))
+	newManagedCluster.Spec.ManagedClusterClientConfigs = clientConfigs
+
+	updated, err := c.patcher.PatchSpec(ctx, newManagedCluster, newManagedCluster.Spec, managedCluster.Spec)
+	if err != nil {
+		return err
+	}
+	if updated {
+		c.eventRecorder.Eventf("ManagedClusterClientConfigsUpdated",
+			"The client configs of managed cluster %s are deduplicated and pruned", managedClusterName)
+		for _, url := range rotatedURLs {
+			c.krecorder.Eventf(managedCluster, nil, corev1.EventTypeNormal, "CABundleRotated", "UpdateClientConfig",
+				"The CA bundle of the client config %q is rotated%s", url, rotatedAt(managedCluster))
+		}
+		// the status will be updated once the spec change is observed
+		return nil
+	}
+
+	cond := metav1.Condition{
+		Type:    ClientConfigsValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ClientConfigsValid",
+		Message: "The managed cluster has valid client configs",
+	}
+	if len(clientConfigs) == len(invalidMessages) {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NoValidClientConfig"
+		cond.Message = "The managed cluster has no valid client config"
+		if len(invalidMessages) > 0 {
+			cond.Message = fmt.Sprintf("%s: %s", cond.Message, strings.Join(invalidMessages, "; "))
+		}
+	}
+	meta.SetStatusCondition(&newManagedCluster.Status.Conditions, cond)
+
+	_, err = c.patcher.PatchStatus(ctx, newManagedCluster, newManagedCluster.Status, managedCluster.Status)
+	return err
+}
+
+// reconcileClientConfigs prunes the stale client configs and deduplicates the valid client configs
+// by url, the one with the newest ca is kept at the position of the first one. The invalid client
+// configs are kept as they are, and the reasons why they are invalid are returned. The urls whose ca
+// is replaced by a newer one are returned as rotated, e.g. the new ca published by the registration agent.
+func reconcileClientConfigs(clientConfigs []v1.ClientConfig, staleURLs sets.Set[string]) ([]v1.ClientConfig, []string, []string) {
+	var reconciled []v1.ClientConfig
+	var invalidMessages []string
+	rotated := sets.New[string]()
+	validIndex := map[string]int{}
+	for _, clientConfig := range clientConfigs {
+		if staleURLs.Has(clientConfig.URL) {
+			continue
+		}
+
+		if err := validateClientConfig(clientConfig); err != nil {
+			reconciled = append(reconciled, clientConfig)
+			invalidMessages = append(invalidMessages, err.Error())
+			continue
+		}
+
+		index, ok := validIndex[clientConfig.URL]
+		if !ok {
+			validIndex[clientConfig.URL] = len(reconciled)
+			reconciled = append(reconciled, clientConfig)
+			continue
+		}
+		if isNewerCABundle(clientConfig.CABundle, reconciled[index].CABundle) {
+			reconciled[index] = clientConfig
+			rotated.Insert(clientConfig.URL)
+		}
+	}
+	return reconciled, invalidMessages, sets.List(rotated)
+}
+
+func validateClientConfig(clientConfig v1.ClientConfig) error {
+	if err := helpers.ValidateHTTPSURL(clientConfig.URL); err != nil {
+		return fmt.Errorf("url %q is invalid: %v", clientConfig.URL, err)
+	}
+	if len(clientConfig.CABundle) == 0 {
+		// system certs are used
+		return nil
+	}
+	if _, err := certutil.ParseCertsPEM(clientConfig.CABundle); err != nil {
+		return fmt.Errorf("ca bundle of url %q is invalid: %v", clientConfig.URL, err)
+	}
+	return nil
+}
+
+// isNewerCABundle returns true if the newest certificate in caBundle is issued later than the one in
+// the other bundle. Both bundles should have been validated.
+func isNewerCABundle(caBundle, other []byte) bool {
+	if bytes.Equal(caBundle, other) {
+		return false
+	}
+	return newestNotBefore(caBundle).After(newestNotBefore(other))
+}
+
+func newestNotBefore(caBundle []byte) (newest time.Time) {
+	certs, _ := certutil.ParseCertsPEM(caBundle)
+	for _, cert := range certs {
+		if cert.NotBefore.After(newest) {
+			newest = cert.NotBefore
+		}
+	}
+	return newest
+}
+
+// rotatedAt returns the rotation time published by the registration agent in the event message.
+func rotatedAt(managedCluster *v1.ManagedCluster) string {
+	timestamp, ok := managedCluster.Annotations[helpers.CABundleRotationTimestampAnnotation]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" at %s", timestamp)
+}
+
+func staleURLs(managedCluster *v1.ManagedCluster) sets.Set[string] {
+	urls := sets.New[string]()
+	value, ok := managedCluster.Annotations[StaleClientConfigsAnnotation]
+	if !ok {
+		return urls
+	}
+	for _, url := range strings.Split(value, ",") {
+		if url = strings.TrimSpace(url); len(url) > 0 {
+			urls.Insert(url)
+		}
+	}
+	return urls
+}
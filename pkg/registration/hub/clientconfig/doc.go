@@ -0,0 +1,3 @@
+// package clientconfig contains the hub-side controller validating, deduplicating and pruning the client
+// configs of managed clusters
+package clientconfig
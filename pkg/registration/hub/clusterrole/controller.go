@@ -24,17 +24,32 @@ import (
 const (
 	registrationClusterRole = "open-cluster-management:managedcluster:registration"
 	workClusterRole         = "open-cluster-management:managedcluster:work"
+
+	// workAdminClusterRole allows to create/update/delete the manifestworks in a cluster namespace, it is
+	// not aggregated and should be bound explicitly.
+	workAdminClusterRole = "open-cluster-management:managedcluster:work-admin"
+	// workViewClusterRole allows to read the manifestworks in a cluster namespace, it is aggregated to the
+	// view role.
+	workViewClusterRole = "open-cluster-management:managedcluster:work-view"
+	// clusterAdminClusterRole allows to manage the addons and the manifestworks in a cluster namespace, it is
+	// not aggregated and should be bound explicitly.
+	clusterAdminClusterRole = "open-cluster-management:managedcluster:cluster-admin"
 )
 
 var clusterRoleFiles = []string{
 	"manifests/managedcluster-registration-clusterrole.yaml",
 	"manifests/managedcluster-work-clusterrole.yaml",
+	"manifests/managedcluster-work-admin-clusterrole.yaml",
+	"manifests/managedcluster-work-view-clusterrole.yaml",
+	"manifests/managedcluster-cluster-admin-clusterrole.yaml",
 }
 
 //go:embed manifests
 var manifestFiles embed.FS
 
-// clusterroleController maintains the necessary clusterroles for registration and work agent on hub cluster.
+// clusterroleController maintains the necessary clusterroles for registration and work agent on hub cluster, and
+// the clusterroles for the users to access the cluster namespaces. The clusterroles are reverted once they are
+// changed, and removed once there are no managed clusters.
 type clusterroleController struct {
 	kubeClient    kubernetes.Interface
 	clusterLister clusterv1listers.ManagedClusterLister
@@ -57,7 +72,8 @@ func NewManagedClusterClusterroleController(
 	return factory.New().
 		WithFilteredEventsInformers(
 			func(obj interface{}) bool {
-				clusterRoles := sets.NewString(registrationClusterRole, workClusterRole)
+				clusterRoles := sets.NewString(registrationClusterRole, workClusterRole,
+					workAdminClusterRole, workViewClusterRole, clusterAdminClusterRole)
 				metaObj := obj.(metav1.Object)
 				return clusterRoles.Has(metaObj.GetName())
 			}, clusterRoleInformer.Informer()).
@@ -77,6 +93,7 @@ func (c *clusterroleController) sync(ctx context.Context, syncCtx factory.SyncCo
 		return helpers.CleanUpManagedClusterManifests(
 			ctx,
 			c.kubeClient,
+			nil,
 			c.eventRecorder,
 			manifestFiles.ReadFile,
 			clusterRoleFiles...,
@@ -2,6 +2,7 @@ package clusterrole
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
@@ -32,14 +34,15 @@ func TestSyncManagedClusterClusterRole(t *testing.T) {
 			clusters:     []runtime.Object{testinghelpers.NewManagedCluster()},
 			clusterroles: []runtime.Object{},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testingcommon.AssertActions(t, actions, "get", "create", "get", "create")
-				registrationClusterRole := (actions[1].(clienttesting.CreateActionImpl).Object).(*rbacv1.ClusterRole)
-				if registrationClusterRole.Name != "open-cluster-management:managedcluster:registration" {
-					t.Errorf("expected registration clusterrole, but failed")
-				}
-				workClusterRole := (actions[3].(clienttesting.CreateActionImpl).Object).(*rbacv1.ClusterRole)
-				if workClusterRole.Name != "open-cluster-management:managedcluster:work" {
-					t.Errorf("expected work clusterrole, but failed")
+				testingcommon.AssertActions(t, actions,
+					"get", "create", "get", "create", "get", "create", "get", "create", "get", "create")
+				expectedNames := []string{registrationClusterRole, workClusterRole,
+					workAdminClusterRole, workViewClusterRole, clusterAdminClusterRole}
+				for i, name := range expectedNames {
+					clusterRole := (actions[2*i+1].(clienttesting.CreateActionImpl).Object).(*rbacv1.ClusterRole)
+					if clusterRole.Name != name {
+						t.Errorf("expected clusterrole %q, but got %q", name, clusterRole.Name)
+					}
 				}
 			},
 		},
@@ -51,7 +54,7 @@ func TestSyncManagedClusterClusterRole(t *testing.T) {
 				&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "open-cluster-management:managedcluster:work"}},
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testingcommon.AssertActions(t, actions, "delete", "delete")
+				testingcommon.AssertActions(t, actions, "delete", "delete", "delete", "delete", "delete")
 				if actions[0].(clienttesting.DeleteActionImpl).Name != "open-cluster-management:managedcluster:registration" {
 					t.Errorf("expected registration clusterrole, but failed")
 				}
@@ -91,3 +94,71 @@ func TestSyncManagedClusterClusterRole(t *testing.T) {
 		})
 	}
 }
+
+func TestUserClusterRoles(t *testing.T) {
+	allWorkVerbs := []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+	readVerbs := []string{"get", "list", "watch"}
+	workRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"work.open-cluster-management.io"}, Resources: []string{"manifestworks"}, Verbs: allWorkVerbs},
+		{APIGroups: []string{"work.open-cluster-management.io"}, Resources: []string{"manifestworks/status"}, Verbs: readVerbs},
+	}
+
+	cases := []struct {
+		name           string
+		file           string
+		expectedName   string
+		expectedLabels map[string]string
+		expectedRules  []rbacv1.PolicyRule
+	}{
+		{
+			name:          "work admin",
+			file:          "manifests/managedcluster-work-admin-clusterrole.yaml",
+			expectedName:  workAdminClusterRole,
+			expectedRules: workRules,
+		},
+		{
+			name:           "work view",
+			file:           "manifests/managedcluster-work-view-clusterrole.yaml",
+			expectedName:   workViewClusterRole,
+			expectedLabels: map[string]string{"rbac.authorization.k8s.io/aggregate-to-view": "true"},
+			expectedRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"work.open-cluster-management.io"},
+					Resources: []string{"manifestworks", "manifestworks/status"},
+					Verbs:     readVerbs,
+				},
+			},
+		},
+		{
+			name:         "cluster admin",
+			file:         "manifests/managedcluster-cluster-admin-clusterrole.yaml",
+			expectedName: clusterAdminClusterRole,
+			expectedRules: append([]rbacv1.PolicyRule{
+				{APIGroups: []string{"addon.open-cluster-management.io"}, Resources: []string{"managedclusteraddons"}, Verbs: allWorkVerbs},
+				{APIGroups: []string{"addon.open-cluster-management.io"}, Resources: []string{"managedclusteraddons/status"}, Verbs: readVerbs},
+			}, workRules...),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			raw, err := manifestFiles.ReadFile(c.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clusterRole := &rbacv1.ClusterRole{}
+			if err := yaml.Unmarshal(raw, clusterRole); err != nil {
+				t.Fatal(err)
+			}
+			if clusterRole.Name != c.expectedName {
+				t.Errorf("expected name %q, but got %q", c.expectedName, clusterRole.Name)
+			}
+			if !reflect.DeepEqual(clusterRole.Labels, c.expectedLabels) {
+				t.Errorf("expected labels %v, but got %v", c.expectedLabels, clusterRole.Labels)
+			}
+			if !reflect.DeepEqual(clusterRole.Rules, c.expectedRules) {
+				t.Errorf("expected rules %v, but got %v", c.expectedRules, clusterRole.Rules)
+			}
+		})
+	}
+}
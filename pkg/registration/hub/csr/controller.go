@@ -29,6 +29,7 @@ type CSRLister[T CSR] interface {
 
 type CSRApprover[T CSR] interface {
 	approve(ctx context.Context, csr T) approveCSRFunc
+	deny(ctx context.Context, csr T) denyCSRFunc
 	isInTerminalState(csr T) bool
 }
 
@@ -73,13 +74,23 @@ func (c *csrApprovingController[T]) sync(ctx context.Context, syncCtx factory.Sy
 		return err
 	}
 
+	csrInfo := newCSRInfo(csr)
 	if c.approver.isInTerminalState(csr) {
+		if !csrInfo.approved {
+			return nil
+		}
+		for _, r := range c.reconcilers {
+			if ar, ok := r.(approvedReconciler); ok {
+				if err := ar.ReconcileApproved(ctx, csrInfo); err != nil {
+					return err
+				}
+			}
+		}
 		return nil
 	}
 
-	csrInfo := newCSRInfo(csr)
 	for _, r := range c.reconcilers {
-		state, err := r.Reconcile(ctx, csrInfo, c.approver.approve(ctx, csr))
+		state, err := r.Reconcile(ctx, csrInfo, c.approver.approve(ctx, csr), c.approver.deny(ctx, csr))
 		if err != nil {
 			return err
 		}
@@ -121,6 +132,20 @@ func (c *CSRV1Approver) approve(ctx context.Context, csr *certificatesv1.Certifi
 	}
 }
 
+func (c *CSRV1Approver) deny(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) denyCSRFunc { //nolint:unused
+	return func(kubeClient kubernetes.Interface, reason, message string) error {
+		csrCopy := csr.DeepCopy()
+		csrCopy.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:    certificatesv1.CertificateDenied,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		})
+		_, err := kubeClient.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csrCopy.Name, csrCopy, metav1.UpdateOptions{})
+		return err
+	}
+}
+
 var _ CSRApprover[*certificatesv1beta1.CertificateSigningRequest] = &CSRV1beta1Approver{}
 
 type CSRV1beta1Approver struct {
@@ -149,3 +174,17 @@ func (c *CSRV1beta1Approver) approve(ctx context.Context, csr *certificatesv1bet
 		return err
 	}
 }
+
+func (c *CSRV1beta1Approver) deny(ctx context.Context, csr *certificatesv1beta1.CertificateSigningRequest) denyCSRFunc { //nolint:unused
+	return func(kubeClient kubernetes.Interface, reason, message string) error {
+		csrCopy := csr.DeepCopy()
+		csrCopy.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
+			Type:    certificatesv1beta1.CertificateDenied,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		})
+		_, err := kubeClient.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(ctx, csrCopy, metav1.UpdateOptions{})
+		return err
+	}
+}
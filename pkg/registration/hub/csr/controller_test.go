@@ -2,6 +2,7 @@ package csr
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
@@ -21,6 +23,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 	"open-cluster-management.io/ocm/pkg/registration/hub/user"
 )
@@ -43,6 +46,7 @@ func TestSync(t *testing.T) {
 		startingClusters     []runtime.Object
 		startingCSRs         []runtime.Object
 		approvalUsers        []string
+		approvalSelector     string
 		autoApprovingAllowed bool
 		validateActions      func(t *testing.T, actions []clienttesting.Action)
 	}{
@@ -165,6 +169,69 @@ func TestSync(t *testing.T) {
 				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
 			},
 		},
+		{
+			name: "auto approve a bootstrap csr request of a cluster matching the selector",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "managedcluster1",
+						Labels: map[string]string{"cluster.open-cluster-management.io/clusterset": "dev"},
+					},
+				},
+			},
+			startingCSRs: []runtime.Object{func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewCSR(validCSR)
+				csr.Spec.Username = "test"
+				return csr
+			}()},
+			approvalUsers:    []string{"test"},
+			approvalSelector: "cluster.open-cluster-management.io/clusterset in (dev,test)",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+			},
+		},
+		{
+			name: "not auto approve a bootstrap csr request of a cluster not matching the selector",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "managedcluster1",
+						Labels: map[string]string{"cluster.open-cluster-management.io/clusterset": "prod"},
+					},
+				},
+			},
+			startingCSRs: []runtime.Object{func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewCSR(validCSR)
+				csr.Spec.Username = "test"
+				return csr
+			}()},
+			approvalUsers:    []string{"test"},
+			approvalSelector: "cluster.open-cluster-management.io/clusterset in (dev,test)",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "not auto approve a bootstrap csr request of a cluster by the labels set by the agent",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "managedcluster1",
+						Labels: map[string]string{"environment": "dev"},
+					},
+				},
+			},
+			startingCSRs: []runtime.Object{func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewCSR(validCSR)
+				csr.Spec.Username = "test"
+				return csr
+			}()},
+			approvalUsers:    []string{"test"},
+			approvalSelector: "cluster.open-cluster-management.io/clusterset in (dev,test)",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -198,6 +265,14 @@ func TestSync(t *testing.T) {
 				}
 			}
 
+			var selector labels.Selector
+			if len(c.approvalSelector) > 0 {
+				var err error
+				if selector, err = helpers.ParseClusterAutoApprovalSelector(c.approvalSelector); err != nil {
+					t.Fatal(err)
+				}
+			}
+
 			recorder := eventstesting.NewTestingEventRecorder(t)
 			ctrl := &csrApprovingController[*certificatesv1.CertificateSigningRequest]{
 				lister:   informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
@@ -214,6 +289,7 @@ func TestSync(t *testing.T) {
 						clusterClient,
 						clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
 						c.approvalUsers,
+						selector,
 						recorder,
 					),
 				},
@@ -228,6 +304,141 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestSyncClusterNamePolicy(t *testing.T) {
+	bootstrapCSR := func(clusterName string) *certificatesv1.CertificateSigningRequest {
+		return testinghelpers.NewCSR(testinghelpers.CSRHolder{
+			Name:         validCSR.Name,
+			Labels:       map[string]string{clusterv1.ClusterNameLabelKey: clusterName},
+			SignerName:   validCSR.SignerName,
+			CN:           user.SubjectPrefix + clusterName + ":spokeagent1",
+			Orgs:         []string{user.SubjectPrefix + clusterName, user.ManagedClustersGroup},
+			Username:     "test",
+			ReqBlockType: validCSR.ReqBlockType,
+		})
+	}
+
+	cases := []struct {
+		name             string
+		startingClusters []runtime.Object
+		startingCSRs     []runtime.Object
+		validateActions  func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:         "cluster name matches the policy",
+			startingCSRs: []runtime.Object{bootstrapCSR("us-prod-1")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:         "cluster name does not match the policy",
+			startingCSRs: []runtime.Object{bootstrapCSR("managedcluster1")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				expectedCondition := certificatesv1.CertificateSigningRequestCondition{
+					Type:   certificatesv1.CertificateDenied,
+					Status: corev1.ConditionTrue,
+					Reason: "ClusterNameNotAllowed",
+					Message: `cluster name "managedcluster1" does not match the cluster name policy ` +
+						`"^(?:[a-z]+-(dev|prod)-[0-9]+)$"`,
+				}
+				testingcommon.AssertActions(t, actions, "update")
+				actual := actions[0].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
+			},
+		},
+		{
+			name:         "cluster name matches the policy",
+			startingCSRs: []runtime.Object{bootstrapCSR("cluster-dev-1")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:         "cluster name partially matches the policy",
+			startingCSRs: []runtime.Object{bootstrapCSR("cluster-dev-1.evil")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+			},
+		},
+		{
+			name: "cluster not accepted yet does not match the policy",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "managedcluster1"}},
+			},
+			startingCSRs: []runtime.Object{bootstrapCSR("managedcluster1")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+			},
+		},
+		{
+			name: "accepted cluster is grandfathered",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{Name: "managedcluster1"},
+					Spec:       clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+				},
+			},
+			startingCSRs: []runtime.Object{bootstrapCSR("managedcluster1")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:         "renewal csr is not checked",
+			startingCSRs: []runtime.Object{testinghelpers.NewCSR(validCSR)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.startingCSRs...)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			csrStore := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore()
+			for _, csr := range c.startingCSRs {
+				if err := csrStore.Add(csr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(c.startingClusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.startingClusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the pattern is not anchored by the user
+			clusterNamePattern, err := CompileClusterNamePattern("[a-z]+-(dev|prod)-[0-9]+")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctrl := &csrApprovingController[*certificatesv1.CertificateSigningRequest]{
+				lister:   informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				approver: NewCSRV1Approver(kubeClient),
+				reconcilers: []Reconciler{
+					NewCSRClusterNameReconciler(
+						kubeClient,
+						clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+						clusterNamePattern,
+						eventstesting.NewTestingEventRecorder(t),
+					),
+				},
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
 func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 	invalidSignerName := "invalidsigner"
 
@@ -326,3 +537,228 @@ func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncRebootstrap(t *testing.T) {
+	bootstrapCSR := testinghelpers.NewCSR(validCSR)
+	bootstrapCSR.Spec.Username = "test"
+	keyHash := publicKeyHash(bootstrapCSR.Spec.Request)
+
+	newCluster := func(annotations map[string]string) *clusterv1.ManagedCluster {
+		return &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "managedcluster1", Annotations: annotations},
+			Spec:       clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+		}
+	}
+	previousIdentity := map[string]string{
+		AgentCommonNameAnnotation:        user.SubjectPrefix + "managedcluster1:spokeagent0",
+		AgentPublicKeyHashAnnotation:     "previous",
+		AgentIdentityTimestampAnnotation: "2023-01-01T00:00:00Z",
+	}
+	approvedCSR := func(csr *certificatesv1.CertificateSigningRequest) *certificatesv1.CertificateSigningRequest {
+		csr = csr.DeepCopy()
+		csr.CreationTimestamp = metav1.NewTime(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:   certificatesv1.CertificateApproved,
+			Status: corev1.ConditionTrue,
+		})
+		return csr
+	}
+
+	cases := []struct {
+		name                   string
+		autoApprove            bool
+		cluster                *clusterv1.ManagedCluster
+		csr                    *certificatesv1.CertificateSigningRequest
+		validateActions        func(t *testing.T, actions []clienttesting.Action)
+		validateClusterActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:    "rebootstrap csr waits for approval",
+			cluster: newCluster(previousIdentity),
+			csr:     bootstrapCSR,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+			validateClusterActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:        "rebootstrap csr is auto approved",
+			autoApprove: true,
+			cluster:     newCluster(previousIdentity),
+			csr:         bootstrapCSR,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+				actual := actions[0].(clienttesting.UpdateActionImpl).Object.(*certificatesv1.CertificateSigningRequest)
+				if !helpers.IsCSRInTerminalState(&actual.Status) {
+					t.Errorf("expected csr is approved, but got %v", actual.Status.Conditions)
+				}
+			},
+			validateClusterActions: testingcommon.AssertNoActions,
+		},
+		{
+			name: "bootstrap csr of the recorded identity is not a rebootstrap",
+			cluster: newCluster(map[string]string{
+				AgentCommonNameAnnotation:    validCSR.CN,
+				AgentPublicKeyHashAnnotation: keyHash,
+			}),
+			csr: bootstrapCSR,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				// approved by the bootstrap reconciler
+				testingcommon.AssertActions(t, actions, "update")
+			},
+			validateClusterActions: testingcommon.AssertNoActions,
+		},
+		{
+			name: "bootstrap csr of the recorded identity with a new key is not a rebootstrap",
+			cluster: newCluster(map[string]string{
+				AgentCommonNameAnnotation:    validCSR.CN,
+				AgentPublicKeyHashAnnotation: "previous",
+			}),
+			csr: bootstrapCSR,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				// approved by the bootstrap reconciler instead of waiting for the rebootstrap approval
+				testingcommon.AssertActions(t, actions, "update")
+			},
+			validateClusterActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:        "renewal csr of the previous identity is denied",
+			autoApprove: true,
+			cluster:     newCluster(previousIdentity),
+			csr:         testinghelpers.NewCSR(validCSR),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+				actual := actions[0].(clienttesting.UpdateActionImpl).Object.(*certificatesv1.CertificateSigningRequest)
+				if actual.Status.Conditions[0].Type != certificatesv1.CertificateDenied ||
+					actual.Status.Conditions[0].Reason != "AgentIdentitySuperseded" {
+					t.Errorf("expected csr is denied, but got %v", actual.Status.Conditions)
+				}
+			},
+			validateClusterActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:    "approved rebootstrap csr invalidates the previous identity",
+			cluster: newCluster(previousIdentity),
+			csr:     approvedCSR(bootstrapCSR),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+				if actions[0].GetResource().Resource != "leases" {
+					t.Errorf("expected lease is deleted, but got %v", actions[0])
+				}
+			},
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				cluster := &clusterv1.ManagedCluster{}
+				if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, cluster); err != nil {
+					t.Fatal(err)
+				}
+				if cluster.Annotations[AgentCommonNameAnnotation] != validCSR.CN ||
+					cluster.Annotations[AgentPublicKeyHashAnnotation] != keyHash {
+					t.Errorf("expected identity is rotated, but got %v", cluster.Annotations)
+				}
+				if _, ok := cluster.Annotations[RebootstrapTimestampAnnotation]; !ok {
+					t.Errorf("expected rebootstrap is recorded, but got %v", cluster.Annotations)
+				}
+			},
+		},
+		{
+			name: "approved bootstrap csr of the recorded identity keeps the lease",
+			cluster: newCluster(map[string]string{
+				AgentCommonNameAnnotation:        validCSR.CN,
+				AgentPublicKeyHashAnnotation:     "previous",
+				AgentIdentityTimestampAnnotation: "2023-01-01T00:00:00Z",
+			}),
+			csr: approvedCSR(bootstrapCSR),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				cluster := &clusterv1.ManagedCluster{}
+				if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, cluster); err != nil {
+					t.Fatal(err)
+				}
+				if cluster.Annotations[AgentPublicKeyHashAnnotation] != keyHash {
+					t.Errorf("expected key is recorded, but got %v", cluster.Annotations)
+				}
+				if _, ok := cluster.Annotations[RebootstrapTimestampAnnotation]; ok {
+					t.Errorf("expected no rebootstrap, but got %v", cluster.Annotations)
+				}
+			},
+		},
+		{
+			name:    "approved csr records the identity",
+			cluster: newCluster(nil),
+			csr:     approvedCSR(testinghelpers.NewCSR(validCSR)),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				cluster := &clusterv1.ManagedCluster{}
+				if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, cluster); err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := cluster.Annotations[RebootstrapTimestampAnnotation]; ok {
+					t.Errorf("expected no rebootstrap, but got %v", cluster.Annotations)
+				}
+			},
+		},
+		{
+			name: "approved csr older than the recorded identity is ignored",
+			cluster: newCluster(map[string]string{
+				AgentCommonNameAnnotation:        validCSR.CN,
+				AgentPublicKeyHashAnnotation:     "previous",
+				AgentIdentityTimestampAnnotation: "2024-01-01T00:00:00Z",
+			}),
+			csr: approvedCSR(bootstrapCSR),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+			validateClusterActions: testingcommon.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.csr)
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+					}, nil
+				},
+			)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			if err := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(c.csr); err != nil {
+				t.Fatal(err)
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+			clusterLister := clusterInformerFactory.Cluster().V1().ManagedClusters().Lister()
+
+			recorder := eventstesting.NewTestingEventRecorder(t)
+			ctrl := &csrApprovingController[*certificatesv1.CertificateSigningRequest]{
+				lister:   informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				approver: NewCSRV1Approver(kubeClient),
+				reconcilers: []Reconciler{
+					NewCSRRebootstrapReconciler(kubeClient, clusterClient, clusterLister, c.autoApprove, recorder),
+					NewCSRBootstrapReconciler(kubeClient, clusterClient, clusterLister, []string{"test"}, nil, recorder),
+				},
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+			c.validateClusterActions(t, clusterClient.Actions())
+		})
+	}
+}
@@ -2,10 +2,13 @@ package csr
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	authorizationv1 "k8s.io/api/authorization/v1"
@@ -13,6 +16,7 @@ import (
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
@@ -22,9 +26,32 @@ import (
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	"open-cluster-management.io/ocm/pkg/registration/hub/user"
 )
 
+const (
+	// AgentCommonNameAnnotation is set on the managed cluster by the hub with the common name of the agent
+	// identity in the csr approved the last time.
+	// TODO move this to the api repo
+	AgentCommonNameAnnotation = "cluster.open-cluster-management.io/agent-common-name"
+	// AgentPublicKeyHashAnnotation is the hash of the public key in the csr approved the last time.
+	// TODO move this to the api repo
+	AgentPublicKeyHashAnnotation = "cluster.open-cluster-management.io/agent-public-key-hash"
+	// AgentIdentityTimestampAnnotation is the creation time of the csr approved the last time, the csrs
+	// created before it are not recorded.
+	// TODO move this to the api repo
+	AgentIdentityTimestampAnnotation = "cluster.open-cluster-management.io/agent-identity-timestamp"
+	// RebootstrapTimestampAnnotation is set on the managed cluster by the hub with the time the cluster is
+	// rebootstrapped with a new agent identity.
+	// TODO move this to the api repo
+	RebootstrapTimestampAnnotation = "cluster.open-cluster-management.io/rebootstrap-timestamp"
+
+	// clusterLeaseName is the name of the lease of the cluster updated by the agent.
+	clusterLeaseName = "managed-cluster-lease"
+)
+
 type reconcileState int64
 
 const (
@@ -33,20 +60,31 @@ const (
 )
 
 type csrInfo struct {
-	name       string
-	labels     map[string]string
-	signerName string
-	username   string
-	uid        string
-	groups     []string
-	extra      map[string]authorizationv1.ExtraValue
-	request    []byte
+	name              string
+	labels            map[string]string
+	creationTimestamp metav1.Time
+	signerName        string
+	username          string
+	uid               string
+	groups            []string
+	extra             map[string]authorizationv1.ExtraValue
+	request           []byte
+	approved          bool
 }
 
 type approveCSRFunc func(kubernetes.Interface) error
 
+// denyCSRFunc denies the csr with the reason and message.
+type denyCSRFunc func(kubeClient kubernetes.Interface, reason, message string) error
+
 type Reconciler interface {
-	Reconcile(context.Context, csrInfo, approveCSRFunc) (reconcileState, error)
+	Reconcile(context.Context, csrInfo, approveCSRFunc, denyCSRFunc) (reconcileState, error)
+}
+
+// approvedReconciler is implemented by the reconcilers which also reconcile the approved csrs, no matter the
+// csrs are approved by the hub or by the hub admin.
+type approvedReconciler interface {
+	ReconcileApproved(context.Context, csrInfo) error
 }
 
 type csrRenewalReconciler struct {
@@ -61,7 +99,7 @@ func NewCSRRenewalReconciler(kubeClient kubernetes.Interface, recorder events.Re
 	}
 }
 
-func (r *csrRenewalReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+func (r *csrRenewalReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc, _ denyCSRFunc) (reconcileState, error) {
 	// Check whether current csr is a valid spoker cluster csr.
 	valid, _, commonName := validateCSR(csr)
 	if !valid {
@@ -97,6 +135,9 @@ type csrBootstrapReconciler struct {
 	clusterClient clusterclientset.Interface
 	clusterLister clusterv1listers.ManagedClusterLister
 	approvalUsers sets.Set[string]
+	// selector restricts the auto approval to the clusters whose hub controlled labels match it, nil matches all
+	// of the clusters.
+	selector      labels.Selector
 	eventRecorder events.Recorder
 }
 
@@ -104,17 +145,19 @@ func NewCSRBootstrapReconciler(kubeClient kubernetes.Interface,
 	clusterClient clusterclientset.Interface,
 	clusterLister clusterv1listers.ManagedClusterLister,
 	approvalUsers []string,
+	selector labels.Selector,
 	recorder events.Recorder) Reconciler {
 	return &csrBootstrapReconciler{
 		kubeClient:    kubeClient,
 		clusterClient: clusterClient,
 		clusterLister: clusterLister,
 		approvalUsers: sets.New(approvalUsers...),
+		selector:      selector,
 		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
 	}
 }
 
-func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc, _ denyCSRFunc) (reconcileState, error) {
 	// Check whether current csr is a valid spoker cluster csr.
 	valid, clusterName, _ := validateCSR(csr)
 	if !valid {
@@ -127,7 +170,7 @@ func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, app
 		return reconcileContinue, nil
 	}
 
-	err := b.accpetCluster(ctx, clusterName)
+	accepted, err := b.accpetCluster(ctx, clusterName)
 	if errors.IsNotFound(err) {
 		// Current spoke cluster not found, could have been deleted, do nothing.
 		return reconcileStop, nil
@@ -135,6 +178,11 @@ func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, app
 	if err != nil {
 		return reconcileContinue, err
 	}
+	if !accepted {
+		// the cluster does not match the auto approval selector, the csr is left to the hub admin.
+		klog.V(4).Infof("Managed cluster %q does not match the auto approval selector", clusterName)
+		return reconcileContinue, nil
+	}
 
 	if err := approveCSR(b.kubeClient); err != nil {
 		return reconcileContinue, err
@@ -144,20 +192,238 @@ func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, app
 	return reconcileStop, nil
 }
 
-func (b *csrBootstrapReconciler) accpetCluster(ctx context.Context, managedClusterName string) error {
+// accpetCluster accepts the cluster if it matches the auto approval selector, it returns false if the cluster
+// does not match the selector.
+func (b *csrBootstrapReconciler) accpetCluster(ctx context.Context, managedClusterName string) (bool, error) {
 	managedCluster, err := b.clusterLister.Get(managedClusterName)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if managedCluster.Spec.HubAcceptsClient {
-		return nil
+		return true, nil
+	}
+
+	if b.selector != nil && !b.selector.Matches(helpers.ClusterAutoApprovalLabels(managedCluster)) {
+		return false, nil
 	}
 
 	patch := []byte("{\"spec\": {\"hubAcceptsClient\": true}}")
 	_, err = b.clusterClient.ClusterV1().ManagedClusters().Patch(
 		ctx, managedCluster.Name, types.MergePatchType, patch, metav1.PatchOptions{})
-	return err
+	return err == nil, err
+}
+
+// csrClusterNameReconciler denies the csrs of the clusters at the initial registration if the cluster name does
+// not match the cluster name policy. The clusters which are already accepted by the hub are not affected.
+type csrClusterNameReconciler struct {
+	kubeClient    kubernetes.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	pattern       *regexp.Regexp
+	eventRecorder events.Recorder
+}
+
+// CompileClusterNamePattern compiles the cluster name policy anchored at both ends, so the whole cluster name must
+// match the pattern rather than a part of it.
+func CompileClusterNamePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+func NewCSRClusterNameReconciler(kubeClient kubernetes.Interface,
+	clusterLister clusterv1listers.ManagedClusterLister,
+	pattern *regexp.Regexp,
+	recorder events.Recorder) Reconciler {
+	return &csrClusterNameReconciler{
+		kubeClient:    kubeClient,
+		clusterLister: clusterLister,
+		pattern:       pattern,
+		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
+	}
+}
+
+func (n *csrClusterNameReconciler) Reconcile(ctx context.Context, csr csrInfo, _ approveCSRFunc, denyCSR denyCSRFunc) (reconcileState, error) {
+	// Check whether current csr is a valid spoker cluster csr.
+	valid, clusterName, commonName := validateCSR(csr)
+	if !valid {
+		klog.V(4).Infof("CSR %q was not recognized", csr.name)
+		return reconcileStop, nil
+	}
+
+	// The renewal csr is not the initial registration.
+	if csr.username == commonName {
+		return reconcileContinue, nil
+	}
+
+	// The clusters accepted by the hub are grandfathered.
+	managedCluster, err := n.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return reconcileContinue, err
+	case managedCluster.Spec.HubAcceptsClient:
+		return reconcileContinue, nil
+	}
+
+	if n.pattern.MatchString(clusterName) {
+		return reconcileContinue, nil
+	}
+
+	message := fmt.Sprintf("cluster name %q does not match the cluster name policy %q", clusterName, n.pattern.String())
+	if err := denyCSR(n.kubeClient, "ClusterNameNotAllowed", message); err != nil {
+		return reconcileContinue, err
+	}
+
+	n.eventRecorder.Warningf("ManagedClusterCSRDenied", "spoke cluster csr %q is denied: %s", csr.name, message)
+	return reconcileStop, nil
+}
+
+// csrRebootstrapReconciler handles the rebootstrap of the accepted clusters, e.g. the agent is reinstalled and
+// registers the cluster with the same name again. The identity of the agent approved the last time is recorded on
+// the managed cluster, and a bootstrap csr of the cluster with a different public key is a rebootstrap:
+//   - it is approved if the rebootstrap auto approval is enabled, otherwise it is not approved by the other
+//     reconcilers and waits for the approval of the hub admin,
+//   - once it is approved, the lease of the previous agent is removed and the recorded identity is rotated,
+//   - the renewal csrs of the previous agent identity are denied afterwards.
+type csrRebootstrapReconciler struct {
+	kubeClient    kubernetes.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	patcher       patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
+	autoApprove   bool
+	eventRecorder events.Recorder
+}
+
+func NewCSRRebootstrapReconciler(kubeClient kubernetes.Interface,
+	clusterClient clusterclientset.Interface,
+	clusterLister clusterv1listers.ManagedClusterLister,
+	autoApprove bool,
+	recorder events.Recorder) Reconciler {
+	return &csrRebootstrapReconciler{
+		kubeClient:    kubeClient,
+		clusterLister: clusterLister,
+		patcher: patcher.NewPatcher[
+			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		autoApprove:   autoApprove,
+		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
+	}
+}
+
+func (r *csrRebootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc, denyCSR denyCSRFunc) (reconcileState, error) {
+	// Check whether current csr is a valid spoker cluster csr.
+	valid, clusterName, commonName := validateCSR(csr)
+	if !valid {
+		klog.V(4).Infof("CSR %q was not recognized", csr.name)
+		return reconcileStop, nil
+	}
+
+	managedCluster, err := r.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		return reconcileContinue, nil
+	case err != nil:
+		return reconcileContinue, err
+	}
+
+	// The identity is recorded once a csr of the cluster is approved.
+	recordedCommonName, ok := managedCluster.Annotations[AgentCommonNameAnnotation]
+	if !ok || !managedCluster.Spec.HubAcceptsClient {
+		return reconcileContinue, nil
+	}
+
+	if csr.username == commonName {
+		if commonName == recordedCommonName {
+			return reconcileContinue, nil
+		}
+
+		message := fmt.Sprintf("agent identity %q is superseded by %q after the cluster is rebootstrapped",
+			commonName, recordedCommonName)
+		if err := denyCSR(r.kubeClient, "AgentIdentitySuperseded", message); err != nil {
+			return reconcileContinue, err
+		}
+		r.eventRecorder.Warningf("ManagedClusterCSRDenied", "spoke cluster csr %q is denied: %s", csr.name, message)
+		return reconcileStop, nil
+	}
+
+	// A bootstrap csr of the recorded identity is requested by the same agent, e.g. its hub kubeconfig expired,
+	// only a new identity means the cluster is rebootstrapped.
+	if commonName == recordedCommonName {
+		return reconcileContinue, nil
+	}
+
+	if !r.autoApprove {
+		r.eventRecorder.Eventf("ManagedClusterRebootstrapPending",
+			"spoke cluster %q is rebootstrapped, csr %q is waiting for approval", clusterName, csr.name)
+		return reconcileStop, nil
+	}
+
+	if err := approveCSR(r.kubeClient); err != nil {
+		return reconcileContinue, err
+	}
+
+	r.eventRecorder.Eventf("ManagedClusterRebootstrapAutoApproved",
+		"spoke cluster %q is rebootstrapped, csr %q is auto approved", clusterName, csr.name)
+	return reconcileStop, nil
+}
+
+// ReconcileApproved records the identity of the agent in the approved csr on the managed cluster, and invalidates
+// the previous identity if the approved csr is a rebootstrap.
+func (r *csrRebootstrapReconciler) ReconcileApproved(ctx context.Context, csr csrInfo) error {
+	valid, clusterName, commonName := validateCSR(csr)
+	if !valid {
+		return nil
+	}
+
+	managedCluster, err := r.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	keyHash := publicKeyHash(csr.request)
+	if keyHash == managedCluster.Annotations[AgentPublicKeyHashAnnotation] {
+		return nil
+	}
+
+	// The csrs approved before the recorded one are ignored, e.g. the csrs of the previous agent are listed after
+	// the hub restarts.
+	recordedTime, err := time.Parse(time.RFC3339, managedCluster.Annotations[AgentIdentityTimestampAnnotation])
+	if err == nil && csr.creationTimestamp.Time.Before(recordedTime) {
+		return nil
+	}
+
+	recordedCommonName, recorded := managedCluster.Annotations[AgentCommonNameAnnotation]
+	rebootstrapped := recorded && csr.username != commonName && commonName != recordedCommonName
+
+	newManagedCluster := managedCluster.DeepCopy()
+	if newManagedCluster.Annotations == nil {
+		newManagedCluster.Annotations = map[string]string{}
+	}
+	newManagedCluster.Annotations[AgentCommonNameAnnotation] = commonName
+	newManagedCluster.Annotations[AgentPublicKeyHashAnnotation] = keyHash
+	newManagedCluster.Annotations[AgentIdentityTimestampAnnotation] = csr.creationTimestamp.UTC().Format(time.RFC3339)
+
+	if rebootstrapped {
+		// remove the lease of the previous agent, it is created again by the lease controller.
+		err := r.kubeClient.CoordinationV1().Leases(clusterName).Delete(ctx, clusterLeaseName, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		newManagedCluster.Annotations[RebootstrapTimestampAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if _, err := r.patcher.PatchLabelAnnotations(
+		ctx, newManagedCluster, newManagedCluster.ObjectMeta, managedCluster.ObjectMeta); err != nil {
+		return err
+	}
+
+	if rebootstrapped {
+		r.eventRecorder.Eventf("ManagedClusterRebootstrapped",
+			"spoke cluster %q is rebootstrapped with agent identity %q, the previous identity %q is invalidated",
+			clusterName, commonName, recordedCommonName)
+	}
+	return nil
 }
 
 // To validate a managed cluster csr, we check
@@ -205,6 +471,23 @@ func validateCSR(csr csrInfo) (bool, string, string) {
 	return true, spokeClusterName, x509cr.Subject.CommonName
 }
 
+// publicKeyHash returns the hash of the public key in the csr request, or an empty string if the request is invalid.
+func publicKeyHash(request []byte) string {
+	block, _ := pem.Decode(request)
+	if block == nil {
+		return ""
+	}
+	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(x509cr.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(publicKey))
+}
+
 // Using SubjectAccessReview API to check whether a spoke agent has been authorized to renew its csr,
 // a spoke agent is authorized after its spoke cluster is accepted by hub cluster admin.
 func authorize(ctx context.Context, kubeClient kubernetes.Interface, csr csrInfo) (bool, error) {
@@ -238,29 +521,45 @@ func newCSRInfo(csr any) csrInfo {
 		for k, v := range v.Spec.Extra {
 			extra[k] = authorizationv1.ExtraValue(v)
 		}
+		approved := false
+		for _, condition := range v.Status.Conditions {
+			if condition.Type == certificatesv1.CertificateApproved {
+				approved = true
+			}
+		}
 		return csrInfo{
-			name:       v.Name,
-			labels:     v.Labels,
-			signerName: v.Spec.SignerName,
-			username:   v.Spec.Username,
-			uid:        v.Spec.UID,
-			groups:     v.Spec.Groups,
-			extra:      extra,
-			request:    v.Spec.Request,
+			name:              v.Name,
+			labels:            v.Labels,
+			creationTimestamp: v.CreationTimestamp,
+			signerName:        v.Spec.SignerName,
+			username:          v.Spec.Username,
+			uid:               v.Spec.UID,
+			groups:            v.Spec.Groups,
+			extra:             extra,
+			request:           v.Spec.Request,
+			approved:          approved,
 		}
 	case *certificatesv1beta1.CertificateSigningRequest:
 		for k, v := range v.Spec.Extra {
 			extra[k] = authorizationv1.ExtraValue(v)
 		}
+		approved := false
+		for _, condition := range v.Status.Conditions {
+			if condition.Type == certificatesv1beta1.CertificateApproved {
+				approved = true
+			}
+		}
 		return csrInfo{
-			name:       v.Name,
-			labels:     v.Labels,
-			signerName: *v.Spec.SignerName,
-			username:   v.Spec.Username,
-			uid:        v.Spec.UID,
-			groups:     v.Spec.Groups,
-			extra:      extra,
-			request:    v.Spec.Request,
+			name:              v.Name,
+			labels:            v.Labels,
+			creationTimestamp: v.CreationTimestamp,
+			signerName:        *v.Spec.SignerName,
+			username:          v.Spec.Username,
+			uid:               v.Spec.UID,
+			groups:            v.Spec.Groups,
+			extra:             extra,
+			request:           v.Spec.Request,
+			approved:          approved,
 		}
 	default:
 		klog.Errorf("Unsupported type %T", v)
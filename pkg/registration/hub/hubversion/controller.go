@@ -0,0 +1,117 @@
+package hubversion
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// hubVersionController propagates the bundle version of the hub components to the managed clusters, so the
+// klusterlet operators are able to hold the agents from upgrading to a newer bundle version than the hub.
+type hubVersionController struct {
+	patcher         patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister   listerv1.ManagedClusterLister
+	configMapLister corev1listers.ConfigMapLister
+	namespace       string
+	eventRecorder   events.Recorder
+}
+
+// NewHubVersionController creates a new hub version controller
+func NewHubVersionController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	namespace string,
+	recorder events.Recorder) factory.Controller {
+	c := &hubVersionController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister:   clusterInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		namespace:       namespace,
+		eventRecorder:   recorder.WithComponentSuffix("hub-version-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			c.clusterQueueKeysFunc,
+			func(obj interface{}) bool {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetNamespace() == namespace && accessor.GetName() == helpers.HubVersionConfigMap
+			},
+			configMapInformer.Informer()).
+		WithSync(c.sync).
+		ToController("HubVersionController", recorder)
+}
+
+// clusterQueueKeysFunc enqueues all the managed clusters once the hub version configmap is changed.
+func (c *hubVersionController) clusterQueueKeysFunc(_ runtime.Object) []string {
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		keys = append(keys, cluster.Name)
+	}
+	return keys
+}
+
+func (c *hubVersionController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling hub bundle version of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	configMap, err := c.configMapLister.ConfigMaps(c.namespace).Get(helpers.HubVersionConfigMap)
+	if errors.IsNotFound(err) {
+		// the hub components are not upgraded yet, keep the last published version.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	bundleVersion := configMap.Data[helpers.HubBundleVersionKey]
+	if len(bundleVersion) == 0 {
+		return nil
+	}
+
+	newManagedCluster := managedCluster.DeepCopy()
+	if newManagedCluster.Annotations == nil {
+		newManagedCluster.Annotations = map[string]string{}
+	}
+	newManagedCluster.Annotations[helpers.HubBundleVersionAnnotation] = bundleVersion
+
+	_, err = c.patcher.PatchLabelAnnotations(ctx, newManagedCluster, newManagedCluster.ObjectMeta, managedCluster.ObjectMeta)
+	return err
+}
@@ -0,0 +1,126 @@
+package hubversion
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+const testNamespace = "open-cluster-management-hub"
+
+func newManagedCluster(bundleVersion string) *v1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	if len(bundleVersion) > 0 {
+		cluster.Annotations = map[string]string{helpers.HubBundleVersionAnnotation: bundleVersion}
+	}
+	return cluster
+}
+
+func newHubVersionConfigMap(bundleVersion string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: helpers.HubVersionConfigMap, Namespace: testNamespace},
+		Data:       map[string]string{helpers.HubBundleVersionKey: bundleVersion},
+	}
+}
+
+func TestSyncHubVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		configMaps      []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:       "sync a deleted spoke cluster",
+			configMaps: []runtime.Object{newHubVersionConfigMap("v0.13.0")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "hub version is not published",
+			clusters: []runtime.Object{newManagedCluster("v0.12.0")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:       "hub version is propagated",
+			clusters:   []runtime.Object{newManagedCluster("v0.12.0")},
+			configMaps: []runtime.Object{newHubVersionConfigMap("v0.13.0")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				managedCluster := &v1.ManagedCluster{}
+				if err := json.Unmarshal(patch, managedCluster); err != nil {
+					t.Fatal(err)
+				}
+				if managedCluster.Annotations[helpers.HubBundleVersionAnnotation] != "v0.13.0" {
+					t.Errorf("expected hub bundle version v0.13.0, but got %v", managedCluster.Annotations)
+				}
+			},
+		},
+		{
+			name:       "hub version is up to date",
+			clusters:   []runtime.Object{newManagedCluster("v0.13.0")},
+			configMaps: []runtime.Object{newHubVersionConfigMap("v0.13.0")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.configMaps...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			configMapStore := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore()
+			for _, configMap := range c.configMaps {
+				if err := configMapStore.Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &hubVersionController{
+				patcher: patcher.NewPatcher[
+					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				configMapLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				namespace:       testNamespace,
+				eventRecorder:   eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testingcommon.AssertError(t, syncErr, "")
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
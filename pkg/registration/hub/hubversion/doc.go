@@ -0,0 +1,3 @@
+// package hubversion contains the hub-side controller propagating the bundle version of the hub components
+// to managed clusters
+package hubversion
@@ -0,0 +1,439 @@
+package importmanifest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	rbacv1informers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/pointer"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+)
+
+const (
+	// GenerateImportManifestsAnnotation is the annotation on a managed cluster to generate its import manifests
+	// into the secret ImportManifestsSecret in the cluster namespace when it is "true". The secret is deleted
+	// once the annotation is removed.
+	// TODO move this to the api repo
+	GenerateImportManifestsAnnotation = "cluster.open-cluster-management.io/generate-import-manifests"
+
+	// ImportManifestsSecret is the secret with the import manifests in the cluster namespace.
+	ImportManifestsSecret = "open-cluster-management-import"
+
+	// ImportManifestsLabel is the label of the secrets with the import manifests. An existing secret without the
+	// label is not generated by the controller and is never updated or deleted.
+	ImportManifestsLabel = "cluster.open-cluster-management.io/import-manifests"
+
+	// ImportClusterRole is the clusterrole allowing to manage the ImportManifestsSecret, it is bound to the
+	// controller in the namespaces of the clusters with the GenerateImportManifestsAnnotation only, by the
+	// rolebinding ImportRoleBinding.
+	ImportClusterRole = "open-cluster-management:managedcluster:import"
+	ImportRoleBinding = "open-cluster-management:managedcluster:import"
+
+	// ImportConfigHashAnnotation is the annotation on the import manifests secret with the hash of the config
+	// the manifests are rendered with, the manifests are regenerated once the config is changed.
+	ImportConfigHashAnnotation = "cluster.open-cluster-management.io/import-config-hash"
+
+	// ImportTokenExpirationAnnotation is the annotation on the import manifests secret with the expiration time
+	// of the bootstrap token in RFC3339, the manifests are regenerated before the token expires.
+	ImportTokenExpirationAnnotation = "cluster.open-cluster-management.io/import-token-expiration"
+
+	// ImportCRDsKey and ImportManifestsKey are the keys of the import manifests secret. The crds should be
+	// applied on the managed cluster before the rest of the manifests.
+	ImportCRDsKey      = "crds.yaml"
+	ImportManifestsKey = "import.yaml"
+
+	// ClusterInfoNamespace is the namespace of the cluster-info configmap published by kubeadm with a
+	// kubeconfig containing the endpoint and the CA bundle of the hub kube-apiserver, and of the
+	// kube-root-ca.crt configmap published on all of the clusters with the CA bundle of the kube-apiserver.
+	ClusterInfoNamespace = "kube-public"
+	ClusterInfoConfigMap = "cluster-info"
+	RootCAConfigMap      = "kube-root-ca.crt"
+)
+
+// Options configures the import manifests of the managed clusters.
+type Options struct {
+	// HubAPIServer overrides the endpoint of the hub kube-apiserver in the cluster-info configmap.
+	HubAPIServer string
+	// HubCABundleFile is the file with the CA bundle of the hub kube-apiserver. If it is empty, the CA bundle in
+	// the cluster-info configmap is used, and then the one in the kube-root-ca.crt configmap.
+	HubCABundleFile string
+	// BootstrapServiceAccountNamespace and BootstrapServiceAccountName is the service account on the hub the
+	// bootstrap tokens are requested for, it should be allowed to create the registration csrs.
+	BootstrapServiceAccountNamespace string
+	BootstrapServiceAccountName      string
+	// ControllerServiceAccountNamespace and ControllerServiceAccountName is the service account of the controller,
+	// which is bound to the ImportClusterRole in the cluster namespaces.
+	ControllerServiceAccountNamespace string
+	ControllerServiceAccountName      string
+	// TokenExpiration is the expiration of the bootstrap tokens.
+	TokenExpiration time.Duration
+
+	// OperatorImage, RegistrationImage and WorkImage are the images in the import manifests, the manifests are
+	// not generated until all of them are set.
+	OperatorImage     string
+	RegistrationImage string
+	WorkImage         string
+}
+
+// importManifestController generates the import manifests of the managed clusters with the
+// GenerateImportManifestsAnnotation, including the crds, the klusterlet operator, the klusterlet and the bootstrap
+// kubeconfig with a bound token of the bootstrap service account.
+type importManifestController struct {
+	kubeClient        kubernetes.Interface
+	clusterLister     listerv1.ManagedClusterLister
+	roleBindingLister rbacv1listers.RoleBindingLister
+	configMapLister   corev1listers.ConfigMapLister
+	options           Options
+	clock             clock.Clock
+	eventRecorder     events.Recorder
+}
+
+// NewImportManifestController creates a new import manifest controller. The rolebinding informer is expected to
+// be filtered by the ImportRoleBinding, and the configmap informer by the ClusterInfoNamespace.
+func NewImportManifestController(
+	kubeClient kubernetes.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	roleBindingInformer rbacv1informers.RoleBindingInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	options Options,
+	recorder events.Recorder) factory.Controller {
+	c := &importManifestController{
+		kubeClient:        kubeClient,
+		clusterLister:     clusterInformer.Lister(),
+		roleBindingLister: roleBindingInformer.Lister(),
+		configMapLister:   configMapInformer.Lister(),
+		options:           options,
+		clock:             clock.RealClock{},
+		eventRecorder:     recorder.WithComponentSuffix("import-manifest-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				// the rolebinding is in the cluster namespace
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetNamespace()
+			},
+			func(obj interface{}) bool {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName() == ImportRoleBinding
+			},
+			roleBindingInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			c.clusterQueueKeysFunc,
+			func(obj interface{}) bool {
+				accessor, _ := meta.Accessor(obj)
+				if accessor.GetNamespace() != ClusterInfoNamespace {
+					return false
+				}
+				return accessor.GetName() == ClusterInfoConfigMap || accessor.GetName() == RootCAConfigMap
+			},
+			configMapInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ImportManifestController", recorder)
+}
+
+// clusterQueueKeysFunc enqueues the managed clusters with the GenerateImportManifestsAnnotation once the
+// cluster-info or kube-root-ca.crt configmap is changed, e.g. the hub CA bundle is rotated.
+func (c *importManifestController) clusterQueueKeysFunc(_ runtime.Object) []string {
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	for _, cluster := range clusters {
+		if cluster.Annotations[GenerateImportManifestsAnnotation] == "true" {
+			keys = append(keys, cluster.Name)
+		}
+	}
+	return keys
+}
+
+func (c *importManifestController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling import manifests of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// the secret and the rolebinding are deleted with the cluster namespace
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	if managedCluster.Annotations[GenerateImportManifestsAnnotation] != "true" {
+		return c.cleanUp(ctx, managedClusterName)
+	}
+
+	if len(c.options.OperatorImage) == 0 || len(c.options.RegistrationImage) == 0 || len(c.options.WorkImage) == 0 {
+		c.eventRecorder.Warningf("ImportManifestsNotGenerated",
+			"The import manifests of managed cluster %s are not generated, the images are not configured",
+			managedClusterName)
+		return nil
+	}
+
+	hub, err := c.hubConfig()
+	if err != nil {
+		return err
+	}
+	if len(hub.Server) == 0 || len(hub.CABundle) == 0 {
+		// the cluster is requeued once the cluster-info or kube-root-ca.crt configmap is published
+		klog.Warningf("Unable to generate the import manifests of ManagedCluster %s, the hub endpoint or CA "+
+			"bundle is unknown", managedClusterName)
+		return nil
+	}
+
+	// the controller is allowed to manage the secret in the cluster namespace by the rolebinding
+	if err := c.applyRoleBinding(ctx, managedClusterName); err != nil {
+		return err
+	}
+
+	secret, err := c.kubeClient.CoreV1().Secrets(managedClusterName).Get(ctx, ImportManifestsSecret, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		secret = nil
+	case err != nil:
+		return err
+	case secret.Labels[ImportManifestsLabel] != "true":
+		c.eventRecorder.Warningf("ImportManifestsNotGenerated",
+			"The import manifests of managed cluster %s are not generated, the secret %s/%s is not created by "+
+				"the hub, remove it to generate the import manifests", managedClusterName, managedClusterName,
+			ImportManifestsSecret)
+		return nil
+	}
+
+	config := newImportConfig(managedClusterName, hub, c.options)
+	hash, err := config.hash()
+	if err != nil {
+		return err
+	}
+	if secret != nil && secret.Annotations[ImportConfigHashAnnotation] == hash {
+		if refreshAfter := c.refreshAfter(secret); refreshAfter > 0 {
+			syncCtx.Queue().AddAfter(managedClusterName, refreshAfter)
+			return nil
+		}
+	}
+
+	tokenRequest, err := c.kubeClient.CoreV1().ServiceAccounts(c.options.BootstrapServiceAccountNamespace).CreateToken(
+		ctx, c.options.BootstrapServiceAccountName, &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{
+				ExpirationSeconds: pointer.Int64(int64(c.options.TokenExpiration.Seconds())),
+			},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to request the bootstrap token of service account %s/%s: %v",
+			c.options.BootstrapServiceAccountNamespace, c.options.BootstrapServiceAccountName, err)
+	}
+	kubeconfig, err := buildBootstrapKubeconfig(hub, tokenRequest.Status.Token)
+	if err != nil {
+		return err
+	}
+	config.BootstrapKubeconfig = base64.StdEncoding.EncodeToString(kubeconfig)
+
+	crds, objects, err := renderImportManifests(config)
+	if err != nil {
+		return err
+	}
+
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ImportManifestsSecret,
+			Namespace: managedClusterName,
+			Labels:    map[string]string{ImportManifestsLabel: "true"},
+			Annotations: map[string]string{
+				ImportConfigHashAnnotation:      hash,
+				ImportTokenExpirationAnnotation: tokenRequest.Status.ExpirationTimestamp.UTC().Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			ImportCRDsKey:      crds,
+			ImportManifestsKey: objects,
+		},
+	}
+	if secret == nil {
+		_, err = c.kubeClient.CoreV1().Secrets(managedClusterName).Create(ctx, required, metav1.CreateOptions{})
+	} else {
+		required.ResourceVersion = secret.ResourceVersion
+		_, err = c.kubeClient.CoreV1().Secrets(managedClusterName).Update(ctx, required, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	c.eventRecorder.Eventf("ImportManifestsGenerated",
+		"The import manifests of managed cluster %s are generated in secret %s/%s",
+		managedClusterName, managedClusterName, ImportManifestsSecret)
+	syncCtx.Queue().AddAfter(managedClusterName, c.refreshAfter(required))
+	return nil
+}
+
+// cleanUp deletes the import manifests secret generated by the controller and then the rolebinding in the cluster
+// namespace. There is nothing to clean up if the rolebinding does not exist, since the controller is not allowed
+// to access the secret without it.
+func (c *importManifestController) cleanUp(ctx context.Context, managedClusterName string) error {
+	_, err := c.roleBindingLister.RoleBindings(managedClusterName).Get(ImportRoleBinding)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	secret, err := c.kubeClient.CoreV1().Secrets(managedClusterName).Get(ctx, ImportManifestsSecret, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	case secret.Labels[ImportManifestsLabel] == "true":
+		err := c.kubeClient.CoreV1().Secrets(managedClusterName).Delete(ctx, ImportManifestsSecret, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	err = c.kubeClient.RbacV1().RoleBindings(managedClusterName).Delete(ctx, ImportRoleBinding, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// applyRoleBinding binds the ImportClusterRole to the controller in the cluster namespace.
+func (c *importManifestController) applyRoleBinding(ctx context.Context, managedClusterName string) error {
+	required := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ImportRoleBinding,
+			Namespace: managedClusterName,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     ImportClusterRole,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: c.options.ControllerServiceAccountNamespace,
+			Name:      c.options.ControllerServiceAccountName,
+		}},
+	}
+
+	existing, err := c.roleBindingLister.RoleBindings(managedClusterName).Get(ImportRoleBinding)
+	switch {
+	case errors.IsNotFound(err):
+		_, err := c.kubeClient.RbacV1().RoleBindings(managedClusterName).Create(ctx, required, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	case err != nil:
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.RoleRef, required.RoleRef) &&
+		equality.Semantic.DeepEqual(existing.Subjects, required.Subjects) {
+		return nil
+	}
+	if !equality.Semantic.DeepEqual(existing.RoleRef, required.RoleRef) {
+		// the roleRef is immutable
+		err := c.kubeClient.RbacV1().RoleBindings(managedClusterName).Delete(ctx, ImportRoleBinding, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = c.kubeClient.RbacV1().RoleBindings(managedClusterName).Create(ctx, required, metav1.CreateOptions{})
+		return err
+	}
+	updated := existing.DeepCopy()
+	updated.Subjects = required.Subjects
+	_, err = c.kubeClient.RbacV1().RoleBindings(managedClusterName).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// hubConfig returns the endpoint and the CA bundle of the hub kube-apiserver. The endpoint is from the options or
+// the cluster-info configmap, and the CA bundle is from the file in the options, the cluster-info configmap or the
+// kube-root-ca.crt configmap in order, since the cluster-info configmap is only published by kubeadm. They are
+// empty if they are unknown.
+func (c *importManifestController) hubConfig() (hubConfig, error) {
+	hub := hubConfig{}
+	clusterInfo, err := c.configMapLister.ConfigMaps(ClusterInfoNamespace).Get(ClusterInfoConfigMap)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return hub, err
+	default:
+		kubeconfig, err := clientcmd.Load([]byte(clusterInfo.Data["kubeconfig"]))
+		if err != nil {
+			return hub, fmt.Errorf("invalid kubeconfig in configmap %s/%s: %v",
+				ClusterInfoNamespace, ClusterInfoConfigMap, err)
+		}
+		// the kubeconfig published by kubeadm has a single cluster
+		for _, cluster := range kubeconfig.Clusters {
+			hub.Server = cluster.Server
+			hub.CABundle = cluster.CertificateAuthorityData
+			break
+		}
+	}
+
+	if len(c.options.HubAPIServer) > 0 {
+		hub.Server = c.options.HubAPIServer
+	}
+
+	switch {
+	case len(c.options.HubCABundleFile) > 0:
+		caBundle, err := os.ReadFile(c.options.HubCABundleFile)
+		if err != nil {
+			return hub, err
+		}
+		hub.CABundle = caBundle
+	case len(hub.CABundle) == 0:
+		rootCA, err := c.configMapLister.ConfigMaps(ClusterInfoNamespace).Get(RootCAConfigMap)
+		switch {
+		case errors.IsNotFound(err):
+		case err != nil:
+			return hub, err
+		default:
+			hub.CABundle = []byte(rootCA.Data["ca.crt"])
+		}
+	}
+	return hub, nil
+}
+
+// refreshAfter returns the duration after which the import manifests should be regenerated, it is a fifth of
+// the token expiration before the token expires.
+func (c *importManifestController) refreshAfter(secret *corev1.Secret) time.Duration {
+	expiration, err := time.Parse(time.RFC3339, secret.Annotations[ImportTokenExpirationAnnotation])
+	if err != nil {
+		return 0
+	}
+	return expiration.Add(-c.options.TokenExpiration / 5).Sub(c.clock.Now())
+}
@@ -0,0 +1,343 @@
+package importmanifest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	testingclock "k8s.io/utils/clock/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+var now = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func newManagedCluster(generate bool) *v1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	if generate {
+		cluster.Annotations = map[string]string{GenerateImportManifestsAnnotation: "true"}
+	}
+	return cluster
+}
+
+func newClusterInfo(t *testing.T, server, caBundle string) *corev1.ConfigMap {
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"": {
+			Server:                   server,
+			CertificateAuthorityData: []byte(caBundle),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterInfoConfigMap, Namespace: ClusterInfoNamespace},
+		Data:       map[string]string{"kubeconfig": string(kubeconfig)},
+	}
+}
+
+func newRootCA(caBundle string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: RootCAConfigMap, Namespace: ClusterInfoNamespace},
+		Data:       map[string]string{"ca.crt": caBundle},
+	}
+}
+
+func newImportRoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: ImportRoleBinding, Namespace: testinghelpers.TestManagedClusterName},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: ImportClusterRole},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: testOptions.ControllerServiceAccountNamespace,
+			Name:      testOptions.ControllerServiceAccountName,
+		}},
+	}
+}
+
+func newImportSecret(t *testing.T, server, caBundle string, tokenExpiration time.Time) *corev1.Secret {
+	hash, err := newImportConfig(testinghelpers.TestManagedClusterName,
+		hubConfig{Server: server, CABundle: []byte(caBundle)}, testOptions).hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ImportManifestsSecret,
+			Namespace:       testinghelpers.TestManagedClusterName,
+			ResourceVersion: "1",
+			Labels:          map[string]string{ImportManifestsLabel: "true"},
+			Annotations: map[string]string{
+				ImportConfigHashAnnotation:      hash,
+				ImportTokenExpirationAnnotation: tokenExpiration.Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+func newUserSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: ImportManifestsSecret, Namespace: testinghelpers.TestManagedClusterName},
+	}
+}
+
+func TestSyncImportManifests(t *testing.T) {
+	cases := []struct {
+		name            string
+		options         func(options *Options)
+		clusters        []runtime.Object
+		configMaps      []runtime.Object
+		roleBindings    []runtime.Object
+		secrets         []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:         "sync a deleted spoke cluster",
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newImportSecret(t, "https://hub:6443", "ca", now.Add(time.Hour))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "import manifests are not requested",
+			clusters: []runtime.Object{newManagedCluster(false)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:         "import manifests are no longer requested",
+			clusters:     []runtime.Object{newManagedCluster(false)},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newImportSecret(t, "https://hub:6443", "ca", now.Add(time.Hour))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete", "delete")
+				testingcommon.AssertDelete(t, actions[1], "secrets",
+					testinghelpers.TestManagedClusterName, ImportManifestsSecret)
+				testingcommon.AssertDelete(t, actions[2], "rolebindings",
+					testinghelpers.TestManagedClusterName, ImportRoleBinding)
+			},
+		},
+		{
+			name:         "the secret not generated by the hub is not deleted",
+			clusters:     []runtime.Object{newManagedCluster(false)},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newUserSecret()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete")
+				testingcommon.AssertDelete(t, actions[1], "rolebindings",
+					testinghelpers.TestManagedClusterName, ImportRoleBinding)
+			},
+		},
+		{
+			name:     "hub endpoint is unknown",
+			clusters: []runtime.Object{newManagedCluster(true)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "images are not configured",
+			options: func(options *Options) {
+				options.OperatorImage = ""
+			},
+			clusters:   []runtime.Object{newManagedCluster(true)},
+			configMaps: []runtime.Object{newClusterInfo(t, "https://hub:6443", "ca")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:       "import manifests are generated",
+			clusters:   []runtime.Object{newManagedCluster(true)},
+			configMaps: []runtime.Object{newClusterInfo(t, "https://hub:6443", "ca")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "get", "create", "create")
+				roleBinding := actions[0].(clienttesting.CreateAction).GetObject().(*rbacv1.RoleBinding)
+				if !reflect.DeepEqual(roleBinding.Subjects, newImportRoleBinding().Subjects) ||
+					roleBinding.RoleRef.Name != ImportClusterRole {
+					t.Errorf("unexpected rolebinding %v", roleBinding)
+				}
+				if actions[2].GetSubresource() != "token" {
+					t.Errorf("expected the bootstrap token requested, but got %v", actions[2])
+				}
+				secret := actions[3].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+				assertImportSecret(t, secret, "https://hub:6443", "ca")
+			},
+		},
+		{
+			name: "import manifests are generated with the root CA bundle",
+			options: func(options *Options) {
+				options.HubAPIServer = "https://hub:6443"
+			},
+			clusters:   []runtime.Object{newManagedCluster(true)},
+			configMaps: []runtime.Object{newRootCA("root-ca")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "get", "create", "create")
+				secret := actions[3].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+				assertImportSecret(t, secret, "https://hub:6443", "root-ca")
+			},
+		},
+		{
+			name:         "import manifests are up to date",
+			clusters:     []runtime.Object{newManagedCluster(true)},
+			configMaps:   []runtime.Object{newClusterInfo(t, "https://hub:6443", "ca")},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newImportSecret(t, "https://hub:6443", "ca", now.Add(time.Hour))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:         "the secret not generated by the hub is not updated",
+			clusters:     []runtime.Object{newManagedCluster(true)},
+			configMaps:   []runtime.Object{newClusterInfo(t, "https://hub:6443", "ca")},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newUserSecret()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:         "import manifests are regenerated with the rotated hub CA bundle",
+			clusters:     []runtime.Object{newManagedCluster(true)},
+			configMaps:   []runtime.Object{newClusterInfo(t, "https://hub:6443", "new-ca")},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newImportSecret(t, "https://hub:6443", "ca", now.Add(time.Hour))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "create", "update")
+				secret := actions[2].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				assertImportSecret(t, secret, "https://hub:6443", "new-ca")
+			},
+		},
+		{
+			name:         "import manifests are regenerated with the changed hub endpoint",
+			clusters:     []runtime.Object{newManagedCluster(true)},
+			configMaps:   []runtime.Object{newClusterInfo(t, "https://new-hub:6443", "ca")},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newImportSecret(t, "https://hub:6443", "ca", now.Add(time.Hour))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "create", "update")
+				secret := actions[2].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				assertImportSecret(t, secret, "https://new-hub:6443", "ca")
+			},
+		},
+		{
+			name:         "import manifests are regenerated before the token expires",
+			clusters:     []runtime.Object{newManagedCluster(true)},
+			configMaps:   []runtime.Object{newClusterInfo(t, "https://hub:6443", "ca")},
+			roleBindings: []runtime.Object{newImportRoleBinding()},
+			secrets:      []runtime.Object{newImportSecret(t, "https://hub:6443", "ca", now.Add(10*time.Minute))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "create", "update")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			objects := append(append(c.configMaps, c.roleBindings...), c.secrets...)
+			kubeClient := kubefake.NewSimpleClientset(objects...)
+			kubeClient.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "token" {
+					return false, nil, nil
+				}
+				return true, &authenticationv1.TokenRequest{
+					Status: authenticationv1.TokenRequestStatus{
+						Token:               "token",
+						ExpirationTimestamp: metav1.NewTime(now.Add(time.Hour)),
+					},
+				}, nil
+			})
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			for _, configMap := range c.configMaps {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, roleBinding := range c.roleBindings {
+				if err := kubeInformerFactory.Rbac().V1().RoleBindings().Informer().GetStore().Add(roleBinding); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient.ClearActions()
+
+			options := testOptions
+			if c.options != nil {
+				c.options(&options)
+			}
+			ctrl := &importManifestController{
+				kubeClient:        kubeClient,
+				clusterLister:     clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				roleBindingLister: kubeInformerFactory.Rbac().V1().RoleBindings().Lister(),
+				configMapLister:   kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				options:           options,
+				clock:             testingclock.NewFakeClock(now),
+				eventRecorder:     eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testingcommon.AssertError(t, syncErr, "")
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func assertImportSecret(t *testing.T, secret *corev1.Secret, server, caBundle string) {
+	if secret.Name != ImportManifestsSecret ||
+		secret.Namespace != testinghelpers.TestManagedClusterName {
+		t.Errorf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+	}
+	if secret.Labels[ImportManifestsLabel] != "true" {
+		t.Errorf("expected the secret labeled with %s", ImportManifestsLabel)
+	}
+	expected := newImportSecret(t, server, caBundle, now.Add(time.Hour))
+	if secret.Annotations[ImportConfigHashAnnotation] != expected.Annotations[ImportConfigHashAnnotation] {
+		t.Errorf("expected the config hash of the hub %s", server)
+	}
+	if secret.Annotations[ImportTokenExpirationAnnotation] != expected.Annotations[ImportTokenExpirationAnnotation] {
+		t.Errorf("expected the token expiration %s, but got %s",
+			expected.Annotations[ImportTokenExpirationAnnotation], secret.Annotations[ImportTokenExpirationAnnotation])
+	}
+
+	objects := decodeManifests(t, secret.Data[ImportManifestsKey])
+	bootstrapSecret := convert[corev1.Secret](t, objects["Secret"])
+	bootstrapConfig, err := clientcmd.Load(bootstrapSecret.Data["kubeconfig"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := bootstrapConfig.Clusters[bootstrapConfig.Contexts[bootstrapConfig.CurrentContext].Cluster]
+	if cluster.Server != server || string(cluster.CertificateAuthorityData) != caBundle {
+		t.Errorf("expected the hub %s in the bootstrap kubeconfig, but got %s", server, cluster.Server)
+	}
+	if len(decodeManifests(t, secret.Data[ImportCRDsKey])["CustomResourceDefinition"]) != 1 {
+		t.Errorf("expected the klusterlet crd")
+	}
+}
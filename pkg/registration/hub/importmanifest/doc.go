@@ -0,0 +1,3 @@
+// package importmanifest contains the hub-side controller generating the import manifests of managed clusters,
+// which are applied on the managed clusters to install the klusterlet and bootstrap the registration
+package importmanifest
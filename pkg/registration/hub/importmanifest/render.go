@@ -0,0 +1,136 @@
+package importmanifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/assets"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"open-cluster-management.io/ocm/manifests"
+)
+
+const (
+	operatorNamespace         = "open-cluster-management"
+	operatorServiceAccount    = "klusterlet"
+	operatorClusterRole       = "klusterlet"
+	agentNamespace            = "open-cluster-management-agent"
+	bootstrapKubeconfigSecret = "bootstrap-hub-kubeconfig"
+)
+
+var (
+	// crdFiles are applied on the managed cluster before the importFiles, so the klusterlet is able to be created.
+	crdFiles = []string{
+		"klusterlet/import/0000_00_operator.open-cluster-management.io_klusterlets.crd.yaml",
+	}
+
+	importFiles = []string{
+		"klusterlet/import/namespace.yaml",
+		"klusterlet/import/operator-serviceaccount.yaml",
+		"klusterlet/import/operator-clusterrole.yaml",
+		"klusterlet/import/operator-clusterrolebinding.yaml",
+		"klusterlet/import/operator-deployment.yaml",
+		"klusterlet/import/agent-namespace.yaml",
+		"klusterlet/import/bootstrap-hub-kubeconfig.yaml",
+		"klusterlet/import/klusterlet.yaml",
+	}
+)
+
+// hubConfig is the endpoint and the CA bundle of the hub kube-apiserver the managed cluster is bootstrapped with.
+type hubConfig struct {
+	Server   string
+	CABundle []byte
+}
+
+// importConfig is the config to render the import manifests of a managed cluster.
+type importConfig struct {
+	ClusterName               string
+	OperatorNamespace         string
+	OperatorServiceAccount    string
+	OperatorClusterRole       string
+	OperatorImage             string
+	AgentNamespace            string
+	RegistrationImage         string
+	WorkImage                 string
+	BootstrapKubeconfigSecret string
+	Hub                       hubConfig
+	// BootstrapKubeconfig is the base64 encoded bootstrap kubeconfig, it is set once the token is requested.
+	BootstrapKubeconfig string
+}
+
+func newImportConfig(clusterName string, hub hubConfig, options Options) importConfig {
+	return importConfig{
+		ClusterName:               clusterName,
+		OperatorNamespace:         operatorNamespace,
+		OperatorServiceAccount:    operatorServiceAccount,
+		OperatorClusterRole:       operatorClusterRole,
+		OperatorImage:             options.OperatorImage,
+		AgentNamespace:            agentNamespace,
+		RegistrationImage:         options.RegistrationImage,
+		WorkImage:                 options.WorkImage,
+		BootstrapKubeconfigSecret: bootstrapKubeconfigSecret,
+		Hub:                       hub,
+	}
+}
+
+// hash returns the hash of the config without the bootstrap kubeconfig, the import manifests are regenerated
+// once it is changed, e.g. the hub CA bundle is rotated or the hub endpoint is changed.
+func (c importConfig) hash() (string, error) {
+	c.BootstrapKubeconfig = ""
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// buildBootstrapKubeconfig builds the bootstrap kubeconfig connecting to the hub with the token.
+func buildBootstrapKubeconfig(hub hubConfig, token string) ([]byte, error) {
+	return clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"hub": {
+			Server:                   hub.Server,
+			CertificateAuthorityData: hub.CABundle,
+		}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"bootstrap": {
+			Token: token,
+		}},
+		Contexts: map[string]*clientcmdapi.Context{"bootstrap": {
+			Cluster:  "hub",
+			AuthInfo: "bootstrap",
+		}},
+		CurrentContext: "bootstrap",
+	})
+}
+
+// renderImportManifests renders the crds and the rest of the import manifests as multi-document yamls.
+func renderImportManifests(config importConfig) ([]byte, []byte, error) {
+	crds, err := renderFiles(crdFiles, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	objects, err := renderFiles(importFiles, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return crds, objects, nil
+}
+
+func renderFiles(files []string, config importConfig) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, file := range files {
+		template, err := manifests.KlusterletImportManifestFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("---\n")
+		}
+		data := assets.MustCreateAssetFromTemplate(file, template, config).Data
+		buf.Write(bytes.TrimSpace(data))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,173 @@
+package importmanifest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+)
+
+var testOptions = Options{
+	BootstrapServiceAccountNamespace:  "open-cluster-management-hub",
+	BootstrapServiceAccountName:       "agent-registration-bootstrap",
+	ControllerServiceAccountNamespace: "open-cluster-management-hub",
+	ControllerServiceAccountName:      "registration-controller-sa",
+	TokenExpiration:                   time.Hour,
+	OperatorImage:                     "quay.io/open-cluster-management/registration-operator:v0.13.0",
+	RegistrationImage:                 "quay.io/open-cluster-management/registration:v0.13.0",
+	WorkImage:                         "quay.io/open-cluster-management/work:v0.13.0",
+}
+
+// decodeManifests decodes the multi-document yaml and indexes the objects by kind.
+func decodeManifests(t *testing.T, data []byte) map[string][]*unstructured.Unstructured {
+	objects := map[string][]*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return objects
+		}
+		if err != nil {
+			t.Fatalf("failed to decode the manifests: %v", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if len(obj.GetKind()) == 0 || len(obj.GetAPIVersion()) == 0 || len(obj.GetName()) == 0 {
+			t.Fatalf("invalid object %v", obj.Object)
+		}
+		objects[obj.GetKind()] = append(objects[obj.GetKind()], obj)
+	}
+}
+
+func convert[T any](t *testing.T, objects []*unstructured.Unstructured) *T {
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, but got %d", len(objects))
+	}
+	obj := new(T)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objects[0].Object, obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestRenderImportManifests(t *testing.T) {
+	hub := hubConfig{Server: "https://hub.example.com:6443", CABundle: []byte("ca-data")}
+	config := newImportConfig("cluster1", hub, testOptions)
+	kubeconfig, err := buildBootstrapKubeconfig(hub, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.BootstrapKubeconfig = base64.StdEncoding.EncodeToString(kubeconfig)
+
+	crds, manifests, err := renderImportManifests(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crd := convert[apiextensionsv1.CustomResourceDefinition](t, decodeManifests(t, crds)["CustomResourceDefinition"])
+	objects := decodeManifests(t, manifests)
+
+	// the klusterlet is an instance of the crd
+	klusterlet := convert[operatorapiv1.Klusterlet](t, objects["Klusterlet"])
+	if klusterlet.GroupVersionKind().Group != crd.Spec.Group || klusterlet.Kind != crd.Spec.Names.Kind {
+		t.Errorf("expected the klusterlet of the crd %s, but got %s", crd.Name, klusterlet.GroupVersionKind())
+	}
+	if klusterlet.Spec.ClusterName != "cluster1" {
+		t.Errorf("expected cluster name cluster1, but got %q", klusterlet.Spec.ClusterName)
+	}
+	if klusterlet.Spec.RegistrationImagePullSpec != testOptions.RegistrationImage ||
+		klusterlet.Spec.WorkImagePullSpec != testOptions.WorkImage {
+		t.Errorf("unexpected images of the klusterlet %v", klusterlet.Spec)
+	}
+
+	// the operator runs with the service account bound to the cluster role in the operator namespace
+	namespaces := map[string]bool{}
+	for _, ns := range objects["Namespace"] {
+		namespaces[ns.GetName()] = true
+	}
+	serviceAccount := convert[corev1.ServiceAccount](t, objects["ServiceAccount"])
+	clusterRole := convert[rbacv1.ClusterRole](t, objects["ClusterRole"])
+	clusterRoleBinding := convert[rbacv1.ClusterRoleBinding](t, objects["ClusterRoleBinding"])
+	deployment := convert[appsv1.Deployment](t, objects["Deployment"])
+	if !namespaces[serviceAccount.Namespace] || !namespaces[deployment.Namespace] {
+		t.Errorf("expected the namespaces of the operator in %v", namespaces)
+	}
+	if deployment.Namespace != serviceAccount.Namespace ||
+		deployment.Spec.Template.Spec.ServiceAccountName != serviceAccount.Name {
+		t.Errorf("expected the operator with service account %s/%s", serviceAccount.Namespace, serviceAccount.Name)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != testOptions.OperatorImage {
+		t.Errorf("expected the operator image %s, but got %s",
+			testOptions.OperatorImage, deployment.Spec.Template.Spec.Containers[0].Image)
+	}
+	if clusterRoleBinding.RoleRef.Name != clusterRole.Name {
+		t.Errorf("expected the cluster role %s, but got %s", clusterRole.Name, clusterRoleBinding.RoleRef.Name)
+	}
+	if len(clusterRoleBinding.Subjects) != 1 || clusterRoleBinding.Subjects[0].Name != serviceAccount.Name ||
+		clusterRoleBinding.Subjects[0].Namespace != serviceAccount.Namespace {
+		t.Errorf("expected the service account %s/%s in the subjects %v",
+			serviceAccount.Namespace, serviceAccount.Name, clusterRoleBinding.Subjects)
+	}
+
+	// the bootstrap kubeconfig is in the agent namespace of the klusterlet
+	secret := convert[corev1.Secret](t, objects["Secret"])
+	if secret.Name != bootstrapKubeconfigSecret || secret.Namespace != klusterlet.Spec.Namespace ||
+		!namespaces[secret.Namespace] {
+		t.Errorf("expected the bootstrap kubeconfig secret in namespace %s, but got %s/%s",
+			klusterlet.Spec.Namespace, secret.Namespace, secret.Name)
+	}
+	bootstrapConfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	context := bootstrapConfig.Contexts[bootstrapConfig.CurrentContext]
+	cluster := bootstrapConfig.Clusters[context.Cluster]
+	if cluster.Server != hub.Server || !bytes.Equal(cluster.CertificateAuthorityData, hub.CABundle) {
+		t.Errorf("expected the hub %s, but got %s", hub.Server, cluster.Server)
+	}
+	if bootstrapConfig.AuthInfos[context.AuthInfo].Token != "token" {
+		t.Errorf("expected the bootstrap token in the kubeconfig")
+	}
+}
+
+func TestImportConfigHash(t *testing.T) {
+	hub := hubConfig{Server: "https://hub.example.com:6443", CABundle: []byte("ca-data")}
+	hash := func(config importConfig) string {
+		h, err := config.hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	config := newImportConfig("cluster1", hub, testOptions)
+	withToken := config
+	withToken.BootstrapKubeconfig = "kubeconfig"
+	if hash(config) != hash(withToken) {
+		t.Errorf("expected the hash without the bootstrap kubeconfig")
+	}
+
+	rotated := newImportConfig("cluster1", hubConfig{Server: hub.Server, CABundle: []byte("new-ca-data")}, testOptions)
+	if hash(config) == hash(rotated) {
+		t.Errorf("expected the hash changed with the hub CA bundle")
+	}
+	moved := newImportConfig("cluster1", hubConfig{Server: "https://new-hub.example.com:6443", CABundle: hub.CABundle}, testOptions)
+	if hash(config) == hash(moved) {
+		t.Errorf("expected the hash changed with the hub endpoint")
+	}
+}
@@ -2,6 +2,7 @@ package lease
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -11,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	coordinformers "k8s.io/client-go/informers/coordination/v1"
 	"k8s.io/client-go/kubernetes"
 	coordlisters "k8s.io/client-go/listers/coordination/v1"
@@ -22,10 +24,15 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
-const leaseDurationTimes = 5
-const leaseName = "managed-cluster-lease"
+const (
+	leaseDurationTimes = 5
+
+	leaseUpdateStoppedReason  = "ManagedClusterLeaseUpdateStopped"
+	leaseUpdateStoppedMessage = "Registration agent stopped updating its lease."
+)
 
 var (
 	// LeaseDurationSeconds is lease update time interval
@@ -38,7 +45,10 @@ type leaseController struct {
 	patcher       patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
 	clusterLister clusterv1listers.ManagedClusterLister
 	leaseLister   coordlisters.LeaseLister
-	eventRecorder events.Recorder
+	// allowedLeaseNamespaces are the namespaces other than the cluster namespace the lease of a cluster is
+	// allowed to be in.
+	allowedLeaseNamespaces sets.Set[string]
+	eventRecorder          events.Recorder
 }
 
 // NewClusterLeaseController creates a cluster lease controller on hub cluster.
@@ -47,15 +57,17 @@ func NewClusterLeaseController(
 	clusterClient clientset.Interface,
 	clusterInformer clusterv1informer.ManagedClusterInformer,
 	leaseInformer coordinformers.LeaseInformer,
+	allowedLeaseNamespaces []string,
 	recorder events.Recorder) factory.Controller {
 	c := &leaseController{
 		kubeClient: kubeClient,
 		patcher: patcher.NewPatcher[
 			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
 			clusterClient.ClusterV1().ManagedClusters()),
-		clusterLister: clusterInformer.Lister(),
-		leaseLister:   leaseInformer.Lister(),
-		eventRecorder: recorder.WithComponentSuffix("managed-cluster-lease-controller"),
+		clusterLister:          clusterInformer.Lister(),
+		leaseLister:            leaseInformer.Lister(),
+		allowedLeaseNamespaces: sets.New[string](allowedLeaseNamespaces...),
+		eventRecorder:          recorder.WithComponentSuffix("managed-cluster-lease-controller"),
 	}
 	return factory.New().
 		WithFilteredEventsInformersQueueKeyFunc(
@@ -72,11 +84,22 @@ func NewClusterLeaseController(
 				// only handle the managed cluster lease
 				// TODO instead of this by adding label filter in the SharedInformerFactory
 				// see https://github.com/open-cluster-management-io/registration/issues/225
-				if _, ok := metaObj.GetObjectMeta().GetLabels()[clusterv1.ClusterNameLabelKey]; !ok {
+				clusterName, ok := metaObj.GetObjectMeta().GetLabels()[clusterv1.ClusterNameLabelKey]
+				if !ok {
 					return false
 				}
+				if metaObj.GetObjectMeta().GetName() == helpers.DefaultClusterLeaseName {
+					return true
+				}
 
-				return metaObj.GetObjectMeta().GetName() == leaseName
+				// the lease in a custom location is handled only if the cluster is pointed to it
+				cluster, err := c.clusterLister.Get(clusterName)
+				if err != nil {
+					return false
+				}
+				namespace, name, allowed := c.leaseLocation(cluster)
+				return allowed && metaObj.GetObjectMeta().GetNamespace() == namespace &&
+					metaObj.GetObjectMeta().GetName() == name
 			},
 			leaseInformer.Informer(),
 		).
@@ -106,11 +129,30 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 		return nil
 	}
 
-	observedLease, err := c.leaseLister.Leases(cluster.Name).Get(leaseName)
+	leaseNamespace, leaseName, allowed := c.leaseLocation(cluster)
+	if !allowed {
+		// the agent is not allowed to renew the lease out of the cluster namespace and the allowed namespaces,
+		// the lease is not trusted.
+		return c.updateClusterStatus(ctx, cluster, "ManagedClusterLeaseNotAllowed", fmt.Sprintf(
+			"The lease namespace %q of the registration agent is not allowed by the hub.", leaseNamespace))
+	}
+
+	observedLease, err := c.leaseLister.Leases(leaseNamespace).Get(leaseName)
+	if errors.IsNotFound(err) && leaseNamespace != cluster.Name {
+		// the lease in a custom location is created by the agent before the cluster is pointed to it, get it from
+		// the hub directly in case the informer is not synced yet.
+		observedLease, err = c.kubeClient.CoordinationV1().Leases(leaseNamespace).Get(ctx, leaseName, metav1.GetOptions{})
+	}
 	if errors.IsNotFound(err) {
 		if !cluster.DeletionTimestamp.IsZero() {
 			// the lease is not found and the cluster is deleting, update the cluster to unknown immediately
-			return c.updateClusterStatus(ctx, cluster)
+			return c.updateClusterStatus(ctx, cluster, leaseUpdateStoppedReason, leaseUpdateStoppedMessage)
+		}
+
+		if leaseNamespace != cluster.Name || leaseName != helpers.DefaultClusterLeaseName {
+			// the hub does not create the lease out of the cluster namespace, the lease in a custom location is
+			// not found means the agent stopped updating it.
+			return c.updateClusterStatus(ctx, cluster, leaseUpdateStoppedReason, leaseUpdateStoppedMessage)
 		}
 
 		// the lease is not found, try to create it
@@ -131,6 +173,11 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 	if err != nil {
 		return err
 	}
+	if leaseNamespace != cluster.Name && observedLease.Labels[clusterv1.ClusterNameLabelKey] != cluster.Name {
+		// the lease out of the cluster namespace is not created by the agent of this cluster.
+		return c.updateClusterStatus(ctx, cluster, "ManagedClusterLeaseNotOwned", fmt.Sprintf(
+			"The lease %s/%s is not labeled with the cluster name.", leaseNamespace, leaseName))
+	}
 
 	gracePeriod := time.Duration(leaseDurationTimes*cluster.Spec.LeaseDurationSeconds) * time.Second
 	if gracePeriod == 0 {
@@ -141,7 +188,7 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 	now := time.Now()
 	if !now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
 		// the lease is not updated constantly, change the cluster available condition to unknown
-		if err := c.updateClusterStatus(ctx, cluster); err != nil {
+		if err := c.updateClusterStatus(ctx, cluster, leaseUpdateStoppedReason, leaseUpdateStoppedMessage); err != nil {
 			return err
 		}
 	}
@@ -151,8 +198,16 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 	return nil
 }
 
-func (c *leaseController) updateClusterStatus(ctx context.Context, cluster *clusterv1.ManagedCluster) error {
-	if meta.IsStatusConditionPresentAndEqual(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable, metav1.ConditionUnknown) {
+// leaseLocation returns the namespace and name of the lease of a managed cluster, and whether the lease namespace
+// is allowed by the hub.
+func (c *leaseController) leaseLocation(cluster *clusterv1.ManagedCluster) (string, string, bool) {
+	namespace, name := helpers.ClusterLeaseLocation(cluster)
+	return namespace, name, namespace == cluster.Name || c.allowedLeaseNamespaces.Has(namespace)
+}
+
+func (c *leaseController) updateClusterStatus(ctx context.Context, cluster *clusterv1.ManagedCluster, reason, message string) error {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	if cond != nil && cond.Status == metav1.ConditionUnknown && (cond.Reason == reason || reason == leaseUpdateStoppedReason) {
 		// the managed cluster available condition alreay is unknown, do nothing
 		return nil
 	}
@@ -161,8 +216,8 @@ func (c *leaseController) updateClusterStatus(ctx context.Context, cluster *clus
 	meta.SetStatusCondition(&newCluster.Status.Conditions, metav1.Condition{
 		Type:    clusterv1.ManagedClusterConditionAvailable,
 		Status:  metav1.ConditionUnknown,
-		Reason:  "ManagedClusterLeaseUpdateStopped",
-		Message: "Registration agent stopped updating its lease.",
+		Reason:  reason,
+		Message: message,
 	})
 
 	updated, err := c.patcher.PatchStatus(ctx, newCluster, newCluster.Status, cluster.Status)
@@ -7,8 +7,11 @@ import (
 	"testing"
 	"time"
 
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
@@ -20,6 +23,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 )
 
@@ -102,6 +106,67 @@ func TestSync(t *testing.T) {
 				testingcommon.AssertCondition(t, managedCluster.Status.Conditions, expected)
 			},
 		},
+		{
+			name:     "managed cluster is available with the lease in a custom location",
+			clusters: []runtime.Object{newLeaseAnnotatedManagedCluster(testinghelpers.NewAvailableManagedCluster())},
+			clusterLeases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-5*time.Minute)),
+				newCustomLease(testinghelpers.TestManagedClusterName),
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, leaseActions)
+				testingcommon.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:     "the lease in a custom location is not labeled with the cluster name",
+			clusters: []runtime.Object{newLeaseAnnotatedManagedCluster(testinghelpers.NewAvailableManagedCluster())},
+			clusterLeases: []runtime.Object{
+				newCustomLease("cluster2"),
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, leaseActions)
+				assertClusterUnknown(t, clusterActions, "ManagedClusterLeaseNotOwned")
+			},
+		},
+		{
+			name: "the lease namespace is not allowed",
+			clusters: []runtime.Object{func() *clusterv1.ManagedCluster {
+				cluster := newLeaseAnnotatedManagedCluster(testinghelpers.NewAvailableManagedCluster())
+				cluster.Annotations[helpers.ClusterLeaseNamespaceAnnotation] = "kube-system"
+				return cluster
+			}()},
+			clusterLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("kube-system", "cluster-heartbeat", now),
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, leaseActions)
+				assertClusterUnknown(t, clusterActions, "ManagedClusterLeaseNotAllowed")
+			},
+		},
+		{
+			name:     "there is no lease in the custom location for a managed cluster",
+			clusters: []runtime.Object{newLeaseAnnotatedManagedCluster(testinghelpers.NewAvailableManagedCluster())},
+			clusterLeases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", now),
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				// the lease is not created by the hub out of the cluster namespace
+				testingcommon.AssertActions(t, leaseActions, "get")
+				testingcommon.AssertActions(t, clusterActions, "patch")
+				patch := clusterActions[0].(clienttesting.PatchAction).GetPatch()
+				managedCluster := &v1.ManagedCluster{}
+				if err := json.Unmarshal(patch, managedCluster); err != nil {
+					t.Fatal(err)
+				}
+				testingcommon.AssertCondition(t, managedCluster.Status.Conditions, metav1.Condition{
+					Type:    clusterv1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionUnknown,
+					Reason:  "ManagedClusterLeaseUpdateStopped",
+					Message: "Registration agent stopped updating its lease.",
+				})
+			},
+		},
 		{
 			name:          "managed cluster is unknown",
 			clusters:      []runtime.Object{testinghelpers.NewUnknownManagedCluster()},
@@ -139,9 +204,10 @@ func TestSync(t *testing.T) {
 				patcher: patcher.NewPatcher[
 					*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
 					clusterClient.ClusterV1().ManagedClusters()),
-				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
-				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
-				eventRecorder: syncCtx.Recorder(),
+				clusterLister:          clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				leaseLister:            leaseInformerFactory.Coordination().V1().Leases().Lister(),
+				allowedLeaseNamespaces: sets.New[string]("cloud-managed"),
+				eventRecorder:          syncCtx.Recorder(),
 			}
 			syncErr := ctrl.sync(context.TODO(), syncCtx)
 			if syncErr != nil {
@@ -158,3 +224,29 @@ func newDeletingManagedCluster() *clusterv1.ManagedCluster {
 	cluster.DeletionTimestamp = &now
 	return cluster
 }
+
+func newLeaseAnnotatedManagedCluster(cluster *clusterv1.ManagedCluster) *clusterv1.ManagedCluster {
+	cluster.Annotations = map[string]string{
+		helpers.ClusterLeaseNamespaceAnnotation: "cloud-managed",
+		helpers.ClusterLeaseNameAnnotation:      "cluster-heartbeat",
+	}
+	return cluster
+}
+
+func newCustomLease(clusterName string) *coordv1.Lease {
+	lease := testinghelpers.NewAddOnLease("cloud-managed", "cluster-heartbeat", now)
+	lease.Labels = map[string]string{clusterv1.ClusterNameLabelKey: clusterName}
+	return lease
+}
+
+func assertClusterUnknown(t *testing.T, clusterActions []clienttesting.Action, reason string) {
+	testingcommon.AssertActions(t, clusterActions, "patch")
+	managedCluster := &v1.ManagedCluster{}
+	if err := json.Unmarshal(clusterActions[0].(clienttesting.PatchAction).GetPatch(), managedCluster); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	if cond == nil || cond.Status != metav1.ConditionUnknown || cond.Reason != reason {
+		t.Errorf("expected the cluster unknown with reason %q, but got %v", reason, cond)
+	}
+}
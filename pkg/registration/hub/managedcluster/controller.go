@@ -32,11 +32,15 @@ const (
 //go:embed manifests
 var manifestFiles embed.FS
 
+// workRoleBindingFile grants the agent the permission on the manifestworks in the cluster namespace, it is not
+// applied on an observe-only cluster.
+const workRoleBindingFile = "manifests/managedcluster-work-rolebinding.yaml"
+
 var staticFiles = []string{
 	"manifests/managedcluster-clusterrole.yaml",
 	"manifests/managedcluster-clusterrolebinding.yaml",
 	"manifests/managedcluster-registration-rolebinding.yaml",
-	"manifests/managedcluster-work-rolebinding.yaml",
+	workRoleBindingFile,
 }
 
 // managedClusterController reconciles instances of ManagedCluster on the hub.
@@ -94,6 +98,14 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 
 	// Spoke cluster is deleting, we remove its related resources
 	if !managedCluster.DeletionTimestamp.IsZero() {
+		// the deletion of a protected cluster is expected to be rejected by the webhook, keep the finalizer
+		// until the deletion is confirmed in case the webhook is bypassed.
+		if helpers.IsClusterDeletionProtected(managedCluster) {
+			c.eventRecorder.Warningf("ManagedClusterDeletionNotConfirmed",
+				"managed cluster %s is protected from deletion, set the annotation %s to confirm the deletion",
+				managedClusterName, helpers.ManagedClusterDeletionConfirmedAnnotation)
+			return nil
+		}
 		if err := c.removeManagedClusterResources(ctx, managedClusterName); err != nil {
 			return err
 		}
@@ -129,12 +141,28 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 	// TODO consider to add the managedcluster-namespace.yaml back to staticFiles,
 	// currently, we keep the namespace after the managed cluster is deleted.
 	applyFiles := []string{"manifests/managedcluster-namespace.yaml"}
-	applyFiles = append(applyFiles, staticFiles...)
+	errs := []error{}
+	if helpers.IsClusterObserveOnly(managedCluster) {
+		// the work rolebinding is removed once the cluster is switched to observe-only, the finalizer of the
+		// rolebinding is removed by the rbac finalizer controller then.
+		for _, file := range staticFiles {
+			if file != workRoleBindingFile {
+				applyFiles = append(applyFiles, file)
+			}
+		}
+		if err := helpers.CleanUpManagedClusterManifests(ctx, c.kubeClient, nil, c.eventRecorder,
+			helpers.ManagedClusterAssetFn(manifestFiles, managedClusterName), workRoleBindingFile); err != nil {
+			errs = append(errs, err)
+		}
+	} else {
+		applyFiles = append(applyFiles, staticFiles...)
+	}
 
 	// Hub cluster-admin accepts the spoke cluster, we apply
 	// 1. clusterrole and clusterrolebinding for this spoke cluster.
 	// 2. namespace for this spoke cluster.
-	// 3. role and rolebinding for this spoke cluster on its namespace.
+	// 3. role and rolebinding for this spoke cluster on its namespace, the work rolebinding is not applied on
+	//    an observe-only cluster.
 	resourceResults := resourceapply.ApplyDirectly(
 		ctx,
 		resourceapply.NewKubeClientHolder(c.kubeClient),
@@ -143,7 +171,6 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		helpers.ManagedClusterAssetFn(manifestFiles, managedClusterName),
 		applyFiles...,
 	)
-	errs := []error{}
 	for _, result := range resourceResults {
 		if result.Error != nil {
 			errs = append(errs, fmt.Errorf("%q (%T): %v", result.File, result.Type, result.Error))
@@ -179,7 +206,7 @@ func (c *managedClusterController) removeManagedClusterResources(ctx context.Con
 	errs := []error{}
 	// Clean up managed cluster manifests
 	assetFn := helpers.ManagedClusterAssetFn(manifestFiles, managedClusterName)
-	if err := helpers.CleanUpManagedClusterManifests(ctx, c.kubeClient, c.eventRecorder, assetFn, staticFiles...); err != nil {
+	if err := helpers.CleanUpManagedClusterManifests(ctx, c.kubeClient, nil, c.eventRecorder, assetFn, staticFiles...); err != nil {
 		errs = append(errs, err)
 	}
 	return operatorhelpers.NewMultiLineAggregate(errs)
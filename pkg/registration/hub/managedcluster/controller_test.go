@@ -3,11 +3,13 @@ package managedcluster
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
@@ -19,6 +21,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 )
 
@@ -110,6 +113,31 @@ func TestSyncManagedCluster(t *testing.T) {
 				testinghelpers.AssertFinalizers(t, managedCluster, []string{})
 			},
 		},
+		{
+			name: "delete a protected spoke cluster without confirmation",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := testinghelpers.NewDeletingManagedCluster()
+				cluster.Labels = map[string]string{helpers.ManagedClusterDeletionProtectionLabel: "true"}
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "delete a protected spoke cluster with confirmation",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := testinghelpers.NewDeletingManagedCluster()
+				cluster.Labels = map[string]string{helpers.ManagedClusterDeletionProtectionLabel: "true"}
+				cluster.Annotations = map[string]string{
+					helpers.ManagedClusterDeletionConfirmedAnnotation: testinghelpers.TestManagedClusterName,
+				}
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -139,3 +167,48 @@ func TestSyncManagedCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncManagedClusterObserveOnly(t *testing.T) {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	kubeClient := kubefake.NewSimpleClientset()
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+
+	ctrl := managedClusterController{
+		kubeClient,
+		clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		patcher.NewPatcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](clusterClient.ClusterV1().ManagedClusters()),
+		resourceapply.NewResourceCache(),
+		eventstesting.NewTestingEventRecorder(t)}
+
+	workRoleBindingName := fmt.Sprintf("open-cluster-management:managedcluster:%s:work", testinghelpers.TestManagedClusterName)
+	// the cluster is switched to observe-only and back
+	for _, observeOnly := range []bool{false, true, false} {
+		cluster = cluster.DeepCopy()
+		cluster.Annotations = map[string]string{}
+		if observeOnly {
+			cluster.Annotations[helpers.ObserveOnlyAnnotation] = "true"
+		}
+		if err := clusterStore.Update(cluster); err != nil {
+			t.Fatal(err)
+		}
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+
+		_, err := kubeClient.RbacV1().RoleBindings(testinghelpers.TestManagedClusterName).Get(
+			context.TODO(), workRoleBindingName, metav1.GetOptions{})
+		switch {
+		case observeOnly && !errors.IsNotFound(err):
+			t.Errorf("expected the work rolebinding deleted on the observe-only cluster, but got %v", err)
+		case !observeOnly && err != nil:
+			t.Errorf("expected the work rolebinding created, but got %v", err)
+		}
+		if _, err := kubeClient.RbacV1().RoleBindings(testinghelpers.TestManagedClusterName).Get(context.TODO(),
+			fmt.Sprintf("open-cluster-management:managedcluster:%s:registration", testinghelpers.TestManagedClusterName),
+			metav1.GetOptions{}); err != nil {
+			t.Errorf("expected the registration rolebinding kept, but got %v", err)
+		}
+	}
+}
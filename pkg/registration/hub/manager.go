@@ -2,6 +2,8 @@ package hub
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
@@ -10,28 +12,44 @@ import (
 	"github.com/spf13/pflag"
 	certv1 "k8s.io/api/certificates/v1"
 	certv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	kevents "k8s.io/client-go/tools/events"
 	"k8s.io/klog/v2"
 
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
 	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterscheme "open-cluster-management.io/api/client/cluster/clientset/versioned/scheme"
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
 	workv1informers "open-cluster-management.io/api/client/work/informers/externalversions"
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	"open-cluster-management.io/ocm/pkg/common/leadership"
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	"open-cluster-management.io/ocm/pkg/registration/hub/addon"
+	"open-cluster-management.io/ocm/pkg/registration/hub/agentconfig"
+	"open-cluster-management.io/ocm/pkg/registration/hub/agentfailures"
+	"open-cluster-management.io/ocm/pkg/registration/hub/claimlabel"
+	"open-cluster-management.io/ocm/pkg/registration/hub/clientconfig"
 	"open-cluster-management.io/ocm/pkg/registration/hub/clusterrole"
 	"open-cluster-management.io/ocm/pkg/registration/hub/csr"
+	"open-cluster-management.io/ocm/pkg/registration/hub/hubversion"
+	"open-cluster-management.io/ocm/pkg/registration/hub/importmanifest"
 	"open-cluster-management.io/ocm/pkg/registration/hub/lease"
 	"open-cluster-management.io/ocm/pkg/registration/hub/managedcluster"
 	"open-cluster-management.io/ocm/pkg/registration/hub/managedclusterset"
 	"open-cluster-management.io/ocm/pkg/registration/hub/managedclustersetbinding"
+	"open-cluster-management.io/ocm/pkg/registration/hub/metrics"
 	"open-cluster-management.io/ocm/pkg/registration/hub/rbacfinalizerdeletion"
 	"open-cluster-management.io/ocm/pkg/registration/hub/taint"
 )
@@ -41,11 +59,48 @@ var ResyncInterval = 5 * time.Minute
 // HubManagerOptions holds configuration for hub manager controller
 type HubManagerOptions struct {
 	ClusterAutoApprovalUsers []string
+	// ClusterAutoApprovalSelector is the label selector of the clusters auto approved for the
+	// ClusterAutoApprovalUsers, it may only refer to the labels controlled by the hub. All of the clusters are
+	// auto approved if it is empty.
+	ClusterAutoApprovalSelector string
+	// ClusterNamePattern is the regular expression the whole names of the clusters must match at the initial
+	// registration. The clusters already accepted by the hub are not affected.
+	ClusterNamePattern string
+	// CSRApprovalBacklogThreshold is the age of the oldest pending registration csr to report the approval backlog.
+	CSRApprovalBacklogThreshold time.Duration
+	// ClusterRebootstrapAutoApproval auto approves the csrs of the accepted clusters registered again with a new
+	// agent identity, otherwise the csrs must be approved by the hub admin.
+	ClusterRebootstrapAutoApproval bool
+	// ClusterLeaseNamespaces are the namespaces other than the cluster namespaces the hub observes the cluster
+	// leases in, the lease annotations of a cluster pointing to the other namespaces are ignored.
+	ClusterLeaseNamespaces []string
+	// ImportHubAPIServer overrides the hub endpoint in the cluster-info configmap of the import manifests.
+	ImportHubAPIServer string
+	// ImportHubCABundleFile is the file with the hub CA bundle of the import manifests.
+	ImportHubCABundleFile string
+	// ImportBootstrapServiceAccount is the service account in the format of namespace/name the bootstrap tokens
+	// of the import manifests are requested for.
+	ImportBootstrapServiceAccount string
+	// ImportControllerServiceAccount is the service account of the controller in the format of namespace/name,
+	// which is allowed to manage the import manifests secrets in the cluster namespaces.
+	ImportControllerServiceAccount string
+	// ImportTokenExpiration is the expiration of the bootstrap tokens of the import manifests.
+	ImportTokenExpiration time.Duration
+	// ImportOperatorImage, ImportRegistrationImage and ImportWorkImage are the images in the import manifests,
+	// the import manifests are not generated until all of them are set.
+	ImportOperatorImage     string
+	ImportRegistrationImage string
+	ImportWorkImage         string
 }
 
 // NewHubManagerOptions returns a HubManagerOptions
 func NewHubManagerOptions() *HubManagerOptions {
-	return &HubManagerOptions{}
+	return &HubManagerOptions{
+		CSRApprovalBacklogThreshold:    15 * time.Minute,
+		ImportBootstrapServiceAccount:  "open-cluster-management-hub/agent-registration-bootstrap",
+		ImportControllerServiceAccount: "open-cluster-management-hub/registration-controller-sa",
+		ImportTokenExpiration:          24 * time.Hour,
+	}
 }
 
 // AddFlags registers flags for manager
@@ -53,11 +108,66 @@ func (m *HubManagerOptions) AddFlags(fs *pflag.FlagSet) {
 	features.DefaultHubRegistrationMutableFeatureGate.AddFlag(fs)
 	fs.StringSliceVar(&m.ClusterAutoApprovalUsers, "cluster-auto-approval-users", m.ClusterAutoApprovalUsers,
 		"A bootstrap user list whose cluster registration requests can be automatically approved.")
-
+	fs.StringVar(&m.ClusterAutoApprovalSelector, "cluster-auto-approval-selector", m.ClusterAutoApprovalSelector,
+		"A label selector of the clusters whose registration requests can be automatically approved for the "+
+			"bootstrap users in --cluster-auto-approval-users. It may only refer to the clusterset label, which is "+
+			"authorized by the hub. All of the clusters are approved if it is empty.")
+	fs.StringVar(&m.ClusterNamePattern, "cluster-name-pattern", m.ClusterNamePattern,
+		"A regular expression the whole cluster name must match, otherwise the csr of the cluster is denied at the "+
+			"initial registration. The clusters already accepted by the hub are not affected.")
+	fs.DurationVar(&m.CSRApprovalBacklogThreshold, "csr-approval-backlog-threshold", m.CSRApprovalBacklogThreshold,
+		"The age of the oldest pending registration csr to report the approval backlog.")
+	fs.BoolVar(&m.ClusterRebootstrapAutoApproval, "cluster-rebootstrap-auto-approval", m.ClusterRebootstrapAutoApproval,
+		"Automatically approve the registration requests of the accepted clusters which are registered again "+
+			"with a new agent identity, e.g. after the agent is reinstalled.")
+	fs.StringSliceVar(&m.ClusterLeaseNamespaces, "cluster-lease-namespaces", m.ClusterLeaseNamespaces,
+		"The namespaces other than the cluster namespaces the agents are allowed to renew the cluster leases in. "+
+			"The lease annotations of a cluster pointing to the other namespaces are ignored.")
+	fs.StringVar(&m.ImportHubAPIServer, "import-hub-apiserver", m.ImportHubAPIServer,
+		"The endpoint of the hub kube-apiserver in the import manifests of the managed clusters. The one in "+
+			"the kube-public/cluster-info configmap is used if it is empty.")
+	fs.StringVar(&m.ImportHubCABundleFile, "import-hub-ca-bundle-file", m.ImportHubCABundleFile,
+		"The file with the CA bundle of the hub kube-apiserver in the import manifests of the managed clusters. "+
+			"The one in the kube-public/cluster-info configmap, or else in the kube-public/kube-root-ca.crt "+
+			"configmap is used if it is empty.")
+	fs.StringVar(&m.ImportBootstrapServiceAccount, "import-bootstrap-serviceaccount", m.ImportBootstrapServiceAccount,
+		"The service account in the format of namespace/name the bootstrap tokens of the import manifests "+
+			"are requested for.")
+	fs.StringVar(&m.ImportControllerServiceAccount, "import-controller-serviceaccount", m.ImportControllerServiceAccount,
+		"The service account of the controller in the format of namespace/name, it is allowed to manage the "+
+			"import manifests in the namespaces of the clusters requesting them.")
+	fs.DurationVar(&m.ImportTokenExpiration, "import-token-expiration", m.ImportTokenExpiration,
+		"The expiration of the bootstrap tokens of the import manifests, the manifests are regenerated before "+
+			"the tokens expire.")
+	fs.StringVar(&m.ImportOperatorImage, "import-operator-image", m.ImportOperatorImage,
+		"The klusterlet operator image in the import manifests of the managed clusters.")
+	fs.StringVar(&m.ImportRegistrationImage, "import-registration-image", m.ImportRegistrationImage,
+		"The registration agent image in the import manifests of the managed clusters.")
+	fs.StringVar(&m.ImportWorkImage, "import-work-image", m.ImportWorkImage,
+		"The work agent image in the import manifests of the managed clusters.")
 }
 
 // RunControllerManager starts the controllers on hub to manage spoke cluster registration.
 func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	groups, err := m.ControllerGroups(ctx, controllerContext)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		go func(group leadership.Group) {
+			if err := group.Run(ctx); err != nil {
+				klog.Errorf("The controllers of %s failed: %v", group.Name, err)
+			}
+		}(group)
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// ControllerGroups creates the controllers on hub to manage spoke cluster registration in the groups led by
+// the hub controller replicas, and starts the informers shared by the groups.
+func (m *HubManagerOptions) ControllerGroups(ctx context.Context, controllerContext *controllercmd.ControllerContext) ([]leadership.Group, error) {
 	// If qps in kubconfig is not set, increase the qps and burst to enhance the ability of kube client to handle
 	// requests in concurrent
 	// TODO: Use ClientConnectionOverrides flags to change qps/burst when library-go exposes them in the future
@@ -69,22 +179,22 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 
 	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	clusterClient, err := clusterv1client.NewForConfig(kubeConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	workClient, err := workv1client.NewForConfig(kubeConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	addOnClient, err := addonclient.NewForConfig(kubeConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	clusterInformers := clusterv1informers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
@@ -105,13 +215,149 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 		controllerContext.EventRecorder,
 	)
 
-	csrReconciles := []csr.Reconciler{csr.NewCSRRenewalReconciler(kubeClient, controllerContext.EventRecorder)}
+	// the events are emitted on the managed clusters
+	eventScheme := runtime.NewScheme()
+	utilruntime.Must(clusterscheme.AddToScheme(eventScheme))
+	broadcaster := kevents.NewBroadcaster(&kevents.EventSinkImpl{Interface: kubeClient.EventsV1()})
+	broadcaster.StartRecordingToSink(ctx.Done())
+	krecorder := broadcaster.NewRecorder(eventScheme, "registration-controller")
+
+	clientConfigController := clientconfig.NewClientConfigController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		controllerContext.EventRecorder,
+		krecorder,
+	)
+
+	agentConfigController := agentconfig.NewAgentConfigController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		controllerContext.EventRecorder,
+	)
+
+	// the critical events reported by the agents are on the managed clusters in the cluster namespaces
+	agentEventInformers := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.SelectorFromSet(fields.Set{
+				"involvedObject.kind": "ManagedCluster",
+				"type":                corev1.EventTypeWarning,
+			}).String()
+		}))
+	agentFailuresController := agentfailures.NewAgentFailuresController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		agentEventInformers.Core().V1().Events(),
+		controllerContext.EventRecorder,
+	)
+
+	// the hub version configmap is published by the cluster manager operator in the namespace of the hub components
+	hubVersionInformers := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+		kubeinformers.WithNamespace(controllerContext.OperatorNamespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", helpers.HubVersionConfigMap).String()
+		}))
+	hubVersionController := hubversion.NewHubVersionController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		hubVersionInformers.Core().V1().ConfigMaps(),
+		controllerContext.OperatorNamespace,
+		controllerContext.EventRecorder,
+	)
+
+	// the mapping of the cluster claims to the labels is configured in the namespace of the hub components
+	claimLabelInformers := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+		kubeinformers.WithNamespace(controllerContext.OperatorNamespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", claimlabel.ClusterClaimLabelsConfigMap).String()
+		}))
+	claimLabelController := claimlabel.NewClaimLabelController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		claimLabelInformers.Core().V1().ConfigMaps(),
+		controllerContext.OperatorNamespace,
+		controllerContext.EventRecorder,
+	)
+
+	var importManifestController factory.Controller
+	var importRoleBindingInformers, clusterInfoInformers kubeinformers.SharedInformerFactory
+	if features.DefaultHubRegistrationMutableFeatureGate.Enabled(features.ClusterImportManifests) {
+		bootstrapNamespace, bootstrapName, err := splitServiceAccount(m.ImportBootstrapServiceAccount)
+		if err != nil {
+			return nil, err
+		}
+		controllerNamespace, controllerName, err := splitServiceAccount(m.ImportControllerServiceAccount)
+		if err != nil {
+			return nil, err
+		}
+		// only the import rolebindings and the configmaps in the cluster-info namespace are watched, the import
+		// manifests secrets are accessed in the cluster namespaces with the rolebindings only.
+		importRoleBindingInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+			kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("metadata.name", importmanifest.ImportRoleBinding).String()
+			}))
+		clusterInfoInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+			kubeinformers.WithNamespace(importmanifest.ClusterInfoNamespace))
+		importManifestController = importmanifest.NewImportManifestController(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			importRoleBindingInformers.Rbac().V1().RoleBindings(),
+			clusterInfoInformers.Core().V1().ConfigMaps(),
+			importmanifest.Options{
+				HubAPIServer:                      m.ImportHubAPIServer,
+				HubCABundleFile:                   m.ImportHubCABundleFile,
+				BootstrapServiceAccountNamespace:  bootstrapNamespace,
+				BootstrapServiceAccountName:       bootstrapName,
+				ControllerServiceAccountNamespace: controllerNamespace,
+				ControllerServiceAccountName:      controllerName,
+				TokenExpiration:                   m.ImportTokenExpiration,
+				OperatorImage:                     m.ImportOperatorImage,
+				RegistrationImage:                 m.ImportRegistrationImage,
+				WorkImage:                         m.ImportWorkImage,
+			},
+			controllerContext.EventRecorder,
+		)
+	}
+
+	agentVersionMetricsController := metrics.NewAgentVersionMetricsController(
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		controllerContext.EventRecorder,
+	)
+
+	var csrReconciles []csr.Reconciler
+	if len(m.ClusterNamePattern) > 0 {
+		clusterNamePattern, err := csr.CompileClusterNamePattern(m.ClusterNamePattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cluster name pattern %q", m.ClusterNamePattern)
+		}
+		csrReconciles = append(csrReconciles, csr.NewCSRClusterNameReconciler(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+			clusterNamePattern,
+			controllerContext.EventRecorder,
+		))
+	}
+	csrReconciles = append(csrReconciles, csr.NewCSRRebootstrapReconciler(
+		kubeClient,
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+		m.ClusterRebootstrapAutoApproval,
+		controllerContext.EventRecorder,
+	))
+	csrReconciles = append(csrReconciles, csr.NewCSRRenewalReconciler(kubeClient, controllerContext.EventRecorder))
 	if features.DefaultHubRegistrationMutableFeatureGate.Enabled(ocmfeature.ManagedClusterAutoApproval) {
+		var autoApprovalSelector labels.Selector
+		if len(m.ClusterAutoApprovalSelector) > 0 {
+			autoApprovalSelector, err = helpers.ParseClusterAutoApprovalSelector(m.ClusterAutoApprovalSelector)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid cluster auto approval selector %q", m.ClusterAutoApprovalSelector)
+			}
+		}
 		csrReconciles = append(csrReconciles, csr.NewCSRBootstrapReconciler(
 			kubeClient,
 			clusterClient,
 			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
 			m.ClusterAutoApprovalUsers,
+			autoApprovalSelector,
 			controllerContext.EventRecorder,
 		))
 	}
@@ -120,7 +366,7 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 	if features.DefaultHubRegistrationMutableFeatureGate.Enabled(ocmfeature.V1beta1CSRAPICompatibility) {
 		v1CSRSupported, v1beta1CSRSupported, err := helpers.IsCSRSupported(kubeClient)
 		if err != nil {
-			return errors.Wrapf(err, "failed CSR api discovery")
+			return nil, errors.Wrapf(err, "failed CSR api discovery")
 		}
 
 		if !v1CSRSupported && v1beta1CSRSupported {
@@ -134,6 +380,8 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 			klog.Info("Using v1beta1 CSR api to manage spoke client certificate")
 		}
 	}
+	// the metrics of the registration csrs are only supported with the v1 csr api
+	var csrMetricsController factory.Controller
 	if csrController == nil {
 		csrController = csr.NewCSRApprovingController[*certv1.CertificateSigningRequest](
 			kubeInfomers.Certificates().V1().CertificateSigningRequests().Informer(),
@@ -142,6 +390,13 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 			csrReconciles,
 			controllerContext.EventRecorder,
 		)
+		csrMetricsController = metrics.NewCSRMetricsController(
+			kubeInfomers.Certificates().V1().CertificateSigningRequests(),
+			kubeClient.CoreV1(),
+			controllerContext.OperatorNamespace,
+			m.CSRApprovalBacklogThreshold,
+			controllerContext.EventRecorder,
+		)
 	}
 
 	leaseController := lease.NewClusterLeaseController(
@@ -149,6 +404,7 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 		clusterClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
 		kubeInfomers.Coordination().V1().Leases(),
+		m.ClusterLeaseNamespaces,
 		controllerContext.EventRecorder,
 	)
 
@@ -197,6 +453,14 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 		controllerContext.EventRecorder,
 	)
 
+	clusterProxyController := addon.NewClusterProxyController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		addOnInformers.Addon().V1alpha1().ClusterManagementAddOns(),
+		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+		controllerContext.EventRecorder,
+	)
+
 	var defaultManagedClusterSetController, globalManagedClusterSetController factory.Controller
 	if features.DefaultHubRegistrationMutableFeatureGate.Enabled(ocmfeature.DefaultClusterSet) {
 		defaultManagedClusterSetController = managedclusterset.NewDefaultManagedClusterSetController(
@@ -214,23 +478,64 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 	go clusterInformers.Start(ctx.Done())
 	go workInformers.Start(ctx.Done())
 	go kubeInfomers.Start(ctx.Done())
+	go hubVersionInformers.Start(ctx.Done())
+	go claimLabelInformers.Start(ctx.Done())
+	go agentEventInformers.Start(ctx.Done())
+	if importManifestController != nil {
+		go importRoleBindingInformers.Start(ctx.Done())
+		go clusterInfoInformers.Start(ctx.Done())
+	}
 	go addOnInformers.Start(ctx.Done())
 
-	go managedClusterController.Run(ctx, 1)
-	go taintController.Run(ctx, 1)
-	go csrController.Run(ctx, 1)
-	go leaseController.Run(ctx, 1)
-	go rbacFinalizerController.Run(ctx, 1)
-	go managedClusterSetController.Run(ctx, 1)
-	go managedClusterSetBindingController.Run(ctx, 1)
-	go clusterroleController.Run(ctx, 1)
-	go addOnHealthCheckController.Run(ctx, 1)
-	go addOnFeatureDiscoveryController.Run(ctx, 1)
-	if features.DefaultHubRegistrationMutableFeatureGate.Enabled(ocmfeature.DefaultClusterSet) {
-		go defaultManagedClusterSetController.Run(ctx, 1)
-		go globalManagedClusterSetController.Run(ctx, 1)
+	groups := []leadership.Group{
+		{
+			Name: "clusters",
+			Run: runControllers(managedClusterController, taintController, clientConfigController,
+				agentConfigController, hubVersionController, claimLabelController, agentFailuresController,
+				importManifestController, agentVersionMetricsController, csrController, csrMetricsController,
+				leaseController, rbacFinalizerController, clusterroleController),
+			// the metrics of the clusters are only reported by the leader
+			Handovers: []func(){metrics.ManagedClustersByAgentVersion.Reset, metrics.PendingRegistrationCSRs.Reset},
+		},
+		{
+			Name: "clustersets",
+			Run: runControllers(managedClusterSetController, managedClusterSetBindingController,
+				defaultManagedClusterSetController, globalManagedClusterSetController),
+		},
+		{
+			Name: "addons",
+			Run:  runControllers(addOnHealthCheckController, addOnFeatureDiscoveryController, clusterProxyController),
+		},
 	}
+	return groups, nil
+}
 
-	<-ctx.Done()
-	return nil
+// runControllers returns the func running the controllers with a worker each until the context is done, the nil
+// controllers which are disabled are skipped.
+func runControllers(controllers ...factory.Controller) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var wg sync.WaitGroup
+		for _, controller := range controllers {
+			if controller == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(controller factory.Controller) {
+				defer wg.Done()
+				controller.Run(ctx, 1)
+			}(controller)
+		}
+		wg.Wait()
+		return nil
+	}
+}
+
+// splitServiceAccount splits the service account in the format of namespace/name.
+func splitServiceAccount(serviceAccount string) (string, string, error) {
+	namespace, name, ok := strings.Cut(serviceAccount, "/")
+	if !ok || len(namespace) == 0 || len(name) == 0 || strings.Contains(name, "/") {
+		return "", "", errors.Errorf("invalid service account %q, it should be in the format of namespace/name",
+			serviceAccount)
+	}
+	return namespace, name, nil
 }
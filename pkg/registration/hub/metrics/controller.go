@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// unknownAgentVersion is the version label of the clusters whose agent does not report its version.
+const unknownAgentVersion = "unknown"
+
+var (
+	// ManagedClustersByAgentVersion is the number of managed clusters per registration agent version.
+	ManagedClustersByAgentVersion = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "registration",
+			Name:           "managed_clusters_by_agent_version",
+			Help:           "Number of managed clusters by the version of the registration agent.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"version"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// Register registers the metrics of the registration hub.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(ManagedClustersByAgentVersion)
+		legacyregistry.MustRegister(PendingRegistrationCSRs)
+		legacyregistry.MustRegister(CSRApprovalDuration)
+		legacyregistry.MustRegister(CSRIssuanceDuration)
+		legacyregistry.MustRegister(CSRDenials)
+	})
+}
+
+// agentVersionMetricsController recomputes the number of managed clusters per agent version
+// from the managed cluster informer.
+type agentVersionMetricsController struct {
+	clusterLister listerv1.ManagedClusterLister
+}
+
+// NewAgentVersionMetricsController creates a controller to maintain the metric of clusters per agent version.
+func NewAgentVersionMetricsController(
+	clusterInformer informerv1.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	Register()
+	c := &agentVersionMetricsController{
+		clusterLister: clusterInformer.Lister(),
+	}
+	return factory.New().
+		WithInformers(clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("AgentVersionMetricsController", recorder)
+}
+
+func (c *agentVersionMetricsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, cluster := range clusters {
+		version := cluster.Annotations[helpers.AgentVersionAnnotation]
+		if len(version) == 0 {
+			version = unknownAgentVersion
+		}
+		counts[version]++
+	}
+
+	// reset the metric to drop the versions no longer running on any cluster
+	ManagedClustersByAgentVersion.Reset()
+	for version, count := range counts {
+		ManagedClustersByAgentVersion.WithLabelValues(version).Set(float64(count))
+	}
+	return nil
+}
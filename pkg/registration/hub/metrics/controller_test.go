@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/testutil"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newClusterWithAgentVersion(name, version string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Name = name
+	if len(version) > 0 {
+		cluster.Annotations = map[string]string{helpers.AgentVersionAnnotation: version}
+	}
+	return cluster
+}
+
+func assertClusterCount(t *testing.T, version string, expected float64) {
+	actual, err := testutil.GetGaugeMetricValue(ManagedClustersByAgentVersion.WithLabelValues(version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != expected {
+		t.Errorf("expected %v clusters with agent version %q, but got %v", expected, version, actual)
+	}
+}
+
+func TestSyncAgentVersionMetrics(t *testing.T) {
+	Register()
+
+	clusterClient := clusterfake.NewSimpleClientset()
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+
+	ctrl := &agentVersionMetricsController{
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+	}
+
+	for _, cluster := range []*clusterv1.ManagedCluster{
+		newClusterWithAgentVersion("cluster1", "v0.11.0"),
+		newClusterWithAgentVersion("cluster2", "v0.11.0"),
+		newClusterWithAgentVersion("cluster3", ""),
+	} {
+		if err := clusterStore.Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, "key")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	assertClusterCount(t, "v0.11.0", 2)
+	assertClusterCount(t, unknownAgentVersion, 1)
+
+	// upgrade the agents of all clusters
+	for _, name := range []string{"cluster1", "cluster2", "cluster3"} {
+		if err := clusterStore.Update(newClusterWithAgentVersion(name, "v0.12.0")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	assertClusterCount(t, "v0.12.0", 3)
+	assertClusterCount(t, "v0.11.0", 0)
+	assertClusterCount(t, unknownAgentVersion, 0)
+}
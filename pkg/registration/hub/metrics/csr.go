@@ -0,0 +1,307 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	certificatesv1informers "k8s.io/client-go/informers/certificates/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	certificatesv1listers "k8s.io/client-go/listers/certificates/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/utils/clock"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+const (
+	// CSRStatusConfigMap is the configmap in the namespace of the hub components with the conditions of the
+	// registration csrs. The conditions are set with the key CSRStatusConditionsKey in json.
+	CSRStatusConfigMap     = "registration-csr-status"
+	CSRStatusConditionsKey = "conditions"
+
+	// CSRApprovalBacklogCondition is true when the oldest pending registration csr exceeds the threshold.
+	CSRApprovalBacklogCondition = "CSRApprovalBacklog"
+)
+
+// pendingCSRAgeBuckets are the upper bounds of the age buckets of the pending registration csrs.
+var pendingCSRAgeBuckets = []struct {
+	label string
+	age   time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"1h", time.Hour},
+	{"+Inf", 0},
+}
+
+var (
+	// PendingRegistrationCSRs is the number of pending registration csrs by the age bucket.
+	PendingRegistrationCSRs = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "registration",
+			Name:           "pending_csrs",
+			Help:           "Number of pending registration csrs by the upper bound of the age bucket.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"age"},
+	)
+
+	// CSRApprovalDuration is the time from the creation to the approval of the registration csrs.
+	CSRApprovalDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      "registration",
+			Name:           "csr_approval_duration_seconds",
+			Help:           "Time from the creation to the approval of the registration csrs.",
+			Buckets:        metrics.ExponentialBuckets(1, 4, 8),
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// CSRIssuanceDuration is the time from the creation to the issuance of the registration csrs.
+	CSRIssuanceDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      "registration",
+			Name:           "csr_issuance_duration_seconds",
+			Help:           "Time from the creation to the issuance of the registration csrs.",
+			Buckets:        metrics.ExponentialBuckets(1, 4, 8),
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// CSRDenials is the number of the denied registration csrs by the reason.
+	CSRDenials = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "registration",
+			Name:           "csr_denials_total",
+			Help:           "Number of the denied registration csrs by the reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+)
+
+// csrMetricsController maintains the metrics of the registration csrs of the managed clusters and sets the
+// CSRApprovalBacklog condition in the CSRStatusConfigMap when the oldest pending csr exceeds the threshold.
+//
+// The approvals, issuances and denials are observed once for each csr, and only if they happen after the
+// controller starts, so they are not observed again after the controller restarts.
+type csrMetricsController struct {
+	csrLister       certificatesv1listers.CertificateSigningRequestLister
+	configMapClient corev1client.ConfigMapsGetter
+	namespace       string
+	threshold       time.Duration
+	clock           clock.PassiveClock
+	startTime       time.Time
+
+	approved sets.Set[string]
+	issued   sets.Set[string]
+	denied   sets.Set[string]
+}
+
+// NewCSRMetricsController creates a controller to maintain the metrics of the registration csrs.
+func NewCSRMetricsController(
+	csrInformer certificatesv1informers.CertificateSigningRequestInformer,
+	configMapClient corev1client.ConfigMapsGetter,
+	namespace string,
+	threshold time.Duration,
+	recorder events.Recorder) factory.Controller {
+	Register()
+	c := newCSRMetricsController(csrInformer.Lister(), configMapClient, namespace, threshold, clock.RealClock{})
+	return factory.New().
+		WithInformers(csrInformer.Informer()).
+		WithSync(c.sync).
+		// refresh the age of the pending csrs
+		ResyncEvery(time.Minute).
+		ToController("CSRMetricsController", recorder)
+}
+
+func newCSRMetricsController(
+	csrLister certificatesv1listers.CertificateSigningRequestLister,
+	configMapClient corev1client.ConfigMapsGetter,
+	namespace string,
+	threshold time.Duration,
+	clock clock.PassiveClock) *csrMetricsController {
+	return &csrMetricsController{
+		csrLister:       csrLister,
+		configMapClient: configMapClient,
+		namespace:       namespace,
+		threshold:       threshold,
+		clock:           clock,
+		startTime:       clock.Now(),
+		approved:        sets.New[string](),
+		issued:          sets.New[string](),
+		denied:          sets.New[string](),
+	}
+}
+
+func (c *csrMetricsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	csrs, err := c.csrLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	now := c.clock.Now()
+	pendingCounts := map[string]int{}
+	existing := sets.New[string]()
+	var oldestPending *certificatesv1.CertificateSigningRequest
+	for _, csr := range csrs {
+		if !isRegistrationCSR(csr) {
+			continue
+		}
+		existing.Insert(csr.Name)
+
+		approved, denied := getApprovalCondition(csr)
+		switch {
+		case denied != nil:
+			if !c.denied.Has(csr.Name) && conditionTime(denied, now).After(c.startTime) {
+				CSRDenials.WithLabelValues(denied.Reason).Inc()
+			}
+			c.denied.Insert(csr.Name)
+		case approved != nil:
+			approvalTime := conditionTime(approved, now)
+			if !c.approved.Has(csr.Name) && approvalTime.After(c.startTime) {
+				CSRApprovalDuration.Observe(approvalTime.Sub(csr.CreationTimestamp.Time).Seconds())
+			}
+			c.approved.Insert(csr.Name)
+
+			if len(csr.Status.Certificate) == 0 {
+				continue
+			}
+			// the signer does not record the issuance time, so it is observed when the certificate is found
+			if !c.issued.Has(csr.Name) && approvalTime.After(c.startTime) {
+				CSRIssuanceDuration.Observe(now.Sub(csr.CreationTimestamp.Time).Seconds())
+			}
+			c.issued.Insert(csr.Name)
+		default:
+			pendingCounts[pendingCSRAgeBucket(now.Sub(csr.CreationTimestamp.Time))]++
+			if oldestPending == nil || csr.CreationTimestamp.Before(&oldestPending.CreationTimestamp) {
+				oldestPending = csr
+			}
+		}
+	}
+
+	// forget the deleted csrs
+	c.approved = c.approved.Intersection(existing)
+	c.issued = c.issued.Intersection(existing)
+	c.denied = c.denied.Intersection(existing)
+
+	for _, bucket := range pendingCSRAgeBuckets {
+		PendingRegistrationCSRs.WithLabelValues(bucket.label).Set(float64(pendingCounts[bucket.label]))
+	}
+
+	cond := metav1.Condition{
+		Type:    CSRApprovalBacklogCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoStalePendingCSR",
+		Message: fmt.Sprintf("No registration csr is pending for more than %v", c.threshold),
+	}
+	if oldestPending != nil && now.Sub(oldestPending.CreationTimestamp.Time) > c.threshold {
+		cond = metav1.Condition{
+			Type:   CSRApprovalBacklogCondition,
+			Status: metav1.ConditionTrue,
+			Reason: "StalePendingCSR",
+			Message: fmt.Sprintf("The registration csr %q of cluster %q is pending for more than %v",
+				oldestPending.Name, oldestPending.Labels[clusterv1.ClusterNameLabelKey], c.threshold),
+		}
+	}
+	return c.updateCondition(ctx, cond)
+}
+
+// updateCondition sets the condition in the CSRStatusConfigMap.
+func (c *csrMetricsController) updateCondition(ctx context.Context, cond metav1.Condition) error {
+	configMap, err := c.configMapClient.ConfigMaps(c.namespace).Get(ctx, CSRStatusConfigMap, metav1.GetOptions{})
+	notFound := apierrors.IsNotFound(err)
+	switch {
+	case notFound:
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      CSRStatusConfigMap,
+				Namespace: c.namespace,
+			},
+		}
+	case err != nil:
+		return err
+	}
+
+	var conditions []metav1.Condition
+	if data, ok := configMap.Data[CSRStatusConditionsKey]; ok {
+		// the conditions are rebuilt if the data is corrupted
+		_ = json.Unmarshal([]byte(data), &conditions)
+	}
+	if existing := meta.FindStatusCondition(conditions, cond.Type); existing != nil &&
+		existing.Status == cond.Status && existing.Reason == cond.Reason && existing.Message == cond.Message {
+		return nil
+	}
+	meta.SetStatusCondition(&conditions, cond)
+
+	data, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+	configMap = configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[CSRStatusConditionsKey] = string(data)
+
+	if notFound {
+		_, err = c.configMapClient.ConfigMaps(c.namespace).Create(ctx, configMap, metav1.CreateOptions{})
+		return err
+	}
+	_, err = c.configMapClient.ConfigMaps(c.namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
+
+// isRegistrationCSR returns true if the csr is created by the registration agent of a managed cluster, the csrs
+// of the addons are ignored.
+func isRegistrationCSR(csr *certificatesv1.CertificateSigningRequest) bool {
+	if _, ok := csr.Labels[clusterv1.ClusterNameLabelKey]; !ok {
+		return false
+	}
+	if _, ok := csr.Labels[addonv1alpha1.AddonLabelKey]; ok {
+		return false
+	}
+	return csr.Spec.SignerName == certificatesv1.KubeAPIServerClientSignerName
+}
+
+func getApprovalCondition(csr *certificatesv1.CertificateSigningRequest) (approved, denied *certificatesv1.CertificateSigningRequestCondition) {
+	for i := range csr.Status.Conditions {
+		switch csr.Status.Conditions[i].Type {
+		case certificatesv1.CertificateApproved:
+			approved = &csr.Status.Conditions[i]
+		case certificatesv1.CertificateDenied:
+			denied = &csr.Status.Conditions[i]
+		}
+	}
+	return approved, denied
+}
+
+// conditionTime returns the last update time of the condition, or now if it is not set.
+func conditionTime(cond *certificatesv1.CertificateSigningRequestCondition, now time.Time) time.Time {
+	if cond.LastUpdateTime.IsZero() {
+		return now
+	}
+	return cond.LastUpdateTime.Time
+}
+
+func pendingCSRAgeBucket(age time.Duration) string {
+	for _, bucket := range pendingCSRAgeBuckets {
+		if bucket.age == 0 || age <= bucket.age {
+			return bucket.label
+		}
+	}
+	return pendingCSRAgeBuckets[len(pendingCSRAgeBuckets)-1].label
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+	testingclock "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+const testNamespace = "open-cluster-management-hub"
+
+func newRegistrationCSR(name string, created time.Time) *certificatesv1.CertificateSigningRequest {
+	csr := testinghelpers.NewCSR(testinghelpers.CSRHolder{
+		Name:       name,
+		Labels:     map[string]string{clusterv1.ClusterNameLabelKey: "cluster1"},
+		SignerName: certificatesv1.KubeAPIServerClientSignerName,
+	})
+	csr.CreationTimestamp = metav1.NewTime(created)
+	return csr
+}
+
+func setCSRCondition(csr *certificatesv1.CertificateSigningRequest, condType certificatesv1.RequestConditionType,
+	reason string, updated time.Time) *certificatesv1.CertificateSigningRequest {
+	csr = csr.DeepCopy()
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           condType,
+		Status:         corev1.ConditionTrue,
+		Reason:         reason,
+		LastUpdateTime: metav1.NewTime(updated),
+	})
+	return csr
+}
+
+func assertPendingCSRs(t *testing.T, expected map[string]float64) {
+	for _, bucket := range pendingCSRAgeBuckets {
+		actual, err := testutil.GetGaugeMetricValue(PendingRegistrationCSRs.WithLabelValues(bucket.label))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected[bucket.label] {
+			t.Errorf("expected %v pending csrs with age %q, but got %v", expected[bucket.label], bucket.label, actual)
+		}
+	}
+}
+
+func histogramCount(t *testing.T, h metrics.ObserverMetric) uint64 {
+	count, err := testutil.GetHistogramMetricCount(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func assertBacklogCondition(t *testing.T, kubeClient *kubefake.Clientset, expected metav1.ConditionStatus) {
+	configMap, err := kubeClient.CoreV1().ConfigMaps(testNamespace).Get(context.TODO(), CSRStatusConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conditions []metav1.Condition
+	if err := json.Unmarshal([]byte(configMap.Data[CSRStatusConditionsKey]), &conditions); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(conditions, CSRApprovalBacklogCondition)
+	if cond == nil || cond.Status != expected {
+		t.Errorf("expected condition %s to be %s, but got %v", CSRApprovalBacklogCondition, expected, cond)
+	}
+}
+
+func TestSyncCSRMetrics(t *testing.T) {
+	Register()
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := testingclock.NewFakeClock(start)
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+	csrStore := kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore()
+
+	ctrl := newCSRMetricsController(
+		kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+		kubeClient.CoreV1(), testNamespace, 15*time.Minute, fakeClock)
+	syncCtx := testingcommon.NewFakeSyncContext(t, "key")
+	sync := func() {
+		if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// csrs approved before the controller starts and the csrs of addons are ignored
+	addonCSR := newRegistrationCSR("addon", start)
+	addonCSR.Labels[addonv1alpha1.AddonLabelKey] = "addon1"
+	for _, csr := range []*certificatesv1.CertificateSigningRequest{
+		setCSRCondition(newRegistrationCSR("old", start.Add(-time.Hour)), certificatesv1.CertificateApproved, "", start.Add(-time.Minute)),
+		addonCSR,
+	} {
+		if err := csrStore.Add(csr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	approvals := histogramCount(t, CSRApprovalDuration.ObserverMetric)
+	issuances := histogramCount(t, CSRIssuanceDuration.ObserverMetric)
+	denials, err := testutil.GetCounterMetricValue(CSRDenials.WithLabelValues("ClusterNameNotAllowed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new csr is pending
+	csr := newRegistrationCSR("csr1", start)
+	if err := csrStore.Add(csr); err != nil {
+		t.Fatal(err)
+	}
+	fakeClock.Step(2 * time.Minute)
+	sync()
+	assertPendingCSRs(t, map[string]float64{"5m": 1})
+	assertBacklogCondition(t, kubeClient, metav1.ConditionFalse)
+
+	// the csr is pending for a long time
+	fakeClock.Step(18 * time.Minute)
+	sync()
+	assertPendingCSRs(t, map[string]float64{"1h": 1})
+	assertBacklogCondition(t, kubeClient, metav1.ConditionTrue)
+
+	// the csr is approved
+	csr = setCSRCondition(csr, certificatesv1.CertificateApproved, "AutoApproved", fakeClock.Now())
+	if err := csrStore.Update(csr); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	sync()
+	assertPendingCSRs(t, map[string]float64{})
+	assertBacklogCondition(t, kubeClient, metav1.ConditionFalse)
+	if actual := histogramCount(t, CSRApprovalDuration.ObserverMetric); actual != approvals+1 {
+		t.Errorf("expected %d approvals observed, but got %d", approvals+1, actual)
+	}
+	if actual := histogramCount(t, CSRIssuanceDuration.ObserverMetric); actual != issuances {
+		t.Errorf("expected %d issuances observed, but got %d", issuances, actual)
+	}
+
+	// the certificate is issued
+	fakeClock.Step(10 * time.Second)
+	csr = csr.DeepCopy()
+	csr.Status.Certificate = []byte("cert")
+	if err := csrStore.Update(csr); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	sync()
+	if actual := histogramCount(t, CSRIssuanceDuration.ObserverMetric); actual != issuances+1 {
+		t.Errorf("expected %d issuances observed, but got %d", issuances+1, actual)
+	}
+
+	// another csr is denied
+	denied := setCSRCondition(newRegistrationCSR("csr2", fakeClock.Now()), certificatesv1.CertificateDenied,
+		"ClusterNameNotAllowed", fakeClock.Now())
+	if err := csrStore.Add(denied); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	sync()
+	actual, err := testutil.GetCounterMetricValue(CSRDenials.WithLabelValues("ClusterNameNotAllowed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != denials+1 {
+		t.Errorf("expected %v denials, but got %v", denials+1, actual)
+	}
+	if actual := histogramCount(t, CSRApprovalDuration.ObserverMetric); actual != approvals+1 {
+		t.Errorf("expected %d approvals observed, but got %d", approvals+1, actual)
+	}
+}
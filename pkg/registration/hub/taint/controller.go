@@ -2,6 +2,8 @@ package taint
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -9,7 +11,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
@@ -20,6 +24,17 @@ import (
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
+const (
+	// ClusterTaintsAnnotation is the annotation on the managed cluster summarizing the keys of its taints, so the
+	// taints are shown in a printer column of the managed cluster, which cannot join the list of the taints.
+	// TODO move this to the api repo as a field of the cluster status
+	ClusterTaintsAnnotation = "cluster.open-cluster-management.io/taints"
+
+	// maxTaintKeysInSummary is the max number of the taint keys in the summary, the number of the other keys is
+	// appended to keep the summary bounded.
+	maxTaintKeysInSummary = 3
+)
+
 var (
 	UnavailableTaint = v1.Taint{
 		Key:    v1.ManagedClusterTaintUnavailable,
@@ -37,6 +52,7 @@ type taintController struct {
 	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
 	clusterLister listerv1.ManagedClusterLister
 	eventRecorder events.Recorder
+	clock         clock.Clock
 }
 
 // NewTaintController creates a new taint controller
@@ -50,6 +66,7 @@ func NewTaintController(
 			clusterClient.ClusterV1().ManagedClusters()),
 		clusterLister: clusterInformer.Lister(),
 		eventRecorder: recorder.WithComponentSuffix("taint-controller"),
+		clock:         clock.RealClock{},
 	}
 	return factory.New().
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
@@ -80,15 +97,28 @@ func (c *taintController) sync(ctx context.Context, syncCtx factory.SyncContext)
 	cond := meta.FindStatusCondition(newManagedCluster.Status.Conditions, v1.ManagedClusterConditionAvailable)
 	var updated bool
 
+	// the taints are added at the time of the controller clock
+	now := metav1.NewTime(c.clock.Now())
+	unavailableTaint, unreachableTaint := UnavailableTaint, UnreachableTaint
+	unavailableTaint.TimeAdded, unreachableTaint.TimeAdded = now, now
+
 	switch {
 	case cond == nil || cond.Status == metav1.ConditionUnknown:
 		updated = helpers.RemoveTaints(&newTaints, UnavailableTaint)
-		updated = helpers.AddTaints(&newTaints, UnreachableTaint) || updated
+		updated = helpers.AddTaints(&newTaints, unreachableTaint) || updated
 	case cond.Status == metav1.ConditionFalse:
 		updated = helpers.RemoveTaints(&newTaints, UnreachableTaint)
-		updated = helpers.AddTaints(&newTaints, UnavailableTaint) || updated
+		updated = helpers.AddTaints(&newTaints, unavailableTaint) || updated
 	case cond.Status == metav1.ConditionTrue:
-		updated = helpers.RemoveTaints(&newTaints, UnavailableTaint, UnreachableTaint)
+		// remove the taints by key, so the stale ones with other values or effects are cleaned up as well
+		updated = helpers.RemoveTaintsByKey(&newTaints, v1.ManagedClusterTaintUnavailable, v1.ManagedClusterTaintUnreachable)
+	}
+
+	// translate the maintenance annotation into the maintenance taint
+	if managedCluster.Annotations[helpers.ManagedClusterMaintenanceAnnotation] == "true" {
+		updated = helpers.SetMaintenanceTaint(&newTaints, now) || updated
+	} else {
+		updated = helpers.UnsetMaintenanceTaint(&newTaints) || updated
 	}
 
 	if updated {
@@ -97,6 +127,38 @@ func (c *taintController) sync(ctx context.Context, syncCtx factory.SyncContext)
 			return err
 		}
 		c.eventRecorder.Eventf("ManagedClusterConditionAvailableUpdated", "Update the original taints to the %+v", newTaints)
+		// the summary of the taints is updated once the cluster with the new taints is synced again.
+		return nil
+	}
+
+	summary := summarizeTaints(managedCluster.Spec.Taints)
+	if managedCluster.Annotations[ClusterTaintsAnnotation] == summary {
+		return nil
+	}
+	if newManagedCluster.Annotations == nil {
+		newManagedCluster.Annotations = map[string]string{}
+	}
+	if len(summary) == 0 {
+		delete(newManagedCluster.Annotations, ClusterTaintsAnnotation)
+	} else {
+		newManagedCluster.Annotations[ClusterTaintsAnnotation] = summary
+	}
+	_, err = c.patcher.PatchLabelAnnotations(ctx, newManagedCluster, newManagedCluster.ObjectMeta, managedCluster.ObjectMeta)
+	return err
+}
+
+// summarizeTaints returns the sorted unique keys of the taints joined by commas, at most maxTaintKeysInSummary
+// keys are listed and the number of the other keys is appended, e.g. "a,b,c,+2".
+func summarizeTaints(taints []v1.Taint) string {
+	keys := sets.New[string]()
+	for _, taint := range taints {
+		keys.Insert(taint.Key)
+	}
+
+	sortedKeys := sets.List(keys)
+	if len(sortedKeys) <= maxTaintKeysInSummary {
+		return strings.Join(sortedKeys, ",")
 	}
-	return nil
+	return fmt.Sprintf("%s,+%d",
+		strings.Join(sortedKeys[:maxTaintKeysInSummary], ","), len(sortedKeys)-maxTaintKeysInSummary)
 }
@@ -8,8 +8,10 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clienttesting "k8s.io/client-go/testing"
+	testingclock "k8s.io/utils/clock/testing"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
@@ -17,10 +19,31 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 )
 
+func taintAddedAt(taint v1.Taint, timeAdded metav1.Time) v1.Taint {
+	taint.TimeAdded = timeAdded
+	return taint
+}
+
 func TestSyncTaintCluster(t *testing.T) {
+	now := metav1.NewTime(time.Now().Round(time.Second))
+	maintenanceTaint := v1.Taint{
+		Key:       helpers.ManagedClusterTaintMaintenance,
+		Effect:    v1.TaintEffectNoSelect,
+		TimeAdded: now,
+	}
+	newMaintenanceCluster := func(annotated bool, taints ...v1.Taint) *v1.ManagedCluster {
+		cluster := testinghelpers.NewAvailableManagedCluster()
+		if annotated {
+			cluster.Annotations = map[string]string{helpers.ManagedClusterMaintenanceAnnotation: "true"}
+		}
+		cluster.Spec.Taints = taints
+		return cluster
+	}
+
 	cases := []struct {
 		name            string
 		startingObjects []runtime.Object
@@ -33,6 +56,26 @@ func TestSyncTaintCluster(t *testing.T) {
 				testingcommon.AssertNoActions(t, actions)
 			},
 		},
+		{
+			name: "stale taints are removed once available",
+			startingObjects: []runtime.Object{newMaintenanceCluster(false,
+				UnreachableTaint,
+				v1.Taint{Key: v1.ManagedClusterTaintUnavailable, Value: "stale", Effect: v1.TaintEffectNoSelectIfNew},
+				v1.Taint{Key: "other", Effect: v1.TaintEffectPreferNoSelect},
+			)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patchData := actions[0].(clienttesting.PatchActionImpl).Patch
+				managedCluster := &v1.ManagedCluster{}
+				if err := json.Unmarshal(patchData, managedCluster); err != nil {
+					t.Fatal(err)
+				}
+				taints := []v1.Taint{{Key: "other", Effect: v1.TaintEffectPreferNoSelect}}
+				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
+					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
+				}
+			},
+		},
 		{
 			name:            "ManagedClusterConditionAvailable conditionStatus is False",
 			startingObjects: []runtime.Object{testinghelpers.NewUnAvailableManagedCluster()},
@@ -44,7 +87,7 @@ func TestSyncTaintCluster(t *testing.T) {
 				if err != nil {
 					t.Fatal(err)
 				}
-				taints := []v1.Taint{UnavailableTaint}
+				taints := []v1.Taint{taintAddedAt(UnavailableTaint, now)}
 				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
 					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
 				}
@@ -61,7 +104,7 @@ func TestSyncTaintCluster(t *testing.T) {
 				if err != nil {
 					t.Fatal(err)
 				}
-				taints := []v1.Taint{UnreachableTaint}
+				taints := []v1.Taint{taintAddedAt(UnreachableTaint, now)}
 				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
 					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
 				}
@@ -78,12 +121,60 @@ func TestSyncTaintCluster(t *testing.T) {
 				if err != nil {
 					t.Fatal(err)
 				}
-				taints := []v1.Taint{UnreachableTaint}
+				taints := []v1.Taint{taintAddedAt(UnreachableTaint, now)}
 				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
 					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
 				}
 			},
 		},
+		{
+			name:            "enter maintenance",
+			startingObjects: []runtime.Object{newMaintenanceCluster(true)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patchData := actions[0].(clienttesting.PatchActionImpl).Patch
+				managedCluster := &v1.ManagedCluster{}
+				err := json.Unmarshal(patchData, managedCluster)
+				if err != nil {
+					t.Fatal(err)
+				}
+				taints := []v1.Taint{maintenanceTaint}
+				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
+					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
+				}
+			},
+		},
+		{
+			name: "in maintenance",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := newMaintenanceCluster(true, v1.Taint{
+					Key:       helpers.ManagedClusterTaintMaintenance,
+					Effect:    v1.TaintEffectNoSelect,
+					TimeAdded: metav1.NewTime(now.Add(-time.Hour)),
+				})
+				cluster.Annotations[ClusterTaintsAnnotation] = helpers.ManagedClusterTaintMaintenance
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:            "exit maintenance",
+			startingObjects: []runtime.Object{newMaintenanceCluster(false, maintenanceTaint)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patchData := actions[0].(clienttesting.PatchActionImpl).Patch
+				managedCluster := &v1.ManagedCluster{}
+				err := json.Unmarshal(patchData, managedCluster)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(managedCluster.Spec.Taints) != 0 {
+					t.Errorf("expected no taints, but actualTaints: %#v", managedCluster.Spec.Taints)
+				}
+			},
+		},
 		{
 			name:            "sync a deleted spoke cluster",
 			startingObjects: []runtime.Object{},
@@ -108,7 +199,8 @@ func TestSyncTaintCluster(t *testing.T) {
 				patcher.NewPatcher[
 					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
 					clusterClient.ClusterV1().ManagedClusters()),
-				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), eventstesting.NewTestingEventRecorder(t)}
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), eventstesting.NewTestingEventRecorder(t),
+				testingclock.NewFakeClock(now.Time)}
 			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
 			if syncErr != nil {
 				t.Errorf("unexpected err: %v", syncErr)
@@ -118,3 +210,96 @@ func TestSyncTaintCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncTaintsSummary(t *testing.T) {
+	newTaint := func(key string) v1.Taint {
+		return v1.Taint{Key: key, Effect: v1.TaintEffectNoSelect}
+	}
+	newCluster := func(summary string, taints ...v1.Taint) *v1.ManagedCluster {
+		cluster := testinghelpers.NewAvailableManagedCluster()
+		if len(summary) > 0 {
+			cluster.Annotations = map[string]string{ClusterTaintsAnnotation: summary}
+		}
+		cluster.Spec.Taints = taints
+		return cluster
+	}
+
+	cases := []struct {
+		name            string
+		cluster         *v1.ManagedCluster
+		expectedSummary *string
+	}{
+		{
+			name:    "no taints",
+			cluster: newCluster(""),
+		},
+		{
+			name:            "taint added",
+			cluster:         newCluster("", newTaint("example.com/gpu")),
+			expectedSummary: stringPtr("example.com/gpu"),
+		},
+		{
+			name:            "taint changed",
+			cluster:         newCluster("example.com/gpu", newTaint("example.com/gpu"), newTaint("example.com/arm")),
+			expectedSummary: stringPtr("example.com/arm,example.com/gpu"),
+		},
+		{
+			name:    "taints not changed",
+			cluster: newCluster("example.com/arm,example.com/gpu", newTaint("example.com/gpu"), newTaint("example.com/arm")),
+		},
+		{
+			name: "taints bounded",
+			cluster: newCluster("example.com/gpu",
+				newTaint("e"), newTaint("d"), newTaint("c"), newTaint("b"), newTaint("a"),
+				v1.Taint{Key: "a", Effect: v1.TaintEffectPreferNoSelect}),
+			expectedSummary: stringPtr("a,b,c,+2"),
+		},
+		{
+			name:            "taints removed",
+			cluster:         newCluster("example.com/gpu"),
+			expectedSummary: stringPtr(""),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := taintController{
+				patcher.NewPatcher[
+					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), eventstesting.NewTestingEventRecorder(t),
+				testingclock.NewFakeClock(time.Now())}
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			if c.expectedSummary == nil {
+				testingcommon.AssertNoActions(t, clusterClient.Actions())
+				return
+			}
+			testingcommon.AssertActions(t, clusterClient.Actions(), "patch")
+			cluster, err := clusterClient.ClusterV1().ManagedClusters().Get(
+				context.TODO(), testinghelpers.TestManagedClusterName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, ok := cluster.Annotations[ClusterTaintsAnnotation]
+			if len(*c.expectedSummary) == 0 && ok {
+				t.Errorf("expected the taints annotation removed, but got %q", summary)
+			}
+			if summary != *c.expectedSummary {
+				t.Errorf("expected taints summary %q, but got %q", *c.expectedSummary, summary)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -3,15 +3,20 @@ package addon
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	coordv1 "k8s.io/api/coordination/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	coordv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/utils/clock"
@@ -41,6 +46,10 @@ type managedClusterAddOnLeaseController struct {
 	hubLeaseClient        coordv1client.CoordinationV1Interface
 	managementLeaseClient coordv1client.CoordinationV1Interface
 	spokeLeaseClient      coordv1client.CoordinationV1Interface
+
+	// leaseNamespaces caches the namespaces of the addon leases found by the search in all the namespaces.
+	leaseNamespaces     map[string]string
+	leaseNamespacesLock sync.Mutex
 }
 
 // NewManagedClusterAddOnLeaseController returns an instance of managedClusterAddOnLeaseController
@@ -62,6 +71,7 @@ func NewManagedClusterAddOnLeaseController(clusterName string,
 		hubLeaseClient:        hubLeaseClient,
 		managementLeaseClient: managementLeaseClient,
 		spokeLeaseClient:      spokeLeaseClient,
+		leaseNamespaces:       map[string]string{},
 	}
 
 	// TODO We do not add leaser informer to support kubernetes version lower than 1.17. Lease v1 api
@@ -127,56 +137,43 @@ func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
 	}
 
 	// addon lease name should be same with the addon name.
-	observedLease, err := leaseClient.Leases(leaseNamespace).Get(ctx, addOn.Name, metav1.GetOptions{})
+	observedLease, triedNamespaces, err := c.resolveLease(ctx, leaseClient, leaseNamespace, addOn)
+	if err != nil {
+		return err
+	}
 
 	var condition metav1.Condition
-	switch {
-	case errors.IsNotFound(err):
+	if observedLease == nil {
 		// for backward compatible, for lower versions kubernetes (less than 1.14), addons update their leases on hub
 		// cluster, so if we cannot find addon lease on managed/management cluster, we will try to use addon hub lease.
 		// TODO remove this after we no longer support lower versions kubernetes (less than 1.14)
 		observedLease, err = c.hubLeaseClient.Leases(addOn.Namespace).Get(ctx, addOn.Name, metav1.GetOptions{})
-		if err == nil {
-			if now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
-				// the lease is constantly updated, update its addon status to available
-				condition = metav1.Condition{
-					Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-					Status:  metav1.ConditionTrue,
-					Reason:  "ManagedClusterAddOnLeaseUpdated",
-					Message: fmt.Sprintf("%s add-on is available.", addOn.Name),
-				}
-				break
-			}
-
-			// the lease is not constantly updated, update its addon status to unavailable
-			condition = metav1.Condition{
-				Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-				Status:  metav1.ConditionFalse,
-				Reason:  "ManagedClusterAddOnLeaseUpdateStopped",
-				Message: fmt.Sprintf("%s add-on is not available.", addOn.Name),
-			}
-			break
+		switch {
+		case errors.IsNotFound(err):
+			observedLease = nil
+		case err != nil:
+			return err
 		}
+	}
+
+	switch {
+	case observedLease == nil:
 		condition = metav1.Condition{
-			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-			Status:  metav1.ConditionUnknown,
-			Reason:  "ManagedClusterAddOnLeaseNotFound",
-			Message: fmt.Sprintf("The status of %s add-on is unknown.", addOn.Name),
+			Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status: metav1.ConditionUnknown,
+			Reason: "ManagedClusterAddOnLeaseNotFound",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, its lease is not found in the namespaces %s "+
+				"or any other namespace.", addOn.Name, strings.Join(triedNamespaces, ", ")),
 		}
-	case err != nil:
-		return err
-	case err == nil:
-		if now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
-			// the lease is constantly updated, update its addon status to available
-			condition = metav1.Condition{
-				Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-				Status:  metav1.ConditionTrue,
-				Reason:  "ManagedClusterAddOnLeaseUpdated",
-				Message: fmt.Sprintf("%s add-on is available.", addOn.Name),
-			}
-			break
+	case now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)):
+		// the lease is constantly updated, update its addon status to available
+		condition = metav1.Condition{
+			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ManagedClusterAddOnLeaseUpdated",
+			Message: fmt.Sprintf("%s add-on is available.", addOn.Name),
 		}
-
+	default:
 		// the lease is not constantly updated, update its addon status to unavailable
 		condition = metav1.Condition{
 			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
@@ -195,12 +192,91 @@ func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
 	if updated {
 		recorder.Eventf("ManagedClusterAddOnStatusUpdated",
 			"update managed cluster addon %q available condition to %q with its lease %q/%q status",
-			addOn.Name, condition.Status, leaseNamespace, addOn.Name)
+			addOn.Name, condition.Status, observedLeaseNamespace(observedLease, leaseNamespace), addOn.Name)
 	}
 
 	return nil
 }
 
+// resolveLease returns the lease of the addon. The agent of an addon may run in a namespace other than the
+// installation namespace, so the lease is looked up in the namespace resolved last time, the namespace in the
+// queue key, the namespace in the addon status and the install namespace in the addon spec in order. If it is
+// not found in any of them, it is searched in all the namespaces, and the namespace found is cached. It returns
+// nil with the namespaces tried if the lease is not found.
+func (c *managedClusterAddOnLeaseController) resolveLease(ctx context.Context, leaseClient coordv1client.CoordinationV1Interface,
+	leaseNamespace string, addOn *addonv1alpha1.ManagedClusterAddOn) (*coordv1.Lease, []string, error) {
+	c.leaseNamespacesLock.Lock()
+	cached, ok := c.leaseNamespaces[addOn.Name]
+	c.leaseNamespacesLock.Unlock()
+
+	candidates := []string{}
+	if ok {
+		candidates = append(candidates, cached)
+	}
+	candidates = append(candidates, leaseNamespace, addOn.Status.Namespace, addOn.Spec.InstallNamespace,
+		defaultAddOnInstallationNamespace)
+
+	tried := sets.New[string]()
+	var triedNamespaces []string
+	for _, namespace := range candidates {
+		if namespace == "" || tried.Has(namespace) {
+			continue
+		}
+		tried.Insert(namespace)
+		triedNamespaces = append(triedNamespaces, namespace)
+
+		lease, err := leaseClient.Leases(namespace).Get(ctx, addOn.Name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			continue
+		case err != nil:
+			return nil, nil, err
+		}
+		return lease, triedNamespaces, nil
+	}
+
+	// search the lease in all the namespaces, the one renewed last is used if there are more than one
+	leases, err := leaseClient.Leases(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", addOn.Name).String(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var found *coordv1.Lease
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if lease.Name != addOn.Name {
+			continue
+		}
+		if found == nil || renewTime(lease).After(renewTime(found)) {
+			found = lease
+		}
+	}
+
+	c.leaseNamespacesLock.Lock()
+	defer c.leaseNamespacesLock.Unlock()
+	if found == nil {
+		delete(c.leaseNamespaces, addOn.Name)
+		return nil, triedNamespaces, nil
+	}
+	c.leaseNamespaces[addOn.Name] = found.Namespace
+	return found, triedNamespaces, nil
+}
+
+func renewTime(lease *coordv1.Lease) time.Time {
+	if lease.Spec.RenewTime == nil {
+		return time.Time{}
+	}
+	return lease.Spec.RenewTime.Time
+}
+
+func observedLeaseNamespace(lease *coordv1.Lease, leaseNamespace string) string {
+	if lease == nil {
+		return leaseNamespace
+	}
+	return lease.Namespace
+}
+
 func (c *managedClusterAddOnLeaseController) queueKeyFunc(lease runtime.Object) string {
 	accessor, _ := meta.Accessor(lease)
 
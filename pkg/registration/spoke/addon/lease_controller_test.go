@@ -3,6 +3,7 @@ package addon
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -158,6 +159,78 @@ func TestSync(t *testing.T) {
 				if addOnCond.Status != metav1.ConditionUnknown {
 					t.Errorf("expected addon available condition is unknown, but failed")
 				}
+				if !strings.Contains(addOnCond.Message, "test, open-cluster-management-agent-addon") {
+					t.Errorf("expected the namespaces tried in the message, but got %q", addOnCond.Message)
+				}
+			},
+		},
+		{
+			name:     "addon lease in the default namespace",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("open-cluster-management-agent-addon", "test", now),
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				err := json.Unmarshal(patch, addOn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
+				if addOnCond == nil {
+					t.Errorf("expected addon available condition, but failed")
+					return
+				}
+				if addOnCond.Status != metav1.ConditionTrue {
+					t.Errorf("expected addon available condition is available, but failed")
+				}
+			},
+		},
+		{
+			name:     "addon lease in a custom namespace",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("custom", "test", now),
+				testinghelpers.NewAddOnLease("custom", "other", now),
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				err := json.Unmarshal(patch, addOn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
+				if addOnCond == nil {
+					t.Errorf("expected addon available condition, but failed")
+					return
+				}
+				if addOnCond.Status != metav1.ConditionTrue {
+					t.Errorf("expected addon available condition is available, but failed")
+				}
 			},
 		},
 		{
@@ -401,6 +474,7 @@ func TestSync(t *testing.T) {
 				addOnLister:           addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
 				managementLeaseClient: managementLeaseClient.CoordinationV1(),
 				spokeLeaseClient:      spokeLeaseClient.CoordinationV1(),
+				leaseNamespaces:       map[string]string{},
 			}
 			syncCtx := testingcommon.NewFakeSyncContext(t, c.queueKey)
 			syncErr := ctrl.sync(context.TODO(), syncCtx)
@@ -412,3 +486,56 @@ func TestSync(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaseNamespaceCache(t *testing.T) {
+	addOn := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      "test",
+		},
+		Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+			InstallNamespace: "test",
+		},
+	}
+	spokeLeaseClient := kubefake.NewSimpleClientset(testinghelpers.NewAddOnLease("custom", "test", now))
+	ctrl := &managedClusterAddOnLeaseController{
+		spokeLeaseClient: spokeLeaseClient.CoordinationV1(),
+		leaseNamespaces:  map[string]string{},
+	}
+
+	// the lease is searched in all the namespaces at first
+	lease, _, err := ctrl.resolveLease(context.TODO(), ctrl.spokeLeaseClient, "test", addOn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease == nil || lease.Namespace != "custom" {
+		t.Fatalf("expected the lease in the custom namespace, but got %v", lease)
+	}
+	testingcommon.AssertActions(t, spokeLeaseClient.Actions(), "get", "get", "list")
+
+	// the namespace found is cached
+	spokeLeaseClient.ClearActions()
+	if _, _, err := ctrl.resolveLease(context.TODO(), ctrl.spokeLeaseClient, "test", addOn); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, spokeLeaseClient.Actions(), "get")
+
+	// the cache is dropped once the lease is removed
+	spokeLeaseClient.ClearActions()
+	if err := spokeLeaseClient.CoordinationV1().Leases("custom").Delete(context.TODO(), "test", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lease, tried, err := ctrl.resolveLease(context.TODO(), ctrl.spokeLeaseClient, "test", addOn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease != nil {
+		t.Errorf("expected no lease, but got %v", lease)
+	}
+	if strings.Join(tried, ",") != "custom,test,open-cluster-management-agent-addon" {
+		t.Errorf("unexpected namespaces tried %v", tried)
+	}
+	if _, ok := ctrl.leaseNamespaces["test"]; ok {
+		t.Errorf("expected the cached namespace dropped")
+	}
+}
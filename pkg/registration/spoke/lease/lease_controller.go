@@ -8,15 +8,22 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
 
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 const leaseUpdateJitterFactor = 0.25
@@ -29,20 +36,36 @@ type managedClusterLeaseController struct {
 	leaseUpdater             *leaseUpdater
 }
 
-// NewManagedClusterLeaseController creates a new managed cluster lease controller on the managed cluster.
+// NewManagedClusterLeaseController creates a new managed cluster lease controller on the managed cluster. The lease
+// is renewed in the leaseNamespace with the leaseName on the hub, an empty leaseNamespace or leaseName means the
+// cluster namespace or the default lease name.
 func NewManagedClusterLeaseController(
 	clusterName string,
+	leaseNamespace string,
+	leaseName string,
 	hubClient clientset.Interface,
+	hubClusterClient clusterclientset.Interface,
 	hubClusterInformer clusterv1informer.ManagedClusterInformer,
 	recorder events.Recorder) factory.Controller {
+	if len(leaseNamespace) == 0 {
+		leaseNamespace = clusterName
+	}
+	if len(leaseName) == 0 {
+		leaseName = helpers.DefaultClusterLeaseName
+	}
 	c := &managedClusterLeaseController{
 		clusterName:      clusterName,
 		hubClusterLister: hubClusterInformer.Lister(),
 		leaseUpdater: &leaseUpdater{
-			hubClient:   hubClient,
-			clusterName: clusterName,
-			leaseName:   "managed-cluster-lease",
-			recorder:    recorder,
+			hubClient: hubClient,
+			patcher: patcher.NewPatcher[
+				*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+				hubClusterClient.ClusterV1().ManagedClusters()),
+			hubClusterLister: hubClusterInformer.Lister(),
+			clusterName:      clusterName,
+			leaseNamespace:   leaseNamespace,
+			leaseName:        leaseName,
+			recorder:         recorder,
 		},
 	}
 
@@ -85,14 +108,20 @@ func (c *managedClusterLeaseController) sync(ctx context.Context, syncCtx factor
 	return nil
 }
 
-// leaseUpdater periodically updates the lease of a managed cluster
+// leaseUpdater periodically updates the lease of a managed cluster. If the lease observed by the hub is not the
+// desired one, the updater migrates the hub to the desired lease without a gap of the heartbeat: the desired lease
+// is created and renewed together with the observed lease, and the hub is pointed to the desired lease by the
+// lease annotations of the managed cluster. The observed lease is abandoned only once the annotations are observed.
 type leaseUpdater struct {
-	hubClient   clientset.Interface
-	clusterName string
-	leaseName   string
-	lock        sync.Mutex
-	cancel      context.CancelFunc
-	recorder    events.Recorder
+	hubClient        clientset.Interface
+	patcher          patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
+	hubClusterLister clusterv1listers.ManagedClusterLister
+	clusterName      string
+	leaseNamespace   string
+	leaseName        string
+	lock             sync.Mutex
+	cancel           context.CancelFunc
+	recorder         events.Recorder
 }
 
 // start a lease update routine to update the lease of a managed cluster periodically.
@@ -125,14 +154,85 @@ func (u *leaseUpdater) stop() {
 
 // update the lease of a given managed cluster.
 func (u *leaseUpdater) update(ctx context.Context) {
-	lease, err := u.hubClient.CoordinationV1().Leases(u.clusterName).Get(ctx, u.leaseName, metav1.GetOptions{})
+	cluster, err := u.hubClusterLister.Get(u.clusterName)
 	if err != nil {
-		utilruntime.HandleError(fmt.Errorf("unable to get cluster lease %q on hub cluster: %w", u.leaseName, err))
+		// the lease observed by the hub is unknown, renew the desired lease only.
+		utilruntime.HandleError(fmt.Errorf("unable to get managed cluster %q from hub: %w", u.clusterName, err))
+		if err := u.renew(ctx, u.leaseNamespace, u.leaseName, false); err != nil {
+			utilruntime.HandleError(err)
+		}
+		return
+	}
+
+	observedNamespace, observedName := helpers.ClusterLeaseLocation(cluster)
+	if observedNamespace == u.leaseNamespace && observedName == u.leaseName {
+		if err := u.renew(ctx, u.leaseNamespace, u.leaseName, false); err != nil {
+			utilruntime.HandleError(err)
+		}
+		return
+	}
+
+	// keep renewing the lease observed by the hub until the hub is pointed to the desired lease.
+	if err := u.renew(ctx, observedNamespace, observedName, false); err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	// the lease in the cluster namespace is created by the hub once the hub observes it, the agent creates the
+	// lease in the other locations.
+	isDefault := u.leaseNamespace == u.clusterName && u.leaseName == helpers.DefaultClusterLeaseName
+	err = u.renew(ctx, u.leaseNamespace, u.leaseName, !isDefault)
+	if err != nil && !(isDefault && errors.IsNotFound(err)) {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	newCluster := cluster.DeepCopy()
+	if isDefault {
+		delete(newCluster.Annotations, helpers.ClusterLeaseNamespaceAnnotation)
+		delete(newCluster.Annotations, helpers.ClusterLeaseNameAnnotation)
+	} else {
+		if newCluster.Annotations == nil {
+			newCluster.Annotations = map[string]string{}
+		}
+		newCluster.Annotations[helpers.ClusterLeaseNamespaceAnnotation] = u.leaseNamespace
+		newCluster.Annotations[helpers.ClusterLeaseNameAnnotation] = u.leaseName
+	}
+	if _, err := u.patcher.PatchLabelAnnotations(ctx, newCluster, newCluster.ObjectMeta, cluster.ObjectMeta); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to point the hub to the cluster lease %s/%s: %w",
+			u.leaseNamespace, u.leaseName, err))
 		return
 	}
+	u.recorder.Eventf("ManagedClusterLeaseMigrated", "Migrate the lease of cluster %q from %s/%s to %s/%s",
+		u.clusterName, observedNamespace, observedName, u.leaseNamespace, u.leaseName)
+}
+
+// renew the lease in the namespace with the name, the lease is created if it is not found and create is true.
+func (u *leaseUpdater) renew(ctx context.Context, namespace, name string, create bool) error {
+	lease, err := u.hubClient.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err) && create:
+		lease = &coordv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{clusterv1.ClusterNameLabelKey: u.clusterName},
+			},
+			Spec: coordv1.LeaseSpec{
+				HolderIdentity: pointer.String(name),
+				RenewTime:      &metav1.MicroTime{Time: time.Now()},
+			},
+		}
+		if _, err := u.hubClient.CoordinationV1().Leases(namespace).Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("unable to create cluster lease %s/%s on hub cluster: %w", namespace, name, err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to get cluster lease %s/%s on hub cluster: %w", namespace, name, err)
+	}
 
 	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
-	if _, err = u.hubClient.CoordinationV1().Leases(u.clusterName).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
-		utilruntime.HandleError(fmt.Errorf("unable to update cluster lease %q on hub cluster: %w", u.leaseName, err))
+	if _, err = u.hubClient.CoordinationV1().Leases(namespace).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update cluster lease %s/%s on hub cluster: %w", namespace, name, err)
 	}
+	return nil
 }
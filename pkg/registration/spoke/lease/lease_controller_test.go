@@ -2,19 +2,24 @@ package lease
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 )
 
@@ -65,10 +70,15 @@ func TestLeaseUpdate(t *testing.T) {
 			hubClient := kubefake.NewSimpleClientset(testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now()))
 
 			leaseUpdater := &leaseUpdater{
-				hubClient:   hubClient,
-				clusterName: testinghelpers.TestManagedClusterName,
-				leaseName:   "managed-cluster-lease",
-				recorder:    eventstesting.NewTestingEventRecorder(t),
+				hubClient: hubClient,
+				patcher: patcher.NewPatcher[
+					*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterName:      testinghelpers.TestManagedClusterName,
+				leaseNamespace:   testinghelpers.TestManagedClusterName,
+				leaseName:        "managed-cluster-lease",
+				recorder:         eventstesting.NewTestingEventRecorder(t),
 			}
 
 			if c.needToStartUpdateBefore {
@@ -91,3 +101,78 @@ func TestLeaseUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaseMigration(t *testing.T) {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	if err := clusterStore.Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	oldLease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now().Add(-time.Minute))
+	hubClient := kubefake.NewSimpleClientset(oldLease)
+
+	updater := &leaseUpdater{
+		hubClient: hubClient,
+		patcher: patcher.NewPatcher[
+			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		clusterName:      testinghelpers.TestManagedClusterName,
+		leaseNamespace:   "cloud-managed",
+		leaseName:        "cluster-heartbeat",
+		recorder:         eventstesting.NewTestingEventRecorder(t),
+	}
+
+	// creating the new lease fails, the old lease is still renewed and the hub is not pointed to the new lease.
+	hubClient.PrependReactor("create", "leases", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("forbidden")
+	})
+	updater.update(context.TODO())
+	testingcommon.AssertActions(t, hubClient.Actions(), "get", "update", "get", "create")
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+	hubClient.ReactionChain = hubClient.ReactionChain[1:]
+
+	// the new lease is created and both leases are renewed during the overlap window, then the hub is pointed to
+	// the new lease.
+	hubClient.ClearActions()
+	updater.update(context.TODO())
+	testingcommon.AssertActions(t, hubClient.Actions(), "get", "update", "get", "create")
+	testingcommon.AssertActions(t, clusterClient.Actions(), "patch")
+	newLease, err := hubClient.CoordinationV1().Leases("cloud-managed").Get(context.TODO(), "cluster-heartbeat", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newLease.Labels[clusterv1.ClusterNameLabelKey] != testinghelpers.TestManagedClusterName {
+		t.Errorf("expected the new lease labeled with the cluster name, but got %v", newLease.Labels)
+	}
+
+	// the annotations are not observed yet, both leases are still renewed.
+	hubClient.ClearActions()
+	clusterClient.ClearActions()
+	updater.update(context.TODO())
+	testingcommon.AssertActions(t, hubClient.Actions(), "get", "update", "get", "update")
+
+	// the annotations are observed, only the new lease is renewed.
+	patched, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), cluster.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespace, name := helpers.ClusterLeaseLocation(patched)
+	if namespace != "cloud-managed" || name != "cluster-heartbeat" {
+		t.Errorf("expected the cluster pointed to the new lease, but got %s/%s", namespace, name)
+	}
+	if err := clusterStore.Update(patched); err != nil {
+		t.Fatal(err)
+	}
+	hubClient.ClearActions()
+	clusterClient.ClearActions()
+	updater.update(context.TODO())
+	testingcommon.AssertActions(t, hubClient.Actions(), "get", "update")
+	if hubClient.Actions()[0].GetNamespace() != "cloud-managed" {
+		t.Errorf("expected the new lease renewed, but got %s", hubClient.Actions()[0].GetNamespace())
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+}
@@ -0,0 +1,103 @@
+package managedcluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// AgentIdentity describes the running registration agent.
+type AgentIdentity struct {
+	Version              string
+	GitCommit            string
+	KlusterletGeneration string
+	PodNamespace         string
+	PodName              string
+	// ObserveOnly indicates the cluster is registered for the inventory only, without a work agent.
+	ObserveOnly bool
+}
+
+func (i AgentIdentity) annotations() map[string]string {
+	annotations := map[string]string{
+		helpers.AgentVersionAnnotation:              i.Version,
+		helpers.AgentGitCommitAnnotation:            i.GitCommit,
+		helpers.AgentKlusterletGenerationAnnotation: i.KlusterletGeneration,
+		helpers.AgentPodNamespaceAnnotation:         i.PodNamespace,
+		helpers.AgentPodNameAnnotation:              i.PodName,
+		helpers.ObserveOnlyAnnotation:               "",
+	}
+	if i.ObserveOnly {
+		annotations[helpers.ObserveOnlyAnnotation] = "true"
+	}
+	return annotations
+}
+
+// agentIdentityController publishes the identity of the running agent as annotations on
+// the managed cluster, so the agent version of each cluster is visible on the hub. Since
+// the identity is fixed for the lifetime of the agent, the annotations are refreshed once
+// the agent is restarted, e.g. after an upgrade.
+type agentIdentityController struct {
+	clusterName      string
+	identity         AgentIdentity
+	patcher          patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
+	hubClusterLister clusterv1listers.ManagedClusterLister
+}
+
+// NewAgentIdentityController creates a controller to maintain the agent identity annotations on the managed cluster.
+func NewAgentIdentityController(
+	clusterName string,
+	identity AgentIdentity,
+	hubClusterClient clientset.Interface,
+	hubClusterInformer clusterv1informer.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &agentIdentityController{
+		clusterName: clusterName,
+		identity:    identity,
+		patcher: patcher.NewPatcher[
+			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+			hubClusterClient.ClusterV1().ManagedClusters()),
+		hubClusterLister: hubClusterInformer.Lister(),
+	}
+
+	return factory.New().
+		WithInformers(hubClusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("AgentIdentityController", recorder)
+}
+
+func (c *agentIdentityController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	cluster, err := c.hubClusterLister.Get(c.clusterName)
+	if errors.IsNotFound(err) {
+		// the managed cluster is not created yet, it will be handled once it is created.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get managed cluster %q from hub: %w", c.clusterName, err)
+	}
+
+	newCluster := cluster.DeepCopy()
+	if newCluster.Annotations == nil {
+		newCluster.Annotations = map[string]string{}
+	}
+	for key, value := range c.identity.annotations() {
+		if len(value) == 0 {
+			delete(newCluster.Annotations, key)
+			continue
+		}
+		newCluster.Annotations[key] = value
+	}
+
+	_, err = c.patcher.PatchLabelAnnotations(ctx, newCluster, newCluster.ObjectMeta, cluster.ObjectMeta)
+	return err
+}
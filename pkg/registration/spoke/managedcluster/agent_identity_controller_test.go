@@ -0,0 +1,127 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncAgentIdentity(t *testing.T) {
+	identity := AgentIdentity{
+		Version:              "v0.12.0",
+		GitCommit:            "abcdef",
+		KlusterletGeneration: "2",
+		PodNamespace:         "open-cluster-management-agent",
+		PodName:              "klusterlet-registration-agent-abc",
+	}
+
+	newClusterWithAnnotations := func(annotations map[string]string) *clusterv1.ManagedCluster {
+		cluster := testinghelpers.NewAcceptedManagedCluster()
+		cluster.Annotations = annotations
+		return cluster
+	}
+
+	cases := []struct {
+		name                string
+		startingObjects     []runtime.Object
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:            "no managed cluster",
+			startingObjects: []runtime.Object{},
+		},
+		{
+			name:            "publish agent identity",
+			startingObjects: []runtime.Object{newClusterWithAnnotations(nil)},
+			expectedAnnotations: map[string]string{
+				helpers.AgentVersionAnnotation:              "v0.12.0",
+				helpers.AgentGitCommitAnnotation:            "abcdef",
+				helpers.AgentKlusterletGenerationAnnotation: "2",
+				helpers.AgentPodNamespaceAnnotation:         "open-cluster-management-agent",
+				helpers.AgentPodNameAnnotation:              "klusterlet-registration-agent-abc",
+			},
+		},
+		{
+			name: "agent is upgraded",
+			startingObjects: []runtime.Object{newClusterWithAnnotations(map[string]string{
+				"test":                                      "test",
+				helpers.AgentVersionAnnotation:              "v0.11.0",
+				helpers.AgentGitCommitAnnotation:            "123456",
+				helpers.AgentKlusterletGenerationAnnotation: "1",
+				helpers.AgentPodNamespaceAnnotation:         "open-cluster-management-agent",
+				helpers.AgentPodNameAnnotation:              "klusterlet-registration-agent-xyz",
+			})},
+			expectedAnnotations: map[string]string{
+				helpers.AgentVersionAnnotation:              "v0.12.0",
+				helpers.AgentGitCommitAnnotation:            "abcdef",
+				helpers.AgentKlusterletGenerationAnnotation: "2",
+				helpers.AgentPodNameAnnotation:              "klusterlet-registration-agent-abc",
+			},
+		},
+		{
+			name: "agent identity is up to date",
+			startingObjects: []runtime.Object{newClusterWithAnnotations(map[string]string{
+				helpers.AgentVersionAnnotation:              "v0.12.0",
+				helpers.AgentGitCommitAnnotation:            "abcdef",
+				helpers.AgentKlusterletGenerationAnnotation: "2",
+				helpers.AgentPodNamespaceAnnotation:         "open-cluster-management-agent",
+				helpers.AgentPodNameAnnotation:              "klusterlet-registration-agent-abc",
+			})},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.startingObjects...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.startingObjects {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &agentIdentityController{
+				clusterName: testinghelpers.TestManagedClusterName,
+				identity:    identity,
+				patcher: patcher.NewPatcher[
+					*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, ""))
+			testingcommon.AssertError(t, syncErr, "")
+
+			actions := clusterClient.Actions()
+			if len(c.expectedAnnotations) == 0 {
+				testingcommon.AssertNoActions(t, actions)
+				return
+			}
+
+			testingcommon.AssertActions(t, actions, "patch")
+			patch := actions[0].(clienttesting.PatchAction).GetPatch()
+			managedCluster := &clusterv1.ManagedCluster{}
+			if err := json.Unmarshal(patch, managedCluster); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(managedCluster.Annotations, c.expectedAnnotations) {
+				t.Errorf("expected annotations %v, but got %v", c.expectedAnnotations, managedCluster.Annotations)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/clock"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+const (
+	// ClusterInfoNamespace is the namespace of the configmaps the CA bundle of the managed cluster kube-apiserver
+	// is read from.
+	ClusterInfoNamespace = "kube-public"
+	// clusterInfoConfigMap is published by kubeadm with a kubeconfig containing the CA bundle of the kube-apiserver.
+	clusterInfoConfigMap = "cluster-info"
+	// rootCAConfigMap is published in each namespace with the CA bundle to verify the kube-apiserver, it is read
+	// if the cluster-info configmap does not exist.
+	rootCAConfigMap = "kube-root-ca.crt"
+)
+
+var (
+	// CABundleRotationDebounce is the time the CA bundle must keep unchanged before it is published to the hub, so
+	// the repeated changes during a rotation are published once. It is exposed so that integration tests can
+	// crank up the controller sync speed.
+	CABundleRotationDebounce = 1 * time.Minute
+)
+
+// caBundleController publishes the CA bundle of the managed cluster kube-apiserver in the ManagedClusterClientConfigs
+// of the external server urls once it is rotated. The new CA bundle is appended as a client config, and the
+// client config controller on the hub replaces the entry with the older CA bundle of the same url.
+type caBundleController struct {
+	clusterName             string
+	spokeExternalServerURLs []string
+	hubClusterClient        clientset.Interface
+	hubClusterLister        clusterv1listers.ManagedClusterLister
+	clusterInfoLister       corev1listers.ConfigMapLister
+	rootCALister            corev1listers.ConfigMapLister
+	clock                   clock.Clock
+
+	// observedCABundle is the last observed CA bundle and observedTime is the time it is observed.
+	observedCABundle []byte
+	observedTime     time.Time
+	// publishedCABundle is the last CA bundle published to the hub. It is not published again if the hub drops
+	// it, e.g. an older CA bundle than the existing one, to avoid updating the managed cluster repeatedly.
+	publishedCABundle []byte
+}
+
+// NewCABundleInformerFactories returns the informer factories of the cluster-info and the kube-root-ca.crt
+// configmaps. Each factory watches a single configmap by its name, since the agent is only permitted to read the
+// configmaps in kube-public by their names.
+func NewCABundleInformerFactories(kubeClient kubernetes.Interface, resync time.Duration) (
+	clusterInfo, rootCA informers.SharedInformerFactory) {
+	newFactory := func(name string) informers.SharedInformerFactory {
+		return informers.NewSharedInformerFactoryWithOptions(kubeClient, resync,
+			informers.WithNamespace(ClusterInfoNamespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			}))
+	}
+	return newFactory(clusterInfoConfigMap), newFactory(rootCAConfigMap)
+}
+
+// NewCABundleController creates a controller to publish the rotated CA bundle of the managed cluster kube-apiserver.
+func NewCABundleController(
+	clusterName string,
+	spokeExternalServerURLs []string,
+	hubClusterClient clientset.Interface,
+	hubClusterInformer clusterv1informer.ManagedClusterInformer,
+	clusterInfoInformer corev1informers.ConfigMapInformer,
+	rootCAInformer corev1informers.ConfigMapInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &caBundleController{
+		clusterName:             clusterName,
+		spokeExternalServerURLs: spokeExternalServerURLs,
+		hubClusterClient:        hubClusterClient,
+		hubClusterLister:        hubClusterInformer.Lister(),
+		clusterInfoLister:       clusterInfoInformer.Lister(),
+		rootCALister:            rootCAInformer.Lister(),
+		clock:                   clock.RealClock{},
+	}
+
+	return factory.New().
+		WithInformers(hubClusterInformer.Informer(), clusterInfoInformer.Informer(), rootCAInformer.Informer()).
+		WithSync(c.sync).
+		ToController("CABundleController", recorder)
+}
+
+func (c *caBundleController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	caBundle, err := c.getCABundle()
+	if err != nil {
+		return err
+	}
+	if len(caBundle) == 0 {
+		// the CA bundle is not published on the managed cluster, keep the client configs as they are.
+		return nil
+	}
+
+	now := c.clock.Now()
+	if !bytes.Equal(caBundle, c.observedCABundle) {
+		c.observedCABundle = caBundle
+		c.observedTime = now
+	}
+
+	cluster, err := c.hubClusterLister.Get(c.clusterName)
+	if errors.IsNotFound(err) {
+		// the managed cluster is not created yet, it will be handled once it is created.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get managed cluster %q from hub: %w", c.clusterName, err)
+	}
+
+	clientConfigs := rotatedClientConfigs(cluster.Spec.ManagedClusterClientConfigs, c.spokeExternalServerURLs, caBundle)
+	if len(clientConfigs) == len(cluster.Spec.ManagedClusterClientConfigs) || bytes.Equal(caBundle, c.publishedCABundle) {
+		return nil
+	}
+
+	// wait until the CA bundle keeps unchanged for a while
+	if elapsed := now.Sub(c.observedTime); elapsed < CABundleRotationDebounce {
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), CABundleRotationDebounce-elapsed)
+		return nil
+	}
+
+	newCluster := cluster.DeepCopy()
+	newCluster.Spec.ManagedClusterClientConfigs = clientConfigs
+	if newCluster.Annotations == nil {
+		newCluster.Annotations = map[string]string{}
+	}
+	newCluster.Annotations[helpers.CABundleRotationTimestampAnnotation] = now.UTC().Format(time.RFC3339)
+	if _, err := c.hubClusterClient.ClusterV1().ManagedClusters().Update(ctx, newCluster, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to publish the rotated CA bundle of managed cluster %q: %w", c.clusterName, err)
+	}
+	c.publishedCABundle = caBundle
+	syncCtx.Recorder().Eventf("CABundleRotated",
+		"The rotated CA bundle of managed cluster %q is published to hub", c.clusterName)
+	return nil
+}
+
+// getCABundle returns the CA bundle of the kube-apiserver from the cluster-info configmap, or from the
+// kube-root-ca.crt configmap if the cluster-info configmap does not exist.
+func (c *caBundleController) getCABundle() ([]byte, error) {
+	clusterInfo, err := c.clusterInfoLister.ConfigMaps(ClusterInfoNamespace).Get(clusterInfoConfigMap)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return nil, err
+	default:
+		return caBundleFromKubeconfig([]byte(clusterInfo.Data["kubeconfig"]))
+	}
+
+	rootCA, err := c.rootCALister.ConfigMaps(ClusterInfoNamespace).Get(rootCAConfigMap)
+	switch {
+	case errors.IsNotFound(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return []byte(rootCA.Data["ca.crt"]), nil
+}
+
+// caBundleFromKubeconfig returns the CA bundle of the first cluster by name in the kubeconfig.
+func caBundleFromKubeconfig(kubeconfig []byte) ([]byte, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig of configmap %s/%s: %w",
+			ClusterInfoNamespace, clusterInfoConfigMap, err)
+	}
+	var names []string
+	for name := range config.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if caBundle := config.Clusters[name].CertificateAuthorityData; len(caBundle) > 0 {
+			return caBundle, nil
+		}
+	}
+	return nil, nil
+}
+
+// rotatedClientConfigs appends a client config with the CA bundle for each of the external server urls whose
+// client configs do not have the CA bundle yet.
+func rotatedClientConfigs(clientConfigs []clusterv1.ClientConfig, urls []string, caBundle []byte) []clusterv1.ClientConfig {
+	rotated := append([]clusterv1.ClientConfig{}, clientConfigs...)
+	for _, url := range urls {
+		published := false
+		for _, clientConfig := range clientConfigs {
+			if clientConfig.URL == url && bytes.Equal(clientConfig.CABundle, caBundle) {
+				published = true
+				break
+			}
+		}
+		if !published {
+			rotated = append(rotated, clusterv1.ClientConfig{URL: url, CABundle: caBundle})
+		}
+	}
+	return rotated
+}
@@ -0,0 +1,192 @@
+package managedcluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+const testServerURL = "https://api.cluster1:6443"
+
+func newClusterInfo(t *testing.T, caBundle []byte) *corev1.ConfigMap {
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"": {Server: testServerURL, CertificateAuthorityData: caBundle},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterInfoConfigMap, Namespace: ClusterInfoNamespace},
+		Data:       map[string]string{"kubeconfig": string(kubeconfig)},
+	}
+}
+
+func newRootCA(caBundle []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: rootCAConfigMap, Namespace: ClusterInfoNamespace},
+		Data:       map[string]string{"ca.crt": string(caBundle)},
+	}
+}
+
+type testCABundleController struct {
+	controller     *caBundleController
+	clusterClient  *clusterfake.Clientset
+	configMapStore cache.Store
+	clock          *clocktesting.FakeClock
+}
+
+func newTestCABundleController(t *testing.T, cluster *clusterv1.ManagedCluster, configMaps ...runtime.Object) *testCABundleController {
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), time.Minute*10)
+	configMapStore := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore()
+	for _, configMap := range configMaps {
+		if err := configMapStore.Add(configMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	return &testCABundleController{
+		controller: &caBundleController{
+			clusterName:             testinghelpers.TestManagedClusterName,
+			spokeExternalServerURLs: []string{testServerURL},
+			hubClusterClient:        clusterClient,
+			hubClusterLister:        clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			clusterInfoLister:       kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+			rootCALister:            kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+			clock:                   fakeClock,
+		},
+		clusterClient:  clusterClient,
+		configMapStore: configMapStore,
+		clock:          fakeClock,
+	}
+}
+
+func (c *testCABundleController) sync(t *testing.T) {
+	syncErr := c.controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, ""))
+	testingcommon.AssertError(t, syncErr, "")
+}
+
+func assertPublishedClientConfigs(t *testing.T, actions []clienttesting.Action, expected []clusterv1.ClientConfig) {
+	testingcommon.AssertActions(t, actions, "update")
+	cluster := actions[0].(clienttesting.UpdateAction).GetObject().(*clusterv1.ManagedCluster)
+	if !reflect.DeepEqual(cluster.Spec.ManagedClusterClientConfigs, expected) {
+		t.Errorf("expected client configs %v, but got %v", expected, cluster.Spec.ManagedClusterClientConfigs)
+	}
+	if _, ok := cluster.Annotations[helpers.CABundleRotationTimestampAnnotation]; !ok {
+		t.Errorf("expected annotation %s, but not found", helpers.CABundleRotationTimestampAnnotation)
+	}
+}
+
+func TestSyncCABundle(t *testing.T) {
+	oldCA := []byte("old-ca")
+	newCA := []byte("new-ca")
+
+	cases := []struct {
+		name                  string
+		configMaps            []runtime.Object
+		expectedClientConfigs []clusterv1.ClientConfig
+	}{
+		{
+			name: "no ca bundle on the managed cluster",
+		},
+		{
+			name:       "ca bundle is not rotated",
+			configMaps: []runtime.Object{newClusterInfo(t, oldCA)},
+		},
+		{
+			name:       "publish the rotated ca bundle in cluster-info",
+			configMaps: []runtime.Object{newClusterInfo(t, newCA), newRootCA(oldCA)},
+			expectedClientConfigs: []clusterv1.ClientConfig{
+				{URL: testServerURL, CABundle: oldCA},
+				{URL: testServerURL, CABundle: newCA},
+			},
+		},
+		{
+			name:       "publish the rotated ca bundle in kube-root-ca.crt",
+			configMaps: []runtime.Object{newRootCA(newCA)},
+			expectedClientConfigs: []clusterv1.ClientConfig{
+				{URL: testServerURL, CABundle: oldCA},
+				{URL: testServerURL, CABundle: newCA},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAcceptedManagedCluster()
+			cluster.Spec.ManagedClusterClientConfigs = []clusterv1.ClientConfig{{URL: testServerURL, CABundle: oldCA}}
+			ctrl := newTestCABundleController(t, cluster, c.configMaps...)
+
+			// the ca bundle is not published until it keeps unchanged for a while
+			ctrl.sync(t)
+			testingcommon.AssertNoActions(t, ctrl.clusterClient.Actions())
+
+			ctrl.clock.Step(CABundleRotationDebounce)
+			ctrl.sync(t)
+			if len(c.expectedClientConfigs) == 0 {
+				testingcommon.AssertNoActions(t, ctrl.clusterClient.Actions())
+				return
+			}
+			assertPublishedClientConfigs(t, ctrl.clusterClient.Actions(), c.expectedClientConfigs)
+
+			// the ca bundle is published once
+			ctrl.clusterClient.ClearActions()
+			ctrl.sync(t)
+			testingcommon.AssertNoActions(t, ctrl.clusterClient.Actions())
+		})
+	}
+}
+
+func TestSyncCABundleDebounce(t *testing.T) {
+	oldCA := []byte("old-ca")
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Spec.ManagedClusterClientConfigs = []clusterv1.ClientConfig{{URL: testServerURL, CABundle: oldCA}}
+	ctrl := newTestCABundleController(t, cluster, newRootCA([]byte("new-ca-1")))
+
+	ctrl.sync(t)
+	ctrl.clock.Step(CABundleRotationDebounce / 2)
+
+	// the ca bundle is changed again during the rotation
+	if err := ctrl.configMapStore.Update(newRootCA([]byte("new-ca-2"))); err != nil {
+		t.Fatal(err)
+	}
+	ctrl.sync(t)
+	ctrl.clock.Step(CABundleRotationDebounce / 2)
+	ctrl.sync(t)
+	testingcommon.AssertNoActions(t, ctrl.clusterClient.Actions())
+
+	ctrl.clock.Step(CABundleRotationDebounce / 2)
+	ctrl.sync(t)
+	assertPublishedClientConfigs(t, ctrl.clusterClient.Actions(), []clusterv1.ClientConfig{
+		{URL: testServerURL, CABundle: oldCA},
+		{URL: testServerURL, CABundle: []byte("new-ca-2")},
+	})
+}
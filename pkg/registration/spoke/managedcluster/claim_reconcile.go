@@ -3,6 +3,7 @@ package managedcluster
 import (
 	"context"
 	"fmt"
+	"path"
 	"sort"
 
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -10,21 +11,35 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/sets"
+	corev1lister "k8s.io/client-go/listers/core/v1"
 
 	clusterv1alpha1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
 	"open-cluster-management.io/ocm/pkg/features"
 )
 
-const labelCustomizedOnly = "open-cluster-management.io/spoke-only"
+const (
+	labelCustomizedOnly = "open-cluster-management.io/spoke-only"
+
+	// maxClaimValueLength is the max length of the value of a cluster claim.
+	maxClaimValueLength = 1024
+)
 
 type claimReconcile struct {
 	recorder               events.Recorder
+	reporter               *agentevents.Reporter
 	claimLister            clusterv1alpha1listers.ClusterClaimLister
+	nodeLister             corev1lister.NodeLister
 	maxCustomClusterClaims int
+	// nodeLabelClaimKeys are the keys of the node labels whose values are exposed as cluster claims.
+	nodeLabelClaimKeys []string
+	// excludeClaimPatterns are the patterns of the names of the claims which are not exposed, the reserved
+	// claims are always exposed.
+	excludeClaimPatterns []string
 }
 
 func (r *claimReconcile) reconcile(ctx context.Context, cluster *clusterv1.ManagedCluster) (*clusterv1.ManagedCluster, reconcileState, error) {
@@ -38,12 +53,18 @@ func (r *claimReconcile) reconcile(ctx context.Context, cluster *clusterv1.Manag
 	}
 
 	err := r.exposeClaims(ctx, cluster)
+	if err != nil {
+		r.reporter.Reportf(ctx, agentevents.CategoryClaimCollectionFailed, "Failed to collect the cluster claims: %v", err)
+	}
 	return cluster, reconcileContinue, err
 }
 
 // exposeClaims saves cluster claims fetched on managed cluster into status of the
 // managed cluster on hub. Some of the customized claims might not be exposed once
-// the total number of the claims exceeds the value of `cluster-claims-max`.
+// the total number of the claims exceeds the value of `cluster-claims-max`. The claims
+// of the node labels are exposed after the reserved claims, and take precedence over
+// the customized claims with the same names. The claims matching the exclude patterns are not exposed, and
+// are removed from the status once they are excluded.
 func (r *claimReconcile) exposeClaims(ctx context.Context, cluster *clusterv1.ManagedCluster) error {
 	reservedClaims := []clusterv1.ManagedClusterClaim{}
 	customClaims := []clusterv1.ManagedClusterClaim{}
@@ -56,6 +77,15 @@ func (r *claimReconcile) exposeClaims(ctx context.Context, cluster *clusterv1.Ma
 		return fmt.Errorf("unable to list cluster claims: %w", err)
 	}
 
+	nodeLabelClaims, err := r.nodeLabelClaims()
+	if err != nil {
+		return err
+	}
+	nodeLabelClaimNames := sets.NewString()
+	for _, claim := range nodeLabelClaims {
+		nodeLabelClaimNames.Insert(claim.Name)
+	}
+
 	reservedClaimNames := sets.NewString(clusterv1alpha1.ReservedClusterClaimNames[:]...)
 	for _, clusterClaim := range clusterClaims {
 		managedClusterClaim := clusterv1.ManagedClusterClaim{
@@ -66,6 +96,9 @@ func (r *claimReconcile) exposeClaims(ctx context.Context, cluster *clusterv1.Ma
 			reservedClaims = append(reservedClaims, managedClusterClaim)
 			continue
 		}
+		if nodeLabelClaimNames.Has(clusterClaim.Name) || r.isExcluded(clusterClaim.Name) {
+			continue
+		}
 		customClaims = append(customClaims, managedClusterClaim)
 	}
 
@@ -86,8 +119,80 @@ func (r *claimReconcile) exposeClaims(ctx context.Context, cluster *clusterv1.Ma
 			n, r.maxCustomClusterClaims, n-r.maxCustomClusterClaims)
 	}
 
-	// merge reserved claims and custom claims
-	claims := append(reservedClaims, customClaims...)
+	// merge reserved claims, node label claims and custom claims
+	claims := append(reservedClaims, nodeLabelClaims...)
+	claims = append(claims, customClaims...)
 	cluster.Status.ClusterClaims = claims
 	return nil
 }
+
+// nodeLabelClaims returns a claim for each of the node label keys present on the nodes. The claim is named after
+// the label key, and its value is the sorted values of the label on the nodes separated by commas, e.g.
+// topology.kubernetes.io/zone: "us-east-1a,us-east-1b". The values exceeding the max length of a claim value
+// are dropped.
+func (r *claimReconcile) nodeLabelClaims() ([]clusterv1.ManagedClusterClaim, error) {
+	if len(r.nodeLabelClaimKeys) == 0 {
+		return nil, nil
+	}
+
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes: %w", err)
+	}
+
+	var claims []clusterv1.ManagedClusterClaim
+	for _, key := range sets.NewString(r.nodeLabelClaimKeys...).List() {
+		values := sets.NewString()
+		for _, node := range nodes {
+			if value, ok := node.Labels[key]; ok && len(value) > 0 {
+				values.Insert(value)
+			}
+		}
+		if values.Len() == 0 || r.isExcluded(key) {
+			continue
+		}
+
+		claimValue := ""
+		for _, value := range values.List() {
+			if len(claimValue) > 0 && len(claimValue)+len(value)+1 > maxClaimValueLength {
+				r.recorder.Eventf("NodeLabelClaimTruncated",
+					"The values of node label %q exceed the max length of a cluster claim value", key)
+				break
+			}
+			if len(claimValue) > 0 {
+				claimValue += ","
+			}
+			claimValue += value
+		}
+		claims = append(claims, clusterv1.ManagedClusterClaim{Name: key, Value: claimValue})
+	}
+	return claims, nil
+}
+
+// isExcluded returns true if the name of a claim matches any of the exclude patterns.
+func (r *claimReconcile) isExcluded(name string) bool {
+	for _, pattern := range r.excludeClaimPatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateClaimExcludePatterns validates the patterns of the names of the claims which are not exposed. A
+// pattern follows the syntax of path.Match, e.g. *.internal.example.com, and should not match any of the
+// reserved claims.
+func ValidateClaimExcludePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid cluster claim exclude pattern %q: %w", pattern, err)
+		}
+		for _, name := range clusterv1alpha1.ReservedClusterClaimNames {
+			if matched, _ := path.Match(pattern, name); matched {
+				return fmt.Errorf("cluster claim exclude pattern %q should not match the reserved claim %q",
+					pattern, name)
+			}
+		}
+	}
+	return nil
+}
@@ -3,11 +3,14 @@ package managedcluster
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubeinformers "k8s.io/client-go/informers"
@@ -110,7 +113,10 @@ func TestSync(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				kubeInformerFactory.Core().V1().Nodes(),
 				20,
+				nil,
+				nil,
 				eventstesting.NewTestingEventRecorder(t),
+				nil,
 			)
 
 			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, ""))
@@ -127,6 +133,7 @@ func TestExposeClaims(t *testing.T) {
 		cluster                *clusterv1.ManagedCluster
 		claims                 []*clusterv1alpha1.ClusterClaim
 		maxCustomClusterClaims int
+		excludeClaimPatterns   []string
 		validateActions        func(t *testing.T, actions []clienttesting.Action)
 		expectedErr            string
 	}{
@@ -251,6 +258,39 @@ func TestExposeClaims(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "remove excluded claims from managed cluster",
+			cluster: newManagedCluster([]clusterv1.ManagedClusterClaim{
+				{Name: "id.k8s.io", Value: "cluster1"},
+				{Name: "a", Value: "b"},
+				{Name: "host.internal.example.com", Value: "10.0.0.1"},
+			}),
+			claims: []*clusterv1alpha1.ClusterClaim{
+				newClusterClaim("id.k8s.io", "cluster1"),
+				newClusterClaim("a", "b"),
+				newClusterClaim("host.internal.example.com", "10.0.0.1"),
+				newClusterClaim("registry.internal.example.com", "registry.local"),
+			},
+			// the reserved claims are exposed even if they match the patterns
+			excludeClaimPatterns: []string{"*.internal.example.com", "*.k8s.io"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				cluster := &clusterv1.ManagedCluster{}
+				err := json.Unmarshal(patch, cluster)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := []clusterv1.ManagedClusterClaim{
+					{Name: "id.k8s.io", Value: "cluster1"},
+					{Name: "a", Value: "b"},
+				}
+				actual := cluster.Status.ClusterClaims
+				if !reflect.DeepEqual(actual, expected) {
+					t.Errorf("expected cluster claim %v but got: %v", expected, actual)
+				}
+			},
+		},
 		{
 			name:    "sync non-customized-only claims into status of the managed cluster",
 			cluster: testinghelpers.NewJoinedManagedCluster(),
@@ -333,7 +373,10 @@ func TestExposeClaims(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				kubeInformerFactory.Core().V1().Nodes(),
 				c.maxCustomClusterClaims,
+				nil,
+				c.excludeClaimPatterns,
 				eventstesting.NewTestingEventRecorder(t),
+				nil,
 			)
 
 			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, c.cluster.Name))
@@ -349,3 +392,147 @@ func newManagedCluster(claims []clusterv1.ManagedClusterClaim) *clusterv1.Manage
 	cluster.Status.ClusterClaims = claims
 	return cluster
 }
+
+func TestNodeLabelClaims(t *testing.T) {
+	newNode := func(name string, labels map[string]string) *corev1.Node {
+		node := testinghelpers.NewNode(name, testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32))
+		node.Labels = labels
+		return node
+	}
+	zones := make([]string, 100)
+	for i := range zones {
+		zones[i] = fmt.Sprintf("zone-%02d-%s", i, strings.Repeat("x", 8))
+	}
+
+	cases := []struct {
+		name               string
+		nodes              []*corev1.Node
+		claims             []*clusterv1alpha1.ClusterClaim
+		nodeLabelClaimKeys []string
+		excludePatterns    []string
+		expectedClaims     []clusterv1.ManagedClusterClaim
+	}{
+		{
+			name:  "no node label claim keys",
+			nodes: []*corev1.Node{newNode("node1", map[string]string{"topology.kubernetes.io/zone": "us-east-1a"})},
+		},
+		{
+			name: "heterogeneous zones",
+			nodes: []*corev1.Node{
+				newNode("node1", map[string]string{"topology.kubernetes.io/zone": "us-east-1b", "gpu": "a100"}),
+				newNode("node2", map[string]string{"topology.kubernetes.io/zone": "us-east-1a"}),
+				newNode("node3", map[string]string{"topology.kubernetes.io/zone": "us-east-1b", "gpu": ""}),
+				newNode("node4", nil),
+			},
+			claims: []*clusterv1alpha1.ClusterClaim{
+				newClusterClaim("id.k8s.io", "cluster1"),
+				newClusterClaim("a", "b"),
+				// the custom claim is overridden by the node label claim
+				newClusterClaim("gpu", "none"),
+			},
+			nodeLabelClaimKeys: []string{"topology.kubernetes.io/zone", "gpu", "node.kubernetes.io/instance-type"},
+			expectedClaims: []clusterv1.ManagedClusterClaim{
+				{Name: "id.k8s.io", Value: "cluster1"},
+				{Name: "gpu", Value: "a100"},
+				{Name: "topology.kubernetes.io/zone", Value: "us-east-1a,us-east-1b"},
+				{Name: "a", Value: "b"},
+			},
+		},
+		{
+			name: "excluded node label claims",
+			nodes: []*corev1.Node{
+				newNode("node1", map[string]string{"zone": "us-east-1a", "hostname.internal": "node1"}),
+			},
+			nodeLabelClaimKeys: []string{"zone", "hostname.internal"},
+			excludePatterns:    []string{"*.internal"},
+			expectedClaims:     []clusterv1.ManagedClusterClaim{{Name: "zone", Value: "us-east-1a"}},
+		},
+		{
+			name: "truncate the values of a node label",
+			nodes: func() []*corev1.Node {
+				var nodes []*corev1.Node
+				for i, zone := range zones {
+					nodes = append(nodes, newNode(fmt.Sprintf("node%d", i), map[string]string{"zone": zone}))
+				}
+				return nodes
+			}(),
+			nodeLabelClaimKeys: []string{"zone"},
+			expectedClaims: []clusterv1.ManagedClusterClaim{
+				// each value has 16 characters with a comma, so 60 values fit into the max length
+				{Name: "zone", Value: strings.Join(zones[:60], ",")},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), time.Minute*10)
+			for _, node := range c.nodes {
+				if err := kubeInformerFactory.Core().V1().Nodes().Informer().GetStore().Add(node); err != nil {
+					t.Fatal(err)
+				}
+			}
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), time.Minute*10)
+			for _, claim := range c.claims {
+				if err := clusterInformerFactory.Cluster().V1alpha1().ClusterClaims().Informer().GetStore().Add(claim); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := &claimReconcile{
+				recorder:               eventstesting.NewTestingEventRecorder(t),
+				claimLister:            clusterInformerFactory.Cluster().V1alpha1().ClusterClaims().Lister(),
+				nodeLister:             kubeInformerFactory.Core().V1().Nodes().Lister(),
+				maxCustomClusterClaims: 20,
+				nodeLabelClaimKeys:     c.nodeLabelClaimKeys,
+				excludeClaimPatterns:   c.excludePatterns,
+			}
+			cluster := testinghelpers.NewJoinedManagedCluster()
+			if err := r.exposeClaims(context.TODO(), cluster); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cluster.Status.ClusterClaims, c.expectedClaims) &&
+				(len(cluster.Status.ClusterClaims) > 0 || len(c.expectedClaims) > 0) {
+				t.Errorf("expected cluster claims %v but got: %v", c.expectedClaims, cluster.Status.ClusterClaims)
+			}
+		})
+	}
+}
+
+func newClusterClaim(name, value string) *clusterv1alpha1.ClusterClaim {
+	return &clusterv1alpha1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       clusterv1alpha1.ClusterClaimSpec{Value: value},
+	}
+}
+
+func TestValidateClaimExcludePatterns(t *testing.T) {
+	cases := []struct {
+		name        string
+		patterns    []string
+		expectedErr string
+	}{
+		{
+			name: "no patterns",
+		},
+		{
+			name:     "valid patterns",
+			patterns: []string{"*.internal.example.com", "hostname", "region?"},
+		},
+		{
+			name:        "invalid pattern",
+			patterns:    []string{"[a-"},
+			expectedErr: `invalid cluster claim exclude pattern "[a-": syntax error in pattern`,
+		},
+		{
+			name:        "pattern matching a reserved claim",
+			patterns:    []string{"*.internal.example.com", "*.open-cluster-management.io"},
+			expectedErr: `cluster claim exclude pattern "*.open-cluster-management.io" should not match the reserved claim "kubeversion.open-cluster-management.io"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testingcommon.AssertError(t, ValidateClaimExcludePatterns(c.patterns), c.expectedErr)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -15,14 +16,28 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 )
 
+const (
+	// ManagedClusterConditionSpokeAPIServerDegraded is the condition type of a managed cluster indicating the
+	// registration agent cannot reach the kube-apiserver of the managed cluster, while the cluster may still be
+	// reachable from the hub.
+	// TODO move this to the api repo
+	ManagedClusterConditionSpokeAPIServerDegraded = "SpokeApiServerDegraded"
+
+	// defaultSpokeAPIServerUnavailableGracePeriod is the duration the available condition of a managed cluster
+	// is kept when its kube-apiserver becomes unavailable, e.g. during a control plane upgrade.
+	defaultSpokeAPIServerUnavailableGracePeriod = 5 * time.Minute
+)
+
 type resoureReconcile struct {
 	managedClusterDiscoveryClient discovery.DiscoveryInterface
 	nodeLister                    corev1lister.NodeLister
+	unavailableGracePeriod        time.Duration
 }
 
 func (r *resoureReconcile) reconcile(ctx context.Context, cluster *clusterv1.ManagedCluster) (*clusterv1.ManagedCluster, reconcileState, error) {
 	// check the kube-apiserver health on managed cluster.
 	condition := r.checkKubeAPIServerStatus(ctx)
+	r.setDegradedCondition(cluster, condition)
 
 	// the managed cluster kube-apiserver is health, update its version and resources if necessary.
 	if condition.Status == metav1.ConditionTrue {
@@ -49,10 +64,51 @@ func (r *resoureReconcile) reconcile(ctx context.Context, cluster *clusterv1.Man
 		cluster.Status.Version = *clusterVersion
 	}
 
+	if condition.Status == metav1.ConditionFalse && r.withinUnavailableGracePeriod(cluster) {
+		// keep the available condition of the managed cluster, the degraded condition is used to indicate the
+		// kube-apiserver is unavailable temporarily.
+		return cluster, reconcileContinue, nil
+	}
+
 	meta.SetStatusCondition(&cluster.Status.Conditions, condition)
 	return cluster, reconcileContinue, nil
 }
 
+// setDegradedCondition sets the SpokeApiServerDegraded condition with the result of the kube-apiserver health check.
+func (r *resoureReconcile) setDegradedCondition(cluster *clusterv1.ManagedCluster, available metav1.Condition) {
+	if available.Status == metav1.ConditionTrue {
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:    ManagedClusterConditionSpokeAPIServerDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  "SpokeApiServerAvailable",
+			Message: "The kube-apiserver of the managed cluster is reachable from the registration agent",
+		})
+		return
+	}
+
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:    ManagedClusterConditionSpokeAPIServerDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  available.Reason,
+		Message: available.Message,
+	})
+}
+
+// withinUnavailableGracePeriod returns true if the managed cluster is available and its kube-apiserver has been
+// unavailable for a duration shorter than the grace period.
+func (r *resoureReconcile) withinUnavailableGracePeriod(cluster *clusterv1.ManagedCluster) bool {
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable) {
+		return false
+	}
+
+	degraded := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionSpokeAPIServerDegraded)
+	if degraded == nil || degraded.Status != metav1.ConditionTrue {
+		return false
+	}
+
+	return time.Since(degraded.LastTransitionTime.Time) < r.unavailableGracePeriod
+}
+
 // using readyz api to check the status of kube apiserver
 func (r *resoureReconcile) checkKubeAPIServerStatus(ctx context.Context) metav1.Condition {
 	statusCode := 0
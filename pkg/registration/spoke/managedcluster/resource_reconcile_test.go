@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -154,6 +156,43 @@ func TestHealthCheck(t *testing.T) {
 				testinghelpers.AssertManagedClusterStatus(t, managedCluster.Status, expectedStatus)
 			},
 		},
+		{
+			name:     "aggregate extended resources",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			nodes: []runtime.Object{
+				newGPUNode("gpunode1", 4, false),
+				newGPUNode("gpunode2", 2, true),
+				testinghelpers.NewNode("testnode1", testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32)),
+			},
+			httpStatus: http.StatusOK,
+			validateActions: func(t *testing.T, clusterClient *clusterfake.Clientset) {
+				expectedStatus := clusterv1.ManagedClusterStatus{
+					Version: clusterv1.ManagedClusterVersion{
+						Kubernetes: "test-version",
+					},
+					Capacity: clusterv1.ResourceList{
+						clusterv1.ResourceCPU:    *resource.NewQuantity(int64(96), resource.DecimalExponent),
+						clusterv1.ResourceMemory: *resource.NewQuantity(int64(1024*1024*192), resource.BinarySI),
+						"nvidia.com/gpu":         *resource.NewQuantity(int64(6), resource.DecimalExponent),
+					},
+					// the allocatable resources of the unschedulable node are not counted
+					Allocatable: clusterv1.ResourceList{
+						clusterv1.ResourceCPU:    *resource.NewQuantity(int64(32), resource.DecimalExponent),
+						clusterv1.ResourceMemory: *resource.NewQuantity(int64(1024*1024*64), resource.BinarySI),
+						"nvidia.com/gpu":         *resource.NewQuantity(int64(4), resource.DecimalExponent),
+					},
+				}
+				actions := clusterClient.Actions()
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				managedCluster := &clusterv1.ManagedCluster{}
+				err := json.Unmarshal(patch, managedCluster)
+				if err != nil {
+					t.Fatal(err)
+				}
+				testinghelpers.AssertManagedClusterStatus(t, managedCluster.Status, expectedStatus)
+			},
+		},
 		{
 			name:       "there is no livez endpoint",
 			clusters:   []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
@@ -284,7 +323,10 @@ func TestHealthCheck(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				kubeInformerFactory.Core().V1().Nodes(),
 				20,
+				nil,
+				nil,
 				eventstesting.NewTestingEventRecorder(t),
+				nil,
 			)
 			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, ""))
 			testingcommon.AssertError(t, syncErr, c.expectedErr)
@@ -293,3 +335,94 @@ func TestHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+// newGPUNode returns a node exposing the extended resource nvidia.com/gpu.
+func newGPUNode(name string, gpus int64, unschedulable bool) *corev1.Node {
+	node := testinghelpers.NewNode(name, testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32))
+	node.Status.Capacity["nvidia.com/gpu"] = *resource.NewQuantity(gpus, resource.DecimalExponent)
+	node.Status.Allocatable["nvidia.com/gpu"] = *resource.NewQuantity(gpus, resource.DecimalExponent)
+	node.Spec.Unschedulable = unschedulable
+	return node
+}
+
+func TestSpokeAPIServerDegraded(t *testing.T) {
+	serverResponse := &serverResponse{}
+	apiServer, discoveryClient := newDiscoveryServer(t, serverResponse)
+	defer apiServer.Close()
+
+	newDegradedCluster := func(since time.Time) *clusterv1.ManagedCluster {
+		cluster := testinghelpers.NewAvailableManagedCluster()
+		cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+			ManagedClusterConditionSpokeAPIServerDegraded,
+			"True",
+			"ManagedClusterKubeAPIServerUnavailable",
+			"The kube-apiserver is not ok",
+			&metav1.Time{Time: since},
+		))
+		return cluster
+	}
+
+	cases := []struct {
+		name              string
+		cluster           *clusterv1.ManagedCluster
+		httpStatus        int
+		expectedAvailable metav1.ConditionStatus
+		expectedDegraded  metav1.ConditionStatus
+	}{
+		{
+			name:              "kube-apiserver becomes unavailable",
+			cluster:           testinghelpers.NewAvailableManagedCluster(),
+			httpStatus:        http.StatusInternalServerError,
+			expectedAvailable: metav1.ConditionTrue,
+			expectedDegraded:  metav1.ConditionTrue,
+		},
+		{
+			name:              "kube-apiserver is unavailable within the grace period",
+			cluster:           newDegradedCluster(time.Now().Add(-1 * time.Minute)),
+			httpStatus:        http.StatusInternalServerError,
+			expectedAvailable: metav1.ConditionTrue,
+			expectedDegraded:  metav1.ConditionTrue,
+		},
+		{
+			name:              "kube-apiserver is unavailable beyond the grace period",
+			cluster:           newDegradedCluster(time.Now().Add(-10 * time.Minute)),
+			httpStatus:        http.StatusInternalServerError,
+			expectedAvailable: metav1.ConditionFalse,
+			expectedDegraded:  metav1.ConditionTrue,
+		},
+		{
+			name:              "kube-apiserver recovers",
+			cluster:           newDegradedCluster(time.Now().Add(-1 * time.Minute)),
+			httpStatus:        http.StatusOK,
+			expectedAvailable: metav1.ConditionTrue,
+			expectedDegraded:  metav1.ConditionFalse,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+
+			serverResponse.httpStatus = c.httpStatus
+			reconciler := &resoureReconcile{
+				managedClusterDiscoveryClient: discoveryClient,
+				nodeLister:                    kubeInformerFactory.Core().V1().Nodes().Lister(),
+				unavailableGracePeriod:        defaultSpokeAPIServerUnavailableGracePeriod,
+			}
+
+			cluster, _, err := reconciler.reconcile(context.TODO(), c.cluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			available := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+			if available == nil || available.Status != c.expectedAvailable {
+				t.Errorf("expected available condition %s, but got %v", c.expectedAvailable, available)
+			}
+			degraded := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionSpokeAPIServerDegraded)
+			if degraded == nil || degraded.Status != c.expectedDegraded {
+				t.Errorf("expected degraded condition %s, but got %v", c.expectedDegraded, degraded)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 )
 
@@ -49,8 +50,11 @@ func NewManagedClusterStatusController(
 	claimInformer clusterv1alpha1informer.ClusterClaimInformer,
 	nodeInformer corev1informers.NodeInformer,
 	maxCustomClusterClaims int,
+	nodeLabelClaimKeys []string,
+	excludeClaimPatterns []string,
 	resyncInterval time.Duration,
-	recorder events.Recorder) factory.Controller {
+	recorder events.Recorder,
+	reporter *agentevents.Reporter) factory.Controller {
 	c := newManagedClusterStatusController(
 		clusterName,
 		hubClusterClient,
@@ -59,7 +63,10 @@ func NewManagedClusterStatusController(
 		claimInformer,
 		nodeInformer,
 		maxCustomClusterClaims,
+		nodeLabelClaimKeys,
+		excludeClaimPatterns,
 		recorder,
+		reporter,
 	)
 
 	return factory.New().
@@ -77,7 +84,10 @@ func newManagedClusterStatusController(
 	claimInformer clusterv1alpha1informer.ClusterClaimInformer,
 	nodeInformer corev1informers.NodeInformer,
 	maxCustomClusterClaims int,
-	recorder events.Recorder) *managedClusterStatusController {
+	nodeLabelClaimKeys []string,
+	excludeClaimPatterns []string,
+	recorder events.Recorder,
+	reporter *agentevents.Reporter) *managedClusterStatusController {
 	return &managedClusterStatusController{
 		clusterName: clusterName,
 		patcher: patcher.NewPatcher[
@@ -85,8 +95,20 @@ func newManagedClusterStatusController(
 			hubClusterClient.ClusterV1().ManagedClusters()),
 		reconcilers: []statusReconcile{
 			&joiningReconcile{recorder: recorder},
-			&resoureReconcile{managedClusterDiscoveryClient: managedClusterDiscoveryClient, nodeLister: nodeInformer.Lister()},
-			&claimReconcile{claimLister: claimInformer.Lister(), recorder: recorder, maxCustomClusterClaims: maxCustomClusterClaims},
+			&resoureReconcile{
+				managedClusterDiscoveryClient: managedClusterDiscoveryClient,
+				nodeLister:                    nodeInformer.Lister(),
+				unavailableGracePeriod:        defaultSpokeAPIServerUnavailableGracePeriod,
+			},
+			&claimReconcile{
+				claimLister:            claimInformer.Lister(),
+				nodeLister:             nodeInformer.Lister(),
+				recorder:               recorder,
+				reporter:               reporter,
+				maxCustomClusterClaims: maxCustomClusterClaims,
+				nodeLabelClaimKeys:     nodeLabelClaimKeys,
+				excludeClaimPatterns:   excludeClaimPatterns,
+			},
 		},
 		hubClusterLister: hubClusterInformer.Lister(),
 	}
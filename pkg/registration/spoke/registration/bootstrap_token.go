@@ -0,0 +1,92 @@
+package registration
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync/atomic"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// bootstrapKubeconfigKey is the key of the kubeconfig in the bootstrap kubeconfig secret
+const bootstrapKubeconfigKey = "kubeconfig"
+
+// NewBootstrapTokenExpiryHandler returns a watch error handler of the bootstrap csr informer. An event is
+// recorded once the bootstrap token is rejected by the hub, since the agent cannot report a condition to the
+// hub without a valid credential. The bootstrap token file is reloaded by the client, so the bootstrap continues
+// once the bootstrap secret is refreshed with a new token.
+func NewBootstrapTokenExpiryHandler(recorder events.Recorder) cache.WatchErrorHandler {
+	var reported atomic.Bool
+	return func(r *cache.Reflector, err error) {
+		if errors.IsUnauthorized(err) && reported.CompareAndSwap(false, true) {
+			klog.Errorf("The bootstrap token is rejected by the hub before the csr is approved: %v", err)
+			recorder.Warningf(helpers.BootstrapTokenExpiredReason,
+				"The bootstrap token is rejected by the hub before the csr is approved, it may be expired: %v", err)
+		}
+		cache.DefaultWatchErrorHandler(r, err)
+	}
+}
+
+// DiscardBootstrapToken removes the token credentials from the bootstrap kubeconfig secret once the agent
+// switches to the client certificate, so the short-lived token is not reused after it expires. The data of
+// the token file referred by the kubeconfig is removed from the secret as well.
+func DiscardBootstrapToken(ctx context.Context, secretClient corev1client.SecretsGetter,
+	namespace, name string, recorder events.Recorder) error {
+	if len(name) == 0 {
+		return nil
+	}
+
+	secret, err := secretClient.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		// the bootstrap kubeconfig is not from a secret in the agent namespace
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to get the bootstrap kubeconfig secret %s/%s: %w", namespace, name, err)
+	}
+
+	kubeconfigData, ok := secret.Data[bootstrapKubeconfigKey]
+	if !ok {
+		return nil
+	}
+	kubeconfig, err := clientcmd.Load(kubeconfigData)
+	if err != nil {
+		return fmt.Errorf("unable to load the kubeconfig in secret %s/%s: %w", namespace, name, err)
+	}
+
+	newSecret := secret.DeepCopy()
+	modified := false
+	for _, authInfo := range kubeconfig.AuthInfos {
+		if len(authInfo.TokenFile) > 0 {
+			delete(newSecret.Data, path.Base(authInfo.TokenFile))
+		}
+		if len(authInfo.Token) > 0 || len(authInfo.TokenFile) > 0 {
+			authInfo.Token = ""
+			authInfo.TokenFile = ""
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+
+	newSecret.Data[bootstrapKubeconfigKey], err = clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	if _, err := secretClient.Secrets(namespace).Update(ctx, newSecret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to discard the bootstrap token in secret %s/%s: %w", namespace, name, err)
+	}
+	recorder.Eventf("BootstrapTokenDiscarded",
+		"The bootstrap token in secret %s/%s is discarded since the client certificate is issued", namespace, name)
+	return nil
+}
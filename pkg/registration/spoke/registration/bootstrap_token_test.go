@@ -0,0 +1,175 @@
+package registration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/clientcert"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+func newBootstrapKubeconfig(t *testing.T, authInfo *clientcmdapi.AuthInfo) []byte {
+	data, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"hub": {
+			Server:                   "https://hub:6443",
+			CertificateAuthorityData: []byte("ca"),
+		}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"bootstrap": authInfo},
+		Contexts:       map[string]*clientcmdapi.Context{"bootstrap": {Cluster: "hub", AuthInfo: "bootstrap"}},
+		CurrentContext: "bootstrap",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBootstrapTokenExpiryHandler(t *testing.T) {
+	recorder := events.NewInMemoryRecorder("test")
+	handler := NewBootstrapTokenExpiryHandler(recorder)
+	reflector := cache.NewReflector(&cache.ListWatch{}, &corev1.Secret{}, cache.NewStore(cache.MetaNamespaceKeyFunc), 0)
+
+	// the transient errors are not reported
+	handler(reflector, apierrors.NewServiceUnavailable("unavailable"))
+	if len(recorder.Events()) != 0 {
+		t.Errorf("expected no events, but got %v", recorder.Events())
+	}
+
+	// the token expires before the csr is approved
+	handler(reflector, apierrors.NewUnauthorized("token expired"))
+	handler(reflector, apierrors.NewUnauthorized("token expired"))
+	if len(recorder.Events()) != 1 {
+		t.Fatalf("expected the expired token reported once, but got %v", recorder.Events())
+	}
+	if reason := recorder.Events()[0].Reason; reason != helpers.BootstrapTokenExpiredReason {
+		t.Errorf("expected reason %q, but got %q", helpers.BootstrapTokenExpiredReason, reason)
+	}
+}
+
+func TestDiscardBootstrapToken(t *testing.T) {
+	newSecret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "bootstrap-hub-kubeconfig"},
+			Data:       data,
+		}
+	}
+
+	cases := []struct {
+		name            string
+		secret          *corev1.Secret
+		expectedActions []string
+		validateSecret  func(t *testing.T, secret *corev1.Secret)
+	}{
+		{
+			name:            "no bootstrap secret",
+			expectedActions: []string{"get"},
+		},
+		{
+			name: "token",
+			secret: newSecret(map[string][]byte{
+				"kubeconfig": newBootstrapKubeconfig(t, &clientcmdapi.AuthInfo{Token: "token"}),
+			}),
+			expectedActions: []string{"get", "update"},
+			validateSecret: func(t *testing.T, secret *corev1.Secret) {
+				assertNoToken(t, secret.Data["kubeconfig"])
+			},
+		},
+		{
+			name: "token file",
+			secret: newSecret(map[string][]byte{
+				"kubeconfig": newBootstrapKubeconfig(t, &clientcmdapi.AuthInfo{TokenFile: "/spoke/bootstrap/token"}),
+				"token":      []byte("token"),
+			}),
+			expectedActions: []string{"get", "update"},
+			validateSecret: func(t *testing.T, secret *corev1.Secret) {
+				assertNoToken(t, secret.Data["kubeconfig"])
+				if _, ok := secret.Data["token"]; ok {
+					t.Errorf("expected the token file removed from the secret")
+				}
+			},
+		},
+		{
+			name: "token is discarded",
+			secret: newSecret(map[string][]byte{
+				"kubeconfig": newBootstrapKubeconfig(t, &clientcmdapi.AuthInfo{}),
+			}),
+			expectedActions: []string{"get"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objs := []runtime.Object{}
+			if c.secret != nil {
+				objs = append(objs, c.secret)
+			}
+			kubeClient := kubefake.NewSimpleClientset(objs...)
+
+			err := DiscardBootstrapToken(context.TODO(), kubeClient.CoreV1(), testNamespace, "bootstrap-hub-kubeconfig",
+				eventstesting.NewTestingEventRecorder(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			testingcommon.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
+			if c.validateSecret != nil {
+				secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(
+					context.TODO(), "bootstrap-hub-kubeconfig", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.validateSecret(t, secret)
+			}
+		})
+	}
+}
+
+func TestHubKubeconfigFromTokenBootstrap(t *testing.T) {
+	bootstrapConfig, err := clientcmd.RESTConfigFromKubeConfig(
+		newBootstrapKubeconfig(t, &clientcmdapi.AuthInfo{Token: "token"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !helpers.IsTokenBasedBootstrap(bootstrapConfig) {
+		t.Fatalf("expected a token based bootstrap config")
+	}
+
+	// the hub kubeconfig written into the hub kubeconfig secret refers to the client certificate only
+	data, err := clientcmd.Write(clientcert.BuildKubeconfig(bootstrapConfig, clientcert.TLSCertFile, clientcert.TLSKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoToken(t, data)
+	hubKubeconfig, err := clientcmd.Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, authInfo := range hubKubeconfig.AuthInfos {
+		if authInfo.ClientCertificate != clientcert.TLSCertFile || authInfo.ClientKey != clientcert.TLSKeyFile {
+			t.Errorf("expected the client certificate used by user %q, but got %v", name, authInfo)
+		}
+	}
+}
+
+func assertNoToken(t *testing.T, kubeconfigData []byte) {
+	kubeconfig, err := clientcmd.Load(kubeconfigData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, authInfo := range kubeconfig.AuthInfos {
+		if len(authInfo.Token) > 0 || len(authInfo.TokenFile) > 0 {
+			t.Errorf("expected no token in user %q, but got %v", name, authInfo)
+		}
+	}
+}
@@ -1,21 +1,25 @@
 package spoke
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/spf13/pflag"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -29,8 +33,11 @@ import (
 	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
+	"open-cluster-management.io/ocm/pkg/common/loglevel"
 	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/registration/clientcert"
@@ -39,6 +46,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/registration/spoke/lease"
 	"open-cluster-management.io/ocm/pkg/registration/spoke/managedcluster"
 	"open-cluster-management.io/ocm/pkg/registration/spoke/registration"
+	"open-cluster-management.io/ocm/pkg/version"
 )
 
 const (
@@ -48,6 +56,20 @@ const (
 	defaultSpokeComponentNamespace = "open-cluster-management-agent"
 )
 
+// registrationSpokeAccesses are the permissions on the managed cluster required by the features of the
+// registration agent.
+var registrationSpokeAccesses = []commonoptions.SpokeAccess{
+	{
+		Feature:    "cluster capacity",
+		Attributes: authorizationv1.ResourceAttributes{Verb: "list", Resource: "nodes"},
+	},
+	{
+		Feature: "cluster claims",
+		Attributes: authorizationv1.ResourceAttributes{
+			Verb: "list", Group: clusterv1alpha1.GroupName, Resource: "clusterclaims"},
+	},
+}
+
 // AddOnLeaseControllerSyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 // TODO if we register the lease informer to the lease controller, we need to increase this time
 var AddOnLeaseControllerSyncInterval = 30 * time.Second
@@ -58,22 +80,30 @@ type SpokeAgentOptions struct {
 	ComponentNamespace          string
 	AgentName                   string
 	BootstrapKubeconfig         string
+	BootstrapKubeconfigSecret   string
+	HubCABundleFile             string
 	HubKubeconfigSecret         string
 	HubKubeconfigDir            string
 	SpokeExternalServerURLs     []string
 	ClusterHealthCheckPeriod    time.Duration
 	MaxCustomClusterClaims      int
 	ClientCertExpirationSeconds int32
+	ClusterLeaseNamespace       string
+	ClusterLeaseName            string
+	NodeLabelClaimKeys          []string
+	ClusterClaimsExclude        []string
+	ObserveOnly                 bool
 }
 
 // NewSpokeAgentOptions returns a SpokeAgentOptions
 func NewSpokeAgentOptions() *SpokeAgentOptions {
 	return &SpokeAgentOptions{
-		AgentOptions:             commonoptions.NewAgentOptions(),
-		HubKubeconfigSecret:      "hub-kubeconfig-secret",
-		HubKubeconfigDir:         "/spoke/hub-kubeconfig",
-		ClusterHealthCheckPeriod: 1 * time.Minute,
-		MaxCustomClusterClaims:   20,
+		AgentOptions:              commonoptions.NewAgentOptions(),
+		BootstrapKubeconfigSecret: "bootstrap-hub-kubeconfig",
+		HubKubeconfigSecret:       "hub-kubeconfig-secret",
+		HubKubeconfigDir:          "/spoke/hub-kubeconfig",
+		ClusterHealthCheckPeriod:  1 * time.Minute,
+		MaxCustomClusterClaims:    20,
 	}
 }
 
@@ -127,6 +157,12 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		return err
 	}
 
+	// the agent running outside of a cluster has no service account namespace file, the component namespace
+	// is from the --namespace flag then.
+	if controllerContext.OperatorNamespace != commonoptions.FallbackOperatorNamespace {
+		o.ComponentNamespace = controllerContext.OperatorNamespace
+	}
+
 	return o.RunSpokeAgentWithSpokeInformers(
 		ctx,
 		kubeConfig,
@@ -161,6 +197,12 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		klog.Fatal(err)
 	}
 
+	// the spoke kubeconfig of the agent running outside of the managed cluster could be restricted, report
+	// the features which cannot work with it.
+	if len(o.AgentOptions.SpokeKubeconfigFile) > 0 {
+		commonoptions.ReportSpokeAccess(ctx, spokeKubeClient.AuthorizationV1(), registrationSpokeAccesses, recorder)
+	}
+
 	// get spoke cluster CA bundle
 	spokeClusterCABundle, err := o.getSpokeClusterCABundle(spokeClientConfig)
 	if err != nil {
@@ -171,11 +213,25 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	namespacedManagementKubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(
 		managementKubeClient, 10*time.Minute, informers.WithNamespace(o.ComponentNamespace))
 
+	// change the log verbosity at runtime once the log level configmap is changed
+	if len(o.AgentOptions.LogLevelConfigMap) > 0 {
+		logLevelController := loglevel.NewLogLevelController(
+			o.ComponentNamespace, o.AgentOptions.LogLevelConfigMap,
+			namespacedManagementKubeInformerFactory.Core().V1().ConfigMaps(), recorder)
+		go logLevelController.Run(ctx, 1)
+	}
+
 	// load bootstrap client config and create bootstrap clients
 	bootstrapClientConfig, err := clientcmd.BuildConfigFromFlags("", o.BootstrapKubeconfig)
 	if err != nil {
 		return fmt.Errorf("unable to load bootstrap kubeconfig from file %q: %w", o.BootstrapKubeconfig, err)
 	}
+	if err := mergeHubCABundle(bootstrapClientConfig, o.HubCABundleFile); err != nil {
+		return err
+	}
+	if err := o.AgentOptions.TLSOptions.ApplyToRestConfig(bootstrapClientConfig); err != nil {
+		return err
+	}
 	bootstrapKubeClient, err := kubernetes.NewForConfig(bootstrapClientConfig)
 	if err != nil {
 		return err
@@ -186,13 +242,8 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	}
 
 	// start a SpokeClusterCreatingController to make sure there is a spoke cluster on hub cluster
-	spokeClusterCreatingController := registration.NewManagedClusterCreatingController(
-		o.AgentOptions.SpokeClusterName, o.SpokeExternalServerURLs,
-		spokeClusterCABundle,
-		bootstrapClusterClient,
-		recorder,
-	)
-	go spokeClusterCreatingController.Run(ctx, 1)
+	switchToHubClusterClient := o.runManagedClusterCreatingController(
+		ctx, spokeClusterCABundle, bootstrapClientConfig, bootstrapClusterClient, recorder)
 
 	hubKubeconfigSecretController := registration.NewHubKubeconfigSecretController(
 		o.HubKubeconfigDir, o.ComponentNamespace, o.HubKubeconfigSecret,
@@ -248,6 +299,14 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 			controllerName,
 		)
 
+		// the bootstrap token may expire before the csr is approved, report it once the hub rejects the token.
+		if helpers.IsTokenBasedBootstrap(bootstrapClientConfig) {
+			if err := csrControl.Informer().SetWatchErrorHandler(
+				registration.NewBootstrapTokenExpiryHandler(recorder)); err != nil {
+				return err
+			}
+		}
+
 		bootstrapCtx, stopBootstrap := context.WithCancel(ctx)
 
 		go bootstrapInformerFactory.Start(bootstrapCtx.Done())
@@ -272,6 +331,9 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	if err != nil {
 		return err
 	}
+	if err := o.AgentOptions.TLSOptions.ApplyToRestConfig(hubClientConfig); err != nil {
+		return err
+	}
 
 	hubKubeClient, err := kubernetes.NewForConfig(hubClientConfig)
 	if err != nil {
@@ -283,6 +345,17 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		return err
 	}
 
+	// the agent switches to the client certificate once the hub client config is ready, no bootstrap token client
+	// is used afterwards and the token is discarded from the bootstrap kubeconfig secret so it is not reused after
+	// it expires. The hub kubeconfig built from the bootstrap kubeconfig refers to the client certificate only.
+	switchToHubClusterClient(hubClusterClient)
+	if helpers.IsTokenBasedBootstrap(bootstrapClientConfig) {
+		if err := registration.DiscardBootstrapToken(ctx, managementKubeClient.CoreV1(),
+			o.ComponentNamespace, o.BootstrapKubeconfigSecret, recorder); err != nil {
+			return err
+		}
+	}
+
 	addOnClient, err := addonclient.NewForConfig(hubClientConfig)
 	if err != nil {
 		return err
@@ -346,7 +419,10 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	// create ManagedClusterLeaseController to keep the spoke cluster heartbeat
 	managedClusterLeaseController := lease.NewManagedClusterLeaseController(
 		o.AgentOptions.SpokeClusterName,
+		o.ClusterLeaseNamespace,
+		o.ClusterLeaseName,
 		hubKubeClient,
+		hubClusterClient,
 		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
 		recorder,
 	)
@@ -360,10 +436,40 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		spokeClusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 		spokeKubeInformerFactory.Core().V1().Nodes(),
 		o.MaxCustomClusterClaims,
+		o.NodeLabelClaimKeys,
+		o.ClusterClaimsExclude,
 		o.ClusterHealthCheckPeriod,
 		recorder,
+		agentevents.NewReporter(hubKubeClient.CoreV1(), o.AgentOptions.SpokeClusterName, "registration-agent"),
+	)
+
+	// create NewAgentIdentityController to publish the identity of this agent on the spoke cluster
+	agentIdentityController := managedcluster.NewAgentIdentityController(
+		o.AgentOptions.SpokeClusterName,
+		o.agentIdentity(),
+		hubClusterClient,
+		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+		recorder,
 	)
 
+	// create NewCABundleController to publish the rotated CA bundle of the spoke cluster kube-apiserver if
+	// SpokeExternalServerURLs is specified
+	var caBundleController factory.Controller
+	var clusterInfoInformerFactory, rootCAInformerFactory informers.SharedInformerFactory
+	if len(o.SpokeExternalServerURLs) > 0 {
+		clusterInfoInformerFactory, rootCAInformerFactory = managedcluster.NewCABundleInformerFactories(
+			spokeKubeClient, 10*time.Minute)
+		caBundleController = managedcluster.NewCABundleController(
+			o.AgentOptions.SpokeClusterName,
+			o.SpokeExternalServerURLs,
+			hubClusterClient,
+			hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+			clusterInfoInformerFactory.Core().V1().ConfigMaps(),
+			rootCAInformerFactory.Core().V1().ConfigMaps(),
+			recorder,
+		)
+	}
+
 	var addOnLeaseController factory.Controller
 	var addOnRegistrationController factory.Controller
 	if features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
@@ -400,10 +506,18 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	if features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.ClusterClaim) {
 		go spokeClusterInformerFactory.Start(ctx.Done())
 	}
+	if clusterInfoInformerFactory != nil {
+		go clusterInfoInformerFactory.Start(ctx.Done())
+		go rootCAInformerFactory.Start(ctx.Done())
+	}
 
 	go clientCertForHubController.Run(ctx, 1)
 	go managedClusterLeaseController.Run(ctx, 1)
 	go managedClusterHealthCheckController.Run(ctx, 1)
+	go agentIdentityController.Run(ctx, 1)
+	if caBundleController != nil {
+		go caBundleController.Run(ctx, 1)
+	}
 	if features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
 		go addOnLeaseController.Run(ctx, 1)
 		go addOnRegistrationController.Run(ctx, 1)
@@ -413,12 +527,39 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	return nil
 }
 
+// agentIdentity returns the identity of the running agent. The pod info and the klusterlet
+// generation are injected into the environment by the klusterlet operator.
+func (o *SpokeAgentOptions) agentIdentity() managedcluster.AgentIdentity {
+	versionInfo := version.Get()
+	identity := managedcluster.AgentIdentity{
+		Version:              versionInfo.GitVersion,
+		GitCommit:            versionInfo.GitCommit,
+		KlusterletGeneration: os.Getenv("KLUSTERLET_GENERATION"),
+		PodNamespace:         os.Getenv("POD_NAMESPACE"),
+		PodName:              os.Getenv("POD_NAME"),
+		ObserveOnly:          o.ObserveOnly,
+	}
+	if len(identity.PodNamespace) == 0 {
+		identity.PodNamespace = o.ComponentNamespace
+	}
+	if len(identity.PodName) == 0 {
+		// the hostname of a pod is its name by default
+		identity.PodName, _ = os.Hostname()
+	}
+	return identity
+}
+
 // AddFlags registers flags for Agent
 func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	features.DefaultSpokeRegistrationMutableFeatureGate.AddFlag(fs)
 	o.AgentOptions.AddFlags(fs)
 	fs.StringVar(&o.BootstrapKubeconfig, "bootstrap-kubeconfig", o.BootstrapKubeconfig,
 		"The path of the kubeconfig file for agent bootstrap.")
+	fs.StringVar(&o.BootstrapKubeconfigSecret, "bootstrap-kubeconfig-secret", o.BootstrapKubeconfigSecret,
+		"The name of secret in component namespace storing the bootstrap kubeconfig. The token in the bootstrap "+
+			"kubeconfig is removed from the secret once the client certificate is issued.")
+	fs.StringVar(&o.HubCABundleFile, "hub-ca-bundle-file", o.HubCABundleFile,
+		"The path of a CA bundle file which is appended to the CA data of the bootstrap kubeconfig to verify the hub.")
 	fs.StringVar(&o.HubKubeconfigSecret, "hub-kubeconfig-secret", o.HubKubeconfigSecret,
 		"The name of secret in component namespace storing kubeconfig for hub.")
 	fs.StringVar(&o.HubKubeconfigDir, "hub-kubeconfig-dir", o.HubKubeconfigDir,
@@ -432,6 +573,22 @@ func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.Int32Var(&o.ClientCertExpirationSeconds, "client-cert-expiration-seconds", o.ClientCertExpirationSeconds,
 		"The requested duration in seconds of validity of the issued client certificate. If this is not set, "+
 			"the value of --cluster-signing-duration command-line flag of the kube-controller-manager will be used.")
+	fs.StringVar(&o.ClusterLeaseNamespace, "cluster-lease-namespace", o.ClusterLeaseNamespace,
+		"The namespace of the cluster lease on the hub. If this is not set, the cluster namespace will be used. "+
+			"The agent must be granted to get, create and update leases in this namespace.")
+	fs.StringVar(&o.ClusterLeaseName, "cluster-lease-name", o.ClusterLeaseName,
+		"The name of the cluster lease on the hub. If this is not set, managed-cluster-lease will be used.")
+	fs.StringSliceVar(&o.NodeLabelClaimKeys, "node-label-claim-keys", o.NodeLabelClaimKeys,
+		"A list of node label keys, e.g. topology.kubernetes.io/zone. The values of each label present on the nodes "+
+			"are exposed as a cluster claim named after the label key. It requires the ClusterClaim feature gate.")
+	fs.StringSliceVar(&o.ClusterClaimsExclude, "cluster-claims-exclude", o.ClusterClaimsExclude,
+		"A list of cluster claim name patterns, e.g. *.internal.example.com. The matching cluster claims are not "+
+			"synced to the hub, and are removed from the managed cluster once they are synced. The reserved "+
+			"cluster claims cannot be excluded.")
+	fs.BoolVar(&o.ObserveOnly, "observe-only", o.ObserveOnly,
+		"Register the cluster for the inventory only. The cluster is annotated with "+helpers.ObserveOnlyAnnotation+
+			" on the hub, so the hub does not grant the permission on the manifestworks or deploy the "+
+			"ManifestWorkReplicaSets to the cluster.")
 }
 
 // Validate verifies the inputs.
@@ -451,8 +608,8 @@ func (o *SpokeAgentOptions) Validate() error {
 	// if SpokeExternalServerURLs is specified we validate every URL in it, we expect the spoke external server URL is https
 	if len(o.SpokeExternalServerURLs) != 0 {
 		for _, serverURL := range o.SpokeExternalServerURLs {
-			if !helpers.IsValidHTTPSURL(serverURL) {
-				return fmt.Errorf("%q is invalid", serverURL)
+			if err := helpers.ValidateHTTPSURL(serverURL); err != nil {
+				return fmt.Errorf("%q is invalid: %v", serverURL, err)
 			}
 		}
 	}
@@ -465,6 +622,16 @@ func (o *SpokeAgentOptions) Validate() error {
 		return errors.New("client certificate expiration seconds must greater or qual to 3600")
 	}
 
+	for _, key := range o.NodeLabelClaimKeys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid node label claim key %q: %s", key, strings.Join(errs, ", "))
+		}
+	}
+
+	if err := managedcluster.ValidateClaimExcludePatterns(o.ClusterClaimsExclude); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -472,9 +639,10 @@ func (o *SpokeAgentOptions) Validate() error {
 func (o *SpokeAgentOptions) Complete(coreV1Client corev1client.CoreV1Interface, ctx context.Context, recorder events.Recorder) error {
 	// get component namespace of spoke agent
 	nsBytes, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
-	if err != nil {
+	switch {
+	case err != nil && len(o.ComponentNamespace) == 0:
 		o.ComponentNamespace = defaultSpokeComponentNamespace
-	} else {
+	case err == nil:
 		o.ComponentNamespace = string(nsBytes)
 	}
 
@@ -546,6 +714,12 @@ func (o *SpokeAgentOptions) hasValidHubClientConfig(ctx context.Context) (bool,
 	return clientcert.IsCertificateValid(certData, nil)
 }
 
+// HasValidHubClientConfig returns true if the hub kubeconfig is written by the agent and valid. It is used by the
+// singleton agent to start the work controllers once the cluster is registered to the hub.
+func (o *SpokeAgentOptions) HasValidHubClientConfig(ctx context.Context) (bool, error) {
+	return o.hasValidHubClientConfig(ctx)
+}
+
 // getOrGenerateClusterAgentNames returns cluster name and agent name.
 // Rules for picking up cluster name:
 //   1. Use cluster name from input arguments if 'cluster-name' is specified;
@@ -613,6 +787,68 @@ func (o *SpokeAgentOptions) getOrGenerateClusterAgentNames() (string, string) {
 	return clusterName, agentName
 }
 
+// mergeHubCABundle appends the CA bundle in the caBundleFile to the CA data of the client config. The merged CA
+// data is also written into the hub kubeconfig built from the bootstrap client config.
+func mergeHubCABundle(clientConfig *rest.Config, caBundleFile string) error {
+	if len(caBundleFile) == 0 {
+		return nil
+	}
+
+	caBundle, err := os.ReadFile(caBundleFile)
+	if err != nil {
+		return fmt.Errorf("unable to load hub CA bundle from file %q: %w", caBundleFile, err)
+	}
+
+	caData := clientConfig.CAData
+	if len(caData) == 0 && len(clientConfig.CAFile) > 0 {
+		caData, err = os.ReadFile(clientConfig.CAFile)
+		if err != nil {
+			return fmt.Errorf("unable to load CA file %q: %w", clientConfig.CAFile, err)
+		}
+	}
+
+	if len(caData) > 0 && !bytes.HasSuffix(caData, []byte("\n")) {
+		caData = append(caData, '\n')
+	}
+	clientConfig.CAData = append(caData, caBundle...)
+	clientConfig.CAFile = ""
+	return nil
+}
+
+// runManagedClusterCreatingController runs the ManagedClusterCreatingController with the bootstrap cluster client.
+// If the bootstrap kubeconfig authenticates with a short-lived token, the returned func stops it and runs it again
+// with the hub cluster client once the client certificate is issued, so the token is not used after the bootstrap.
+func (o *SpokeAgentOptions) runManagedClusterCreatingController(ctx context.Context, spokeClusterCABundle []byte,
+	bootstrapClientConfig *rest.Config, bootstrapClusterClient clusterv1client.Interface,
+	recorder events.Recorder) func(hubClusterClient clusterv1client.Interface) {
+	newController := func(clusterClient clusterv1client.Interface) factory.Controller {
+		return registration.NewManagedClusterCreatingController(
+			o.AgentOptions.SpokeClusterName, o.SpokeExternalServerURLs,
+			spokeClusterCABundle,
+			clusterClient,
+			recorder,
+		)
+	}
+
+	switch {
+	case helpers.IsBootstrapTokenDiscarded(bootstrapClientConfig):
+		// the agent is bootstrapped already and the bootstrap token is discarded
+		return func(hubClusterClient clusterv1client.Interface) {
+			go newController(hubClusterClient).Run(ctx, 1)
+		}
+	case helpers.IsTokenBasedBootstrap(bootstrapClientConfig):
+		bootstrapCtx, stopBootstrap := context.WithCancel(ctx)
+		go newController(bootstrapClusterClient).Run(bootstrapCtx, 1)
+		return func(hubClusterClient clusterv1client.Interface) {
+			stopBootstrap()
+			go newController(hubClusterClient).Run(ctx, 1)
+		}
+	}
+
+	go newController(bootstrapClusterClient).Run(ctx, 1)
+	return func(clusterv1client.Interface) {}
+}
+
 // getSpokeClusterCABundle returns the spoke cluster Kubernetes client CA data when SpokeExternalServerURLs is specified
 func (o *SpokeAgentOptions) getSpokeClusterCABundle(kubeConfig *rest.Config) ([]byte, error) {
 	if len(o.SpokeExternalServerURLs) == 0 {
@@ -621,6 +857,10 @@ func (o *SpokeAgentOptions) getSpokeClusterCABundle(kubeConfig *rest.Config) ([]
 	if kubeConfig.CAData != nil {
 		return kubeConfig.CAData, nil
 	}
+	// the spoke kubeconfig may trust the system roots of the agent instead of a CA file
+	if len(kubeConfig.CAFile) == 0 {
+		return nil, nil
+	}
 	data, err := os.ReadFile(kubeConfig.CAFile)
 	if err != nil {
 		return nil, err
@@ -11,13 +11,17 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+
 	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/registration/clientcert"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+	"open-cluster-management.io/ocm/pkg/registration/spoke/registration"
 )
 
 func TestComplete(t *testing.T) {
@@ -174,7 +178,7 @@ func TestValidate(t *testing.T) {
 				AgentName:               "testagent",
 				SpokeExternalServerURLs: []string{"https://127.0.0.1:64433", "http://127.0.0.1:8080"},
 			},
-			expectedErr: "\"http://127.0.0.1:8080\" is invalid",
+			expectedErr: "\"http://127.0.0.1:8080\" is invalid: the scheme should be https",
 		},
 		{
 			name: "invalid cluster healthcheck period",
@@ -193,6 +197,19 @@ func TestValidate(t *testing.T) {
 			options:     defaultCompletedOptions,
 			expectedErr: "",
 		},
+		{
+			name: "cluster claims exclude pattern matching a reserved claim",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig",
+				AgentOptions: &commonoptions.AgentOptions{
+					SpokeClusterName: "testcluster",
+				},
+				AgentName:                "testagent",
+				ClusterHealthCheckPeriod: 1 * time.Minute,
+				ClusterClaimsExclude:     []string{"id.*"},
+			},
+			expectedErr: "cluster claim exclude pattern \"id.*\" should not match the reserved claim \"id.k8s.io\"",
+		},
 		{
 			name: "default completed options",
 			options: &SpokeAgentOptions{
@@ -390,7 +407,7 @@ func TestGetSpokeClusterCABundle(t *testing.T) {
 		{
 			name:           "no ca data",
 			options:        &SpokeAgentOptions{SpokeExternalServerURLs: []string{"https://127.0.0.1:6443"}},
-			expectedErr:    "open : no such file or directory",
+			expectedErr:    "",
 			expectedCAData: nil,
 		},
 		{
@@ -429,3 +446,147 @@ func TestGetSpokeClusterCABundle(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeHubCABundle(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "testmergehubcabundle")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	caBundleFile := path.Join(tempDir, "ca-bundle.crt")
+	testinghelpers.WriteFile(caBundleFile, []byte("custom-ca\n"))
+	caFile := path.Join(tempDir, "ca.crt")
+	testinghelpers.WriteFile(caFile, []byte("ca-from-file"))
+
+	cases := []struct {
+		name           string
+		caBundleFile   string
+		config         *rest.Config
+		expectedErr    string
+		expectedCAData []byte
+	}{
+		{
+			name:           "no ca bundle file",
+			config:         &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("cadata")}},
+			expectedCAData: []byte("cadata"),
+		},
+		{
+			name:         "ca bundle file not found",
+			caBundleFile: path.Join(tempDir, "notfound"),
+			config:       &rest.Config{},
+			expectedErr: "unable to load hub CA bundle from file \"" + path.Join(tempDir, "notfound") +
+				"\": open " + path.Join(tempDir, "notfound") + ": no such file or directory",
+		},
+		{
+			name:           "merge with ca data",
+			caBundleFile:   caBundleFile,
+			config:         &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("cadata")}},
+			expectedCAData: []byte("cadata\ncustom-ca\n"),
+		},
+		{
+			name:           "merge with ca file",
+			caBundleFile:   caBundleFile,
+			config:         &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAFile: caFile}},
+			expectedCAData: []byte("ca-from-file\ncustom-ca\n"),
+		},
+		{
+			name:           "no ca in config",
+			caBundleFile:   caBundleFile,
+			config:         &rest.Config{},
+			expectedCAData: []byte("custom-ca\n"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := mergeHubCABundle(c.config, c.caBundleFile)
+			testingcommon.AssertError(t, err, c.expectedErr)
+			if err != nil {
+				return
+			}
+			if !bytes.Equal(c.config.CAData, c.expectedCAData) {
+				t.Errorf("expect %q but got %q", c.expectedCAData, c.config.CAData)
+			}
+			if len(c.caBundleFile) > 0 && len(c.config.CAFile) > 0 {
+				t.Errorf("expect ca file is cleared, but got %q", c.config.CAFile)
+			}
+		})
+	}
+}
+
+func TestRunManagedClusterCreatingController(t *testing.T) {
+	syncInterval := registration.CreatingControllerSyncInterval
+	registration.CreatingControllerSyncInterval = 50 * time.Millisecond
+	defer func() {
+		registration.CreatingControllerSyncInterval = syncInterval
+	}()
+
+	cases := []struct {
+		name                          string
+		bootstrapClientConfig         *rest.Config
+		expectedBootstrapBeforeSwitch bool
+		expectedBootstrapAfterSwitch  bool
+		expectedHubAfterSwitch        bool
+	}{
+		{
+			name:                          "token",
+			bootstrapClientConfig:         &rest.Config{BearerToken: "token"},
+			expectedBootstrapBeforeSwitch: true,
+			expectedHubAfterSwitch:        true,
+		},
+		{
+			name:                   "token discarded",
+			bootstrapClientConfig:  &rest.Config{},
+			expectedHubAfterSwitch: true,
+		},
+		{
+			name: "client certificate",
+			bootstrapClientConfig: &rest.Config{
+				TLSClientConfig: rest.TLSClientConfig{CertData: []byte("cert"), KeyData: []byte("key")},
+			},
+			expectedBootstrapBeforeSwitch: true,
+			expectedBootstrapAfterSwitch:  true,
+		},
+	}
+
+	// hasActions waits for the actions of the client, or makes sure there is no action in a few sync intervals
+	hasActions := func(client *clusterfake.Clientset) bool {
+		err := wait.PollUntilContextTimeout(context.TODO(), 10*time.Millisecond, 500*time.Millisecond, true,
+			func(context.Context) (bool, error) {
+				return len(client.Actions()) > 0, nil
+			})
+		return err == nil
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			bootstrapClusterClient := clusterfake.NewSimpleClientset()
+			hubClusterClient := clusterfake.NewSimpleClientset()
+			options := NewSpokeAgentOptions()
+			options.AgentOptions.SpokeClusterName = "cluster1"
+			switchToHubClusterClient := options.runManagedClusterCreatingController(ctx, nil,
+				c.bootstrapClientConfig, bootstrapClusterClient, eventstesting.NewTestingEventRecorder(t))
+
+			if actual := hasActions(bootstrapClusterClient); actual != c.expectedBootstrapBeforeSwitch {
+				t.Errorf("expected the bootstrap client used before the switch %v, but got %v",
+					c.expectedBootstrapBeforeSwitch, actual)
+			}
+
+			switchToHubClusterClient(hubClusterClient)
+			// the in-flight sync of the bootstrap controller is finished before the actions are cleared
+			time.Sleep(100 * time.Millisecond)
+			bootstrapClusterClient.ClearActions()
+
+			if actual := hasActions(bootstrapClusterClient); actual != c.expectedBootstrapAfterSwitch {
+				t.Errorf("expected the bootstrap client used after the switch %v, but got %v",
+					c.expectedBootstrapAfterSwitch, actual)
+			}
+			if actual := hasActions(hubClusterClient); actual != c.expectedHubAfterSwitch {
+				t.Errorf("expected the hub client used after the switch %v, but got %v", c.expectedHubAfterSwitch, actual)
+			}
+		})
+	}
+}
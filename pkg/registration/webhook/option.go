@@ -1,11 +1,20 @@
 package webhook
 
-import "github.com/spf13/pflag"
+import (
+	"github.com/spf13/pflag"
+
+	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
+)
 
 // Config contains the server (the webhook) cert and key.
 type Options struct {
-	Port    int
-	CertDir string
+	Port       int
+	CertDir    string
+	TLSOptions commonoptions.TLSOptions
+
+	// DeletionProtectionExemptServiceAccounts is the service accounts, in format namespace:name, of the approved
+	// deprovision workflow allowed to delete a protected ManagedCluster without the confirmation.
+	DeletionProtectionExemptServiceAccounts []string
 }
 
 // NewOptions constructs a new set of default options for webhook.
@@ -21,4 +30,9 @@ func (c *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&c.CertDir, "certdir", c.CertDir,
 		"CertDir is the directory that contains the server key and certificate. If not set, "+
 			"webhook server would look up the server key and certificate in {TempDir}/k8s-webhook-server/serving-certs")
+	fs.StringSliceVar(&c.DeletionProtectionExemptServiceAccounts, "deletion-protection-exempt-service-accounts",
+		c.DeletionProtectionExemptServiceAccounts,
+		"The service accounts, in format namespace:name, allowed to delete a protected ManagedCluster "+
+			"without the deletion confirmation annotation.")
+	c.TLSOptions.AddFlags(fs)
 }
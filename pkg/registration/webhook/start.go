@@ -1,8 +1,13 @@
 package webhook
 
 import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Import all auth plugins (e.g. Azure, GCP, OIDC, etc.) to ensure exec-entrypoint and run can make use of them.
 	"k8s.io/klog/v2"
@@ -29,12 +34,28 @@ func init() {
 }
 
 func (c *Options) RunWebhookServer() error {
+	if err := c.TLSOptions.Validate(); err != nil {
+		return err
+	}
+	exemptUsers, err := c.deletionProtectionExemptUsers()
+	if err != nil {
+		return err
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		Port:                   c.Port,
 		HealthProbeBindAddress: ":8000",
 		CertDir:                c.CertDir,
-		WebhookServer:          webhook.NewServer(webhook.Options{TLSMinVersion: "1.3"}),
+		WebhookServer: webhook.NewServer(webhook.Options{
+			TLSMinVersion: "1.3",
+			TLSOpts: []func(*tls.Config){
+				func(config *tls.Config) {
+					// the options are validated already
+					_ = c.TLSOptions.ApplyToTLSConfig(config)
+				},
+			},
+		}),
 	})
 
 	if err != nil {
@@ -53,7 +74,7 @@ func (c *Options) RunWebhookServer() error {
 		return err
 	}
 
-	if err = (&internalv1.ManagedClusterWebhook{}).Init(mgr); err != nil {
+	if err = internalv1.NewManagedClusterWebhook(exemptUsers...).Init(mgr); err != nil {
 		klog.Error(err, "unable to create ManagedCluster webhook")
 		return err
 	}
@@ -81,3 +102,17 @@ func (c *Options) RunWebhookServer() error {
 	}
 	return nil
 }
+
+// deletionProtectionExemptUsers returns the usernames of the service accounts allowed to delete a protected
+// ManagedCluster without the confirmation.
+func (c *Options) deletionProtectionExemptUsers() ([]string, error) {
+	users := []string{}
+	for _, sa := range c.DeletionProtectionExemptServiceAccounts {
+		namespace, name, ok := strings.Cut(sa, ":")
+		if !ok || len(namespace) == 0 || len(name) == 0 {
+			return nil, fmt.Errorf("invalid service account %q, the format should be namespace:name", sa)
+		}
+		users = append(users, serviceaccount.MakeUsername(namespace, name))
+	}
+	return users, nil
+}
@@ -79,8 +79,9 @@ func (r *ManagedClusterWebhook) processTaints(managedCluster, oldManagedCluster
 		case originalTaint == nil:
 			// handle UPDATE operation.
 			// new taint
-			// The request will be denied if it has any taint with timeAdded specified.
-			if !taint.TimeAdded.IsZero() {
+			// The request will be denied if it has any taint with timeAdded specified, except the maintenance
+			// taint added with timeAdded by the registration controller, which is overridden.
+			if !taint.TimeAdded.IsZero() && taint.Key != helpers.ManagedClusterTaintMaintenance {
 				invalidTaints = append(invalidTaints, taint.Key)
 				continue
 			}
@@ -19,6 +19,7 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 func TestDefault(t *testing.T) {
@@ -223,6 +224,52 @@ func TestDefault(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "maintenance taint with timeAdded specified",
+			expectedError: false,
+			oldCluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+					Labels: map[string]string{
+						clusterv1beta2.ClusterSetLabel: defaultClusterSetName,
+					},
+				},
+			},
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+					Labels: map[string]string{
+						clusterv1beta2.ClusterSetLabel: defaultClusterSetName,
+					},
+				},
+				Spec: clusterv1.ManagedClusterSpec{
+					Taints: []clusterv1.Taint{
+						{
+							Key:       helpers.ManagedClusterTaintMaintenance,
+							Effect:    clusterv1.TaintEffectNoSelect,
+							TimeAdded: newTime(now, -10*time.Second),
+						},
+					},
+				},
+			},
+			expectCluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+					Labels: map[string]string{
+						clusterv1beta2.ClusterSetLabel: defaultClusterSetName,
+					},
+				},
+				Spec: clusterv1.ManagedClusterSpec{
+					Taints: []clusterv1.Taint{
+						{
+							Key:       helpers.ManagedClusterTaintMaintenance,
+							Effect:    clusterv1.TaintEffectNoSelect,
+							TimeAdded: newTime(now, 0),
+						},
+					},
+				},
+			},
+		},
 		{
 			name:          "taint update request denied",
 			expectedError: true,
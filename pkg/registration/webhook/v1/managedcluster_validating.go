@@ -13,6 +13,7 @@ import (
 	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -113,8 +114,49 @@ func (r *ManagedClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *ManagedClusterWebhook) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
-	return nil, nil
+func (r *ManagedClusterWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	managedCluster, ok := obj.(*v1.ManagedCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest("Request cluster obj format is not right")
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	if !helpers.IsClusterDeletionProtected(managedCluster) {
+		return nil, nil
+	}
+
+	if !r.deletionProtectionExemptUsers.Has(req.UserInfo.Username) {
+		return nil, apierrors.NewForbidden(
+			v1.Resource("managedclusters"),
+			managedCluster.Name,
+			fmt.Errorf("cluster %q is protected by the label %q, set the annotation %q to %q to confirm the deletion",
+				managedCluster.Name, helpers.ManagedClusterDeletionProtectionLabel,
+				helpers.ManagedClusterDeletionConfirmedAnnotation, managedCluster.Name),
+		)
+	}
+
+	// the deletion is initiated by the deprovision workflow, confirm it on behalf of the workflow so the
+	// cleanup of the cluster is not blocked on the hub.
+	if req.DryRun != nil && *req.DryRun {
+		return nil, nil
+	}
+	return nil, r.confirmDeletion(ctx, managedCluster.Name)
+}
+
+// confirmDeletion sets the deletion confirmation annotation on the cluster.
+func (r *ManagedClusterWebhook) confirmDeletion(ctx context.Context, clusterName string) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`,
+		helpers.ManagedClusterDeletionConfirmedAnnotation, clusterName)
+	_, err := r.clusterClient.ClusterV1().ManagedClusters().Patch(
+		ctx, clusterName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return apierrors.NewInternalError(
+			fmt.Errorf("failed to confirm the deletion of cluster %q: %v", clusterName, err))
+	}
+	return nil
 }
 
 // validateManagedClusterObj validates the fileds of ManagedCluster object
@@ -131,8 +173,8 @@ func (r *ManagedClusterWebhook) validateManagedClusterObj(cluster v1.ManagedClus
 
 	// validate the url in spoke client configs
 	for _, clientConfig := range cluster.Spec.ManagedClusterClientConfigs {
-		if !helpers.IsValidHTTPSURL(clientConfig.URL) {
-			errs = append(errs, fmt.Errorf("url %q is invalid in client configs", clientConfig.URL))
+		if err := helpers.ValidateHTTPSURL(clientConfig.URL); err != nil {
+			errs = append(errs, fmt.Errorf("url %q is invalid in client configs: %v", clientConfig.URL, err))
 		}
 	}
 	if len(errs) != 0 {
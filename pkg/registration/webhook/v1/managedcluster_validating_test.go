@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,8 +14,11 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 func TestValidateCreate(t *testing.T) {
@@ -603,3 +607,126 @@ func TestValidateUpdate(t *testing.T) {
 		t.Errorf("Non cluster obj, Expect Error but got nil")
 	}
 }
+
+func TestValidateDelete(t *testing.T) {
+	protectedCluster := func(confirmation string) *v1.ManagedCluster {
+		cluster := &v1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster1",
+				Labels: map[string]string{
+					helpers.ManagedClusterDeletionProtectionLabel: "true",
+				},
+			},
+		}
+		if len(confirmation) > 0 {
+			cluster.Annotations = map[string]string{
+				helpers.ManagedClusterDeletionConfirmedAnnotation: confirmation,
+			}
+		}
+		return cluster
+	}
+
+	cases := []struct {
+		name              string
+		cluster           *v1.ManagedCluster
+		username          string
+		dryRun            bool
+		expectedError     bool
+		expectedConfirmed bool
+	}{
+		{
+			name: "cluster not protected",
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+			},
+			username: "admin",
+		},
+		{
+			name: "cluster protected by a label not true",
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "cluster1",
+					Labels: map[string]string{helpers.ManagedClusterDeletionProtectionLabel: "false"},
+				},
+			},
+			username: "admin",
+		},
+		{
+			name:          "protected cluster without confirmation",
+			cluster:       protectedCluster(""),
+			username:      "admin",
+			expectedError: true,
+		},
+		{
+			name:          "protected cluster confirmed with a wrong name",
+			cluster:       protectedCluster("cluster2"),
+			username:      "admin",
+			expectedError: true,
+		},
+		{
+			name:     "protected cluster with confirmation",
+			cluster:  protectedCluster("cluster1"),
+			username: "admin",
+		},
+		{
+			name:              "protected cluster deleted by the deprovision workflow",
+			cluster:           protectedCluster(""),
+			username:          "system:serviceaccount:deprovision:workflow",
+			expectedConfirmed: true,
+		},
+		{
+			name:     "protected cluster deleted by the deprovision workflow in dry run",
+			cluster:  protectedCluster(""),
+			username: "system:serviceaccount:deprovision:workflow",
+			dryRun:   true,
+		},
+		{
+			name:          "protected cluster deleted by another service account",
+			cluster:       protectedCluster(""),
+			username:      "system:serviceaccount:deprovision:other",
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			w := NewManagedClusterWebhook("system:serviceaccount:deprovision:workflow")
+			w.clusterClient = clusterClient
+
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Delete,
+					UserInfo:  authenticationv1.UserInfo{Username: c.username},
+					DryRun:    &c.dryRun,
+				},
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), req)
+
+			_, err := w.ValidateDelete(ctx, c.cluster)
+			if err != nil && !c.expectedError {
+				t.Errorf("expect nil but got error: %v", err)
+			}
+			if err == nil && c.expectedError {
+				t.Errorf("expect error but got nil")
+			}
+
+			cluster, err := clusterClient.ClusterV1().ManagedClusters().Get(
+				context.TODO(), c.cluster.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			confirmed := len(clusterClient.Actions()) > 1 &&
+				cluster.Annotations[helpers.ManagedClusterDeletionConfirmedAnnotation] == c.cluster.Name
+			if confirmed != c.expectedConfirmed {
+				t.Errorf("expect the deletion confirmed %v, but got %v", c.expectedConfirmed, confirmed)
+			}
+		})
+	}
+
+	w := ManagedClusterWebhook{}
+	_, err := w.ValidateDelete(context.Background(), &v1beta1.ManagedClusterSet{})
+	if err == nil {
+		t.Errorf("Non cluster obj, Expect Error but got nil")
+	}
+}
@@ -1,14 +1,29 @@
 package v1
 
 import (
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	v1 "open-cluster-management.io/api/cluster/v1"
 )
 
 type ManagedClusterWebhook struct {
-	kubeClient kubernetes.Interface
+	kubeClient    kubernetes.Interface
+	clusterClient clusterclientset.Interface
+
+	// deletionProtectionExemptUsers is the usernames of the service accounts of the approved deprovision
+	// workflow, which are allowed to delete a protected cluster without the confirmation.
+	deletionProtectionExemptUsers sets.Set[string]
+}
+
+// NewManagedClusterWebhook returns a ManagedCluster webhook allowing the users to delete a protected cluster
+// without the confirmation.
+func NewManagedClusterWebhook(deletionProtectionExemptUsers ...string) *ManagedClusterWebhook {
+	return &ManagedClusterWebhook{
+		deletionProtectionExemptUsers: sets.New[string](deletionProtectionExemptUsers...),
+	}
 }
 
 func (r *ManagedClusterWebhook) Init(mgr ctrl.Manager) error {
@@ -17,6 +32,10 @@ func (r *ManagedClusterWebhook) Init(mgr ctrl.Manager) error {
 		return err
 	}
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.clusterClient, err = clusterclientset.NewForConfig(mgr.GetConfig())
 	return err
 }
 
@@ -0,0 +1,83 @@
+package spoke
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
+	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/registration/clientcert"
+	registration "open-cluster-management.io/ocm/pkg/registration/spoke"
+	work "open-cluster-management.io/ocm/pkg/work/spoke"
+)
+
+// AgentOptions holds the configuration of the singleton agent, which runs the controllers of the registration
+// agent and the work agent in one process.
+type AgentOptions struct {
+	RegistrationOptions *registration.SpokeAgentOptions
+	WorkOptions         *work.WorkloadAgentOptions
+}
+
+// NewAgentOptions returns the options of the singleton agent. The registration and work options share the same
+// common agent options, so the cluster name generated by the registration agent is used by the work agent.
+func NewAgentOptions() *AgentOptions {
+	agentOptions := commonoptions.NewAgentOptions()
+	registrationOptions := registration.NewSpokeAgentOptions()
+	registrationOptions.AgentOptions = agentOptions
+	workOptions := work.NewWorkloadAgentOptions()
+	workOptions.AgentOptions = agentOptions
+	return &AgentOptions{
+		RegistrationOptions: registrationOptions,
+		WorkOptions:         workOptions,
+	}
+}
+
+// AddFlags registers the flags of the registration agent and the work agent. The feature gates of the work agent
+// are set with the flag --work-feature-gates since --feature-gates is taken by the registration agent.
+func (o *AgentOptions) AddFlags(fs *pflag.FlagSet) {
+	o.RegistrationOptions.AddFlags(fs)
+	o.WorkOptions.AddWorkFlags(fs)
+
+	workFeatureGates := pflag.NewFlagSet("work", pflag.ContinueOnError)
+	features.DefaultSpokeWorkMutableFeatureGate.AddFlag(workFeatureGates)
+	workFeatureGates.VisitAll(func(flag *pflag.Flag) {
+		flag.Name = "work-" + flag.Name
+		fs.AddFlag(flag)
+	})
+}
+
+// RunSpokeAgent starts the registration controllers, and starts the work controllers with the hub kubeconfig
+// written by the registration controllers once the cluster is registered to the hub.
+func (o *AgentOptions) RunSpokeAgent(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	// the work controllers are not started on an observe-only cluster
+	if o.RegistrationOptions.ObserveOnly {
+		return o.RegistrationOptions.RunSpokeAgent(ctx, controllerContext)
+	}
+
+	o.WorkOptions.HubKubeconfigFile = path.Join(o.RegistrationOptions.HubKubeconfigDir, clientcert.KubeconfigFile)
+
+	go func() {
+		if err := o.RegistrationOptions.RunSpokeAgent(ctx, controllerContext); err != nil {
+			klog.Fatal(err)
+		}
+	}()
+
+	// wait for the hub client config is ready.
+	klog.Info("Waiting for hub client config to start the work controllers")
+	if err := wait.PollUntilContextCancel(ctx, 1*time.Second, true, o.RegistrationOptions.HasValidHubClientConfig); err != nil {
+		return err
+	}
+
+	// the log verbosity of the process is changed by the registration controllers already
+	workAgentOptions := *o.WorkOptions.AgentOptions
+	workAgentOptions.LogLevelConfigMap = ""
+	o.WorkOptions.AgentOptions = &workAgentOptions
+
+	return o.WorkOptions.RunWorkloadAgent(ctx, controllerContext)
+}
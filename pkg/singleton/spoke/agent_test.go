@@ -0,0 +1,41 @@
+package spoke
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	ocmfeature "open-cluster-management.io/api/feature"
+
+	"open-cluster-management.io/ocm/pkg/features"
+)
+
+func TestAddFlags(t *testing.T) {
+	o := NewAgentOptions()
+	fs := pflag.NewFlagSet("klusterlet-agent", pflag.ContinueOnError)
+	o.AddFlags(fs)
+
+	err := fs.Parse([]string{
+		"--spoke-cluster-name=cluster1",
+		"--agent-id=agent1",
+		"--feature-gates=AddonManagement=true",
+		"--work-feature-gates=RawFeedbackJsonString=true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.RegistrationOptions.AgentOptions.SpokeClusterName != "cluster1" || o.WorkOptions.AgentOptions.SpokeClusterName != "cluster1" {
+		t.Errorf("expected the cluster name is shared, but got %q and %q",
+			o.RegistrationOptions.AgentOptions.SpokeClusterName, o.WorkOptions.AgentOptions.SpokeClusterName)
+	}
+	if o.WorkOptions.AgentID != "agent1" {
+		t.Errorf("expected agent id agent1, but got %q", o.WorkOptions.AgentID)
+	}
+	if !features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
+		t.Errorf("expected the registration feature gate is enabled")
+	}
+	if !features.DefaultSpokeWorkMutableFeatureGate.Enabled(ocmfeature.RawFeedbackJsonString) {
+		t.Errorf("expected the work feature gate is enabled")
+	}
+}
@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DependsOnAnnotation is the annotation on a ManifestWork to list the names of the ManifestWorks in the same
+	// namespace it depends on, separated by commas, e.g. work.open-cluster-management.io/depends-on: "database".
+	// The work agent does not apply the ManifestWork until all the ManifestWorks it depends on are available.
+	// On a ManifestWorkReplicaSet, it is propagated to the ManifestWorks, so a ManifestWorkReplicaSet depends on
+	// another one in the same namespace by its name.
+	// TODO move this to the api repo
+	DependsOnAnnotation = "work.open-cluster-management.io/depends-on"
+	// DependencyTimeoutAnnotation is the annotation on a ManifestWork to set the duration, e.g. 10m, the
+	// ManifestWork waits for its dependencies. The ManifestWork waits until the dependencies are available
+	// if it is not set.
+	// TODO move this to the api repo
+	DependencyTimeoutAnnotation = "work.open-cluster-management.io/dependency-timeout"
+	// DependencyTimeoutPolicyAnnotation is the annotation on a ManifestWork to set what the work agent does once
+	// the dependency timeout is reached, the value is Proceed or Fail, Fail is the default.
+	// TODO move this to the api repo
+	DependencyTimeoutPolicyAnnotation = "work.open-cluster-management.io/dependency-timeout-policy"
+)
+
+// DependencyTimeoutPolicy is the policy of a ManifestWork once it waits for its dependencies too long.
+type DependencyTimeoutPolicy string
+
+const (
+	// DependencyTimeoutPolicyProceed applies the ManifestWork regardless of its dependencies.
+	DependencyTimeoutPolicyProceed DependencyTimeoutPolicy = "Proceed"
+	// DependencyTimeoutPolicyFail marks the ManifestWork as failed to apply until its dependencies are available.
+	DependencyTimeoutPolicyFail DependencyTimeoutPolicy = "Fail"
+)
+
+// dependencyAnnotations are the annotations of the dependencies propagated from a ManifestWorkReplicaSet to
+// its ManifestWorks.
+var dependencyAnnotations = []string{
+	DependsOnAnnotation,
+	DependencyTimeoutAnnotation,
+	DependencyTimeoutPolicyAnnotation,
+}
+
+// GetDependencies returns the sorted names of the ManifestWorks the object depends on, the empty names
+// are ignored.
+func GetDependencies(obj metav1.Object) []string {
+	value, ok := obj.GetAnnotations()[DependsOnAnnotation]
+	if !ok {
+		return nil
+	}
+	dependencies := sets.New[string]()
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			dependencies.Insert(name)
+		}
+	}
+	return sets.List(dependencies)
+}
+
+// GetDependencyTimeout returns the dependency timeout and its policy of the object, the timeout is 0 if it
+// is not set or invalid.
+func GetDependencyTimeout(obj metav1.Object) (time.Duration, DependencyTimeoutPolicy) {
+	annotations := obj.GetAnnotations()
+	policy := DependencyTimeoutPolicyFail
+	if annotations[DependencyTimeoutPolicyAnnotation] == string(DependencyTimeoutPolicyProceed) {
+		policy = DependencyTimeoutPolicyProceed
+	}
+	timeout, err := time.ParseDuration(annotations[DependencyTimeoutAnnotation])
+	if err != nil || timeout < 0 {
+		return 0, policy
+	}
+	return timeout, policy
+}
+
+// ValidateDependencies validates the dependency annotations of the object.
+func ValidateDependencies(obj metav1.Object) error {
+	annotations := obj.GetAnnotations()
+	for _, name := range GetDependencies(obj) {
+		if name == obj.GetName() {
+			return fmt.Errorf("annotation %s: the work should not depend on itself", DependsOnAnnotation)
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("annotation %s: invalid work name %q: %s",
+				DependsOnAnnotation, name, strings.Join(errs, ", "))
+		}
+	}
+	if value, ok := annotations[DependencyTimeoutAnnotation]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("annotation %s: %v", DependencyTimeoutAnnotation, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("annotation %s: the timeout should be positive", DependencyTimeoutAnnotation)
+		}
+	}
+	if value, ok := annotations[DependencyTimeoutPolicyAnnotation]; ok {
+		switch DependencyTimeoutPolicy(value) {
+		case DependencyTimeoutPolicyProceed, DependencyTimeoutPolicyFail:
+		default:
+			return fmt.Errorf("annotation %s: the policy should be %s or %s",
+				DependencyTimeoutPolicyAnnotation, DependencyTimeoutPolicyProceed, DependencyTimeoutPolicyFail)
+		}
+	}
+	return nil
+}
+
+// FindDependencyCycle returns the cycle of the dependencies through the work, e.g. [a b c a] if a depends on b,
+// b depends on c and c depends on a. The dependencies of the other works are returned by getDependencies. It
+// returns nil if there is no cycle.
+func FindDependencyCycle(name string, dependencies []string, getDependencies func(name string) []string) []string {
+	visited := sets.New[string]()
+	var path []string
+	var visit func(current string, dependencies []string) bool
+	visit = func(current string, dependencies []string) bool {
+		path = append(path, current)
+		for _, dependency := range dependencies {
+			if dependency == name {
+				path = append(path, dependency)
+				return true
+			}
+			if visited.Has(dependency) {
+				continue
+			}
+			visited.Insert(dependency)
+			if visit(dependency, getDependencies(dependency)) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+
+	if visit(name, dependencies) {
+		return path
+	}
+	return nil
+}
+
+// PropagateDependencies copies the dependency annotations of the source to the annotations, and returns the
+// annotations.
+func PropagateDependencies(source metav1.Object, annotations map[string]string) map[string]string {
+	for _, key := range dependencyAnnotations {
+		value, ok := source.GetAnnotations()[key]
+		if !ok {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = value
+	}
+	return annotations
+}
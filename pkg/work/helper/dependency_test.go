@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func newWorkWithAnnotations(name string, annotations map[string]string) *workapiv1.ManifestWork {
+	return &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cluster1", Annotations: annotations},
+	}
+}
+
+func TestGetDependencies(t *testing.T) {
+	work := newWorkWithAnnotations("app", map[string]string{
+		DependsOnAnnotation:               " db,cache,,db ",
+		DependencyTimeoutAnnotation:       "10m",
+		DependencyTimeoutPolicyAnnotation: "Proceed",
+	})
+	if dependencies := GetDependencies(work); !reflect.DeepEqual(dependencies, []string{"cache", "db"}) {
+		t.Errorf("expected dependencies [cache db], but got %v", dependencies)
+	}
+	if timeout, policy := GetDependencyTimeout(work); timeout != 10*time.Minute || policy != DependencyTimeoutPolicyProceed {
+		t.Errorf("expected timeout 10m with policy Proceed, but got %v with %s", timeout, policy)
+	}
+
+	work = newWorkWithAnnotations("app", map[string]string{DependencyTimeoutAnnotation: "invalid"})
+	if dependencies := GetDependencies(work); dependencies != nil {
+		t.Errorf("expected no dependencies, but got %v", dependencies)
+	}
+	if timeout, policy := GetDependencyTimeout(work); timeout != 0 || policy != DependencyTimeoutPolicyFail {
+		t.Errorf("expected no timeout with policy Fail, but got %v with %s", timeout, policy)
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			annotations: map[string]string{
+				DependsOnAnnotation:               "db,cache",
+				DependencyTimeoutAnnotation:       "10m",
+				DependencyTimeoutPolicyAnnotation: "Fail",
+			},
+		},
+		{
+			name:          "depends on itself",
+			annotations:   map[string]string{DependsOnAnnotation: "db,app"},
+			expectedError: "the work should not depend on itself",
+		},
+		{
+			name:          "invalid work name",
+			annotations:   map[string]string{DependsOnAnnotation: "DB"},
+			expectedError: `invalid work name "DB"`,
+		},
+		{
+			name:          "invalid timeout",
+			annotations:   map[string]string{DependsOnAnnotation: "db", DependencyTimeoutAnnotation: "10"},
+			expectedError: DependencyTimeoutAnnotation,
+		},
+		{
+			name:          "negative timeout",
+			annotations:   map[string]string{DependsOnAnnotation: "db", DependencyTimeoutAnnotation: "-1m"},
+			expectedError: "the timeout should be positive",
+		},
+		{
+			name:          "invalid policy",
+			annotations:   map[string]string{DependsOnAnnotation: "db", DependencyTimeoutPolicyAnnotation: "Retry"},
+			expectedError: "the policy should be Proceed or Fail",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateDependencies(newWorkWithAnnotations("app", c.annotations))
+			switch {
+			case len(c.expectedError) == 0 && err != nil:
+				t.Errorf("expected no error, but got %v", err)
+			case len(c.expectedError) > 0 && (err == nil || !strings.Contains(err.Error(), c.expectedError)):
+				t.Errorf("expected error %q, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestFindDependencyCycle(t *testing.T) {
+	cases := []struct {
+		name          string
+		dependencies  []string
+		works         map[string][]string
+		expectedCycle []string
+	}{
+		{
+			name:         "chain",
+			dependencies: []string{"app"},
+			works:        map[string][]string{"app": {"db"}, "db": {"storage"}},
+		},
+		{
+			name:         "diamond",
+			dependencies: []string{"app", "cache"},
+			works:        map[string][]string{"app": {"db", "cache"}, "cache": {"db"}},
+		},
+		{
+			name:          "cycle",
+			dependencies:  []string{"app"},
+			works:         map[string][]string{"app": {"db"}, "db": {"web"}},
+			expectedCycle: []string{"web", "app", "db", "web"},
+		},
+		{
+			name:         "cycle not through the work",
+			dependencies: []string{"app"},
+			works:        map[string][]string{"app": {"db"}, "db": {"app"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cycle := FindDependencyCycle("web", c.dependencies, func(name string) []string {
+				return c.works[name]
+			})
+			if !reflect.DeepEqual(cycle, c.expectedCycle) {
+				t.Errorf("expected cycle %v, but got %v", c.expectedCycle, cycle)
+			}
+		})
+	}
+}
+
+func TestPropagateDependencies(t *testing.T) {
+	source := newWorkWithAnnotations("app", map[string]string{
+		DependsOnAnnotation:         "db",
+		DependencyTimeoutAnnotation: "10m",
+		"other":                     "value",
+	})
+	annotations := PropagateDependencies(source, map[string]string{PriorityAnnotation: "high"})
+	expected := map[string]string{
+		PriorityAnnotation:          "high",
+		DependsOnAnnotation:         "db",
+		DependencyTimeoutAnnotation: "10m",
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("expected annotations %v, but got %v", expected, annotations)
+	}
+
+	if annotations := PropagateDependencies(newWorkWithAnnotations("app", nil), nil); annotations != nil {
+		t.Errorf("expected no annotations, but got %v", annotations)
+	}
+}
@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// ExecutorCreatorAnnotation is the annotation on a ManifestWorkReplicaSet with the user, in json, who set the
+	// executor of its manifestWork template. It is maintained by the mutating webhook, and the execute-as
+	// permission of the user is checked on the executor rendered for each cluster before the ManifestWork of the
+	// cluster is created or updated.
+	// TODO move this to the api repo
+	ExecutorCreatorAnnotation = "work.open-cluster-management.io/executor-creator"
+
+	// sampleClusterName is the cluster name to render the executor template with at admission, when the
+	// clusters are not known yet.
+	sampleClusterName = "cluster1"
+)
+
+// executorPlaceholder matches the cluster name placeholder in the service account of an executor.
+var executorPlaceholder = regexp.MustCompile(`\{\{\s*\.ClusterName\s*\}\}`)
+
+// IsExecutorTemplate returns true if the namespace or the name of the executor service account contains any
+// placeholder.
+func IsExecutorTemplate(executor *workapiv1.ManifestWorkExecutor) bool {
+	if executor == nil || executor.Subject.ServiceAccount == nil {
+		return false
+	}
+	return strings.Contains(executor.Subject.ServiceAccount.Namespace, "{{") ||
+		strings.Contains(executor.Subject.ServiceAccount.Name, "{{")
+}
+
+// RenderExecutor returns a copy of the executor with the placeholder {{ .ClusterName }} in the namespace and the
+// name of the service account replaced with the cluster name.
+func RenderExecutor(executor *workapiv1.ManifestWorkExecutor, clusterName string) *workapiv1.ManifestWorkExecutor {
+	if !IsExecutorTemplate(executor) {
+		return executor
+	}
+	rendered := executor.DeepCopy()
+	serviceAccount := rendered.Subject.ServiceAccount
+	serviceAccount.Namespace = executorPlaceholder.ReplaceAllLiteralString(serviceAccount.Namespace, clusterName)
+	serviceAccount.Name = executorPlaceholder.ReplaceAllLiteralString(serviceAccount.Name, clusterName)
+	return rendered
+}
+
+// ValidateExecutorTemplate renders the executor template with a sample cluster name, and checks only the
+// placeholder {{ .ClusterName }} is used and the rendered service account is valid. The permission on the
+// executor can only be checked once the clusters are known.
+func ValidateExecutorTemplate(executor *workapiv1.ManifestWorkExecutor) error {
+	if !IsExecutorTemplate(executor) {
+		return nil
+	}
+
+	serviceAccount := RenderExecutor(executor, sampleClusterName).Subject.ServiceAccount
+	if strings.Contains(serviceAccount.Namespace, "{{") || strings.Contains(serviceAccount.Name, "{{") {
+		return fmt.Errorf("only the placeholder {{ .ClusterName }} is supported in the executor")
+	}
+	if errs := validation.IsDNS1123Label(serviceAccount.Namespace); len(errs) > 0 {
+		return fmt.Errorf("the executor namespace %q is invalid: %s",
+			executor.Subject.ServiceAccount.Namespace, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(serviceAccount.Name); len(errs) > 0 {
+		return fmt.Errorf("the executor name %q is invalid: %s",
+			executor.Subject.ServiceAccount.Name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ExecutorCreator returns the value of the ExecutorCreatorAnnotation of the user. The extra info of the user is
+// dropped to keep the annotation small.
+func ExecutorCreator(userInfo authenticationv1.UserInfo) (string, error) {
+	data, err := json.Marshal(authenticationv1.UserInfo{
+		Username: userInfo.Username,
+		UID:      userInfo.UID,
+		Groups:   userInfo.Groups,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetExecutorCreator returns the user in the ExecutorCreatorAnnotation of the object, or nil if it is not set.
+func GetExecutorCreator(obj metav1.Object) (*authenticationv1.UserInfo, error) {
+	value, ok := obj.GetAnnotations()[ExecutorCreatorAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	userInfo := &authenticationv1.UserInfo{}
+	if err := json.Unmarshal([]byte(value), userInfo); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %v", ExecutorCreatorAnnotation, err)
+	}
+	return userInfo, nil
+}
+
+// CanExecuteAs checks whether the user has the execute-as permission on the executor service account of the
+// ManifestWorks in the namespace.
+func CanExecuteAs(ctx context.Context, sarClient authorizationv1client.SubjectAccessReviewsGetter,
+	userInfo authenticationv1.UserInfo, namespace string, serviceAccount *workapiv1.ManifestWorkSubjectServiceAccount) (bool, error) {
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:     "work.open-cluster-management.io",
+				Resource:  "manifestworks",
+				Verb:      "execute-as",
+				Namespace: namespace,
+				Name:      fmt.Sprintf("system:serviceaccount:%s:%s", serviceAccount.Namespace, serviceAccount.Name),
+			},
+		},
+	}
+	sar, err := sarClient.SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func newExecutor(namespace, name string) *workapiv1.ManifestWorkExecutor {
+	return &workapiv1.ManifestWorkExecutor{
+		Subject: workapiv1.ManifestWorkExecutorSubject{
+			Type: workapiv1.ExecutorSubjectTypeServiceAccount,
+			ServiceAccount: &workapiv1.ManifestWorkSubjectServiceAccount{
+				Namespace: namespace,
+				Name:      name,
+			},
+		},
+	}
+}
+
+func TestRenderExecutor(t *testing.T) {
+	executor := newExecutor("{{ .ClusterName }}-apps", "{{.ClusterName}}")
+	rendered := RenderExecutor(executor, "cluster1")
+	if rendered.Subject.ServiceAccount.Namespace != "cluster1-apps" || rendered.Subject.ServiceAccount.Name != "cluster1" {
+		t.Errorf("unexpected rendered executor %v", rendered.Subject.ServiceAccount)
+	}
+	if executor.Subject.ServiceAccount.Namespace != "{{ .ClusterName }}-apps" {
+		t.Errorf("expected the executor template is not changed")
+	}
+
+	executor = newExecutor("apps", "deployer")
+	if RenderExecutor(executor, "cluster1") != executor {
+		t.Errorf("expected the executor without placeholder is returned as it is")
+	}
+}
+
+func TestValidateExecutorTemplate(t *testing.T) {
+	cases := []struct {
+		name          string
+		executor      *workapiv1.ManifestWorkExecutor
+		expectedError string
+	}{
+		{
+			name: "no executor",
+		},
+		{
+			name:     "no placeholder",
+			executor: newExecutor("apps", "deployer"),
+		},
+		{
+			name:     "valid template",
+			executor: newExecutor("{{ .ClusterName }}-apps", "deployer-{{ .ClusterName }}"),
+		},
+		{
+			name:          "unsupported placeholder",
+			executor:      newExecutor("{{ .Values.namespace }}", "deployer"),
+			expectedError: "only the placeholder {{ .ClusterName }} is supported in the executor",
+		},
+		{
+			name:     "invalid namespace",
+			executor: newExecutor("{{ .ClusterName }}_apps", "deployer"),
+			expectedError: "the executor namespace \"{{ .ClusterName }}_apps\" is invalid: a lowercase RFC 1123 label " +
+				"must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric " +
+				"character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateExecutorTemplate(c.executor)
+			switch {
+			case len(c.expectedError) == 0 && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case len(c.expectedError) > 0 && (err == nil || err.Error() != c.expectedError):
+				t.Errorf("expected error %q, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -19,6 +21,7 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 
 	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
 	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
 )
@@ -43,6 +46,20 @@ func newManifestCondition(ordinal int32, resource string, conds ...metav1.Condit
 	}
 }
 
+func newConfigMapManifestCondition(ordinal int32, name, resource string, conds ...metav1.Condition) workapiv1.ManifestCondition {
+	return workapiv1.ManifestCondition{
+		ResourceMeta: workapiv1.ManifestResourceMeta{
+			Ordinal:   ordinal,
+			Version:   "v1",
+			Kind:      "ConfigMap",
+			Resource:  resource,
+			Namespace: "ns1",
+			Name:      name,
+		},
+		Conditions: conds,
+	}
+}
+
 func newSecret(namespace, name string, terminated bool, uid string, owner ...metav1.OwnerReference) *corev1.Secret {
 	secret := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -138,6 +155,66 @@ func TestMergeManifestConditions(t *testing.T) {
 				newManifestCondition(0, "resource2", newCondition("two", "True", "my-reason", "my-message", &transitionTime)),
 			},
 		},
+		{
+			name: "insert manifest",
+			startingConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(1, "cm2", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+			},
+			newConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+				newConfigMapManifestCondition(1, "cm3", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+				newConfigMapManifestCondition(2, "cm2", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+			},
+			expectedConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(1, "cm3", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+				newConfigMapManifestCondition(2, "cm2", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+			},
+		},
+		{
+			name: "remove manifest",
+			startingConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(1, "cm2", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(2, "cm3", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+			},
+			newConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+				newConfigMapManifestCondition(1, "cm3", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+			},
+			expectedConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(1, "cm3", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+			},
+		},
+		{
+			name: "reorder manifests",
+			startingConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(1, "cm2", "configmaps", newCondition("one", "False", "my-reason", "my-message", &transitionTime)),
+			},
+			newConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm2", "configmaps", newCondition("one", "False", "my-reason", "my-message", nil)),
+				newConfigMapManifestCondition(1, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", nil)),
+			},
+			expectedConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm2", "configmaps", newCondition("one", "False", "my-reason", "my-message", &transitionTime)),
+				newConfigMapManifestCondition(1, "cm1", "configmaps", newCondition("one", "True", "my-reason", "my-message", &transitionTime)),
+			},
+		},
+		{
+			name: "match with identity when resource is resolved",
+			startingConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(0, "cm1", "", newCondition("one", "False", "my-reason", "my-message", &transitionTime)),
+			},
+			newConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(1, "cm1", "configmaps", newCondition("one", "False", "my-reason", "my-message", nil)),
+			},
+			expectedConditions: []workapiv1.ManifestCondition{
+				newConfigMapManifestCondition(1, "cm1", "configmaps", newCondition("one", "False", "my-reason", "my-message", &transitionTime)),
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -167,6 +244,37 @@ func TestMergeManifestConditions(t *testing.T) {
 	}
 }
 
+func TestManifestIdentity(t *testing.T) {
+	cases := []struct {
+		name             string
+		meta             workapiv1.ManifestResourceMeta
+		expectedIdentity string
+	}{
+		{
+			name:             "namespaced manifest",
+			meta:             workapiv1.ManifestResourceMeta{Ordinal: 1, Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments", Namespace: "ns1", Name: "test"},
+			expectedIdentity: "apps/v1/Deployment/ns1/test",
+		},
+		{
+			name:             "cluster scoped manifest",
+			meta:             workapiv1.ManifestResourceMeta{Version: "v1", Kind: "Namespace", Name: "ns1"},
+			expectedIdentity: "/v1/Namespace//ns1",
+		},
+		{
+			name: "unknown manifest",
+			meta: workapiv1.ManifestResourceMeta{Ordinal: 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if identity := ManifestIdentity(c.meta); identity != c.expectedIdentity {
+				t.Errorf("expected identity %q, but got %q", c.expectedIdentity, identity)
+			}
+		})
+	}
+}
+
 func TestMergeStatusConditions(t *testing.T) {
 	transitionTime := metav1.Now()
 
@@ -338,7 +446,7 @@ func TestDeleteAppliedResourcess(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(scheme, c.existingResources...)
-			actual, err := DeleteAppliedResources(context.TODO(), c.resourcesToRemove, "testing", fakeDynamicClient, eventstesting.NewTestingEventRecorder(t), c.owner)
+			actual, err := DeleteAppliedResources(context.TODO(), c.resourcesToRemove, "testing", fakeDynamicClient, eventstesting.NewTestingEventRecorder(t), c.owner, "hub1")
 			if err != nil {
 				t.Errorf("unexpected err: %v", err)
 			}
@@ -350,6 +458,67 @@ func TestDeleteAppliedResourcess(t *testing.T) {
 	}
 }
 
+func TestDeleteAppliedResourcesWithSourceHubHash(t *testing.T) {
+	owner := metav1.OwnerReference{Name: "n1", UID: "a"}
+	cases := []struct {
+		name          string
+		hubHash       string
+		expectDeleted bool
+	}{
+		{
+			name:          "delete resource applied by the same hub",
+			hubHash:       "hub1",
+			expectDeleted: true,
+		},
+		{
+			name:          "skip resource applied by other hub",
+			hubHash:       "hub2",
+			expectDeleted: false,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secret := newSecret("ns1", "n1", false, "ns1-n1", owner)
+			secret.Annotations = map[string]string{SourceHubHashAnnotation: c.hubHash}
+			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(scheme, secret)
+			resourcesToRemove := []workapiv1.AppliedManifestResourceMeta{
+				{Version: "v1", ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "secrets", Namespace: "ns1", Name: "n1"}, UID: "ns1-n1"},
+			}
+
+			pending, errs := DeleteAppliedResources(context.TODO(), resourcesToRemove, "testing", fakeDynamicClient,
+				eventstesting.NewTestingEventRecorder(t), owner, "hub1")
+			if len(errs) != 0 {
+				t.Errorf("unexpected err: %v", errs)
+			}
+
+			if c.expectDeleted {
+				if len(pending) != 1 {
+					t.Errorf("expected the resource to be deleted, but got pending resources %v", pending)
+				}
+				return
+			}
+
+			if len(pending) != 0 {
+				t.Errorf("expected no pending resources, but got %v", pending)
+			}
+			u, err := fakeDynamicClient.Resource(gvr).Namespace("ns1").Get(context.TODO(), "n1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected the resource applied by other hub to be kept, but got %v", err)
+			}
+			if IsOwnedBy(owner, u.GetOwnerReferences()) {
+				t.Errorf("expected the owner to be removed, but got %v", u.GetOwnerReferences())
+			}
+		})
+	}
+}
+
 func TestHubHash(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -514,6 +683,84 @@ func TestApplyOwnerReferences(t *testing.T) {
 	}
 }
 
+func TestApplyOwnerReferencesAndAnnotations(t *testing.T) {
+	owner := metav1.OwnerReference{Name: "n1", UID: "a"}
+	testCases := []struct {
+		name     string
+		existing map[string]string
+		required map[string]string
+
+		wantPatch       bool
+		wantAnnotations map[string]interface{}
+	}{
+		{
+			name:            "stamp annotations",
+			required:        map[string]string{SourceHubHashAnnotation: "hub1", SourceAgentIDAnnotation: "agent1"},
+			wantPatch:       true,
+			wantAnnotations: map[string]interface{}{SourceHubHashAnnotation: "hub1", SourceAgentIDAnnotation: "agent1"},
+		},
+		{
+			name:            "update annotations",
+			existing:        map[string]string{SourceHubHashAnnotation: "hub2", SourceAgentIDAnnotation: "agent1"},
+			required:        map[string]string{SourceHubHashAnnotation: "hub1", SourceAgentIDAnnotation: "agent1"},
+			wantPatch:       true,
+			wantAnnotations: map[string]interface{}{SourceHubHashAnnotation: "hub1"},
+		},
+		{
+			name:            "remove annotations",
+			existing:        map[string]string{SourceHubHashAnnotation: "hub1", "test": "test"},
+			required:        map[string]string{SourceHubHashAnnotation: "", SourceAgentIDAnnotation: ""},
+			wantPatch:       true,
+			wantAnnotations: map[string]interface{}{SourceHubHashAnnotation: nil},
+		},
+		{
+			name:     "annotations are up to date",
+			existing: map[string]string{SourceHubHashAnnotation: "hub1"},
+			required: map[string]string{SourceHubHashAnnotation: "hub1", SourceAgentIDAnnotation: ""},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			object := newSecret("ns1", "n1", false, "ns1-n1", owner)
+			object.Annotations = c.existing
+			fakeClient := fakedynamic.NewSimpleDynamicClient(scheme, object)
+			gvr := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+			err := ApplyOwnerReferencesAndAnnotations(context.TODO(), fakeClient, gvr, object, owner, c.required)
+			if err != nil {
+				t.Errorf("apply err: %v", err)
+			}
+
+			actions := fakeClient.Actions()
+			if !c.wantPatch {
+				if len(actions) > 0 {
+					t.Fatalf("expect not patch but got %v", actions)
+				}
+				return
+			}
+
+			if len(actions) != 1 {
+				t.Fatalf("expect patch action but got %v", actions)
+			}
+
+			patch := actions[0].(clienttesting.PatchAction).GetPatch()
+			patchedObject := map[string]interface{}{}
+			if err := json.Unmarshal(patch, &patchedObject); err != nil {
+				t.Fatalf("failed to marshal patch: %v", err)
+			}
+			annotations, _, _ := unstructured.NestedFieldNoCopy(patchedObject, "metadata", "annotations")
+			if !equality.Semantic.DeepEqual(c.wantAnnotations, annotations) {
+				t.Errorf("want annotations %v, but got %v", c.wantAnnotations, annotations)
+			}
+		})
+	}
+}
+
 func TestOwnedByTheWork(t *testing.T) {
 	testGVR := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
 	namespace := "testns"
@@ -587,6 +834,10 @@ func TestOwnedByTheWork(t *testing.T) {
 
 func TestBuildResourceMeta(t *testing.T) {
 	restMapper := spoketesting.NewFakeRestMapper()
+	noKindMatchErr := &meta.NoKindMatchError{
+		GroupKind:        schema.GroupKind{Group: "test", Kind: "NewObject"},
+		SearchedVersions: []string{"v1"},
+	}
 
 	cases := []struct {
 		name         string
@@ -630,7 +881,7 @@ func TestBuildResourceMeta(t *testing.T) {
 			name:        "unknow object type",
 			index:       1,
 			obj:         spoketesting.NewUnstructured("test/v1", "NewObject", "ns1", "test"),
-			expectedErr: fmt.Errorf("the server doesn't have a resource type %q", "NewObject"),
+			expectedErr: fmt.Errorf("the server doesn't have a resource type %q: %w", "NewObject", noKindMatchErr),
 			expectedGVR: schema.GroupVersionResource{},
 			expectedMeta: workapiv1.ManifestResourceMeta{
 				Ordinal:   int32(1),
@@ -666,3 +917,48 @@ func TestBuildResourceMeta(t *testing.T) {
 		})
 	}
 }
+
+func TestPlacementRefNamespace(t *testing.T) {
+	cases := []struct {
+		name              string
+		annotations       map[string]string
+		placementRef      string
+		expectedNamespace string
+	}{
+		{
+			name:              "no annotation",
+			placementRef:      "place1",
+			expectedNamespace: "default",
+		},
+		{
+			name:              "placement in other namespace",
+			annotations:       map[string]string{PlacementRefNamespacesAnnotation: `{"place1": "shared"}`},
+			placementRef:      "place1",
+			expectedNamespace: "shared",
+		},
+		{
+			name:              "placement not in annotation",
+			annotations:       map[string]string{PlacementRefNamespacesAnnotation: `{"place1": "shared"}`},
+			placementRef:      "place2",
+			expectedNamespace: "default",
+		},
+		{
+			name:              "invalid annotation",
+			annotations:       map[string]string{PlacementRefNamespacesAnnotation: `shared`},
+			placementRef:      "place1",
+			expectedNamespace: "default",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := &workapiv1alpha1.ManifestWorkReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Annotations: c.annotations},
+			}
+			namespace := PlacementRefNamespace(mwrSet, c.placementRef)
+			if namespace != c.expectedNamespace {
+				t.Errorf("expect namespace %s, but got %s", c.expectedNamespace, namespace)
+			}
+		})
+	}
+}
@@ -23,21 +23,94 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
 
 	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 )
 
 const (
 	// unknownKind is returned by resourcehelper.GuessObjectGroupVersionKind() when it
 	// cannot tell the kind of the given object
 	unknownKind = "<unknown>"
+
+	// PlacementRefNamespacesAnnotation is the annotation on a ManifestWorkReplicaSet to reference placements
+	// in other namespaces. The value is a json map from the name of a placementRef to the namespace of the
+	// placement, e.g. {"placement1": "shared"}. The placementRefs not in the map refer to the placements in
+	// the namespace of the ManifestWorkReplicaSet.
+	PlacementRefNamespacesAnnotation = "work.open-cluster-management.io/placement-ref-namespaces"
+
+	// SourceHubHashAnnotation, SourceManifestWorkAnnotation and SourceAgentIDAnnotation are the annotations
+	// stamped on the resources applied by the work agent, to indicate the hash of the hub, the namespace/name
+	// of the manifestwork and the id of the agent which apply the resource.
+	SourceHubHashAnnotation      = "work.open-cluster-management.io/source-hub-hash"
+	SourceManifestWorkAnnotation = "work.open-cluster-management.io/source-manifestwork"
+	SourceAgentIDAnnotation      = "work.open-cluster-management.io/source-agent-id"
+
+	// LastAppliedTimeAnnotation is the annotation on a ManifestWork set by the work agent with the RFC3339 time
+	// when any of the manifests is last created or changed on the managed cluster. It is not updated if the apply
+	// is a no-op. On a ManifestWorkReplicaSet, it is the oldest last applied time of its ManifestWorks.
+	// TODO move this to the api repo
+	LastAppliedTimeAnnotation = "work.open-cluster-management.io/last-applied-time"
+	// AppliedGenerationAnnotation is the annotation on a ManifestWork set by the work agent with the generation
+	// of the ManifestWork whose manifests are all applied successfully.
+	// TODO move this to the api repo
+	AppliedGenerationAnnotation = "work.open-cluster-management.io/applied-generation"
+	// PriorityAnnotation is the annotation on a ManifestWork to set the priority class with which the work agent
+	// syncs the ManifestWork, the value is one of critical, high, normal and low. The ManifestWork is synced with
+	// the normal priority if the annotation is not set or invalid. On a ManifestWorkReplicaSet, it is propagated
+	// to the ManifestWorks.
+	// TODO move this to the api repo
+	PriorityAnnotation = "work.open-cluster-management.io/priority"
+	// RestartedAtAnnotation is the annotation on a ManifestWork to restart the Deployments, DaemonSets and
+	// StatefulSets in the work like kubectl rollout restart. The work agent sets its value, e.g. a timestamp, as
+	// the kubectl.kubernetes.io/restartedAt annotation of the pod templates, so the workloads are restarted once
+	// the value is changed. On a ManifestWorkReplicaSet, it is propagated to the ManifestWorks.
+	// TODO move this to the api repo
+	RestartedAtAnnotation = "work.open-cluster-management.io/restarted-at"
+
+	// ManifestWorkReplicaSetControllerNameLabelKey is the label key on manifestwork to ref to the ManifestWorkReplicaSet
+	// that owns this manifestwork
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetControllerNameLabelKey = "work.open-cluster-management.io/manifestworkreplicaset"
+	// TemplateHashAnnotationKey is the annotation on a manifestwork created by a ManifestWorkReplicaSet with the hash
+	// of the manifestwork rendered from the template when it is last applied. The value is a hex sha256 sum.
+	// TODO move this to the api repo
+	TemplateHashAnnotationKey = "work.open-cluster-management.io/template-hash"
+)
+
+// WorkPriority is the priority class of a ManifestWork, the ManifestWorks with a higher priority are synced
+// first by the work agent.
+type WorkPriority int
+
+const (
+	WorkPriorityLow WorkPriority = iota
+	WorkPriorityNormal
+	WorkPriorityHigh
+	WorkPriorityCritical
+
+	// NumWorkPriorities is the number of the priority classes.
+	NumWorkPriorities = int(WorkPriorityCritical) + 1
 )
 
+var workPriorities = map[string]WorkPriority{
+	"low":      WorkPriorityLow,
+	"normal":   WorkPriorityNormal,
+	"high":     WorkPriorityHigh,
+	"critical": WorkPriorityCritical,
+}
+
+// GetWorkPriority returns the priority class of the object set by the PriorityAnnotation.
+func GetWorkPriority(obj metav1.Object) WorkPriority {
+	if priority, ok := workPriorities[obj.GetAnnotations()[PriorityAnnotation]]; ok {
+		return priority
+	}
+	return WorkPriorityNormal
+}
+
 var (
 	genericScheme = runtime.NewScheme()
 )
@@ -51,30 +124,27 @@ func init() {
 // MergeManifestConditions return a new ManifestCondition array which merges the existing manifest
 // conditions and the new manifest conditions. Rules to match ManifestCondition between two arrays:
 // 1. match the manifest condition with the whole ManifestResourceMeta;
-// 2. if not matched, try to match with properties other than ordinal in ManifestResourceMeta
+// 2. if not matched, try to match with properties other than ordinal in ManifestResourceMeta;
+// 3. if still not matched, try to match with the identity of the manifest, see ManifestIdentity.
 // If no existing manifest condition is matched, the new manifest condition will be used.
+// So the conditions of a manifest, including their lastTransitionTimes, are kept even if the manifest
+// is moved to another position in the spec by inserting, removing or reordering the other manifests.
 func MergeManifestConditions(conditions, newConditions []workapiv1.ManifestCondition) []workapiv1.ManifestCondition {
 	merged := []workapiv1.ManifestCondition{}
 
 	// build search indices
 	metaIndex := map[workapiv1.ManifestResourceMeta]workapiv1.ManifestCondition{}
-	metaWithoutOridinalIndex := map[workapiv1.ManifestResourceMeta]workapiv1.ManifestCondition{}
+	metaWithoutOridinalIndex := newUniqueManifestConditionIndex[workapiv1.ManifestResourceMeta]()
+	identityIndex := newUniqueManifestConditionIndex[string]()
 
-	duplicated := []workapiv1.ManifestResourceMeta{}
 	for _, condition := range conditions {
 		metaIndex[condition.ResourceMeta] = condition
 		if metaWithoutOridinal := resetOrdinal(condition.ResourceMeta); metaWithoutOridinal != (workapiv1.ManifestResourceMeta{}) {
-			if _, exists := metaWithoutOridinalIndex[metaWithoutOridinal]; exists {
-				duplicated = append(duplicated, metaWithoutOridinal)
-			} else {
-				metaWithoutOridinalIndex[metaWithoutOridinal] = condition
-			}
+			metaWithoutOridinalIndex.add(metaWithoutOridinal, condition)
+		}
+		if identity := ManifestIdentity(condition.ResourceMeta); len(identity) > 0 {
+			identityIndex.add(identity, condition)
 		}
-	}
-
-	// remove metaWithoutOridinal from index if it is not unique
-	for _, metaWithoutOridinal := range duplicated {
-		delete(metaWithoutOridinalIndex, metaWithoutOridinal)
 	}
 
 	// try to match and merge manifest conditions
@@ -84,7 +154,12 @@ func MergeManifestConditions(conditions, newConditions []workapiv1.ManifestCondi
 
 		// match with properties in ResourceMeta other than ordinal if not found yet
 		if !ok {
-			condition, ok = metaWithoutOridinalIndex[resetOrdinal(newCondition.ResourceMeta)]
+			condition, ok = metaWithoutOridinalIndex.get(resetOrdinal(newCondition.ResourceMeta))
+		}
+
+		// match with the identity of the manifest if not found yet
+		if !ok {
+			condition, ok = identityIndex.get(ManifestIdentity(newCondition.ResourceMeta))
 		}
 
 		// if there is existing condition, merge it with new condition
@@ -104,6 +179,48 @@ func MergeManifestConditions(conditions, newConditions []workapiv1.ManifestCondi
 	return merged
 }
 
+// ManifestIdentity returns a stable identity of a manifest built from its group, version, kind,
+// namespace and name. Unlike the ordinal, it does not change when the other manifests in the
+// work are inserted, removed or reordered; and unlike the resource, it is known even if the api
+// of the manifest is not available on the managed cluster. An empty string is returned if the
+// kind or name of the manifest is unknown.
+func ManifestIdentity(meta workapiv1.ManifestResourceMeta) string {
+	if len(meta.Kind) == 0 || len(meta.Name) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s", meta.Group, meta.Version, meta.Kind, meta.Namespace, meta.Name)
+}
+
+// uniqueManifestConditionIndex indexes manifest conditions by a key, the keys shared by more
+// than one condition are not matched since the condition cannot be determined.
+type uniqueManifestConditionIndex[K comparable] struct {
+	index      map[K]workapiv1.ManifestCondition
+	duplicated map[K]bool
+}
+
+func newUniqueManifestConditionIndex[K comparable]() *uniqueManifestConditionIndex[K] {
+	return &uniqueManifestConditionIndex[K]{
+		index:      map[K]workapiv1.ManifestCondition{},
+		duplicated: map[K]bool{},
+	}
+}
+
+func (i *uniqueManifestConditionIndex[K]) add(key K, condition workapiv1.ManifestCondition) {
+	if _, exists := i.index[key]; exists {
+		i.duplicated[key] = true
+		return
+	}
+	i.index[key] = condition
+}
+
+func (i *uniqueManifestConditionIndex[K]) get(key K) (workapiv1.ManifestCondition, bool) {
+	if i.duplicated[key] {
+		return workapiv1.ManifestCondition{}, false
+	}
+	condition, ok := i.index[key]
+	return condition, ok
+}
+
 func resetOrdinal(meta workapiv1.ManifestResourceMeta) workapiv1.ManifestResourceMeta {
 	return workapiv1.ManifestResourceMeta{
 		Group:     meta.Group,
@@ -140,13 +257,16 @@ func MergeStatusConditions(conditions []metav1.Condition, newConditions []metav1
 
 // DeleteAppliedResources deletes all given applied resources and returns those pending for finalization
 // If the uid recorded in resources is different from what we get by client, ignore the deletion.
+// If the resource is stamped by the work agent of another hub, see SourceHubHashAnnotation, only the owner
+// is removed from the resource to protect it from being deleted across hubs.
 func DeleteAppliedResources(
 	ctx context.Context,
 	resources []workapiv1.AppliedManifestResourceMeta,
 	reason string,
 	dynamicClient dynamic.Interface,
 	recorder events.Recorder,
-	owner metav1.OwnerReference) ([]workapiv1.AppliedManifestResourceMeta, []error) {
+	owner metav1.OwnerReference,
+	hubHash string) ([]workapiv1.AppliedManifestResourceMeta, []error) {
 	var resourcesPendingFinalization []workapiv1.AppliedManifestResourceMeta
 	var errs []error
 
@@ -184,6 +304,20 @@ func DeleteAppliedResources(
 			continue
 		}
 
+		// If the resource is applied by the work agent of another hub, update ownerrefs only.
+		if sourceHubHash, ok := u.GetAnnotations()[SourceHubHashAnnotation]; ok && sourceHubHash != hubHash {
+			klog.V(2).Infof("Skip deleting resource %v with key %s/%s since it is applied by hub %s",
+				gvr, resource.Namespace, resource.Name, sourceHubHash)
+			err := ApplyOwnerReferences(ctx, dynamicClient, gvr, u, *ownerCopy)
+			if err != nil {
+				errs = append(errs, fmt.Errorf(
+					"failed to remove owner from resource %v with key %s/%s: %w",
+					gvr, resource.Namespace, resource.Name, err))
+			}
+
+			continue
+		}
+
 		// If there are still any other existing appliedManifestWorks owners, update ownerrefs only.
 		if existOtherAppliedManifestWorkOwners(owner, existingOwner) {
 			err := ApplyOwnerReferences(ctx, dynamicClient, gvr, u, *ownerCopy)
@@ -357,6 +491,13 @@ func FindManifestConiguration(resourceMeta workapiv1.ManifestResourceMeta, manif
 
 func ApplyOwnerReferences(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource,
 	existing runtime.Object, requiredOwner metav1.OwnerReference) error {
+	return ApplyOwnerReferencesAndAnnotations(ctx, dynamicClient, gvr, existing, requiredOwner, nil)
+}
+
+// ApplyOwnerReferencesAndAnnotations patches the owner references and the annotations of the existing resource
+// in one request. The annotation with an empty value in requiredAnnotations is removed from the resource.
+func ApplyOwnerReferencesAndAnnotations(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource,
+	existing runtime.Object, requiredOwner metav1.OwnerReference, requiredAnnotations map[string]string) error {
 	accessor, err := meta.Accessor(existing)
 	if err != nil {
 		return fmt.Errorf("type %t cannot be accessed: %v", existing, err)
@@ -371,6 +512,25 @@ func ApplyOwnerReferences(ctx context.Context, dynamicClient dynamic.Interface,
 	resourcemerge.MergeOwnerRefs(&modified, &patchedOwner, []metav1.OwnerReference{requiredOwner})
 	patch.SetOwnerReferences(patchedOwner)
 
+	existingAnnotations := accessor.GetAnnotations()
+	patchedAnnotations := map[string]interface{}{}
+	for key, value := range requiredAnnotations {
+		existingValue, ok := existingAnnotations[key]
+		switch {
+		case len(value) == 0 && ok:
+			// set to null to remove the annotation with the merge patch
+			patchedAnnotations[key] = nil
+		case len(value) > 0 && existingValue != value:
+			patchedAnnotations[key] = value
+		}
+	}
+	if len(patchedAnnotations) > 0 {
+		modified = true
+		if err := unstructured.SetNestedField(patch.Object, patchedAnnotations, "metadata", "annotations"); err != nil {
+			return err
+		}
+	}
+
 	if !modified {
 		return nil
 	}
@@ -465,7 +625,7 @@ func BuildResourceMeta(
 	}
 	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
-		return resourceMeta, schema.GroupVersionResource{}, fmt.Errorf("the server doesn't have a resource type %q", gvk.Kind)
+		return resourceMeta, schema.GroupVersionResource{}, fmt.Errorf("the server doesn't have a resource type %q: %w", gvk.Kind, err)
 	}
 
 	resourceMeta.Resource = mapping.Resource.Resource
@@ -480,10 +640,54 @@ func (pdl PlacementDecisionGetter) List(selector labels.Selector, namespace stri
 	return pdl.Client.PlacementDecisions(namespace).List(selector)
 }
 
-// Get added and deleted clusters names
-func GetClusters(client clusterlister.PlacementDecisionLister, placement *clusterv1beta1.Placement,
-	existingClusters sets.Set[string]) (sets.Set[string], sets.Set[string], error) {
-	pdtracker := clusterv1beta1.NewPlacementDecisionClustersTracker(placement, PlacementDecisionGetter{Client: client}, existingClusters)
+// GetPlacementRefNamespaces returns the namespaces of the placementRefs of the ManifestWorkReplicaSet
+// specified by the PlacementRefNamespacesAnnotation.
+func GetPlacementRefNamespaces(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) (map[string]string, error) {
+	value, ok := mwrSet.Annotations[PlacementRefNamespacesAnnotation]
+	if !ok {
+		return map[string]string{}, nil
+	}
+
+	namespaces := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &namespaces); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %s: %w", PlacementRefNamespacesAnnotation, err)
+	}
+	return namespaces, nil
+}
 
-	return pdtracker.Get()
+// PlacementRefNamespace returns the namespace of the placement referenced by the ManifestWorkReplicaSet.
+// The namespace of the ManifestWorkReplicaSet is returned if the placementRef is not in the
+// PlacementRefNamespacesAnnotation or the annotation is invalid.
+func PlacementRefNamespace(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, placementRefName string) string {
+	namespaces, err := GetPlacementRefNamespaces(mwrSet)
+	if err != nil {
+		return mwrSet.Namespace
+	}
+	if namespace := namespaces[placementRefName]; len(namespace) > 0 {
+		return namespace
+	}
+	return mwrSet.Namespace
+}
+
+// ManifestWorkReplicaSetMetadata returns the labels and the annotations of the manifestworks created by the
+// ManifestWorkReplicaSet, except the TemplateHashAnnotationKey which is set on the rendered manifestwork.
+func ManifestWorkReplicaSetMetadata(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) (map[string]string, map[string]string) {
+	labels := map[string]string{
+		ManifestWorkReplicaSetControllerNameLabelKey: fmt.Sprintf("%s.%s", mwrSet.Namespace, mwrSet.Name),
+	}
+
+	var annotations map[string]string
+	// the manifestworks are synced by the work agents with the priority of the ManifestWorkReplicaSet, and the
+	// workloads in all the clusters are restarted with one edit of the ManifestWorkReplicaSet.
+	for _, key := range []string{PriorityAnnotation, RestartedAtAnnotation} {
+		if value, ok := mwrSet.Annotations[key]; ok {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = value
+		}
+	}
+	// the manifestworks depend on the manifestworks of the other ManifestWorkReplicaSets by their names, since the
+	// manifestworks are named after the ManifestWorkReplicaSets in each cluster namespace.
+	return labels, PropagateDependencies(mwrSet, annotations)
 }
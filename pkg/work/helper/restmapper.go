@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+var _ meta.ResettableRESTMapper = &resettableRESTMapper{}
+
+// resettableRESTMapper delegates to a RESTMapper which is rebuilt on reset. The lazy RESTMapper caches the
+// mappings of a group once it is discovered, so the stale mappings, e.g. the scope of a CRD changed across
+// versions, are dropped by rebuilding the mapper.
+type resettableRESTMapper struct {
+	lock      sync.RWMutex
+	newMapper func() (meta.RESTMapper, error)
+	delegate  meta.RESTMapper
+}
+
+// NewResettableRESTMapper returns a ResettableRESTMapper with the mapper built by newMapper.
+func NewResettableRESTMapper(newMapper func() (meta.RESTMapper, error)) (meta.ResettableRESTMapper, error) {
+	delegate, err := newMapper()
+	if err != nil {
+		return nil, err
+	}
+	return &resettableRESTMapper{newMapper: newMapper, delegate: delegate}, nil
+}
+
+// Reset rebuilds the delegated mapper, the current mapper is kept if it fails to build a new one.
+func (m *resettableRESTMapper) Reset() {
+	delegate, err := m.newMapper()
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.delegate = delegate
+}
+
+func (m *resettableRESTMapper) mapper() meta.RESTMapper {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.delegate
+}
+
+func (m *resettableRESTMapper) KindFor(resource schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	return m.mapper().KindFor(resource)
+}
+
+func (m *resettableRESTMapper) KindsFor(resource schema.GroupVersionResource) ([]schema.GroupVersionKind, error) {
+	return m.mapper().KindsFor(resource)
+}
+
+func (m *resettableRESTMapper) ResourceFor(input schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+	return m.mapper().ResourceFor(input)
+}
+
+func (m *resettableRESTMapper) ResourcesFor(input schema.GroupVersionResource) ([]schema.GroupVersionResource, error) {
+	return m.mapper().ResourcesFor(input)
+}
+
+func (m *resettableRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	return m.mapper().RESTMapping(gk, versions...)
+}
+
+func (m *resettableRESTMapper) RESTMappings(gk schema.GroupKind, versions ...string) ([]*meta.RESTMapping, error) {
+	return m.mapper().RESTMappings(gk, versions...)
+}
+
+func (m *resettableRESTMapper) ResourceSingularizer(resource string) (string, error) {
+	return m.mapper().ResourceSingularizer(resource)
+}
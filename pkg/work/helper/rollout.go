@@ -0,0 +1,228 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// RolloutStrategyAnnotationKey is the annotation on a ManifestWorkReplicaSet to set the strategy of the
+	// progressive rollout, either Progressive or ProgressivePerGroup. It is Progressive if only the
+	// RolloutMaxConcurrencyAnnotationKey is set.
+	// TODO move this to the api repo
+	RolloutStrategyAnnotationKey = "work.open-cluster-management.io/rollout-strategy"
+
+	// RolloutMaxConcurrencyAnnotationKey is the annotation on a ManifestWorkReplicaSet to roll out the manifestworks
+	// progressively, the value is the max number, e.g. 2, or the percentage, e.g. 20%, of the clusters whose
+	// manifestworks are created or updated at a time. The next clusters are rolled out once the manifestworks of
+	// the current clusters are applied and available. The manifestworks are rolled out to all the clusters at once
+	// without it.
+	// TODO move this to the api repo
+	RolloutMaxConcurrencyAnnotationKey = "work.open-cluster-management.io/rollout-max-concurrency"
+
+	// RolloutProgressDeadlineAnnotationKey is the annotation on a ManifestWorkReplicaSet to set the duration, e.g.
+	// 10m, a cluster is waited for in the progressive rollout. The rollout proceeds without the cluster whose
+	// manifestwork is not available after the deadline. The rollout waits for the clusters forever if it is not set.
+	// TODO move this to the api repo
+	RolloutProgressDeadlineAnnotationKey = "work.open-cluster-management.io/rollout-progress-deadline"
+
+	// RolloutMandatoryDecisionGroupsAnnotationKey is the annotation on a ManifestWorkReplicaSet rolled out with the
+	// ProgressivePerGroup strategy, the value is the comma separated indexes of the decision groups, e.g. 1,2, the
+	// rollout waits for. The rollout waits for every decision group if it is not set.
+	// TODO move this to the api repo
+	RolloutMandatoryDecisionGroupsAnnotationKey = "work.open-cluster-management.io/rollout-mandatory-decision-groups"
+
+	// RolloutMaxFailuresAnnotationKey is the annotation on a ManifestWorkReplicaSet rolled out with the
+	// ProgressivePerGroup strategy, the value is the max number, e.g. 1, or the percentage, e.g. 10%, of the
+	// clusters in a decision group which are degraded or exceed the progress deadline. The rollout stops at the
+	// decision group with more failed clusters. It is 0 if not set.
+	// TODO move this to the api repo
+	RolloutMaxFailuresAnnotationKey = "work.open-cluster-management.io/rollout-max-failures"
+
+	// RolloutDecisionGroupsAnnotationKey is the annotation on a ManifestWorkReplicaSet rolled out with the
+	// ProgressivePerGroup strategy, the value is the semicolon separated label selectors of the ManagedClusters,
+	// e.g. env=dev;env=staging, each of which selects the clusters of a decision group in order. The decision
+	// groups are the placements in the order of the placementRefs if it is not set.
+	// TODO move this to the api repo
+	RolloutDecisionGroupsAnnotationKey = "work.open-cluster-management.io/rollout-decision-groups"
+
+	// RolloutStrategyProgressive rolls out the clusters in waves limited by the max concurrency.
+	RolloutStrategyProgressive = "Progressive"
+	// RolloutStrategyProgressivePerGroup rolls out the clusters in the order of the decision groups, which are
+	// either selected by the RolloutDecisionGroupsAnnotationKey or the placements in the order of the placementRefs.
+	RolloutStrategyProgressivePerGroup = "ProgressivePerGroup"
+)
+
+// RolloutStrategy is the progressive rollout parsed from the annotations of a ManifestWorkReplicaSet.
+type RolloutStrategy struct {
+	PerGroup         bool
+	MaxConcurrency   int
+	ProgressDeadline time.Duration
+	// MandatoryGroups is the indexes of the decision groups the rollout per group waits for, all the decision
+	// groups if empty.
+	MandatoryGroups sets.Set[int]
+	MaxFailures     intstr.IntOrString
+	// DecisionGroups is the label selectors of the clusters of the decision groups in order, the decision groups
+	// are the placements if empty.
+	DecisionGroups []labels.Selector
+}
+
+// ParseRolloutStrategy returns the rollout strategy of the annotations, or nil if the manifestworks are not
+// rolled out progressively. The max concurrency is scaled by the number of the clusters, at least 1, and is 100%
+// if not set.
+func ParseRolloutStrategy(annotations map[string]string, total int) (*RolloutStrategy, error) {
+	strategy := &RolloutStrategy{MandatoryGroups: sets.New[int]()}
+	switch value := annotations[RolloutStrategyAnnotationKey]; value {
+	case "":
+		if _, ok := annotations[RolloutMaxConcurrencyAnnotationKey]; !ok {
+			return nil, nil
+		}
+	case RolloutStrategyProgressive:
+	case RolloutStrategyProgressivePerGroup:
+		strategy.PerGroup = true
+	default:
+		return nil, fmt.Errorf("invalid %s %q", RolloutStrategyAnnotationKey, value)
+	}
+
+	if value := annotations[RolloutProgressDeadlineAnnotationKey]; len(value) > 0 {
+		deadline, err := time.ParseDuration(value)
+		if err != nil || deadline <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", RolloutProgressDeadlineAnnotationKey, value)
+		}
+		strategy.ProgressDeadline = deadline
+	}
+
+	if strategy.PerGroup {
+		if value := annotations[RolloutMandatoryDecisionGroupsAnnotationKey]; len(value) > 0 {
+			for _, item := range strings.Split(value, ",") {
+				index, err := strconv.Atoi(strings.TrimSpace(item))
+				if err != nil || index <= 0 {
+					return nil, fmt.Errorf("invalid %s %q", RolloutMandatoryDecisionGroupsAnnotationKey, value)
+				}
+				strategy.MandatoryGroups.Insert(index)
+			}
+		}
+
+		if value, ok := annotations[RolloutDecisionGroupsAnnotationKey]; ok {
+			for _, item := range strings.Split(value, ";") {
+				selector, err := labels.Parse(strings.TrimSpace(item))
+				if err != nil || selector.Empty() {
+					return nil, fmt.Errorf("invalid %s %q", RolloutDecisionGroupsAnnotationKey, value)
+				}
+				strategy.DecisionGroups = append(strategy.DecisionGroups, selector)
+			}
+		}
+
+		strategy.MaxFailures = intstr.FromInt(0)
+		if value, ok := annotations[RolloutMaxFailuresAnnotationKey]; ok {
+			strategy.MaxFailures = intstr.Parse(value)
+			scaled, err := intstr.GetScaledValueFromIntOrPercent(&strategy.MaxFailures, 100, false)
+			if err != nil || scaled < 0 {
+				return nil, fmt.Errorf("invalid %s %q", RolloutMaxFailuresAnnotationKey, value)
+			}
+		}
+		return strategy, nil
+	}
+
+	// the Progressive strategy rolls out to all the clusters at once without the max concurrency, the same as the
+	// default of the webhook, in case the webhook is not run
+	maxConcurrency := intstr.FromString("100%")
+	value, ok := annotations[RolloutMaxConcurrencyAnnotationKey]
+	if ok {
+		maxConcurrency = intstr.Parse(value)
+	}
+	scaled, err := intstr.GetScaledValueFromIntOrPercent(&maxConcurrency, total, true)
+	if err != nil || (maxConcurrency.Type == intstr.Int && maxConcurrency.IntVal <= 0) || scaled < 0 {
+		return nil, fmt.Errorf("invalid %s %q", RolloutMaxConcurrencyAnnotationKey, value)
+	}
+	if scaled == 0 {
+		scaled = 1
+	}
+	strategy.MaxConcurrency = scaled
+	return strategy, nil
+}
+
+// rolloutAnnotationKeys is the annotations setting the progressive rollout of a ManifestWorkReplicaSet.
+var rolloutAnnotationKeys = []string{
+	RolloutStrategyAnnotationKey,
+	RolloutMaxConcurrencyAnnotationKey,
+	RolloutProgressDeadlineAnnotationKey,
+	RolloutMandatoryDecisionGroupsAnnotationKey,
+	RolloutMaxFailuresAnnotationKey,
+	RolloutDecisionGroupsAnnotationKey,
+}
+
+// RolloutStrategyChanged returns true if any of the rollout annotations is changed.
+func RolloutStrategyChanged(oldAnnotations, newAnnotations map[string]string) bool {
+	for _, key := range rolloutAnnotationKeys {
+		oldValue, oldOk := oldAnnotations[key]
+		newValue, newOk := newAnnotations[key]
+		if oldOk != newOk || oldValue != newValue {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRolloutStrategy sets the missing rollout annotations of a progressive rollout: the strategy is Progressive
+// if only the max concurrency is set, the Progressive strategy rolls out to all the clusters at once and the
+// ProgressivePerGroup strategy tolerates no failure by default. It returns true if the annotations are changed.
+func DefaultRolloutStrategy(annotations map[string]string) bool {
+	_, maxConcurrencyOk := annotations[RolloutMaxConcurrencyAnnotationKey]
+	switch annotations[RolloutStrategyAnnotationKey] {
+	case "":
+		if !maxConcurrencyOk {
+			return false
+		}
+		annotations[RolloutStrategyAnnotationKey] = RolloutStrategyProgressive
+		return true
+	case RolloutStrategyProgressive:
+		if maxConcurrencyOk {
+			return false
+		}
+		annotations[RolloutMaxConcurrencyAnnotationKey] = "100%"
+		return true
+	case RolloutStrategyProgressivePerGroup:
+		if _, ok := annotations[RolloutMaxFailuresAnnotationKey]; ok {
+			return false
+		}
+		annotations[RolloutMaxFailuresAnnotationKey] = "0"
+		return true
+	}
+	return false
+}
+
+// ValidateRolloutStrategy checks the rollout annotations are valid and consistent with the strategy: the max
+// concurrency is only set with the Progressive strategy, and the decision groups, the mandatory decision groups
+// and the max failures are only set with the ProgressivePerGroup strategy.
+func ValidateRolloutStrategy(annotations map[string]string) error {
+	strategy, err := ParseRolloutStrategy(annotations, 100)
+	if err != nil {
+		return err
+	}
+
+	perGroupKeys := []string{RolloutDecisionGroupsAnnotationKey, RolloutMandatoryDecisionGroupsAnnotationKey,
+		RolloutMaxFailuresAnnotationKey}
+	var inconsistent []string
+	switch {
+	case strategy == nil:
+		inconsistent = append([]string{RolloutProgressDeadlineAnnotationKey}, perGroupKeys...)
+	case strategy.PerGroup:
+		inconsistent = []string{RolloutMaxConcurrencyAnnotationKey}
+	default:
+		inconsistent = perGroupKeys
+	}
+	for _, key := range inconsistent {
+		if _, ok := annotations[key]; ok {
+			return fmt.Errorf("%s is not allowed with the %s %q", key, RolloutStrategyAnnotationKey,
+				annotations[RolloutStrategyAnnotationKey])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParseRolloutStrategy(t *testing.T) {
+	cases := []struct {
+		name                   string
+		annotations            map[string]string
+		total                  int
+		expectedNil            bool
+		expectedErr            bool
+		expectedPerGroup       bool
+		expectedMaxConcurrency int
+		expectedMaxFailures    intstr.IntOrString
+	}{
+		{
+			name:        "no rollout",
+			annotations: map[string]string{},
+			total:       10,
+			expectedNil: true,
+		},
+		{
+			name: "progressive with max concurrency",
+			annotations: map[string]string{
+				RolloutStrategyAnnotationKey:       RolloutStrategyProgressive,
+				RolloutMaxConcurrencyAnnotationKey: "20%",
+			},
+			total:                  10,
+			expectedMaxConcurrency: 2,
+		},
+		{
+			name:                   "max concurrency only",
+			annotations:            map[string]string{RolloutMaxConcurrencyAnnotationKey: "3"},
+			total:                  10,
+			expectedMaxConcurrency: 3,
+		},
+		{
+			name:                   "progressive without max concurrency",
+			annotations:            map[string]string{RolloutStrategyAnnotationKey: RolloutStrategyProgressive},
+			total:                  10,
+			expectedMaxConcurrency: 10,
+		},
+		{
+			name:                   "progressive without max concurrency and clusters",
+			annotations:            map[string]string{RolloutStrategyAnnotationKey: RolloutStrategyProgressive},
+			total:                  0,
+			expectedMaxConcurrency: 1,
+		},
+		{
+			name: "progressive with empty max concurrency",
+			annotations: map[string]string{
+				RolloutStrategyAnnotationKey:       RolloutStrategyProgressive,
+				RolloutMaxConcurrencyAnnotationKey: "",
+			},
+			total:       10,
+			expectedErr: true,
+		},
+		{
+			name:                "progressive per group without max failures",
+			annotations:         map[string]string{RolloutStrategyAnnotationKey: RolloutStrategyProgressivePerGroup},
+			total:               10,
+			expectedPerGroup:    true,
+			expectedMaxFailures: intstr.FromInt(0),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			strategy, err := ParseRolloutStrategy(c.annotations, c.total)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, but got %v", strategy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.expectedNil {
+				if strategy != nil {
+					t.Errorf("expected no rollout strategy, but got %v", strategy)
+				}
+				return
+			}
+			if strategy == nil {
+				t.Fatalf("expected rollout strategy, but got nil")
+			}
+			if strategy.PerGroup != c.expectedPerGroup {
+				t.Errorf("expected per group %v, but got %v", c.expectedPerGroup, strategy.PerGroup)
+			}
+			if strategy.MaxConcurrency != c.expectedMaxConcurrency {
+				t.Errorf("expected max concurrency %d, but got %d", c.expectedMaxConcurrency, strategy.MaxConcurrency)
+			}
+			if strategy.MaxFailures != c.expectedMaxFailures {
+				t.Errorf("expected max failures %v, but got %v", c.expectedMaxFailures, strategy.MaxFailures)
+			}
+		})
+	}
+}
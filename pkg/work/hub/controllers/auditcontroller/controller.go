@@ -0,0 +1,106 @@
+package auditcontroller
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	kevents "k8s.io/client-go/tools/events"
+
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+// ReasonManifestWorkSpecChanged is the reason of the events recording the spec changes of the manifestworks.
+const ReasonManifestWorkSpecChanged = "ManifestWorkSpecChanged"
+
+const (
+	// DefaultMaxMessageLength is the default max length of the message of an event, the changes exceeding it are
+	// counted instead of listed.
+	DefaultMaxMessageLength = 1000
+
+	// maxEventNoteLength is the limit of the note of an event.
+	maxEventNoteLength = 1024
+)
+
+// auditController records an event on a manifestwork each time its spec is changed, with the summary of the
+// added, removed and changed manifests and the changed spec fields. The old spec is only compared in memory
+// when the update is observed, and the values of the manifests are never recorded. The updates by a
+// ManifestWorkReplicaSet are attributed to it as the related object of the event.
+type auditController struct {
+	recorder         kevents.EventRecorder
+	maxMessageLength int
+}
+
+// NewAuditController registers the controller recording the spec changes of the manifestworks observed by the
+// informer. It does not have a queue, the events are recorded in the update handler of the informer. The message
+// of each event is at most maxMessageLength long.
+func NewAuditController(recorder kevents.EventRecorder, manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	maxMessageLength int) error {
+	if maxMessageLength <= 0 || maxMessageLength > maxEventNoteLength {
+		return fmt.Errorf("the max length of the audit message should be between 1 and %d, but got %d",
+			maxEventNoteLength, maxMessageLength)
+	}
+	c := &auditController{recorder: recorder, maxMessageLength: maxMessageLength}
+	_, err := manifestWorkInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldWork, ok := oldObj.(*workapiv1.ManifestWork)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", oldObj))
+				return
+			}
+			newWork, ok := newObj.(*workapiv1.ManifestWork)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", newObj))
+				return
+			}
+			c.audit(oldWork, newWork)
+		},
+	})
+	return err
+}
+
+func (c *auditController) audit(oldWork, newWork *workapiv1.ManifestWork) {
+	// the generation is only increased when the spec is changed
+	if oldWork.Generation == newWork.Generation {
+		return
+	}
+
+	diff := diffSpecs(&oldWork.Spec, &newWork.Spec)
+	if diff.empty() {
+		return
+	}
+
+	prefix := fmt.Sprintf("Generation %d to %d", oldWork.Generation, newWork.Generation)
+	related := manifestWorkReplicaSetRef(newWork)
+	if related != nil {
+		prefix += fmt.Sprintf(" by ManifestWorkReplicaSet %s/%s", related.Namespace, related.Name)
+	}
+	c.recorder.Eventf(newWork, related, corev1.EventTypeNormal, ReasonManifestWorkSpecChanged, "UpdateSpec",
+		"%s", diff.message(prefix+": ", c.maxMessageLength))
+}
+
+// manifestWorkReplicaSetRef returns the reference of the ManifestWorkReplicaSet owning the manifestwork, or nil
+// if the manifestwork is not created by a ManifestWorkReplicaSet.
+func manifestWorkReplicaSetRef(mw *workapiv1.ManifestWork) *corev1.ObjectReference {
+	key, ok := mw.Labels[manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey]
+	if !ok {
+		return nil
+	}
+	// the label is the namespace and the name joined by a dot, and the namespace does not have any dot
+	namespace, name, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: workapiv1alpha1.GroupVersion.String(),
+		Kind:       "ManifestWorkReplicaSet",
+		Namespace:  namespace,
+		Name:       name,
+	}
+}
@@ -0,0 +1,166 @@
+package auditcontroller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kevents "k8s.io/client-go/tools/events"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newConfigMap(name, value string) *unstructured.Unstructured {
+	return spoketesting.NewUnstructuredWithContent("v1", "ConfigMap", "ns1", name, map[string]interface{}{
+		"data": map[string]interface{}{"key": value},
+	})
+}
+
+func newSecret(value string) *unstructured.Unstructured {
+	return spoketesting.NewUnstructuredWithContent("v1", "Secret", "ns1", "secret1", map[string]interface{}{
+		"data": map[string]interface{}{"password": value},
+	})
+}
+
+func newWork(generation int64, objects ...*unstructured.Unstructured) *workapiv1.ManifestWork {
+	mw, _ := spoketesting.NewManifestWork(0, objects...)
+	mw.Generation = generation
+	return mw
+}
+
+func TestAudit(t *testing.T) {
+	cases := []struct {
+		name            string
+		oldWork         *workapiv1.ManifestWork
+		newWork         func() *workapiv1.ManifestWork
+		expectedEvents  []string
+		unexpectedTexts []string
+	}{
+		{
+			name:    "status update",
+			oldWork: newWork(1, newConfigMap("cm1", "v1")),
+			newWork: func() *workapiv1.ManifestWork { return newWork(1, newConfigMap("cm1", "v1")) },
+		},
+		{
+			name:    "add a manifest",
+			oldWork: newWork(1, newConfigMap("cm1", "v1")),
+			newWork: func() *workapiv1.ManifestWork {
+				return newWork(2, newConfigMap("cm1", "v1"), newConfigMap("cm2", "v1"))
+			},
+			expectedEvents: []string{
+				"Normal ManifestWorkSpecChanged Generation 1 to 2: added [1] v1 ConfigMap ns1/cm2",
+			},
+		},
+		{
+			name:    "remove a manifest",
+			oldWork: newWork(1, newConfigMap("cm1", "v1"), newConfigMap("cm2", "v1")),
+			newWork: func() *workapiv1.ManifestWork { return newWork(2, newConfigMap("cm2", "v1")) },
+			expectedEvents: []string{
+				"Normal ManifestWorkSpecChanged Generation 1 to 2: removed [0] v1 ConfigMap ns1/cm1",
+			},
+		},
+		{
+			name:    "change a manifest in place and the delete option",
+			oldWork: newWork(1, newConfigMap("cm1", "v1"), newConfigMap("cm2", "v1")),
+			newWork: func() *workapiv1.ManifestWork {
+				mw := newWork(2, newConfigMap("cm1", "v1"), newConfigMap("cm2", "v2"))
+				mw.Spec.DeleteOption = &workapiv1.DeleteOption{PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan}
+				return mw
+			},
+			expectedEvents: []string{
+				"Normal ManifestWorkSpecChanged Generation 1 to 2: changed [1] v1 ConfigMap ns1/cm2 (data.key); " +
+					"changed spec.deleteOption",
+			},
+			unexpectedTexts: []string{"v2"},
+		},
+		{
+			name:    "redact the secret",
+			oldWork: newWork(1, newSecret("b2xk")),
+			newWork: func() *workapiv1.ManifestWork { return newWork(2, newSecret("bmV3")) },
+			expectedEvents: []string{
+				"Normal ManifestWorkSpecChanged Generation 1 to 2: changed [0] v1 Secret ns1/secret1 (data(redacted))",
+			},
+			unexpectedTexts: []string{"password", "b2xk", "bmV3"},
+		},
+		{
+			name:    "attribute to the manifestworkreplicaset",
+			oldWork: newWork(1, newConfigMap("cm1", "v1")),
+			newWork: func() *workapiv1.ManifestWork {
+				mw := newWork(2, newConfigMap("cm1", "v2"))
+				mw.Labels = map[string]string{
+					manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey: "default.mwrset1",
+				}
+				return mw
+			},
+			expectedEvents: []string{
+				"Normal ManifestWorkSpecChanged Generation 1 to 2 by ManifestWorkReplicaSet default/mwrset1: " +
+					"changed [0] v1 ConfigMap ns1/cm1 (data.key)",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := kevents.NewFakeRecorder(10)
+			controller := &auditController{recorder: recorder, maxMessageLength: DefaultMaxMessageLength}
+			controller.audit(c.oldWork, c.newWork())
+
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			if len(events) != len(c.expectedEvents) {
+				t.Fatalf("expected events %v, but got %v", c.expectedEvents, events)
+			}
+			for i := range events {
+				if events[i] != c.expectedEvents[i] {
+					t.Errorf("expected event %q, but got %q", c.expectedEvents[i], events[i])
+				}
+				for _, text := range c.unexpectedTexts {
+					if strings.Contains(events[i], text) {
+						t.Errorf("expected %q not recorded, but got %q", text, events[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAuditMessageLength(t *testing.T) {
+	var objects []*unstructured.Unstructured
+	for _, name := range []string{"cm1", "cm2", "cm3", "cm4", "cm5"} {
+		objects = append(objects, newConfigMap(name, "v1"))
+	}
+	diff := diffSpecs(&newWork(1).Spec, &newWork(2, objects...).Spec)
+
+	// the changes exceeding the max length are counted
+	message := diff.message("prefix: ", 80)
+	if message != "prefix: added [0] v1 ConfigMap ns1/cm1; and 4 more changes" {
+		t.Errorf("expected the omitted changes counted, but got %q", message)
+	}
+}
+
+func TestNewAuditControllerMaxMessageLength(t *testing.T) {
+	cases := []struct {
+		maxMessageLength int
+		expectedErr      bool
+	}{
+		{maxMessageLength: DefaultMaxMessageLength},
+		{maxMessageLength: 1024},
+		{maxMessageLength: 0, expectedErr: true},
+		{maxMessageLength: 1025, expectedErr: true},
+	}
+	for _, c := range cases {
+		workInformerFactory := workinformers.NewSharedInformerFactory(fakeworkclient.NewSimpleClientset(), 10*time.Minute)
+		err := NewAuditController(kevents.NewFakeRecorder(10), workInformerFactory.Work().V1().ManifestWorks(), c.maxMessageLength)
+		if (err != nil) != c.expectedErr {
+			t.Errorf("max message length %d: expected error %v, but got %v", c.maxMessageLength, c.expectedErr, err)
+		}
+	}
+}
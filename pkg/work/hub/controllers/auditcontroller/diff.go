@@ -0,0 +1,197 @@
+package auditcontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// redactedFields are the fields of the Secret manifests whose nested keys are not recorded, only the change of
+// the field itself is.
+var redactedFields = sets.New[string]("data", "stringData")
+
+// manifestChange is a manifest added, removed or changed in the spec of a manifestwork.
+type manifestChange struct {
+	// index is the index of the manifest in the spec, the old spec for the removed manifests.
+	index    int
+	resource string
+	// fields are the changed fields of a changed manifest, the nested keys of the redacted fields are omitted.
+	fields []string
+}
+
+func (m manifestChange) String() string {
+	if len(m.fields) == 0 {
+		return fmt.Sprintf("[%d] %s", m.index, m.resource)
+	}
+	return fmt.Sprintf("[%d] %s (%s)", m.index, m.resource, strings.Join(m.fields, ", "))
+}
+
+// specDiff is the summary of the changes between two specs of a manifestwork. It only has the identities of the
+// manifests and the names of the changed fields, not the values.
+type specDiff struct {
+	added   []manifestChange
+	removed []manifestChange
+	changed []manifestChange
+	// specFields are the changed fields of the spec other than the manifests.
+	specFields []string
+}
+
+func (d specDiff) empty() bool {
+	return len(d.added) == 0 && len(d.removed) == 0 && len(d.changed) == 0 && len(d.specFields) == 0
+}
+
+// entries returns the changes in the message, in the order of added, removed and changed manifests, and the
+// changed spec fields.
+func (d specDiff) entries() []string {
+	var entries []string
+	for _, group := range []struct {
+		verb    string
+		changes []manifestChange
+	}{{"added", d.added}, {"removed", d.removed}, {"changed", d.changed}} {
+		for _, change := range group.changes {
+			entries = append(entries, fmt.Sprintf("%s %s", group.verb, change))
+		}
+	}
+	for _, field := range d.specFields {
+		entries = append(entries, fmt.Sprintf("changed spec.%s", field))
+	}
+	return entries
+}
+
+// message returns the summary with the prefix in at most maxLength bytes, the changes exceeding it are counted.
+func (d specDiff) message(prefix string, maxLength int) string {
+	entries := d.entries()
+	for kept := len(entries); kept >= 0; kept-- {
+		message := prefix + strings.Join(entries[:kept], "; ")
+		if omitted := len(entries) - kept; omitted > 0 {
+			if kept > 0 {
+				message += "; "
+			}
+			message += fmt.Sprintf("and %d more changes", omitted)
+		}
+		if len(message) <= maxLength {
+			return message
+		}
+	}
+	return truncate(prefix+fmt.Sprintf("%d changes", len(entries)), maxLength)
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	return s[:maxLength]
+}
+
+// diffSpecs compares the manifests of the specs by the group, kind, namespace and name, and the other fields of
+// the specs.
+func diffSpecs(oldSpec, newSpec *workapiv1.ManifestWorkSpec) specDiff {
+	oldManifests := parseManifests(oldSpec.Workload.Manifests)
+	newManifests := parseManifests(newSpec.Workload.Manifests)
+
+	diff := specDiff{}
+	for _, key := range sortedKeys(newManifests) {
+		newManifest := newManifests[key]
+		oldManifest, ok := oldManifests[key]
+		switch {
+		case !ok:
+			diff.added = append(diff.added, manifestChange{index: newManifest.index, resource: newManifest.resource})
+		case !equality.Semantic.DeepEqual(oldManifest.obj.Object, newManifest.obj.Object):
+			diff.changed = append(diff.changed, manifestChange{
+				index:    newManifest.index,
+				resource: newManifest.resource,
+				fields:   changedFields(oldManifest.obj, newManifest.obj),
+			})
+		}
+	}
+	for _, key := range sortedKeys(oldManifests) {
+		if _, ok := newManifests[key]; !ok {
+			oldManifest := oldManifests[key]
+			diff.removed = append(diff.removed, manifestChange{index: oldManifest.index, resource: oldManifest.resource})
+		}
+	}
+	for _, changes := range [][]manifestChange{diff.added, diff.removed, diff.changed} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].index < changes[j].index })
+	}
+
+	if !equality.Semantic.DeepEqual(oldSpec.DeleteOption, newSpec.DeleteOption) {
+		diff.specFields = append(diff.specFields, "deleteOption")
+	}
+	if !equality.Semantic.DeepEqual(oldSpec.ManifestConfigs, newSpec.ManifestConfigs) {
+		diff.specFields = append(diff.specFields, "manifestConfigs")
+	}
+	if !equality.Semantic.DeepEqual(oldSpec.Executor, newSpec.Executor) {
+		diff.specFields = append(diff.specFields, "executor")
+	}
+	return diff
+}
+
+type parsedManifest struct {
+	index    int
+	resource string
+	obj      *unstructured.Unstructured
+}
+
+// parseManifests returns the manifests keyed by the group, kind, namespace and name. The manifests which cannot
+// be decoded are keyed by the index.
+func parseManifests(manifests []workapiv1.Manifest) map[string]parsedManifest {
+	parsed := map[string]parsedManifest{}
+	for i, manifest := range manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			key := fmt.Sprintf("invalid/%d", i)
+			parsed[key] = parsedManifest{index: i, resource: "invalid manifest", obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{"raw": string(manifest.Raw)}}}
+			continue
+		}
+		gvk := obj.GroupVersionKind()
+		key := fmt.Sprintf("%s/%s/%s/%s", gvk.Group, gvk.Kind, obj.GetNamespace(), obj.GetName())
+		resource := fmt.Sprintf("%s %s %s", obj.GetAPIVersion(), obj.GetKind(), obj.GetName())
+		if obj.GetNamespace() != "" {
+			resource = fmt.Sprintf("%s %s %s/%s", obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		}
+		parsed[key] = parsedManifest{index: i, resource: resource, obj: obj}
+	}
+	return parsed
+}
+
+// changedFields returns the changed top level fields of the manifest, with the changed nested keys if the field
+// is an object. The nested keys of the redacted fields of the Secrets are omitted.
+func changedFields(oldObj, newObj *unstructured.Unstructured) []string {
+	redact := newObj.GroupVersionKind().Group == "" && newObj.GetKind() == "Secret"
+
+	var fields []string
+	for _, field := range sets.List(sets.KeySet(oldObj.Object).Union(sets.KeySet(newObj.Object))) {
+		oldValue, newValue := oldObj.Object[field], newObj.Object[field]
+		if equality.Semantic.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		if redact && redactedFields.Has(field) {
+			fields = append(fields, field+"(redacted)")
+			continue
+		}
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if !oldIsMap || !newIsMap {
+			fields = append(fields, field)
+			continue
+		}
+		for _, key := range sets.List(sets.KeySet(oldMap).Union(sets.KeySet(newMap))) {
+			if !equality.Semantic.DeepEqual(oldMap[key], newMap[key]) {
+				fields = append(fields, fmt.Sprintf("%s.%s", field, key))
+			}
+		}
+	}
+	return fields
+}
+
+func sortedKeys(manifests map[string]parsedManifest) []string {
+	return sets.List(sets.KeySet(manifests))
+}
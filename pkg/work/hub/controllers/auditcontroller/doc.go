@@ -0,0 +1,3 @@
+// package auditcontroller contains the hub-side controller recording the summaries of the spec changes of the
+// manifestworks as events for the audit.
+package auditcontroller
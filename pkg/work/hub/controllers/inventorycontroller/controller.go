@@ -0,0 +1,263 @@
+package inventorycontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	workinformerv1alpha1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1alpha1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	worklisterv1alpha1 "open-cluster-management.io/api/client/work/listers/work/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+const (
+	// InventorySnapshotAnnotationKey is the annotation on a ManifestWork or a ManifestWorkReplicaSet to request an
+	// inventory snapshot. Once it is set, the manifests of the ManifestWork, or of all the ManifestWorks of the
+	// ManifestWorkReplicaSet after the templating, are exported as a yaml into ConfigMaps in the same namespace,
+	// and the annotation is removed.
+	// TODO move this to the api repo
+	InventorySnapshotAnnotationKey = "work.open-cluster-management.io/inventory-snapshot"
+
+	// InventorySnapshotIncludeSecretsAnnotationKey is the annotation on a ManifestWork or a ManifestWorkReplicaSet
+	// to include the values of the secrets in the inventory snapshot when it is "true". The snapshot is exported
+	// into Secrets instead of ConfigMaps in this case. It is removed together with the InventorySnapshotAnnotationKey.
+	// TODO move this to the api repo
+	InventorySnapshotIncludeSecretsAnnotationKey = "work.open-cluster-management.io/inventory-snapshot-include-secrets"
+
+	// LastInventorySnapshotAnnotationKey is the annotation on a ManifestWork or a ManifestWorkReplicaSet set by the
+	// controller with the comma separated names of the ConfigMaps or Secrets of the last inventory snapshot.
+	// TODO move this to the api repo
+	LastInventorySnapshotAnnotationKey = "work.open-cluster-management.io/last-inventory-snapshot"
+
+	// InventorySnapshotLabelKey is the label on the ConfigMaps and Secrets of the inventory snapshots, the value is
+	// the kind of the snapshot source.
+	// TODO move this to the api repo
+	InventorySnapshotLabelKey = "work.open-cluster-management.io/inventory-snapshot"
+
+	// the annotations on the ConfigMaps and Secrets of the inventory snapshots
+	inventorySourceAnnotationKey    = "work.open-cluster-management.io/inventory-source"
+	inventoryTimestampAnnotationKey = "work.open-cluster-management.io/inventory-timestamp"
+	inventoryChunkAnnotationKey     = "work.open-cluster-management.io/inventory-chunk"
+
+	// InventoryDataKey is the key of the inventory yaml in the ConfigMaps and Secrets of the inventory snapshots.
+	InventoryDataKey = "inventory.yaml"
+
+	manifestWorkKind           = "ManifestWork"
+	manifestWorkReplicaSetKind = "ManifestWorkReplicaSet"
+)
+
+// InventoryChunkSize is the max size in bytes of the inventory in each ConfigMap or Secret of a snapshot. It is
+// exposed so that the tests can use a smaller chunk size.
+var InventoryChunkSize = 512 * 1024
+
+// inventoryController exports the inventory snapshots of the ManifestWorks and ManifestWorkReplicaSets requested
+// by the InventorySnapshotAnnotationKey. Each snapshot is named with the name of the source and the time of the
+// snapshot, and split into chunks of InventoryChunkSize. The snapshots are not owned by the source, so they are
+// kept for the audit after the source is deleted.
+type inventoryController struct {
+	workClient                   workclientset.Interface
+	kubeClient                   corev1client.CoreV1Interface
+	manifestWorkLister           worklisterv1.ManifestWorkLister
+	manifestWorkReplicaSetLister worklisterv1alpha1.ManifestWorkReplicaSetLister
+	clock                        clock.Clock
+}
+
+// NewInventoryController returns a controller exporting the inventory snapshots of the ManifestWorks and the
+// ManifestWorkReplicaSets.
+func NewInventoryController(
+	recorder events.Recorder,
+	workClient workclientset.Interface,
+	kubeClient corev1client.CoreV1Interface,
+	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer) factory.Controller {
+	c := &inventoryController{
+		workClient:                   workClient,
+		kubeClient:                   kubeClient,
+		manifestWorkLister:           manifestWorkInformer.Lister(),
+		manifestWorkReplicaSetLister: manifestWorkReplicaSetInformer.Lister(),
+		clock:                        clock.RealClock{},
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeyFunc(
+			queueKeyFunc(manifestWorkKind), snapshotRequested, manifestWorkInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			queueKeyFunc(manifestWorkReplicaSetKind), snapshotRequested, manifestWorkReplicaSetInformer.Informer()).
+		WithSync(c.sync).ToController("InventoryController", recorder)
+}
+
+// queueKeyFunc returns the queue key func of a kind, the key is the kind followed by the namespace and name.
+func queueKeyFunc(kind string) factory.ObjectQueueKeyFunc {
+	return func(obj runtime.Object) string {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return ""
+		}
+		return fmt.Sprintf("%s/%s", kind, key)
+	}
+}
+
+func snapshotRequested(obj interface{}) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	_, ok := accessor.GetAnnotations()[InventorySnapshotAnnotationKey]
+	return ok
+}
+
+func (c *inventoryController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	key := controllerContext.QueueKey()
+	klog.V(4).Infof("Reconciling inventory snapshot %q", key)
+
+	kind, namespacedName, _ := strings.Cut(key, "/")
+	namespace, name, err := cache.SplitMetaNamespaceKey(namespacedName)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	switch kind {
+	case manifestWorkKind:
+		mw, err := c.manifestWorkLister.ManifestWorks(namespace).Get(name)
+		switch {
+		case errors.IsNotFound(err):
+			return nil
+		case err != nil:
+			return err
+		}
+		if !snapshotRequested(mw) {
+			return nil
+		}
+
+		names, err := c.snapshot(ctx, kind, &mw.ObjectMeta, []*workapiv1.ManifestWork{mw})
+		if err != nil {
+			return err
+		}
+
+		newMW := mw.DeepCopy()
+		completeSnapshot(&newMW.ObjectMeta, names)
+		_, err = patcher.NewPatcher[
+			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+			c.workClient.WorkV1().ManifestWorks(namespace)).
+			PatchLabelAnnotations(ctx, newMW, newMW.ObjectMeta, mw.ObjectMeta)
+		return err
+	case manifestWorkReplicaSetKind:
+		mwrSet, err := c.manifestWorkReplicaSetLister.ManifestWorkReplicaSets(namespace).Get(name)
+		switch {
+		case errors.IsNotFound(err):
+			return nil
+		case err != nil:
+			return err
+		}
+		if !snapshotRequested(mwrSet) {
+			return nil
+		}
+
+		req, err := labels.NewRequirement(manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey,
+			selection.Equals, []string{fmt.Sprintf("%s.%s", mwrSet.Namespace, mwrSet.Name)})
+		if err != nil {
+			return err
+		}
+		manifestWorks, err := c.manifestWorkLister.List(labels.NewSelector().Add(*req))
+		if err != nil {
+			return err
+		}
+		sort.Slice(manifestWorks, func(i, j int) bool {
+			return manifestWorks[i].Namespace < manifestWorks[j].Namespace
+		})
+
+		names, err := c.snapshot(ctx, kind, &mwrSet.ObjectMeta, manifestWorks)
+		if err != nil {
+			return err
+		}
+
+		newMWRSet := mwrSet.DeepCopy()
+		completeSnapshot(&newMWRSet.ObjectMeta, names)
+		_, err = patcher.NewPatcher[
+			*workapiv1alpha1.ManifestWorkReplicaSet, workapiv1alpha1.ManifestWorkReplicaSetSpec, workapiv1alpha1.ManifestWorkReplicaSetStatus](
+			c.workClient.WorkV1alpha1().ManifestWorkReplicaSets(namespace)).
+			PatchLabelAnnotations(ctx, newMWRSet, newMWRSet.ObjectMeta, mwrSet.ObjectMeta)
+		return err
+	default:
+		utilruntime.HandleError(fmt.Errorf("unknown inventory snapshot key %q", key))
+		return nil
+	}
+}
+
+// snapshot exports the inventory of the manifestworks into the ConfigMaps or Secrets in the namespace of the
+// source, and returns their names. The chunks already written are skipped, so the snapshot is resumed once the
+// sync is retried within the same second.
+func (c *inventoryController) snapshot(ctx context.Context, kind string, source *metav1.ObjectMeta,
+	manifestWorks []*workapiv1.ManifestWork) ([]string, error) {
+	includeSecrets := source.Annotations[InventorySnapshotIncludeSecretsAnnotationKey] == "true"
+	inventory, err := renderInventory(manifestWorks, includeSecrets)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.clock.Now().UTC()
+	chunks := chunkInventory(inventory, InventoryChunkSize)
+	names := []string{}
+	for index, chunk := range chunks {
+		objectMeta := metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-inventory-%s-%d", source.Name, now.Format("20060102-150405"), index),
+			Namespace: source.Namespace,
+			Labels:    map[string]string{InventorySnapshotLabelKey: strings.ToLower(kind)},
+			Annotations: map[string]string{
+				inventorySourceAnnotationKey:    fmt.Sprintf("%s/%s/%s", kind, source.Namespace, source.Name),
+				inventoryTimestampAnnotationKey: now.Format(metav1.RFC3339Micro),
+				inventoryChunkAnnotationKey:     fmt.Sprintf("%d/%d", index+1, len(chunks)),
+			},
+		}
+
+		if includeSecrets {
+			_, err = c.kubeClient.Secrets(source.Namespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: objectMeta,
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{InventoryDataKey: []byte(chunk)},
+			}, metav1.CreateOptions{})
+		} else {
+			_, err = c.kubeClient.ConfigMaps(source.Namespace).Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: objectMeta,
+				Data:       map[string]string{InventoryDataKey: chunk},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		names = append(names, objectMeta.Name)
+	}
+	return names, nil
+}
+
+// completeSnapshot removes the snapshot request annotations and records the names of the snapshot.
+func completeSnapshot(objectMeta *metav1.ObjectMeta, names []string) {
+	delete(objectMeta.Annotations, InventorySnapshotAnnotationKey)
+	delete(objectMeta.Annotations, InventorySnapshotIncludeSecretsAnnotationKey)
+	objectMeta.Annotations[LastInventorySnapshotAnnotationKey] = strings.Join(names, ",")
+}
@@ -0,0 +1,261 @@
+package inventorycontroller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newSecretManifestWork() *workapiv1.ManifestWork {
+	secret := spoketesting.NewUnstructuredWithContent("v1", "Secret", "ns1", "secret1", map[string]interface{}{
+		"data":       map[string]interface{}{"password": "c2VjcmV0"},
+		"stringData": map[string]interface{}{"token": "plain-secret"},
+	})
+	secret.SetAnnotations(map[string]string{lastAppliedConfigAnnotation: `{"data":{"password":"c2VjcmV0"}}`})
+	mw, _ := spoketesting.NewManifestWork(0, secret,
+		spoketesting.NewUnstructuredWithContent("v1", "ConfigMap", "ns1", "cm1", map[string]interface{}{
+			"data": map[string]interface{}{"key": "value"},
+		}))
+	return mw
+}
+
+func TestRenderInventory(t *testing.T) {
+	mw := newSecretManifestWork()
+
+	redacted, err := renderInventory([]*workapiv1.ManifestWork{mw}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(redacted, "# cluster: cluster1, manifestwork: work-0\n---\n") {
+		t.Errorf("unexpected inventory header: %q", redacted)
+	}
+	if strings.Contains(redacted, "c2VjcmV0") || strings.Contains(redacted, "plain-secret") {
+		t.Errorf("expected the secret values redacted, but got %q", redacted)
+	}
+	if !strings.Contains(redacted, "password: REDACTED") || !strings.Contains(redacted, "token: REDACTED") {
+		t.Errorf("expected the secret keys kept, but got %q", redacted)
+	}
+	if strings.Contains(redacted, lastAppliedConfigAnnotation) {
+		t.Errorf("expected the last applied configuration removed, but got %q", redacted)
+	}
+	if !strings.Contains(redacted, "key: value") {
+		t.Errorf("expected the configmap not redacted, but got %q", redacted)
+	}
+
+	included, err := renderInventory([]*workapiv1.ManifestWork{mw}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(included, "password: c2VjcmV0") || !strings.Contains(included, "token: plain-secret") {
+		t.Errorf("expected the secret values included, but got %q", included)
+	}
+}
+
+func TestChunkInventory(t *testing.T) {
+	cases := []struct {
+		name      string
+		inventory string
+		size      int
+		expected  []string
+	}{
+		{
+			name:      "empty inventory",
+			inventory: "",
+			size:      10,
+			expected:  []string{""},
+		},
+		{
+			name:      "fit in one chunk",
+			inventory: "a: b\n",
+			size:      10,
+			expected:  []string{"a: b\n"},
+		},
+		{
+			name:      "split at line breaks",
+			inventory: "a: b\nc: d\ne: f\n",
+			size:      10,
+			expected:  []string{"a: b\nc: d\n", "e: f\n"},
+		},
+		{
+			name:      "split a long line at rune boundaries",
+			inventory: "a: ééééé\n",
+			size:      6,
+			expected:  []string{"a: é", "ééé", "é\n"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := chunkInventory(c.inventory, c.size)
+			if strings.Join(actual, "|") != strings.Join(c.expected, "|") {
+				t.Errorf("expected chunks %q, but got %q", c.expected, actual)
+			}
+			if strings.Join(actual, "") != c.inventory {
+				t.Errorf("expected the chunks joined to the inventory, but got %q", actual)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	chunkSize := InventoryChunkSize
+	defer func() { InventoryChunkSize = chunkSize }()
+	InventoryChunkSize = 128
+
+	mw := newSecretManifestWork()
+	mw.Annotations = map[string]string{InventorySnapshotAnnotationKey: "audit"}
+
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		InventorySnapshotAnnotationKey:               "audit",
+		InventorySnapshotIncludeSecretsAnnotationKey: "true",
+	}
+	mwrSetWorks := helpertest.CreateTestManifestWorks("mwrSet-test", "default", "cls2", "cls1")
+
+	notRequested, _ := spoketesting.NewManifestWork(1)
+
+	cases := []struct {
+		name            string
+		queueKey        string
+		validateActions func(t *testing.T, workActions, kubeActions []clienttesting.Action)
+	}{
+		{
+			name:     "manifestwork not found",
+			queueKey: "ManifestWork/cluster1/work-2",
+			validateActions: func(t *testing.T, workActions, kubeActions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, workActions)
+				testingcommon.AssertNoActions(t, kubeActions)
+			},
+		},
+		{
+			name:     "snapshot not requested",
+			queueKey: "ManifestWork/cluster1/work-1",
+			validateActions: func(t *testing.T, workActions, kubeActions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, workActions)
+				testingcommon.AssertNoActions(t, kubeActions)
+			},
+		},
+		{
+			name:     "snapshot a manifestwork into chunked configmaps",
+			queueKey: "ManifestWork/cluster1/work-0",
+			validateActions: func(t *testing.T, workActions, kubeActions []clienttesting.Action) {
+				if len(kubeActions) < 2 {
+					t.Fatalf("expected the inventory chunked, but got %d actions", len(kubeActions))
+				}
+				inventory := ""
+				names := []string{}
+				for _, action := range kubeActions {
+					if action.GetVerb() != "create" || action.GetResource().Resource != "configmaps" {
+						t.Fatalf("expected configmaps created, but got %s %s", action.GetVerb(), action.GetResource().Resource)
+					}
+					cm := action.(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+					if cm.Namespace != "cluster1" || cm.Labels[InventorySnapshotLabelKey] != "manifestwork" {
+						t.Errorf("unexpected configmap %s/%s with labels %v", cm.Namespace, cm.Name, cm.Labels)
+					}
+					if len(cm.Data[InventoryDataKey]) > InventoryChunkSize {
+						t.Errorf("expected the chunk size at most %d, but got %d", InventoryChunkSize, len(cm.Data[InventoryDataKey]))
+					}
+					inventory += cm.Data[InventoryDataKey]
+					names = append(names, cm.Name)
+				}
+				if names[0] != "work-0-inventory-20261016-183000-0" {
+					t.Errorf("unexpected snapshot name %q", names[0])
+				}
+				if strings.Contains(inventory, "c2VjcmV0") || !strings.Contains(inventory, "password: REDACTED") {
+					t.Errorf("expected the secret values redacted, but got %q", inventory)
+				}
+
+				testingcommon.AssertActions(t, workActions, "patch")
+				assertSnapshotCompleted(t, workActions[0], strings.Join(names, ","))
+			},
+		},
+		{
+			name:     "snapshot a manifestworkreplicaset into secrets",
+			queueKey: "ManifestWorkReplicaSet/default/mwrSet-test",
+			validateActions: func(t *testing.T, workActions, kubeActions []clienttesting.Action) {
+				inventory := ""
+				names := []string{}
+				for _, action := range kubeActions {
+					if action.GetVerb() != "create" || action.GetResource().Resource != "secrets" {
+						t.Fatalf("expected secrets created, but got %s %s", action.GetVerb(), action.GetResource().Resource)
+					}
+					secret := action.(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+					if secret.Namespace != "default" || secret.Labels[InventorySnapshotLabelKey] != "manifestworkreplicaset" {
+						t.Errorf("unexpected secret %s/%s with labels %v", secret.Namespace, secret.Name, secret.Labels)
+					}
+					inventory += string(secret.Data[InventoryDataKey])
+					names = append(names, secret.Name)
+				}
+				cls1 := strings.Index(inventory, "# cluster: cls1, manifestwork: mwrSet-test")
+				cls2 := strings.Index(inventory, "# cluster: cls2, manifestwork: mwrSet-test")
+				if cls1 < 0 || cls2 < cls1 {
+					t.Errorf("expected the manifestworks of the clusters in order, but got %q", inventory)
+				}
+
+				testingcommon.AssertActions(t, workActions, "patch")
+				assertSnapshotCompleted(t, workActions[0], strings.Join(names, ","))
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objects := append([]runtime.Object{mw, notRequested, mwrSet}, mwrSetWorks...)
+			fakeWorkClient := fakeworkclient.NewSimpleClientset(objects...)
+			workInformerFactory := workinformers.NewSharedInformerFactory(fakeWorkClient, 10*time.Minute)
+			for _, obj := range append([]runtime.Object{mw, notRequested}, mwrSetWorks...) {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+				t.Fatal(err)
+			}
+			fakeKubeClient := fakekube.NewSimpleClientset()
+
+			ctrl := &inventoryController{
+				workClient:                   fakeWorkClient,
+				kubeClient:                   fakeKubeClient.CoreV1(),
+				manifestWorkLister:           workInformerFactory.Work().V1().ManifestWorks().Lister(),
+				manifestWorkReplicaSetLister: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+				clock:                        testingclock.NewFakeClock(time.Date(2026, 10, 16, 18, 30, 0, 0, time.UTC)),
+			}
+			fakeWorkClient.ClearActions()
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, c.queueKey)); err != nil {
+				t.Fatal(err)
+			}
+			c.validateActions(t, fakeWorkClient.Actions(), fakeKubeClient.Actions())
+		})
+	}
+}
+
+func assertSnapshotCompleted(t *testing.T, action clienttesting.Action, expectedNames string) {
+	patch := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(action.(clienttesting.PatchActionImpl).Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	annotations, _ := patch["metadata"]["annotations"].(map[string]interface{})
+	if annotations[InventorySnapshotAnnotationKey] != nil {
+		t.Errorf("expected the snapshot annotation removed, but got %v", annotations)
+	}
+	if annotations[LastInventorySnapshotAnnotationKey] != expectedNames {
+		t.Errorf("expected the last snapshot %q, but got %v", expectedNames, annotations)
+	}
+}
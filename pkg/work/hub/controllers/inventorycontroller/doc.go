@@ -0,0 +1,3 @@
+// package inventorycontroller contains the hub-side controller exporting the inventory snapshots of the
+// manifestworks and the manifestworkreplicasets on request.
+package inventorycontroller
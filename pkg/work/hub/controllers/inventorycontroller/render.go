@@ -0,0 +1,96 @@
+package inventorycontroller
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// redactedValue replaces the values of the secrets in the inventory snapshots without the secrets included.
+	redactedValue = "REDACTED"
+
+	// lastAppliedConfigAnnotation may carry the whole secret, it is removed from the redacted secrets.
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// renderInventory renders the manifests of the manifestworks as a multi-document yaml. Each manifestwork starts
+// with a comment line of its cluster and name. The values of the secrets are redacted unless includeSecrets is
+// true.
+func renderInventory(manifestWorks []*workapiv1.ManifestWork, includeSecrets bool) (string, error) {
+	buf := &bytes.Buffer{}
+	for _, mw := range manifestWorks {
+		fmt.Fprintf(buf, "# cluster: %s, manifestwork: %s\n", mw.Namespace, mw.Name)
+		for index, manifest := range mw.Spec.Workload.Manifests {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+				return "", fmt.Errorf("failed to decode manifest %d of manifestwork %s/%s: %w",
+					index, mw.Namespace, mw.Name, err)
+			}
+			if !includeSecrets {
+				redactSecret(obj)
+			}
+			data, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString("---\n")
+			buf.Write(data)
+		}
+	}
+	return buf.String(), nil
+}
+
+// redactSecret replaces the values in the data and stringData of a secret, the keys are kept.
+func redactSecret(obj *unstructured.Unstructured) {
+	if obj.GetKind() != "Secret" || obj.GetAPIVersion() != "v1" {
+		return
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		values, found, err := unstructured.NestedMap(obj.Object, field)
+		if err != nil || !found {
+			continue
+		}
+		for key := range values {
+			values[key] = redactedValue
+		}
+		_ = unstructured.SetNestedMap(obj.Object, values, field)
+	}
+
+	if annotations := obj.GetAnnotations(); len(annotations[lastAppliedConfigAnnotation]) > 0 {
+		delete(annotations, lastAppliedConfigAnnotation)
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// chunkInventory splits the inventory into chunks of at most size bytes. A chunk ends at a line break if there
+// is one in the chunk, otherwise at a rune boundary, so each chunk is a valid utf-8 string.
+func chunkInventory(inventory string, size int) []string {
+	var chunks []string
+	for len(inventory) > size {
+		end := strings.LastIndexByte(inventory[:size], '\n') + 1
+		if end == 0 {
+			end = size
+			for end > 0 && !utf8.RuneStart(inventory[end]) {
+				end--
+			}
+			if end == 0 {
+				// the size is smaller than a rune
+				end = size
+			}
+		}
+		chunks = append(chunks, inventory[:end])
+		inventory = inventory[end:]
+	}
+	if len(inventory) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, inventory)
+	}
+	return chunks
+}
@@ -0,0 +1,121 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+const (
+	// CleanupPolicyAnnotationKey is the annotation on a ManifestWorkReplicaSet to decide how the manifestworks are
+	// cleaned up once the clusters drop out of the placement decisions, or the ManifestWorkReplicaSet is deleted.
+	// Without it, or with an unknown value, the manifestworks are deleted.
+	// TODO move this to the api repo
+	CleanupPolicyAnnotationKey = "work.open-cluster-management.io/cleanup-policy"
+
+	// CleanupPolicyDelete deletes the manifestworks, so the workloads are removed from the clusters.
+	CleanupPolicyDelete = "Delete"
+
+	// CleanupPolicyOrphan removes the ManifestWorkReplicaSetControllerNameLabelKey label from the manifestworks
+	// and leaves them in place, so the workloads keep running on the clusters. The orphaned manifestworks are
+	// no longer managed or counted in the summary of the ManifestWorkReplicaSet.
+	CleanupPolicyOrphan = "Orphan"
+
+	// OrphanResourcesOnDeletionAnnotationKey is the annotation on a ManifestWorkReplicaSet to orphan all the
+	// resources on the clusters when it is deleted with "true", regardless of the DeleteOption in the
+	// ManifestWorkTemplate. The DeleteOption of the manifestworks is patched to Orphan before they are deleted, so
+	// the manifestworks are cleaned up on the hub while the workloads keep running on the clusters.
+	// TODO move this to the api repo
+	OrphanResourcesOnDeletionAnnotationKey = "work.open-cluster-management.io/orphan-resources-on-deletion"
+)
+
+// isOrphanCleanup returns true if the manifestworks of the ManifestWorkReplicaSet are orphaned instead of deleted.
+func isOrphanCleanup(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	return mwrSet.Annotations[CleanupPolicyAnnotationKey] == CleanupPolicyOrphan
+}
+
+// isOrphanResourcesOnDeletion returns true if the resources of the ManifestWorkReplicaSet are orphaned on the
+// clusters when it is deleted.
+func isOrphanResourcesOnDeletion(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	return mwrSet.Annotations[OrphanResourcesOnDeletionAnnotationKey] == "true"
+}
+
+// orphanManifestWork removes the ManifestWorkReplicaSetControllerNameLabelKey label from the manifestwork, so it
+// is released from the ManifestWorkReplicaSet. The manifestwork is adopted again once it is applied by the
+// ManifestWorkReplicaSet, e.g. the cluster is selected again.
+func orphanManifestWork(ctx context.Context, workClient workclientset.Interface, mw *workapiv1.ManifestWork) error {
+	workPatcher := patcher.NewPatcher[
+		*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+		workClient.WorkV1().ManifestWorks(mw.Namespace))
+
+	newWork := mw.DeepCopy()
+	delete(newWork.Labels, ManifestWorkReplicaSetControllerNameLabelKey)
+	_, err := workPatcher.PatchLabelAnnotations(ctx, newWork, newWork.ObjectMeta, mw.ObjectMeta)
+	return err
+}
+
+// orphanManifestWorks orphans all the manifestworks of the deleted ManifestWorkReplicaSet with the Orphan cleanup
+// policy, the manifestworks being deleted are left as they are.
+func (f *finalizeReconciler) orphanManifestWorks(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	manifestWorks, err := listManifestWorksByManifestWorkReplicaSet(mwrSet, f.manifestWorkLister)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, mw := range manifestWorks {
+		if !mw.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := orphanManifestWork(ctx, f.workClient, mw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteManifestWorksOrphaningResources deletes all the manifestworks of the deleted ManifestWorkReplicaSet with
+// the Orphan delete option, so the resources are left on the clusters. The manifestworks being deleted are
+// patched as well, and the ManifestWorkReplicaSet is released once all the manifestworks are patched and deleted,
+// without waiting for the agents.
+func (f *finalizeReconciler) deleteManifestWorksOrphaningResources(ctx context.Context,
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	manifestWorks, err := listManifestWorksByManifestWorkReplicaSet(mwrSet, f.manifestWorkLister)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, mw := range manifestWorks {
+		if mw.Spec.DeleteOption == nil || mw.Spec.DeleteOption.PropagationPolicy != workapiv1.DeletePropagationPolicyTypeOrphan {
+			workPatcher := patcher.NewPatcher[
+				*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+				f.workClient.WorkV1().ManifestWorks(mw.Namespace))
+
+			newSpec := mw.Spec.DeepCopy()
+			newSpec.DeleteOption = &workapiv1.DeleteOption{PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan}
+			if _, err := workPatcher.PatchSpec(ctx, mw, *newSpec, mw.Spec); err != nil {
+				if !errors.IsNotFound(err) {
+					errs = append(errs, err)
+				}
+				// the manifestwork is not deleted until it is patched, otherwise the resources are deleted
+				continue
+			}
+		}
+
+		if !mw.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := f.workApplier.Delete(ctx, mw.Namespace, mw.Name); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,230 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestDeployReconcileCleanupPolicy(t *testing.T) {
+	cases := []struct {
+		name            string
+		policy          string
+		expectedActions []string
+		expectedOrphan  bool
+	}{
+		{
+			name:            "no cleanup policy",
+			expectedActions: []string{"delete"},
+		},
+		{
+			name:            "delete",
+			policy:          CleanupPolicyDelete,
+			expectedActions: []string{"delete"},
+		},
+		{
+			name:            "orphan",
+			policy:          CleanupPolicyOrphan,
+			expectedActions: []string{"patch"},
+			expectedOrphan:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			if len(c.policy) > 0 {
+				mwrSet.Annotations = map[string]string{CleanupPolicyAnnotationKey: c.policy}
+			}
+			objs := []runtime.Object{mwrSet}
+			for _, cls := range []string{"cls1", "cls2"} {
+				mw, _ := CreateManifestWork(mwrSet, cls)
+				objs = append(objs, mw)
+			}
+			fWorkClient := fakeworkclient.NewSimpleClientset(objs...)
+			workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+			for _, obj := range objs[1:] {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+			// cls2 drops out of the placement decision
+			placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1")
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+				fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+				t.Fatal(err)
+			}
+			if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+				t.Fatal(err)
+			}
+
+			reconciler := deployReconciler{
+				workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+				workClient:         fWorkClient,
+				manifestWorkLister: mwLister,
+				placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+					clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+					clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+			}
+			fWorkClient.ClearActions()
+
+			mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testingcommon.AssertActions(t, fWorkClient.Actions(), c.expectedActions...)
+			if mwrSet.Status.Summary.Total != 1 {
+				t.Errorf("expected 1 manifestwork in the summary, but got %d", mwrSet.Status.Summary.Total)
+			}
+
+			mw, err := fWorkClient.WorkV1().ManifestWorks("cls2").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+			switch {
+			case !c.expectedOrphan && !errors.IsNotFound(err):
+				t.Errorf("expected the manifestwork of cls2 deleted, but got %v", err)
+			case c.expectedOrphan && err != nil:
+				t.Fatal(err)
+			case c.expectedOrphan:
+				if _, ok := mw.Labels[ManifestWorkReplicaSetControllerNameLabelKey]; ok {
+					t.Errorf("expected the manifestwork of cls2 orphaned, but got labels %v", mw.Labels)
+				}
+			}
+		})
+	}
+}
+
+func TestFinalizeReconcileOrphan(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{CleanupPolicyAnnotationKey: CleanupPolicyOrphan}
+	mw, _ := CreateManifestWork(mwrSet, "cluster1")
+	fakeClient := fakeworkclient.NewSimpleClientset(mwrSet, mw)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeClient, 1*time.Minute)
+	if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+		t.Fatal(err)
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	// the policy is honoured with the tombstone cleanup as well
+	finalizerController := finalizeReconciler{
+		workClient:            fakeClient,
+		manifestWorkLister:    mwLister,
+		workApplier:           workapplier.NewWorkApplierWithTypedClient(fakeClient, mwLister),
+		cleanupWithTombstones: true,
+	}
+
+	now := metav1.Now()
+	mwrSet.DeletionTimestamp = &now
+	mwrSet.Finalizers = append(mwrSet.Finalizers, ManifestWorkReplicaSetFinalizer)
+	if _, _, err := finalizerController.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+
+	orphaned, err := fakeClient.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := orphaned.Labels[ManifestWorkReplicaSetControllerNameLabelKey]; ok {
+		t.Errorf("expected the manifestwork orphaned, but got labels %v", orphaned.Labels)
+	}
+
+	updated, err := fakeClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(updated.Finalizers, ManifestWorkReplicaSetFinalizer) {
+		t.Errorf("expected the finalizer removed, but got %v", updated.Finalizers)
+	}
+}
+
+func TestCreateManifestWorkDeleteOption(t *testing.T) {
+	deleteOptions := []*workapiv1.DeleteOption{
+		{PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan},
+		{
+			PropagationPolicy: workapiv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+			SelectivelyOrphan: &workapiv1.SelectivelyOrphan{
+				OrphaningRules: []workapiv1.OrphaningRule{{Resource: "configmaps", Namespace: "ns1", Name: "cm1"}},
+			},
+		},
+	}
+
+	for _, deleteOption := range deleteOptions {
+		mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+		mwrSet.Spec.ManifestWorkTemplate.DeleteOption = deleteOption
+		mw, err := CreateManifestWork(mwrSet, "cluster1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equality.Semantic.DeepEqual(mw.Spec.DeleteOption, deleteOption) {
+			t.Errorf("expected delete option %v, but got %v", deleteOption, mw.Spec.DeleteOption)
+		}
+	}
+}
+
+func TestFinalizeReconcileOrphanResources(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{OrphanResourcesOnDeletionAnnotationKey: "true"}
+	mwrSet.Spec.ManifestWorkTemplate.DeleteOption = &workapiv1.DeleteOption{
+		PropagationPolicy: workapiv1.DeletePropagationPolicyTypeForeground,
+	}
+	mw, _ := CreateManifestWork(mwrSet, "cluster1")
+	fakeClient := fakeworkclient.NewSimpleClientset(mwrSet, mw)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeClient, 1*time.Minute)
+	if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+		t.Fatal(err)
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	finalizerController := finalizeReconciler{
+		workClient:         fakeClient,
+		manifestWorkLister: mwLister,
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fakeClient, mwLister),
+	}
+
+	now := metav1.Now()
+	mwrSet.DeletionTimestamp = &now
+	mwrSet.Finalizers = append(mwrSet.Finalizers, ManifestWorkReplicaSetFinalizer)
+	if _, _, err := finalizerController.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+
+	// the manifestwork is patched with the Orphan delete option before it is deleted
+	actions := fakeClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch", "delete", "patch")
+	work := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, work); err != nil {
+		t.Fatal(err)
+	}
+	if work.Spec.DeleteOption == nil || work.Spec.DeleteOption.PropagationPolicy != workapiv1.DeletePropagationPolicyTypeOrphan {
+		t.Errorf("expected the Orphan delete option, but got %v", work.Spec.DeleteOption)
+	}
+	testingcommon.AssertDelete(t, actions[1], "manifestworks", "cluster1", mwrSet.Name)
+
+	updated, err := fakeClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(updated.Finalizers, ManifestWorkReplicaSetFinalizer) {
+		t.Errorf("expected the finalizer removed, but got %v", updated.Finalizers)
+	}
+}
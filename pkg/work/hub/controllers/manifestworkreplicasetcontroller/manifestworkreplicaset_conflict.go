@@ -0,0 +1,71 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kevents "k8s.io/client-go/tools/events"
+
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// ManifestWorkReplicaSetConditionManifestWorkApplyConflict is the condition type of a ManifestWorkReplicaSet
+	// whose manifestworks on some clusters have the same name as the manifestworks of another
+	// ManifestWorkReplicaSet. The manifestworks of the other ManifestWorkReplicaSet are not updated, and the
+	// condition is true and lists the conflicting clusters. It is removed once there is no conflict.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionManifestWorkApplyConflict = "ManifestWorkApplyConflict"
+
+	// ReasonManifestWorkOwnedByOthers is the reason of the ManifestWorkApplyConflict condition and the event when
+	// the manifestwork is owned by another ManifestWorkReplicaSet.
+	ReasonManifestWorkOwnedByOthers = "OwnedByOtherManifestWorkReplicaSet"
+)
+
+// findConflicts returns the clusters whose manifestworks with the name of the manifestWorkReplicaSet are owned by
+// another ManifestWorkReplicaSet, with the message of each cluster.
+func findConflicts(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, manifestWorkLister worklisterv1.ManifestWorkLister,
+	clusters sets.Set[string]) (map[string]string, error) {
+	conflicts := map[string]string{}
+	key := manifestWorkReplicaSetKey(mwrSet)
+	for cls := range clusters {
+		mw, err := manifestWorkLister.ManifestWorks(cls).Get(mwrSet.Name)
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		if owner, ok := mw.Labels[ManifestWorkReplicaSetControllerNameLabelKey]; ok && owner != key {
+			conflicts[cls] = fmt.Sprintf("the manifestwork is owned by ManifestWorkReplicaSet %s", owner)
+		}
+	}
+	return conflicts, nil
+}
+
+// updateManifestWorkApplyConflict sets the ManifestWorkApplyConflict condition of the manifestWorkReplicaSet
+// with the conflicting clusters, and records an event for each conflicting cluster once the conflicts change.
+func updateManifestWorkApplyConflict(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, conflicts map[string]string,
+	recorder kevents.EventRecorder) {
+	if len(conflicts) == 0 {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionManifestWorkApplyConflict)
+		return
+	}
+
+	message := clusterMessages(conflicts)
+	existing := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionManifestWorkApplyConflict)
+	if recorder != nil && (existing == nil || existing.Message != message) {
+		for _, cls := range sets.List(sets.KeySet(conflicts)) {
+			recorder.Eventf(mwrSet, nil, corev1.EventTypeWarning, ReasonManifestWorkOwnedByOthers,
+				"ApplyManifestWork", "The manifestwork on cluster %s is not applied: %s", cls, conflicts[cls])
+		}
+	}
+	apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+		ManifestWorkReplicaSetConditionManifestWorkApplyConflict, ReasonManifestWorkOwnedByOthers, message, metav1.ConditionTrue))
+}
@@ -0,0 +1,105 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	kevents "k8s.io/client-go/tools/events"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestDeployReconcileConflict(t *testing.T) {
+	// the manifestworkreplicasets with the same name in the default and the other namespace both select cls1
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	conflicting := helpertest.CreateTestManifestWorks("mwrSet-test", "other", "cls1")[0]
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet, conflicting)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	if err := mwStore.Add(conflicting); err != nil {
+		t.Fatal(err)
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakekube.NewSimpleClientset(), 1*time.Minute)
+
+	recorder := kevents.NewFakeRecorder(10)
+	reconciler := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+		templateValuesLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+		recorder:             recorder,
+	}
+
+	reconcile := func(name string, expectedActions ...string) {
+		fWorkClient.ClearActions()
+		var err error
+		if mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		testingcommon.AssertActions(t, fWorkClient.Actions(), expectedActions...)
+	}
+
+	// only the manifestwork of cls2 is created, the manifestwork of cls1 owned by the other one is not updated
+	reconcile("first reconcile", "create")
+	created, err := fWorkClient.WorkV1().ManifestWorks("cls2").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mwStore.Add(created); err != nil {
+		t.Fatal(err)
+	}
+	cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionManifestWorkApplyConflict)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != ReasonManifestWorkOwnedByOthers ||
+		cond.Message != "cls1: the manifestwork is owned by ManifestWorkReplicaSet other.mwrSet-test" {
+		t.Fatalf("expected the conflict on cls1, but got %v", cond)
+	}
+	if mwrSet.Status.Summary.Total != 1 {
+		t.Errorf("expected the conflicting cluster not counted, but got %v", mwrSet.Status.Summary)
+	}
+	assertEvents(t, recorder,
+		"Warning OwnedByOtherManifestWorkReplicaSet The manifestwork on cluster cls1 is not applied: "+
+			"the manifestwork is owned by ManifestWorkReplicaSet other.mwrSet-test")
+
+	// the event is not recorded again while the conflicts are not changed
+	reconcile("conflict not changed")
+	assertEvents(t, recorder)
+
+	// the conflict is removed once the manifestwork of the other one is deleted
+	if err := mwStore.Delete(conflicting); err != nil {
+		t.Fatal(err)
+	}
+	if err := fWorkClient.WorkV1().ManifestWorks("cls1").Delete(context.TODO(), mwrSet.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reconcile("conflict removed", "create")
+	if apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionManifestWorkApplyConflict) != nil {
+		t.Errorf("expected the conflict condition removed, but got %v", mwrSet.Status.Conditions)
+	}
+	assertEvents(t, recorder)
+}
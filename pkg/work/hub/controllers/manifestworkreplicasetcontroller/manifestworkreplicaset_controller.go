@@ -2,21 +2,33 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterinformerv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
@@ -28,13 +40,15 @@ import (
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/metrics"
 )
 
 const (
 	// ManifestWorkReplicaSetControllerNameLabelKey is the label key on manifestwork to ref to the ManifestWorkReplicaSet
 	// that owns this manifestwork
-	// TODO move this to the api repo
-	ManifestWorkReplicaSetControllerNameLabelKey = "work.open-cluster-management.io/manifestworkreplicaset"
+	ManifestWorkReplicaSetControllerNameLabelKey = helper.ManifestWorkReplicaSetControllerNameLabelKey
 
 	// ManifestWorkReplicaSetFinalizer is the name of the finalizer added to ManifestWorkReplicaSet. It is used to ensure
 	// related manifestworks is deleted
@@ -45,6 +59,11 @@ type ManifestWorkReplicaSetController struct {
 	workClient                    workclientset.Interface
 	manifestWorkReplicaSetLister  worklisterv1alpha1.ManifestWorkReplicaSetLister
 	manifestWorkReplicaSetIndexer cache.Indexer
+	driftRepair                   *driftRepairer
+	decisionBackoff               *decisionBackoff
+	// manifestWorkLister and placementDecisionTracker find the manifestWorkReplicaSets related to a cluster.
+	manifestWorkLister       worklisterv1.ManifestWorkLister
+	placementDecisionTracker *placementhelpers.PlacementDecisionTracker
 
 	reconcilers []ManifestWorkReplicaSetReconcile
 }
@@ -64,24 +83,56 @@ const (
 
 func NewManifestWorkReplicaSetController(
 	recorder events.Recorder,
+	krecorder kevents.EventRecorder,
 	workClient workclientset.Interface,
+	kubeClient corev1client.ConfigMapsGetter,
+	sarClient authorizationv1client.SubjectAccessReviewsGetter,
 	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
 	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	templateValuesInformer corev1informers.ConfigMapInformer,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
-	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer) factory.Controller {
+	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	driftRepairInterval time.Duration,
+	cleanupWithTombstones bool,
+	unavailableClusterTimeout time.Duration,
+	forceDeleteStuckWorks bool,
+	rolloutStallThreshold time.Duration,
+	maxNotAvailableClusters int,
+	feedbackSummary bool,
+	maxFeedbackSummaryClusters int,
+	resyncInterval time.Duration,
+	workApplyQPS float32,
+	workApplyBurst int) factory.Controller {
 
+	metrics.Register()
 	controller := newController(
-		workClient, manifestWorkReplicaSetInformer, manifestWorkInformer, placementInformer, placeDecisionInformer)
+		workClient, kubeClient, sarClient, krecorder, manifestWorkReplicaSetInformer, manifestWorkInformer, configMapInformer,
+		templateValuesInformer, placementInformer, placeDecisionInformer, clusterInformer, driftRepairInterval,
+		cleanupWithTombstones, unavailableClusterTimeout, forceDeleteStuckWorks, rolloutStallThreshold, maxNotAvailableClusters,
+		feedbackSummary, maxFeedbackSummaryClusters, newWorkApplyLimiter(workApplyQPS, workApplyBurst))
 
 	err := manifestWorkReplicaSetInformer.Informer().AddIndexers(
 		cache.Indexers{
-			manifestWorkReplicaSetByPlacement: indexManifestWorkReplicaSetByPlacement,
+			manifestWorkReplicaSetByPlacement:      indexManifestWorkReplicaSetByPlacement,
+			manifestWorkReplicaSetByTemplateValues: indexManifestWorkReplicaSetByTemplateValues,
+			manifestWorkReplicaSetByMaintenance:    indexManifestWorkReplicaSetByMaintenance,
 		})
 	if err != nil {
 		utilruntime.HandleError(err)
 	}
 
+	// the clusters are watched for entering or exiting maintenance only, the other changes of the clusters, e.g.
+	// the status updates, do not affect the manifestworks.
+	syncCtx := factory.NewSyncContext("ManifestWorkReplicaSetController", recorder)
+	if _, err := clusterInformer.Informer().AddEventHandler(
+		controller.clusterEventHandler(syncCtx.Queue())); err != nil {
+		utilruntime.HandleError(err)
+	}
+
 	return factory.New().
+		WithSyncContext(syncCtx).
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
 			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 			if err != nil {
@@ -96,11 +147,12 @@ func NewManifestWorkReplicaSetController(
 			if !ok {
 				return ""
 			}
-			keys := strings.Split(labelValue, ".")
-			if len(keys) != 2 {
+			// the label is the namespace and the name joined by a dot, and the namespace does not have any dot
+			namespace, name, ok := strings.Cut(labelValue, ".")
+			if !ok {
 				return ""
 			}
-			return fmt.Sprintf("%s/%s", keys[0], keys[1])
+			return fmt.Sprintf("%s/%s", namespace, name)
 		}, func(obj interface{}) bool {
 			accessor, err := meta.Accessor(obj)
 			if err != nil {
@@ -110,38 +162,98 @@ func NewManifestWorkReplicaSetController(
 				return true
 			}
 			return false
-		}, manifestWorkInformer.Informer()).
+		}, manifestWorkInformer.Informer(), configMapInformer.Informer()).
 		WithInformersQueueKeysFunc(controller.placementDecisionQueueKeysFunc, placeDecisionInformer.Informer()).
 		WithInformersQueueKeysFunc(controller.placementQueueKeysFunc, placementInformer.Informer()).
+		WithInformersQueueKeysFunc(controller.templateValuesQueueKeysFunc, templateValuesInformer.Informer()).
+		WithBareInformers(clusterInformer.Informer()).
+		// resync to enqueue all the manifestWorkReplicaSets. It is disabled if the interval is 0, the drift repair
+		// requeues each manifestWorkReplicaSet on its own interval.
+		ResyncEvery(resyncInterval).
 		WithSync(controller.sync).ToController("ManifestWorkReplicaSetController", recorder)
 }
 
 func newController(workClient workclientset.Interface,
+	kubeClient corev1client.ConfigMapsGetter,
+	sarClient authorizationv1client.SubjectAccessReviewsGetter,
+	krecorder kevents.EventRecorder,
 	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
 	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	templateValuesInformer corev1informers.ConfigMapInformer,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
-	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer) *ManifestWorkReplicaSetController {
+	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	driftRepairInterval time.Duration,
+	cleanupWithTombstones bool,
+	unavailableClusterTimeout time.Duration,
+	forceDeleteStuckWorks bool,
+	rolloutStallThreshold time.Duration,
+	maxNotAvailableClusters int,
+	feedbackSummary bool,
+	maxFeedbackSummaryClusters int,
+	workApplyLimiter flowcontrol.RateLimiter) *ManifestWorkReplicaSetController {
+	driftRepair := newDriftRepairer(driftRepairInterval, workClient, manifestWorkInformer.Lister(), krecorder)
+	decisionBackoff := newDecisionBackoff()
 	return &ManifestWorkReplicaSetController{
 		workClient:                    workClient,
 		manifestWorkReplicaSetLister:  manifestWorkReplicaSetInformer.Lister(),
 		manifestWorkReplicaSetIndexer: manifestWorkReplicaSetInformer.Informer().GetIndexer(),
+		driftRepair:                   driftRepair,
+		decisionBackoff:               decisionBackoff,
+		manifestWorkLister:            manifestWorkInformer.Lister(),
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			placementInformer.Lister(), placeDecisionInformer.Lister()),
 
 		reconcilers: []ManifestWorkReplicaSetReconcile{
 			&finalizeReconciler{workApplier: workapplier.NewWorkApplierWithTypedClient(workClient, manifestWorkInformer.Lister()),
-				workClient: workClient, manifestWorkLister: manifestWorkInformer.Lister()},
+				workClient: workClient, kubeClient: kubeClient, manifestWorkLister: manifestWorkInformer.Lister(),
+				clusterLister: clusterInformer.Lister(), recorder: krecorder,
+				cleanupWithTombstones:     cleanupWithTombstones,
+				unavailableClusterTimeout: unavailableClusterTimeout,
+				forceDeleteStuckWorks:     forceDeleteStuckWorks},
 			&addFinalizerReconciler{workClient: workClient},
 			&deployReconciler{workApplier: workapplier.NewWorkApplierWithTypedClient(workClient, manifestWorkInformer.Lister()),
-				manifestWorkLister: manifestWorkInformer.Lister(), placementLister: placementInformer.Lister(), placeDecisionLister: placeDecisionInformer.Lister()},
-			&statusReconciler{manifestWorkLister: manifestWorkInformer.Lister()},
+				workClient:         workClient,
+				manifestWorkLister: manifestWorkInformer.Lister(),
+				placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+					placementInformer.Lister(), placeDecisionInformer.Lister()),
+				templateValuesLister: templateValuesInformer.Lister(),
+				clusterLister:        clusterInformer.Lister(),
+				driftRepair:          driftRepair,
+				clock:                clock.RealClock{},
+				executorVerifier:     newExecutorVerifier(sarClient),
+				recorder:             krecorder,
+				decisionBackoff:      decisionBackoff,
+				workApplyLimiter:     workApplyLimiter},
+			&statusReconciler{manifestWorkLister: manifestWorkInformer.Lister(), clock: clock.RealClock{},
+				stallThreshold: rolloutStallThreshold, maxNotAvailableClusters: maxNotAvailableClusters,
+				feedbackSummary: feedbackSummary, maxFeedbackSummaryClusters: maxFeedbackSummaryClusters},
+			&statusDetailReconciler{kubeClient: kubeClient, configMapLister: configMapInformer.Lister(),
+				manifestWorkLister: manifestWorkInformer.Lister()},
+			newPlacementEventReconciler(placementInformer.Lister(), krecorder),
 		},
 	}
 }
 
-// sync is the main reconcile loop for placeManifest work. It is triggered every 15sec
-func (m *ManifestWorkReplicaSetController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+// sync is the main reconcile loop for placeManifest work. It is triggered by the events of the watched resources
+// and the resync.
+func (m *ManifestWorkReplicaSetController) sync(ctx context.Context, controllerContext factory.SyncContext) (err error) {
 	key := controllerContext.QueueKey()
 	klog.V(4).Infof("Reconciling ManifestWorkReplicaSet %q", key)
 
+	if key == factory.DefaultQueueKey {
+		// handle the resync
+		mwrSets, err := m.manifestWorkReplicaSetLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, mwrSet := range mwrSets {
+			controllerContext.Queue().Add(fmt.Sprintf("%s/%s", mwrSet.Namespace, mwrSet.Name))
+		}
+		return nil
+	}
+
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		// ignore placement whose key is not in format: namespace/name
@@ -149,9 +261,21 @@ func (m *ManifestWorkReplicaSetController) sync(ctx context.Context, controllerC
 		return nil
 	}
 
+	start := time.Now()
+	defer func() {
+		result := metrics.SyncResultSuccess
+		if err != nil {
+			result = metrics.SyncResultError
+		}
+		metrics.ManifestWorkReplicaSetSyncDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
+
 	oldManifestWorkReplicaSet, err := m.manifestWorkReplicaSetLister.ManifestWorkReplicaSets(namespace).Get(name)
 	switch {
 	case errors.IsNotFound(err):
+		m.driftRepair.forget(fmt.Sprintf("%s.%s", namespace, name))
+		m.decisionBackoff.forget(fmt.Sprintf("%s.%s", namespace, name))
+		metrics.DeleteManifestWorkReplicaSetMetrics(namespace, name)
 		return nil
 	case err != nil:
 		return err
@@ -162,8 +286,12 @@ func (m *ManifestWorkReplicaSetController) sync(ctx context.Context, controllerC
 	var state reconcileState
 	var errs []error
 	for _, reconciler := range m.reconcilers {
+		var rqe *requeueError
 		manifestWorkReplicaSet, state, err = reconciler.reconcile(ctx, manifestWorkReplicaSet)
-		if err != nil {
+		if goerrors.As(err, &rqe) {
+			klog.V(4).Infof("Requeue ManifestWorkReplicaSet %q: %v", key, rqe)
+			controllerContext.Queue().AddAfter(key, rqe.requeueAfter)
+		} else if err != nil {
 			errs = append(errs, err)
 		}
 		if state == reconcileStop {
@@ -180,9 +308,58 @@ func (m *ManifestWorkReplicaSetController) sync(ctx context.Context, controllerC
 		errs = append(errs, err)
 	}
 
+	// Patch the annotations maintained by the controller
+	if err := m.patchAnnotations(ctx, manifestWorkReplicaSet, oldManifestWorkReplicaSet); err != nil {
+		errs = append(errs, err)
+	}
+
 	return utilerrors.NewAggregate(errs)
 }
 
+// patchAnnotations patches the last applied time, the rollout progress, the feedback summary and the not available
+// clusters annotations set by the statusReconciler and the status detail annotation set by the
+// statusDetailReconciler. The status is patched with the resourceVersion of the manifestWorkReplicaSet just now, so
+// the annotations are patched without the resourceVersion. It is safe since only the controller maintains these annotations.
+func (m *ManifestWorkReplicaSetController) patchAnnotations(ctx context.Context,
+	mwrSet, oldMWRSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	annotations := map[string]interface{}{}
+	if lastAppliedTime, ok := mwrSet.Annotations[helper.LastAppliedTimeAnnotation]; ok &&
+		lastAppliedTime != oldMWRSet.Annotations[helper.LastAppliedTimeAnnotation] {
+		annotations[helper.LastAppliedTimeAnnotation] = lastAppliedTime
+	}
+
+	for _, key := range []string{StatusDetailConfigMapsAnnotationKey, RolloutProgressAnnotationKey, FeedbackSummaryAnnotationKey,
+		NotAvailableClustersAnnotationKey} {
+		value, ok := mwrSet.Annotations[key]
+		oldValue, oldOk := oldMWRSet.Annotations[key]
+		switch {
+		case ok && (!oldOk || value != oldValue):
+			annotations[key] = value
+		case !ok && oldOk:
+			// remove the annotation if the status detail mode is disabled, the rollout is completed, no
+			// feedback value is left or all the manifestworks are available
+			annotations[key] = nil
+		}
+	}
+
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = m.workClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Patch(
+		ctx, mwrSet.Name, types.MergePatchType, patchData, metav1.PatchOptions{})
+	return err
+}
+
 func listManifestWorksByManifestWorkReplicaSet(mwrs *workapiv1alpha1.ManifestWorkReplicaSet,
 	manifestWorkLister worklisterv1.ManifestWorkLister) ([]*workapiv1.ManifestWork, error) {
 	req, err := labels.NewRequirement(ManifestWorkReplicaSetControllerNameLabelKey, selection.Equals, []string{manifestWorkReplicaSetKey(mwrs)})
@@ -9,14 +9,19 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	kevents "k8s.io/client-go/tools/events"
 
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
@@ -107,7 +112,8 @@ func TestManifestWorkReplicaSetControllerPatchStatus(t *testing.T) {
 				if err := json.Unmarshal(p, workSet); err != nil {
 					t.Fatal(err)
 				}
-				if !meta.IsStatusConditionFalse(workSet.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionManifestworkApplied) {
+				cond := meta.FindStatusCondition(workSet.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionPlacementVerified)
+				if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonAwaitingDecision {
 					t.Fatal(spew.Sdump(workSet.Status.Conditions))
 				}
 			},
@@ -143,6 +149,32 @@ func TestManifestWorkReplicaSetControllerPatchStatus(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "remove the status detail annotation when the status detail is disabled",
+			mwrSet: func() *workapiv1alpha1.ManifestWorkReplicaSet {
+				w := helpertest.CreateTestManifestWorkReplicaSet("test", "default", "placement")
+				w.Finalizers = []string{ManifestWorkReplicaSetFinalizer}
+				w.Annotations = map[string]string{StatusDetailConfigMapsAnnotationKey: "test-status-0"}
+				return w
+			}(),
+			works: helpertest.CreateTestManifestWorks("test", "default", "cluster1", "cluster2"),
+			placement: func() *clusterv1beta1.Placement {
+				p, _ := helpertest.CreateTestPlacement("placement", "default", "cluster1", "cluster2")
+				return p
+			}(),
+			decision: func() *clusterv1beta1.PlacementDecision {
+				_, d := helpertest.CreateTestPlacement("placement", "default", "cluster1", "cluster2")
+				return d
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch", "patch")
+				p := actions[1].(clienttesting.PatchActionImpl).Patch
+				expected := `{"metadata":{"annotations":{"work.open-cluster-management.io/status-detail-configmaps":null}}}`
+				if string(p) != expected {
+					t.Errorf("expected patch %s, but got %s", expected, string(p))
+				}
+			},
+		},
 		{
 			name: "no additonal apply needed",
 			mwrSet: func() *workapiv1alpha1.ManifestWorkReplicaSet {
@@ -209,6 +241,12 @@ func TestManifestWorkReplicaSetControllerPatchStatus(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
+			// the works are applied from the template already
+			for _, o := range c.works {
+				if err := setTemplateHash(o.(*workapiv1.ManifestWork)); err != nil {
+					t.Fatal(err)
+				}
+			}
 			workObjects := []runtime.Object{c.mwrSet}
 			workObjects = append(workObjects, c.works...)
 			fakeClient := fakeworkclient.NewSimpleClientset(workObjects...)
@@ -223,12 +261,30 @@ func TestManifestWorkReplicaSetControllerPatchStatus(t *testing.T) {
 			clusterInformers.Cluster().V1beta1().Placements().Informer().GetStore().Add(c.placement)
 			clusterInformers.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(c.decision)
 
+			fakeKubeClient := fakekube.NewSimpleClientset()
+			kubeInformers := kubeinformers.NewSharedInformerFactory(fakeKubeClient, 10*time.Minute)
+
 			ctrl := newController(
 				fakeClient,
+				fakeKubeClient.CoreV1(),
+				fakeKubeClient.AuthorizationV1(),
+				kevents.NewFakeRecorder(100),
 				workInformers.Work().V1alpha1().ManifestWorkReplicaSets(),
 				workInformers.Work().V1().ManifestWorks(),
+				kubeInformers.Core().V1().ConfigMaps(),
+				kubeInformers.Core().V1().ConfigMaps(),
 				clusterInformers.Cluster().V1beta1().Placements(),
 				clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+				clusterInformers.Cluster().V1().ManagedClusters(),
+				0,
+				false,
+				0,
+				false,
+				0,
+				0,
+				false,
+				0,
+				nil,
 			)
 
 			controllerContext := testingcommon.NewFakeSyncContext(t, c.mwrSet.Namespace+"/"+c.mwrSet.Name)
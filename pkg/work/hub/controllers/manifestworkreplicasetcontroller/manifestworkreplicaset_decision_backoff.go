@@ -0,0 +1,47 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// ReasonAwaitingDecision is the reason of the PlacementDecisionVerified condition when a placement exists but
+	// its PlacementDecisions are not created by the placement controller yet.
+	// TODO move this to the api repo
+	ReasonAwaitingDecision = "AwaitingDecision"
+
+	awaitingDecisionBaseDelay = 5 * time.Second
+	awaitingDecisionMaxDelay  = 5 * time.Minute
+)
+
+// decisionBackoff delays the requeue of the manifestWorkReplicaSets awaiting the PlacementDecisions exponentially,
+// capped at awaitingDecisionMaxDelay. The manifestWorkReplicaSets are still reconciled immediately once the
+// PlacementDecisions are created, since they are enqueued by the PlacementDecision informer.
+type decisionBackoff struct {
+	rateLimiter workqueue.RateLimiter
+}
+
+func newDecisionBackoff() *decisionBackoff {
+	return &decisionBackoff{
+		rateLimiter: workqueue.NewItemExponentialFailureRateLimiter(awaitingDecisionBaseDelay, awaitingDecisionMaxDelay),
+	}
+}
+
+// when returns the delay of the next requeue of the manifestWorkReplicaSet, it is doubled on each call until
+// the manifestWorkReplicaSet is forgotten.
+func (b *decisionBackoff) when(key string) time.Duration {
+	if b == nil {
+		return awaitingDecisionBaseDelay
+	}
+	return b.rateLimiter.When(key)
+}
+
+// forget resets the delay of the manifestWorkReplicaSet.
+func (b *decisionBackoff) forget(key string) {
+	if b == nil {
+		return
+	}
+	b.rateLimiter.Forget(key)
+}
@@ -2,47 +2,120 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
 
-	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
-	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	"open-cluster-management.io/api/utils/work/v1/workapplier"
 	workv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
 	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/metrics"
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
 // deployReconciler is to manage ManifestWork based on the placement.
 type deployReconciler struct {
-	workApplier         *workapplier.WorkApplier
-	manifestWorkLister  worklisterv1.ManifestWorkLister
-	placeDecisionLister clusterlister.PlacementDecisionLister
-	placementLister     clusterlister.PlacementLister
+	workApplier              *workapplier.WorkApplier
+	manifestWorkLister       worklisterv1.ManifestWorkLister
+	placementDecisionTracker *placementhelpers.PlacementDecisionTracker
+	// workClient orphans the manifestworks of the deleted clusters with the Orphan cleanup policy.
+	workClient workclientset.Interface
+	// templateValuesLister lists the template values ConfigMaps in the cluster namespaces.
+	templateValuesLister corev1lister.ConfigMapLister
+	// clusterLister gets the clusters to apply the maintenance policy and to skip the observe-only clusters.
+	clusterLister clusterlisterv1.ManagedClusterLister
+
+	// driftRepair verifies the manifestworks periodically regardless of the cache of the workApplier. It is
+	// disabled if nil.
+	driftRepair *driftRepairer
+	// clock is used to check the progress deadline of the progressive rollout.
+	clock clock.Clock
+	// executorVerifier checks the permission on the executor of each cluster before the manifestwork is
+	// created or updated.
+	executorVerifier *executorVerifier
+	// recorder records an event for each cluster whose manifestwork is owned by another manifestWorkReplicaSet.
+	recorder kevents.EventRecorder
+	// decisionBackoff delays the requeue of the manifestWorkReplicaSet while the PlacementDecisions are not
+	// created yet.
+	decisionBackoff *decisionBackoff
+	// workApplyLimiter limits the rate of the manifestwork create and update calls, so a template change does not
+	// update the manifestworks of all the clusters at once. It is disabled if nil.
+	workApplyLimiter flowcontrol.RateLimiter
 }
 
 func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
 ) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	// the manifestworks are frozen while the manifestWorkReplicaSet is paused, the status is still updated by
+	// the following reconcilers.
+	if updatePaused(mwrSet) {
+		return mwrSet, reconcileContinue, nil
+	}
+
 	// Manifestwork create/update/delete logic.
-	var placements []*clusterv1beta1.Placement
+	// Compare the normalized clusters of all the placements with the existing clusters, so the decisions
+	// rewritten in a different order or chunking do not change the rollout.
+	// The clusters selected by more than one placement have only one manifestwork.
+	decisionClusters := sets.New[string]()
+	placementClusters := map[string]sets.Set[string]{}
+	var notFound, awaiting []string
 	for _, placementRef := range mwrSet.Spec.PlacementRefs {
-		placement, err := d.placementLister.Placements(mwrSet.Namespace).Get(placementRef.Name)
-		if errors.IsNotFound(err) {
-			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonPlacementDecisionNotFound, ""))
-			return mwrSet, reconcileStop, nil
-		}
-		if err != nil {
-			return mwrSet, reconcileContinue, fmt.Errorf("Failed get placement %w", err)
+		clusters, err := d.placementDecisionTracker.Clusters(
+			helper.PlacementRefNamespace(mwrSet, placementRef.Name), placementRef.Name)
+		switch {
+		case apierrors.IsNotFound(err):
+			notFound = append(notFound, placementRef.Name)
+			continue
+		case errors.Is(err, placementhelpers.ErrDecisionsNotCreated):
+			awaiting = append(awaiting, placementRef.Name)
+			continue
+		case errors.Is(err, placementhelpers.ErrInconsistentDecisions):
+			// the decisions are being re-chunked by the placement controller, wait for a consistent snapshot
+			// before adding or deleting any manifestwork.
+			return mwrSet, reconcileStop, err
+		case err != nil:
+			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonNotAsExpected, ""))
+			return mwrSet, reconcileContinue, err
 		}
-		placements = append(placements, placement)
+
+		placementClusters[placementRef.Name] = clusters
+		decisionClusters = decisionClusters.Union(clusters)
 	}
+	setPlacementSummaries(mwrSet, placementClusters)
+
+	// the manifestworks are not changed until all the placements exist, otherwise the manifestworks of the
+	// clusters selected by a placement being recreated would be deleted.
+	if len(notFound) > 0 {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonPlacementDecisionNotFound,
+			fmt.Sprintf("placements %s are not found", strings.Join(notFound, ", "))))
+		return mwrSet, reconcileStop, nil
+	}
+
+	// the decisions of a placement just created are not generated yet, it is not an error. The manifestWorkReplicaSet
+	// is requeued with backoff, and reconciled again once the decisions are created.
+	key := manifestWorkReplicaSetKey(mwrSet)
+	if len(awaiting) > 0 {
+		message := fmt.Sprintf("the decisions of placements %s are not created yet", strings.Join(awaiting, ", "))
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(ReasonAwaitingDecision, message))
+		return mwrSet, reconcileStop, &requeueError{message: message, requeueAfter: d.decisionBackoff.when(key)}
+	}
+	d.decisionBackoff.forget(key)
 
 	manifestWorks, err := listManifestWorksByManifestWorkReplicaSet(mwrSet, d.manifestWorkLister)
 	if err != nil {
@@ -50,60 +123,169 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	}
 
 	errs := []error{}
-	addedClusters, deletedClusters, existingClusters := sets.New[string](), sets.New[string](), sets.New[string]()
+	existingClusters := sets.New[string]()
+	existingWorks := map[string]*workv1.ManifestWork{}
 	for _, mw := range manifestWorks {
 		existingClusters.Insert(mw.Namespace)
+		existingWorks[mw.Namespace] = mw
+	}
+
+	// on the drift repair pass, the manifestworks are applied with a workApplier without cache, so the missing
+	// and the modified manifestworks are repaired even if the template is not changed.
+	workApplier := d.workApplier
+	repair := d.driftRepair.start(mwrSet)
+	if repair != nil {
+		workApplier = d.driftRepair.newWorkApplier()
 	}
 
-	for _, placement := range placements {
-		added, deleted, err := helper.GetClusters(d.placeDecisionLister, placement, existingClusters)
+	expectedClusters, retainedClusters, err := applyMaintenancePolicy(mwrSet, d.clusterLister, decisionClusters, existingClusters)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	var skipped map[string]string
+	if d.clusterLister != nil {
+		skipped, err = skipObserveOnlyClusters(d.clusterLister, expectedClusters, existingClusters, retainedClusters)
 		if err != nil {
-			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonNotAsExpected, ""))
+			return mwrSet, reconcileContinue, err
+		}
+	}
+
+	addedClusters := expectedClusters.Difference(existingClusters)
+	deletedClusters := existingClusters.Difference(expectedClusters)
+
+	// the manifestworks with the same name owned by another manifestWorkReplicaSet are not updated
+	conflicts, err := findConflicts(mwrSet, d.manifestWorkLister, addedClusters)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	addedClusters = addedClusters.Difference(sets.KeySet(conflicts))
+	updateManifestWorkApplyConflict(mwrSet, conflicts, d.recorder)
 
-			return mwrSet, reconcileContinue, utilerrors.NewAggregate(errs)
+	// the clusters whose template values cannot be resolved or whose executor is not allowed, their
+	// manifestworks are not created or updated.
+	unresolved := map[string]string{}
+	denied := map[string]string{}
+
+	// render the manifestworks of the added clusters and the existing clusters to update, the manifestworks retained
+	// on the clusters in maintenance are left until the clusters return
+	desired := map[string]*workv1.ManifestWork{}
+	for cls := range addedClusters.Union(existingClusters.Difference(deletedClusters).Difference(retainedClusters)) {
+		mw, err := d.manifestWork(ctx, mwrSet, cls, unresolved, denied)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if mw != nil {
+			desired[cls] = mw
 		}
+	}
 
-		addedClusters = addedClusters.Union(added)
-		deletedClusters = deletedClusters.Union(deleted)
+	// with the rollout per group, the clusters are rolled out in the order of the decision groups, which are selected
+	// by the rollout annotations or the placements in order
+	var groups []sets.Set[string]
+	if mwrSet.Annotations[helper.RolloutStrategyAnnotationKey] == helper.RolloutStrategyProgressivePerGroup {
+		groups, err = d.decisionGroups(mwrSet)
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+	}
+
+	// with the progressive rollout, only the manifestworks of the clusters in the current wave are created or updated
+	rollout, err := planRollout(mwrSet, desired, existingWorks, groups, d.clock)
+	if err != nil {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolloutCompleted,
+			ReasonInvalidRolloutStrategy, err.Error(), metav1.ConditionFalse))
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolloutProgressing,
+			ReasonInvalidRolloutStrategy, err.Error(), metav1.ConditionFalse))
+		desired = map[string]*workv1.ManifestWork{}
+	}
+
+	// the manifestworks are not created or updated once the writes are throttled, the manifestWorkReplicaSet is
+	// requeued to continue instead of waiting in the worker.
+	throttled := false
+	admitWrite := func() bool {
+		if !throttled && !tryApply(d.workApplyLimiter) {
+			throttled = true
+		}
+		return !throttled
 	}
 
 	// Create manifestWork for added clusters
 	for cls := range addedClusters {
-		mw, err := CreateManifestWork(mwrSet, cls)
-		if err != nil {
-			errs = append(errs, err)
+		mw, ok := desired[cls]
+		if !ok || !rollout.isAdmitted(cls) {
 			continue
 		}
 
-		_, err = d.workApplier.Apply(ctx, mw)
+		if !admitWrite() {
+			continue
+		}
+		_, err = workApplier.Apply(ctx, mw)
 		if err != nil {
 			errs = append(errs, err)
+			continue
 		}
+		metrics.ManifestWorkReplicaSetWorkOperations.WithLabelValues(
+			mwrSet.Namespace, mwrSet.Name, metrics.OperationCreated).Inc()
+		repair.missing(cls)
 	}
 
 	// Update manifestWorks in case there are changes at ManifestWork or ManifestWorkReplicaSet
 	for cls := range existingClusters {
-		// Delete manifestWork for deleted clusters
+		// Delete or orphan manifestWork for deleted clusters
 		if deletedClusters.Has(cls) {
-			err = d.workApplier.Delete(ctx, cls, mwrSet.Name)
+			if isOrphanCleanup(mwrSet) {
+				err = orphanManifestWork(ctx, d.workClient, existingWorks[cls])
+			} else {
+				err = d.workApplier.Delete(ctx, cls, mwrSet.Name)
+				if err == nil {
+					metrics.ManifestWorkReplicaSetWorkOperations.WithLabelValues(
+						mwrSet.Namespace, mwrSet.Name, metrics.OperationDeleted).Inc()
+				}
+			}
 			if err != nil {
 				errs = append(errs, err)
 			}
 			continue
 		}
 
-		mw, err := CreateManifestWork(mwrSet, cls)
-		if err != nil {
-			errs = append(errs, err)
+		mw, ok := desired[cls]
+		if !ok || !rollout.isAdmitted(cls) {
+			continue
+		}
+
+		// the manifestwork applied with the same hash is not compared with the template again, except on the
+		// drift repair pass.
+		if repair == nil && templateHashMatched(mw, existingWorks[cls]) {
+			continue
+		}
+		if appliedWithoutTemplateHash(mw, existingWorks[cls]) {
 			continue
 		}
 
-		_, err = d.workApplier.Apply(ctx, mw)
+		modified := !workapplier.ManifestWorkEqual(mw, existingWorks[cls])
+		if modified && !admitWrite() {
+			continue
+		}
+		_, err = workApplier.Apply(ctx, mw)
 		if err != nil {
 			errs = append(errs, err)
+			continue
+		}
+		if modified {
+			metrics.ManifestWorkReplicaSetWorkOperations.WithLabelValues(
+				mwrSet.Namespace, mwrSet.Name, metrics.OperationUpdated).Inc()
+			if repair != nil {
+				repair.modified(cls)
+			}
 		}
 	}
 
+	// the drift repair pass is not done until all the manifestworks are compared
+	if repair != nil && !throttled {
+		d.driftRepair.done(mwrSet, repair)
+	}
+
 	// Set the Summary
 	if mwrSet.Status.Summary == (workapiv1alpha1.ManifestWorkReplicaSetSummary{}) {
 		mwrSet.Status.Summary = workapiv1alpha1.ManifestWorkReplicaSetSummary{}
@@ -114,6 +296,7 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	}
 
 	mwrSet.Status.Summary.Total = total
+	metrics.ManifestWorkReplicaSetTargetClusters.WithLabelValues(mwrSet.Namespace, mwrSet.Name).Set(float64(total))
 	if total == 0 {
 		mwrSet.Status.Summary.Applied = 0
 		mwrSet.Status.Summary.Available = 0
@@ -124,7 +307,93 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonAsExpected, ""))
 	}
 
-	return mwrSet, reconcileContinue, utilerrors.NewAggregate(errs)
+	if _, ok := mwrSet.Annotations[TemplateValuesConfigMapAnnotationKey]; ok {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetTemplateValuesResolved(unresolved))
+	} else {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionTemplateValuesResolved)
+	}
+
+	if mwrSet.Spec.ManifestWorkTemplate.Executor != nil && d.executorVerifier != nil {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetExecutorVerified(denied))
+	} else {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionExecutorVerified)
+	}
+
+	if len(skipped) > 0 {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetClustersSkipped(skipped))
+	} else {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionClustersSkipped)
+	}
+
+	if rollout != nil {
+		for _, condition := range rollout.conditions() {
+			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, condition)
+		}
+	} else if !rolloutEnabled(mwrSet) {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutCompleted)
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutProgressing)
+	}
+
+	if len(errs) > 0 {
+		return mwrSet, reconcileContinue, utilerrors.NewAggregate(errs)
+	}
+
+	// requeue to continue the throttled writes, to check the progress deadline of the clusters in progress, or
+	// to repair the drift once the interval has passed, whichever is the earliest.
+	var requeue *requeueError
+	requeueAt := func(message string, after time.Duration) {
+		if after > 0 && (requeue == nil || after < requeue.requeueAfter) {
+			requeue = &requeueError{message: message, requeueAfter: after}
+		}
+	}
+	if throttled {
+		requeueAt("the manifestwork writes are throttled", applyRetryDelay(d.workApplyLimiter))
+	}
+	if rollout != nil {
+		requeueAt("the clusters are in progress of the rollout", rollout.requeueAfter)
+	}
+	requeueAt("the drift repair is scheduled", d.driftRepair.nextRepairIn(mwrSet))
+	if requeue != nil {
+		return mwrSet, reconcileContinue, requeue
+	}
+	return mwrSet, reconcileContinue, nil
+}
+
+// manifestWork returns the manifestwork of the cluster rendered with the template values of the cluster. It
+// returns nil and records the message in unresolved if the template values of the cluster cannot be resolved,
+// or in denied if the creator of the executor cannot execute as the executor of the cluster, so only the
+// manifestwork of the cluster is skipped.
+func (d *deployReconciler) manifestWork(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	cls string, unresolved, denied map[string]string) (*workv1.ManifestWork, error) {
+	mw, err := CreateManifestWork(mwrSet, cls)
+	if err != nil {
+		return nil, err
+	}
+
+	var notResolvedErr *templateValuesNotResolvedError
+	err = renderTemplateValues(mwrSet, mw, d.templateValuesLister)
+	switch {
+	case errors.As(err, &notResolvedErr):
+		unresolved[cls] = notResolvedErr.Error()
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	message, err := d.executorVerifier.verify(ctx, mwrSet, mw)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(message) > 0:
+		denied[cls] = message
+		return nil, nil
+	}
+
+	// the manifests may be changed by the template values of the cluster
+	if err := setTemplateHash(mw); err != nil {
+		return nil, err
+	}
+	return mw, nil
 }
 
 // Return only True status if there all clusters have manifests applied as expected
@@ -161,11 +430,24 @@ func CreateManifestWork(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterN
 		return nil, fmt.Errorf("Invalid cluster namespace")
 	}
 
-	return &workv1.ManifestWork{
+	// strip the fields set by the api server from the manifests as the manifestwork webhook does, otherwise the
+	// manifestwork stored would never match the template.
+	spec := *mwrSet.Spec.ManifestWorkTemplate.DeepCopy()
+	spec.Workload.Manifests, _ = common.StripManifests(spec.Workload.Manifests)
+	// the executor of each cluster is rendered with the cluster name
+	spec.Executor = helper.RenderExecutor(spec.Executor, clusterNS)
+
+	labels, annotations := helper.ManifestWorkReplicaSetMetadata(mwrSet)
+	mw := &workv1.ManifestWork{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      mwrSet.Name,
-			Namespace: clusterNS,
-			Labels:    map[string]string{ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet)},
+			Name:        mwrSet.Name,
+			Namespace:   clusterNS,
+			Labels:      labels,
+			Annotations: annotations,
 		},
-		Spec: mwrSet.Spec.ManifestWorkTemplate}, nil
+		Spec: spec}
+	if err := setTemplateHash(mw); err != nil {
+		return nil, err
+	}
+	return mw, nil
 }
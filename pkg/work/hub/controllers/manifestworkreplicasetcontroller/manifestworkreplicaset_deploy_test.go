@@ -2,20 +2,30 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	"open-cluster-management.io/api/utils/work/v1/workapplier"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
 )
 
 func TestDeployReconcileAsExpected(t *testing.T) {
@@ -48,10 +58,9 @@ func TestDeployReconcileAsExpected(t *testing.T) {
 	placementDecisionLister := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()
 
 	pmwDeployController := deployReconciler{
-		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
-		manifestWorkLister:  mwLister,
-		placeDecisionLister: placementDecisionLister,
-		placementLister:     placementLister,
+		workApplier:              workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:       mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(placementLister, placementDecisionLister),
 	}
 
 	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
@@ -112,10 +121,9 @@ func TestDeployReconcileAsPlacementDecisionEmpty(t *testing.T) {
 	placementDecisionLister := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()
 
 	pmwDeployController := deployReconciler{
-		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
-		manifestWorkLister:  mwLister,
-		placeDecisionLister: placementDecisionLister,
-		placementLister:     placementLister,
+		workApplier:              workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:       mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(placementLister, placementDecisionLister),
 	}
 
 	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
@@ -172,10 +180,9 @@ func TestDeployReconcileAsPlacementNotExist(t *testing.T) {
 	placementDecisionLister := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()
 
 	pmwDeployController := deployReconciler{
-		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
-		manifestWorkLister:  mwLister,
-		placeDecisionLister: placementDecisionLister,
-		placementLister:     placementLister,
+		workApplier:              workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:       mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(placementLister, placementDecisionLister),
 	}
 
 	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
@@ -200,3 +207,428 @@ func TestDeployReconcileAsPlacementNotExist(t *testing.T) {
 		t.Fatal("Placement condition Reason not match PlacementDecisionEmpty ", placeCondition)
 	}
 }
+
+func TestDeployReconcileWithPlacementInOtherNamespace(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{helper.PlacementRefNamespacesAnnotation: `{"place-test": "shared"}`}
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	// The placement with the same name in the namespace of the mwrSet should be ignored
+	localPlacement, localPlacementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1")
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "shared", "cls2", "cls3")
+	fClusterClient := fakeclusterclient.NewSimpleClientset(localPlacement, localPlacementDecision, placement, placementDecision)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fClusterClient, 1*time.Minute)
+
+	for _, p := range []*clusterv1beta1.Placement{localPlacement, placement} {
+		if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pd := range []*clusterv1beta1.PlacementDecision{localPlacementDecision, placementDecision} {
+		if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(pd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+	}
+
+	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mwrSet.Status.Summary.Total != 2 {
+		t.Fatal("Summary not as expected ", mwrSet.Status.Summary)
+	}
+
+	var clusters []string
+	for _, action := range fWorkClient.Actions() {
+		if action.GetVerb() == "create" && action.GetResource().Resource == "manifestworks" {
+			clusters = append(clusters, action.GetNamespace())
+		}
+	}
+	sort.Strings(clusters)
+	if !reflect.DeepEqual(clusters, []string{"cls2", "cls3"}) {
+		t.Fatal("manifestworks should be created for the clusters selected by the shared placement ", clusters)
+	}
+}
+
+func TestDeployReconcileWithDecisionsRewritten(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	for _, cls := range []string{"cls1", "cls2", "cls3"} {
+		mw, _ := CreateManifestWork(mwrSet, cls)
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	// The decisions are rewritten with the same clusters in a different order and chunked into two decisions.
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls3", "cls1")
+	_, anotherPlacementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls2", "cls1")
+	anotherPlacementDecision.Name = "place-test-decision-2"
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fakeclusterclient.NewSimpleClientset(), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	for _, pd := range []*clusterv1beta1.PlacementDecision{placementDecision, anotherPlacementDecision} {
+		if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(pd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+	}
+
+	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mwrSet.Status.Summary.Total != 3 {
+		t.Fatal("Summary not as expected ", mwrSet.Status.Summary)
+	}
+	if len(fWorkClient.Actions()) != 0 {
+		t.Fatal("expected no work api calls, but got ", fWorkClient.Actions())
+	}
+}
+
+func TestDeployReconcileWithDecisionsRechunking(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	for _, cls := range []string{"cls1", "cls2", "cls3"} {
+		mw, _ := CreateManifestWork(mwrSet, cls)
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	// The decisions of cls1, cls2 and cls3 are re-chunked with cls4 added. The first decision is updated, while
+	// the second decision is not updated yet.
+	oldChecksum := placementhelpers.DecisionChecksum(sets.New[string]("cls1", "cls2", "cls3"))
+	newChecksum := placementhelpers.DecisionChecksum(sets.New[string]("cls1", "cls2", "cls3", "cls4"))
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2", "cls3")
+	placementDecision.Labels[placementhelpers.DecisionIndexLabel] = "1"
+	placementDecision.Annotations = map[string]string{placementhelpers.DecisionChecksumAnnotation: newChecksum}
+	_, anotherPlacementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls3")
+	anotherPlacementDecision.Name = "place-test-decision-2"
+	anotherPlacementDecision.Labels[placementhelpers.DecisionIndexLabel] = "2"
+	anotherPlacementDecision.Annotations = map[string]string{placementhelpers.DecisionChecksumAnnotation: oldChecksum}
+
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fakeclusterclient.NewSimpleClientset(), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	decisionStore := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore()
+	for _, pd := range []*clusterv1beta1.PlacementDecision{placementDecision, anotherPlacementDecision} {
+		if err := decisionStore.Add(pd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+	}
+
+	// the intermediate state is skipped
+	_, state, err := pmwDeployController.reconcile(context.TODO(), mwrSet.DeepCopy())
+	if !errors.Is(err, placementhelpers.ErrInconsistentDecisions) {
+		t.Fatalf("expected inconsistent decisions, but got %v", err)
+	}
+	if state != reconcileStop {
+		t.Errorf("expected the reconcile is stopped")
+	}
+	if len(fWorkClient.Actions()) != 0 {
+		t.Fatal("expected no work api calls, but got ", fWorkClient.Actions())
+	}
+
+	// the second decision is updated
+	anotherPlacementDecision = anotherPlacementDecision.DeepCopy()
+	anotherPlacementDecision.Status.Decisions = []clusterv1beta1.ClusterDecision{{ClusterName: "cls4"}}
+	anotherPlacementDecision.Annotations[placementhelpers.DecisionChecksumAnnotation] = newChecksum
+	if err := decisionStore.Update(anotherPlacementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	mwrSet, _, err = pmwDeployController.reconcile(context.TODO(), mwrSet.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mwrSet.Status.Summary.Total != 4 {
+		t.Fatal("Summary not as expected ", mwrSet.Status.Summary)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "create")
+	if ns := fWorkClient.Actions()[0].GetNamespace(); ns != "cls4" {
+		t.Errorf("expected manifestwork created in cls4, but got %s", ns)
+	}
+}
+
+func TestCreateManifestWorkStripsServerSetFields(t *testing.T) {
+	polluted := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test")
+	polluted.SetResourceVersion("12345")
+	polluted.SetUID("d0b9a3b4-7a6c-4d6f-9f0a-3c1b2a4e5f60")
+	pollutedWork, _ := spoketesting.NewManifestWork(0, polluted)
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Spec.ManifestWorkTemplate = pollutedWork.Spec
+
+	mw, err := CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &unstructured.Unstructured{}
+	if err := manifest.UnmarshalJSON(mw.Spec.Workload.Manifests[0].Raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.GetResourceVersion()) > 0 || len(manifest.GetUID()) > 0 {
+		t.Errorf("expected the server set fields are stripped, but got %v", manifest.Object)
+	}
+
+	// the template of the manifestWorkReplicaSet is not changed
+	if !reflect.DeepEqual(mwrSet.Spec.ManifestWorkTemplate, pollutedWork.Spec) {
+		t.Errorf("expected the template is not changed")
+	}
+}
+
+func TestCreateManifestWorkWithPriority(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw, err := CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mw.Annotations[helper.PriorityAnnotation]; ok {
+		t.Errorf("expected no priority on the manifestwork, but got %v", mw.Annotations)
+	}
+
+	mwrSet.Annotations = map[string]string{helper.PriorityAnnotation: "critical"}
+	mw, err = CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priority := helper.GetWorkPriority(mw); priority != helper.WorkPriorityCritical {
+		t.Errorf("expected the critical priority propagated to the manifestwork, but got %v", priority)
+	}
+}
+
+func TestCreateManifestWorkWithRestartedAt(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw, err := CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mw.Annotations[helper.RestartedAtAnnotation]; ok {
+		t.Errorf("expected no restartedAt on the manifestwork, but got %v", mw.Annotations)
+	}
+	hash := mw.Annotations[TemplateHashAnnotationKey]
+
+	mwrSet.Annotations = map[string]string{helper.RestartedAtAnnotation: "2026-10-16T00:00:00Z"}
+	mw, err = CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restartedAt := mw.Annotations[helper.RestartedAtAnnotation]; restartedAt != "2026-10-16T00:00:00Z" {
+		t.Errorf("expected the restartedAt propagated to the manifestwork, but got %q", restartedAt)
+	}
+	// the template hash is changed, so the manifestworks are updated
+	if mw.Annotations[TemplateHashAnnotationKey] == hash {
+		t.Errorf("expected the template hash changed with the restartedAt")
+	}
+}
+
+func TestCreateManifestWorkWithDependencies(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("app", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		helper.DependsOnAnnotation:               "database",
+		helper.DependencyTimeoutAnnotation:       "10m",
+		helper.DependencyTimeoutPolicyAnnotation: "Proceed",
+	}
+	mw, err := CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the manifestwork depends on the manifestwork of the database ManifestWorkReplicaSet in the same cluster
+	if dependencies := helper.GetDependencies(mw); !reflect.DeepEqual(dependencies, []string{"database"}) {
+		t.Errorf("expected the dependencies propagated to the manifestwork, but got %v", dependencies)
+	}
+	if timeout, policy := helper.GetDependencyTimeout(mw); timeout != 10*time.Minute ||
+		policy != helper.DependencyTimeoutPolicyProceed {
+		t.Errorf("expected the dependency timeout propagated to the manifestwork, but got %v with %s", timeout, policy)
+	}
+}
+
+func TestDeployReconcileWithMultiplePlacements(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "prod")
+	mwrSet.Spec.PlacementRefs = append(mwrSet.Spec.PlacementRefs, workapiv1alpha1.LocalPlacementReference{Name: "canary"})
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fakeclusterclient.NewSimpleClientset(), 1*time.Minute)
+	for name, clusters := range map[string][]string{"prod": {"cls1", "cls2"}, "canary": {"cls2", "cls3"}} {
+		placement, placementDecision := helpertest.CreateTestPlacement(name, "default", clusters...)
+		if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+	}
+
+	// the cluster selected by both placements has only one manifestwork
+	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "create", "create", "create")
+	if mwrSet.Status.Summary.Total != 3 {
+		t.Errorf("expected 3 manifestworks in total, but got %d", mwrSet.Status.Summary.Total)
+	}
+	for name, expectedMessage := range map[string]string{
+		"prod":   "2 clusters are selected, 1 of them are selected by the other placements as well",
+		"canary": "2 clusters are selected, 1 of them are selected by the other placements as well",
+	} {
+		cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, placementSummaryConditionType(name))
+		if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != expectedMessage {
+			t.Errorf("expected the summary of placement %s %q, but got %v", name, expectedMessage, cond)
+		}
+	}
+	works, err := fWorkClient.WorkV1().ManifestWorks(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range works.Items {
+		if err := mwStore.Add(&works.Items[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the manifestwork of the cluster no longer selected by any placement is deleted once a placement is removed
+	fWorkClient.ClearActions()
+	mwrSet.Spec.PlacementRefs = mwrSet.Spec.PlacementRefs[:1]
+	mwrSet, _, err = pmwDeployController.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := fWorkClient.Actions()
+	testingcommon.AssertActions(t, actions, "delete")
+	if actions[0].GetNamespace() != "cls3" {
+		t.Errorf("expected the manifestwork in cls3 deleted, but got %s", actions[0].GetNamespace())
+	}
+	if cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, placementSummaryConditionType("canary")); cond != nil {
+		t.Errorf("expected the summary of placement canary removed, but got %v", cond)
+	}
+	if cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, placementSummaryConditionType("prod")); cond == nil ||
+		cond.Message != "2 clusters are selected" {
+		t.Errorf("expected the summary of placement prod, but got %v", cond)
+	}
+
+	// no manifestwork is changed while a placement is missing
+	fWorkClient.ClearActions()
+	mwrSet.Spec.PlacementRefs = append(mwrSet.Spec.PlacementRefs, workapiv1alpha1.LocalPlacementReference{Name: "missing"})
+	mwrSet, _, err = pmwDeployController.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertNoActions(t, fWorkClient.Actions())
+	cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionPlacementVerified)
+	if cond == nil || cond.Reason != workapiv1alpha1.ReasonPlacementDecisionNotFound || cond.Message != "placements missing are not found" {
+		t.Errorf("expected the placement not found, but got %v", cond)
+	}
+	if cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, placementSummaryConditionType("missing")); cond == nil ||
+		cond.Reason != workapiv1alpha1.ReasonPlacementDecisionNotFound {
+		t.Errorf("expected the summary of placement missing, but got %v", cond)
+	}
+}
+
+func TestDeployReconcileAwaitingDecision(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw, _ := CreateManifestWork(mwrSet, "cls1")
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet, mw)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+		t.Fatal(err)
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	// the placement is created, but its decision is not created yet
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	fClusterClient := fakeclusterclient.NewSimpleClientset(placement)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fClusterClient, 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+
+	reconciler := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		workClient:         fWorkClient,
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+		decisionBackoff: newDecisionBackoff(),
+	}
+
+	// the requeue is delayed exponentially, and the manifestworks are not changed
+	for _, expectedDelay := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second} {
+		updated, state, err := reconciler.reconcile(context.TODO(), mwrSet.DeepCopy())
+		var rqe *requeueError
+		if !errors.As(err, &rqe) || rqe.requeueAfter != expectedDelay {
+			t.Fatalf("expected requeue after %v, but got %v", expectedDelay, err)
+		}
+		if state != reconcileStop {
+			t.Errorf("expected the reconcile stopped")
+		}
+		cond := apimeta.FindStatusCondition(updated.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionPlacementVerified)
+		if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonAwaitingDecision {
+			t.Errorf("expected the placement condition awaiting the decision, but got %v", cond)
+		}
+	}
+	testingcommon.AssertNoActions(t, fWorkClient.Actions())
+
+	// the manifestworks are applied once the decision is created, and the backoff is reset
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+	updated, _, err := reconciler.reconcile(context.TODO(), mwrSet.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !apimeta.IsStatusConditionTrue(updated.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionPlacementVerified) {
+		t.Errorf("expected the placement verified, but got %v", updated.Status.Conditions)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "create")
+	if delay := reconciler.decisionBackoff.when(manifestWorkReplicaSetKey(mwrSet)); delay != awaitingDecisionBaseDelay {
+		t.Errorf("expected the backoff reset, but got %v", delay)
+	}
+}
@@ -0,0 +1,148 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// ReasonManifestWorksRepaired is the reason of the event emitted on a ManifestWorkReplicaSet when the drift
+	// repair pass recreates the missing manifestworks or reverts the modified ones.
+	ReasonManifestWorksRepaired = "ManifestWorksRepaired"
+
+	// maxRepairedClustersInEvent is the max number of clusters listed in the repair event.
+	maxRepairedClustersInEvent = 10
+)
+
+// driftRepairer schedules the drift repair pass of each ManifestWorkReplicaSet. The workApplier skips the
+// manifestworks whose template hash and generation are not changed since the last apply, so a manifestwork
+// deleted or modified manually may not be repaired until the template or the placement is changed. On the
+// drift repair pass, the manifestworks are compared with the template again and repaired.
+type driftRepairer struct {
+	interval           time.Duration
+	workClient         workclientset.Interface
+	manifestWorkLister worklisterv1.ManifestWorkLister
+	recorder           kevents.EventRecorder
+	clock              clock.Clock
+
+	// lastRepairTimes records the start time of the last drift repair pass of each manifestWorkReplicaSet.
+	lastRepairTimes sync.Map
+}
+
+func newDriftRepairer(interval time.Duration, workClient workclientset.Interface,
+	manifestWorkLister worklisterv1.ManifestWorkLister, recorder kevents.EventRecorder) *driftRepairer {
+	if interval <= 0 {
+		return nil
+	}
+	return &driftRepairer{
+		interval:           interval,
+		workClient:         workClient,
+		manifestWorkLister: manifestWorkLister,
+		recorder:           recorder,
+		clock:              clock.RealClock{},
+	}
+}
+
+// start returns a driftRepair if the drift repair interval has passed since the last repair pass of the
+// manifestWorkReplicaSet started, otherwise nil. The first reconcile of a manifestWorkReplicaSet only starts the
+// interval, the manifestworks are created or compared with the template by the workApplier with an empty cache then.
+func (r *driftRepairer) start(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) *driftRepair {
+	if r == nil {
+		return nil
+	}
+	now := r.clock.Now()
+	last, loaded := r.lastRepairTimes.LoadOrStore(manifestWorkReplicaSetKey(mwrSet), now)
+	if !loaded || now.Sub(last.(time.Time)) < r.interval {
+		return nil
+	}
+	return &driftRepair{startedAt: now}
+}
+
+// nextRepairIn returns the duration until the next drift repair pass of the manifestWorkReplicaSet, so it is
+// requeued for the repair regardless of the resync. The interval is counted from the start of the last pass, the
+// same as start, so the requeued reconcile always runs the pass. It returns 0 if the drift repair is disabled.
+func (r *driftRepairer) nextRepairIn(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) time.Duration {
+	if r == nil {
+		return 0
+	}
+	last, ok := r.lastRepairTimes.Load(manifestWorkReplicaSetKey(mwrSet))
+	if !ok {
+		return r.interval
+	}
+	if remaining := r.interval - r.clock.Now().Sub(last.(time.Time)); remaining > 0 {
+		return remaining
+	}
+	return time.Second
+}
+
+// newWorkApplier returns a workApplier with an empty cache, so every manifestwork is compared with the template.
+func (r *driftRepairer) newWorkApplier() *workapplier.WorkApplier {
+	return workapplier.NewWorkApplierWithTypedClient(r.workClient, r.manifestWorkLister)
+}
+
+// done records the start time of the repair pass of the manifestWorkReplicaSet, so the time taken by the pass is
+// not added to the interval, and emits an event if any manifestwork is repaired.
+func (r *driftRepairer) done(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, repair *driftRepair) {
+	r.lastRepairTimes.Store(manifestWorkReplicaSetKey(mwrSet), repair.startedAt)
+
+	if r.recorder == nil || (len(repair.missingClusters) == 0 && len(repair.modifiedClusters) == 0) {
+		return
+	}
+	r.recorder.Eventf(mwrSet, nil, corev1.EventTypeNormal, ReasonManifestWorksRepaired, "RepairManifestWorks",
+		"Recreated %d missing manifestworks%s and reverted %d modified manifestworks%s",
+		len(repair.missingClusters), clustersString(repair.missingClusters),
+		len(repair.modifiedClusters), clustersString(repair.modifiedClusters))
+}
+
+// forget removes the repair time of the manifestWorkReplicaSet once it is deleted.
+func (r *driftRepairer) forget(key string) {
+	if r == nil {
+		return
+	}
+	r.lastRepairTimes.Delete(key)
+}
+
+// driftRepair records the clusters whose manifestworks are repaired on a drift repair pass. A nil driftRepair
+// records nothing.
+type driftRepair struct {
+	startedAt        time.Time
+	missingClusters  []string
+	modifiedClusters []string
+}
+
+func (r *driftRepair) missing(cluster string) {
+	if r != nil {
+		r.missingClusters = append(r.missingClusters, cluster)
+	}
+}
+
+func (r *driftRepair) modified(cluster string) {
+	if r != nil {
+		r.modifiedClusters = append(r.modifiedClusters, cluster)
+	}
+}
+
+func clustersString(clusters []string) string {
+	if len(clusters) == 0 {
+		return ""
+	}
+	sorted := append([]string{}, clusters...)
+	sort.Strings(sorted)
+	if len(sorted) > maxRepairedClustersInEvent {
+		return fmt.Sprintf(" (%s and %d more)", strings.Join(sorted[:maxRepairedClustersInEvent], ","),
+			len(sorted)-maxRepairedClustersInEvent)
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(sorted, ","))
+}
@@ -0,0 +1,148 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	kevents "k8s.io/client-go/tools/events"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestDeployReconcileDriftRepair(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakekube.NewSimpleClientset(), 1*time.Minute)
+
+	recorder := kevents.NewFakeRecorder(10)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	driftRepair := newDriftRepairer(10*time.Minute, fWorkClient, mwLister, recorder)
+	driftRepair.clock = fakeClock
+
+	reconciler := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+		templateValuesLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+		driftRepair:          driftRepair,
+	}
+
+	// the manifestWorkReplicaSet is requeued for the next repair pass regardless of the resync
+	reconcile := func(name string, expectedRequeue time.Duration, expectedActions ...string) {
+		fWorkClient.ClearActions()
+		_, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+		var rqe *requeueError
+		if !errors.As(err, &rqe) {
+			t.Fatalf("%s: expected requeue, but got %v", name, err)
+		}
+		if rqe.requeueAfter != expectedRequeue {
+			t.Errorf("%s: expected requeue after %v, but got %v", name, expectedRequeue, rqe.requeueAfter)
+		}
+		testingcommon.AssertActions(t, fWorkClient.Actions(), expectedActions...)
+	}
+
+	// the first reconcile creates the manifestworks without a repair pass
+	reconcile("first reconcile", 10*time.Minute, "create", "create")
+	assertEvents(t, recorder)
+	for _, cls := range []string{"cls1", "cls2"} {
+		mw, err := fWorkClient.WorkV1().ManifestWorks(cls).Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mwStore.Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the manifestwork of cls2 is modified manually, it is skipped by the cache of the workApplier since the
+	// template is not changed.
+	modified, err := mwLister.ManifestWorks("cls2").Get(mwrSet.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified = modified.DeepCopy()
+	manual, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "ConfigMap", "test-ns", "manual"))
+	modified.Spec.Workload.Manifests = manual.Spec.Workload.Manifests
+	if err := mwStore.Update(modified); err != nil {
+		t.Fatal(err)
+	}
+	fakeClock.Step(4 * time.Minute)
+	reconcile("modified before the repair interval", 6*time.Minute)
+	assertEvents(t, recorder)
+
+	// the manifestwork of cls1 is deleted manually
+	deleted, err := mwLister.ManifestWorks("cls1").Get(mwrSet.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fWorkClient.WorkV1().ManifestWorks("cls1").Delete(context.TODO(), deleted.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mwStore.Delete(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	// the repair pass recreates the deleted manifestwork and reverts the modified manifestwork
+	fakeClock.Step(6 * time.Minute)
+	reconcile("repair pass", 10*time.Minute, "create", "patch")
+	assertEvents(t, recorder,
+		"Normal ManifestWorksRepaired Recreated 1 missing manifestworks (cls1) and reverted 1 modified manifestworks (cls2)")
+}
+
+func TestDriftRepairSchedule(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	driftRepair := newDriftRepairer(10*time.Minute, nil, nil, kevents.NewFakeRecorder(10))
+	driftRepair.clock = fakeClock
+
+	if repair := driftRepair.start(mwrSet); repair != nil {
+		t.Fatalf("expected no repair pass on the first reconcile")
+	}
+	fakeClock.Step(10 * time.Minute)
+	repair := driftRepair.start(mwrSet)
+	if repair == nil {
+		t.Fatalf("expected a repair pass once the interval passed")
+	}
+
+	// the time taken by the pass is not added to the interval, the reconcile requeued after nextRepairIn runs the
+	// next pass.
+	fakeClock.Step(time.Minute)
+	driftRepair.done(mwrSet, repair)
+	if next := driftRepair.nextRepairIn(mwrSet); next != 9*time.Minute {
+		t.Errorf("expected the next repair pass in 9m, but got %v", next)
+	}
+	fakeClock.Step(9 * time.Minute)
+	if repair := driftRepair.start(mwrSet); repair == nil {
+		t.Errorf("expected a repair pass on the requeued reconcile")
+	}
+}
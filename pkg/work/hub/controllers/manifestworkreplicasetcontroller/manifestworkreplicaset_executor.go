@@ -0,0 +1,102 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// ManifestWorkReplicaSetConditionExecutorVerified is the condition type of a ManifestWorkReplicaSet with the
+	// executor in its manifestWork template. It is false if the creator of the executor has no execute-as
+	// permission on the executor of any cluster, and the message lists the clusters and why.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionExecutorVerified = "ExecutorVerified"
+
+	// ReasonExecutorPermissionDenied is the reason of the ExecutorVerified condition when the creator of the
+	// executor cannot execute as the executor in some clusters.
+	ReasonExecutorPermissionDenied = "ExecutorPermissionDenied"
+
+	// executorPermissionCacheSize and executorPermissionCacheTTL limit the cached results of the execute-as
+	// permission checks, so the permissions are not checked on every reconcile of each cluster.
+	executorPermissionCacheSize = 10000
+	executorPermissionCacheTTL  = 5 * time.Minute
+)
+
+// executorVerifier checks the creator of the executor of a ManifestWorkReplicaSet has the execute-as permission
+// on the executor rendered for each cluster. It is disabled if nil.
+type executorVerifier struct {
+	sarClient authorizationv1client.SubjectAccessReviewsGetter
+	cache     *utilcache.LRUExpireCache
+}
+
+func newExecutorVerifier(sarClient authorizationv1client.SubjectAccessReviewsGetter) *executorVerifier {
+	if sarClient == nil {
+		return nil
+	}
+	return &executorVerifier{
+		sarClient: sarClient,
+		cache:     utilcache.NewLRUExpireCache(executorPermissionCacheSize),
+	}
+}
+
+// verify returns the message why the manifestwork cannot be created or updated with its executor, or an empty
+// message if it is allowed. The ExecutorCreatorAnnotation is checked by the validating webhook to be the user of
+// the request which sets the executor. The ManifestWorkReplicaSets created without the annotation are not checked
+// unless the executor is a template, since their manifestworks were created without the check.
+func (v *executorVerifier) verify(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	mw *workv1.ManifestWork) (string, error) {
+	executor := mw.Spec.Executor
+	if v == nil || executor == nil || executor.Subject.ServiceAccount == nil {
+		return "", nil
+	}
+
+	creator, err := helper.GetExecutorCreator(mwrSet)
+	switch {
+	case err != nil:
+		return err.Error(), nil
+	case creator == nil && helper.IsExecutorTemplate(mwrSet.Spec.ManifestWorkTemplate.Executor):
+		return "the creator of the executor is unknown", nil
+	case creator == nil:
+		return "", nil
+	}
+
+	serviceAccount := executor.Subject.ServiceAccount
+	key := strings.Join([]string{mwrSet.Annotations[helper.ExecutorCreatorAnnotation], mw.Namespace,
+		serviceAccount.Namespace, serviceAccount.Name}, "/")
+	allowed, ok := v.cache.Get(key)
+	if !ok {
+		allowed, err = helper.CanExecuteAs(ctx, v.sarClient, *creator, mw.Namespace, serviceAccount)
+		if err != nil {
+			return "", err
+		}
+		v.cache.Add(key, allowed, executorPermissionCacheTTL)
+	}
+
+	if !allowed.(bool) {
+		return fmt.Sprintf("user %s cannot execute as %s/%s", creator.Username,
+			serviceAccount.Namespace, serviceAccount.Name), nil
+	}
+	return "", nil
+}
+
+// GetExecutorVerified returns the ExecutorVerified condition with the messages of the clusters whose executor
+// is not allowed.
+func GetExecutorVerified(denied map[string]string) metav1.Condition {
+	if len(denied) == 0 {
+		return getCondition(ManifestWorkReplicaSetConditionExecutorVerified,
+			workapiv1alpha1.ReasonAsExpected, "", metav1.ConditionTrue)
+	}
+	return getCondition(ManifestWorkReplicaSetConditionExecutorVerified,
+		ReasonExecutorPermissionDenied, clusterMessages(denied), metav1.ConditionFalse)
+}
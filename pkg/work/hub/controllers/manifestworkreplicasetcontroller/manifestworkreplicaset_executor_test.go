@@ -0,0 +1,185 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newExecutorManifestWorkReplicaSet(t *testing.T, creator *authenticationv1.UserInfo) *workapiv1alpha1.ManifestWorkReplicaSet {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Spec.ManifestWorkTemplate.Executor = &workv1.ManifestWorkExecutor{
+		Subject: workv1.ManifestWorkExecutorSubject{
+			Type: workv1.ExecutorSubjectTypeServiceAccount,
+			ServiceAccount: &workv1.ManifestWorkSubjectServiceAccount{
+				Namespace: "{{ .ClusterName }}-apps",
+				Name:      "deployer",
+			},
+		},
+	}
+	if creator != nil {
+		value, err := helper.ExecutorCreator(*creator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mwrSet.Annotations = map[string]string{helper.ExecutorCreatorAnnotation: value}
+	}
+	return mwrSet
+}
+
+func TestCreateManifestWorkRendersExecutor(t *testing.T) {
+	mwrSet := newExecutorManifestWorkReplicaSet(t, nil)
+	mw, err := CreateManifestWork(mwrSet, "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serviceAccount := mw.Spec.Executor.Subject.ServiceAccount
+	if serviceAccount.Namespace != "cls1-apps" || serviceAccount.Name != "deployer" {
+		t.Errorf("expected the executor cls1-apps/deployer, but got %s/%s", serviceAccount.Namespace, serviceAccount.Name)
+	}
+	if mwrSet.Spec.ManifestWorkTemplate.Executor.Subject.ServiceAccount.Namespace != "{{ .ClusterName }}-apps" {
+		t.Errorf("expected the template is not changed")
+	}
+}
+
+func TestDeployReconcileExecutor(t *testing.T) {
+	cases := []struct {
+		name              string
+		creator           *authenticationv1.UserInfo
+		allowedNamespaces []string
+		expectedActions   []string
+		expectedVerified  bool
+		expectedMessage   string
+		expectedSARs      int
+	}{
+		{
+			name:              "executor allowed in all clusters",
+			creator:           &authenticationv1.UserInfo{Username: "user1"},
+			allowedNamespaces: []string{"cls1", "cls2"},
+			expectedActions:   []string{"create", "create"},
+			expectedVerified:  true,
+			expectedSARs:      2,
+		},
+		{
+			name:              "executor denied in cls2",
+			creator:           &authenticationv1.UserInfo{Username: "user1"},
+			allowedNamespaces: []string{"cls1"},
+			expectedActions:   []string{"create"},
+			expectedMessage:   "cls2: user user1 cannot execute as cls2-apps/deployer",
+			expectedSARs:      2,
+		},
+		{
+			name:            "creator unknown",
+			expectedMessage: "cls1: the creator of the executor is unknown; cls2: the creator of the executor is unknown",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := newExecutorManifestWorkReplicaSet(t, c.creator)
+			fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+			workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+			mwStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+			mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+			placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+				fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+				t.Fatal(err)
+			}
+			if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+				t.Fatal(err)
+			}
+
+			kubeClient := fakekube.NewSimpleClientset()
+			sars := 0
+			kubeClient.PrependReactor("create", "subjectaccessreviews",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					sars++
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					attributes := sar.Spec.ResourceAttributes
+					if attributes.Verb != "execute-as" || attributes.Name != fmt.Sprintf(
+						"system:serviceaccount:%s-apps:deployer", attributes.Namespace) {
+						t.Errorf("unexpected subjectaccessreview %v", attributes)
+					}
+					for _, ns := range c.allowedNamespaces {
+						if ns == attributes.Namespace {
+							sar.Status.Allowed = true
+						}
+					}
+					return true, sar, nil
+				})
+
+			reconciler := deployReconciler{
+				workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+				manifestWorkLister: mwLister,
+				placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+					clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+					clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+				executorVerifier: newExecutorVerifier(kubeClient.AuthorizationV1()),
+			}
+
+			// reconcile twice, the permissions are checked only once with the cache
+			for i := 0; i < 2; i++ {
+				fWorkClient.ClearActions()
+				var err error
+				mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i > 0 {
+					continue
+				}
+				testingcommon.AssertActions(t, fWorkClient.Actions(), c.expectedActions...)
+				// sync the manifestworks created to the informer
+				works, err := fWorkClient.WorkV1().ManifestWorks(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for j := range works.Items {
+					if err := mwStore.Add(&works.Items[j]); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if sars != c.expectedSARs {
+				t.Errorf("expected %d subjectaccessreviews, but got %d", c.expectedSARs, sars)
+			}
+
+			cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionExecutorVerified)
+			if cond == nil {
+				t.Fatalf("expected the ExecutorVerified condition")
+			}
+			if verified := cond.Status == metav1.ConditionTrue; verified != c.expectedVerified {
+				t.Errorf("expected verified %v, but got %v", c.expectedVerified, cond.Status)
+			}
+			if cond.Message != c.expectedMessage {
+				t.Errorf("expected message %q, but got %q", c.expectedMessage, cond.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// FeedbackSummaryAnnotationKey is the annotation on a ManifestWorkReplicaSet set by the controller with the
+	// summary of the status feedback values of the manifestworks in json, since the status of the
+	// ManifestWorkReplicaSet has no field for them. The integer values of each feedback rule of each resource are
+	// summed across the clusters, and the other values are only counted as unaggregatable.
+	// TODO move this to the api repo
+	FeedbackSummaryAnnotationKey = "work.open-cluster-management.io/feedback-summary"
+
+	// DefaultMaxFeedbackSummaryClusters is the default max number of clusters in the per cluster breakdown of
+	// each feedback rule.
+	DefaultMaxFeedbackSummaryClusters = 10
+)
+
+// feedbackSummary is the summary of the status feedback values of the manifestworks of a ManifestWorkReplicaSet.
+type feedbackSummary struct {
+	Rules []feedbackRuleSummary `json:"rules,omitempty"`
+	// Unaggregatable is the number of the feedback values which are not integers.
+	Unaggregatable int `json:"unaggregatable,omitempty"`
+}
+
+// feedbackRuleSummary is the sum of the integer values of a feedback rule of a resource across the clusters.
+type feedbackRuleSummary struct {
+	// Resource is the resource the value is fed back from, in the format of resource.group/namespace/name.
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Sum      int64  `json:"sum"`
+	// Clusters is the value of the first clusters sorted by name up to the max number configured on the
+	// controller, and OmittedClusters is the number of the rest clusters.
+	Clusters        map[string]int64 `json:"clusters,omitempty"`
+	OmittedClusters int              `json:"omittedClusters,omitempty"`
+}
+
+// updateFeedbackSummary sets the FeedbackSummaryAnnotationKey annotation of the manifestWorkReplicaSet with the
+// status feedback values of the manifestworks, the annotation is removed if there is no feedback value. At most
+// maxClusters clusters are listed in the breakdown of each rule, so the annotation is not rewritten each time a
+// value on one of thousands of clusters changes. The breakdown is disabled if maxClusters is 0.
+func updateFeedbackSummary(annotations map[string]string, manifestWorks []*workapiv1.ManifestWork,
+	maxClusters int) (map[string]string, error) {
+	summary := summarizeFeedbacks(manifestWorks, maxClusters)
+	if len(summary.Rules) == 0 && summary.Unaggregatable == 0 {
+		delete(annotations, FeedbackSummaryAnnotationKey)
+		return annotations, nil
+	}
+
+	value, err := json.Marshal(summary)
+	if err != nil {
+		return annotations, err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[FeedbackSummaryAnnotationKey] = string(value)
+	return annotations, nil
+}
+
+func summarizeFeedbacks(manifestWorks []*workapiv1.ManifestWork, maxClusters int) feedbackSummary {
+	type ruleKey struct {
+		resource string
+		name     string
+	}
+	values := map[ruleKey]map[string]int64{}
+	summary := feedbackSummary{}
+	for _, mw := range manifestWorks {
+		if !mw.DeletionTimestamp.IsZero() {
+			continue
+		}
+		for _, manifest := range mw.Status.ResourceStatus.Manifests {
+			resource := feedbackResource(manifest.ResourceMeta)
+			for _, feedback := range manifest.StatusFeedbacks.Values {
+				if feedback.Value.Type != workapiv1.Integer || feedback.Value.Integer == nil {
+					summary.Unaggregatable++
+					continue
+				}
+				key := ruleKey{resource: resource, name: feedback.Name}
+				if values[key] == nil {
+					values[key] = map[string]int64{}
+				}
+				values[key][mw.Namespace] = *feedback.Value.Integer
+			}
+		}
+	}
+
+	for key, clusterValues := range values {
+		rule := feedbackRuleSummary{Resource: key.resource, Name: key.name}
+		clusters := make([]string, 0, len(clusterValues))
+		for cluster, value := range clusterValues {
+			rule.Sum += value
+			clusters = append(clusters, cluster)
+		}
+		sort.Strings(clusters)
+		for index, cluster := range clusters {
+			if index >= maxClusters {
+				rule.OmittedClusters = len(clusters) - index
+				break
+			}
+			if rule.Clusters == nil {
+				rule.Clusters = map[string]int64{}
+			}
+			rule.Clusters[cluster] = clusterValues[cluster]
+		}
+		summary.Rules = append(summary.Rules, rule)
+	}
+	sort.Slice(summary.Rules, func(i, j int) bool {
+		if summary.Rules[i].Resource != summary.Rules[j].Resource {
+			return summary.Rules[i].Resource < summary.Rules[j].Resource
+		}
+		return summary.Rules[i].Name < summary.Rules[j].Name
+	})
+	return summary
+}
+
+func feedbackResource(meta workapiv1.ManifestResourceMeta) string {
+	resource := schema.GroupResource{Group: meta.Group, Resource: meta.Resource}.String()
+	if len(meta.Namespace) == 0 {
+		return fmt.Sprintf("%s/%s", resource, meta.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", resource, meta.Namespace, meta.Name)
+}
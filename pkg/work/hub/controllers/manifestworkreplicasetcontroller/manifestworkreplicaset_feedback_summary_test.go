@@ -0,0 +1,137 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newFeedbackManifest(namespace, name string, values ...workv1.FeedbackValue) workv1.ManifestCondition {
+	return workv1.ManifestCondition{
+		ResourceMeta: workv1.ManifestResourceMeta{
+			Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments", Namespace: namespace, Name: name,
+		},
+		StatusFeedbacks: workv1.StatusFeedbackResult{Values: values},
+	}
+}
+
+func integerFeedback(name string, value int64) workv1.FeedbackValue {
+	return workv1.FeedbackValue{Name: name, Value: workv1.FieldValue{Type: workv1.Integer, Integer: &value}}
+}
+
+func TestStatusReconcileFeedbackSummary(t *testing.T) {
+	clusters := []string{"cls1", "cls2", "cls3"}
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Status.Summary.Total = len(clusters)
+
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeworkclient.NewSimpleClientset(), 1*time.Minute)
+	workStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	status := "Available"
+	for index, cls := range clusters {
+		mw, _ := CreateManifestWork(mwrSet, cls)
+		mw.Status.ResourceStatus.Manifests = []workv1.ManifestCondition{
+			newFeedbackManifest("default", "nginx",
+				integerFeedback("readyReplicas", int64(index+1)),
+				workv1.FeedbackValue{Name: "status", Value: workv1.FieldValue{Type: workv1.String, String: &status}}),
+		}
+		if cls == "cls1" {
+			mw.Status.ResourceStatus.Manifests = append(mw.Status.ResourceStatus.Manifests,
+				newFeedbackManifest("", "cluster-scoped", integerFeedback("replicas", 5)))
+		}
+		if err := workStore.Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reconciler := statusReconciler{
+		manifestWorkLister:         workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		feedbackSummary:            true,
+		maxFeedbackSummaryClusters: 2,
+	}
+	mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary := feedbackSummary{}
+	if err := json.Unmarshal([]byte(mwrSet.Annotations[FeedbackSummaryAnnotationKey]), &summary); err != nil {
+		t.Fatalf("invalid feedback summary %q: %v", mwrSet.Annotations[FeedbackSummaryAnnotationKey], err)
+	}
+	expected := feedbackSummary{
+		Rules: []feedbackRuleSummary{
+			{
+				Resource: "deployments.apps/cluster-scoped",
+				Name:     "replicas",
+				Sum:      5,
+				Clusters: map[string]int64{"cls1": 5},
+			},
+			{
+				Resource:        "deployments.apps/default/nginx",
+				Name:            "readyReplicas",
+				Sum:             6,
+				Clusters:        map[string]int64{"cls1": 1, "cls2": 2},
+				OmittedClusters: 1,
+			},
+		},
+		Unaggregatable: 3,
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("expected feedback summary %v, but got %v", expected, summary)
+	}
+
+	// only the sums are kept if the breakdown is disabled
+	reconciler.maxFeedbackSummaryClusters = 0
+	mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary = feedbackSummary{}
+	if err := json.Unmarshal([]byte(mwrSet.Annotations[FeedbackSummaryAnnotationKey]), &summary); err != nil {
+		t.Fatalf("invalid feedback summary %q: %v", mwrSet.Annotations[FeedbackSummaryAnnotationKey], err)
+	}
+	for _, rule := range summary.Rules {
+		if len(rule.Clusters) != 0 {
+			t.Errorf("expected no cluster in the breakdown of %s, but got %v", rule.Name, rule.Clusters)
+		}
+	}
+	if summary.Rules[1].Sum != 6 || summary.Rules[1].OmittedClusters != 3 {
+		t.Errorf("expected the sum 6 of 3 omitted clusters, but got %v", summary.Rules[1])
+	}
+
+	// the annotation is removed once the feedback summary is disabled
+	reconciler.feedbackSummary = false
+	mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mwrSet.Annotations[FeedbackSummaryAnnotationKey]; ok {
+		t.Errorf("expected the feedback summary annotation removed once it is disabled")
+	}
+	reconciler.feedbackSummary = true
+
+	// the annotation is removed once no feedback value is left
+	for _, cls := range clusters {
+		obj, _, _ := workStore.GetByKey(fmt.Sprintf("%s/%s", cls, mwrSet.Name))
+		mw := obj.(*workv1.ManifestWork).DeepCopy()
+		mw.Status.ResourceStatus.Manifests = nil
+		if err := workStore.Update(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mwrSet.Annotations[FeedbackSummaryAnnotationKey]; ok {
+		t.Errorf("expected the feedback summary annotation removed")
+	}
+}
@@ -2,23 +2,88 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	goerrors "errors"
+	"fmt"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
 
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 )
 
-// finalizeReconciler is to finalize the manifestWorkReplicaSet by deleting all related manifestWorks.
+const (
+	// CleanupPendingLabelKey is the label key on a manifestwork of a deleted ManifestWorkReplicaSet which is still
+	// being deleted on an unavailable or deleted cluster. The ManifestWorkReplicaSet is released without waiting for
+	// the manifestwork, and the manifestwork is left for later cleanup.
+	// TODO move this to the api repo
+	CleanupPendingLabelKey = "work.open-cluster-management.io/cleanup-pending"
+
+	// ReasonManifestWorksSkipped is the reason of the event emitted on a ManifestWorkReplicaSet when it is released
+	// without waiting for the manifestworks on the unavailable or deleted clusters.
+	ReasonManifestWorksSkipped = "ManifestWorksSkipped"
+
+	// ManifestWorkReplicaSetConditionManifestWorksDeleting is the condition type of a deleted
+	// ManifestWorkReplicaSet waiting for its manifestworks to be deleted. The message lists the pending clusters
+	// and when the manifestworks are skipped.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionManifestWorksDeleting = "ManifestWorksDeleting"
+
+	// ReasonWaitingForManifestWorks is the reason of the ManifestWorksDeleting condition.
+	ReasonWaitingForManifestWorks = "WaitingForManifestWorks"
+
+	// finalizeRequeueInterval is the interval to recheck the manifestworks on the unavailable clusters before
+	// the timeout.
+	finalizeRequeueInterval = time.Minute
+)
+
+// requeueError is returned by a reconciler to requeue the manifestWorkReplicaSet after a while.
+type requeueError struct {
+	message      string
+	requeueAfter time.Duration
+}
+
+func (r *requeueError) Error() string {
+	return fmt.Sprintf("%s, requeue after %v", r.message, r.requeueAfter)
+}
+
+// finalizeReconciler is to finalize the manifestWorkReplicaSet by deleting all related manifestWorks, or by
+// orphaning them with the Orphan cleanup policy. With the OrphanResourcesOnDeletionAnnotationKey, the
+// manifestWorks are deleted with the Orphan delete option, so the resources are left on the clusters. With the
+// tombstone cleanup, a tombstone is written in the namespace of each manifestWork instead, and the finalizer is
+// removed once all the tombstones are written. The manifestWorks are deleted by the tombstoneController then.
+//
+// If the manifestWorks are created with the Foreground delete option, which is the default, the finalizer is
+// removed once all the manifestWorks are deleted, and the ManifestWorksDeleting condition shows the pending
+// clusters meanwhile. A manifestWork on a deleted cluster, or on a cluster unavailable longer than the
+// unavailableClusterTimeout, may never be deleted since the agent is gone. The wait is also bounded by the
+// unavailableClusterTimeout since the manifestWorkReplicaSet is deleted, in case the agent never finishes the
+// deletion. A stuck manifestWork is skipped, and either its finalizers are removed if forceDeleteStuckWorks is
+// true, or it is labeled with CleanupPendingLabelKey and cleaned up by the orphanGCController later.
 type finalizeReconciler struct {
-	workApplier        *workapplier.WorkApplier
-	workClient         workclientset.Interface
-	manifestWorkLister worklisterv1.ManifestWorkLister
+	workApplier               *workapplier.WorkApplier
+	workClient                workclientset.Interface
+	kubeClient                corev1client.ConfigMapsGetter
+	manifestWorkLister        worklisterv1.ManifestWorkLister
+	clusterLister             clusterlisterv1.ManagedClusterLister
+	recorder                  kevents.EventRecorder
+	cleanupWithTombstones     bool
+	unavailableClusterTimeout time.Duration
+	forceDeleteStuckWorks     bool
 }
 
 func (f *finalizeReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
@@ -27,7 +92,21 @@ func (f *finalizeReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alp
 		return mwrSet, reconcileContinue, nil
 	}
 
-	if err := f.finalizeManifestWorkReplicaSet(ctx, mwrSet); err != nil {
+	finalize := f.finalizeManifestWorkReplicaSet
+	switch {
+	case isOrphanCleanup(mwrSet):
+		finalize = f.orphanManifestWorks
+	case isOrphanResourcesOnDeletion(mwrSet):
+		finalize = f.deleteManifestWorksOrphaningResources
+	case f.cleanupWithTombstones:
+		finalize = f.writeTombstones
+	}
+	if err := finalize(ctx, mwrSet); err != nil {
+		// stop the other reconcilers while waiting for the manifestworks to be deleted
+		var rqe *requeueError
+		if goerrors.As(err, &rqe) {
+			return mwrSet, reconcileStop, err
+		}
 		return mwrSet, reconcileContinue, err
 	}
 
@@ -50,12 +129,133 @@ func (m *finalizeReconciler) finalizeManifestWorkReplicaSet(ctx context.Context,
 	}
 
 	errs := []error{}
+	var pending, skipped []string
+	var requeueAfter time.Duration
+	waited := time.Since(manifestWorkReplicaSet.DeletionTimestamp.Time)
 	for _, mw := range manifestWorks {
-		err = m.workApplier.Delete(ctx, mw.Namespace, mw.Name)
-		if err != nil && !errors.IsNotFound(err) {
+		if mw.DeletionTimestamp.IsZero() {
+			err = m.workApplier.Delete(ctx, mw.Namespace, mw.Name)
+			if err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			if isForegroundDeletion(manifestWorkReplicaSet) {
+				pending = append(pending, mw.Namespace)
+			}
+			continue
+		}
+		if !isForegroundDeletion(manifestWorkReplicaSet) || mw.Labels[CleanupPendingLabelKey] == "true" {
+			continue
+		}
+
+		stuck, remaining, err := m.isStuck(mw, waited)
+		switch {
+		case err != nil:
 			errs = append(errs, err)
+		case !stuck:
+			pending = append(pending, mw.Namespace)
+			if remaining > 0 && (requeueAfter == 0 || remaining < requeueAfter) {
+				requeueAfter = remaining
+			}
+		default:
+			if err := m.skipManifestWork(ctx, mw); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			skipped = append(skipped, mw.Namespace)
+		}
+	}
+
+	if len(skipped) > 0 {
+		action := "labeled for later cleanup"
+		if m.forceDeleteStuckWorks {
+			action = "force deleted"
+		}
+		klog.V(2).Infof("The manifestworks of ManifestWorkReplicaSet %s/%s on clusters %v are %s",
+			manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name, skipped, action)
+		if m.recorder != nil {
+			m.recorder.Eventf(manifestWorkReplicaSet, nil, corev1.EventTypeWarning, ReasonManifestWorksSkipped,
+				"FinalizeManifestWorkReplicaSet", "Skipped %d manifestworks on unavailable or deleted clusters%s, "+
+					"the manifestworks are %s", len(skipped), clustersString(skipped), action)
+		}
+	}
+
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if requeueAfter == 0 || requeueAfter > finalizeRequeueInterval {
+		requeueAfter = finalizeRequeueInterval
+	}
+
+	message := fmt.Sprintf("Waiting for %d manifestworks to be deleted%s", len(pending), clustersString(pending))
+	if m.unavailableClusterTimeout > 0 {
+		message += fmt.Sprintf(", they are skipped after %s",
+			manifestWorkReplicaSet.DeletionTimestamp.Add(m.unavailableClusterTimeout).UTC().Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(&manifestWorkReplicaSet.Status.Conditions, metav1.Condition{
+		Type:    ManifestWorkReplicaSetConditionManifestWorksDeleting,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonWaitingForManifestWorks,
+		Message: message,
+	})
+	return &requeueError{
+		message: fmt.Sprintf("waiting for %d manifestworks to be deleted%s",
+			len(pending), clustersString(pending)),
+		requeueAfter: requeueAfter,
+	}
+}
+
+// isForegroundDeletion returns true if the manifestworks of the manifestWorkReplicaSet are created with the
+// Foreground delete option, so the manifestWorkReplicaSet waits for the manifestworks to be deleted. A nil delete
+// option is the Foreground delete option by default.
+func isForegroundDeletion(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	deleteOption := mwrSet.Spec.ManifestWorkTemplate.DeleteOption
+	return deleteOption == nil || deleteOption.PropagationPolicy == workapiv1.DeletePropagationPolicyTypeForeground
+}
+
+// isStuck returns true if the manifestwork is on a deleted cluster, on a cluster unavailable longer than the
+// timeout, or the manifestWorkReplicaSet has waited longer than the timeout. Otherwise, it returns the remaining
+// time before the manifestwork is stuck.
+func (m *finalizeReconciler) isStuck(mw *workapiv1.ManifestWork, waited time.Duration) (bool, time.Duration, error) {
+	cluster, err := m.clusterLister.Get(mw.Namespace)
+	switch {
+	case errors.IsNotFound(err):
+		return true, 0, nil
+	case err != nil:
+		return false, 0, err
+	}
+
+	if m.unavailableClusterTimeout <= 0 {
+		return false, 0, nil
+	}
+	remaining := m.unavailableClusterTimeout - waited
+	available := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	if available != nil && available.Status != metav1.ConditionTrue {
+		if unavailableRemaining := m.unavailableClusterTimeout - time.Since(available.LastTransitionTime.Time); unavailableRemaining < remaining {
+			remaining = unavailableRemaining
 		}
 	}
+	return remaining <= 0, remaining, nil
+}
+
+// skipManifestWork removes the finalizers of the stuck manifestwork if forceDeleteStuckWorks is true, otherwise
+// it labels the manifestwork for later cleanup.
+func (m *finalizeReconciler) skipManifestWork(ctx context.Context, mw *workapiv1.ManifestWork) error {
+	workPatcher := patcher.NewPatcher[
+		*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+		m.workClient.WorkV1().ManifestWorks(mw.Namespace))
 
-	return utilerrors.NewAggregate(errs)
+	if m.forceDeleteStuckWorks {
+		return workPatcher.RemoveFinalizer(ctx, mw, mw.Finalizers...)
+	}
+
+	newWork := mw.DeepCopy()
+	if newWork.Labels == nil {
+		newWork.Labels = map[string]string{}
+	}
+	newWork.Labels[CleanupPendingLabelKey] = "true"
+	_, err := workPatcher.PatchLabelAnnotations(ctx, newWork, newWork.ObjectMeta, mw.ObjectMeta)
+	return err
 }
@@ -2,15 +2,23 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kevents "k8s.io/client-go/tools/events"
 
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1 "open-cluster-management.io/api/work/v1"
 
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
 )
@@ -49,3 +57,179 @@ func TestFinalizeReconcile(t *testing.T) {
 		t.Fatal("Finalizer not deleted", mwrSetTest.Finalizers)
 	}
 }
+
+func newFinalizeCluster(name string, status metav1.ConditionStatus, since time.Duration) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: clusterv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{{
+				Type:               clusterv1.ManagedClusterConditionAvailable,
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-since)),
+			}},
+		},
+	}
+}
+
+func TestFinalizeReconcileWithForegroundDeletion(t *testing.T) {
+	cases := []struct {
+		name                  string
+		clusters              []*clusterv1.ManagedCluster
+		deleteOption          *workapiv1.DeleteOption
+		deletedSince          time.Duration
+		forceDeleteStuckWorks bool
+		expectedRequeue       bool
+		expectedSkipped       bool
+	}{
+		{
+			name:            "wait for the manifestwork on an available cluster",
+			clusters:        []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionTrue, 2*time.Hour)},
+			expectedRequeue: true,
+		},
+		{
+			name:     "wait for the manifestwork with the Foreground delete option",
+			clusters: []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionTrue, 2*time.Hour)},
+			deleteOption: &workapiv1.DeleteOption{
+				PropagationPolicy: workapiv1.DeletePropagationPolicyTypeForeground,
+			},
+			expectedRequeue: true,
+		},
+		{
+			name:     "not wait for the manifestwork with the orphan delete option",
+			clusters: []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionTrue, 2*time.Hour)},
+			deleteOption: &workapiv1.DeleteOption{
+				PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan,
+			},
+		},
+		{
+			name:            "skip the manifestwork on an available cluster waited beyond the timeout",
+			clusters:        []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionTrue, 2*time.Hour)},
+			deletedSince:    2 * time.Hour,
+			expectedSkipped: true,
+		},
+		{
+			name:            "wait for the manifestwork on a cluster unavailable within the timeout",
+			clusters:        []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionUnknown, time.Minute)},
+			expectedRequeue: true,
+		},
+		{
+			name:            "skip the manifestwork on a cluster unavailable beyond the timeout",
+			clusters:        []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionUnknown, 2*time.Hour)},
+			expectedSkipped: true,
+		},
+		{
+			name:            "skip the manifestwork on a deleted cluster",
+			expectedSkipped: true,
+		},
+		{
+			name:                  "force delete the manifestwork on a deleted cluster",
+			forceDeleteStuckWorks: true,
+			expectedSkipped:       true,
+		},
+		{
+			name:                  "force delete the manifestwork on a cluster unavailable beyond the timeout",
+			clusters:              []*clusterv1.ManagedCluster{newFinalizeCluster("cluster1", metav1.ConditionFalse, 2*time.Hour)},
+			forceDeleteStuckWorks: true,
+			expectedSkipped:       true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			// a nil delete option is the Foreground delete option by default
+			mwrSet.Spec.ManifestWorkTemplate.DeleteOption = c.deleteOption
+			now := metav1.Now()
+			deletedAt := metav1.NewTime(now.Add(-c.deletedSince))
+			mwrSet.DeletionTimestamp = &deletedAt
+			mwrSet.Finalizers = []string{ManifestWorkReplicaSetFinalizer}
+			mw, _ := CreateManifestWork(mwrSet, "cluster1")
+			mw.DeletionTimestamp = &now
+			mw.Finalizers = []string{"cluster.open-cluster-management.io/manifest-work-cleanup"}
+
+			fakeClient := fakeclient.NewSimpleClientset(mwrSet, mw)
+			workInformerFactory := workinformers.NewSharedInformerFactory(fakeClient, 10*time.Minute)
+			if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+				t.Fatal(err)
+			}
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 10*time.Minute)
+			for _, cluster := range c.clusters {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+			recorder := kevents.NewFakeRecorder(10)
+
+			finalizerController := finalizeReconciler{
+				workClient:                fakeClient,
+				manifestWorkLister:        mwLister,
+				workApplier:               workapplier.NewWorkApplierWithTypedClient(fakeClient, mwLister),
+				clusterLister:             clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				recorder:                  recorder,
+				unavailableClusterTimeout: time.Hour,
+				forceDeleteStuckWorks:     c.forceDeleteStuckWorks,
+			}
+
+			_, state, err := finalizerController.reconcile(context.TODO(), mwrSet)
+			var rqe *requeueError
+			if c.expectedRequeue != errors.As(err, &rqe) {
+				t.Fatalf("expected requeue %t, but got %v", c.expectedRequeue, err)
+			}
+			if !c.expectedRequeue && err != nil {
+				t.Fatal(err)
+			}
+			if state != reconcileStop {
+				t.Errorf("expected the reconcile to stop")
+			}
+
+			updatedSet, err := fakeClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Get(
+				context.TODO(), mwrSet.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.expectedRequeue != slices.Contains(updatedSet.Finalizers, ManifestWorkReplicaSetFinalizer) {
+				t.Errorf("expected the finalizer kept %t, but got %v", c.expectedRequeue, updatedSet.Finalizers)
+			}
+			cond := meta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionManifestWorksDeleting)
+			if c.expectedRequeue != (cond != nil) {
+				t.Errorf("expected the ManifestWorksDeleting condition set %t, but got %v", c.expectedRequeue, cond)
+			}
+			if cond != nil && !strings.Contains(cond.Message, "(cluster1)") {
+				t.Errorf("expected the pending cluster in the condition message, but got %q", cond.Message)
+			}
+
+			updatedWork, err := fakeClient.WorkV1().ManifestWorks(mw.Namespace).Get(context.TODO(), mw.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case !c.expectedSkipped:
+				for _, action := range fakeClient.Actions() {
+					if action.Matches("patch", "manifestworks") {
+						t.Errorf("expected the manifestwork not patched, but got %v", action)
+					}
+				}
+			case c.forceDeleteStuckWorks:
+				if len(updatedWork.Finalizers) != 0 {
+					t.Errorf("expected the finalizers of the manifestwork removed, but got %v", updatedWork.Finalizers)
+				}
+			default:
+				if updatedWork.Labels[CleanupPendingLabelKey] != "true" || len(updatedWork.Finalizers) == 0 {
+					t.Errorf("expected the manifestwork labeled with its finalizers kept, but got %v", updatedWork.ObjectMeta)
+				}
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if !c.expectedSkipped || !strings.Contains(event, "(cluster1)") {
+					t.Errorf("unexpected event %s", event)
+				}
+			default:
+				if c.expectedSkipped {
+					t.Errorf("expected an event of the skipped clusters")
+				}
+			}
+		})
+	}
+}
@@ -6,15 +6,23 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
-	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 )
 
 const (
-	manifestWorkReplicaSetByPlacement = "manifestWorkReplicaSetByPlacement"
+	manifestWorkReplicaSetByPlacement      = "manifestWorkReplicaSetByPlacement"
+	manifestWorkReplicaSetByTemplateValues = "manifestWorkReplicaSetByTemplateValues"
+	manifestWorkReplicaSetByMaintenance    = "manifestWorkReplicaSetByMaintenance"
 )
 
 func (m *ManifestWorkReplicaSetController) placementQueueKeysFunc(obj runtime.Object) []string {
@@ -42,12 +50,12 @@ func (m *ManifestWorkReplicaSetController) placementQueueKeysFunc(obj runtime.Ob
 
 func (m *ManifestWorkReplicaSetController) placementDecisionQueueKeysFunc(obj runtime.Object) []string {
 	accessor, _ := meta.Accessor(obj)
-	placementName, ok := accessor.GetLabels()[clusterv1beta1.PlacementLabel]
+	placementKey, ok := placementhelpers.PlacementKeyOfDecision(obj)
 	if !ok {
 		return []string{}
 	}
 
-	objs, err := m.manifestWorkReplicaSetIndexer.ByIndex(manifestWorkReplicaSetByPlacement, fmt.Sprintf("%s/%s", accessor.GetNamespace(), placementName))
+	objs, err := m.manifestWorkReplicaSetIndexer.ByIndex(manifestWorkReplicaSetByPlacement, placementKey)
 	if err != nil {
 		utilruntime.HandleError(err)
 		return []string{}
@@ -64,6 +72,116 @@ func (m *ManifestWorkReplicaSetController) placementDecisionQueueKeysFunc(obj ru
 	return keys
 }
 
+// templateValuesQueueKeysFunc enqueues the manifestWorkReplicaSets referring to the name of the template values
+// ConfigMap. The manifestworks of the other clusters are rendered the same, so only the manifestwork in the
+// namespace of the ConfigMap is updated.
+func (m *ManifestWorkReplicaSetController) templateValuesQueueKeysFunc(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	objs, err := m.manifestWorkReplicaSetIndexer.ByIndex(manifestWorkReplicaSetByTemplateValues, accessor.GetName())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	var keys []string
+	for _, o := range objs {
+		manifestWorkReplicaSet := o.(*workapiv1alpha1.ManifestWorkReplicaSet)
+		klog.V(4).Infof("enqueue manifestWorkReplicaSet %s/%s, because of template values %s/%s",
+			manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name, accessor.GetNamespace(), accessor.GetName())
+		keys = append(keys, fmt.Sprintf("%s/%s", manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name))
+	}
+
+	return keys
+}
+
+// clusterEventHandler enqueues the manifestWorkReplicaSets related to a cluster entering or exiting maintenance,
+// since their manifestworks on the cluster may be changed without any change of the placement decisions.
+func (m *ManifestWorkReplicaSetController) clusterEventHandler(queue workqueue.Interface) cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		for _, key := range m.clusterQueueKeysFunc(obj) {
+			queue.Add(key)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCluster, ok := oldObj.(*clusterv1.ManagedCluster)
+			if !ok {
+				return
+			}
+			newCluster, ok := newObj.(*clusterv1.ManagedCluster)
+			if !ok {
+				return
+			}
+			if registrationhelpers.IsClusterInMaintenance(oldCluster) != registrationhelpers.IsClusterInMaintenance(newCluster) {
+				enqueue(newCluster)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cluster, ok := obj.(*clusterv1.ManagedCluster); ok && registrationhelpers.IsClusterInMaintenance(cluster) {
+				enqueue(cluster)
+			}
+		},
+	}
+}
+
+// clusterQueueKeysFunc returns the manifestWorkReplicaSets with a maintenance policy, whose placements select the
+// cluster or whose manifestwork is on the cluster.
+func (m *ManifestWorkReplicaSetController) clusterQueueKeysFunc(obj interface{}) []string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return []string{}
+	}
+	clusterName := accessor.GetName()
+
+	var keys []string
+	for _, policy := range []string{MaintenancePolicyRemove, MaintenancePolicyRetain} {
+		objs, err := m.manifestWorkReplicaSetIndexer.ByIndex(manifestWorkReplicaSetByMaintenance, policy)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return []string{}
+		}
+
+		for _, o := range objs {
+			manifestWorkReplicaSet := o.(*workapiv1alpha1.ManifestWorkReplicaSet)
+			if !m.relatedToCluster(manifestWorkReplicaSet, clusterName) {
+				continue
+			}
+			klog.V(4).Infof("enqueue manifestWorkReplicaSet %s/%s, because of cluster %s",
+				manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name, clusterName)
+			keys = append(keys, fmt.Sprintf("%s/%s", manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name))
+		}
+	}
+
+	return keys
+}
+
+// relatedToCluster returns true if the manifestWorkReplicaSet has a manifestwork on the cluster, or any of its
+// placements selects the cluster.
+func (m *ManifestWorkReplicaSetController) relatedToCluster(
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterName string) bool {
+	mw, err := m.manifestWorkLister.ManifestWorks(clusterName).Get(mwrSet.Name)
+	if err == nil && mw.Labels[ManifestWorkReplicaSetControllerNameLabelKey] == manifestWorkReplicaSetKey(mwrSet) {
+		return true
+	}
+
+	for _, placementRef := range mwrSet.Spec.PlacementRefs {
+		clusters, err := m.placementDecisionTracker.Clusters(
+			helper.PlacementRefNamespace(mwrSet, placementRef.Name), placementRef.Name)
+		if err != nil {
+			// the placement is not found or its decisions are not consistent yet, the manifestWorkReplicaSet is
+			// enqueued by the events of the placement decisions.
+			continue
+		}
+		if clusters.Has(clusterName) {
+			return true
+		}
+	}
+	return false
+}
+
 // we will generate manifestwork with a label
 func (m *ManifestWorkReplicaSetController) manifestWorkQueueKeyFunc(obj runtime.Object) string {
 	accessor, _ := meta.Accessor(obj)
@@ -81,13 +199,40 @@ func indexManifestWorkReplicaSetByPlacement(obj interface{}) ([]string, error) {
 		return []string{}, fmt.Errorf("obj %T is not a ManifestWorkReplicaSet", obj)
 	}
 
-	var keys []string
+	// a placement referenced more than once is indexed only once
+	keys := sets.New[string]()
 	for _, placementRef := range manifestWorkReplicaSet.Spec.PlacementRefs {
-		key := fmt.Sprintf("%s/%s", manifestWorkReplicaSet.Namespace, placementRef.Name)
-		keys = append(keys, key)
+		key := fmt.Sprintf("%s/%s", helper.PlacementRefNamespace(manifestWorkReplicaSet, placementRef.Name), placementRef.Name)
+		keys.Insert(key)
+	}
+
+	return sets.List(keys), nil
+}
+
+func indexManifestWorkReplicaSetByTemplateValues(obj interface{}) ([]string, error) {
+	manifestWorkReplicaSet, ok := obj.(*workapiv1alpha1.ManifestWorkReplicaSet)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not a ManifestWorkReplicaSet", obj)
 	}
 
-	return keys, nil
+	configMapName, ok := manifestWorkReplicaSet.Annotations[TemplateValuesConfigMapAnnotationKey]
+	if !ok || len(configMapName) == 0 {
+		return []string{}, nil
+	}
+	return []string{configMapName}, nil
+}
+
+func indexManifestWorkReplicaSetByMaintenance(obj interface{}) ([]string, error) {
+	manifestWorkReplicaSet, ok := obj.(*workapiv1alpha1.ManifestWorkReplicaSet)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not a ManifestWorkReplicaSet", obj)
+	}
+
+	policy, ok := manifestWorkReplicaSet.Annotations[MaintenancePolicyAnnotationKey]
+	if !ok || len(policy) == 0 {
+		return []string{}, nil
+	}
+	return []string{policy}, nil
 }
 
 // manifestWorkReplicaSetKey return the value of the key of manifestworkreplicaset, and comply with
@@ -1,17 +1,26 @@
 package manifestworkreplicasetcontroller
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	registrationhelpers "open-cluster-management.io/ocm/pkg/registration/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
 )
 
@@ -62,7 +71,7 @@ func TestPlaceMWControllerIndex(t *testing.T) {
 				workClient: fWorkClient, manifestWorkLister: mwLister},
 			&addFinalizerReconciler{workClient: fWorkClient},
 			&deployReconciler{workApplier: workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
-				manifestWorkLister: mwLister, placementLister: placementLister, placeDecisionLister: placementDecisionLister},
+				manifestWorkLister: mwLister, placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(placementLister, placementDecisionLister)},
 			&statusReconciler{manifestWorkLister: mwLister},
 		},
 	}
@@ -121,3 +130,222 @@ func TestPlaceMWControllerIndex(t *testing.T) {
 		t.Fatal("Expected manifestwork key should not exist ", key)
 	}
 }
+
+func TestPlaceMWControllerIndexCrossNamespace(t *testing.T) {
+	mwrSetLocal := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-local", "default", "place-test")
+	mwrSetShared := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-shared", "default", "place-test")
+	mwrSetShared.Annotations = map[string]string{helper.PlacementRefNamespacesAnnotation: `{"place-test": "shared"}`}
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSetLocal, mwrSetShared)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+
+	err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().AddIndexers(
+		cache.Indexers{manifestWorkReplicaSetByPlacement: indexManifestWorkReplicaSetByPlacement})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mwrSet := range []*workapiv1alpha1.ManifestWorkReplicaSet{mwrSetLocal, mwrSetShared} {
+		if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwController := &ManifestWorkReplicaSetController{
+		workClient:                    fWorkClient,
+		manifestWorkReplicaSetLister:  workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+		manifestWorkReplicaSetIndexer: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetIndexer(),
+	}
+
+	placementKey, err := indexManifestWorkReplicaSetByPlacement(mwrSetShared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(placementKey, []string{"shared/place-test"}) {
+		t.Fatal("placement Key not match ", placementKey)
+	}
+
+	// decision updates in the shared namespace enqueue only the manifestWorkReplicaSet referencing it
+	sharedPlacement, sharedDecision := helpertest.CreateTestPlacement("place-test", "shared", "cls1")
+	keys := pmwController.placementDecisionQueueKeysFunc(sharedDecision)
+	if !reflect.DeepEqual(keys, []string{"default/mwrSet-shared"}) {
+		t.Fatal("Expected placementDecision keys not match ", keys)
+	}
+	keys = pmwController.placementQueueKeysFunc(sharedPlacement)
+	if !reflect.DeepEqual(keys, []string{"default/mwrSet-shared"}) {
+		t.Fatal("Expected placement keys not match ", keys)
+	}
+
+	// decision updates in the local namespace enqueue only the manifestWorkReplicaSet referencing it
+	_, localDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1")
+	keys = pmwController.placementDecisionQueueKeysFunc(localDecision)
+	if !reflect.DeepEqual(keys, []string{"default/mwrSet-local"}) {
+		t.Fatal("Expected placementDecision keys not match ", keys)
+	}
+}
+
+func TestPlaceMWControllerIndexMultiplePlacements(t *testing.T) {
+	mwrSetBoth := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-both", "default", "prod")
+	mwrSetBoth.Spec.PlacementRefs = append(mwrSetBoth.Spec.PlacementRefs,
+		workapiv1alpha1.LocalPlacementReference{Name: "canary"}, workapiv1alpha1.LocalPlacementReference{Name: "prod"})
+	mwrSetCanary := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-canary", "default", "canary")
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSetBoth, mwrSetCanary)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+
+	err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().AddIndexers(
+		cache.Indexers{manifestWorkReplicaSetByPlacement: indexManifestWorkReplicaSetByPlacement})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mwrSet := range []*workapiv1alpha1.ManifestWorkReplicaSet{mwrSetBoth, mwrSetCanary} {
+		if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwController := &ManifestWorkReplicaSetController{
+		workClient:                    fWorkClient,
+		manifestWorkReplicaSetLister:  workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+		manifestWorkReplicaSetIndexer: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetIndexer(),
+	}
+
+	// the placement referenced twice is indexed once
+	placementKey, err := indexManifestWorkReplicaSetByPlacement(mwrSetBoth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(placementKey, []string{"default/canary", "default/prod"}) {
+		t.Fatal("placement Key not match ", placementKey)
+	}
+
+	cases := map[string][]string{
+		"prod":   {"default/mwrSet-both"},
+		"canary": {"default/mwrSet-both", "default/mwrSet-canary"},
+	}
+	for name, expectedKeys := range cases {
+		placement, decision := helpertest.CreateTestPlacement(name, "default", "cls1")
+		keys := pmwController.placementQueueKeysFunc(placement)
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, expectedKeys) {
+			t.Errorf("Expected placement %s keys %v, but got %v", name, expectedKeys, keys)
+		}
+		keys = pmwController.placementDecisionQueueKeysFunc(decision)
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, expectedKeys) {
+			t.Errorf("Expected placementDecision %s keys %v, but got %v", name, expectedKeys, keys)
+		}
+	}
+}
+
+func TestClusterEventHandler(t *testing.T) {
+	newMWRSet := func(name, placementName, policy string) *workapiv1alpha1.ManifestWorkReplicaSet {
+		mwrSet := helpertest.CreateTestManifestWorkReplicaSet(name, "default", placementName)
+		if len(policy) > 0 {
+			mwrSet.Annotations = map[string]string{MaintenancePolicyAnnotationKey: policy}
+		}
+		return mwrSet
+	}
+	// the placement of remove selects cls1, the manifestwork of retain is retained on cls1 which is not selected by
+	// its placement any more, and the others are not related to cls1 or have no maintenance policy.
+	mwrSets := []*workapiv1alpha1.ManifestWorkReplicaSet{
+		newMWRSet("remove", "place1", MaintenancePolicyRemove),
+		newMWRSet("retain", "place2", MaintenancePolicyRetain),
+		newMWRSet("other", "place2", MaintenancePolicyRemove),
+		newMWRSet("nopolicy", "place1", ""),
+	}
+	retainedWork, err := CreateManifestWork(mwrSets[1], "cls1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workInformerFactory := workinformers.NewSharedInformerFactory(fakeworkclient.NewSimpleClientset(), 0)
+	mwrSetInformer := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets()
+	if err := mwrSetInformer.Informer().AddIndexers(cache.Indexers{
+		manifestWorkReplicaSetByMaintenance: indexManifestWorkReplicaSetByMaintenance,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, mwrSet := range mwrSets {
+		if err := mwrSetInformer.Informer().GetStore().Add(mwrSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(retainedWork); err != nil {
+		t.Fatal(err)
+	}
+
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 0)
+	for name, clusters := range map[string][]string{"place1": {"cls1", "cls2"}, "place2": {"cls2"}} {
+		placement, decision := helpertest.CreateTestPlacement(name, "default", clusters...)
+		if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(decision); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	controller := &ManifestWorkReplicaSetController{
+		manifestWorkReplicaSetIndexer: mwrSetInformer.Informer().GetIndexer(),
+		manifestWorkLister:            workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+	}
+
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cls1"}}
+	clusterInMaintenance := cluster.DeepCopy()
+	clusterInMaintenance.Spec.Taints = []clusterv1.Taint{
+		{Key: registrationhelpers.ManagedClusterTaintMaintenance, Effect: clusterv1.TaintEffectNoSelect},
+	}
+	clusterWithStatus := cluster.DeepCopy()
+	clusterWithStatus.Status.Version.Kubernetes = "v1.27.0"
+
+	cases := []struct {
+		name         string
+		event        func(handler cache.ResourceEventHandler)
+		expectedKeys []string
+	}{
+		{
+			name:  "cluster status updated",
+			event: func(handler cache.ResourceEventHandler) { handler.OnUpdate(cluster, clusterWithStatus) },
+		},
+		{
+			name:         "cluster enters maintenance",
+			event:        func(handler cache.ResourceEventHandler) { handler.OnUpdate(cluster, clusterInMaintenance) },
+			expectedKeys: []string{"default/remove", "default/retain"},
+		},
+		{
+			name:         "cluster exits maintenance",
+			event:        func(handler cache.ResourceEventHandler) { handler.OnUpdate(clusterInMaintenance, cluster) },
+			expectedKeys: []string{"default/remove", "default/retain"},
+		},
+		{
+			name: "cluster in maintenance deleted",
+			event: func(handler cache.ResourceEventHandler) {
+				handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "cls1", Obj: clusterInMaintenance})
+			},
+			expectedKeys: []string{"default/remove", "default/retain"},
+		},
+		{
+			name:  "cluster not in maintenance deleted",
+			event: func(handler cache.ResourceEventHandler) { handler.OnDelete(cluster) },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			queue := workqueue.New()
+			defer queue.ShutDown()
+			c.event(controller.clusterEventHandler(queue))
+
+			var keys []string
+			for queue.Len() > 0 {
+				key, _ := queue.Get()
+				keys = append(keys, key.(string))
+				queue.Done(key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, c.expectedKeys) {
+				t.Errorf("expected keys %v, but got %v", c.expectedKeys, keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package manifestworkreplicasetcontroller
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+const (
+	// MaintenancePolicyAnnotationKey is the annotation on a ManifestWorkReplicaSet to decide how the manifestworks
+	// on the clusters in maintenance are handled. Without it, the manifestworks follow the placement decisions,
+	// which drain the clusters in maintenance according to the tolerations of the placements.
+	// TODO move this to the api repo
+	MaintenancePolicyAnnotationKey = "work.open-cluster-management.io/maintenance-policy"
+
+	// MaintenancePolicyRemove removes the manifestworks on the clusters once they are in maintenance, even if the
+	// clusters are still in the placement decisions.
+	MaintenancePolicyRemove = "Remove"
+
+	// MaintenancePolicyRetain leaves the manifestworks on the clusters in maintenance until the clusters return,
+	// even if the clusters are drained from the placement decisions. The retained manifestworks are not updated.
+	MaintenancePolicyRetain = "Retain"
+)
+
+// applyMaintenancePolicy returns the clusters the manifestworks are expected on according to the maintenance
+// policy of the manifestWorkReplicaSet, and the clusters in maintenance whose manifestworks are retained.
+func applyMaintenancePolicy(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterLister clusterlisterv1.ManagedClusterLister,
+	decisionClusters, existingClusters sets.Set[string]) (sets.Set[string], sets.Set[string], error) {
+	policy := mwrSet.Annotations[MaintenancePolicyAnnotationKey]
+	if policy != MaintenancePolicyRemove && policy != MaintenancePolicyRetain {
+		return decisionClusters, sets.New[string](), nil
+	}
+
+	inMaintenance := func(clusterName string) (bool, error) {
+		cluster, err := clusterLister.Get(clusterName)
+		switch {
+		case apierrors.IsNotFound(err):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+		return helpers.IsClusterInMaintenance(cluster), nil
+	}
+
+	expectedClusters := decisionClusters.Clone()
+	retainedClusters := sets.New[string]()
+	switch policy {
+	case MaintenancePolicyRemove:
+		for cls := range decisionClusters {
+			maintenance, err := inMaintenance(cls)
+			if err != nil {
+				return nil, nil, err
+			}
+			if maintenance {
+				expectedClusters.Delete(cls)
+			}
+		}
+	case MaintenancePolicyRetain:
+		for cls := range existingClusters.Difference(decisionClusters) {
+			maintenance, err := inMaintenance(cls)
+			if err != nil {
+				return nil, nil, err
+			}
+			if maintenance {
+				expectedClusters.Insert(cls)
+				retainedClusters.Insert(cls)
+			}
+		}
+	}
+	return expectedClusters, retainedClusters, nil
+}
@@ -0,0 +1,138 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newMaintenanceCluster(name string, maintenance bool) *clusterv1.ManagedCluster {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	if maintenance {
+		helpers.SetMaintenanceTaint(&cluster.Spec.Taints, metav1.Now())
+	}
+	return cluster
+}
+
+func TestDeployReconcileMaintenancePolicy(t *testing.T) {
+	cases := []struct {
+		name             string
+		policy           string
+		expectedActions  []string
+		expectedDeleted  []string
+		expectedSumTotal int
+	}{
+		{
+			// cls3 is drained from the decision, cls1 is still in the decision since the taint is tolerated
+			name:             "follow the decisions",
+			expectedActions:  []string{"delete"},
+			expectedDeleted:  []string{"cls3"},
+			expectedSumTotal: 2,
+		},
+		{
+			name:             "remove the manifestworks on the clusters in maintenance",
+			policy:           MaintenancePolicyRemove,
+			expectedActions:  []string{"delete", "delete"},
+			expectedDeleted:  []string{"cls1", "cls3"},
+			expectedSumTotal: 1,
+		},
+		{
+			name:             "retain the manifestworks on the clusters in maintenance",
+			policy:           MaintenancePolicyRetain,
+			expectedSumTotal: 3,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			if len(c.policy) > 0 {
+				mwrSet.Annotations = map[string]string{MaintenancePolicyAnnotationKey: c.policy}
+			}
+
+			var works []runtime.Object
+			for _, cls := range []string{"cls1", "cls2", "cls3"} {
+				mw, _ := CreateManifestWork(mwrSet, cls)
+				works = append(works, mw)
+			}
+			fWorkClient := fakeworkclient.NewSimpleClientset(works...)
+			workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+			for _, mw := range works {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+			placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+				fakeclusterclient.NewSimpleClientset(), 1*time.Minute)
+			clusterObjs := []runtime.Object{
+				placement, placementDecision,
+				newMaintenanceCluster("cls1", true),
+				newMaintenanceCluster("cls2", false),
+				newMaintenanceCluster("cls3", true),
+			}
+			for _, obj := range clusterObjs {
+				var err error
+				switch obj.(type) {
+				case *clusterv1.ManagedCluster:
+					err = clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(obj)
+				case *clusterv1beta1.Placement:
+					err = clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(obj)
+				case *clusterv1beta1.PlacementDecision:
+					err = clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(obj)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reconciler := deployReconciler{
+				workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+				manifestWorkLister: mwLister,
+				placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+					clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+					clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+
+			mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actions := fWorkClient.Actions()
+			testingcommon.AssertActions(t, actions, c.expectedActions...)
+			deleted := sets.New[string]()
+			for _, action := range actions {
+				deleted.Insert(action.GetNamespace())
+			}
+			if !deleted.Equal(sets.New[string](c.expectedDeleted...)) {
+				t.Errorf("expected the manifestworks on %v deleted, but got %v", c.expectedDeleted, sets.List(deleted))
+			}
+			if mwrSet.Status.Summary.Total != c.expectedSumTotal {
+				t.Errorf("expected total %d, but got %d", c.expectedSumTotal, mwrSet.Status.Summary.Total)
+			}
+		})
+	}
+}
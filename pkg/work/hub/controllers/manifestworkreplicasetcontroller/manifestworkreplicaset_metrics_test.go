@@ -0,0 +1,135 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/hub/metrics"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestManifestWorkReplicaSetMetrics(t *testing.T) {
+	metrics.Register()
+
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("metrics", "default", "placement")
+	mwrSet.Finalizers = []string{ManifestWorkReplicaSetFinalizer}
+	// the manifestwork on cluster1 is deleted, and the manifestworks on cluster2 and cluster3 are created
+	works := helpertest.CreateTestManifestWorks("metrics", "default", "cluster1")
+	placement, decision := helpertest.CreateTestPlacement("placement", "default", "cluster2", "cluster3")
+
+	fakeClient := fakeworkclient.NewSimpleClientset(append([]runtime.Object{mwrSet}, works...)...)
+	workInformers := workinformers.NewSharedInformerFactory(fakeClient, 10*time.Minute)
+	if err := workInformers.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	for _, work := range works {
+		if err := workInformers.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clusterInformers := clusterinformers.NewSharedInformerFactory(
+		fakeclusterclient.NewSimpleClientset(placement, decision), 10*time.Minute)
+	if err := clusterInformers.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformers.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(decision); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeKubeClient := fakekube.NewSimpleClientset()
+	kubeInformers := kubeinformers.NewSharedInformerFactory(fakeKubeClient, 10*time.Minute)
+
+	ctrl := newController(
+		fakeClient,
+		fakeKubeClient.CoreV1(),
+		fakeKubeClient.AuthorizationV1(),
+		kevents.NewFakeRecorder(100),
+		workInformers.Work().V1alpha1().ManifestWorkReplicaSets(),
+		workInformers.Work().V1().ManifestWorks(),
+		kubeInformers.Core().V1().ConfigMaps(),
+		kubeInformers.Core().V1().ConfigMaps(),
+		clusterInformers.Cluster().V1beta1().Placements(),
+		clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		0,
+		false,
+		0,
+		false,
+		0,
+		0,
+		false,
+		0,
+		nil,
+	)
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "default/metrics")); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	var syncCount uint64
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch family.GetName() {
+			case "work_manifestworkreplicaset_sync_duration_seconds":
+				if testutil.LabelsMatch(metric, map[string]string{"result": metrics.SyncResultSuccess}) {
+					syncCount = metric.GetHistogram().GetSampleCount()
+				}
+			case "work_manifestworkreplicaset_manifestwork_operations_total":
+				if testutil.LabelsMatch(metric, map[string]string{"namespace": "default", "name": "metrics"}) {
+					for _, label := range metric.GetLabel() {
+						if label.GetName() == "operation" {
+							values[label.GetValue()] = metric.GetCounter().GetValue()
+						}
+					}
+				}
+			case "work_manifestworkreplicaset_target_clusters", "work_manifestworkreplicaset_not_available_manifestworks":
+				if testutil.LabelsMatch(metric, map[string]string{"namespace": "default", "name": "metrics"}) {
+					values[family.GetName()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	expected := map[string]float64{
+		metrics.OperationCreated:                      2,
+		metrics.OperationDeleted:                      1,
+		"work_manifestworkreplicaset_target_clusters": 2,
+		// the available manifestwork on cluster1 is not removed from the cache yet
+		"work_manifestworkreplicaset_not_available_manifestworks": 1,
+	}
+	for name, value := range expected {
+		if actual, ok := values[name]; !ok || actual != value {
+			t.Errorf("expected %s to be %v, but got %v", name, value, values)
+		}
+	}
+	if syncCount == 0 {
+		t.Errorf("expected the sync duration observed")
+	}
+
+	// the series are deleted with the manifestworkreplicaset
+	metrics.DeleteManifestWorkReplicaSetMetrics("default", "metrics")
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(""),
+		"work_manifestworkreplicaset_target_clusters"); err != nil {
+		t.Errorf("expected the series deleted: %v", err)
+	}
+}
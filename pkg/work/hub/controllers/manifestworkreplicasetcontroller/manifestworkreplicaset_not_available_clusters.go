@@ -0,0 +1,121 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"encoding/json"
+	"sort"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// NotAvailableClustersAnnotationKey is the annotation on a ManifestWorkReplicaSet set by the controller with the
+	// status of the manifestworks not available in json, since the status of the ManifestWorkReplicaSet only has
+	// the counts. At most the max number of clusters configured on the controller are listed, ordered by the
+	// cluster name, and the rest are only counted, so the ManifestWorkReplicaSet stays small with thousands of
+	// clusters. The annotation is removed once all the manifestworks are available.
+	// TODO move this to the api repo
+	NotAvailableClustersAnnotationKey = "work.open-cluster-management.io/not-available-clusters"
+
+	// DefaultMaxNotAvailableClusters is the default max number of clusters listed in the
+	// NotAvailableClustersAnnotationKey annotation.
+	DefaultMaxNotAvailableClusters = 20
+)
+
+// notAvailableClusters is the status of the manifestworks not available of a ManifestWorkReplicaSet.
+type notAvailableClusters struct {
+	Clusters []notAvailableCluster `json:"clusters"`
+	// Omitted is the counts of the clusters not listed.
+	Omitted *omittedNotAvailableClusters `json:"omitted,omitempty"`
+}
+
+// notAvailableCluster is the status of the manifestwork on a cluster, it is the same as the status detail.
+type notAvailableCluster struct {
+	Name string `json:"name"`
+	clusterStatusDetail
+}
+
+type omittedNotAvailableClusters struct {
+	Total      int `json:"total"`
+	NotApplied int `json:"notApplied,omitempty"`
+	Degraded   int `json:"degraded,omitempty"`
+}
+
+// manifestWorkConditions is whether each condition of a manifestwork is true, the conditions are only scanned
+// once for a manifestwork.
+type manifestWorkConditions struct {
+	applied     bool
+	available   bool
+	degraded    bool
+	progressing bool
+}
+
+func newManifestWorkConditions(mw *workapiv1.ManifestWork) manifestWorkConditions {
+	conditions := manifestWorkConditions{}
+	for i := range mw.Status.Conditions {
+		cond := &mw.Status.Conditions[i]
+		if cond.Status != "True" {
+			continue
+		}
+		switch cond.Type {
+		case workapiv1.WorkApplied:
+			conditions.applied = true
+		case workapiv1.WorkAvailable:
+			conditions.available = true
+		case workapiv1.WorkDegraded:
+			conditions.degraded = true
+		case workapiv1.WorkProgressing:
+			conditions.progressing = true
+		}
+	}
+	return conditions
+}
+
+// updateNotAvailableClusters sets the NotAvailableClustersAnnotationKey annotation with the manifestworks not
+// available, at most maxClusters of them are listed. The annotation is removed if all the manifestworks are
+// available or maxClusters is 0.
+func updateNotAvailableClusters(annotations map[string]string, notAvailable []*workapiv1.ManifestWork,
+	maxClusters int) (map[string]string, error) {
+	if len(notAvailable) == 0 || maxClusters <= 0 {
+		delete(annotations, NotAvailableClustersAnnotationKey)
+		return annotations, nil
+	}
+
+	// only the manifestworks are sorted, the status of the listed ones is read then
+	sort.Slice(notAvailable, func(i, j int) bool {
+		return notAvailable[i].Namespace < notAvailable[j].Namespace
+	})
+	listed := notAvailable
+	if len(listed) > maxClusters {
+		listed = listed[:maxClusters]
+	}
+
+	summary := notAvailableClusters{Clusters: make([]notAvailableCluster, 0, len(listed))}
+	for _, mw := range listed {
+		summary.Clusters = append(summary.Clusters, notAvailableCluster{
+			Name:                mw.Namespace,
+			clusterStatusDetail: newClusterStatusDetail(mw),
+		})
+	}
+	if omitted := notAvailable[len(listed):]; len(omitted) > 0 {
+		summary.Omitted = &omittedNotAvailableClusters{Total: len(omitted)}
+		for _, mw := range omitted {
+			conditions := newManifestWorkConditions(mw)
+			if !conditions.applied {
+				summary.Omitted.NotApplied++
+			}
+			if conditions.degraded {
+				summary.Omitted.Degraded++
+			}
+		}
+	}
+
+	value, err := json.Marshal(summary)
+	if err != nil {
+		return annotations, err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[NotAvailableClustersAnnotationKey] = string(value)
+	return annotations, nil
+}
@@ -0,0 +1,58 @@
+package manifestworkreplicasetcontroller
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+const (
+	// ManifestWorkReplicaSetConditionClustersSkipped is the condition type of a ManifestWorkReplicaSet whose
+	// placements select the observe-only clusters, which run no work agent. It is true and lists the skipped
+	// clusters, and is removed once no cluster is skipped.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionClustersSkipped = "ClustersSkipped"
+
+	// ReasonClustersObserveOnly is the reason of the ClustersSkipped condition when some clusters are observe-only.
+	ReasonClustersObserveOnly = "ObserveOnly"
+)
+
+// skipObserveOnlyClusters removes the observe-only clusters from the expected clusters, so no manifestwork is
+// created on them. The manifestworks created before a cluster is switched to observe-only are retained without
+// update, since the manifestworks could not be cleaned up without the work agent. It returns the message of each
+// skipped cluster.
+func skipObserveOnlyClusters(clusterLister clusterlisterv1.ManagedClusterLister,
+	expectedClusters, existingClusters, retainedClusters sets.Set[string]) (map[string]string, error) {
+	skipped := map[string]string{}
+	for cls := range expectedClusters {
+		cluster, err := clusterLister.Get(cls)
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		if !helpers.IsClusterObserveOnly(cluster) {
+			continue
+		}
+
+		if existingClusters.Has(cls) {
+			retainedClusters.Insert(cls)
+			skipped[cls] = "the cluster is observe-only, the existing manifestwork is not updated"
+		} else {
+			expectedClusters.Delete(cls)
+			skipped[cls] = "the cluster is observe-only"
+		}
+	}
+	return skipped, nil
+}
+
+// GetClustersSkipped returns the ClustersSkipped condition with the messages of the skipped clusters.
+func GetClustersSkipped(skipped map[string]string) metav1.Condition {
+	return getCondition(ManifestWorkReplicaSetConditionClustersSkipped,
+		ReasonClustersObserveOnly, clusterMessages(skipped), metav1.ConditionTrue)
+}
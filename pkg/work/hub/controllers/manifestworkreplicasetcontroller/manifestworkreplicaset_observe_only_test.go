@@ -0,0 +1,135 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newObserveOnlyCluster(name string, observeOnly bool) *clusterv1.ManagedCluster {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	if observeOnly {
+		cluster.Annotations = map[string]string{helpers.ObserveOnlyAnnotation: "true"}
+	}
+	return cluster
+}
+
+func TestDeployReconcileObserveOnlyClusters(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw, _ := CreateManifestWork(mwrSet, "cls1")
+	fWorkClient := fakeworkclient.NewSimpleClientset(mw)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	if err := mwStore.Add(mw); err != nil {
+		t.Fatal(err)
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+
+	reconciler := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+	}
+
+	steps := []struct {
+		name            string
+		observeOnly     map[string]bool
+		expectedActions []string
+		expectedTotal   int
+		expectedMessage string
+	}{
+		{
+			name:          "both clusters are observe-only",
+			observeOnly:   map[string]bool{"cls1": true, "cls2": true},
+			expectedTotal: 1,
+			expectedMessage: "cls1: the cluster is observe-only, the existing manifestwork is not updated; " +
+				"cls2: the cluster is observe-only",
+		},
+		{
+			name:            "cls2 is switched from observe-only",
+			observeOnly:     map[string]bool{"cls1": true, "cls2": false},
+			expectedActions: []string{"create"},
+			expectedTotal:   2,
+			expectedMessage: "cls1: the cluster is observe-only, the existing manifestwork is not updated",
+		},
+		{
+			name:          "cls1 is switched from observe-only",
+			observeOnly:   map[string]bool{"cls1": false, "cls2": false},
+			expectedTotal: 2,
+		},
+		{
+			name:            "cls2 is switched to observe-only",
+			observeOnly:     map[string]bool{"cls1": false, "cls2": true},
+			expectedTotal:   2,
+			expectedMessage: "cls2: the cluster is observe-only, the existing manifestwork is not updated",
+		},
+	}
+	for _, step := range steps {
+		for cls, observeOnly := range step.observeOnly {
+			if err := clusterStore.Update(newObserveOnlyCluster(cls, observeOnly)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fWorkClient.ClearActions()
+		var err error
+		mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testingcommon.AssertActions(t, fWorkClient.Actions(), step.expectedActions...)
+		for _, action := range fWorkClient.Actions() {
+			created, err := fWorkClient.WorkV1().ManifestWorks(action.GetNamespace()).Get(
+				context.TODO(), mwrSet.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mwStore.Add(created); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if mwrSet.Status.Summary.Total != step.expectedTotal {
+			t.Errorf("%s: expected total %d, but got %d", step.name, step.expectedTotal, mwrSet.Status.Summary.Total)
+		}
+		cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionClustersSkipped)
+		switch {
+		case len(step.expectedMessage) == 0 && cond != nil:
+			t.Errorf("%s: expected the ClustersSkipped condition removed, but got %v", step.name, cond)
+		case len(step.expectedMessage) > 0 && (cond == nil || cond.Reason != ReasonClustersObserveOnly ||
+			cond.Message != step.expectedMessage):
+			t.Errorf("%s: expected the ClustersSkipped condition %q, but got %v", step.name, step.expectedMessage, cond)
+		}
+	}
+}
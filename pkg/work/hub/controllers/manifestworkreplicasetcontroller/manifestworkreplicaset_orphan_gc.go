@@ -0,0 +1,182 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	workinformerv1alpha1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1alpha1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	worklisterv1alpha1 "open-cluster-management.io/api/client/work/listers/work/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+// ReasonOrphanedManifestWorkDeleted is the reason of the event recorded on each manifestwork deleted since its
+// ManifestWorkReplicaSet no longer exists.
+const ReasonOrphanedManifestWorkDeleted = "OrphanedManifestWorkDeleted"
+
+// ReasonCleanupPendingManifestWorkReleased is the reason of the event recorded on each manifestwork labeled with
+// CleanupPendingLabelKey whose finalizers are removed since its cluster no longer exists.
+const ReasonCleanupPendingManifestWorkReleased = "CleanupPendingManifestWorkReleased"
+
+// orphanGCController deletes the manifestworks whose ManifestWorkReplicaSet no longer exists periodically. They
+// are left if the finalizer of the ManifestWorkReplicaSet is removed manually, or the ManifestWorkReplicaSet is
+// removed by an etcd restore. It is conservative: the manifestworks with a label value not in the format of
+// namespace.name are skipped, and the ManifestWorkReplicaSet is verified to be gone on the apiserver rather than
+// the cache before the manifestwork is deleted.
+//
+// It also cleans up the manifestworks labeled with CleanupPendingLabelKey when their ManifestWorkReplicaSets are
+// finalized. Their finalizers are removed once the cluster is deleted, since no agent is left to delete them.
+type orphanGCController struct {
+	workClient                   workclientset.Interface
+	manifestWorkLister           worklisterv1.ManifestWorkLister
+	manifestWorkReplicaSetLister worklisterv1alpha1.ManifestWorkReplicaSetLister
+	clusterLister                clusterlisterv1.ManagedClusterLister
+	recorder                     kevents.EventRecorder
+}
+
+// NewOrphanGCController returns a controller deleting the orphaned manifestworks of the ManifestWorkReplicaSets
+// every interval. The manifestwork informer is expected to be filtered by the
+// ManifestWorkReplicaSetControllerNameLabelKey.
+func NewOrphanGCController(
+	recorder events.Recorder,
+	krecorder kevents.EventRecorder,
+	workClient workclientset.Interface,
+	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	interval time.Duration) factory.Controller {
+	c := &orphanGCController{
+		workClient:                   workClient,
+		manifestWorkLister:           manifestWorkInformer.Lister(),
+		manifestWorkReplicaSetLister: manifestWorkReplicaSetInformer.Lister(),
+		clusterLister:                clusterInformer.Lister(),
+		recorder:                     krecorder,
+	}
+
+	return factory.New().
+		WithBareInformers(manifestWorkInformer.Informer(), manifestWorkReplicaSetInformer.Informer(),
+			clusterInformer.Informer()).
+		WithSync(c.sync).
+		ResyncEvery(interval).
+		ToController("ManifestWorkReplicaSetOrphanGCController", recorder)
+}
+
+func (c *orphanGCController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	klog.V(4).Infof("Deleting the orphaned manifestworks of the ManifestWorkReplicaSets")
+
+	req, err := labels.NewRequirement(ManifestWorkReplicaSetControllerNameLabelKey, selection.Exists, []string{})
+	if err != nil {
+		return err
+	}
+	manifestWorks, err := c.manifestWorkLister.List(labels.NewSelector().Add(*req))
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, mw := range manifestWorks {
+		if !mw.DeletionTimestamp.IsZero() {
+			if err := c.releaseCleanupPending(ctx, mw); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		key := mw.Labels[ManifestWorkReplicaSetControllerNameLabelKey]
+		// the label is the namespace and the name joined by a dot, and the namespace does not have any dot
+		namespace, name, ok := strings.Cut(key, ".")
+		if !ok || namespace == "" || name == "" {
+			klog.V(4).Infof("Skip the manifestwork %s/%s with the ManifestWorkReplicaSet %q not in the format of "+
+				"namespace.name", mw.Namespace, mw.Name, key)
+			continue
+		}
+
+		_, err := c.manifestWorkReplicaSetLister.ManifestWorkReplicaSets(namespace).Get(name)
+		switch {
+		case err == nil:
+			continue
+		case !errors.IsNotFound(err):
+			errs = append(errs, err)
+			continue
+		}
+
+		// the ManifestWorkReplicaSet may be missed by the cache, verify it on the apiserver
+		_, err = c.workClient.WorkV1alpha1().ManifestWorkReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			continue
+		case !errors.IsNotFound(err):
+			errs = append(errs, err)
+			continue
+		}
+
+		err = c.workClient.WorkV1().ManifestWorks(mw.Namespace).Delete(ctx, mw.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &mw.UID},
+		})
+		switch {
+		case errors.IsNotFound(err):
+			continue
+		case err != nil:
+			errs = append(errs, err)
+			continue
+		}
+
+		klog.V(2).Infof("Deleted the manifestwork %s/%s of the deleted ManifestWorkReplicaSet %s/%s",
+			mw.Namespace, mw.Name, namespace, name)
+		if c.recorder != nil {
+			c.recorder.Eventf(mw, nil, corev1.EventTypeNormal, ReasonOrphanedManifestWorkDeleted, "Delete",
+				"Deleted the manifestwork since its ManifestWorkReplicaSet %s/%s no longer exists", namespace, name)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// releaseCleanupPending removes the finalizers of a deleting manifestwork labeled with CleanupPendingLabelKey if
+// its cluster no longer exists. It is left to the agent otherwise, since the cluster may come back.
+func (c *orphanGCController) releaseCleanupPending(ctx context.Context, mw *workapiv1.ManifestWork) error {
+	if mw.Labels[CleanupPendingLabelKey] != "true" || len(mw.Finalizers) == 0 {
+		return nil
+	}
+
+	_, err := c.clusterLister.Get(mw.Namespace)
+	switch {
+	case err == nil:
+		return nil
+	case !errors.IsNotFound(err):
+		return err
+	}
+
+	workPatcher := patcher.NewPatcher[
+		*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+		c.workClient.WorkV1().ManifestWorks(mw.Namespace))
+	if err := workPatcher.RemoveFinalizer(ctx, mw, mw.Finalizers...); err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("Removed the finalizers of the cleanup pending manifestwork %s/%s on the deleted cluster",
+		mw.Namespace, mw.Name)
+	if c.recorder != nil {
+		c.recorder.Eventf(mw, nil, corev1.EventTypeNormal, ReasonCleanupPendingManifestWorkReleased, "Release",
+			"Removed the finalizers of the manifestwork since its cluster %s no longer exists", mw.Namespace)
+	}
+	return nil
+}
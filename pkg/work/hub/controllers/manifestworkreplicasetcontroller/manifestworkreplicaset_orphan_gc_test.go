@@ -0,0 +1,163 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kevents "k8s.io/client-go/tools/events"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestOrphanGC(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("valid", "default", "place-test")
+	valid := helpertest.CreateTestManifestWorks("valid", "default", "cls1")[0]
+	orphaned := helpertest.CreateTestManifestWorks("orphaned", "default", "cls1")[0]
+	malformed := helpertest.CreateTestManifestWorks("malformed", "default", "cls1")[0].(*workapiv1.ManifestWork)
+	malformed.Labels[ManifestWorkReplicaSetControllerNameLabelKey] = ".malformed"
+	invalid := helpertest.CreateTestManifestWorks("invalid", "default", "cls1")[0].(*workapiv1.ManifestWork)
+	invalid.Labels[ManifestWorkReplicaSetControllerNameLabelKey] = "invalid"
+	// the manifestworkreplicaset is not synced into the cache yet
+	uncached := helpertest.CreateTestManifestWorks("uncached", "default", "cls1")[0]
+	uncachedMWRSet := helpertest.CreateTestManifestWorkReplicaSet("uncached", "default", "place-test")
+
+	works := []runtime.Object{valid, orphaned, malformed, invalid, uncached}
+	fWorkClient := fakeworkclient.NewSimpleClientset(append(works, mwrSet, uncachedMWRSet)...)
+	workInformerFactory := workinformers.NewSharedInformerFactory(fWorkClient, 10*time.Minute)
+	for _, work := range works {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := kevents.NewFakeRecorder(10)
+	controller := &orphanGCController{
+		workClient:                   fWorkClient,
+		manifestWorkLister:           workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		manifestWorkReplicaSetLister: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+		recorder:                     recorder,
+	}
+
+	if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "key")); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the orphaned manifestwork is deleted, the uncached manifestworkreplicaset is verified on the apiserver
+	var gets, deletes int
+	for _, action := range fWorkClient.Actions() {
+		switch action.GetVerb() {
+		case "get":
+			gets++
+		case "delete":
+			deletes++
+			testingcommon.AssertDelete(t, action, "manifestworks", "cls1", "orphaned")
+		default:
+			t.Errorf("unexpected action %v", action)
+		}
+	}
+	if gets != 2 || deletes != 1 {
+		t.Errorf("expected 2 gets and 1 delete, but got %d gets and %d deletes", gets, deletes)
+	}
+	assertEvents(t, recorder,
+		"Normal OrphanedManifestWorkDeleted Deleted the manifestwork since its ManifestWorkReplicaSet default/orphaned no longer exists")
+}
+
+func TestOrphanGCDottedName(t *testing.T) {
+	// the name of a ManifestWorkReplicaSet may have dots, only the namespace has no dot
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("app.v1", "default", "place-test")
+	valid := helpertest.CreateTestManifestWorks("app.v1", "default", "cls1")[0]
+	orphaned := helpertest.CreateTestManifestWorks("app.v2", "default", "cls1")[0]
+
+	works := []runtime.Object{valid, orphaned}
+	fWorkClient := fakeworkclient.NewSimpleClientset(append(works, mwrSet)...)
+	workInformerFactory := workinformers.NewSharedInformerFactory(fWorkClient, 10*time.Minute)
+	for _, work := range works {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := kevents.NewFakeRecorder(10)
+	controller := &orphanGCController{
+		workClient:                   fWorkClient,
+		manifestWorkLister:           workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		manifestWorkReplicaSetLister: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+		recorder:                     recorder,
+	}
+
+	if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "key")); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "get", "delete")
+	testingcommon.AssertDelete(t, fWorkClient.Actions()[1], "manifestworks", "cls1", "app.v2")
+	assertEvents(t, recorder,
+		"Normal OrphanedManifestWorkDeleted Deleted the manifestwork since its ManifestWorkReplicaSet default/app.v2 no longer exists")
+}
+
+func TestOrphanGCCleanupPending(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("test", "default", "place-test")
+	now := metav1.Now()
+	newWork := func(cluster string, cleanupPending bool) *workapiv1.ManifestWork {
+		mw := helpertest.CreateTestManifestWorks("test", "default", cluster)[0].(*workapiv1.ManifestWork)
+		mw.DeletionTimestamp = &now
+		mw.Finalizers = []string{"cluster.open-cluster-management.io/manifest-work-cleanup"}
+		if cleanupPending {
+			mw.Labels[CleanupPendingLabelKey] = "true"
+		}
+		return mw
+	}
+	// only the cleanup pending manifestwork on the deleted cluster is released
+	works := []runtime.Object{newWork("cls1", true), newWork("cls2", true), newWork("cls3", false)}
+
+	fWorkClient := fakeworkclient.NewSimpleClientset(append(works, mwrSet)...)
+	workInformerFactory := workinformers.NewSharedInformerFactory(fWorkClient, 10*time.Minute)
+	for _, work := range works {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 10*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(
+		newFinalizeCluster("cls1", metav1.ConditionUnknown, 2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := kevents.NewFakeRecorder(10)
+	controller := &orphanGCController{
+		workClient:                   fWorkClient,
+		manifestWorkLister:           workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		manifestWorkReplicaSetLister: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+		clusterLister:                clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		recorder:                     recorder,
+	}
+
+	if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "key")); err != nil {
+		t.Fatal(err)
+	}
+
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "patch")
+	if ns := fWorkClient.Actions()[0].GetNamespace(); ns != "cls2" {
+		t.Errorf("expected the manifestwork on cls2 patched, but got %s", ns)
+	}
+	assertEvents(t, recorder,
+		"Normal CleanupPendingManifestWorkReleased Removed the finalizers of the manifestwork since its cluster cls2 no longer exists")
+}
@@ -0,0 +1,42 @@
+package manifestworkreplicasetcontroller
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// PausedAnnotationKey is the annotation on a ManifestWorkReplicaSet to freeze its manifestworks when it is
+	// "true". No manifestwork is created, updated or deleted until the annotation is removed, while the status
+	// keeps reflecting the existing manifestworks and the deletion of the ManifestWorkReplicaSet is not blocked.
+	// TODO move this to the api repo
+	PausedAnnotationKey = "work.open-cluster-management.io/paused"
+
+	// ManifestWorkReplicaSetConditionProgressing is the condition type of a ManifestWorkReplicaSet which is false
+	// while the ManifestWorkReplicaSet is paused, and is removed once it is resumed.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionProgressing = "Progressing"
+
+	// ReasonPaused is the reason of the Progressing condition when the ManifestWorkReplicaSet is paused.
+	ReasonPaused = "Paused"
+)
+
+// isPaused returns true if the manifestworks of the manifestWorkReplicaSet are frozen.
+func isPaused(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	return mwrSet.Annotations[PausedAnnotationKey] == "true"
+}
+
+// updatePaused sets the Progressing condition of the manifestWorkReplicaSet if it is paused, or removes the
+// condition otherwise. It returns true if the manifestWorkReplicaSet is paused.
+func updatePaused(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	if !isPaused(mwrSet) {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionProgressing)
+		return false
+	}
+
+	apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionProgressing,
+		ReasonPaused, "the manifestworks are not changed until the ManifestWorkReplicaSet is resumed", metav1.ConditionFalse))
+	return true
+}
@@ -0,0 +1,47 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"testing"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestDeployReconcilePaused(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{PausedAnnotationKey: "true"}
+	r := newRolloutTest(t, mwrSet, "cls1", "cls2")
+
+	assertPaused := func(paused bool) {
+		cond := apimeta.FindStatusCondition(r.mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionProgressing)
+		switch {
+		case paused && (cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonPaused):
+			t.Errorf("expected the Progressing condition paused, but got %v", cond)
+		case !paused && cond != nil:
+			t.Errorf("expected the Progressing condition removed, but got %v", cond)
+		}
+	}
+
+	// no manifestwork is created while paused
+	r.reconcile()
+	assertPaused(true)
+
+	// the manifestworks are created once resumed
+	delete(r.mwrSet.Annotations, PausedAnnotationKey)
+	r.reconcile("create", "create")
+	assertPaused(false)
+
+	// the template change is not rolled out while paused
+	r.mwrSet.Annotations[PausedAnnotationKey] = "true"
+	updatedWork, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "updated"))
+	r.mwrSet.Spec.ManifestWorkTemplate = updatedWork.Spec
+	r.reconcile()
+	assertPaused(true)
+
+	delete(r.mwrSet.Annotations, PausedAnnotationKey)
+	r.reconcile("patch", "patch")
+	assertPaused(false)
+}
@@ -0,0 +1,95 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kevents "k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
+
+	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+var (
+	// PlacementDegradedEventThreshold is the number of degraded manifestworks of a manifestWorkReplicaSet
+	// to trigger a warning event on the referenced placements.
+	PlacementDegradedEventThreshold = 1
+	// PlacementDegradedEventInterval is the minimum interval between two warning events emitted on a placement
+	// for the same manifestWorkReplicaSet.
+	PlacementDegradedEventInterval = 10 * time.Minute
+)
+
+const ReasonManifestWorkReplicaSetDegraded = "ManifestWorkReplicaSetDegraded"
+
+// placementEventReconciler mirrors the degraded summary of the manifestWorkReplicaSet to the referenced placements
+// with warning events, so the placement owners are aware of the failing workloads scheduled by their placements.
+type placementEventReconciler struct {
+	placementLister clusterlister.PlacementLister
+	recorder        kevents.EventRecorder
+	clock           clock.Clock
+
+	// lastEventTimes records the last time an event is emitted for a manifestWorkReplicaSet and a placement.
+	lastEventTimes sync.Map
+}
+
+func newPlacementEventReconciler(
+	placementLister clusterlister.PlacementLister, recorder kevents.EventRecorder) *placementEventReconciler {
+	return &placementEventReconciler{
+		placementLister: placementLister,
+		recorder:        recorder,
+		clock:           clock.RealClock{},
+	}
+}
+
+func (p *placementEventReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	if p.recorder == nil {
+		return mwrSet, reconcileContinue, nil
+	}
+
+	summary := mwrSet.Status.Summary
+	if summary.Degraded < PlacementDegradedEventThreshold {
+		// reset the rate limiting once the manifestWorkReplicaSet recovers
+		for _, placementRef := range mwrSet.Spec.PlacementRefs {
+			p.lastEventTimes.Delete(placementEventKey(mwrSet, placementRef.Name))
+		}
+		return mwrSet, reconcileContinue, nil
+	}
+
+	for _, placementRef := range mwrSet.Spec.PlacementRefs {
+		placement, err := p.placementLister.Placements(
+			helper.PlacementRefNamespace(mwrSet, placementRef.Name)).Get(placementRef.Name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+
+		key := placementEventKey(mwrSet, placement.Name)
+		now := p.clock.Now()
+		if last, ok := p.lastEventTimes.Load(key); ok && now.Sub(last.(time.Time)) < PlacementDegradedEventInterval {
+			continue
+		}
+
+		p.recorder.Eventf(
+			placement, mwrSet, corev1.EventTypeWarning,
+			ReasonManifestWorkReplicaSetDegraded, "ManifestWorkReplicaSetDegraded",
+			"ManifestWorkReplicaSet %s/%s has %d of %d manifestworks degraded (applied: %d, available: %d, progressing: %d)",
+			mwrSet.Namespace, mwrSet.Name, summary.Degraded, summary.Total, summary.Applied, summary.Available, summary.Progressing)
+		p.lastEventTimes.Store(key, now)
+	}
+
+	return mwrSet, reconcileContinue, nil
+}
+
+func placementEventKey(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, placementName string) string {
+	return fmt.Sprintf("%s/%s", manifestWorkReplicaSetKey(mwrSet), placementName)
+}
@@ -0,0 +1,95 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kevents "k8s.io/client-go/tools/events"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestPlacementEventReconcile(t *testing.T) {
+	placement, _ := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2", "cls3")
+	fakeClusterClient := fakeclusterclient.NewSimpleClientset(placement)
+	clusterInformers := clusterinformers.NewSharedInformerFactory(fakeClusterClient, 10*time.Minute)
+	if err := clusterInformers.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := kevents.NewFakeRecorder(10)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	reconciler := newPlacementEventReconciler(clusterInformers.Cluster().V1beta1().Placements().Lister(), recorder)
+	reconciler.clock = fakeClock
+
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Status.Summary.Total = 3
+	mwrSet.Status.Summary.Applied = 3
+	mwrSet.Status.Summary.Available = 1
+	mwrSet.Status.Summary.Degraded = 2
+
+	expectedEvent := "Warning ManifestWorkReplicaSetDegraded ManifestWorkReplicaSet default/mwrSet-test has 2 of 3 " +
+		"manifestworks degraded (applied: 3, available: 1, progressing: 0)"
+
+	// the first reconcile emits the event
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, recorder, expectedEvent)
+
+	// the event is rate limited within the interval
+	fakeClock.Step(PlacementDegradedEventInterval / 2)
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, recorder)
+
+	// the event is emitted again after the interval
+	fakeClock.Step(PlacementDegradedEventInterval)
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, recorder, expectedEvent)
+
+	// no event when the manifestworkreplicaset recovers, and the rate limiting is reset
+	mwrSet.Status.Summary.Available = 3
+	mwrSet.Status.Summary.Degraded = 0
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, recorder)
+
+	mwrSet.Status.Summary.Available = 1
+	mwrSet.Status.Summary.Degraded = 2
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, recorder, expectedEvent)
+}
+
+func assertEvents(t *testing.T, recorder *kevents.FakeRecorder, expectedEvents ...string) {
+	var actualEvents []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			actualEvents = append(actualEvents, e)
+			continue
+		default:
+		}
+		break
+	}
+
+	if len(actualEvents) != len(expectedEvents) {
+		t.Fatalf("expected events %v, but got %v", expectedEvents, actualEvents)
+	}
+	for i := range expectedEvents {
+		if actualEvents[i] != expectedEvents[i] {
+			t.Errorf("expected event %q, but got %q", expectedEvents[i], actualEvents[i])
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"fmt"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+// ManifestWorkReplicaSetConditionPlacementSummaryPrefix is the prefix of the condition type of each placement
+// referenced by a ManifestWorkReplicaSet, e.g. PlacementSummary.prod, with the number of the clusters selected by
+// the placement. The manifestworks are deployed to the union of the clusters of all the placements.
+// TODO move this to the api repo as a field of the status
+const ManifestWorkReplicaSetConditionPlacementSummaryPrefix = "PlacementSummary."
+
+// placementSummaryConditionType returns the condition type of the summary of the placement.
+func placementSummaryConditionType(placementName string) string {
+	return ManifestWorkReplicaSetConditionPlacementSummaryPrefix + placementName
+}
+
+// setPlacementSummaries sets the summary condition of each placement by the clusters selected, which is nil if
+// the placement is not found, and removes the conditions of the placements no longer referenced.
+func setPlacementSummaries(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, placementClusters map[string]sets.Set[string]) {
+	referenced := sets.New[string]()
+	for _, placementRef := range mwrSet.Spec.PlacementRefs {
+		referenced.Insert(placementSummaryConditionType(placementRef.Name))
+
+		clusters := placementClusters[placementRef.Name]
+		switch {
+		case clusters == nil:
+			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(placementSummaryConditionType(placementRef.Name),
+				workapiv1alpha1.ReasonPlacementDecisionNotFound, "", metav1.ConditionFalse))
+			continue
+		case clusters.Len() == 0:
+			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(placementSummaryConditionType(placementRef.Name),
+				workapiv1alpha1.ReasonPlacementDecisionEmpty, "", metav1.ConditionFalse))
+			continue
+		}
+
+		// the clusters selected by the other placements as well have only one manifestwork
+		shared := sets.New[string]()
+		for name, others := range placementClusters {
+			if name != placementRef.Name {
+				shared = shared.Union(clusters.Intersection(others))
+			}
+		}
+		message := fmt.Sprintf("%d clusters are selected", clusters.Len())
+		if shared.Len() > 0 {
+			message = fmt.Sprintf("%s, %d of them are selected by the other placements as well", message, shared.Len())
+		}
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(placementSummaryConditionType(placementRef.Name),
+			workapiv1alpha1.ReasonAsExpected, message, metav1.ConditionTrue))
+	}
+
+	var conditions []metav1.Condition
+	for _, condition := range mwrSet.Status.Conditions {
+		if strings.HasPrefix(condition.Type, ManifestWorkReplicaSetConditionPlacementSummaryPrefix) &&
+			!referenced.Has(condition.Type) {
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+	mwrSet.Status.Conditions = conditions
+}
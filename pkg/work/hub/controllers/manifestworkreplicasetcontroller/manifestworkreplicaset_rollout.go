@@ -0,0 +1,342 @@
+package manifestworkreplicasetcontroller
+
+import (
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// RolloutStartTimeAnnotationKey is the annotation on a manifestwork with the time it is created or updated by
+	// the progressive rollout, to check the progress deadline.
+	// TODO move this to the api repo
+	RolloutStartTimeAnnotationKey = "work.open-cluster-management.io/rollout-start-time"
+
+	// ManifestWorkReplicaSetConditionRolloutCompleted is the condition type of a ManifestWorkReplicaSet rolled out
+	// progressively. It is true once the manifestworks of all the clusters are updated and the clusters are either
+	// available or failed.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionRolloutCompleted = "RolloutCompleted"
+
+	// ManifestWorkReplicaSetConditionRolloutProgressing is the condition type of a ManifestWorkReplicaSet rolled out
+	// progressively. It is true while some clusters are not rolled out, and false once the rollout is completed or
+	// stopped at a failed decision group.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionRolloutProgressing = "RolloutProgressing"
+
+	// ReasonRolloutProgressing is the reason of the RolloutCompleted condition when some clusters are not rolled out.
+	ReasonRolloutProgressing = "RolloutProgressing"
+	// ReasonRolloutProgressDeadlineExceeded is the reason of the RolloutCompleted condition when the rollout is
+	// completed while some clusters are not available within the progress deadline.
+	ReasonRolloutProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	// ReasonRolloutClustersDegraded is the reason of the RolloutCompleted condition when the rollout per group is
+	// completed while some clusters are degraded.
+	ReasonRolloutClustersDegraded = "ClustersDegraded"
+	// ReasonRolloutGroupFailed is the reason of the RolloutProgressing condition when the rollout per group is
+	// stopped at a decision group with more failed clusters than the max failures.
+	ReasonRolloutGroupFailed = "GroupFailed"
+	// ReasonRolloutStopped is the reason of the RolloutCompleted condition when the rollout per group is stopped
+	// at a failed decision group.
+	ReasonRolloutStopped = "RolloutStopped"
+	// ReasonInvalidRolloutStrategy is the reason of the RolloutCompleted condition when the rollout annotations
+	// are invalid, no manifestwork is created or updated then.
+	ReasonInvalidRolloutStrategy = "InvalidRolloutStrategy"
+)
+
+// rolloutPlan is the clusters whose manifestworks are created or updated in the current wave of the progressive
+// rollout. The state of each cluster is derived from its manifestwork:
+//   - pending, the manifestwork is not created or outdated, and the cluster is not admitted in the current wave;
+//   - in progress, the manifestwork is updated but not applied and available yet;
+//   - succeeded, the manifestwork is updated, applied and available;
+//   - timed out, the manifestwork is updated but not available within the progress deadline;
+//   - degraded, the manifestwork is updated and degraded, which is a failure only in the rollout per group.
+//
+// Only the pending clusters are limited by the max concurrency, and a cluster newly added to the placements is
+// pending as well, so it joins the current wave once there is room.
+type rolloutPlan struct {
+	maxConcurrency int
+	// pending is the clusters not admitted in the current wave
+	pending    []string
+	inProgress sets.Set[string]
+	succeeded  sets.Set[string]
+	timedOut   sets.Set[string]
+	degraded   sets.Set[string]
+	admitted   sets.Set[string]
+	// requeueAfter is the duration after which the first in progress cluster exceeds the progress deadline.
+	requeueAfter time.Duration
+
+	// perGroup is true with the ProgressivePerGroup strategy, the decision groups are reported by the indexes
+	// starting from 1.
+	perGroup        bool
+	rollingGroups   []int
+	completedGroups []int
+	// failedGroup is the message of the decision group the rollout per group is stopped at.
+	failedGroup string
+}
+
+// planRollout returns the rollout plan of the manifestWorkReplicaSet, or nil if it is not rolled out progressively.
+// The groups are the clusters of the decision groups in order, which are used only by the rollout per group. The
+// admitted pending manifestworks are marked with the rollout start time.
+func planRollout(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, desired, existing map[string]*workv1.ManifestWork,
+	groups []sets.Set[string], clock clock.PassiveClock) (*rolloutPlan, error) {
+	strategy, err := helper.ParseRolloutStrategy(mwrSet.Annotations, len(desired))
+	if strategy == nil || err != nil {
+		return nil, err
+	}
+	now := clock.Now()
+
+	plan := &rolloutPlan{
+		maxConcurrency: strategy.MaxConcurrency,
+		inProgress:     sets.New[string](),
+		succeeded:      sets.New[string](),
+		timedOut:       sets.New[string](),
+		degraded:       sets.New[string](),
+		admitted:       sets.New[string](),
+		perGroup:       strategy.PerGroup,
+	}
+	candidates := sets.New[string]()
+	for cls, mw := range desired {
+		existingWork := existing[cls]
+		switch {
+		case existingWork == nil || !workapplier.ManifestWorkEqual(mw, existingWork):
+			candidates.Insert(cls)
+			continue
+		case rolloutSucceeded(existingWork):
+			plan.succeeded.Insert(cls)
+		case strategy.PerGroup && rolloutDegraded(existingWork):
+			plan.degraded.Insert(cls)
+		case strategy.ProgressDeadline > 0:
+			startTime := existingWork.CreationTimestamp.Time
+			if t, err := time.Parse(time.RFC3339, existingWork.Annotations[RolloutStartTimeAnnotationKey]); err == nil {
+				startTime = t
+			}
+			if left := startTime.Add(strategy.ProgressDeadline).Sub(now); left > 0 {
+				plan.inProgress.Insert(cls)
+				if plan.requeueAfter == 0 || left < plan.requeueAfter {
+					plan.requeueAfter = left
+				}
+			} else {
+				plan.timedOut.Insert(cls)
+			}
+		default:
+			plan.inProgress.Insert(cls)
+		}
+		// the updated manifestworks are applied as usual, which changes nothing unless they drift.
+		plan.admitted.Insert(cls)
+	}
+
+	admit := func(cls string) {
+		if desired[cls].Annotations == nil {
+			desired[cls].Annotations = map[string]string{}
+		}
+		desired[cls].Annotations[RolloutStartTimeAnnotationKey] = now.UTC().Format(time.RFC3339)
+		plan.inProgress.Insert(cls)
+		plan.admitted.Insert(cls)
+		if strategy.ProgressDeadline > 0 && (plan.requeueAfter == 0 || strategy.ProgressDeadline < plan.requeueAfter) {
+			plan.requeueAfter = strategy.ProgressDeadline
+		}
+	}
+
+	if strategy.PerGroup {
+		plan.admitGroups(strategy, groups, sets.KeySet(desired), candidates, admit)
+		return plan, nil
+	}
+
+	// the pending clusters are admitted in the order of the names, until the max concurrency is reached
+	for _, cls := range sets.List(candidates) {
+		if plan.inProgress.Len() >= plan.maxConcurrency {
+			plan.pending = append(plan.pending, cls)
+			continue
+		}
+		admit(cls)
+	}
+	return plan, nil
+}
+
+// admitGroups admits the pending clusters of the decision groups in order. The clusters of a decision group are
+// admitted all at once, and the next decision group is admitted once the clusters of the current one are either
+// succeeded or failed, or right away if the current one is not mandatory. The rollout stops at the decision group
+// with more failed clusters than the max failures. A cluster in several decision groups belongs to the first one,
+// and the clusters in no decision group, e.g. not selected by any label selector of the decision groups, join the
+// last one.
+func (p *rolloutPlan) admitGroups(strategy *helper.RolloutStrategy, groups []sets.Set[string], clusters, candidates sets.Set[string],
+	admit func(cls string)) {
+	var clusterGroups []sets.Set[string]
+	grouped := sets.New[string]()
+	for _, group := range groups {
+		clusterGroup := group.Intersection(clusters).Difference(grouped)
+		grouped = grouped.Union(clusterGroup)
+		clusterGroups = append(clusterGroups, clusterGroup)
+	}
+	if ungrouped := clusters.Difference(grouped); ungrouped.Len() > 0 {
+		if len(clusterGroups) == 0 {
+			clusterGroups = append(clusterGroups, sets.New[string]())
+		}
+		last := len(clusterGroups) - 1
+		clusterGroups[last] = clusterGroups[last].Union(ungrouped)
+	}
+
+	stopped := false
+	for i, group := range clusterGroups {
+		index := i + 1
+		if stopped {
+			p.pending = append(p.pending, sets.List(group.Intersection(candidates))...)
+			continue
+		}
+		for _, cls := range sets.List(group.Intersection(candidates)) {
+			admit(cls)
+		}
+
+		failed := group.Intersection(p.timedOut.Union(p.degraded)).Len()
+		maxFailures, _ := intstr.GetScaledValueFromIntOrPercent(&strategy.MaxFailures, group.Len(), false)
+		switch {
+		case failed > maxFailures:
+			p.failedGroup = fmt.Sprintf("%d of %d clusters failed in decision group %d, more than the max failures %d",
+				failed, group.Len(), index, maxFailures)
+			stopped = true
+		case group.Intersection(p.inProgress).Len() == 0:
+			p.completedGroups = append(p.completedGroups, index)
+		default:
+			p.rollingGroups = append(p.rollingGroups, index)
+			stopped = strategy.MandatoryGroups.Len() == 0 || strategy.MandatoryGroups.Has(index)
+		}
+	}
+}
+
+// rolloutEnabled returns true if the manifestWorkReplicaSet is rolled out progressively.
+func rolloutEnabled(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	_, strategy := mwrSet.Annotations[helper.RolloutStrategyAnnotationKey]
+	_, maxConcurrency := mwrSet.Annotations[helper.RolloutMaxConcurrencyAnnotationKey]
+	return strategy || maxConcurrency
+}
+
+// rolloutSucceeded returns true if the current generation of the manifestwork is applied and available.
+func rolloutSucceeded(mw *workv1.ManifestWork) bool {
+	for _, conditionType := range []string{workv1.WorkApplied, workv1.WorkAvailable} {
+		condition := apimeta.FindStatusCondition(mw.Status.Conditions, conditionType)
+		if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != mw.Generation {
+			return false
+		}
+	}
+	return true
+}
+
+// rolloutDegraded returns true if the current generation of the manifestwork is degraded.
+func rolloutDegraded(mw *workv1.ManifestWork) bool {
+	condition := apimeta.FindStatusCondition(mw.Status.Conditions, workv1.WorkDegraded)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == mw.Generation
+}
+
+// isAdmitted returns true if the manifestwork of the cluster is created or updated in the current wave. All the
+// clusters are admitted without the progressive rollout.
+func (p *rolloutPlan) isAdmitted(cls string) bool {
+	return p == nil || p.admitted.Has(cls)
+}
+
+// conditions returns the RolloutCompleted and RolloutProgressing conditions of the rollout plan.
+func (p *rolloutPlan) conditions() []metav1.Condition {
+	completed := p.completedCondition()
+	switch {
+	case len(p.failedGroup) > 0:
+		return []metav1.Condition{completed, getCondition(ManifestWorkReplicaSetConditionRolloutProgressing,
+			ReasonRolloutGroupFailed, p.failedGroup, metav1.ConditionFalse)}
+	case completed.Status == metav1.ConditionFalse:
+		return []metav1.Condition{completed, getCondition(ManifestWorkReplicaSetConditionRolloutProgressing,
+			ReasonRolloutProgressing, completed.Message, metav1.ConditionTrue)}
+	default:
+		return []metav1.Condition{completed, getCondition(ManifestWorkReplicaSetConditionRolloutProgressing,
+			completed.Reason, completed.Message, metav1.ConditionFalse)}
+	}
+}
+
+// completedCondition returns the RolloutCompleted condition of the rollout plan.
+func (p *rolloutPlan) completedCondition() metav1.Condition {
+	rolledOut := p.succeeded.Len() + p.timedOut.Len() + p.degraded.Len()
+	total := rolledOut + p.inProgress.Len() + len(p.pending)
+	switch {
+	case len(p.failedGroup) > 0:
+		return getCondition(ManifestWorkReplicaSetConditionRolloutCompleted, ReasonRolloutStopped,
+			p.failedGroup, metav1.ConditionFalse)
+	case (len(p.pending) > 0 || p.inProgress.Len() > 0) && p.perGroup:
+		return getCondition(ManifestWorkReplicaSetConditionRolloutCompleted, ReasonRolloutProgressing,
+			fmt.Sprintf("decision groups %v are rolling out and %v are completed, %d of %d clusters are rolled out, %d failed",
+				p.rollingGroups, p.completedGroups, rolledOut, total, p.timedOut.Len()+p.degraded.Len()), metav1.ConditionFalse)
+	case len(p.pending) > 0 || p.inProgress.Len() > 0:
+		return getCondition(ManifestWorkReplicaSetConditionRolloutCompleted, ReasonRolloutProgressing,
+			fmt.Sprintf("%d of %d clusters are rolled out, %d are in progress, %d exceed the progress deadline",
+				rolledOut, total, p.inProgress.Len(), p.timedOut.Len()), metav1.ConditionFalse)
+	case p.timedOut.Len() > 0 || p.degraded.Len() > 0:
+		failed := map[string]string{}
+		for cls := range p.timedOut {
+			failed[cls] = "not available within the progress deadline"
+		}
+		for cls := range p.degraded {
+			failed[cls] = "degraded"
+		}
+		reason := ReasonRolloutProgressDeadlineExceeded
+		if p.degraded.Len() > 0 {
+			reason = ReasonRolloutClustersDegraded
+		}
+		return getCondition(ManifestWorkReplicaSetConditionRolloutCompleted, reason,
+			clusterMessages(failed), metav1.ConditionTrue)
+	default:
+		return getCondition(ManifestWorkReplicaSetConditionRolloutCompleted, workapiv1alpha1.ReasonAsExpected,
+			"", metav1.ConditionTrue)
+	}
+}
+
+// decisionGroups returns the clusters of the decision groups of the manifestWorkReplicaSet in order, which are
+// selected by the label selectors of the rollout decision groups, or the clusters of each placement in the order
+// of the placementRefs. It returns nil if the rollout strategy is invalid, which is reported by planRollout.
+func (d *deployReconciler) decisionGroups(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) ([]sets.Set[string], error) {
+	strategy, err := helper.ParseRolloutStrategy(mwrSet.Annotations, 0)
+	if strategy == nil || err != nil {
+		return nil, nil
+	}
+
+	var groups []sets.Set[string]
+	if len(strategy.DecisionGroups) > 0 {
+		clusters, err := d.clusterLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, selector := range strategy.DecisionGroups {
+			group := sets.New[string]()
+			for _, cluster := range clusters {
+				if selector.Matches(labels.Set(cluster.Labels)) {
+					group.Insert(cluster.Name)
+				}
+			}
+			groups = append(groups, group)
+		}
+		return groups, nil
+	}
+
+	for _, placementRef := range mwrSet.Spec.PlacementRefs {
+		clusters, err := d.placementDecisionTracker.Clusters(
+			helper.PlacementRefNamespace(mwrSet, placementRef.Name), placementRef.Name)
+		switch {
+		case errors.IsNotFound(err), goerrors.Is(err, placementhelpers.ErrDecisionsNotCreated):
+			clusters = sets.New[string]()
+		case err != nil:
+			return nil, err
+		}
+		groups = append(groups, clusters)
+	}
+	return groups, nil
+}
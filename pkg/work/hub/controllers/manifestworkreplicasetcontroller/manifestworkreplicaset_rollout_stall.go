@@ -0,0 +1,154 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// RolloutStallThresholdAnnotationKey is the annotation on a ManifestWorkReplicaSet to set the duration, e.g.
+	// 30m, after which the rollout is stalled if the applied and available manifestworks are not increased. It
+	// overrides the threshold of the hub, and 0 disables the RolloutStalled condition of the ManifestWorkReplicaSet.
+	// TODO move this to the api repo
+	RolloutStallThresholdAnnotationKey = "work.open-cluster-management.io/rollout-stall-threshold"
+
+	// RolloutProgressAnnotationKey is the annotation on a ManifestWorkReplicaSet maintained by the controller with
+	// the numbers of the applied and available manifestworks, and the last time either of them increased, in the
+	// format of applied,available,time.
+	// TODO move this to the api repo
+	RolloutProgressAnnotationKey = "work.open-cluster-management.io/rollout-progress"
+
+	// ManifestWorkReplicaSetConditionRolloutStalled is the condition type of a ManifestWorkReplicaSet whose
+	// manifestworks are not all applied at their generations and available, and the numbers of the applied and
+	// available manifestworks are not increased within the stall threshold. It is meant to be alerted on.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionRolloutStalled = "RolloutStalled"
+
+	// ReasonRolloutNoProgress is the reason of the RolloutStalled condition when the rollout is stalled.
+	ReasonRolloutNoProgress = "NoProgress"
+	// ReasonInvalidRolloutStallThreshold is the reason of the RolloutStalled condition when the stall threshold
+	// annotation is invalid.
+	ReasonInvalidRolloutStallThreshold = "InvalidStallThreshold"
+)
+
+// rolloutProgress is the numbers of the applied and available manifestworks of a ManifestWorkReplicaSet, and the
+// last time either of them increased.
+type rolloutProgress struct {
+	applied   int
+	available int
+	time      time.Time
+}
+
+func parseRolloutProgress(value string) (*rolloutProgress, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	applied, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, false
+	}
+	available, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	t, err := time.Parse(time.RFC3339, parts[2])
+	if err != nil {
+		return nil, false
+	}
+	return &rolloutProgress{applied: applied, available: available, time: t}, true
+}
+
+func (p *rolloutProgress) String() string {
+	return fmt.Sprintf("%d,%d,%s", p.applied, p.available, p.time.UTC().Format(time.RFC3339))
+}
+
+// rolloutCompleted returns true if all the manifestworks of the clusters are applied at their generations and
+// available, and none of them is degraded.
+func rolloutCompleted(summary workapiv1alpha1.ManifestWorkReplicaSetSummary, manifestWorks []*workv1.ManifestWork) bool {
+	if summary.Applied < summary.Total || summary.Available < summary.Total || summary.Degraded > 0 {
+		return false
+	}
+	for _, mw := range manifestWorks {
+		cond := apimeta.FindStatusCondition(mw.Status.Conditions, workv1.WorkApplied)
+		if mw.DeletionTimestamp.IsZero() && (cond == nil || cond.ObservedGeneration != mw.Generation) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateRolloutStalled sets the RolloutStalled condition of the manifestWorkReplicaSet with the summary of its
+// manifestworks, and records the progress in the RolloutProgressAnnotationKey annotation. It returns the duration
+// after which the rollout is stalled if no progress is made, or 0 if the rollout is stalled or completed.
+func (d *statusReconciler) updateRolloutStalled(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	manifestWorks []*workv1.ManifestWork) time.Duration {
+	threshold := d.stallThreshold
+	if value, ok := mwrSet.Annotations[RolloutStallThresholdAnnotationKey]; ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+				ManifestWorkReplicaSetConditionRolloutStalled, ReasonInvalidRolloutStallThreshold,
+				fmt.Sprintf("invalid %s %q", RolloutStallThresholdAnnotationKey, value), metav1.ConditionFalse))
+			delete(mwrSet.Annotations, RolloutProgressAnnotationKey)
+			return 0
+		}
+		threshold = duration
+	}
+
+	summary := mwrSet.Status.Summary
+	switch {
+	case threshold == 0 || summary.Total == 0:
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutStalled)
+		delete(mwrSet.Annotations, RolloutProgressAnnotationKey)
+		return 0
+	case rolloutCompleted(summary, manifestWorks):
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+			ManifestWorkReplicaSetConditionRolloutStalled, workapiv1alpha1.ReasonAsExpected,
+			"the manifestworks of all the clusters are applied and available", metav1.ConditionFalse))
+		delete(mwrSet.Annotations, RolloutProgressAnnotationKey)
+		return 0
+	case isPaused(mwrSet):
+		// no progress is expected while the manifestworks are frozen, the stall timer restarts once resumed.
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+			ManifestWorkReplicaSetConditionRolloutStalled, ReasonPaused,
+			"the rollout is paused", metav1.ConditionFalse))
+		delete(mwrSet.Annotations, RolloutProgressAnnotationKey)
+		return 0
+	}
+
+	// the progress time is reset once the applied or available manifestworks increase. The numbers are recorded
+	// even if they decrease, so the recovery of a failed cluster is a progress as well.
+	now := d.clock.Now()
+	progress := &rolloutProgress{applied: summary.Applied, available: summary.Available, time: now}
+	if last, ok := parseRolloutProgress(mwrSet.Annotations[RolloutProgressAnnotationKey]); ok &&
+		progress.applied <= last.applied && progress.available <= last.available {
+		progress.time = last.time
+	}
+	if mwrSet.Annotations == nil {
+		mwrSet.Annotations = map[string]string{}
+	}
+	mwrSet.Annotations[RolloutProgressAnnotationKey] = progress.String()
+
+	counts := fmt.Sprintf("%d of %d clusters are applied, %d are available, %d are degraded",
+		summary.Applied, summary.Total, summary.Available, summary.Degraded)
+	stalled := now.Sub(progress.time)
+	if stalled >= threshold {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+			ManifestWorkReplicaSetConditionRolloutStalled, ReasonRolloutNoProgress,
+			fmt.Sprintf("no progress since %s, %s", progress.time.UTC().Format(time.RFC3339), counts),
+			metav1.ConditionTrue))
+		return 0
+	}
+	apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+		ManifestWorkReplicaSetConditionRolloutStalled, ReasonRolloutProgressing, counts, metav1.ConditionFalse))
+	return threshold - stalled
+}
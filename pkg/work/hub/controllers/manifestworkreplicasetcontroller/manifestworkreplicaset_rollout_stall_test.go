@@ -0,0 +1,227 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+// stallTest runs the statusReconciler against the manifestworks in the informer store with a fake clock.
+type stallTest struct {
+	t            *testing.T
+	workStore    cache.Store
+	clock        *testingclock.FakeClock
+	reconciler   *statusReconciler
+	mwrSet       *workapiv1alpha1.ManifestWorkReplicaSet
+	requeueAfter time.Duration
+}
+
+func newStallTest(t *testing.T, annotations map[string]string, clusters ...string) *stallTest {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = annotations
+	mwrSet.Status.Summary.Total = len(clusters)
+
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeworkclient.NewSimpleClientset(), 1*time.Minute)
+	workStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	for _, cls := range clusters {
+		mw, _ := CreateManifestWork(mwrSet, cls)
+		mw.Generation = 1
+		if err := workStore.Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakeClock := testingclock.NewFakeClock(time.Now().Truncate(time.Second))
+	return &stallTest{
+		t:         t,
+		workStore: workStore,
+		clock:     fakeClock,
+		reconciler: &statusReconciler{
+			manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister(),
+			clock:              fakeClock,
+			stallThreshold:     30 * time.Minute,
+		},
+		mwrSet: mwrSet,
+	}
+}
+
+// setConditions sets the status of the conditions of the manifestwork applied at its generation.
+func (s *stallTest) setConditions(cls string, status metav1.ConditionStatus, conditionTypes ...string) {
+	obj, ok, err := s.workStore.GetByKey(fmt.Sprintf("%s/%s", cls, s.mwrSet.Name))
+	if err != nil || !ok {
+		s.t.Fatalf("manifestwork in %s is not found: %v", cls, err)
+	}
+	mw := obj.(*workv1.ManifestWork).DeepCopy()
+	for _, conditionType := range conditionTypes {
+		apimeta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             "Test",
+			ObservedGeneration: mw.Generation,
+		})
+	}
+	if err := s.workStore.Update(mw); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *stallTest) reconcile(status metav1.ConditionStatus, reason string) {
+	var err error
+	var rqe *requeueError
+	s.requeueAfter = 0
+	s.mwrSet, _, err = s.reconciler.reconcile(context.TODO(), s.mwrSet)
+	switch {
+	case errors.As(err, &rqe):
+		s.requeueAfter = rqe.requeueAfter
+	case err != nil:
+		s.t.Fatal(err)
+	}
+
+	cond := apimeta.FindStatusCondition(s.mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutStalled)
+	if cond == nil {
+		s.t.Fatalf("expected the RolloutStalled condition")
+	}
+	if cond.Status != status || cond.Reason != reason {
+		s.t.Errorf("expected condition %s %s, but got %s %s %q", status, reason, cond.Status, cond.Reason, cond.Message)
+	}
+}
+
+func TestStatusReconcileRolloutStalled(t *testing.T) {
+	s := newStallTest(t, nil, "cls1", "cls2", "cls3")
+
+	// the rollout starts, and is requeued to check the stall once the threshold is reached
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	if s.requeueAfter != 30*time.Minute {
+		t.Errorf("expected requeue after the stall threshold, but got %v", s.requeueAfter)
+	}
+
+	// the progress resets the stall timer
+	s.clock.Step(20 * time.Minute)
+	s.setConditions("cls1", metav1.ConditionTrue, workv1.WorkApplied, workv1.WorkAvailable)
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	s.clock.Step(20 * time.Minute)
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	if s.requeueAfter != 10*time.Minute {
+		t.Errorf("expected requeue after the rest of the stall threshold, but got %v", s.requeueAfter)
+	}
+
+	// cls2 is applied but degraded, and no further progress is made within the threshold
+	s.setConditions("cls2", metav1.ConditionTrue, workv1.WorkApplied, workv1.WorkDegraded)
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	s.clock.Step(30 * time.Minute)
+	s.reconcile(metav1.ConditionTrue, ReasonRolloutNoProgress)
+	if s.requeueAfter != 0 {
+		t.Errorf("expected no requeue once the rollout is stalled, but got %v", s.requeueAfter)
+	}
+
+	// a manifestwork lost by a cluster does not reset the stall timer
+	s.setConditions("cls1", metav1.ConditionFalse, workv1.WorkAvailable)
+	s.reconcile(metav1.ConditionTrue, ReasonRolloutNoProgress)
+
+	// the rollout recovers once the progress resumes, and completes
+	s.setConditions("cls1", metav1.ConditionTrue, workv1.WorkAvailable)
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	s.setConditions("cls2", metav1.ConditionFalse, workv1.WorkDegraded)
+	s.setConditions("cls2", metav1.ConditionTrue, workv1.WorkAvailable)
+	s.setConditions("cls3", metav1.ConditionTrue, workv1.WorkApplied, workv1.WorkAvailable)
+	s.reconcile(metav1.ConditionFalse, workapiv1alpha1.ReasonAsExpected)
+	if _, ok := s.mwrSet.Annotations[RolloutProgressAnnotationKey]; ok {
+		t.Errorf("expected the rollout progress annotation removed once the rollout is completed")
+	}
+
+	// the manifestworks are updated, and the rollout is stalled while the generation is not applied
+	for _, cls := range []string{"cls1", "cls2", "cls3"} {
+		obj, _, _ := s.workStore.GetByKey(fmt.Sprintf("%s/%s", cls, s.mwrSet.Name))
+		mw := obj.(*workv1.ManifestWork).DeepCopy()
+		mw.Generation = 2
+		if err := s.workStore.Update(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	s.clock.Step(30 * time.Minute)
+	s.reconcile(metav1.ConditionTrue, ReasonRolloutNoProgress)
+}
+
+func TestStatusReconcileRolloutStalledPaused(t *testing.T) {
+	s := newStallTest(t, nil, "cls1", "cls2")
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+
+	// the paused rollout is not stalled however long it is paused
+	s.mwrSet.Annotations[PausedAnnotationKey] = "true"
+	s.clock.Step(20 * time.Minute)
+	s.reconcile(metav1.ConditionFalse, ReasonPaused)
+	s.clock.Step(time.Hour)
+	s.reconcile(metav1.ConditionFalse, ReasonPaused)
+	if s.requeueAfter != 0 {
+		t.Errorf("expected no requeue while the rollout is paused, but got %v", s.requeueAfter)
+	}
+
+	// the stall timer restarts once the rollout is resumed
+	delete(s.mwrSet.Annotations, PausedAnnotationKey)
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	if s.requeueAfter != 30*time.Minute {
+		t.Errorf("expected requeue after the stall threshold, but got %v", s.requeueAfter)
+	}
+	s.clock.Step(30 * time.Minute)
+	s.reconcile(metav1.ConditionTrue, ReasonRolloutNoProgress)
+}
+
+func TestStatusReconcileRolloutStallThreshold(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedReason string
+	}{
+		{
+			name:           "threshold overridden",
+			annotations:    map[string]string{RolloutStallThresholdAnnotationKey: "5m"},
+			expectedReason: ReasonRolloutNoProgress,
+		},
+		{
+			name:           "invalid threshold",
+			annotations:    map[string]string{RolloutStallThresholdAnnotationKey: "5"},
+			expectedReason: ReasonInvalidRolloutStallThreshold,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newStallTest(t, c.annotations, "cls1")
+			_, _, _ = s.reconciler.reconcile(context.TODO(), s.mwrSet)
+			s.clock.Step(10 * time.Minute)
+			status := metav1.ConditionFalse
+			if c.expectedReason == ReasonRolloutNoProgress {
+				status = metav1.ConditionTrue
+			}
+			s.reconcile(status, c.expectedReason)
+		})
+	}
+
+	// the condition is removed once it is disabled on the ManifestWorkReplicaSet
+	s := newStallTest(t, nil, "cls1")
+	s.reconcile(metav1.ConditionFalse, ReasonRolloutProgressing)
+	s.mwrSet.Annotations[RolloutStallThresholdAnnotationKey] = "0"
+	if _, _, err := s.reconciler.reconcile(context.TODO(), s.mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	if apimeta.FindStatusCondition(s.mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutStalled) != nil {
+		t.Errorf("expected the RolloutStalled condition removed")
+	}
+	if _, ok := s.mwrSet.Annotations[RolloutProgressAnnotationKey]; ok {
+		t.Errorf("expected the rollout progress annotation removed")
+	}
+}
@@ -0,0 +1,419 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+// rolloutTest runs the deployReconciler against a fake work client, and syncs the manifestworks created or
+// updated into the informer store after each reconcile.
+type rolloutTest struct {
+	t          *testing.T
+	workClient *fakeworkclient.Clientset
+	workStore  cache.Store
+	// placementStore is the informer store of the placements to add the placements
+	placementStore cache.Store
+	// decisionStore is the informer store of the placement decisions to change the clusters
+	decisionStore cache.Store
+	// clusterStore is the informer store of the clusters selected by the decision groups
+	clusterStore cache.Store
+	clock        *testingclock.FakeClock
+	reconciler   *deployReconciler
+	mwrSet       *workapiv1alpha1.ManifestWorkReplicaSet
+	requeueAfter time.Duration
+}
+
+func newRolloutTest(t *testing.T, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusters ...string) *rolloutTest {
+	workClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(workClient, 1*time.Minute)
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", clusters...)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rollout start time is recorded in seconds
+	fakeClock := testingclock.NewFakeClock(time.Now().Truncate(time.Second))
+	return &rolloutTest{
+		t:              t,
+		workClient:     workClient,
+		workStore:      workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore(),
+		placementStore: clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore(),
+		decisionStore:  clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore(),
+		clusterStore:   clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore(),
+		clock:          fakeClock,
+		reconciler: &deployReconciler{
+			workApplier:        workapplier.NewWorkApplierWithTypedClient(workClient, mwLister),
+			manifestWorkLister: mwLister,
+			placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+				clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+				clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+			clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			clock:         fakeClock,
+		},
+		mwrSet: mwrSet,
+	}
+}
+
+// reconcile returns the namespaces of the manifestworks created or updated.
+func (r *rolloutTest) reconcile(expectedActions ...string) []string {
+	r.workClient.ClearActions()
+	var err error
+	var rqe *requeueError
+	r.requeueAfter = 0
+	r.mwrSet, _, err = r.reconciler.reconcile(context.TODO(), r.mwrSet)
+	switch {
+	case errors.As(err, &rqe):
+		r.requeueAfter = rqe.requeueAfter
+	case err != nil:
+		r.t.Fatal(err)
+	}
+
+	actions := r.workClient.Actions()
+	testingcommon.AssertActions(r.t, actions, expectedActions...)
+	var namespaces []string
+	for _, action := range actions {
+		mw, err := r.workClient.WorkV1().ManifestWorks(action.GetNamespace()).Get(
+			context.TODO(), r.mwrSet.Name, metav1.GetOptions{})
+		if err != nil {
+			r.t.Fatal(err)
+		}
+		if err := r.workStore.Update(mw); err != nil {
+			r.t.Fatal(err)
+		}
+		namespaces = append(namespaces, action.GetNamespace())
+	}
+	return namespaces
+}
+
+// available marks the manifestworks in the namespaces applied and available.
+func (r *rolloutTest) available(namespaces ...string) {
+	r.setConditions(namespaces, workv1.WorkApplied, workv1.WorkAvailable)
+}
+
+// degraded marks the manifestworks in the namespaces applied and degraded.
+func (r *rolloutTest) degraded(namespaces ...string) {
+	r.setConditions(namespaces, workv1.WorkApplied, workv1.WorkDegraded)
+}
+
+func (r *rolloutTest) setConditions(namespaces []string, conditionTypes ...string) {
+	for _, ns := range namespaces {
+		obj, ok, err := r.workStore.GetByKey(fmt.Sprintf("%s/%s", ns, r.mwrSet.Name))
+		if err != nil || !ok {
+			r.t.Fatalf("manifestwork in %s is not found: %v", ns, err)
+		}
+		mw := obj.(*workv1.ManifestWork).DeepCopy()
+		for _, conditionType := range conditionTypes {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{
+				Type:               conditionType,
+				Status:             metav1.ConditionTrue,
+				Reason:             "AsExpected",
+				ObservedGeneration: mw.Generation,
+			})
+		}
+		if err := r.workStore.Update(mw); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+}
+
+// decisionGroups adds the clusters of each decision group labeled with the index of the group, selects the
+// decision groups by the labels in order, and replaces the PlacementDecision of the placement with all the clusters.
+func (r *rolloutTest) decisionGroups(groups ...[]string) {
+	var clusters, selectors []string
+	for i, group := range groups {
+		for _, cls := range group {
+			cluster := &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: cls, Labels: map[string]string{"group": fmt.Sprintf("%d", i+1)}},
+			}
+			if err := r.clusterStore.Add(cluster); err != nil {
+				r.t.Fatal(err)
+			}
+		}
+		clusters = append(clusters, group...)
+		selectors = append(selectors, fmt.Sprintf("group=%d", i+1))
+	}
+	r.mwrSet.Annotations[helper.RolloutDecisionGroupsAnnotationKey] = strings.Join(selectors, ";")
+
+	_, decision := helpertest.CreateTestPlacement("place-test", "default", clusters...)
+	if err := r.decisionStore.Replace([]interface{}{decision}, ""); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func (r *rolloutTest) assertCondition(status metav1.ConditionStatus, reason, message string) {
+	r.assertConditionOfType(ManifestWorkReplicaSetConditionRolloutCompleted, status, reason, message)
+}
+
+func (r *rolloutTest) assertConditionOfType(conditionType string, status metav1.ConditionStatus, reason, message string) {
+	cond := apimeta.FindStatusCondition(r.mwrSet.Status.Conditions, conditionType)
+	if cond == nil {
+		r.t.Fatalf("expected the %s condition", conditionType)
+	}
+	if cond.Status != status || cond.Reason != reason || cond.Message != message {
+		r.t.Errorf("expected condition %s %s %q, but got %s %s %q",
+			status, reason, message, cond.Status, cond.Reason, cond.Message)
+	}
+}
+
+func TestDeployReconcileProgressiveRollout(t *testing.T) {
+	var clusters []string
+	for i := 0; i < 10; i++ {
+		clusters = append(clusters, fmt.Sprintf("cluster%d", i))
+	}
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{helper.RolloutMaxConcurrencyAnnotationKey: "2"}
+	r := newRolloutTest(t, mwrSet, clusters...)
+
+	// the manifestworks are created in waves of 2 clusters
+	for wave := 0; wave < 5; wave++ {
+		created := r.reconcile("create", "create")
+		expected := sets.New[string](clusters[wave*2], clusters[wave*2+1])
+		if !expected.Equal(sets.New[string](created...)) {
+			t.Fatalf("wave %d: expected manifestworks created in %v, but got %v", wave, sets.List(expected), created)
+		}
+		r.assertCondition(metav1.ConditionFalse, ReasonRolloutProgressing, fmt.Sprintf(
+			"%d of 10 clusters are rolled out, 2 are in progress, 0 exceed the progress deadline", wave*2))
+
+		// the next wave waits until the clusters of the current wave are available
+		r.reconcile()
+		r.available(created...)
+	}
+	r.reconcile()
+	r.assertCondition(metav1.ConditionTrue, workapiv1alpha1.ReasonAsExpected, "")
+
+	// the manifestworks are updated in waves once the template is changed
+	updatedWork, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "updated"))
+	r.mwrSet.Spec.ManifestWorkTemplate = updatedWork.Spec
+	updated := r.reconcile("patch", "patch")
+	if !sets.New[string](clusters[0], clusters[1]).Equal(sets.New[string](updated...)) {
+		t.Errorf("expected manifestworks updated in %v, but got %v", clusters[:2], updated)
+	}
+	r.reconcile()
+}
+
+func TestDeployReconcileProgressiveRolloutDeadline(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		helper.RolloutMaxConcurrencyAnnotationKey:   "50%",
+		helper.RolloutProgressDeadlineAnnotationKey: "5m",
+	}
+	r := newRolloutTest(t, mwrSet, "cluster0", "cluster1", "cluster2")
+
+	// 50% of 3 clusters is rounded up to 2
+	r.reconcile("create", "create")
+	if r.requeueAfter != 5*time.Minute {
+		t.Errorf("expected requeue after the progress deadline, but got %v", r.requeueAfter)
+	}
+
+	// cluster0 is available, cluster1 is stuck and blocks the rollout until the deadline
+	r.available("cluster0")
+	r.clock.Step(3 * time.Minute)
+	r.reconcile("create")
+	r.available("cluster2")
+	r.reconcile()
+	r.assertCondition(metav1.ConditionFalse, ReasonRolloutProgressing,
+		"2 of 3 clusters are rolled out, 1 are in progress, 0 exceed the progress deadline")
+	if r.requeueAfter != 2*time.Minute {
+		t.Errorf("expected requeue after the progress deadline of cluster1, but got %v", r.requeueAfter)
+	}
+
+	r.clock.Step(3 * time.Minute)
+	r.reconcile()
+	r.assertCondition(metav1.ConditionTrue, ReasonRolloutProgressDeadlineExceeded,
+		"cluster1: not available within the progress deadline")
+
+	// a cluster newly added to the placement is rolled out since the stuck cluster does not block the rollout
+	_, decision := helpertest.CreateTestPlacement("place-test", "default", "cluster0", "cluster1", "cluster2", "cluster3")
+	if err := r.decisionStore.Update(decision); err != nil {
+		t.Fatal(err)
+	}
+	if created := r.reconcile("create"); created[0] != "cluster3" {
+		t.Errorf("expected manifestwork created in cluster3, but got %v", created)
+	}
+	r.assertCondition(metav1.ConditionFalse, ReasonRolloutProgressing,
+		"3 of 4 clusters are rolled out, 1 are in progress, 1 exceed the progress deadline")
+}
+
+func TestDeployReconcileInvalidRolloutStrategy(t *testing.T) {
+	cases := []struct {
+		annotations     map[string]string
+		expectedMessage string
+	}{
+		{
+			annotations:     map[string]string{helper.RolloutMaxConcurrencyAnnotationKey: "0"},
+			expectedMessage: fmt.Sprintf("invalid %s %q", helper.RolloutMaxConcurrencyAnnotationKey, "0"),
+		},
+		{
+			annotations:     map[string]string{helper.RolloutStrategyAnnotationKey: "All"},
+			expectedMessage: fmt.Sprintf("invalid %s %q", helper.RolloutStrategyAnnotationKey, "All"),
+		},
+		{
+			annotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:                helper.RolloutStrategyProgressivePerGroup,
+				helper.RolloutMandatoryDecisionGroupsAnnotationKey: "1,a",
+			},
+			expectedMessage: fmt.Sprintf("invalid %s %q", helper.RolloutMandatoryDecisionGroupsAnnotationKey, "1,a"),
+		},
+		{
+			annotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:    helper.RolloutStrategyProgressivePerGroup,
+				helper.RolloutMaxFailuresAnnotationKey: "-1",
+			},
+			expectedMessage: fmt.Sprintf("invalid %s %q", helper.RolloutMaxFailuresAnnotationKey, "-1"),
+		},
+		{
+			annotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:       helper.RolloutStrategyProgressivePerGroup,
+				helper.RolloutDecisionGroupsAnnotationKey: "env=dev;;env=prod",
+			},
+			expectedMessage: fmt.Sprintf("invalid %s %q", helper.RolloutDecisionGroupsAnnotationKey, "env=dev;;env=prod"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expectedMessage, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			mwrSet.Annotations = c.annotations
+			r := newRolloutTest(t, mwrSet, "cluster0")
+
+			r.reconcile()
+			r.assertCondition(metav1.ConditionFalse, ReasonInvalidRolloutStrategy, c.expectedMessage)
+		})
+	}
+}
+
+func TestDeployReconcileProgressivePerGroup(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{helper.RolloutStrategyAnnotationKey: helper.RolloutStrategyProgressivePerGroup}
+	r := newRolloutTest(t, mwrSet)
+	// the decision groups are ordered by the label selectors rather than the names of the clusters
+	r.decisionGroups([]string{"cluster4", "cluster5"}, []string{"cluster3"}, []string{"cluster1", "cluster2"})
+
+	waves := []struct {
+		clusters        []string
+		expectedMessage string
+	}{
+		{
+			clusters:        []string{"cluster4", "cluster5"},
+			expectedMessage: "decision groups [1] are rolling out and [] are completed, 0 of 5 clusters are rolled out, 0 failed",
+		},
+		{
+			clusters:        []string{"cluster3"},
+			expectedMessage: "decision groups [2] are rolling out and [1] are completed, 2 of 5 clusters are rolled out, 0 failed",
+		},
+		{
+			clusters:        []string{"cluster1", "cluster2"},
+			expectedMessage: "decision groups [3] are rolling out and [1 2] are completed, 3 of 5 clusters are rolled out, 0 failed",
+		},
+	}
+	for i, wave := range waves {
+		var expectedActions []string
+		for range wave.clusters {
+			expectedActions = append(expectedActions, "create")
+		}
+		created := r.reconcile(expectedActions...)
+		if !sets.New[string](wave.clusters...).Equal(sets.New[string](created...)) {
+			t.Fatalf("wave %d: expected manifestworks created in %v, but got %v", i, wave.clusters, created)
+		}
+		r.assertCondition(metav1.ConditionFalse, ReasonRolloutProgressing, wave.expectedMessage)
+		r.assertConditionOfType(ManifestWorkReplicaSetConditionRolloutProgressing, metav1.ConditionTrue,
+			ReasonRolloutProgressing, wave.expectedMessage)
+
+		// the next decision group waits until the current one is available
+		r.reconcile()
+		r.available(created...)
+	}
+	r.reconcile()
+	r.assertCondition(metav1.ConditionTrue, workapiv1alpha1.ReasonAsExpected, "")
+}
+
+func TestDeployReconcileProgressivePerGroupByPlacements(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{helper.RolloutStrategyAnnotationKey: helper.RolloutStrategyProgressivePerGroup}
+	// the decision groups are the placements in the order of the placementRefs
+	mwrSet.Spec.PlacementRefs = append([]workapiv1alpha1.LocalPlacementReference{{Name: "place-first"}},
+		mwrSet.Spec.PlacementRefs...)
+	r := newRolloutTest(t, mwrSet, "cluster1")
+	placement, decision := helpertest.CreateTestPlacement("place-first", "default", "cluster2")
+	if err := r.placementStore.Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.decisionStore.Add(decision); err != nil {
+		t.Fatal(err)
+	}
+
+	if created := r.reconcile("create"); created[0] != "cluster2" {
+		t.Fatalf("expected manifestwork created in cluster2, but got %v", created)
+	}
+	r.available("cluster2")
+	if created := r.reconcile("create"); created[0] != "cluster1" {
+		t.Fatalf("expected manifestwork created in cluster1, but got %v", created)
+	}
+}
+
+func TestDeployReconcileProgressivePerGroupFailures(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		helper.RolloutStrategyAnnotationKey:                helper.RolloutStrategyProgressivePerGroup,
+		helper.RolloutMandatoryDecisionGroupsAnnotationKey: "1",
+	}
+	r := newRolloutTest(t, mwrSet)
+	r.decisionGroups([]string{"cluster1", "cluster2"}, []string{"cluster3"}, []string{"cluster4"})
+
+	r.reconcile("create", "create")
+	r.available("cluster1")
+	r.degraded("cluster2")
+
+	// the rollout stops at the mandatory decision group with a failed cluster
+	r.reconcile()
+	r.assertConditionOfType(ManifestWorkReplicaSetConditionRolloutProgressing, metav1.ConditionFalse,
+		ReasonRolloutGroupFailed, "1 of 2 clusters failed in decision group 1, more than the max failures 0")
+	r.assertCondition(metav1.ConditionFalse, ReasonRolloutStopped,
+		"1 of 2 clusters failed in decision group 1, more than the max failures 0")
+
+	// the failed cluster is tolerated, and the decision groups which are not mandatory are rolled out at once
+	r.mwrSet.Annotations[helper.RolloutMaxFailuresAnnotationKey] = "50%"
+	created := r.reconcile("create", "create")
+	if !sets.New[string]("cluster3", "cluster4").Equal(sets.New[string](created...)) {
+		t.Errorf("expected manifestworks created in cluster3 and cluster4, but got %v", created)
+	}
+	r.assertCondition(metav1.ConditionFalse, ReasonRolloutProgressing,
+		"decision groups [2 3] are rolling out and [1] are completed, 2 of 4 clusters are rolled out, 1 failed")
+
+	r.available("cluster3", "cluster4")
+	r.reconcile()
+	r.assertCondition(metav1.ConditionTrue, ReasonRolloutClustersDegraded, "cluster2: degraded")
+	r.assertConditionOfType(ManifestWorkReplicaSetConditionRolloutProgressing, metav1.ConditionFalse,
+		ReasonRolloutClustersDegraded, "cluster2: degraded")
+}
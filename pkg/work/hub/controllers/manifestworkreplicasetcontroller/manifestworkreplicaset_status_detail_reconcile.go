@@ -0,0 +1,221 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// StatusDetailAnnotationKey is the annotation on a ManifestWorkReplicaSet to enable the status detail mode. When
+	// it is "true", the status of the manifestwork on each cluster is listed in ConfigMaps in the namespace of the
+	// ManifestWorkReplicaSet, since the status of the ManifestWorkReplicaSet only has the counts.
+	// TODO move this to the api repo
+	StatusDetailAnnotationKey = "work.open-cluster-management.io/status-detail"
+
+	// StatusDetailConfigMapsAnnotationKey is the annotation on a ManifestWorkReplicaSet set by the controller with
+	// the comma separated names of the ConfigMaps listing the status of the manifestwork on each cluster.
+	// TODO move this to the api repo
+	StatusDetailConfigMapsAnnotationKey = "work.open-cluster-management.io/status-detail-configmaps"
+
+	// maxStatusDetailMessageLength is the max length of the error message of a cluster in the status detail.
+	maxStatusDetailMessageLength = 256
+)
+
+// StatusDetailPageSize is the max number of clusters in each status detail ConfigMap. It is exposed so that the
+// tests can use a smaller page size.
+var StatusDetailPageSize = 500
+
+// clusterStatusDetail is the status of the manifestwork on a cluster. It is stored in the status detail ConfigMap
+// with the cluster name as the key.
+type clusterStatusDetail struct {
+	Applied   bool   `json:"applied"`
+	Available bool   `json:"available"`
+	Degraded  bool   `json:"degraded"`
+	Message   string `json:"message,omitempty"`
+}
+
+// statusDetailReconciler maintains the status detail ConfigMaps of a ManifestWorkReplicaSet. The clusters are
+// sorted by name and split into pages of StatusDetailPageSize clusters, only the ConfigMaps of the changed pages
+// are updated. The ConfigMaps are owned by the ManifestWorkReplicaSet, so they are garbage collected with it.
+type statusDetailReconciler struct {
+	kubeClient         corev1client.ConfigMapsGetter
+	configMapLister    corev1lister.ConfigMapLister
+	manifestWorkLister worklisterv1.ManifestWorkLister
+}
+
+func (d *statusDetailReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	var pages []map[string]string
+	if mwrSet.Annotations[StatusDetailAnnotationKey] == "true" {
+		manifestWorks, err := listManifestWorksByManifestWorkReplicaSet(mwrSet, d.manifestWorkLister)
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+		pages, err = statusDetailPages(manifestWorks, StatusDetailPageSize)
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+	}
+
+	var errs []error
+	names := []string{}
+	for index, data := range pages {
+		name := statusDetailConfigMapName(mwrSet.Name, index)
+		names = append(names, name)
+		if err := d.applyConfigMap(ctx, mwrSet, name, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// delete the ConfigMaps of the pages no longer needed
+	existingConfigMaps, err := d.listStatusDetailConfigMaps(mwrSet)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	expectedNames := sets.New[string](names...)
+	for _, cm := range existingConfigMaps {
+		if expectedNames.Has(cm.Name) {
+			continue
+		}
+		err := d.kubeClient.ConfigMaps(cm.Namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	if pages == nil {
+		delete(mwrSet.Annotations, StatusDetailConfigMapsAnnotationKey)
+	} else {
+		mwrSet.Annotations[StatusDetailConfigMapsAnnotationKey] = strings.Join(names, ",")
+	}
+
+	return mwrSet, reconcileContinue, utilerrors.NewAggregate(errs)
+}
+
+func (d *statusDetailReconciler) applyConfigMap(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	name string, data map[string]string) error {
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mwrSet.Namespace,
+			Labels:    map[string]string{ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet)},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(mwrSet, workapiv1alpha1.GroupVersion.WithKind("ManifestWorkReplicaSet")),
+			},
+		},
+		Data: data,
+	}
+
+	existing, err := d.configMapLister.ConfigMaps(mwrSet.Namespace).Get(name)
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = d.kubeClient.ConfigMaps(mwrSet.Namespace).Create(ctx, required, metav1.CreateOptions{})
+		return err
+	case err != nil:
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Data, required.Data) &&
+		equality.Semantic.DeepEqual(existing.Labels, required.Labels) &&
+		equality.Semantic.DeepEqual(existing.OwnerReferences, required.OwnerReferences) {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.Labels = required.Labels
+	updated.OwnerReferences = required.OwnerReferences
+	updated.Data = required.Data
+	_, err = d.kubeClient.ConfigMaps(mwrSet.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+func (d *statusDetailReconciler) listStatusDetailConfigMaps(
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) ([]*corev1.ConfigMap, error) {
+	req, err := labels.NewRequirement(ManifestWorkReplicaSetControllerNameLabelKey, selection.Equals, []string{manifestWorkReplicaSetKey(mwrSet)})
+	if err != nil {
+		return nil, err
+	}
+
+	return d.configMapLister.ConfigMaps(mwrSet.Namespace).List(labels.NewSelector().Add(*req))
+}
+
+// statusDetailPages returns the status details of the manifestworks sorted by the cluster name and split into
+// pages with at most pageSize clusters.
+func statusDetailPages(manifestWorks []*workapiv1.ManifestWork, pageSize int) ([]map[string]string, error) {
+	var works []*workapiv1.ManifestWork
+	for _, mw := range manifestWorks {
+		if mw.DeletionTimestamp.IsZero() {
+			works = append(works, mw)
+		}
+	}
+	sort.Slice(works, func(i, j int) bool {
+		return works[i].Namespace < works[j].Namespace
+	})
+
+	pages := []map[string]string{}
+	for index, mw := range works {
+		if index%pageSize == 0 {
+			pages = append(pages, map[string]string{})
+		}
+
+		detail, err := json.Marshal(newClusterStatusDetail(mw))
+		if err != nil {
+			return nil, err
+		}
+		pages[len(pages)-1][mw.Namespace] = string(detail)
+	}
+	return pages, nil
+}
+
+func newClusterStatusDetail(mw *workapiv1.ManifestWork) clusterStatusDetail {
+	detail := clusterStatusDetail{
+		Applied:   apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkApplied),
+		Available: apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkAvailable),
+		Degraded:  apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkDegraded),
+	}
+
+	// the message of the first unhealthy condition is the last error of the cluster
+	for _, cond := range []struct {
+		conditionType string
+		unhealthy     bool
+	}{
+		{workapiv1.WorkDegraded, detail.Degraded},
+		{workapiv1.WorkApplied, !detail.Applied},
+		{workapiv1.WorkAvailable, !detail.Available},
+	} {
+		if !cond.unhealthy {
+			continue
+		}
+		if c := apimeta.FindStatusCondition(mw.Status.Conditions, cond.conditionType); c != nil && len(c.Message) > 0 {
+			detail.Message = c.Message
+			break
+		}
+	}
+	if len(detail.Message) > maxStatusDetailMessageLength {
+		detail.Message = detail.Message[:maxStatusDetailMessageLength]
+	}
+	return detail
+}
+
+func statusDetailConfigMapName(mwrSetName string, index int) string {
+	return fmt.Sprintf("%s-status-%d", mwrSetName, index)
+}
@@ -0,0 +1,222 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newTestClusters(count int) []string {
+	clusters := []string{}
+	for i := 0; i < count; i++ {
+		clusters = append(clusters, fmt.Sprintf("cluster%02d", i))
+	}
+	return clusters
+}
+
+func newStatusDetailConfigMap(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, index int,
+	data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statusDetailConfigMapName(mwrSet.Name, index),
+			Namespace: mwrSet.Namespace,
+			Labels:    map[string]string{ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet)},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(mwrSet, workapiv1alpha1.GroupVersion.WithKind("ManifestWorkReplicaSet")),
+			},
+		},
+		Data: data,
+	}
+}
+
+func TestStatusDetailReconcile(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{StatusDetailAnnotationKey: "true"}
+
+	healthyDetail := `{"applied":true,"available":true,"degraded":false}`
+
+	cases := []struct {
+		name                  string
+		mwrSet                *workapiv1alpha1.ManifestWorkReplicaSet
+		clusters              []string
+		configMaps            []runtime.Object
+		expectedConfigMaps    string
+		validateActions       func(t *testing.T, actions []clienttesting.Action)
+		expectedAnnotationSet bool
+	}{
+		{
+			name:                  "no clusters",
+			mwrSet:                mwrSet,
+			clusters:              []string{},
+			expectedAnnotationSet: true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:                  "clusters fill exactly one page",
+			mwrSet:                mwrSet,
+			clusters:              newTestClusters(2),
+			expectedAnnotationSet: true,
+			expectedConfigMaps:    "mwrSet-test-status-0",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+				cm := actions[0].(clienttesting.CreateActionImpl).Object.(*corev1.ConfigMap)
+				if len(cm.Data) != 2 || cm.Data["cluster00"] != healthyDetail {
+					t.Errorf("unexpected data %v", cm.Data)
+				}
+				if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].Name != "mwrSet-test" {
+					t.Errorf("expected the configmap owned by the manifestworkreplicaset, but got %v", cm.OwnerReferences)
+				}
+			},
+		},
+		{
+			name:                  "clusters overflow to the next page",
+			mwrSet:                mwrSet,
+			clusters:              newTestClusters(3),
+			expectedAnnotationSet: true,
+			expectedConfigMaps:    "mwrSet-test-status-0,mwrSet-test-status-1",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "create")
+				cm := actions[1].(clienttesting.CreateActionImpl).Object.(*corev1.ConfigMap)
+				if len(cm.Data) != 1 || cm.Data["cluster02"] != healthyDetail {
+					t.Errorf("unexpected data %v", cm.Data)
+				}
+			},
+		},
+		{
+			name:     "only the changed page is updated",
+			mwrSet:   mwrSet,
+			clusters: newTestClusters(4),
+			configMaps: []runtime.Object{
+				newStatusDetailConfigMap(mwrSet, 0, map[string]string{"cluster00": healthyDetail, "cluster01": healthyDetail}),
+				newStatusDetailConfigMap(mwrSet, 1, map[string]string{"cluster02": healthyDetail}),
+			},
+			expectedAnnotationSet: true,
+			expectedConfigMaps:    "mwrSet-test-status-0,mwrSet-test-status-1",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+				cm := actions[0].(clienttesting.UpdateActionImpl).Object.(*corev1.ConfigMap)
+				if cm.Name != "mwrSet-test-status-1" || len(cm.Data) != 2 {
+					t.Errorf("unexpected configmap %v", cm)
+				}
+			},
+		},
+		{
+			name:     "redundant pages are deleted",
+			mwrSet:   mwrSet,
+			clusters: newTestClusters(2),
+			configMaps: []runtime.Object{
+				newStatusDetailConfigMap(mwrSet, 0, map[string]string{"cluster00": healthyDetail, "cluster01": healthyDetail}),
+				newStatusDetailConfigMap(mwrSet, 1, map[string]string{"cluster02": healthyDetail}),
+			},
+			expectedAnnotationSet: true,
+			expectedConfigMaps:    "mwrSet-test-status-0",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+				testingcommon.AssertDelete(t, actions[0], "configmaps", "default", "mwrSet-test-status-1")
+			},
+		},
+		{
+			name:     "status detail is disabled",
+			mwrSet:   helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test"),
+			clusters: newTestClusters(2),
+			configMaps: []runtime.Object{
+				newStatusDetailConfigMap(mwrSet, 0, map[string]string{"cluster00": healthyDetail, "cluster01": healthyDetail}),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+				testingcommon.AssertDelete(t, actions[0], "configmaps", "default", "mwrSet-test-status-0")
+			},
+		},
+	}
+
+	pageSize := StatusDetailPageSize
+	StatusDetailPageSize = 2
+	defer func() {
+		StatusDetailPageSize = pageSize
+	}()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := c.mwrSet.DeepCopy()
+			fakeWorkClient := fakeworkclient.NewSimpleClientset()
+			workInformerFactory := workinformers.NewSharedInformerFactory(fakeWorkClient, 10*time.Minute)
+			for _, o := range helpertest.CreateTestManifestWorks(mwrSet.Name, mwrSet.Namespace, c.clusters...) {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(o); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fakeKubeClient := fakekube.NewSimpleClientset(c.configMaps...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakeKubeClient, 10*time.Minute)
+			for _, o := range c.configMaps {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(o); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reconciler := &statusDetailReconciler{
+				kubeClient:         fakeKubeClient.CoreV1(),
+				configMapLister:    kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister(),
+			}
+
+			mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			configMaps, ok := mwrSet.Annotations[StatusDetailConfigMapsAnnotationKey]
+			if ok != c.expectedAnnotationSet {
+				t.Errorf("expected the status detail annotation set %v, but got %v", c.expectedAnnotationSet, ok)
+			}
+			if configMaps != c.expectedConfigMaps {
+				t.Errorf("expected status detail configmaps %q, but got %q", c.expectedConfigMaps, configMaps)
+			}
+			c.validateActions(t, fakeKubeClient.Actions())
+		})
+	}
+}
+
+func TestNewClusterStatusDetail(t *testing.T) {
+	mw := &workapiv1.ManifestWork{}
+	apimeta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{
+		Type:    workapiv1.WorkApplied,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AppliedManifestWorkFailed",
+		Message: "Failed to apply manifest work",
+	})
+	apimeta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{
+		Type:   workapiv1.WorkDegraded,
+		Status: metav1.ConditionTrue,
+		Reason: "Degraded",
+	})
+
+	detail, err := json.Marshal(newClusterStatusDetail(mw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"applied":false,"available":false,"degraded":true,"message":"Failed to apply manifest work"}`
+	if string(detail) != expected {
+		t.Errorf("expected %s, but got %s", expected, string(detail))
+	}
+}
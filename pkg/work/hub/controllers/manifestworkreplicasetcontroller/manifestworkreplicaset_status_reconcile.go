@@ -2,17 +2,34 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"time"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/clock"
 
 	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/metrics"
 )
 
 // statusReconciler is to update manifestWorkReplicaSet status.
 type statusReconciler struct {
 	manifestWorkLister worklisterv1.ManifestWorkLister
+	clock              clock.PassiveClock
+	// stallThreshold is the default duration after which a rollout without progress is stalled, the
+	// RolloutStalled condition is disabled by default if it is 0.
+	stallThreshold time.Duration
+	// maxNotAvailableClusters is the max number of clusters listed in the NotAvailableClustersAnnotationKey
+	// annotation, the annotation is disabled if it is 0.
+	maxNotAvailableClusters int
+	// feedbackSummary enables the FeedbackSummaryAnnotationKey annotation, and maxFeedbackSummaryClusters is the
+	// max number of clusters in the breakdown of each feedback rule. The annotation is disabled by default since
+	// it is rewritten each time a summed feedback value changes on any cluster.
+	feedbackSummary            bool
+	maxFeedbackSummaryClusters int
 }
 
 func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
@@ -25,6 +42,10 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		} else {
 			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetManifestworkApplied(workapiv1alpha1.ReasonNotAsExpected, ""))
 		}
+		d.updateRolloutStalled(mwrSet, nil)
+		delete(mwrSet.Annotations, FeedbackSummaryAnnotationKey)
+		delete(mwrSet.Annotations, NotAvailableClustersAnnotationKey)
+		metrics.ManifestWorkReplicaSetNotAvailableWorks.WithLabelValues(mwrSet.Namespace, mwrSet.Name).Set(0)
 
 		return mwrSet, reconcileContinue, nil
 	}
@@ -35,25 +56,33 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	}
 
 	appliedCount, availableCount, degradCount, processingCount := 0, 0, 0, 0
+	var oldestLastAppliedTime *time.Time
+	var notAvailable []*workapiv1.ManifestWork
 	for _, mw := range manifestWorks {
 		if !mw.DeletionTimestamp.IsZero() {
 			continue
 		}
 
-		// applied condition
-		if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkApplied) {
+		// the oldest last applied time of the manifestworks
+		if lastAppliedTime, err := time.Parse(time.RFC3339, mw.Annotations[helper.LastAppliedTimeAnnotation]); err == nil &&
+			(oldestLastAppliedTime == nil || lastAppliedTime.Before(*oldestLastAppliedTime)) {
+			oldestLastAppliedTime = &lastAppliedTime
+		}
+
+		// the works from the lister are only read, so they are counted without being copied
+		conditions := newManifestWorkConditions(mw)
+		if conditions.applied {
 			appliedCount++
 		}
-		// Progressing condition
-		if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkProgressing) {
+		if conditions.progressing {
 			processingCount++
 		}
-		// Available condition
-		if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkAvailable) {
+		if conditions.available {
 			availableCount++
+		} else {
+			notAvailable = append(notAvailable, mw)
 		}
-		// Degraded condition
-		if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkDegraded) {
+		if conditions.degraded {
 			degradCount++
 		}
 	}
@@ -62,6 +91,33 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	mwrSet.Status.Summary.Degraded = degradCount
 	mwrSet.Status.Summary.Progressing = processingCount
 	mwrSet.Status.Summary.Applied = appliedCount
+	notAvailableCount := mwrSet.Status.Summary.Total - availableCount
+	if notAvailableCount < 0 {
+		notAvailableCount = 0
+	}
+	metrics.ManifestWorkReplicaSetNotAvailableWorks.WithLabelValues(mwrSet.Namespace, mwrSet.Name).Set(
+		float64(notAvailableCount))
+
+	if oldestLastAppliedTime != nil {
+		if mwrSet.Annotations == nil {
+			mwrSet.Annotations = map[string]string{}
+		}
+		mwrSet.Annotations[helper.LastAppliedTimeAnnotation] = oldestLastAppliedTime.UTC().Format(time.RFC3339)
+	}
+
+	if d.feedbackSummary {
+		mwrSet.Annotations, err = updateFeedbackSummary(mwrSet.Annotations, manifestWorks, d.maxFeedbackSummaryClusters)
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+	} else {
+		delete(mwrSet.Annotations, FeedbackSummaryAnnotationKey)
+	}
+
+	mwrSet.Annotations, err = updateNotAvailableClusters(mwrSet.Annotations, notAvailable, d.maxNotAvailableClusters)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
 
 	if mwrSet.Status.Summary.Available == mwrSet.Status.Summary.Total &&
 		mwrSet.Status.Summary.Progressing == 0 && mwrSet.Status.Summary.Degraded == 0 {
@@ -72,5 +128,12 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetManifestworkApplied(workapiv1alpha1.ReasonNotAsExpected, ""))
 	}
 
+	// requeue to set the rollout stalled once the threshold is reached without any change of the manifestworks
+	if requeueAfter := d.updateRolloutStalled(mwrSet, manifestWorks); requeueAfter > 0 {
+		return mwrSet, reconcileContinue, &requeueError{
+			message:      "the rollout is in progress",
+			requeueAfter: requeueAfter,
+		}
+	}
 	return mwrSet, reconcileContinue, nil
 }
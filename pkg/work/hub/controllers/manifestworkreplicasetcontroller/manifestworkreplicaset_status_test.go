@@ -2,6 +2,7 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	workv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
 )
 
@@ -229,3 +231,132 @@ func TestStatusReconcileNotAsExpected(t *testing.T) {
 		t.Fatal("Applied condition Reason not match NotAsExpected ", appliedCondition)
 	}
 }
+
+func TestStatusReconcileOldestLastAppliedTime(t *testing.T) {
+	lastAppliedTimes := map[string]string{
+		"cls1": "2023-01-02T00:00:00Z",
+		"cls2": "2023-01-01T00:00:00Z",
+		"cls3": "2023-01-03T00:00:00Z",
+		// the work on cls4 is not applied yet
+		"cls4": "",
+	}
+	mwrSetTest := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSetTest.Status.Summary.Total = len(lastAppliedTimes)
+
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSetTest)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+
+	for cls, lastAppliedTime := range lastAppliedTimes {
+		mw, _ := CreateManifestWork(mwrSetTest, cls)
+		if len(lastAppliedTime) > 0 {
+			mw.Annotations = map[string]string{helper.LastAppliedTimeAnnotation: lastAppliedTime}
+		}
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mwrSetStatusController := statusReconciler{
+		manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister(),
+	}
+
+	mwrSetTest, _, err := mwrSetStatusController.reconcile(context.TODO(), mwrSetTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mwrSetTest.Annotations[helper.LastAppliedTimeAnnotation] != "2023-01-01T00:00:00Z" {
+		t.Errorf("expected the oldest last applied time 2023-01-01T00:00:00Z, but got %v", mwrSetTest.Annotations)
+	}
+}
+
+func TestStatusReconcileNotAvailableClusters(t *testing.T) {
+	mwrSetTest := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSetTest.Status.Summary.Total = 5
+
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSetTest)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+
+	// the works on cls2, cls3, cls4 and cls5 are not available, the work on cls5 is not applied and the work on
+	// cls4 is degraded
+	for _, cls := range []string{"cls5", "cls4", "cls3", "cls2", "cls1"} {
+		mw, _ := CreateManifestWork(mwrSetTest, cls)
+		if cls != "cls5" {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkApplied, "", "", metav1.ConditionTrue))
+		}
+		if cls == "cls1" {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkAvailable, "", "", metav1.ConditionTrue))
+		} else {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkAvailable, "ResourceNotAvailable",
+				"not available on "+cls, metav1.ConditionFalse))
+		}
+		if cls == "cls4" {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkDegraded, "", "", metav1.ConditionTrue))
+		}
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mwrSetStatusController := statusReconciler{
+		manifestWorkLister:      workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		maxNotAvailableClusters: 2,
+	}
+
+	mwrSetTest, _, err := mwrSetStatusController.reconcile(context.TODO(), mwrSetTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"clusters":[{"name":"cls2","applied":true,"available":false,"degraded":false,` +
+		`"message":"not available on cls2"},{"name":"cls3","applied":true,"available":false,"degraded":false,` +
+		`"message":"not available on cls3"}],"omitted":{"total":2,"notApplied":1,"degraded":1}}`
+	if actual := mwrSetTest.Annotations[NotAvailableClustersAnnotationKey]; actual != expected {
+		t.Errorf("expected not available clusters %s, but got %s", expected, actual)
+	}
+
+	// the annotation is removed once no cluster is selected
+	mwrSetTest.Status.Summary.Total = 0
+	mwrSetTest, _, err = mwrSetStatusController.reconcile(context.TODO(), mwrSetTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mwrSetTest.Annotations[NotAvailableClustersAnnotationKey]; ok {
+		t.Errorf("expected the not available clusters annotation removed, but got %v", mwrSetTest.Annotations)
+	}
+}
+
+// BenchmarkStatusReconcile measures the status reconcile of a ManifestWorkReplicaSet with 5000 manifestworks, a
+// tenth of which are not available.
+func BenchmarkStatusReconcile(b *testing.B) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Status.Summary.Total = 5000
+
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeworkclient.NewSimpleClientset(), 1*time.Minute)
+	for i := 0; i < mwrSet.Status.Summary.Total; i++ {
+		mw, _ := CreateManifestWork(mwrSet, fmt.Sprintf("cluster%04d", i))
+		mw.Annotations = map[string]string{helper.LastAppliedTimeAnnotation: "2023-06-01T00:00:00Z"}
+		apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkApplied, "", "", metav1.ConditionTrue))
+		if i%10 == 0 {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkAvailable, "ResourceNotAvailable",
+				"the deployment is not available", metav1.ConditionFalse))
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkProgressing, "", "", metav1.ConditionTrue))
+		} else {
+			apimeta.SetStatusCondition(&mw.Status.Conditions, getCondition(workv1.WorkAvailable, "", "", metav1.ConditionTrue))
+		}
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	reconciler := statusReconciler{
+		manifestWorkLister:      workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		maxNotAvailableClusters: DefaultMaxNotAvailableClusters,
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := reconciler.reconcile(context.TODO(), mwrSet.DeepCopy()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
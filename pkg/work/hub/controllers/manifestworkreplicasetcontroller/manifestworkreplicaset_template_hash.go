@@ -0,0 +1,89 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+// minApplyRetryDelay is the min delay to requeue a manifestWorkReplicaSet whose manifestwork writes are throttled,
+// so it is not rendered again for each token refilled.
+const minApplyRetryDelay = time.Second
+
+// TemplateHashAnnotationKey is the hash of the manifestwork rendered from the template when it is last applied. The
+// manifestwork is not applied again while the hash is unchanged, except by the drift repair.
+const TemplateHashAnnotationKey = helper.TemplateHashAnnotationKey
+
+// setTemplateHash sets the hash of the labels, the annotations and the spec of the rendered manifestwork.
+func setTemplateHash(mw *workv1.ManifestWork) error {
+	delete(mw.Annotations, TemplateHashAnnotationKey)
+	data, err := json.Marshal(&workv1.ManifestWork{
+		ObjectMeta: *mw.ObjectMeta.DeepCopy(),
+		Spec:       mw.Spec,
+	})
+	if err != nil {
+		return err
+	}
+
+	if mw.Annotations == nil {
+		mw.Annotations = map[string]string{}
+	}
+	mw.Annotations[TemplateHashAnnotationKey] = fmt.Sprintf("%x", sha256.Sum256(data))
+	return nil
+}
+
+// templateHashMatched returns true if the existing manifestwork is applied with the same hash as the desired one.
+func templateHashMatched(desired, existing *workv1.ManifestWork) bool {
+	hash, ok := existing.Annotations[TemplateHashAnnotationKey]
+	return ok && hash == desired.Annotations[TemplateHashAnnotationKey]
+}
+
+// appliedWithoutTemplateHash returns true if the existing manifestwork is applied before the template hash is
+// introduced and is equal to the desired one except the hash. It is not updated only to add the hash, so the
+// manifestworks are not all rewritten once on upgrade.
+func appliedWithoutTemplateHash(desired, existing *workv1.ManifestWork) bool {
+	if _, ok := existing.Annotations[TemplateHashAnnotationKey]; ok {
+		return false
+	}
+	unhashed := desired.DeepCopy()
+	delete(unhashed.Annotations, TemplateHashAnnotationKey)
+	return workapplier.ManifestWorkEqual(unhashed, existing)
+}
+
+// newWorkApplyLimiter returns the rate limiter of the manifestwork create and update calls of the deployReconciler,
+// or nil if the qps is not positive.
+func newWorkApplyLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+}
+
+// tryApply returns true if a manifestwork can be created or updated now, it always returns true without limiter.
+// It does not block, so the shared workers are not held by a throttled manifestWorkReplicaSet.
+func tryApply(limiter flowcontrol.RateLimiter) bool {
+	return limiter == nil || limiter.TryAccept()
+}
+
+// applyRetryDelay returns the delay to requeue a manifestWorkReplicaSet whose manifestwork writes are throttled,
+// it is the time to refill a token but no less than minApplyRetryDelay.
+func applyRetryDelay(limiter flowcontrol.RateLimiter) time.Duration {
+	delay := minApplyRetryDelay
+	if qps := limiter.QPS(); qps > 0 {
+		if refill := time.Duration(float64(time.Second) / float64(qps)); refill > delay {
+			delay = refill
+		}
+	}
+	return delay
+}
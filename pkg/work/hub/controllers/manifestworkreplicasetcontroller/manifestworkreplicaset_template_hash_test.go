@@ -0,0 +1,186 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newTemplateHashTestReconciler(t *testing.T, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusters ...string) (
+	*deployReconciler, *fakeworkclient.Clientset, cache.Store) {
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", clusters...)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	return &deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+	}, fWorkClient, workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+}
+
+// syncWorkStore replaces the manifestworks in the store with the manifestworks of the client.
+func syncWorkStore(t *testing.T, fWorkClient *fakeworkclient.Clientset, store cache.Store) {
+	works, err := fWorkClient.WorkV1().ManifestWorks(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range works.Items {
+		if err := store.Add(&works.Items[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDeployReconcileUnchangedTemplate(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	reconciler, fWorkClient, store := newTemplateHashTestReconciler(t, mwrSet, "cls1", "cls2")
+
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "create", "create")
+	syncWorkStore(t, fWorkClient, store)
+	hashes := map[string]string{}
+	for _, obj := range store.List() {
+		mw := obj.(metav1.Object)
+		hash := mw.GetAnnotations()[TemplateHashAnnotationKey]
+		if len(hash) == 0 {
+			t.Fatalf("expected the template hash on manifestwork %s/%s", mw.GetNamespace(), mw.GetName())
+		}
+		hashes[mw.GetNamespace()] = hash
+	}
+
+	// the applier is not called with an unchanged template, even without the cache of the applier
+	fWorkClient.ClearActions()
+	reconciler.workApplier = workapplier.NewWorkApplierWithTypedClient(fWorkClient, reconciler.manifestWorkLister)
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertNoActions(t, fWorkClient.Actions())
+
+	// the manifestworks are updated with a new hash once the template is changed
+	fWorkClient.ClearActions()
+	mwrSet = mwrSet.DeepCopy()
+	mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests = append(mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests,
+		helpertest.CreateTestManifestWorkReplicaSet("other", "default", "place-test").Spec.ManifestWorkTemplate.Workload.Manifests...)
+	if _, _, err := reconciler.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "patch", "patch")
+	syncWorkStore(t, fWorkClient, store)
+	for _, obj := range store.List() {
+		mw := obj.(metav1.Object)
+		if mw.GetAnnotations()[TemplateHashAnnotationKey] == hashes[mw.GetNamespace()] {
+			t.Errorf("expected the template hash of manifestwork %s/%s changed", mw.GetNamespace(), mw.GetName())
+		}
+	}
+}
+
+func TestTemplateHash(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw1, _ := CreateManifestWork(mwrSet, "cls1")
+	mw2, _ := CreateManifestWork(mwrSet, "cls1")
+	if err := setTemplateHash(mw1); err != nil {
+		t.Fatal(err)
+	}
+	if err := setTemplateHash(mw2); err != nil {
+		t.Fatal(err)
+	}
+	if !templateHashMatched(mw2, mw1) {
+		t.Errorf("expected the same hash of the same template")
+	}
+
+	// the hash is not changed by the hash set before
+	if err := setTemplateHash(mw1); err != nil {
+		t.Fatal(err)
+	}
+	if !templateHashMatched(mw2, mw1) {
+		t.Errorf("expected the same hash once set again")
+	}
+
+	mw3, _ := CreateManifestWork(mwrSet, "cls1")
+	other, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "ConfigMap", "ns", "cm"))
+	mw3.Spec.Workload = other.Spec.Workload
+	if err := setTemplateHash(mw3); err != nil {
+		t.Fatal(err)
+	}
+	if templateHashMatched(mw3, mw1) {
+		t.Errorf("expected a different hash of a different template")
+	}
+
+	// the manifestwork applied without the hash never matches
+	mw4, _ := CreateManifestWork(mwrSet, "cls1")
+	delete(mw4.Annotations, TemplateHashAnnotationKey)
+	if templateHashMatched(mw1, mw4) {
+		t.Errorf("expected no match without the hash")
+	}
+}
+
+func TestAppliedWithoutTemplateHash(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	desired, _ := CreateManifestWork(mwrSet, "cls1")
+	if err := setTemplateHash(desired); err != nil {
+		t.Fatal(err)
+	}
+
+	// the manifestwork applied before the template hash is introduced is not updated only to add the hash
+	existing, _ := CreateManifestWork(mwrSet, "cls1")
+	delete(existing.Annotations, TemplateHashAnnotationKey)
+	if !appliedWithoutTemplateHash(desired, existing) {
+		t.Errorf("expected the manifestwork without the hash unchanged")
+	}
+
+	other, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "ConfigMap", "ns", "cm"))
+	existing.Spec.Workload = other.Spec.Workload
+	if appliedWithoutTemplateHash(desired, existing) {
+		t.Errorf("expected the changed manifestwork without the hash updated")
+	}
+
+	if appliedWithoutTemplateHash(desired, desired) {
+		t.Errorf("expected false with the hash")
+	}
+}
+
+func TestDeployReconcileWorkApplyRateLimit(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	reconciler, fWorkClient, _ := newTemplateHashTestReconciler(t, mwrSet, "cls1", "cls2", "cls3")
+	// only one manifestwork is created in the burst, the rest are throttled without blocking the worker and the
+	// manifestWorkReplicaSet is requeued once a token is refilled
+	reconciler.workApplyLimiter = newWorkApplyLimiter(0.001, 1)
+
+	_, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+	var rqe *requeueError
+	if !errors.As(err, &rqe) || rqe.requeueAfter < 999*time.Second {
+		t.Errorf("expected requeue after the token is refilled, but got %v", err)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "create")
+}
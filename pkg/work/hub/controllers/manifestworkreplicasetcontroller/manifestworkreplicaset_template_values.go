@@ -0,0 +1,155 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// TemplateValuesConfigMapAnnotationKey is the annotation on a ManifestWorkReplicaSet with the name of the
+	// ConfigMap in each cluster namespace which holds the template values of the cluster. When it is set, the
+	// placeholders {{ .ClusterName }} and {{ .Values.<key> }} in the string fields of the manifests are replaced
+	// with the cluster name and the value of the key in the ConfigMap of the cluster.
+	// TODO move this to the api repo
+	TemplateValuesConfigMapAnnotationKey = "work.open-cluster-management.io/template-values-configmap"
+
+	// TemplateClusterNameAnnotationKey is the annotation on a ManifestWorkReplicaSet to replace the placeholder
+	// {{ .ClusterName }} in the string fields of the manifests with the cluster name when it is "true", without
+	// the template values ConfigMaps. The placeholders of the template values are kept as they are.
+	// TODO move this to the api repo
+	TemplateClusterNameAnnotationKey = "work.open-cluster-management.io/template-cluster-name"
+
+	// TemplateValuesLabelKey is the label key required on the template values ConfigMaps. Only the ConfigMaps
+	// with this label are watched, the ConfigMaps without it are treated as not found.
+	// TODO move this to the api repo
+	TemplateValuesLabelKey = "work.open-cluster-management.io/template-values"
+
+	// ManifestWorkReplicaSetConditionTemplateValuesResolved is the condition type of a ManifestWorkReplicaSet
+	// with the TemplateValuesConfigMapAnnotationKey annotation. It is false if the template values of any
+	// cluster cannot be resolved, and the message lists the clusters and why.
+	// TODO move this to the api repo
+	ManifestWorkReplicaSetConditionTemplateValuesResolved = "TemplateValuesResolved"
+
+	// ReasonTemplateValuesNotFound is the reason of the TemplateValuesResolved condition when the template values
+	// ConfigMap or any referenced key is missing in the namespace of some clusters.
+	ReasonTemplateValuesNotFound = "TemplateValuesNotFound"
+
+	// maxUnresolvedClustersInMessage is the max number of clusters listed in the message of the
+	// TemplateValuesResolved and the ExecutorVerified conditions.
+	maxUnresolvedClustersInMessage = 10
+)
+
+// templatePlaceholder matches the placeholders of the cluster name and the template values in the manifests.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*\.(ClusterName|Values\.([-._a-zA-Z0-9]+))\s*\}\}`)
+
+// templateValuesNotResolvedError is returned when the template values of a cluster cannot be resolved, the
+// manifestwork of the cluster is skipped instead of failing the whole ManifestWorkReplicaSet.
+type templateValuesNotResolvedError struct {
+	message string
+}
+
+func (e *templateValuesNotResolvedError) Error() string {
+	return e.message
+}
+
+// renderTemplateValues replaces the placeholders in the manifests of the manifestwork with the cluster name
+// and the values in the template values ConfigMap in the namespace of the cluster. The manifestwork is not
+// changed if the ManifestWorkReplicaSet has neither the TemplateValuesConfigMapAnnotationKey annotation nor
+// the TemplateClusterNameAnnotationKey annotation. The manifestwork is created for each cluster from a copy
+// of the template, so the template is never changed.
+func renderTemplateValues(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, mw *workv1.ManifestWork,
+	configMapLister corev1lister.ConfigMapLister) error {
+	configMapName, withValues := mwrSet.Annotations[TemplateValuesConfigMapAnnotationKey]
+	if !withValues && mwrSet.Annotations[TemplateClusterNameAnnotationKey] != "true" {
+		return nil
+	}
+
+	cluster := mw.Namespace
+	var values map[string]string
+	if withValues {
+		configMap, err := configMapLister.ConfigMaps(cluster).Get(configMapName)
+		switch {
+		case apierrors.IsNotFound(err):
+			return &templateValuesNotResolvedError{
+				message: fmt.Sprintf("configmap %s/%s is not found", cluster, configMapName),
+			}
+		case err != nil:
+			return err
+		}
+		values = configMap.Data
+	}
+
+	missingKeys := sets.New[string]()
+	for index, manifest := range mw.Spec.Workload.Manifests {
+		rendered := templatePlaceholder.ReplaceAllFunc(manifest.Raw, func(placeholder []byte) []byte {
+			match := templatePlaceholder.FindSubmatch(placeholder)
+			value := cluster
+			if len(match[2]) > 0 {
+				if !withValues {
+					return placeholder
+				}
+				key := string(match[2])
+				var ok bool
+				if value, ok = values[key]; !ok {
+					missingKeys.Insert(key)
+					return placeholder
+				}
+			}
+			// the placeholders are in the json strings of the manifests, so the value is escaped as a json string
+			escaped, _ := json.Marshal(value)
+			return escaped[1 : len(escaped)-1]
+		})
+		mw.Spec.Workload.Manifests[index].Raw = rendered
+	}
+
+	if missingKeys.Len() > 0 {
+		return &templateValuesNotResolvedError{
+			message: fmt.Sprintf("keys %s are not found in configmap %s/%s",
+				strings.Join(sets.List(missingKeys), ","), cluster, configMapName),
+		}
+	}
+	return nil
+}
+
+// GetTemplateValuesResolved returns the TemplateValuesResolved condition with the messages of the clusters whose
+// template values cannot be resolved.
+func GetTemplateValuesResolved(unresolved map[string]string) metav1.Condition {
+	if len(unresolved) == 0 {
+		return getCondition(ManifestWorkReplicaSetConditionTemplateValuesResolved,
+			workapiv1alpha1.ReasonAsExpected, "", metav1.ConditionTrue)
+	}
+
+	return getCondition(ManifestWorkReplicaSetConditionTemplateValuesResolved,
+		ReasonTemplateValuesNotFound, clusterMessages(unresolved), metav1.ConditionFalse)
+}
+
+// clusterMessages joins the messages of the clusters sorted by the cluster names, at most
+// maxUnresolvedClustersInMessage clusters are listed.
+func clusterMessages(clusterMessages map[string]string) string {
+	clusters := make([]string, 0, len(clusterMessages))
+	for cluster := range clusterMessages {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	var messages []string
+	for _, cluster := range clusters {
+		if len(messages) == maxUnresolvedClustersInMessage {
+			messages = append(messages, fmt.Sprintf("and %d more clusters", len(clusters)-maxUnresolvedClustersInMessage))
+			break
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", cluster, clusterMessages[cluster]))
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,281 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	placementhelpers "open-cluster-management.io/ocm/pkg/placement/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newTemplateValues(cluster string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "values",
+			Namespace: cluster,
+			Labels:    map[string]string{TemplateValuesLabelKey: "true"},
+		},
+		Data: data,
+	}
+}
+
+func newTemplateManifestWorkReplicaSet() *workapiv1alpha1.ManifestWorkReplicaSet {
+	manifest := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test")
+	manifest.Object["data"] = map[string]interface{}{
+		"cluster": "{{ .ClusterName }}",
+		"vlan":    `{{.Values.vlan}}`,
+	}
+	work, _ := spoketesting.NewManifestWork(0, manifest)
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Spec.ManifestWorkTemplate = work.Spec
+	mwrSet.Annotations = map[string]string{TemplateValuesConfigMapAnnotationKey: "values"}
+	return mwrSet
+}
+
+func TestRenderTemplateValues(t *testing.T) {
+	cases := []struct {
+		name            string
+		configMaps      []*corev1.ConfigMap
+		expectedData    map[string]interface{}
+		expectedMessage string
+	}{
+		{
+			name:            "configmap is not found",
+			expectedMessage: "configmap cls1/values is not found",
+		},
+		{
+			name:            "key is not found",
+			configMaps:      []*corev1.ConfigMap{newTemplateValues("cls1", map[string]string{"mtu": "1500"})},
+			expectedMessage: "keys vlan are not found in configmap cls1/values",
+		},
+		{
+			name:       "values are escaped",
+			configMaps: []*corev1.ConfigMap{newTemplateValues("cls1", map[string]string{"vlan": `"10"`})},
+			expectedData: map[string]interface{}{
+				"cluster": "cls1",
+				"vlan":    `"10"`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, cm := range c.configMaps {
+				if err := indexer.Add(cm); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mwrSet := newTemplateManifestWorkReplicaSet()
+			mw, _ := CreateManifestWork(mwrSet, "cls1")
+
+			err := renderTemplateValues(mwrSet, mw, corev1lister.NewConfigMapLister(indexer))
+			if len(c.expectedMessage) > 0 {
+				testingcommon.AssertError(t, err, c.expectedMessage)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest := &unstructured.Unstructured{}
+			if err := manifest.UnmarshalJSON(mw.Spec.Workload.Manifests[0].Raw); err != nil {
+				t.Fatal(err)
+			}
+			data, _, _ := unstructured.NestedMap(manifest.Object, "data")
+			for k, v := range c.expectedData {
+				if data[k] != v {
+					t.Errorf("expected %s to be %v, but got %v", k, v, data[k])
+				}
+			}
+		})
+	}
+}
+
+func TestDeployReconcileTemplateValues(t *testing.T) {
+	mwrSet := newTemplateManifestWorkReplicaSet()
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakekube.NewSimpleClientset(), 1*time.Minute)
+	configMapStore := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore()
+
+	reconciler := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+		templateValuesLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+	}
+
+	steps := []struct {
+		name              string
+		configMaps        []*corev1.ConfigMap
+		expectedActions   []string
+		expectedNamespace []string
+		expectedResolved  bool
+		expectedMessage   string
+	}{
+		{
+			name: "values of cls2 miss the key",
+			configMaps: []*corev1.ConfigMap{
+				newTemplateValues("cls1", map[string]string{"vlan": "10"}),
+				newTemplateValues("cls2", map[string]string{"mtu": "1500"}),
+			},
+			expectedActions:   []string{"create"},
+			expectedNamespace: []string{"cls1"},
+			expectedMessage:   "cls2: keys vlan are not found in configmap cls2/values",
+		},
+		{
+			name:              "values of cls1 are updated",
+			configMaps:        []*corev1.ConfigMap{newTemplateValues("cls1", map[string]string{"vlan": "20"})},
+			expectedActions:   []string{"patch"},
+			expectedNamespace: []string{"cls1"},
+			expectedMessage:   "cls2: keys vlan are not found in configmap cls2/values",
+		},
+		{
+			name:              "values of cls2 are fixed",
+			configMaps:        []*corev1.ConfigMap{newTemplateValues("cls2", map[string]string{"vlan": "30"})},
+			expectedActions:   []string{"create"},
+			expectedNamespace: []string{"cls2"},
+			expectedResolved:  true,
+		},
+	}
+
+	for _, step := range steps {
+		for _, cm := range step.configMaps {
+			if err := configMapStore.Update(cm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fWorkClient.ClearActions()
+
+		var err error
+		mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		actions := fWorkClient.Actions()
+		testingcommon.AssertActions(t, actions, step.expectedActions...)
+		for i, ns := range step.expectedNamespace {
+			if actions[i].GetNamespace() != ns {
+				t.Errorf("%s: expected action in %s, but got %s", step.name, ns, actions[i].GetNamespace())
+			}
+		}
+		// sync the manifestworks created or updated to the informer
+		for _, ns := range step.expectedNamespace {
+			mw, err := fWorkClient.WorkV1().ManifestWorks(ns).Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mwStore.Update(mw); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionTemplateValuesResolved)
+		if cond == nil {
+			t.Fatalf("%s: expected condition %s", step.name, ManifestWorkReplicaSetConditionTemplateValuesResolved)
+		}
+		if (cond.Status == metav1.ConditionTrue) != step.expectedResolved || !strings.Contains(cond.Message, step.expectedMessage) {
+			t.Errorf("%s: unexpected condition %v", step.name, cond)
+		}
+	}
+}
+
+func TestDeployReconcileTemplateClusterName(t *testing.T) {
+	mwrSet := newTemplateManifestWorkReplicaSet()
+	mwrSet.Annotations = map[string]string{TemplateClusterNameAnnotationKey: "true"}
+	template := mwrSet.Spec.ManifestWorkTemplate.DeepCopy()
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakekube.NewSimpleClientset(), 1*time.Minute)
+	reconciler := deployReconciler{
+		workApplier:        workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister: mwLister,
+		placementDecisionTracker: placementhelpers.NewPlacementDecisionTracker(
+			clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister()),
+		templateValuesLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+	}
+
+	mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fWorkClient.Actions(), "create", "create")
+
+	for _, cls := range []string{"cls1", "cls2"} {
+		mw, err := fWorkClient.WorkV1().ManifestWorks(cls).Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest := &unstructured.Unstructured{}
+		if err := manifest.UnmarshalJSON(mw.Spec.Workload.Manifests[0].Raw); err != nil {
+			t.Fatal(err)
+		}
+		data, _, _ := unstructured.NestedStringMap(manifest.Object, "data")
+		if data["cluster"] != cls {
+			t.Errorf("expected the cluster name %s rendered, but got %s", cls, data["cluster"])
+		}
+		// the placeholders of the template values are kept without the template values configmap
+		if data["vlan"] != "{{.Values.vlan}}" {
+			t.Errorf("expected the placeholder of vlan kept, but got %s", data["vlan"])
+		}
+	}
+
+	if !apiequality.Semantic.DeepEqual(mwrSet.Spec.ManifestWorkTemplate, *template) {
+		t.Errorf("expected the template is not changed")
+	}
+	if cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions,
+		ManifestWorkReplicaSetConditionTemplateValuesResolved); cond != nil {
+		t.Errorf("expected no condition %s without the template values, but got %v",
+			ManifestWorkReplicaSetConditionTemplateValuesResolved, cond)
+	}
+}
@@ -0,0 +1,184 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	workinformerv1alpha1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1alpha1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	worklisterv1alpha1 "open-cluster-management.io/api/client/work/listers/work/v1alpha1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// TombstoneLabelKey is the label key on the tombstone ConfigMaps written in the cluster namespaces when a
+	// ManifestWorkReplicaSet is deleted with the tombstone cleanup. The value is the same as the value of the
+	// ManifestWorkReplicaSetControllerNameLabelKey on the manifestworks.
+	// TODO move this to the api repo
+	TombstoneLabelKey = "work.open-cluster-management.io/manifestworkreplicaset-tombstone"
+
+	// the data keys of the tombstone ConfigMap
+	tombstoneNamespaceKey = "namespace"
+	tombstoneNameKey      = "name"
+	tombstoneUIDKey       = "uid"
+)
+
+// tombstoneName returns the name of the tombstone ConfigMap of a ManifestWorkReplicaSet.
+func tombstoneName(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) string {
+	return fmt.Sprintf("%s.tombstone", manifestWorkReplicaSetKey(mwrSet))
+}
+
+// writeTombstones writes a tombstone ConfigMap in the namespace of each manifestwork of the ManifestWorkReplicaSet.
+// The tombstones already written are skipped, so it is safe to write them again once the controller is restarted.
+func (f *finalizeReconciler) writeTombstones(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	manifestWorks, err := listManifestWorksByManifestWorkReplicaSet(mwrSet, f.manifestWorkLister)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, mw := range manifestWorks {
+		tombstone := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tombstoneName(mwrSet),
+				Namespace: mw.Namespace,
+				Labels:    map[string]string{TombstoneLabelKey: manifestWorkReplicaSetKey(mwrSet)},
+			},
+			Data: map[string]string{
+				tombstoneNamespaceKey: mwrSet.Namespace,
+				tombstoneNameKey:      mwrSet.Name,
+				tombstoneUIDKey:       string(mwrSet.UID),
+			},
+		}
+		_, err := f.kubeClient.ConfigMaps(mw.Namespace).Create(ctx, tombstone, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// tombstoneController consumes the tombstones of the deleted ManifestWorkReplicaSets. It deletes the manifestwork
+// of the ManifestWorkReplicaSet in the namespace of the tombstone, and then deletes the tombstone itself. The
+// tombstones are handled in parallel, and each of them is handled idempotently, so the cleanup is resumed from
+// the remaining tombstones once the controller is restarted.
+type tombstoneController struct {
+	workClient                   workclientset.Interface
+	kubeClient                   corev1client.ConfigMapsGetter
+	tombstoneLister              corev1lister.ConfigMapLister
+	manifestWorkLister           worklisterv1.ManifestWorkLister
+	manifestWorkReplicaSetLister worklisterv1alpha1.ManifestWorkReplicaSetLister
+}
+
+// NewTombstoneController returns a controller consuming the tombstones of the deleted ManifestWorkReplicaSets. The
+// tombstone informer is expected to be filtered by the TombstoneLabelKey.
+func NewTombstoneController(
+	recorder events.Recorder,
+	workClient workclientset.Interface,
+	kubeClient corev1client.ConfigMapsGetter,
+	tombstoneInformer corev1informers.ConfigMapInformer,
+	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer) factory.Controller {
+	c := &tombstoneController{
+		workClient:                   workClient,
+		kubeClient:                   kubeClient,
+		tombstoneLister:              tombstoneInformer.Lister(),
+		manifestWorkLister:           manifestWorkInformer.Lister(),
+		manifestWorkReplicaSetLister: manifestWorkReplicaSetInformer.Lister(),
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				utilruntime.HandleError(err)
+				return ""
+			}
+			return key
+		}, tombstoneInformer.Informer()).
+		WithSync(c.sync).ToController("ManifestWorkReplicaSetTombstoneController", recorder)
+}
+
+func (c *tombstoneController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	key := controllerContext.QueueKey()
+	klog.V(4).Infof("Reconciling ManifestWorkReplicaSet tombstone %q", key)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	tombstone, err := c.tombstoneLister.ConfigMaps(namespace).Get(name)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if err := c.deleteManifestWork(ctx, tombstone); err != nil {
+		return err
+	}
+
+	err = c.kubeClient.ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &tombstone.UID},
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// deleteManifestWork deletes the manifestwork of the deleted ManifestWorkReplicaSet in the namespace of the
+// tombstone. The manifestwork is kept if the ManifestWorkReplicaSet is recreated with the same name, since it
+// is taken over by the new ManifestWorkReplicaSet.
+func (c *tombstoneController) deleteManifestWork(ctx context.Context, tombstone *corev1.ConfigMap) error {
+	mwrSetNamespace, mwrSetName := tombstone.Data[tombstoneNamespaceKey], tombstone.Data[tombstoneNameKey]
+	mwrSet, err := c.manifestWorkReplicaSetLister.ManifestWorkReplicaSets(mwrSetNamespace).Get(mwrSetName)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	case mwrSet.UID != types.UID(tombstone.Data[tombstoneUIDKey]):
+		klog.V(2).Infof("ManifestWorkReplicaSet %s/%s is recreated, skip deleting the manifestwork in %s",
+			mwrSetNamespace, mwrSetName, tombstone.Namespace)
+		return nil
+	}
+
+	mw, err := c.manifestWorkLister.ManifestWorks(tombstone.Namespace).Get(mwrSetName)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if mw.Labels[ManifestWorkReplicaSetControllerNameLabelKey] != tombstone.Labels[TombstoneLabelKey] ||
+		!mw.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	err = c.workClient.WorkV1().ManifestWorks(mw.Namespace).Delete(ctx, mw.Name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
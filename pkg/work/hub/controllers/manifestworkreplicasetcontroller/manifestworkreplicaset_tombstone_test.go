@@ -0,0 +1,231 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/api/utils/work/v1/workapplier"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestFinalizeReconcileWithTombstones(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.UID = "uid1"
+	now := metav1.Now()
+	mwrSet.DeletionTimestamp = &now
+	mwrSet.Finalizers = []string{ManifestWorkReplicaSetFinalizer}
+
+	works := helpertest.CreateTestManifestWorks(mwrSet.Name, mwrSet.Namespace, "cls1", "cls2")
+	fWorkClient := fakeworkclient.NewSimpleClientset(append(works, mwrSet)...)
+	workInformerFactory := workinformers.NewSharedInformerFactory(fWorkClient, 10*time.Minute)
+	for _, work := range works {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	// the tombstone of cls2 fails to be written at the first time
+	fKubeClient := fakekube.NewSimpleClientset()
+	failed := false
+	fKubeClient.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "cls2" && !failed {
+			failed = true
+			return true, nil, fmt.Errorf("fake error")
+		}
+		return false, nil, nil
+	})
+
+	newReconciler := func() *finalizeReconciler {
+		return &finalizeReconciler{
+			workApplier:           workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+			workClient:            fWorkClient,
+			kubeClient:            fKubeClient.CoreV1(),
+			manifestWorkLister:    mwLister,
+			cleanupWithTombstones: true,
+		}
+	}
+
+	assertFinalizer := func(expected bool) {
+		updated, err := fWorkClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Get(
+			context.TODO(), mwrSet.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(updated.Finalizers, ManifestWorkReplicaSetFinalizer) != expected {
+			t.Errorf("expected finalizer %v, but got %v", expected, updated.Finalizers)
+		}
+	}
+
+	if _, _, err := newReconciler().reconcile(context.TODO(), mwrSet); err == nil {
+		t.Errorf("expected error")
+	}
+	assertFinalizer(true)
+
+	// the controller is restarted, the tombstones are written again and the finalizer is removed
+	if _, _, err := newReconciler().reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+	assertFinalizer(false)
+
+	for _, cluster := range []string{"cls1", "cls2"} {
+		tombstone, err := fKubeClient.CoreV1().ConfigMaps(cluster).Get(context.TODO(), tombstoneName(mwrSet), metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tombstone.Labels[TombstoneLabelKey] != manifestWorkReplicaSetKey(mwrSet) || tombstone.Data[tombstoneUIDKey] != "uid1" {
+			t.Errorf("unexpected tombstone %v", tombstone)
+		}
+	}
+
+	// the manifestworks are not deleted by the finalizer
+	for _, action := range fWorkClient.Actions() {
+		if action.GetVerb() == "delete" {
+			t.Errorf("unexpected action %v", action)
+		}
+	}
+}
+
+func TestTombstoneController(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.UID = "uid1"
+	newTombstone := func(cluster string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tombstoneName(mwrSet),
+				Namespace: cluster,
+				Labels:    map[string]string{TombstoneLabelKey: manifestWorkReplicaSetKey(mwrSet)},
+			},
+			Data: map[string]string{
+				tombstoneNamespaceKey: mwrSet.Namespace,
+				tombstoneNameKey:      mwrSet.Name,
+				tombstoneUIDKey:       "uid1",
+			},
+		}
+	}
+
+	cases := []struct {
+		name                string
+		mwrSets             []runtime.Object
+		works               []runtime.Object
+		tombstones          []runtime.Object
+		expectedWorkActions []string
+		expectedKubeActions []string
+	}{
+		{
+			name:       "tombstone is consumed",
+			works:      helpertest.CreateTestManifestWorks(mwrSet.Name, mwrSet.Namespace, "cls1"),
+			tombstones: []runtime.Object{newTombstone("cls1")},
+			// the manifestwork is deleted before the tombstone
+			expectedWorkActions: []string{"delete"},
+			expectedKubeActions: []string{"delete"},
+		},
+		{
+			name: "the finalizer of the manifestworkreplicaset is not removed yet",
+			mwrSets: []runtime.Object{func() runtime.Object {
+				deleting := mwrSet.DeepCopy()
+				now := metav1.Now()
+				deleting.DeletionTimestamp = &now
+				return deleting
+			}()},
+			works:               helpertest.CreateTestManifestWorks(mwrSet.Name, mwrSet.Namespace, "cls1"),
+			tombstones:          []runtime.Object{newTombstone("cls1")},
+			expectedWorkActions: []string{"delete"},
+			expectedKubeActions: []string{"delete"},
+		},
+		{
+			// the controller is restarted after the manifestwork is deleted but before the tombstone is deleted
+			name:                "the manifestwork is deleted already",
+			tombstones:          []runtime.Object{newTombstone("cls1")},
+			expectedKubeActions: []string{"delete"},
+		},
+		{
+			name: "the manifestworkreplicaset is recreated",
+			mwrSets: []runtime.Object{func() runtime.Object {
+				recreated := mwrSet.DeepCopy()
+				recreated.UID = "uid2"
+				return recreated
+			}()},
+			works:               helpertest.CreateTestManifestWorks(mwrSet.Name, mwrSet.Namespace, "cls1"),
+			tombstones:          []runtime.Object{newTombstone("cls1")},
+			expectedKubeActions: []string{"delete"},
+		},
+		{
+			name:                "the manifestwork is not owned by the manifestworkreplicaset",
+			works:               helpertest.CreateTestManifestWorks(mwrSet.Name, "other", "cls1"),
+			tombstones:          []runtime.Object{newTombstone("cls1")},
+			expectedKubeActions: []string{"delete"},
+		},
+		{
+			name: "the tombstone is deleted already",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fWorkClient := fakeworkclient.NewSimpleClientset(append(c.works, c.mwrSets...)...)
+			workInformerFactory := workinformers.NewSharedInformerFactory(fWorkClient, 10*time.Minute)
+			for _, work := range c.works {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, set := range c.mwrSets {
+				if err := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(set); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fKubeClient := fakekube.NewSimpleClientset(c.tombstones...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fKubeClient, 10*time.Minute)
+			for _, tombstone := range c.tombstones {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(tombstone); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &tombstoneController{
+				workClient:                   fWorkClient,
+				kubeClient:                   fKubeClient.CoreV1(),
+				tombstoneLister:              kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				manifestWorkLister:           workInformerFactory.Work().V1().ManifestWorks().Lister(),
+				manifestWorkReplicaSetLister: workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+			}
+			fWorkClient.ClearActions()
+			fKubeClient.ClearActions()
+			syncCtx := testingcommon.NewFakeSyncContext(t, fmt.Sprintf("cls1/%s", tombstoneName(mwrSet)))
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+
+			testingcommon.AssertActions(t, fWorkClient.Actions(), c.expectedWorkActions...)
+			testingcommon.AssertActions(t, fKubeClient.Actions(), c.expectedKubeActions...)
+
+			// the tombstone cleans up after itself
+			_, err := fKubeClient.CoreV1().ConfigMaps("cls1").Get(context.TODO(), tombstoneName(mwrSet), metav1.GetOptions{})
+			if !errors.IsNotFound(err) {
+				t.Errorf("expected the tombstone is deleted, but got %v", err)
+			}
+
+			// it is idempotent to consume the tombstone again
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -2,21 +2,142 @@ package hub
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/spf13/pflag"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 
 	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterscheme "open-cluster-management.io/api/client/cluster/clientset/versioned/scheme"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workscheme "open-cluster-management.io/api/client/work/clientset/versioned/scheme"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/auditcontroller"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/inventorycontroller"
 	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+	"open-cluster-management.io/ocm/pkg/work/hub/metrics"
 )
 
+// WorkHubManagerOptions holds the configuration of the work hub manager.
+type WorkHubManagerOptions struct {
+	// DriftRepairInterval is the interval to verify the manifestworks of every ManifestWorkReplicaSet and
+	// repair the missing or modified ones, it is disabled if it is 0.
+	DriftRepairInterval time.Duration
+	// ResyncInterval is the interval to reconcile every ManifestWorkReplicaSet, it is disabled if it is 0.
+	ResyncInterval time.Duration
+	// WorkApplyQPS and WorkApplyBurst limit the rate of the manifestwork create and update calls of the
+	// ManifestWorkReplicaSet controller. The rate is not limited if WorkApplyQPS is 0.
+	WorkApplyQPS   float32
+	WorkApplyBurst int
+	// CleanupWithTombstones releases the finalizer of a deleted ManifestWorkReplicaSet once a tombstone is
+	// written in the namespace of each manifestwork, the manifestworks are deleted by the tombstone worker.
+	CleanupWithTombstones bool
+	// UnavailableClusterTimeout is the duration a deleted ManifestWorkReplicaSet waits for its manifestworks on an
+	// unavailable cluster, the manifestworks are skipped then. It is disabled if it is 0.
+	UnavailableClusterTimeout time.Duration
+	// ForceDeleteStuckWorks removes the finalizers of the skipped manifestworks instead of labeling them for
+	// later cleanup.
+	ForceDeleteStuckWorks bool
+	// RolloutStallThreshold is the default duration after which the rollout of a ManifestWorkReplicaSet is
+	// stalled if no progress is made. It is disabled if it is 0.
+	RolloutStallThreshold time.Duration
+	// MaxNotAvailableClusters is the max number of the not available clusters listed on a ManifestWorkReplicaSet,
+	// the rest are only counted. The list is disabled if it is 0.
+	MaxNotAvailableClusters int
+	// FeedbackSummary sums the status feedback values of the manifestworks on each ManifestWorkReplicaSet, and
+	// MaxFeedbackSummaryClusters is the max number of the clusters in the breakdown of each feedback rule.
+	FeedbackSummary            bool
+	MaxFeedbackSummaryClusters int
+	// AuditManifestWorkSpecChanges records an event on a manifestwork each time its spec is changed, with the
+	// summary of the changed manifests.
+	AuditManifestWorkSpecChanges bool
+	// MaxAuditMessageLength is the max length of the message of each audit event.
+	MaxAuditMessageLength int
+	// OrphanedManifestWorkGCInterval is the interval to delete the manifestworks whose ManifestWorkReplicaSet
+	// no longer exists, it is disabled if it is 0.
+	OrphanedManifestWorkGCInterval time.Duration
+}
+
+// NewWorkHubManagerOptions returns the options with default value set.
+func NewWorkHubManagerOptions() *WorkHubManagerOptions {
+	return &WorkHubManagerOptions{
+		DriftRepairInterval:            10 * time.Minute,
+		ResyncInterval:                 10 * time.Minute,
+		WorkApplyQPS:                   50,
+		WorkApplyBurst:                 100,
+		UnavailableClusterTimeout:      time.Hour,
+		RolloutStallThreshold:          30 * time.Minute,
+		MaxNotAvailableClusters:        manifestworkreplicasetcontroller.DefaultMaxNotAvailableClusters,
+		MaxFeedbackSummaryClusters:     manifestworkreplicasetcontroller.DefaultMaxFeedbackSummaryClusters,
+		MaxAuditMessageLength:          auditcontroller.DefaultMaxMessageLength,
+		OrphanedManifestWorkGCInterval: 10 * time.Minute,
+	}
+}
+
+// AddFlags registers the flags of the work hub manager.
+func (o *WorkHubManagerOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&o.DriftRepairInterval, "drift-repair-interval", o.DriftRepairInterval,
+		"The interval to recreate the missing manifestworks and revert the modified manifestworks of the "+
+			"ManifestWorkReplicaSets even if the template is not changed. Set it to 0 to disable the drift repair.")
+	fs.DurationVar(&o.ResyncInterval, "manifestworkreplicaset-resync-interval", o.ResyncInterval,
+		"The interval to reconcile every ManifestWorkReplicaSet. The drift repair is scheduled on its own "+
+			"interval regardless of the resync. Set it to 0 to disable the resync.")
+	fs.Float32Var(&o.WorkApplyQPS, "manifestwork-apply-qps", o.WorkApplyQPS,
+		"The max rate of the manifestwork create and update calls of the ManifestWorkReplicaSet controller. "+
+			"Set it to 0 to disable the rate limit.")
+	fs.IntVar(&o.WorkApplyBurst, "manifestwork-apply-burst", o.WorkApplyBurst,
+		"The max burst of the manifestwork create and update calls of the ManifestWorkReplicaSet controller.")
+	fs.BoolVar(&o.CleanupWithTombstones, "cleanup-with-tombstones", o.CleanupWithTombstones,
+		"Release the finalizer of a deleted ManifestWorkReplicaSet once a tombstone is written in each cluster "+
+			"namespace, and delete the manifestworks by consuming the tombstones in parallel.")
+	fs.DurationVar(&o.UnavailableClusterTimeout, "unavailable-cluster-cleanup-timeout", o.UnavailableClusterTimeout,
+		"The duration a deleted ManifestWorkReplicaSet with the Foreground delete option waits for its manifestworks "+
+			"on an unavailable cluster, or for all its manifestworks since it is deleted, before skipping them. The "+
+			"manifestworks on a deleted cluster are always skipped. Set it to 0 to wait forever.")
+	fs.BoolVar(&o.ForceDeleteStuckWorks, "force-delete-stuck-manifestworks", o.ForceDeleteStuckWorks,
+		"Remove the finalizers of the manifestworks skipped by a deleted ManifestWorkReplicaSet, otherwise the "+
+			"manifestworks are labeled with "+manifestworkreplicasetcontroller.CleanupPendingLabelKey+" and their "+
+			"finalizers are removed once their clusters are deleted.")
+	fs.DurationVar(&o.RolloutStallThreshold, "rollout-stall-threshold", o.RolloutStallThreshold,
+		"The duration after which the RolloutStalled condition of a ManifestWorkReplicaSet is set if the applied "+
+			"and available manifestworks do not increase while the rollout is not completed. It is overridden by "+
+			"the "+manifestworkreplicasetcontroller.RolloutStallThresholdAnnotationKey+" annotation. Set it to 0 "+
+			"to disable the condition by default.")
+	fs.IntVar(&o.MaxNotAvailableClusters, "max-not-available-clusters", o.MaxNotAvailableClusters,
+		"The max number of the clusters whose manifestworks are not available listed in the "+
+			manifestworkreplicasetcontroller.NotAvailableClustersAnnotationKey+" annotation of a "+
+			"ManifestWorkReplicaSet, ordered by the cluster name. The rest are only counted. Set it to 0 to disable the list.")
+	fs.BoolVar(&o.FeedbackSummary, "feedback-summary", o.FeedbackSummary,
+		"Sum the integer status feedback values of the manifestworks of each ManifestWorkReplicaSet in the "+
+			manifestworkreplicasetcontroller.FeedbackSummaryAnnotationKey+" annotation. The annotation is updated "+
+			"each time a summed value changes on any cluster.")
+	fs.IntVar(&o.MaxFeedbackSummaryClusters, "max-feedback-summary-clusters", o.MaxFeedbackSummaryClusters,
+		"The max number of the clusters whose values are listed in the breakdown of each feedback rule of the "+
+			"feedback summary, ordered by the cluster name. The rest are only counted. Set it to 0 to disable the "+
+			"breakdown, so the annotation is only updated when the sums change.")
+	fs.BoolVar(&o.AuditManifestWorkSpecChanges, "audit-manifestwork-spec-changes", o.AuditManifestWorkSpecChanges,
+		"Record an event on a manifestwork each time its spec is changed, with the indexes and the resources of the "+
+			"added, removed and changed manifests. The contents of the Secret manifests are redacted.")
+	fs.IntVar(&o.MaxAuditMessageLength, "max-audit-message-length", o.MaxAuditMessageLength,
+		"The max length of the message of each audit event, between 1 and 1024. The changes exceeding it are "+
+			"counted instead of listed.")
+	fs.DurationVar(&o.OrphanedManifestWorkGCInterval, "orphaned-manifestwork-gc-interval", o.OrphanedManifestWorkGCInterval,
+		"The interval to delete the manifestworks whose ManifestWorkReplicaSet no longer exists, which are left if the "+
+			"finalizer of the ManifestWorkReplicaSet is removed manually. Set it to 0 to disable the garbage collection.")
+}
+
 // RunWorkHubManager starts the controllers on hub.
-func RunWorkHubManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+func (o *WorkHubManagerOptions) RunWorkHubManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
 	hubWorkClient, err := workclientset.NewForConfig(controllerContext.KubeConfig)
 	if err != nil {
 		return err
@@ -27,6 +148,19 @@ func RunWorkHubManager(ctx context.Context, controllerContext *controllercmd.Con
 		return err
 	}
 
+	kubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	// the events are emitted on placements and manifestworks and refer to the manifestworkreplicasets
+	eventScheme := runtime.NewScheme()
+	utilruntime.Must(clusterscheme.AddToScheme(eventScheme))
+	utilruntime.Must(workscheme.AddToScheme(eventScheme))
+	broadcaster := events.NewBroadcaster(&events.EventSinkImpl{Interface: kubeClient.EventsV1()})
+	broadcaster.StartRecordingToSink(ctx.Done())
+	recorder := broadcaster.NewRecorder(eventScheme, "manifestWorkReplicaSetController")
+
 	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(hubClusterClient, 30*time.Minute)
 	workInformerFactory := workinformers.NewSharedInformerFactory(hubWorkClient, 30*time.Minute)
 
@@ -46,20 +180,145 @@ func RunWorkHubManager(ctx context.Context, controllerContext *controllercmd.Con
 		},
 	))
 
+	// only watch the status detail configmaps of the manifestworkreplicasets
+	configMapInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute, kubeinformers.WithTweakListOptions(
+		func(listOptions *metav1.ListOptions) {
+			selector := &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			}
+			listOptions.LabelSelector = metav1.FormatLabelSelector(selector)
+		},
+	))
+
+	// only watch the template values configmaps in the cluster namespaces
+	templateValuesInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute, kubeinformers.WithTweakListOptions(
+		func(listOptions *metav1.ListOptions) {
+			selector := &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      manifestworkreplicasetcontroller.TemplateValuesLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			}
+			listOptions.LabelSelector = metav1.FormatLabelSelector(selector)
+		},
+	))
+
 	manifestWorkReplicaSetController := manifestworkreplicasetcontroller.NewManifestWorkReplicaSetController(
 		controllerContext.EventRecorder,
+		recorder,
 		hubWorkClient,
+		kubeClient.CoreV1(),
+		kubeClient.AuthorizationV1(),
 		workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
 		manifestWorkInformerFactory.Work().V1().ManifestWorks(),
+		configMapInformerFactory.Core().V1().ConfigMaps(),
+		templateValuesInformerFactory.Core().V1().ConfigMaps(),
 		clusterInformerFactory.Cluster().V1beta1().Placements(),
 		clusterInformerFactory.Cluster().V1beta1().PlacementDecisions(),
+		clusterInformerFactory.Cluster().V1().ManagedClusters(),
+		o.DriftRepairInterval,
+		o.CleanupWithTombstones,
+		o.UnavailableClusterTimeout,
+		o.ForceDeleteStuckWorks,
+		o.RolloutStallThreshold,
+		o.MaxNotAvailableClusters,
+		o.FeedbackSummary,
+		o.MaxFeedbackSummaryClusters,
+		o.ResyncInterval,
+		o.WorkApplyQPS,
+		o.WorkApplyBurst,
+	)
+
+	// only watch the tombstones of the deleted manifestworkreplicasets. The tombstone controller always runs, so
+	// the tombstones left are consumed even if the tombstone cleanup is disabled later.
+	tombstoneInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute, kubeinformers.WithTweakListOptions(
+		func(listOptions *metav1.ListOptions) {
+			selector := &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      manifestworkreplicasetcontroller.TombstoneLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			}
+			listOptions.LabelSelector = metav1.FormatLabelSelector(selector)
+		},
+	))
+	tombstoneController := manifestworkreplicasetcontroller.NewTombstoneController(
+		controllerContext.EventRecorder,
+		hubWorkClient,
+		kubeClient.CoreV1(),
+		tombstoneInformerFactory.Core().V1().ConfigMaps(),
+		manifestWorkInformerFactory.Work().V1().ManifestWorks(),
+		workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
+	)
+
+	orphanGCController := manifestworkreplicasetcontroller.NewOrphanGCController(
+		controllerContext.EventRecorder,
+		recorder,
+		hubWorkClient,
+		manifestWorkInformerFactory.Work().V1().ManifestWorks(),
+		workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
+		clusterInformerFactory.Cluster().V1().ManagedClusters(),
+		o.OrphanedManifestWorkGCInterval,
+	)
+
+	inventoryController := inventorycontroller.NewInventoryController(
+		controllerContext.EventRecorder,
+		hubWorkClient,
+		kubeClient.CoreV1(),
+		workInformerFactory.Work().V1().ManifestWorks(),
+		workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
+	)
+
+	if o.AuditManifestWorkSpecChanges {
+		if err := auditcontroller.NewAuditController(
+			broadcaster.NewRecorder(eventScheme, "manifestWorkAuditController"),
+			workInformerFactory.Work().V1().ManifestWorks(),
+			o.MaxAuditMessageLength,
+		); err != nil {
+			return err
+		}
+	}
+
+	workApplyMetricsController := metrics.NewWorkApplyMetricsController(
+		hubWorkClient,
+		workInformerFactory.Work().V1().ManifestWorks(),
+		clusterInformerFactory.Cluster().V1().ManagedClusters(),
+		controllerContext.EventRecorder,
 	)
 
 	go clusterInformerFactory.Start(ctx.Done())
 	go workInformerFactory.Start(ctx.Done())
 	go manifestWorkInformerFactory.Start(ctx.Done())
-	go manifestWorkReplicaSetController.Run(ctx, 5)
+	go configMapInformerFactory.Start(ctx.Done())
+	go templateValuesInformerFactory.Start(ctx.Done())
+	go tombstoneInformerFactory.Start(ctx.Done())
+
+	// the controllers are waited for to stop, so they never run on two replicas once the leadership is handed over
+	var wg sync.WaitGroup
+	run := func(controller factory.Controller, workers int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			controller.Run(ctx, workers)
+		}()
+	}
+	run(manifestWorkReplicaSetController, 5)
+	run(tombstoneController, 10)
+	run(inventoryController, 1)
+	if o.OrphanedManifestWorkGCInterval > 0 {
+		run(orphanGCController, 1)
+	}
+	run(workApplyMetricsController, 1)
 
-	<-ctx.Done()
+	wg.Wait()
 	return nil
 }
@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/utils/clock"
+
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+// CreatedOnUnavailableClusterAnnotation is set on the manifestworks created while the managed cluster is
+// unavailable. It is set once the manifestwork is inspected, so the latency of the manifestwork is not
+// counted in the apply latency even if the cluster becomes available before the manifestwork is applied.
+// TODO move this to the api repo
+const CreatedOnUnavailableClusterAnnotation = "work.open-cluster-management.io/created-on-unavailable-cluster"
+
+var (
+	// WorkApplyDuration is the time from the creation to the applied of the manifestworks, the manifestworks
+	// created on the unavailable clusters are excluded.
+	WorkApplyDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      "work",
+			Name:           "apply_duration_seconds",
+			Help:           "Time from the creation to the applied of the manifestworks created on the available clusters.",
+			Buckets:        []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	// WorkLateApplyDuration is the time from the creation to the applied of the manifestworks created on the
+	// unavailable clusters.
+	WorkLateApplyDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      "work",
+			Name:           "late_apply_duration_seconds",
+			Help:           "Time from the creation to the applied of the manifestworks created on the unavailable clusters.",
+			Buckets:        []float64{60, 300, 900, 1800, 3600, 4 * 3600, 12 * 3600, 24 * 3600},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// Register registers the metrics of the work hub.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(WorkApplyDuration)
+		legacyregistry.MustRegister(WorkLateApplyDuration)
+		legacyregistry.MustRegister(ManifestWorkReplicaSetTargetClusters)
+		legacyregistry.MustRegister(ManifestWorkReplicaSetNotAvailableWorks)
+		legacyregistry.MustRegister(ManifestWorkReplicaSetWorkOperations)
+		legacyregistry.MustRegister(ManifestWorkReplicaSetSyncDuration)
+	})
+}
+
+// workApplyMetricsController observes the latency from the creation to the applied of the manifestworks.
+//
+// A manifestwork is inspected when it is observed and not applied yet, it is annotated with the
+// CreatedOnUnavailableClusterAnnotation if the cluster was tainted unavailable or unreachable before the
+// manifestwork is created. The latency is observed once for each manifestwork, and only if the manifestwork
+// is applied after the controller starts, so it is not observed again after the controller restarts.
+type workApplyMetricsController struct {
+	workClient    workclientset.Interface
+	workLister    worklisterv1.ManifestWorkLister
+	clusterLister clusterlisterv1.ManagedClusterLister
+	startTime     time.Time
+
+	lock sync.Mutex
+	// observed is the uid of the observed manifestworks by the key.
+	observed map[string]types.UID
+}
+
+// NewWorkApplyMetricsController creates a controller to observe the apply latency of the manifestworks.
+func NewWorkApplyMetricsController(
+	workClient workclientset.Interface,
+	workInformer workinformerv1.ManifestWorkInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	Register()
+	c := newWorkApplyMetricsController(workClient, workInformer.Lister(), clusterInformer.Lister(), clock.RealClock{})
+	return factory.New().
+		WithInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			key, _ := cache.MetaNamespaceKeyFunc(obj)
+			return []string{key}
+		}, workInformer.Informer()).
+		WithBareInformers(clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("WorkApplyMetricsController", recorder)
+}
+
+func newWorkApplyMetricsController(
+	workClient workclientset.Interface,
+	workLister worklisterv1.ManifestWorkLister,
+	clusterLister clusterlisterv1.ManagedClusterLister,
+	clock clock.PassiveClock) *workApplyMetricsController {
+	return &workApplyMetricsController{
+		workClient:    workClient,
+		workLister:    workLister,
+		clusterLister: clusterLister,
+		startTime:     clock.Now(),
+		observed:      map[string]types.UID{},
+	}
+}
+
+func (c *workApplyMetricsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	key := syncCtx.QueueKey()
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		// ignore the invalid key
+		return nil
+	}
+
+	work, err := c.workLister.ManifestWorks(namespace).Get(name)
+	switch {
+	case errors.IsNotFound(err):
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.observed, key)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	createdOnUnavailableCluster := work.Annotations[CreatedOnUnavailableClusterAnnotation] == "true" ||
+		c.isClusterUnavailableAt(work.Namespace, work.CreationTimestamp)
+
+	applied := meta.FindStatusCondition(work.Status.Conditions, workapiv1.WorkApplied)
+	if applied == nil || applied.Status != metav1.ConditionTrue {
+		if !createdOnUnavailableCluster || work.Annotations[CreatedOnUnavailableClusterAnnotation] == "true" {
+			return nil
+		}
+		newWork := work.DeepCopy()
+		if newWork.Annotations == nil {
+			newWork.Annotations = map[string]string{}
+		}
+		newWork.Annotations[CreatedOnUnavailableClusterAnnotation] = "true"
+		workPatcher := patcher.NewPatcher[
+			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+			c.workClient.WorkV1().ManifestWorks(work.Namespace))
+		_, err := workPatcher.PatchLabelAnnotations(ctx, newWork, newWork.ObjectMeta, work.ObjectMeta)
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.observed[key] == work.UID {
+		return nil
+	}
+	c.observed[key] = work.UID
+	if !applied.LastTransitionTime.After(c.startTime) {
+		return nil
+	}
+
+	latency := applied.LastTransitionTime.Sub(work.CreationTimestamp.Time).Seconds()
+	if createdOnUnavailableCluster {
+		WorkLateApplyDuration.WithLabelValues(work.Namespace).Observe(latency)
+		return nil
+	}
+	WorkApplyDuration.WithLabelValues(work.Namespace).Observe(latency)
+	return nil
+}
+
+// isClusterUnavailableAt returns true if the cluster is tainted unavailable or unreachable at the time.
+func (c *workApplyMetricsController) isClusterUnavailableAt(clusterName string, t metav1.Time) bool {
+	cluster, err := c.clusterLister.Get(clusterName)
+	if err != nil {
+		return false
+	}
+	for _, taint := range cluster.Spec.Taints {
+		if taint.Key != clusterv1.ManagedClusterTaintUnavailable && taint.Key != clusterv1.ManagedClusterTaintUnreachable {
+			continue
+		}
+		if !taint.TimeAdded.After(t.Time) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+var start = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newCluster(name string, taints ...clusterv1.Taint) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       clusterv1.ManagedClusterSpec{Taints: taints},
+	}
+}
+
+func newTaint(key string, added time.Time) clusterv1.Taint {
+	return clusterv1.Taint{
+		Key:       key,
+		Effect:    clusterv1.TaintEffectNoSelect,
+		TimeAdded: metav1.NewTime(added),
+	}
+}
+
+func newWork(cluster string, created time.Time, applied *time.Time, annotations map[string]string) *workapiv1.ManifestWork {
+	work := &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "work1",
+			Namespace:         cluster,
+			UID:               "uid1",
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       annotations,
+		},
+	}
+	if applied != nil {
+		work.Status.Conditions = []metav1.Condition{
+			{
+				Type:               workapiv1.WorkApplied,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(*applied),
+			},
+		}
+	}
+	return work
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func histogramCount(t *testing.T, h *metrics.HistogramVec, cluster string) uint64 {
+	count, err := testutil.GetHistogramMetricCount(h.WithLabelValues(cluster))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestSyncWorkApplyMetrics(t *testing.T) {
+	Register()
+
+	unavailable := map[string]string{CreatedOnUnavailableClusterAnnotation: "true"}
+
+	cases := []struct {
+		name                 string
+		cluster              *clusterv1.ManagedCluster
+		work                 *workapiv1.ManifestWork
+		expectedAnnotated    bool
+		expectedObserved     uint64
+		expectedLateObserved uint64
+	}{
+		{
+			name:    "work created on an available cluster is pending",
+			cluster: newCluster("cluster1"),
+			work:    newWork("cluster1", start.Add(time.Minute), nil, nil),
+		},
+		{
+			name:              "work created on an unavailable cluster is annotated",
+			cluster:           newCluster("cluster2", newTaint(clusterv1.ManagedClusterTaintUnavailable, start)),
+			work:              newWork("cluster2", start.Add(time.Minute), nil, nil),
+			expectedAnnotated: true,
+		},
+		{
+			name:              "work created on an unreachable cluster is annotated",
+			cluster:           newCluster("cluster3", newTaint(clusterv1.ManagedClusterTaintUnreachable, start)),
+			work:              newWork("cluster3", start.Add(time.Minute), nil, nil),
+			expectedAnnotated: true,
+		},
+		{
+			name:    "cluster becomes unavailable after the work is created",
+			cluster: newCluster("cluster4", newTaint(clusterv1.ManagedClusterTaintUnavailable, start.Add(2*time.Minute))),
+			work:    newWork("cluster4", start.Add(time.Minute), nil, nil),
+		},
+		{
+			name:             "work is applied",
+			cluster:          newCluster("cluster5"),
+			work:             newWork("cluster5", start.Add(time.Minute), timePtr(start.Add(2*time.Minute)), nil),
+			expectedObserved: 1,
+		},
+		{
+			name:                 "work created on an unavailable cluster is applied",
+			cluster:              newCluster("cluster6"),
+			work:                 newWork("cluster6", start.Add(time.Minute), timePtr(start.Add(time.Hour)), unavailable),
+			expectedLateObserved: 1,
+		},
+		{
+			name:                 "work is applied while the cluster is still tainted",
+			cluster:              newCluster("cluster7", newTaint(clusterv1.ManagedClusterTaintUnavailable, start)),
+			work:                 newWork("cluster7", start.Add(time.Minute), timePtr(start.Add(time.Hour)), nil),
+			expectedLateObserved: 1,
+		},
+		{
+			name:    "work is applied before the controller starts",
+			cluster: newCluster("cluster8"),
+			work:    newWork("cluster8", start.Add(-time.Hour), timePtr(start.Add(-time.Minute)), nil),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := fakeclusterclient.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			workClient := fakeworkclient.NewSimpleClientset(c.work)
+			workInformerFactory := workinformers.NewSharedInformerFactory(workClient, 10*time.Minute)
+			if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(c.work); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := newWorkApplyMetricsController(workClient,
+				workInformerFactory.Work().V1().ManifestWorks().Lister(),
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				testingclock.NewFakeClock(start))
+
+			observed := histogramCount(t, WorkApplyDuration, c.work.Namespace)
+			lateObserved := histogramCount(t, WorkLateApplyDuration, c.work.Namespace)
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, c.work.Namespace+"/"+c.work.Name)
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+			// the latency is observed once even if the work is synced again
+			if !c.expectedAnnotated {
+				if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if actual := histogramCount(t, WorkApplyDuration, c.work.Namespace); actual != observed+c.expectedObserved {
+				t.Errorf("expected %d apply latency observed, but got %d", observed+c.expectedObserved, actual)
+			}
+			if actual := histogramCount(t, WorkLateApplyDuration, c.work.Namespace); actual != lateObserved+c.expectedLateObserved {
+				t.Errorf("expected %d late apply latency observed, but got %d", lateObserved+c.expectedLateObserved, actual)
+			}
+
+			actions := workClient.Actions()
+			if !c.expectedAnnotated {
+				testingcommon.AssertNoActions(t, actions)
+				return
+			}
+			testingcommon.AssertActions(t, actions, "patch")
+			work := &workapiv1.ManifestWork{}
+			if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, work); err != nil {
+				t.Fatal(err)
+			}
+			if work.Annotations[CreatedOnUnavailableClusterAnnotation] != "true" {
+				t.Errorf("expected the work annotated, but got %v", work.Annotations)
+			}
+		})
+	}
+}
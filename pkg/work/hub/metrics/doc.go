@@ -0,0 +1,2 @@
+// package metrics contains the hub-side controller exposing metrics of the manifestworks
+package metrics
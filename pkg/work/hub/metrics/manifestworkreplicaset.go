@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"k8s.io/component-base/metrics"
+)
+
+const (
+	// OperationCreated, OperationUpdated and OperationDeleted are the operations on the manifestworks by the
+	// ManifestWorkReplicaSet controller.
+	OperationCreated = "created"
+	OperationUpdated = "updated"
+	OperationDeleted = "deleted"
+
+	// SyncResultSuccess and SyncResultError are the results of the syncs of the ManifestWorkReplicaSets.
+	SyncResultSuccess = "success"
+	SyncResultError   = "error"
+)
+
+var (
+	// ManifestWorkReplicaSetTargetClusters is the number of the clusters targeted by each ManifestWorkReplicaSet.
+	ManifestWorkReplicaSetTargetClusters = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "work",
+			Name:           "manifestworkreplicaset_target_clusters",
+			Help:           "Number of the clusters targeted by the ManifestWorkReplicaSet.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// ManifestWorkReplicaSetNotAvailableWorks is the number of the manifestworks of each ManifestWorkReplicaSet
+	// which are not available yet.
+	ManifestWorkReplicaSetNotAvailableWorks = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "work",
+			Name:           "manifestworkreplicaset_not_available_manifestworks",
+			Help:           "Number of the manifestworks of the ManifestWorkReplicaSet which are not available yet.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// ManifestWorkReplicaSetWorkOperations is the number of the manifestworks created, updated and deleted for
+	// each ManifestWorkReplicaSet.
+	ManifestWorkReplicaSetWorkOperations = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "work",
+			Name:           "manifestworkreplicaset_manifestwork_operations_total",
+			Help:           "Number of the manifestworks created, updated and deleted for the ManifestWorkReplicaSet.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "name", "operation"},
+	)
+
+	// ManifestWorkReplicaSetSyncDuration is the duration of the syncs of the ManifestWorkReplicaSets by the result.
+	ManifestWorkReplicaSetSyncDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      "work",
+			Name:           "manifestworkreplicaset_sync_duration_seconds",
+			Help:           "Duration of the syncs of the ManifestWorkReplicaSets.",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+)
+
+// DeleteManifestWorkReplicaSetMetrics deletes the series of the deleted ManifestWorkReplicaSet.
+func DeleteManifestWorkReplicaSetMetrics(namespace, name string) {
+	labels := map[string]string{"namespace": namespace, "name": name}
+	ManifestWorkReplicaSetTargetClusters.Delete(labels)
+	ManifestWorkReplicaSetNotAvailableWorks.Delete(labels)
+	for _, operation := range []string{OperationCreated, OperationUpdated, OperationDeleted} {
+		ManifestWorkReplicaSetWorkOperations.Delete(map[string]string{
+			"namespace": namespace, "name": name, "operation": operation,
+		})
+	}
+}
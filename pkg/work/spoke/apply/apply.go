@@ -15,13 +15,15 @@ import (
 	workapiv1 "open-cluster-management.io/api/work/v1"
 )
 
+// Applier applies the required resource on the managed cluster. It returns the applied object, and whether
+// the object is created or changed by this apply.
 type Applier interface {
 	Apply(ctx context.Context,
 		gvr schema.GroupVersionResource,
 		required *unstructured.Unstructured,
 		owner metav1.OwnerReference,
 		applyOption *workapiv1.ManifestConfigOption,
-		recorder events.Recorder) (runtime.Object, error)
+		recorder events.Recorder) (runtime.Object, bool, error)
 }
 
 type Appliers struct {
@@ -29,7 +29,7 @@ func (c *CreateOnlyApply) Apply(ctx context.Context,
 	required *unstructured.Unstructured,
 	owner metav1.OwnerReference,
 	_ *workapiv1.ManifestConfigOption,
-	recorder events.Recorder) (runtime.Object, error) {
+	recorder events.Recorder) (runtime.Object, bool, error) {
 
 	obj, err := c.client.
 		Resource(gvr).
@@ -43,7 +43,8 @@ func (c *CreateOnlyApply) Apply(ctx context.Context,
 			recorder.Eventf(fmt.Sprintf(
 				"%s Created", required.GetKind()), "Created %s/%s because it was missing", required.GetNamespace(), required.GetName())
 		}
+		return obj, err == nil, err
 	}
 
-	return obj, err
+	return obj, false, err
 }
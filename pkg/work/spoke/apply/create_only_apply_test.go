@@ -23,14 +23,16 @@ func TestCreateOnlyApply(t *testing.T) {
 		existing        *unstructured.Unstructured
 		required        *unstructured.Unstructured
 		gvr             schema.GroupVersionResource
+		expectChanged   bool
 		validateActions func(t *testing.T, actions []clienttesting.Action)
 	}{
 		{
-			name:     "create a non exist object",
-			owner:    metav1.OwnerReference{APIVersion: "v1", Name: "test", UID: "testowner"},
-			existing: nil,
-			required: spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"),
-			gvr:      schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+			name:          "create a non exist object",
+			owner:         metav1.OwnerReference{APIVersion: "v1", Name: "test", UID: "testowner"},
+			existing:      nil,
+			required:      spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"),
+			gvr:           schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+			expectChanged: true,
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testingcommon.AssertActions(t, actions, "get", "create")
 
@@ -73,12 +75,15 @@ func TestCreateOnlyApply(t *testing.T) {
 			applier := NewCreateOnlyApply(dynamicClient)
 
 			syncContext := testingcommon.NewFakeSyncContext(t, "test")
-			obj, err := applier.Apply(
+			obj, changed, err := applier.Apply(
 				context.TODO(), c.gvr, c.required, c.owner, nil, syncContext.Recorder())
 
 			if err != nil {
 				t.Errorf("expect no error, but got %v", obj)
 			}
+			if changed != c.expectChanged {
+				t.Errorf("expect changed %v, but got %v", c.expectChanged, changed)
+			}
 
 			accessor, err := meta.Accessor(obj)
 			if err != nil {
@@ -41,7 +41,7 @@ func (c *ServerSideApply) Apply(
 	required *unstructured.Unstructured,
 	owner metav1.OwnerReference,
 	applyOption *workapiv1.ManifestConfigOption,
-	recorder events.Recorder) (runtime.Object, error) {
+	recorder events.Recorder) (runtime.Object, bool, error) {
 
 	force := false
 	fieldManager := workapiv1.DefaultFieldManager
@@ -55,7 +55,20 @@ func (c *ServerSideApply) Apply(
 
 	patch, err := json.Marshal(required)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	// get the existing object to tell whether the apply changes it, the resourceVersion is not bumped by
+	// the apiserver if the apply is a no-op.
+	existing, err := c.client.
+		Resource(gvr).
+		Namespace(required.GetNamespace()).
+		Get(ctx, required.GetName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return nil, false, err
 	}
 
 	// TODO use Apply method instead when upgrading the client-go to 0.25.x
@@ -70,9 +83,12 @@ func (c *ServerSideApply) Apply(
 	}
 
 	if errors.IsConflict(err) {
-		return obj, &ServerSideApplyConflictError{ssaErr: err}
+		return obj, false, &ServerSideApplyConflictError{ssaErr: err}
+	}
+	if err != nil {
+		return obj, false, err
 	}
 
-	return obj, err
+	return obj, existing == nil || existing.GetResourceVersion() != obj.GetResourceVersion(), nil
 
 }
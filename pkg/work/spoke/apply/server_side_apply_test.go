@@ -30,15 +30,29 @@ func TestServerSideApply(t *testing.T) {
 		required        *unstructured.Unstructured
 		gvr             schema.GroupVersionResource
 		conflict        bool
+		expectChanged   bool
 		validateActions func(t *testing.T, actions []clienttesting.Action)
 	}{
 		{
-			name:            "server side apply successfully",
-			owner:           metav1.OwnerReference{APIVersion: "v1", Name: "test", UID: "testowner"},
-			existing:        nil,
-			required:        spoketesting.NewUnstructured("v1", "Namespace", "", "test"),
-			gvr:             schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {},
+			name:          "server side apply successfully",
+			owner:         metav1.OwnerReference{APIVersion: "v1", Name: "test", UID: "testowner"},
+			existing:      nil,
+			required:      spoketesting.NewUnstructured("v1", "Namespace", "", "test"),
+			gvr:           schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			expectChanged: true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "patch")
+			},
+		},
+		{
+			name:     "server side apply with no change",
+			owner:    metav1.OwnerReference{APIVersion: "v1", Name: "test", UID: "testowner"},
+			existing: newUnstructuredWithResourceVersion("v1", "ConfigMap", "ns1", "test", "1"),
+			required: spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test"),
+			gvr:      schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "patch")
+			},
 		},
 		{
 			name:     "server side apply successfully conflict",
@@ -48,7 +62,7 @@ func TestServerSideApply(t *testing.T) {
 			gvr:      schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
 			conflict: true,
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testingcommon.AssertActions(t, actions, "patch")
+				testingcommon.AssertActions(t, actions, "get", "patch")
 			},
 		},
 	}
@@ -76,13 +90,16 @@ func TestServerSideApply(t *testing.T) {
 					Type: workapiv1.UpdateStrategyTypeServerSideApply,
 				},
 			}
-			obj, err := applier.Apply(
+			obj, changed, err := applier.Apply(
 				context.TODO(), c.gvr, c.required, c.owner, option, syncContext.Recorder())
 			c.validateActions(t, dynamicClient.Actions())
 			if !c.conflict {
 				if err != nil {
 					t.Errorf("expect no error, but got %v", err)
 				}
+				if changed != c.expectChanged {
+					t.Errorf("expect changed %v, but got %v", c.expectChanged, changed)
+				}
 
 				accessor, err := meta.Accessor(obj)
 				if err != nil {
@@ -126,6 +143,8 @@ func (r *reactor) React(action clienttesting.Action) (handled bool, ret runtime.
 	switch action.GetResource().Resource {
 	case "namespaces":
 		return true, spoketesting.NewUnstructured("v1", "Namespace", "", "test"), nil
+	case "configmaps":
+		return true, newUnstructuredWithResourceVersion("v1", "ConfigMap", "ns1", "test", "1"), nil
 	case "secrets":
 		return true, nil, apierrors.NewApplyConflict([]metav1.StatusCause{
 			{
@@ -138,3 +157,9 @@ func (r *reactor) React(action clienttesting.Action) (handled bool, ret runtime.
 
 	return true, nil, fmt.Errorf("PatchType is not supported")
 }
+
+func newUnstructuredWithResourceVersion(apiVersion, kind, namespace, name, resourceVersion string) *unstructured.Unstructured {
+	obj := spoketesting.NewUnstructured(apiVersion, kind, namespace, name)
+	obj.SetResourceVersion(resourceVersion)
+	return obj
+}
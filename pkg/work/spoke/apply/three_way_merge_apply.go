@@ -0,0 +1,189 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/openshift/library-go/pkg/operator/events"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// ThreeWayMergeAnnotation is the annotation on a manifest to opt in the three-way merge with the Update
+	// strategy. The fields removed from the manifest are removed from the resource on the managed cluster,
+	// while the fields added by others on the managed cluster are kept.
+	// TODO move this to the api repo
+	ThreeWayMergeAnnotation = "work.open-cluster-management.io/three-way-merge"
+
+	// LastAppliedConfigAnnotation is the annotation on the resource on the managed cluster to record the
+	// manifest last applied with the three-way merge.
+	LastAppliedConfigAnnotation = "work.open-cluster-management.io/last-applied-configuration"
+)
+
+// isThreeWayMergeEnabled returns true if the manifest opts in the three-way merge.
+func isThreeWayMergeEnabled(required *unstructured.Unstructured) bool {
+	return required.GetAnnotations()[ThreeWayMergeAnnotation] == "true"
+}
+
+// applyThreeWayMerge patches the existing resource with the three-way merge patch computed from the last
+// applied manifest, the required manifest and the existing resource like kubectl apply. The strategic merge
+// patch is used for the types known by the scheme, and the json merge patch is used for the others.
+func (c *UpdateApply) applyThreeWayMerge(
+	ctx context.Context,
+	required *unstructured.Unstructured,
+	owner metav1.OwnerReference,
+	gvr schema.GroupVersionResource,
+	recorder events.Recorder) (*unstructured.Unstructured, bool, error) {
+	// the owner references are not merged here, they are patched after the apply with the other owners kept.
+	modified := required.DeepCopy()
+	lastApplied, err := modified.MarshalJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	annotations := modified.GetAnnotations()
+	annotations[LastAppliedConfigAnnotation] = string(lastApplied)
+	modified.SetAnnotations(annotations)
+
+	existing, err := c.dynamicClient.
+		Resource(gvr).
+		Namespace(required.GetNamespace()).
+		Get(ctx, required.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		modified.SetOwnerReferences([]metav1.OwnerReference{owner})
+		actual, err := c.dynamicClient.Resource(gvr).Namespace(required.GetNamespace()).Create(
+			ctx, modified, metav1.CreateOptions{})
+		recorder.Eventf(fmt.Sprintf(
+			"%s Created", required.GetKind()), "Created %s/%s because it was missing", required.GetNamespace(), required.GetName())
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modifiedData, err := modified.MarshalJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	currentData, err := existing.MarshalJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	var originalData []byte
+	if original, ok := existing.GetAnnotations()[LastAppliedConfigAnnotation]; ok {
+		originalData = []byte(original)
+	}
+
+	patchType := types.StrategicMergePatchType
+	var patch []byte
+	versionedObject, err := scheme.Scheme.New(required.GroupVersionKind())
+	switch {
+	case runtime.IsNotRegisteredError(err):
+		patchType = types.MergePatchType
+		patch, err = createThreeWayJSONMergePatch(originalData, modifiedData, currentData)
+	case err != nil:
+		return nil, false, err
+	default:
+		var lookupPatchMeta strategicpatch.LookupPatchMeta
+		lookupPatchMeta, err = strategicpatch.NewPatchMetaFromStruct(versionedObject)
+		if err != nil {
+			return nil, false, err
+		}
+		patch, err = strategicpatch.CreateThreeWayMergePatch(originalData, modifiedData, currentData, lookupPatchMeta, true)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create three-way merge patch for %s/%s: %w",
+			required.GetNamespace(), required.GetName(), err)
+	}
+
+	if string(patch) == "{}" {
+		return existing, false, nil
+	}
+
+	actual, err := c.dynamicClient.Resource(gvr).Namespace(required.GetNamespace()).Patch(
+		ctx, required.GetName(), patchType, patch, metav1.PatchOptions{})
+	recorder.Eventf(fmt.Sprintf(
+		"%s Updated", required.GetKind()), "Patched %s/%s with three-way merge", required.GetNamespace(), required.GetName())
+	return actual, true, err
+}
+
+// createThreeWayJSONMergePatch creates a json merge patch which changes the current object to the modified
+// object, and deletes the fields in the original object but not in the modified object. The fields only in
+// the current object are kept.
+func createThreeWayJSONMergePatch(original, modified, current []byte) ([]byte, error) {
+	changePatch, err := jsonpatch.CreateMergePatch(current, modified)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]interface{}{}
+	if err := json.Unmarshal(changePatch, &changes); err != nil {
+		return nil, err
+	}
+	changes = filterPatch(changes, false)
+
+	deletions := map[string]interface{}{}
+	if len(original) > 0 {
+		deletionPatch, err := jsonpatch.CreateMergePatch(original, modified)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(deletionPatch, &deletions); err != nil {
+			return nil, err
+		}
+		deletions = filterPatch(deletions, true)
+	}
+
+	// the deletions and the changes never conflict, since a field deleted in the modified object does not
+	// appear in the changes.
+	for key, value := range deletions {
+		changes[key] = mergePatchValue(changes[key], value)
+	}
+	return json.Marshal(changes)
+}
+
+// filterPatch keeps only the deletions (the null values) in the json merge patch if keepDeletions is true,
+// otherwise it removes the deletions from the patch.
+func filterPatch(patch map[string]interface{}, keepDeletions bool) map[string]interface{} {
+	filtered := map[string]interface{}{}
+	for key, value := range patch {
+		switch v := value.(type) {
+		case nil:
+			if keepDeletions {
+				filtered[key] = nil
+			}
+		case map[string]interface{}:
+			if sub := filterPatch(v, keepDeletions); len(sub) > 0 || (!keepDeletions && len(v) == 0) {
+				filtered[key] = sub
+			}
+		default:
+			if !keepDeletions {
+				filtered[key] = value
+			}
+		}
+	}
+	return filtered
+}
+
+// mergePatchValue merges the deletions into the changes of the same field.
+func mergePatchValue(change, deletion interface{}) interface{} {
+	changeMap, ok := change.(map[string]interface{})
+	if !ok {
+		return deletion
+	}
+	deletionMap, ok := deletion.(map[string]interface{})
+	if !ok {
+		return deletion
+	}
+	for key, value := range deletionMap {
+		changeMap[key] = mergePatchValue(changeMap[key], value)
+	}
+	return changeMap
+}
@@ -0,0 +1,259 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func newThreeWayMergeDeployment(labels map[string]interface{}, env ...string) *unstructured.Unstructured {
+	envs := []interface{}{}
+	for _, name := range env {
+		envs = append(envs, map[string]interface{}{"name": name, "value": name})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":        "test",
+			"namespace":   "ns1",
+			"labels":      labels,
+			"annotations": map[string]interface{}{ThreeWayMergeAnnotation: "true"},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "test", "image": "test:v1", "env": envs},
+					},
+				},
+			},
+		},
+	}}
+	return obj
+}
+
+func newThreeWayMergeCustomResource(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "test.io/v1",
+		"kind":       "Test",
+		"metadata": map[string]interface{}{
+			"name":        "test",
+			"namespace":   "ns1",
+			"annotations": map[string]interface{}{ThreeWayMergeAnnotation: "true"},
+		},
+		"spec": spec,
+	}}
+}
+
+// withLastApplied sets the last applied annotation of the existing resource with the manifest, and the
+// fields added on the managed cluster.
+func withLastApplied(t *testing.T, existing, lastApplied *unstructured.Unstructured,
+	mutate func(obj *unstructured.Unstructured)) *unstructured.Unstructured {
+	data, err := lastApplied.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing = existing.DeepCopy()
+	annotations := existing.GetAnnotations()
+	annotations[LastAppliedConfigAnnotation] = string(data)
+	existing.SetAnnotations(annotations)
+	if mutate != nil {
+		mutate(existing)
+	}
+	return existing
+}
+
+func TestThreeWayMergeApply(t *testing.T) {
+	deploymentGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	customResourceGVR := schema.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "tests"}
+	addSpokeFields := func(obj *unstructured.Unstructured) {
+		labels := obj.GetLabels()
+		labels["spoke"] = "added"
+		obj.SetLabels(labels)
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		containers[0].(map[string]interface{})["imagePullPolicy"] = "Always"
+		_ = unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+	}
+
+	cases := []struct {
+		name          string
+		existing      *unstructured.Unstructured
+		required      *unstructured.Unstructured
+		gvr           schema.GroupVersionResource
+		expectChanged bool
+		validate      func(t *testing.T, actions []clienttesting.Action, existing *unstructured.Unstructured)
+	}{
+		{
+			name:          "create the resource with the last applied annotation",
+			required:      newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A"),
+			gvr:           deploymentGVR,
+			expectChanged: true,
+			validate: func(t *testing.T, actions []clienttesting.Action, _ *unstructured.Unstructured) {
+				testingcommon.AssertActions(t, actions, "get", "create")
+				obj := actions[1].(clienttesting.CreateActionImpl).Object.(*unstructured.Unstructured)
+				if _, ok := obj.GetAnnotations()[LastAppliedConfigAnnotation]; !ok {
+					t.Errorf("expected last applied annotation, but got %v", obj.GetAnnotations())
+				}
+				if len(obj.GetOwnerReferences()) != 1 {
+					t.Errorf("expected owner, but got %v", obj.GetOwnerReferences())
+				}
+			},
+		},
+		{
+			name: "remove a label and keep the label added on the spoke",
+			existing: withLastApplied(t,
+				newThreeWayMergeDeployment(map[string]interface{}{"a": "1", "b": "2"}, "A"),
+				newThreeWayMergeDeployment(map[string]interface{}{"a": "1", "b": "2"}, "A"),
+				addSpokeFields),
+			required:      newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A"),
+			gvr:           deploymentGVR,
+			expectChanged: true,
+			validate: func(t *testing.T, actions []clienttesting.Action, existing *unstructured.Unstructured) {
+				deployment := applyStrategicPatch(t, actions, existing)
+				expectedLabels := map[string]string{"a": "1", "spoke": "added"}
+				if !equality.Semantic.DeepEqual(deployment.Labels, expectedLabels) {
+					t.Errorf("expected labels %v, but got %v", expectedLabels, deployment.Labels)
+				}
+			},
+		},
+		{
+			name: "remove a container env var and keep the fields added on the spoke",
+			existing: withLastApplied(t,
+				newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A", "B"),
+				newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A", "B"),
+				addSpokeFields),
+			required:      newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A"),
+			gvr:           deploymentGVR,
+			expectChanged: true,
+			validate: func(t *testing.T, actions []clienttesting.Action, existing *unstructured.Unstructured) {
+				deployment := applyStrategicPatch(t, actions, existing)
+				container := deployment.Spec.Template.Spec.Containers[0]
+				if len(container.Env) != 1 || container.Env[0].Name != "A" {
+					t.Errorf("expected env A only, but got %v", container.Env)
+				}
+				if container.ImagePullPolicy != "Always" {
+					t.Errorf("expected the imagePullPolicy added on the spoke is kept, but got %v", container.ImagePullPolicy)
+				}
+				if deployment.Labels["spoke"] != "added" {
+					t.Errorf("expected the label added on the spoke is kept, but got %v", deployment.Labels)
+				}
+			},
+		},
+		{
+			name: "remove a field of a custom resource and keep the field added on the spoke",
+			existing: withLastApplied(t,
+				newThreeWayMergeCustomResource(map[string]interface{}{"a": "1", "b": "2", "c": "3"}),
+				newThreeWayMergeCustomResource(map[string]interface{}{"a": "1", "b": "2"}),
+				nil),
+			required:      newThreeWayMergeCustomResource(map[string]interface{}{"a": "2"}),
+			gvr:           customResourceGVR,
+			expectChanged: true,
+			validate: func(t *testing.T, actions []clienttesting.Action, existing *unstructured.Unstructured) {
+				testingcommon.AssertActions(t, actions, "get", "patch")
+				patchAction := actions[1].(clienttesting.PatchActionImpl)
+				if patchAction.GetPatchType() != types.MergePatchType {
+					t.Errorf("expected json merge patch, but got %s", patchAction.GetPatchType())
+				}
+				current, err := existing.MarshalJSON()
+				if err != nil {
+					t.Fatal(err)
+				}
+				patched, err := jsonpatch.MergePatch(current, patchAction.GetPatch())
+				if err != nil {
+					t.Fatal(err)
+				}
+				obj := &unstructured.Unstructured{}
+				if err := obj.UnmarshalJSON(patched); err != nil {
+					t.Fatal(err)
+				}
+				spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+				expectedSpec := map[string]interface{}{"a": "2", "c": "3"}
+				if !equality.Semantic.DeepEqual(spec, expectedSpec) {
+					t.Errorf("expected spec %v, but got %v", expectedSpec, spec)
+				}
+			},
+		},
+		{
+			name: "no change with the fields added on the spoke",
+			existing: withLastApplied(t,
+				newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A"),
+				newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A"),
+				addSpokeFields),
+			required: newThreeWayMergeDeployment(map[string]interface{}{"a": "1"}, "A"),
+			gvr:      deploymentGVR,
+			validate: func(t *testing.T, actions []clienttesting.Action, _ *unstructured.Unstructured) {
+				testingcommon.AssertActions(t, actions, "get")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objects := []runtime.Object{}
+			if c.existing != nil {
+				objects = append(objects, c.existing)
+			}
+			dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					deploymentGVR:     "DeploymentList",
+					customResourceGVR: "TestList",
+				}, objects...)
+			// the fake client cannot handle the strategic merge patch of the unstructured object, the patch
+			// is verified in the test cases.
+			dynamicClient.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, c.existing, nil
+			})
+
+			applier := NewUpdateApply(dynamicClient, nil, nil)
+			syncContext := testingcommon.NewFakeSyncContext(t, "test")
+			owner := metav1.OwnerReference{APIVersion: "v1", Name: "test", UID: "testowner"}
+			_, changed, err := applier.Apply(
+				context.TODO(), c.gvr, c.required.DeepCopy(), owner, nil, syncContext.Recorder())
+			if err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if changed != c.expectChanged {
+				t.Errorf("expect changed %v, but got %v", c.expectChanged, changed)
+			}
+
+			c.validate(t, dynamicClient.Actions(), c.existing)
+		})
+	}
+}
+
+func applyStrategicPatch(t *testing.T, actions []clienttesting.Action, existing *unstructured.Unstructured) *appsv1.Deployment {
+	testingcommon.AssertActions(t, actions, "get", "patch")
+	patchAction := actions[1].(clienttesting.PatchActionImpl)
+	if patchAction.GetPatchType() != types.StrategicMergePatchType {
+		t.Errorf("expected strategic merge patch, but got %s", patchAction.GetPatchType())
+	}
+
+	current, err := existing.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, err := strategicpatch.StrategicMergePatch(current, patchAction.GetPatch(), &appsv1.Deployment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := json.Unmarshal(patched, deployment); err != nil {
+		t.Fatal(err)
+	}
+	return deployment
+}
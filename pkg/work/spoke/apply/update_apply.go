@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
@@ -36,17 +37,39 @@ func NewUpdateApply(dynamicClient dynamic.Interface, kubeclient kubernetes.Inter
 		apiExtensionClient: apiExtensionClient,
 		// TODO we did not gc resources in cache, which may cause more memory usage. It
 		// should be refactored using own cache implementation in the future.
-		staticResourceCache: resourceapply.NewResourceCache(),
+		staticResourceCache: &safeResourceCache{cache: resourceapply.NewResourceCache()},
 	}
 }
 
+// safeResourceCache guards the resource cache with a lock, since manifests may be applied in parallel.
+type safeResourceCache struct {
+	lock  sync.Mutex
+	cache resourceapply.ResourceCache
+}
+
+func (c *safeResourceCache) UpdateCachedResourceMetadata(required runtime.Object, actual runtime.Object) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cache.UpdateCachedResourceMetadata(required, actual)
+}
+
+func (c *safeResourceCache) SafeToSkipApply(required runtime.Object, existing runtime.Object) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.cache.SafeToSkipApply(required, existing)
+}
+
 func (c *UpdateApply) Apply(
 	ctx context.Context,
 	gvr schema.GroupVersionResource,
 	required *unstructured.Unstructured,
 	owner metav1.OwnerReference,
 	_ *workapiv1.ManifestConfigOption,
-	recorder events.Recorder) (runtime.Object, error) {
+	recorder events.Recorder) (runtime.Object, bool, error) {
+
+	if isThreeWayMergeEnabled(required) {
+		return c.applyThreeWayMerge(ctx, required, owner, gvr, recorder)
+	}
 
 	clientHolder := resourceapply.NewClientHolder().
 		WithAPIExtensionsClient(c.apiExtensionClient).
@@ -58,23 +81,23 @@ func (c *UpdateApply) Apply(
 		return required.MarshalJSON()
 	}, "manifest")
 
-	obj, err := results[0].Result, results[0].Error
+	obj, changed, err := results[0].Result, results[0].Changed, results[0].Error
 
 	// Try apply with dynamic client if the manifest cannot be decoded by scheme or typed client is not found
 	// TODO we should check the certain error.
 	// Use dynamic client when scheme cannot decode manifest or typed client cannot handle the object
 	if isDecodeError(err) || isUnhandledError(err) || isUnsupportedError(err) {
-		obj, _, err = c.applyUnstructured(ctx, required, gvr, recorder)
+		obj, changed, err = c.applyUnstructured(ctx, required, gvr, recorder)
 	}
 
 	if err == nil && (!reflect.ValueOf(obj).IsValid() || reflect.ValueOf(obj).IsNil()) {
 		// ApplyDirectly may return a nil Result when there is no error, we get the latest object for the Result
-		return c.dynamicClient.
+		obj, err = c.dynamicClient.
 			Resource(gvr).
 			Namespace(required.GetNamespace()).
 			Get(ctx, required.GetName(), metav1.GetOptions{})
 	}
-	return obj, err
+	return obj, changed, err
 }
 
 func (c *UpdateApply) applyUnstructured(
@@ -362,7 +362,7 @@ func TestUpdateApplyKube(t *testing.T) {
 			applier := NewUpdateApply(nil, kubeclient, nil)
 
 			syncContext := testingcommon.NewFakeSyncContext(t, "test")
-			obj, err := applier.Apply(
+			obj, _, err := applier.Apply(
 				context.TODO(), c.gvr, c.required, c.owner, nil, syncContext.Recorder())
 
 			if err != nil {
@@ -431,7 +431,7 @@ func TestUpdateApplyDynamic(t *testing.T) {
 			applier := NewUpdateApply(dynamicclient, nil, nil)
 
 			syncContext := testingcommon.NewFakeSyncContext(t, "test")
-			obj, err := applier.Apply(
+			obj, _, err := applier.Apply(
 				context.TODO(), c.gvr, c.required, c.owner, nil, syncContext.Recorder())
 
 			if err != nil {
@@ -511,7 +511,7 @@ func TestUpdateApplyApiExtension(t *testing.T) {
 			applier := NewUpdateApply(nil, nil, apiextensionClient)
 
 			syncContext := testingcommon.NewFakeSyncContext(t, "test")
-			obj, err := applier.Apply(
+			obj, _, err := applier.Apply(
 				context.TODO(), c.gvr, c.required, c.owner, nil, syncContext.Recorder())
 
 			if err != nil {
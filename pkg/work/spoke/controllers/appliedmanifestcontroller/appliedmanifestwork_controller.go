@@ -16,8 +16,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	corev1informer "k8s.io/client-go/informers/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
 	workinformer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
@@ -26,10 +31,12 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
 )
 
 // AppliedManifestWorkController is to sync the applied resources of appliedmanifestwork with related
-// manifestwork and delete any resource which is no longer maintained by the manifestwork
+// manifestwork and delete any resource which is no longer maintained by the manifestwork. It also summarizes
+// the number of the applied resources by group, version and resource.
 type AppliedManifestWorkController struct {
 	patcher                   patcher.Patcher[*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus]
 	manifestWorkLister        worklister.ManifestWorkNamespaceLister
@@ -37,9 +44,21 @@ type AppliedManifestWorkController struct {
 	spokeDynamicClient        dynamic.Interface
 	hubHash                   string
 	rateLimiter               workqueue.RateLimiter
+	summaries                 summaryRecorder
+	// contentHashPruneTTL is the max duration the superseded instances of the content hash named ConfigMaps and
+	// Secrets are retained while pods reference them, they are checked again every contentHashPruneInterval.
+	contentHashPruneTTL      time.Duration
+	contentHashPruneInterval time.Duration
+	// contentHashListers are the metadata listers of the ConfigMaps and Secrets by resource, and podLister lists
+	// the pods referencing them. They are nil if the superseded instances are not retained.
+	contentHashListers map[string]cache.GenericLister
+	podLister          corev1lister.PodLister
+	clock              clock.PassiveClock
 }
 
-// NewAppliedManifestWorkController returns a AppliedManifestWorkController
+// NewAppliedManifestWorkController returns a AppliedManifestWorkController. The pod, ConfigMap and Secret informers
+// are only required to retain the superseded instances of the content hash named ConfigMaps and Secrets, they are
+// nil if the contentHashPruneTTL is 0.
 func NewAppliedManifestWorkController(
 	recorder events.Recorder,
 	spokeDynamicClient dynamic.Interface,
@@ -47,7 +66,10 @@ func NewAppliedManifestWorkController(
 	manifestWorkLister worklister.ManifestWorkNamespaceLister,
 	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface,
 	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
-	hubHash string) factory.Controller {
+	hubHash string,
+	contentHashPruneTTL, contentHashPruneInterval time.Duration,
+	podInformer corev1informer.PodInformer,
+	configMapInformer, secretInformer informers.GenericInformer) factory.Controller {
 
 	controller := &AppliedManifestWorkController{
 		patcher: patcher.NewPatcher[
@@ -58,9 +80,23 @@ func NewAppliedManifestWorkController(
 		spokeDynamicClient:        spokeDynamicClient,
 		hubHash:                   hubHash,
 		rateLimiter:               workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+		contentHashPruneTTL:       contentHashPruneTTL,
+		contentHashPruneInterval:  contentHashPruneInterval,
+		clock:                     clock.RealClock{},
 	}
+	RegisterMetrics()
 
-	return factory.New().
+	f := factory.New()
+	if contentHashPruneTTL > 0 {
+		controller.contentHashListers = map[string]cache.GenericLister{
+			"configmaps": configMapInformer.Lister(),
+			"secrets":    secretInformer.Lister(),
+		}
+		controller.podLister = podInformer.Lister()
+		f = f.WithBareInformers(podInformer.Informer(), configMapInformer.Informer(), secretInformer.Informer())
+	}
+
+	return f.
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
@@ -76,9 +112,11 @@ func (m *AppliedManifestWorkController) sync(ctx context.Context, controllerCont
 	manifestWorkName := controllerContext.QueueKey()
 	klog.V(4).Infof("Reconciling ManifestWork %q", manifestWorkName)
 
+	appliedManifestWorkName := fmt.Sprintf("%s-%s", m.hubHash, manifestWorkName)
 	manifestWork, err := m.manifestWorkLister.Get(manifestWorkName)
 	if errors.IsNotFound(err) {
 		// work not found, could have been deleted, do nothing.
+		m.summaries.forget(appliedManifestWorkName)
 		return nil
 	}
 	if err != nil {
@@ -86,13 +124,14 @@ func (m *AppliedManifestWorkController) sync(ctx context.Context, controllerCont
 	}
 	// no work to do if we're deleted
 	if !manifestWork.DeletionTimestamp.IsZero() {
+		m.summaries.forget(appliedManifestWorkName)
 		return nil
 	}
 
-	appliedManifestWorkName := fmt.Sprintf("%s-%s", m.hubHash, manifestWork.Name)
 	appliedManifestWork, err := m.appliedManifestWorkLister.Get(appliedManifestWorkName)
 	if errors.IsNotFound(err) {
 		// appliedmanifestwork not found, could have been deleted, do nothing.
+		m.summaries.forget(appliedManifestWorkName)
 		return nil
 	}
 	if err != nil {
@@ -100,6 +139,7 @@ func (m *AppliedManifestWorkController) sync(ctx context.Context, controllerCont
 	}
 	// no work to do if we're deleted
 	if !appliedManifestWork.DeletionTimestamp.IsZero() {
+		m.summaries.forget(appliedManifestWorkName)
 		return nil
 	}
 
@@ -121,30 +161,32 @@ func (m *AppliedManifestWorkController) syncManifestWork(
 	var appliedResources []workapiv1.AppliedManifestResourceMeta
 	var errs []error
 	for _, resourceStatus := range manifestWork.Status.ResourceStatus.Manifests {
+		// the content hash named ConfigMaps and Secrets are applied with the hashed names
+		name := transformer.AppliedName(manifestWork.Spec.Workload.Manifests, resourceStatus.ResourceMeta)
 		gvr := schema.GroupVersionResource{
 			Group:    resourceStatus.ResourceMeta.Group,
 			Version:  resourceStatus.ResourceMeta.Version,
 			Resource: resourceStatus.ResourceMeta.Resource,
 		}
-		if len(gvr.Resource) == 0 || len(gvr.Version) == 0 || len(resourceStatus.ResourceMeta.Name) == 0 {
+		if len(gvr.Resource) == 0 || len(gvr.Version) == 0 || len(name) == 0 {
 			continue
 		}
 
 		u, err := m.spokeDynamicClient.
 			Resource(gvr).
 			Namespace(resourceStatus.ResourceMeta.Namespace).
-			Get(context.TODO(), resourceStatus.ResourceMeta.Name, metav1.GetOptions{})
+			Get(context.TODO(), name, metav1.GetOptions{})
 		if errors.IsNotFound(err) {
 			klog.V(2).Infof(
 				"Resource %v with key %s/%s does not exist",
-				gvr, resourceStatus.ResourceMeta.Namespace, resourceStatus.ResourceMeta.Name)
+				gvr, resourceStatus.ResourceMeta.Namespace, name)
 			continue
 		}
 
 		if err != nil {
 			errs = append(errs, fmt.Errorf(
 				"failed to get resource %v with key %s/%s: %w",
-				gvr, resourceStatus.ResourceMeta.Namespace, resourceStatus.ResourceMeta.Name, err))
+				gvr, resourceStatus.ResourceMeta.Namespace, name, err))
 			continue
 		}
 
@@ -153,7 +195,7 @@ func (m *AppliedManifestWorkController) syncManifestWork(
 				Group:     resourceStatus.ResourceMeta.Group,
 				Resource:  resourceStatus.ResourceMeta.Resource,
 				Namespace: resourceStatus.ResourceMeta.Namespace,
-				Name:      resourceStatus.ResourceMeta.Name,
+				Name:      name,
 			},
 			Version: resourceStatus.ResourceMeta.Version,
 			UID:     string(u.GetUID()),
@@ -168,15 +210,26 @@ func (m *AppliedManifestWorkController) syncManifestWork(
 	// delete applied resources which are no longer maintained by manifest work
 	noLongerMaintainedResources := findUntrackedResources(appliedManifestWork.Status.AppliedResources, appliedResources)
 
+	// the superseded instances of the content hash named ConfigMaps and Secrets are kept in the applied resources
+	// until no pod references them, so the workloads are rolled before they are deleted.
+	retainedResources, noLongerMaintainedResources, retainAfter, err := m.retainSupersededInstances(ctx, noLongerMaintainedResources)
+	if err != nil {
+		return err
+	}
+	if len(retainedResources) > 0 {
+		controllerContext.Queue().AddAfter(manifestWork.Name, retainAfter)
+	}
+
 	reason := fmt.Sprintf("it is no longer maintained by manifestwork %s", manifestWork.Name)
 
 	resourcesPendingFinalization, errs := helper.DeleteAppliedResources(
-		ctx, noLongerMaintainedResources, reason, m.spokeDynamicClient, controllerContext.Recorder(), *owner)
+		ctx, noLongerMaintainedResources, reason, m.spokeDynamicClient, controllerContext.Recorder(), *owner, m.hubHash)
 	if len(errs) != 0 {
 		return utilerrors.NewAggregate(errs)
 	}
 
 	appliedResources = append(appliedResources, resourcesPendingFinalization...)
+	appliedResources = append(appliedResources, retainedResources...)
 
 	// sort applied resources
 	sort.SliceStable(appliedResources, func(i, j int) bool {
@@ -200,7 +253,9 @@ func (m *AppliedManifestWorkController) syncManifestWork(
 		if len(resourcesPendingFinalization) != 0 {
 			controllerContext.Queue().AddAfter(manifestWork.Name, m.rateLimiter.When(manifestWork.Name))
 		}
-		return nil
+		// the summary is updated once the applied resources are unchanged, so it does not conflict with the
+		// status update, which requeues the work.
+		return m.patchSummary(ctx, originalAppliedManifestWork)
 	}
 
 	// reset the rate limiter for the manifest work
@@ -211,7 +266,7 @@ func (m *AppliedManifestWorkController) syncManifestWork(
 	// update appliedmanifestwork status with latest applied resources. if this conflicts, we'll try again later
 	// for retrying update without reassessing the status can cause overwriting of valid information.
 	appliedManifestWork.Status.AppliedResources = appliedResources
-	_, err := m.patcher.PatchStatus(ctx, appliedManifestWork, appliedManifestWork.Status, originalAppliedManifestWork.Status)
+	_, err = m.patcher.PatchStatus(ctx, appliedManifestWork, appliedManifestWork.Status, originalAppliedManifestWork.Status)
 	return err
 }
 
@@ -197,6 +197,9 @@ func TestSyncManifestWork(t *testing.T) {
 			testingWork, _ := spoketesting.NewManifestWork(0)
 			testingAppliedWork := appliedWork.DeepCopy()
 			testingAppliedWork.Status.AppliedResources = c.appliedResources
+			testingAppliedWork.Annotations = map[string]string{
+				AppliedResourceSummaryAnnotation: newResourceSummary(c.appliedResources).annotation(),
+			}
 			testingWork.Status.ResourceStatus.Manifests = c.manifests
 
 			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), c.existingResources...)
@@ -0,0 +1,218 @@
+package appliedmanifestcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
+	workinformer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
+)
+
+// AppliedManifestWorkMigrationController migrates the appliedmanifestworks of the previous hub hashes to the
+// current hub hash, e.g. after the hub apiserver url is renamed, so the applied resources are not evicted.
+//
+// An appliedmanifestwork of a previous hub hash is migrated only if its manifestwork still exists on the hub:
+//   - the appliedmanifestwork of the current hub hash is created with the applied resources,
+//   - the owner of the new appliedmanifestwork is added to each applied resource,
+//   - the appliedmanifestwork of the previous hub hash is deleted, its owner is removed from the applied
+//     resources by the finalizer since they are owned by the new appliedmanifestwork.
+//
+// Each step is skipped if it is done already, so it is safe to run the migration repeatedly.
+type AppliedManifestWorkMigrationController struct {
+	patcher                   patcher.Patcher[*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus]
+	manifestWorkLister        worklister.ManifestWorkNamespaceLister
+	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface
+	appliedManifestWorkLister worklister.AppliedManifestWorkLister
+	spokeDynamicClient        dynamic.Interface
+	previousHubHashes         sets.Set[string]
+	hubHash                   string
+	agentID                   string
+}
+
+// NewAppliedManifestWorkMigrationController returns a AppliedManifestWorkMigrationController
+func NewAppliedManifestWorkMigrationController(
+	recorder events.Recorder,
+	spokeDynamicClient dynamic.Interface,
+	manifestWorkInformer workinformer.ManifestWorkInformer,
+	manifestWorkLister worklister.ManifestWorkNamespaceLister,
+	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface,
+	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
+	previousHubHashes []string,
+	hubHash, agentID string) factory.Controller {
+
+	controller := &AppliedManifestWorkMigrationController{
+		patcher: patcher.NewPatcher[
+			*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus](
+			appliedManifestWorkClient),
+		manifestWorkLister:        manifestWorkLister,
+		appliedManifestWorkClient: appliedManifestWorkClient,
+		appliedManifestWorkLister: appliedManifestWorkInformer.Lister(),
+		spokeDynamicClient:        spokeDynamicClient,
+		previousHubHashes:         sets.New[string](previousHubHashes...).Delete(hubHash),
+		hubHash:                   hubHash,
+		agentID:                   agentID,
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			accessor, _ := meta.Accessor(obj)
+			var keys []string
+			for _, previousHubHash := range controller.previousHubHashes.UnsortedList() {
+				keys = append(keys, fmt.Sprintf("%s-%s", previousHubHash, accessor.GetName()))
+			}
+			return keys
+		}, manifestWorkInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName()
+			},
+			func(obj interface{}) bool {
+				appliedManifestWork, ok := obj.(*workapiv1.AppliedManifestWork)
+				return ok && controller.previousHubHashes.Has(appliedManifestWork.Spec.HubHash)
+			},
+			appliedManifestWorkInformer.Informer()).
+		WithSync(controller.sync).ToController("AppliedManifestWorkMigrationController", recorder)
+}
+
+func (m *AppliedManifestWorkMigrationController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	appliedManifestWorkName := controllerContext.QueueKey()
+	klog.V(4).Infof("Migrating AppliedManifestWork %q", appliedManifestWorkName)
+
+	appliedManifestWork, err := m.appliedManifestWorkLister.Get(appliedManifestWorkName)
+	switch {
+	case errors.IsNotFound(err):
+		// the appliedmanifestwork is migrated already
+		return nil
+	case err != nil:
+		return err
+	}
+	if !m.previousHubHashes.Has(appliedManifestWork.Spec.HubHash) || !appliedManifestWork.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	_, err = m.manifestWorkLister.Get(appliedManifestWork.Spec.ManifestWorkName)
+	switch {
+	case errors.IsNotFound(err):
+		// the manifestwork is missing on the hub, the appliedmanifestwork is evicted instead of migrated
+		return nil
+	case err != nil:
+		return err
+	}
+
+	migratedAppliedManifestWork, err := m.applyMigratedAppliedManifestWork(ctx, appliedManifestWork)
+	if err != nil {
+		return err
+	}
+
+	owner := helper.NewAppliedManifestWorkOwner(appliedManifestWork)
+	migratedOwner := helper.NewAppliedManifestWorkOwner(migratedAppliedManifestWork)
+	var errs []error
+	for _, resource := range appliedManifestWork.Status.AppliedResources {
+		if err := m.migrateOwner(ctx, resource, *owner, *migratedOwner); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	klog.V(2).Infof("Delete appliedWork %s after it is migrated to %s", appliedManifestWork.Name, migratedAppliedManifestWork.Name)
+	err = m.appliedManifestWorkClient.Delete(ctx, appliedManifestWork.Name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// applyMigratedAppliedManifestWork creates the appliedmanifestwork of the current hub hash if it does not exist, and
+// adds the applied resources of the previous appliedmanifestwork to its status.
+func (m *AppliedManifestWorkMigrationController) applyMigratedAppliedManifestWork(
+	ctx context.Context, appliedManifestWork *workapiv1.AppliedManifestWork) (*workapiv1.AppliedManifestWork, error) {
+	migratedName := fmt.Sprintf("%s-%s", m.hubHash, appliedManifestWork.Spec.ManifestWorkName)
+	migratedAppliedManifestWork, err := m.appliedManifestWorkLister.Get(migratedName)
+	switch {
+	case errors.IsNotFound(err):
+		migratedAppliedManifestWork, err = m.appliedManifestWorkClient.Create(ctx, &workapiv1.AppliedManifestWork{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       migratedName,
+				Finalizers: []string{controllers.AppliedManifestWorkFinalizer},
+			},
+			Spec: workapiv1.AppliedManifestWorkSpec{
+				HubHash:          m.hubHash,
+				ManifestWorkName: appliedManifestWork.Spec.ManifestWorkName,
+				AgentID:          m.agentID,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	newAppliedManifestWork := migratedAppliedManifestWork.DeepCopy()
+	for _, resource := range appliedManifestWork.Status.AppliedResources {
+		if !hasAppliedResource(newAppliedManifestWork.Status.AppliedResources, resource) {
+			newAppliedManifestWork.Status.AppliedResources = append(newAppliedManifestWork.Status.AppliedResources, resource)
+		}
+	}
+	_, err = m.patcher.PatchStatus(ctx, newAppliedManifestWork, newAppliedManifestWork.Status, migratedAppliedManifestWork.Status)
+	return migratedAppliedManifestWork, err
+}
+
+// migrateOwner adds the migrated owner to the applied resource owned by the previous appliedmanifestwork, and
+// updates the source hub hash annotation of the resource if it is stamped.
+func (m *AppliedManifestWorkMigrationController) migrateOwner(ctx context.Context,
+	resource workapiv1.AppliedManifestResourceMeta, owner, migratedOwner metav1.OwnerReference) error {
+	gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource}
+	u, err := m.spokeDynamicClient.Resource(gvr).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get resource %v with key %s/%s: %w", gvr, resource.Namespace, resource.Name, err)
+	}
+
+	if !helper.IsOwnedBy(owner, u.GetOwnerReferences()) {
+		return nil
+	}
+
+	var requiredAnnotations map[string]string
+	if _, ok := u.GetAnnotations()[helper.SourceHubHashAnnotation]; ok {
+		requiredAnnotations = map[string]string{helper.SourceHubHashAnnotation: m.hubHash}
+	}
+	if err := helper.ApplyOwnerReferencesAndAnnotations(
+		ctx, m.spokeDynamicClient, gvr, u, migratedOwner, requiredAnnotations); err != nil {
+		return fmt.Errorf("failed to migrate owner of resource %v with key %s/%s: %w",
+			gvr, resource.Namespace, resource.Name, err)
+	}
+	return nil
+}
+
+func hasAppliedResource(resources []workapiv1.AppliedManifestResourceMeta, resource workapiv1.AppliedManifestResourceMeta) bool {
+	for _, r := range resources {
+		if r.ResourceIdentifier == resource.ResourceIdentifier && r.Version == resource.Version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package appliedmanifestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestSyncMigrateAppliedManifestWork(t *testing.T) {
+	work, _ := spoketesting.NewManifestWork(0)
+	oldAppliedWork := spoketesting.NewAppliedManifestWork("old", 0, types.UID("old"))
+	oldAppliedWork.Status.AppliedResources = []workapiv1.AppliedManifestResourceMeta{
+		{Version: "v1", ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "secrets", Namespace: "ns1", Name: "n1"}, UID: "ns1-n1"},
+	}
+	oldOwner := helper.NewAppliedManifestWorkOwner(oldAppliedWork)
+	newAppliedWork := spoketesting.NewAppliedManifestWork("new", 0, types.UID("new"))
+	newAppliedWork.Status.AppliedResources = oldAppliedWork.Status.AppliedResources
+	newOwner := helper.NewAppliedManifestWorkOwner(newAppliedWork)
+
+	newSecret := func(owners ...metav1.OwnerReference) runtime.Object {
+		secret := spoketesting.NewUnstructuredSecret("ns1", "n1", false, "ns1-n1", owners...)
+		secret.SetAnnotations(map[string]string{helper.SourceHubHashAnnotation: "old"})
+		return secret
+	}
+
+	cases := []struct {
+		name                               string
+		appliedManifestWorkName            string
+		works                              []runtime.Object
+		appliedWorks                       []runtime.Object
+		existingResources                  []runtime.Object
+		validateAppliedManifestWorkActions func(t *testing.T, actions []clienttesting.Action)
+		validateResourceActions            func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:                               "appliedmanifestwork is migrated already",
+			appliedManifestWorkName:            "old-work-0",
+			works:                              []runtime.Object{work},
+			appliedWorks:                       []runtime.Object{newAppliedWork},
+			validateAppliedManifestWorkActions: testingcommon.AssertNoActions,
+			validateResourceActions:            testingcommon.AssertNoActions,
+		},
+		{
+			name:                               "appliedmanifestwork of the current hub hash",
+			appliedManifestWorkName:            "new-work-0",
+			works:                              []runtime.Object{work},
+			appliedWorks:                       []runtime.Object{newAppliedWork},
+			validateAppliedManifestWorkActions: testingcommon.AssertNoActions,
+			validateResourceActions:            testingcommon.AssertNoActions,
+		},
+		{
+			name:                               "manifestwork is missing on the hub",
+			appliedManifestWorkName:            "old-work-0",
+			appliedWorks:                       []runtime.Object{oldAppliedWork},
+			existingResources:                  []runtime.Object{newSecret(*oldOwner)},
+			validateAppliedManifestWorkActions: testingcommon.AssertNoActions,
+			validateResourceActions:            testingcommon.AssertNoActions,
+		},
+		{
+			name:                    "migrate appliedmanifestwork to the current hub hash",
+			appliedManifestWorkName: "old-work-0",
+			works:                   []runtime.Object{work},
+			appliedWorks:            []runtime.Object{oldAppliedWork},
+			existingResources:       []runtime.Object{newSecret(*oldOwner)},
+			validateAppliedManifestWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "patch", "delete")
+				created := actions[0].(clienttesting.CreateAction).GetObject().(*workapiv1.AppliedManifestWork)
+				if created.Name != "new-work-0" || created.Spec.HubHash != "new" || created.Spec.AgentID != "agent" {
+					t.Errorf("unexpected appliedmanifestwork %v", created)
+				}
+				appliedWork := &workapiv1.AppliedManifestWork{}
+				if err := json.Unmarshal(actions[1].(clienttesting.PatchActionImpl).Patch, appliedWork); err != nil {
+					t.Fatal(err)
+				}
+				if len(appliedWork.Status.AppliedResources) != 1 {
+					t.Errorf("expected applied resources are migrated, but got %v", appliedWork.Status.AppliedResources)
+				}
+				if name := actions[2].(clienttesting.DeleteActionImpl).Name; name != "old-work-0" {
+					t.Errorf("expected appliedmanifestwork old-work-0 is deleted, but got %s", name)
+				}
+			},
+			validateResourceActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "patch")
+				patch := &metav1.PartialObjectMetadata{}
+				if err := json.Unmarshal(actions[1].(clienttesting.PatchActionImpl).Patch, patch); err != nil {
+					t.Fatal(err)
+				}
+				if len(patch.OwnerReferences) != 2 {
+					t.Errorf("expected the owner is added, but got %v", patch.OwnerReferences)
+				}
+				if patch.Annotations[helper.SourceHubHashAnnotation] != "new" {
+					t.Errorf("expected the source hub hash is updated, but got %v", patch.Annotations)
+				}
+			},
+		},
+		{
+			name:                    "resume the migration",
+			appliedManifestWorkName: "old-work-0",
+			works:                   []runtime.Object{work},
+			appliedWorks:            []runtime.Object{oldAppliedWork, newAppliedWork},
+			existingResources:       []runtime.Object{newSecret(*oldOwner, *newOwner)},
+			validateAppliedManifestWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateResourceActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "patch")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), c.existingResources...)
+			fakeClient := fakeworkclient.NewSimpleClientset(c.appliedWorks...)
+			informerFactory := workinformers.NewSharedInformerFactory(fakeClient, 5*time.Minute)
+			for _, work := range c.works {
+				if err := informerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, appliedWork := range c.appliedWorks {
+				if err := informerFactory.Work().V1().AppliedManifestWorks().Informer().GetStore().Add(appliedWork); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := AppliedManifestWorkMigrationController{
+				patcher: patcher.NewPatcher[
+					*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus](
+					fakeClient.WorkV1().AppliedManifestWorks()),
+				manifestWorkLister:        informerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks("cluster1"),
+				appliedManifestWorkClient: fakeClient.WorkV1().AppliedManifestWorks(),
+				appliedManifestWorkLister: informerFactory.Work().V1().AppliedManifestWorks().Lister(),
+				spokeDynamicClient:        fakeDynamicClient,
+				previousHubHashes:         sets.New[string]("old"),
+				hubHash:                   "new",
+				agentID:                   "agent",
+			}
+
+			controllerContext := testingcommon.NewFakeSyncContext(t, c.appliedManifestWorkName)
+			if err := controller.sync(context.TODO(), controllerContext); err != nil {
+				t.Fatal(err)
+			}
+
+			c.validateAppliedManifestWorkActions(t, fakeClient.Actions())
+			c.validateResourceActions(t, fakeDynamicClient.Actions())
+		})
+	}
+}
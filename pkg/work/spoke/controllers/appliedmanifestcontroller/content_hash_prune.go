@@ -0,0 +1,142 @@
+package appliedmanifestcontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
+)
+
+const (
+	// ContentHashSupersededAnnotation is the annotation set by the agent on the superseded instances of the content
+	// hash named ConfigMaps and Secrets with the time they are superseded, they are pruned once no pod references
+	// them or the prune TTL is elapsed since then.
+	// TODO move this to the api repo
+	ContentHashSupersededAnnotation = "work.open-cluster-management.io/content-hash-superseded"
+)
+
+// DefaultContentHashPruneInterval is the default interval to check again whether the retained superseded instances
+// are still referenced by pods, since the pods do not trigger the controller.
+const DefaultContentHashPruneInterval = time.Minute
+
+// retainSupersededInstances splits the resources no longer maintained by the manifestwork into the superseded
+// instances of the content hash named ConfigMaps and Secrets still referenced by pods, which are retained, and
+// the rest, which are deleted. It returns the duration after which the retained ones are checked again.
+func (m *AppliedManifestWorkController) retainSupersededInstances(ctx context.Context,
+	resources []workapiv1.AppliedManifestResourceMeta) (retained, deletable []workapiv1.AppliedManifestResourceMeta,
+	requeueAfter time.Duration, err error) {
+	// the pods are listed once for each namespace
+	references := map[string]map[string]bool{}
+	for _, resource := range resources {
+		lister := m.contentHashListers[resource.Resource]
+		if resource.Group != "" || lister == nil || m.contentHashPruneTTL <= 0 {
+			deletable = append(deletable, resource)
+			continue
+		}
+
+		gvr := schema.GroupVersionResource{Version: resource.Version, Resource: resource.Resource}
+		cached, err := lister.ByNamespace(resource.Namespace).Get(resource.Name)
+		switch {
+		case errors.IsNotFound(err):
+			deletable = append(deletable, resource)
+			continue
+		case err != nil:
+			return nil, nil, 0, err
+		}
+		obj, err := meta.Accessor(cached)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if _, ok := obj.GetAnnotations()[transformer.ContentHashBaseNameAnnotation]; !ok || string(obj.GetUID()) != resource.UID {
+			deletable = append(deletable, resource)
+			continue
+		}
+
+		now := m.clock.Now()
+		superseded, err := m.supersededTime(ctx, gvr, obj, now)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		remaining := m.contentHashPruneTTL - now.Sub(superseded)
+		if remaining <= 0 {
+			deletable = append(deletable, resource)
+			continue
+		}
+
+		if _, ok := references[resource.Namespace]; !ok {
+			if references[resource.Namespace], err = m.podReferences(resource.Namespace); err != nil {
+				return nil, nil, 0, err
+			}
+		}
+		if !references[resource.Namespace][fmt.Sprintf("%s/%s", resource.Resource, resource.Name)] {
+			deletable = append(deletable, resource)
+			continue
+		}
+
+		klog.V(4).Infof("Retain the superseded %s %s/%s referenced by pods", resource.Resource, resource.Namespace, resource.Name)
+		retained = append(retained, resource)
+		if remaining > m.contentHashPruneInterval {
+			remaining = m.contentHashPruneInterval
+		}
+		if requeueAfter == 0 || remaining < requeueAfter {
+			requeueAfter = remaining
+		}
+	}
+
+	return retained, deletable, requeueAfter, nil
+}
+
+// supersededTime returns the time the instance is superseded, it is recorded on the instance the first time.
+func (m *AppliedManifestWorkController) supersededTime(ctx context.Context, gvr schema.GroupVersionResource,
+	obj metav1.Object, now time.Time) (time.Time, error) {
+	if superseded, err := time.Parse(time.RFC3339, obj.GetAnnotations()[ContentHashSupersededAnnotation]); err == nil {
+		return superseded, nil
+	}
+
+	value := now.UTC().Format(time.RFC3339)
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, ContentHashSupersededAnnotation, value)
+	_, err := m.spokeDynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Patch(
+		ctx, obj.GetName(), types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	return now, err
+}
+
+// podReferences returns the ConfigMaps and Secrets referenced by the pods in the namespace which are not
+// terminated, in the format of resource/name.
+func (m *AppliedManifestWorkController) podReferences(namespace string) (map[string]bool, error) {
+	pods, err := m.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	references := map[string]bool{}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pod.Spec)
+		if err != nil {
+			return nil, err
+		}
+		configMaps, secrets := transformer.PodSpecReferences(podSpec)
+		for name := range configMaps {
+			references["configmaps/"+name] = true
+		}
+		for name := range secrets {
+			references["secrets/"+name] = true
+		}
+	}
+	return references, nil
+}
@@ -0,0 +1,191 @@
+package appliedmanifestcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	testingclock "k8s.io/utils/clock/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
+)
+
+func TestSyncManifestWorkPruneSupersededInstances(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	uid := types.UID("test")
+	appliedWork := spoketesting.NewAppliedManifestWork("test", 0, uid)
+	owner := helper.NewAppliedManifestWorkOwner(appliedWork)
+
+	newConfigMap := func(name string, annotations map[string]string) runtime.Object {
+		cm := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", name, *owner)
+		cm.SetUID(types.UID(name))
+		cm.SetAnnotations(annotations)
+		return cm
+	}
+	newPod := func(phase corev1.PodPhase) runtime.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name: "config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "cm-old"},
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	hashed := map[string]string{transformer.ContentHashBaseNameAnnotation: "cm"}
+
+	cases := []struct {
+		name             string
+		existing         []runtime.Object
+		expectedRetained bool
+	}{
+		{
+			name:             "retain the instance referenced by a running pod",
+			existing:         []runtime.Object{newConfigMap("cm-old", hashed), newPod("Running")},
+			expectedRetained: true,
+		},
+		{
+			name:     "prune the instance not referenced by any pod",
+			existing: []runtime.Object{newConfigMap("cm-old", hashed)},
+		},
+		{
+			name:     "prune the instance referenced by a completed pod",
+			existing: []runtime.Object{newConfigMap("cm-old", hashed), newPod("Succeeded")},
+		},
+		{
+			name: "prune the instance once the ttl is elapsed",
+			existing: []runtime.Object{
+				newConfigMap("cm-old", map[string]string{
+					transformer.ContentHashBaseNameAnnotation: "cm",
+					ContentHashSupersededAnnotation:           now.Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+				}),
+				newPod("Running"),
+			},
+		},
+		{
+			name:     "delete the instance which is not content hash named",
+			existing: []runtime.Object{newConfigMap("cm-old", nil), newPod("Running")},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testingWork, _ := spoketesting.NewManifestWork(0)
+			testingWork.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+				newManifest("", "v1", "configmaps", "ns1", "cm-new"),
+			}
+			appliedResources := []workapiv1.AppliedManifestResourceMeta{
+				{Version: "v1", ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "configmaps", Namespace: "ns1", Name: "cm-new"}, UID: "cm-new"},
+				{Version: "v1", ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "configmaps", Namespace: "ns1", Name: "cm-old"}, UID: "cm-old"},
+			}
+			testingAppliedWork := appliedWork.DeepCopy()
+			testingAppliedWork.Status.AppliedResources = appliedResources
+			testingAppliedWork.Annotations = map[string]string{
+				AppliedResourceSummaryAnnotation: newResourceSummary(appliedResources).annotation(),
+			}
+
+			objects := append([]runtime.Object{newConfigMap("cm-new", hashed)}, c.existing...)
+			podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			var configMaps []runtime.Object
+			for _, obj := range objects {
+				switch obj := obj.(type) {
+				case *corev1.Pod:
+					if err := podIndexer.Add(obj); err != nil {
+						t.Fatal(err)
+					}
+				case *unstructured.Unstructured:
+					configMaps = append(configMaps, obj)
+					if err := configMapIndexer.Add(&metav1.PartialObjectMetadata{
+						TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: metav1.ObjectMeta{Name: obj.GetName(), Namespace: obj.GetNamespace(),
+							UID: obj.GetUID(), Annotations: obj.GetAnnotations()},
+					}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), configMaps...)
+			fakeClient := fakeworkclient.NewSimpleClientset(testingWork, testingAppliedWork)
+			informerFactory := workinformers.NewSharedInformerFactory(fakeClient, 5*time.Minute)
+			if err := informerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(testingWork); err != nil {
+				t.Fatal(err)
+			}
+			if err := informerFactory.Work().V1().AppliedManifestWorks().Informer().GetStore().Add(testingAppliedWork); err != nil {
+				t.Fatal(err)
+			}
+
+			controller := AppliedManifestWorkController{
+				manifestWorkLister: informerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks("cluster1"),
+				patcher: patcher.NewPatcher[
+					*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus](
+					fakeClient.WorkV1().AppliedManifestWorks()),
+				appliedManifestWorkLister: informerFactory.Work().V1().AppliedManifestWorks().Lister(),
+				spokeDynamicClient:        fakeDynamicClient,
+				hubHash:                   "test",
+				rateLimiter:               workqueue.NewItemExponentialFailureRateLimiter(0, 1*time.Second),
+				contentHashPruneTTL:       time.Hour,
+				contentHashPruneInterval:  DefaultContentHashPruneInterval,
+				contentHashListers: map[string]cache.GenericLister{
+					"configmaps": cache.NewGenericLister(configMapIndexer, corev1.Resource("configmaps")),
+					"secrets": cache.NewGenericLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+						corev1.Resource("secrets")),
+				},
+				podLister: corev1lister.NewPodLister(podIndexer),
+				clock:     testingclock.NewFakeClock(now),
+			}
+
+			if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testingWork.Name)); err != nil {
+				t.Fatal(err)
+			}
+
+			deleted := false
+			for _, action := range fakeDynamicClient.Actions() {
+				if action.GetVerb() == "delete" && action.GetResource().Resource == "configmaps" {
+					deleted = true
+				}
+			}
+			if deleted == c.expectedRetained {
+				t.Errorf("expected the superseded instance retained %v, but it is deleted %v", c.expectedRetained, deleted)
+			}
+
+			if !c.expectedRetained {
+				return
+			}
+			// the retained instance is kept in the applied resources, and the superseded time is recorded on it
+			testingcommon.AssertNoActions(t, fakeClient.Actions())
+			cm, err := fakeDynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+				Namespace("ns1").Get(context.TODO(), "cm-old", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cm.GetAnnotations()[ContentHashSupersededAnnotation] != now.UTC().Format(time.RFC3339) {
+				t.Errorf("expected the superseded time recorded, but got %v", cm.GetAnnotations())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package appliedmanifestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// AppliedResourceSummaryAnnotation is the annotation on an AppliedManifestWork to summarize the number of its
+	// applied resources by group, version and resource, e.g. {"apps/v1/deployments":10,"v1/configmaps":1000}.
+	// Only the counts are kept, so the annotation is bounded by the number of the kinds of the applied resources.
+	// TODO move this to the api repo
+	AppliedResourceSummaryAnnotation = "work.open-cluster-management.io/applied-resource-summary"
+)
+
+var (
+	// AppliedResources is the number of the resources tracked by the AppliedManifestWorks of the agent.
+	AppliedResources = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "work_agent",
+			Name:           "applied_resources",
+			Help:           "Number of the resources tracked by the AppliedManifestWorks by group, version and resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "version", "resource"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of the appliedmanifestwork controller.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(AppliedResources)
+	})
+}
+
+// resourceSummary is the number of the applied resources by group, version and resource.
+type resourceSummary map[schema.GroupVersionResource]int
+
+func newResourceSummary(appliedResources []workapiv1.AppliedManifestResourceMeta) resourceSummary {
+	summary := resourceSummary{}
+	for _, resource := range appliedResources {
+		summary[schema.GroupVersionResource{
+			Group:    resource.Group,
+			Version:  resource.Version,
+			Resource: resource.Resource,
+		}]++
+	}
+	return summary
+}
+
+// annotation returns the value of the summary annotation, the keys are sorted by json.
+func (s resourceSummary) annotation() string {
+	counts := map[string]int{}
+	for gvr, count := range s {
+		counts[fmt.Sprintf("%s/%s", gvr.GroupVersion().String(), gvr.Resource)] = count
+	}
+	data, _ := json.Marshal(counts)
+	return string(data)
+}
+
+// summaryRecorder records the summaries of the AppliedManifestWorks into the metrics incrementally, only the
+// changed counts of an AppliedManifestWork are added into the metrics.
+type summaryRecorder struct {
+	lock      sync.Mutex
+	summaries map[string]resourceSummary
+}
+
+// record replaces the summary of the AppliedManifestWork.
+func (r *summaryRecorder) record(name string, summary resourceSummary) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.summaries == nil {
+		r.summaries = map[string]resourceSummary{}
+	}
+	last := r.summaries[name]
+	for gvr, count := range summary {
+		if delta := count - last[gvr]; delta != 0 {
+			AppliedResources.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource).Add(float64(delta))
+		}
+	}
+	for gvr, count := range last {
+		if _, ok := summary[gvr]; !ok {
+			AppliedResources.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource).Add(-float64(count))
+		}
+	}
+	r.summaries[name] = summary
+}
+
+// forget removes the summary of the AppliedManifestWork once it is deleted.
+func (r *summaryRecorder) forget(name string) {
+	r.record(name, resourceSummary{})
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.summaries, name)
+}
+
+// patchSummary updates the summary annotation of the AppliedManifestWork if the counts are changed.
+func (m *AppliedManifestWorkController) patchSummary(
+	ctx context.Context, appliedManifestWork *workapiv1.AppliedManifestWork) error {
+	summary := newResourceSummary(appliedManifestWork.Status.AppliedResources)
+	m.summaries.record(appliedManifestWork.Name, summary)
+
+	value := summary.annotation()
+	if appliedManifestWork.Annotations[AppliedResourceSummaryAnnotation] == value {
+		return nil
+	}
+	newAppliedManifestWork := appliedManifestWork.DeepCopy()
+	if newAppliedManifestWork.Annotations == nil {
+		newAppliedManifestWork.Annotations = map[string]string{}
+	}
+	newAppliedManifestWork.Annotations[AppliedResourceSummaryAnnotation] = value
+	_, err := m.patcher.PatchLabelAnnotations(
+		ctx, newAppliedManifestWork, newAppliedManifestWork.ObjectMeta, appliedManifestWork.ObjectMeta)
+	return err
+}
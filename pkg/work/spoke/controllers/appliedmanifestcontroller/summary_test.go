@@ -0,0 +1,128 @@
+package appliedmanifestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/component-base/metrics/testutil"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newAppliedResource(apiVersion, kind, resource, namespace, name string) (
+	*unstructured.Unstructured, workapiv1.ManifestCondition, workapiv1.AppliedManifestResourceMeta) {
+	obj := spoketesting.NewUnstructured(apiVersion, kind, namespace, name)
+	uid := namespace + "-" + name
+	obj.SetUID(types.UID(uid))
+	gv := obj.GroupVersionKind().GroupVersion()
+	return obj, newManifest(gv.Group, gv.Version, resource, namespace, name), workapiv1.AppliedManifestResourceMeta{
+		ResourceIdentifier: workapiv1.ResourceIdentifier{
+			Group: gv.Group, Resource: resource, Namespace: namespace, Name: name,
+		},
+		Version: gv.Version,
+		UID:     uid,
+	}
+}
+
+func TestAppliedResourceSummary(t *testing.T) {
+	var objects []runtime.Object
+	var manifests []workapiv1.ManifestCondition
+	var appliedResources []workapiv1.AppliedManifestResourceMeta
+	for _, r := range []struct{ apiVersion, kind, resource, name string }{
+		{"apps/v1", "Deployment", "deployments", "d1"},
+		{"v1", "ConfigMap", "configmaps", "c1"},
+		{"v1", "ConfigMap", "configmaps", "c2"},
+		{"v1", "ConfigMap", "configmaps", "c3"},
+		{"my.domain/v1", "Guestbook", "guestbooks", "g1"},
+	} {
+		obj, manifest, appliedResource := newAppliedResource(r.apiVersion, r.kind, r.resource, "ns1", r.name)
+		objects = append(objects, obj)
+		manifests = append(manifests, manifest)
+		appliedResources = append(appliedResources, appliedResource)
+	}
+	// the applied resources are sorted by group, version, resource, namespace and name
+	appliedResources = []workapiv1.AppliedManifestResourceMeta{
+		appliedResources[1], appliedResources[2], appliedResources[3], appliedResources[0], appliedResources[4],
+	}
+
+	work, _ := spoketesting.NewManifestWork(0)
+	work.Status.ResourceStatus.Manifests = manifests
+	appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "test")
+	appliedWork.Status.AppliedResources = appliedResources
+
+	sync := func(controller *AppliedManifestWorkController, appliedWork *workapiv1.AppliedManifestWork) []clienttesting.Action {
+		fakeClient := fakeworkclient.NewSimpleClientset(work, appliedWork)
+		informerFactory := workinformers.NewSharedInformerFactory(fakeClient, 5*time.Minute)
+		if err := informerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+		if err := informerFactory.Work().V1().AppliedManifestWorks().Informer().GetStore().Add(appliedWork); err != nil {
+			t.Fatal(err)
+		}
+		controller.manifestWorkLister = informerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks("cluster1")
+		controller.appliedManifestWorkLister = informerFactory.Work().V1().AppliedManifestWorks().Lister()
+		controller.patcher = patcher.NewPatcher[
+			*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus](
+			fakeClient.WorkV1().AppliedManifestWorks())
+		if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, work.Name)); err != nil {
+			t.Fatal(err)
+		}
+		return fakeClient.Actions()
+	}
+	assertMetric := func(group, version, resource string, expected float64) {
+		actual, err := testutil.GetGaugeMetricValue(AppliedResources.WithLabelValues(group, version, resource))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected %v %s/%s/%s, but got %v", expected, group, version, resource, actual)
+		}
+	}
+
+	RegisterMetrics()
+	controller := &AppliedManifestWorkController{
+		spokeDynamicClient: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...),
+		hubHash:            "test",
+		rateLimiter:        workqueue.NewItemExponentialFailureRateLimiter(0, 1*time.Second),
+	}
+
+	// the summary is added to the appliedmanifestwork
+	actions := sync(controller, appliedWork)
+	testingcommon.AssertActions(t, actions, "patch")
+	patched := &workapiv1.AppliedManifestWork{}
+	if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, patched); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"apps/v1/deployments":1,"my.domain/v1/guestbooks":1,"v1/configmaps":3}`
+	if summary := patched.Annotations[AppliedResourceSummaryAnnotation]; summary != expected {
+		t.Errorf("expected summary %s, but got %s", expected, summary)
+	}
+	assertMetric("", "v1", "configmaps", 3)
+	assertMetric("apps", "v1", "deployments", 1)
+	assertMetric("my.domain", "v1", "guestbooks", 1)
+
+	// the unchanged summary is not written again, and the metrics are not counted twice
+	appliedWork = appliedWork.DeepCopy()
+	appliedWork.Annotations = map[string]string{AppliedResourceSummaryAnnotation: expected}
+	testingcommon.AssertNoActions(t, sync(controller, appliedWork))
+	assertMetric("", "v1", "configmaps", 3)
+
+	// the metrics are reduced once the appliedmanifestwork is deleted
+	controller.summaries.forget(appliedWork.Name)
+	assertMetric("", "v1", "configmaps", 0)
+	assertMetric("apps", "v1", "deployments", 0)
+}
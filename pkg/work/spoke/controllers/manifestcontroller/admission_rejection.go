@@ -0,0 +1,116 @@
+package manifestcontroller
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// QuotaExceededReason is the reason of the applied condition of a manifest when the manifest is rejected
+	// since a ResourceQuota in its namespace is exceeded.
+	QuotaExceededReason = "QuotaExceeded"
+
+	// LimitRangeViolatedReason is the reason of the applied condition of a manifest when the manifest is rejected
+	// since it violates a LimitRange in its namespace.
+	LimitRangeViolatedReason = "LimitRangeViolated"
+)
+
+var (
+	// QuotaRejectionInitialBackoff and QuotaRejectionMaxBackoff are the requeue backoff of a work which has
+	// manifests rejected by ResourceQuotas or LimitRanges. A rejected manifest is unlikely to be admitted until
+	// the quota is released, so the work is retried in an exponential backoff instead of at the full rate.
+	QuotaRejectionInitialBackoff = 10 * time.Second
+	QuotaRejectionMaxBackoff     = ResyncInterval
+)
+
+var (
+	// the formats of the forbidden errors returned by the ResourceQuota admission plugin, e.g.
+	// exceeded quota: compute-resources, requested: limits.cpu=2, used: limits.cpu=1, limited: limits.cpu=2
+	// failed quota: compute-resources: must specify limits.cpu,limits.memory
+	exceededQuotaRegexp = regexp.MustCompile(`exceeded quota: ([^,]+), requested: (\S+), used:`)
+	failedQuotaRegexp   = regexp.MustCompile(`failed quota: ([^:]+): must specify (\S+)`)
+
+	// the formats of the forbidden errors returned by the LimitRanger admission plugin, e.g.
+	// maximum cpu usage per Container is 1, but limit is 2
+	// cpu max limit to request ratio per Container is 2, but provided ratio is 4.000000
+	limitRangeUsageRegexp = regexp.MustCompile(`(?:maximum|minimum) (\S+) usage per \S+ is`)
+	limitRangeRatioRegexp = regexp.MustCompile(`(\S+) max limit to request ratio per \S+ is`)
+)
+
+// quotaExceededError indicates a manifest is rejected since a ResourceQuota is exceeded.
+type quotaExceededError struct {
+	quota     string
+	resources []string
+	err       error
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("the resource quota %s is exceeded on %s: %v", e.quota, strings.Join(e.resources, ","), e.err)
+}
+
+func (e *quotaExceededError) Unwrap() error {
+	return e.err
+}
+
+// limitRangeViolatedError indicates a manifest is rejected since it violates a LimitRange.
+type limitRangeViolatedError struct {
+	resources []string
+	err       error
+}
+
+func (e *limitRangeViolatedError) Error() string {
+	return fmt.Sprintf("the limit range is violated on %s: %v", strings.Join(e.resources, ","), e.err)
+}
+
+func (e *limitRangeViolatedError) Unwrap() error {
+	return e.err
+}
+
+// classifyApplyError returns a quotaExceededError or a limitRangeViolatedError if the error is a forbidden
+// error returned by the ResourceQuota or LimitRanger admission plugins, otherwise the error is returned as is.
+func classifyApplyError(err error) error {
+	if !apierrors.IsForbidden(err) {
+		return err
+	}
+
+	message := err.Error()
+	if matches := exceededQuotaRegexp.FindStringSubmatch(message); matches != nil {
+		resources := []string{}
+		for _, requested := range strings.Split(matches[2], ",") {
+			resources = append(resources, strings.SplitN(requested, "=", 2)[0])
+		}
+		return &quotaExceededError{quota: matches[1], resources: resources, err: err}
+	}
+	if matches := failedQuotaRegexp.FindStringSubmatch(message); matches != nil {
+		return &quotaExceededError{quota: matches[1], resources: strings.Split(matches[2], ","), err: err}
+	}
+
+	resources := sets.New[string]()
+	for _, matches := range limitRangeUsageRegexp.FindAllStringSubmatch(message, -1) {
+		resources.Insert(matches[1])
+	}
+	for _, matches := range limitRangeRatioRegexp.FindAllStringSubmatch(message, -1) {
+		resources.Insert(strings.TrimPrefix(matches[1], "["))
+	}
+	if resources.Len() > 0 {
+		sorted := resources.UnsortedList()
+		sort.Strings(sorted)
+		return &limitRangeViolatedError{resources: sorted, err: err}
+	}
+
+	return err
+}
+
+// isQuotaRejection returns true if the manifest is rejected by a ResourceQuota or a LimitRange.
+func isQuotaRejection(err error) bool {
+	var quotaErr *quotaExceededError
+	var limitRangeErr *limitRangeViolatedError
+	return errors.As(err, &quotaErr) || errors.As(err, &limitRangeErr)
+}
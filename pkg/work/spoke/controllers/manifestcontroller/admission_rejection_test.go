@@ -0,0 +1,77 @@
+package manifestcontroller
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassifyApplyError(t *testing.T) {
+	forbidden := func(message string) error {
+		return apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "deploy1",
+			fmt.Errorf("%s", message))
+	}
+
+	cases := []struct {
+		name               string
+		err                error
+		expectedQuota      string
+		expectedResources  []string
+		expectedLimitRange bool
+	}{
+		{
+			name: "not forbidden",
+			err:  fmt.Errorf("exceeded quota: compute-resources, requested: limits.cpu=2, used: limits.cpu=1, limited: limits.cpu=2"),
+		},
+		{
+			name: "forbidden by rbac",
+			err:  forbidden("User \"system:serviceaccount:open-cluster-management-agent:klusterlet-work-sa\" cannot create resource"),
+		},
+		{
+			name: "exceeded quota",
+			err: forbidden("exceeded quota: compute-resources, requested: limits.cpu=2,limits.memory=1Gi, " +
+				"used: limits.cpu=1,limits.memory=1Gi, limited: limits.cpu=2,limits.memory=2Gi"),
+			expectedQuota:     "compute-resources",
+			expectedResources: []string{"limits.cpu", "limits.memory"},
+		},
+		{
+			name:              "failed quota",
+			err:               forbidden("failed quota: compute-resources: must specify limits.cpu,limits.memory"),
+			expectedQuota:     "compute-resources",
+			expectedResources: []string{"limits.cpu", "limits.memory"},
+		},
+		{
+			name: "limit range violated",
+			err: forbidden("[maximum memory usage per Container is 1Gi, but limit is 2Gi, " +
+				"cpu max limit to request ratio per Container is 2, but provided ratio is 4.000000]"),
+			expectedResources:  []string{"cpu", "memory"},
+			expectedLimitRange: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := classifyApplyError(c.err)
+			switch e := err.(type) {
+			case *quotaExceededError:
+				if c.expectedQuota != e.quota || !reflect.DeepEqual(c.expectedResources, e.resources) {
+					t.Errorf("expected quota %s on %v, but got %s on %v", c.expectedQuota, c.expectedResources, e.quota, e.resources)
+				}
+			case *limitRangeViolatedError:
+				if !c.expectedLimitRange || !reflect.DeepEqual(c.expectedResources, e.resources) {
+					t.Errorf("expected limit range %v on %v, but got limit range on %v", c.expectedLimitRange, c.expectedResources, e.resources)
+				}
+			default:
+				if len(c.expectedResources) > 0 {
+					t.Errorf("expected a quota rejection, but got %v", err)
+				}
+				if err != c.err {
+					t.Errorf("expected the error is returned as is, but got %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package manifestcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// WorkWaiting is the condition type of a manifestwork which depends on other manifestworks. The manifestwork
+	// is not applied while the condition is true, and it is released once the condition becomes false with the
+	// reason DependenciesAvailable or DependencyTimeoutProceeded. A released manifestwork is not held again.
+	// TODO move this to the api repo
+	WorkWaiting = "Waiting"
+
+	// DependenciesNotAvailableReason is the reason of the waiting condition when some dependencies are not available.
+	DependenciesNotAvailableReason = "DependenciesNotAvailable"
+	// DependenciesAvailableReason is the reason of the waiting condition when all dependencies are available.
+	DependenciesAvailableReason = "DependenciesAvailable"
+	// DependencyTimeoutProceededReason is the reason of the waiting condition when the manifestwork is applied
+	// after the dependency timeout with the Proceed policy.
+	DependencyTimeoutProceededReason = "DependencyTimeoutProceeded"
+	// DependencyTimeoutReason is the reason of the waiting and applied conditions when the manifestwork fails
+	// after the dependency timeout with the Fail policy. The manifestwork is still released once the
+	// dependencies become available.
+	DependencyTimeoutReason = "DependencyTimeout"
+
+	// worksByDependency is the index of the works held by their dependencies.
+	worksByDependency = "worksByDependency"
+)
+
+// indexWorksByDependency indexes the work by the namespaced names of its dependencies until it is released, the
+// released works are not reconciled on the changes of their dependencies.
+func indexWorksByDependency(obj interface{}) ([]string, error) {
+	work, ok := obj.(*workapiv1.ManifestWork)
+	if !ok || isReleased(work) {
+		return nil, nil
+	}
+	var keys []string
+	for _, dependency := range helper.GetDependencies(work) {
+		keys = append(keys, fmt.Sprintf("%s/%s", work.Namespace, dependency))
+	}
+	return keys, nil
+}
+
+// isReleased returns true if the manifestwork is not held by its dependencies. A manifestwork applied before it
+// has any dependencies is released as well.
+func isReleased(manifestWork *workapiv1.ManifestWork) bool {
+	cond := meta.FindStatusCondition(manifestWork.Status.Conditions, WorkWaiting)
+	if cond == nil {
+		return meta.FindStatusCondition(manifestWork.Status.Conditions, workapiv1.WorkApplied) != nil
+	}
+	return cond.Status == metav1.ConditionFalse && cond.Reason != DependencyTimeoutReason
+}
+
+// unavailableDependencies returns the messages of the dependencies which are not available, a dependency which
+// is not found or being deleted is not available.
+func (m *ManifestWorkController) unavailableDependencies(dependencies []string) ([]string, error) {
+	var msgs []string
+	for _, name := range dependencies {
+		dependency, err := m.manifestWorkLister.Get(name)
+		switch {
+		case apierrors.IsNotFound(err):
+			msgs = append(msgs, fmt.Sprintf("%s is not found", name))
+		case err != nil:
+			return nil, err
+		case !dependency.DeletionTimestamp.IsZero():
+			msgs = append(msgs, fmt.Sprintf("%s is being deleted", name))
+		case !meta.IsStatusConditionTrue(dependency.Status.Conditions, workapiv1.WorkAvailable):
+			msgs = append(msgs, fmt.Sprintf("%s is not available", name))
+		}
+	}
+	return msgs, nil
+}
+
+// syncDependencies sets the waiting condition of the manifestwork by the availability of its dependencies, and
+// returns true if the manifestwork should not be applied in this sync. The manifestwork waiting for a timeout is
+// requeued once the timeout is reached, otherwise it is requeued when its dependencies are changed.
+func (m *ManifestWorkController) syncDependencies(
+	ctx context.Context, controllerContext factory.SyncContext,
+	manifestWork, oldManifestWork *workapiv1.ManifestWork) (bool, error) {
+	dependencies := helper.GetDependencies(manifestWork)
+	if len(dependencies) == 0 {
+		meta.RemoveStatusCondition(&manifestWork.Status.Conditions, WorkWaiting)
+		return false, nil
+	}
+	if isReleased(manifestWork) {
+		return false, nil
+	}
+
+	msgs, err := m.unavailableDependencies(dependencies)
+	if err != nil {
+		return true, err
+	}
+	if len(msgs) == 0 {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               WorkWaiting,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: manifestWork.Generation,
+			Reason:             DependenciesAvailableReason,
+			Message:            fmt.Sprintf("Dependencies %s are available", strings.Join(dependencies, ", ")),
+		})
+		return false, nil
+	}
+
+	waitingCond := meta.FindStatusCondition(manifestWork.Status.Conditions, WorkWaiting)
+	if waitingCond != nil && waitingCond.Reason == DependencyTimeoutReason {
+		// the manifestwork failed already, it is released once the dependencies become available.
+		return true, nil
+	}
+
+	// the manifestwork starts to wait from the last transition time of the waiting condition
+	waitingSince := time.Now()
+	if waitingCond != nil {
+		waitingSince = waitingCond.LastTransitionTime.Time
+	}
+	timeout, policy := helper.GetDependencyTimeout(manifestWork)
+	remaining := timeout - time.Since(waitingSince)
+	switch {
+	case timeout == 0 || remaining > 0:
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               WorkWaiting,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: manifestWork.Generation,
+			Reason:             DependenciesNotAvailableReason,
+			Message:            fmt.Sprintf("Waiting for dependencies: %s", strings.Join(msgs, "; ")),
+		})
+		if timeout > 0 {
+			controllerContext.Queue().AddAfter(manifestWork.Name, remaining)
+		}
+	case policy == helper.DependencyTimeoutPolicyProceed:
+		klog.V(2).Infof("ManifestWork %q proceeds after waiting for dependencies for %v", manifestWork.Name, timeout)
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               WorkWaiting,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: manifestWork.Generation,
+			Reason:             DependencyTimeoutProceededReason,
+			Message: fmt.Sprintf("Proceeded after waiting for dependencies for %v: %s",
+				timeout, strings.Join(msgs, "; ")),
+		})
+		return false, nil
+	default:
+		klog.V(2).Infof("ManifestWork %q fails after waiting for dependencies for %v", manifestWork.Name, timeout)
+		message := fmt.Sprintf("Failed after waiting for dependencies for %v: %s", timeout, strings.Join(msgs, "; "))
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               WorkWaiting,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: manifestWork.Generation,
+			Reason:             DependencyTimeoutReason,
+			Message:            message,
+		})
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               workapiv1.WorkApplied,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: manifestWork.Generation,
+			Reason:             DependencyTimeoutReason,
+			Message:            message,
+		})
+		controllerContext.Recorder().Warningf("ManifestWorkDependencyTimeout",
+			"manifestwork %s fails after waiting for dependencies for %v", manifestWork.Name, timeout)
+	}
+
+	if _, err := m.manifestWorkPatcher.PatchStatus(
+		ctx, manifestWork, manifestWork.Status, oldManifestWork.Status); err != nil {
+		return true, fmt.Errorf("failed to update work status with err %w", err)
+	}
+	return true, nil
+}
+
+// dependentWorksQueueKeysFunc returns the works held by the changed work, so the works are reconciled right after
+// their dependencies are changed or deleted.
+func (m *ManifestWorkController) dependentWorksQueueKeysFunc(obj runtime.Object) []string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	works, err := m.manifestWorkIndexer.ByIndex(worksByDependency,
+		fmt.Sprintf("%s/%s", accessor.GetNamespace(), accessor.GetName()))
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	var keys []string
+	for _, obj := range works {
+		if work, ok := obj.(*workapiv1.ManifestWork); ok {
+			keys = append(keys, work.Name)
+		}
+	}
+	return keys
+}
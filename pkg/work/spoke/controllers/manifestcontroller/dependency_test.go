@@ -0,0 +1,273 @@
+package manifestcontroller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newDependentWork(annotations map[string]string, conditions ...metav1.Condition) *workapiv1.ManifestWork {
+	work, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"))
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	work.Annotations = annotations
+	work.Status.Conditions = conditions
+	return work
+}
+
+func newDependencyWork(name string, available bool, dependsOn string) *workapiv1.ManifestWork {
+	work := &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cluster1"},
+	}
+	if len(dependsOn) > 0 {
+		work.Annotations = map[string]string{helper.DependsOnAnnotation: dependsOn}
+	}
+	if available {
+		work.Status.Conditions = []metav1.Condition{{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue}}
+	}
+	return work
+}
+
+func newWorkLister(t *testing.T, works ...*workapiv1.ManifestWork) worklister.ManifestWorkNamespaceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, work := range works {
+		if err := indexer.Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return worklister.NewManifestWorkLister(indexer).ManifestWorks("cluster1")
+}
+
+func TestSyncDependencies(t *testing.T) {
+	deleting := newDependencyWork("db", true, "")
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	hourAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	waitingSinceHourAgo := metav1.Condition{
+		Type:               WorkWaiting,
+		Status:             metav1.ConditionTrue,
+		Reason:             DependenciesNotAvailableReason,
+		LastTransitionTime: hourAgo,
+	}
+
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		conditions      []metav1.Condition
+		dependencies    []*workapiv1.ManifestWork
+		expectedApplied bool
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "no dependencies",
+			expectedApplied: true,
+		},
+		{
+			name:            "dependency not found",
+			annotations:     map[string]string{helper.DependsOnAnnotation: "db"},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  DependenciesNotAvailableReason,
+			expectedMessage: "db is not found",
+		},
+		{
+			name:            "dependency not available",
+			annotations:     map[string]string{helper.DependsOnAnnotation: "db, cache"},
+			dependencies:    []*workapiv1.ManifestWork{newDependencyWork("db", false, ""), newDependencyWork("cache", true, "")},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  DependenciesNotAvailableReason,
+			expectedMessage: "Waiting for dependencies: db is not available",
+		},
+		{
+			name:            "dependency being deleted",
+			annotations:     map[string]string{helper.DependsOnAnnotation: "db"},
+			dependencies:    []*workapiv1.ManifestWork{deleting},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  DependenciesNotAvailableReason,
+			expectedMessage: "db is being deleted",
+		},
+		{
+			name:            "all dependencies available",
+			annotations:     map[string]string{helper.DependsOnAnnotation: "db,cache"},
+			dependencies:    []*workapiv1.ManifestWork{newDependencyWork("db", true, ""), newDependencyWork("cache", true, "")},
+			expectedApplied: true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  DependenciesAvailableReason,
+			expectedMessage: "Dependencies cache, db are available",
+		},
+		{
+			name:        "chain with an intermediate dependency waiting",
+			annotations: map[string]string{helper.DependsOnAnnotation: "app"},
+			dependencies: []*workapiv1.ManifestWork{
+				newDependencyWork("app", false, "db"), newDependencyWork("db", true, ""),
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  DependenciesNotAvailableReason,
+			expectedMessage: "app is not available",
+		},
+		{
+			name:        "chain with all dependencies available",
+			annotations: map[string]string{helper.DependsOnAnnotation: "app"},
+			dependencies: []*workapiv1.ManifestWork{
+				newDependencyWork("app", true, "db"), newDependencyWork("db", true, ""),
+			},
+			expectedApplied: true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  DependenciesAvailableReason,
+		},
+		{
+			name:        "released work is not held after the dependency is deleted",
+			annotations: map[string]string{helper.DependsOnAnnotation: "db"},
+			conditions: []metav1.Condition{
+				{Type: WorkWaiting, Status: metav1.ConditionFalse, Reason: DependenciesAvailableReason},
+			},
+			expectedApplied: true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  DependenciesAvailableReason,
+		},
+		{
+			name:        "work applied before having dependencies is not held",
+			annotations: map[string]string{helper.DependsOnAnnotation: "db"},
+			conditions: []metav1.Condition{
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue, Reason: "AppliedManifestWorkComplete"},
+			},
+			expectedApplied: true,
+		},
+		{
+			name: "waiting before timeout",
+			annotations: map[string]string{
+				helper.DependsOnAnnotation:         "db",
+				helper.DependencyTimeoutAnnotation: "2h",
+			},
+			conditions:     []metav1.Condition{waitingSinceHourAgo},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: DependenciesNotAvailableReason,
+		},
+		{
+			name: "proceed after timeout",
+			annotations: map[string]string{
+				helper.DependsOnAnnotation:               "db",
+				helper.DependencyTimeoutAnnotation:       "10m",
+				helper.DependencyTimeoutPolicyAnnotation: "Proceed",
+			},
+			conditions:      []metav1.Condition{waitingSinceHourAgo},
+			expectedApplied: true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  DependencyTimeoutProceededReason,
+			expectedMessage: "Proceeded after waiting for dependencies for 10m0s: db is not found",
+		},
+		{
+			name: "fail after timeout",
+			annotations: map[string]string{
+				helper.DependsOnAnnotation:         "db",
+				helper.DependencyTimeoutAnnotation: "10m",
+			},
+			conditions:      []metav1.Condition{waitingSinceHourAgo},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  DependencyTimeoutReason,
+			expectedMessage: "Failed after waiting for dependencies for 10m0s: db is not found",
+		},
+		{
+			name: "failed work is released once the dependencies are available",
+			annotations: map[string]string{
+				helper.DependsOnAnnotation:         "db",
+				helper.DependencyTimeoutAnnotation: "10m",
+			},
+			conditions: []metav1.Condition{
+				{Type: WorkWaiting, Status: metav1.ConditionFalse, Reason: DependencyTimeoutReason},
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: DependencyTimeoutReason},
+			},
+			dependencies:    []*workapiv1.ManifestWork{newDependencyWork("db", true, "")},
+			expectedApplied: true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  DependenciesAvailableReason,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work := newDependentWork(c.annotations, c.conditions...)
+			controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).
+				withKubeObject().withUnstructuredObject()
+			controller.controller.manifestWorkLister = newWorkLister(t, append(c.dependencies, work)...)
+
+			syncContext := testingcommon.NewFakeSyncContext(t, work.Name)
+			if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+				t.Fatalf("Should be success with no err: %v", err)
+			}
+
+			syncedWork, err := controller.workClient.WorkV1().ManifestWorks("cluster1").Get(
+				context.TODO(), work.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			applied := meta.FindStatusCondition(syncedWork.Status.Conditions, workapiv1.WorkApplied)
+			if c.expectedApplied != (applied != nil && applied.Status == metav1.ConditionTrue) {
+				t.Errorf("expected applied %t, but got %v", c.expectedApplied, applied)
+			}
+
+			waiting := meta.FindStatusCondition(syncedWork.Status.Conditions, WorkWaiting)
+			switch {
+			case len(c.expectedStatus) == 0 && waiting != nil:
+				t.Errorf("expected no waiting condition, but got %v", waiting)
+			case len(c.expectedStatus) > 0 && (waiting == nil || waiting.Status != c.expectedStatus ||
+				waiting.Reason != c.expectedReason || !strings.Contains(waiting.Message, c.expectedMessage)):
+				t.Errorf("expected waiting condition %s/%s with message %q, but got %v",
+					c.expectedStatus, c.expectedReason, c.expectedMessage, waiting)
+			}
+			if c.expectedReason == DependencyTimeoutReason && (applied == nil || applied.Reason != DependencyTimeoutReason) {
+				t.Errorf("expected applied condition with reason %s, but got %v", DependencyTimeoutReason, applied)
+			}
+		})
+	}
+}
+
+func TestDependentWorksQueueKeys(t *testing.T) {
+	released := newDependencyWork("released", true, "db")
+	released.Status.Conditions = append(released.Status.Conditions,
+		metav1.Condition{Type: WorkWaiting, Status: metav1.ConditionFalse, Reason: DependenciesAvailableReason})
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{worksByDependency: indexWorksByDependency})
+	for _, work := range []*workapiv1.ManifestWork{
+		newDependencyWork("db", true, ""),
+		newDependencyWork("app", false, "db"),
+		newDependencyWork("web", false, "cache,app"),
+		released,
+	} {
+		if err := indexer.Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	controller := &ManifestWorkController{manifestWorkIndexer: indexer}
+
+	cases := []struct {
+		name         string
+		changed      string
+		expectedKeys []string
+	}{
+		{name: "dependency of a waiting work", changed: "db", expectedKeys: []string{"app"}},
+		{name: "intermediate dependency of a chain", changed: "app", expectedKeys: []string{"web"}},
+		{name: "deleted dependency", changed: "cache", expectedKeys: []string{"web"}},
+		{name: "no dependents", changed: "web"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			keys := controller.dependentWorksQueueKeysFunc(newDependencyWork(c.changed, true, ""))
+			if !reflect.DeepEqual(keys, c.expectedKeys) {
+				t.Errorf("expected keys %v, but got %v", c.expectedKeys, keys)
+			}
+		})
+	}
+}
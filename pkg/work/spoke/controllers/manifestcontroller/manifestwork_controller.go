@@ -2,7 +2,11 @@ package manifestcontroller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -13,11 +17,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
@@ -26,12 +35,15 @@ import (
 	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
+	"open-cluster-management.io/ocm/pkg/common/namespaces"
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth/basic"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
 )
 
 var (
@@ -39,11 +51,20 @@ var (
 	MaxRequeueDuration = 24 * time.Hour
 )
 
+const (
+	// ApiNotAvailableReason is the reason of the applied condition of a manifest when the api of the
+	// manifest does not exist on the managed cluster.
+	ApiNotAvailableReason = "ApiNotAvailable"
+)
+
 // ManifestWorkController is to reconcile the workload resources
 // fetched from hub cluster on spoke cluster.
 type ManifestWorkController struct {
-	manifestWorkPatcher        patcher.Patcher[*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus]
-	manifestWorkLister         worklister.ManifestWorkNamespaceLister
+	manifestWorkPatcher patcher.Patcher[*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus]
+	manifestWorkClient  workv1client.ManifestWorkInterface
+	manifestWorkLister  worklister.ManifestWorkNamespaceLister
+	// manifestWorkIndexer indexes the works held by their dependencies.
+	manifestWorkIndexer        cache.Indexer
 	appliedManifestWorkClient  workv1client.AppliedManifestWorkInterface
 	appliedManifestWorkPatcher patcher.Patcher[*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus]
 	appliedManifestWorkLister  worklister.AppliedManifestWorkLister
@@ -53,11 +74,29 @@ type ManifestWorkController struct {
 	restMapper                 meta.RESTMapper
 	appliers                   *apply.Appliers
 	validator                  auth.ExecutorValidator
+	// applyConcurrency is the max number of manifests in a work applied in parallel.
+	applyConcurrency int
+	// stampSourceAnnotations indicates whether the applied resources are stamped with the source annotations.
+	stampSourceAnnotations bool
+	// transformers mutate the manifests after they are decoded and before they are applied.
+	transformers transformer.Transformers
+	// protectedNamespaces matches the namespaces the manifests are never applied into.
+	protectedNamespaces *namespaces.Matcher
+	// reporter reports the failures of the works as critical events on hub.
+	reporter *agentevents.Reporter
+	// quotaBackoff is the requeue backoff of the works which have manifests rejected by quotas or limit ranges.
+	quotaBackoff *flowcontrol.Backoff
+	// panics counts the consecutive panics of the works to quarantine the works crashing the apply loop.
+	panics panicTracker
+	// priorityQueue feeds the controller queue with the works by their priorities.
+	priorityQueue *priorityQueue
 }
 
 type applyResult struct {
 	Result runtime.Object
 	Error  error
+	// changed indicates the resource is created or changed by the apply
+	changed bool
 
 	resourceMeta workapiv1.ManifestResourceMeta
 }
@@ -73,15 +112,32 @@ func NewManifestWorkController(
 	manifestWorkLister worklister.ManifestWorkNamespaceLister,
 	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface,
 	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
+	crdInformer cache.SharedIndexInformer,
 	hubHash, agentID string,
 	restMapper meta.RESTMapper,
-	validator auth.ExecutorValidator) factory.Controller {
+	validator auth.ExecutorValidator,
+	applyConcurrency int,
+	resyncBudget int,
+	stampSourceAnnotations bool,
+	transformers transformer.Transformers,
+	protectedNamespaces *namespaces.Matcher,
+	reporter *agentevents.Reporter) factory.Controller {
+	RegisterMetrics()
+
+	err := manifestWorkInformer.Informer().AddIndexers(cache.Indexers{
+		worksByDependency: indexWorksByDependency,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
 
 	controller := &ManifestWorkController{
 		manifestWorkPatcher: patcher.NewPatcher[
 			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
 			manifestWorkClient),
+		manifestWorkClient:        manifestWorkClient,
 		manifestWorkLister:        manifestWorkLister,
+		manifestWorkIndexer:       manifestWorkInformer.Informer().GetIndexer(),
 		appliedManifestWorkClient: appliedManifestWorkClient,
 		appliedManifestWorkPatcher: patcher.NewPatcher[
 			*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus](
@@ -93,30 +149,83 @@ func NewManifestWorkController(
 		restMapper:                restMapper,
 		appliers:                  apply.NewAppliers(spokeDynamicClient, spokeKubeClient, spokeAPIExtensionClient),
 		validator:                 validator,
+		applyConcurrency:          applyConcurrency,
+		stampSourceAnnotations:    stampSourceAnnotations,
+		transformers:              transformers,
+		protectedNamespaces:       protectedNamespaces,
+		reporter:                  reporter,
+		quotaBackoff:              flowcontrol.NewBackOff(QuotaRejectionInitialBackoff, QuotaRejectionMaxBackoff),
+	}
+
+	// the manifestworks changed by the informer are queued by their priorities, and moved into the controller
+	// queue in the order of the priorities.
+	syncCtx := factory.NewSyncContext("ManifestWorkAgent", recorder)
+	if handler := restMapperResetHandler(restMapper); handler != nil {
+		if _, err := crdInformer.AddEventHandler(handler); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}
+	// the works not changed since they were applied are synced at most resyncBudget per second, so a relist
+	// from the hub does not burst no-op applies against the managed cluster.
+	var budget flowcontrol.PassiveRateLimiter
+	if resyncBudget > 0 {
+		budget = flowcontrol.NewTokenBucketPassiveRateLimiter(float32(resyncBudget), resyncBudget)
+	}
+	controller.priorityQueue = newPriorityQueue(budget)
+	if _, err := manifestWorkInformer.Informer().AddEventHandler(controller.priorityQueue.eventHandler()); err != nil {
+		utilruntime.HandleError(err)
 	}
 
 	return factory.New().
-		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
-			accessor, _ := meta.Accessor(obj)
-			return accessor.GetName()
-		}, manifestWorkInformer.Informer()).
+		WithSyncContext(syncCtx).
+		WithBareInformers(manifestWorkInformer.Informer()).
+		WithPostStartHooks(func(ctx context.Context, syncCtx factory.SyncContext) error {
+			controller.priorityQueue.run(ctx, syncCtx.Queue())
+			return nil
+		}).
 		WithFilteredEventsInformersQueueKeyFunc(
 			helper.AppliedManifestworkQueueKeyFunc(hubHash),
 			helper.AppliedManifestworkHubHashFilter(hubHash),
 			appliedManifestWorkInformer.Informer()).
+		WithInformersQueueKeysFunc(controller.apiNotAvailableWorksQueueKeysFunc, crdInformer).
+		WithInformersQueueKeysFunc(controller.dependentWorksQueueKeysFunc, manifestWorkInformer.Informer()).
 		WithSync(controller.sync).ResyncEvery(ResyncInterval).ToController("ManifestWorkAgent", recorder)
 }
 
 // sync is the main reconcile loop for manifest work. It is triggered in two scenarios
 // 1. ManifestWork API changes
 // 2. Resources defined in manifest changed on spoke
-func (m *ManifestWorkController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+//
+// A panic when the work is applied is recovered, so a work crashing the apply loop does not take the other
+// works down with the agent. The work is quarantined once it panics QuarantineThreshold times.
+func (m *ManifestWorkController) sync(ctx context.Context, controllerContext factory.SyncContext) (err error) {
+	manifestWorkName := controllerContext.QueueKey()
+	// the work is taken out of the controller queue, so the queue is fed with the next works
+	if m.priorityQueue != nil {
+		m.priorityQueue.signal()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(r)
+		}
+		var panicErr *panicError
+		if errors.As(err, &panicErr) {
+			err = m.handlePanic(ctx, controllerContext, manifestWorkName, panicErr)
+		}
+	}()
+
+	return m.syncManifestWork(ctx, controllerContext)
+}
+
+func (m *ManifestWorkController) syncManifestWork(ctx context.Context, controllerContext factory.SyncContext) error {
 	manifestWorkName := controllerContext.QueueKey()
 	klog.V(4).Infof("Reconciling ManifestWork %q", manifestWorkName)
 
 	oldManifestWork, err := m.manifestWorkLister.Get(manifestWorkName)
 	if apierrors.IsNotFound(err) {
 		// work not found, could have been deleted, do nothing.
+		m.quotaBackoff.DeleteEntry(manifestWorkName)
+		m.panics.forget(manifestWorkName)
 		return nil
 	}
 	if err != nil {
@@ -135,6 +244,17 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 		return nil
 	}
 
+	// skip the quarantined work until its spec is changed
+	if isQuarantined(manifestWork) {
+		klog.V(4).Infof("ManifestWork %q is quarantined, skip applying it", manifestWorkName)
+		return nil
+	}
+
+	// hold the work until its dependencies are available
+	if waiting, err := m.syncDependencies(ctx, controllerContext, manifestWork, oldManifestWork); waiting {
+		return err
+	}
+
 	// Apply appliedManifestWork
 	appliedManifestWork, err := m.applyAppliedManifestWork(ctx, manifestWork.Name, m.hubHash, m.agentID)
 	if err != nil {
@@ -143,13 +263,15 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 
 	// We creat a ownerref instead of controller ref since multiple controller can declare the ownership of a manifests
 	owner := helper.NewAppliedManifestWorkOwner(appliedManifestWork)
+	sourceAnnotations := m.sourceAnnotations(manifestWork)
 
 	errs := []error{}
 	// Apply resources on spoke cluster.
 	resourceResults := make([]applyResult, len(manifestWork.Spec.Workload.Manifests))
 	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		resourceResults = m.applyManifests(
-			ctx, manifestWork.Spec.Workload.Manifests, manifestWork.Spec, controllerContext.Recorder(), *owner, resourceResults)
+			ctx, manifestWork.Spec.Workload.Manifests, manifestWork.Spec, manifestWork.Annotations[helper.RestartedAtAnnotation],
+			controllerContext.Recorder(), *owner, sourceAnnotations, resourceResults)
 
 		for _, result := range resourceResults {
 			if apierrors.IsConflict(result.Error) {
@@ -163,8 +285,18 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 		klog.Errorf("failed to apply resource with error %v", err)
 	}
 
+	// the work is retried or quarantined if it panics, the status is not updated.
+	for _, result := range resourceResults {
+		var panicErr *panicError
+		if errors.As(result.Error, &panicErr) {
+			return panicErr
+		}
+	}
+	m.panics.forget(manifestWorkName)
+
 	newManifestConditions := []workapiv1.ManifestCondition{}
 	var requeueTime = MaxRequeueDuration
+	quotaRejected := false
 	for _, result := range resourceResults {
 		manifestCondition := workapiv1.ManifestCondition{
 			ResourceMeta: result.resourceMeta,
@@ -188,6 +320,36 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 			}
 		}
 
+		// the api not available error is not returned, the work will be requeued once the crd is installed.
+		var apiErr *apiNotAvailableError
+		if errors.As(result.Error, &apiErr) {
+			klog.V(2).Infof("apply work %s fails with err: %v", manifestWorkName, result.Error)
+			result.Error = nil
+		}
+
+		// the scope mismatch error is not returned either, the work will be requeued once the scope is changed.
+		var scopeErr *scopeMismatchError
+		if errors.As(result.Error, &scopeErr) {
+			klog.V(2).Infof("apply work %s fails with err: %v", manifestWorkName, result.Error)
+			result.Error = nil
+		}
+
+		// the manifests in the protected namespaces are not retried, the agent is restarted once the protected
+		// namespaces are changed.
+		var protectedErr *protectedNamespaceError
+		if errors.As(result.Error, &protectedErr) {
+			klog.V(2).Infof("apply work %s fails with err: %v", manifestWorkName, result.Error)
+			result.Error = nil
+		}
+
+		// the quota and limit range rejections are not returned, the work is requeued with a backoff until the
+		// quota is released or the limit range is changed.
+		if isQuotaRejection(result.Error) {
+			klog.V(2).Infof("apply work %s fails with err: %v", manifestWorkName, result.Error)
+			result.Error = nil
+			quotaRejected = true
+		}
+
 		// ignore server side apply conflict error since it cannot be resolved by error fallback.
 		var ssaConflict *apply.ServerSideApplyConflictError
 		if result.Error != nil && !errors.As(result.Error, &ssaConflict) {
@@ -213,6 +375,8 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 		}
 		meta.SetStatusCondition(&manifestWork.Status.Conditions, appliedCondition)
 	}
+	// the work is not quarantined anymore once its spec is changed
+	meta.RemoveStatusCondition(&manifestWork.Status.Conditions, WorkQuarantined)
 
 	// Update work status
 	updated, err := m.manifestWorkPatcher.PatchStatus(ctx, manifestWork, manifestWork.Status, oldManifestWork.Status)
@@ -220,6 +384,20 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 		errs = append(errs, fmt.Errorf("failed to update work status with err %w", err))
 	}
 
+	// Update the last applied time and the applied generation of the work
+	if err := m.applyWorkAnnotations(ctx, oldManifestWork, resourceResults); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update work annotations with err %w", err))
+	}
+
+	if quotaRejected {
+		m.quotaBackoff.Next(manifestWorkName, m.quotaBackoff.Clock.Now())
+		if backoff := m.quotaBackoff.Get(manifestWorkName); backoff < requeueTime {
+			requeueTime = backoff
+		}
+	} else {
+		m.quotaBackoff.DeleteEntry(manifestWorkName)
+	}
+
 	if !updated && requeueTime < MaxRequeueDuration {
 		controllerContext.Queue().AddAfter(manifestWorkName, requeueTime)
 	}
@@ -227,11 +405,55 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 	if len(errs) > 0 {
 		err = utilerrors.NewAggregate(errs)
 		klog.Errorf("Reconcile work %s fails with err: %v", manifestWorkName, err)
+		m.reporter.Reportf(ctx, agentevents.CategoryWorkApplyFailed, "Failed to apply manifestwork %s: %v", manifestWorkName, err)
 	}
 
 	return err
 }
 
+// applyWorkAnnotations sets the last applied time of the work if any of the resources is created or changed,
+// and sets the applied generation of the work if all the resources are applied successfully.
+func (m *ManifestWorkController) applyWorkAnnotations(
+	ctx context.Context, manifestWork *workapiv1.ManifestWork, results []applyResult) error {
+	requiredAnnotations := map[string]string{}
+	succeeded := true
+	for _, result := range results {
+		if result.changed {
+			requiredAnnotations[helper.LastAppliedTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		}
+		if result.Error != nil {
+			succeeded = false
+		}
+	}
+	if succeeded {
+		requiredAnnotations[helper.AppliedGenerationAnnotation] = strconv.FormatInt(manifestWork.Generation, 10)
+	}
+
+	patchedAnnotations := map[string]interface{}{}
+	for key, value := range requiredAnnotations {
+		if manifestWork.Annotations[key] != value {
+			patchedAnnotations[key] = value
+		}
+	}
+	if len(patchedAnnotations) == 0 {
+		return nil
+	}
+
+	// the work status is patched with the resourceVersion of the work just now, so the annotations are patched
+	// without the resourceVersion. It is safe since only the agent maintains these annotations.
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": patchedAnnotations,
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = m.manifestWorkClient.Patch(ctx, manifestWork.Name, types.MergePatchType, patchData, metav1.PatchOptions{})
+	return err
+}
+
 func (m *ManifestWorkController) applyAppliedManifestWork(ctx context.Context, workName, hubHash, agentID string) (*workapiv1.AppliedManifestWork, error) {
 	appliedManifestWorkName := fmt.Sprintf("%s-%s", m.hubHash, workName)
 	requiredAppliedWork := &workapiv1.AppliedManifestWork{
@@ -259,35 +481,110 @@ func (m *ManifestWorkController) applyAppliedManifestWork(ctx context.Context, w
 	return appliedManifestWork, err
 }
 
+// applyManifests applies the manifests without result or with a conflict error. The manifests are
+// applied in waves, the CRDs and namespaces are applied before the other manifests, and the manifests
+// in the same wave are applied in parallel with at most applyConcurrency workers. The result of each
+// manifest is kept at the index of the manifest.
 func (m *ManifestWorkController) applyManifests(
 	ctx context.Context,
 	manifests []workapiv1.Manifest,
 	workSpec workapiv1.ManifestWorkSpec,
+	restartedAt string,
 	recorder events.Recorder,
 	owner metav1.OwnerReference,
+	sourceAnnotations map[string]string,
 	existingResults []applyResult) []applyResult {
 
+	waves := make([][]int, applyWaveCount)
 	for index, manifest := range manifests {
 		switch {
 		case existingResults[index].Result == nil:
 			// Apply if there is no result.
-			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner)
 		case apierrors.IsConflict(existingResults[index].Error):
 			// Apply if there is a resource conflict error.
-			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner)
+		default:
+			continue
+		}
+		wave := manifestApplyWave(manifest)
+		waves[wave] = append(waves[wave], index)
+	}
+
+	concurrency := m.applyConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// the content hash names depend on all the manifests of the work, so they are computed before any manifest
+	// is applied and the references to them are rewritten in the other manifests.
+	transformers := m.transformers
+	if contentHash := transformer.NewContentHashTransformer(manifests); contentHash != nil {
+		transformers = append(transformer.Transformers{contentHash}, m.transformers...)
+	}
+	// the workloads are restarted by the restartedAt annotation of the work, the agent transformers are copied
+	// since they are shared by the works.
+	if restart := transformer.NewRestartTransformer(restartedAt); restart != nil {
+		transformers = append(append(transformer.Transformers{}, transformers...), restart)
+	}
+
+	for _, wave := range waves {
+		var wg sync.WaitGroup
+		tokens := make(chan struct{}, concurrency)
+		for _, index := range wave {
+			tokens <- struct{}{}
+			wg.Add(1)
+			go func(index int) {
+				defer func() {
+					// a panic in the goroutine cannot be recovered by the caller, it is returned as the result.
+					if r := recover(); r != nil {
+						existingResults[index] = applyResult{Error: newPanicError(r)}
+					}
+					<-tokens
+					wg.Done()
+				}()
+				existingResults[index] = m.applyOneManifest(
+					ctx, index, manifests[index], workSpec, transformers, recorder, owner, sourceAnnotations)
+			}(index)
 		}
+		wg.Wait()
 	}
 
 	return existingResults
 }
 
+const (
+	// the CRDs and namespaces are applied in the first wave since the other manifests may depend on them.
+	applyWavePrerequisite = iota
+	applyWaveDefault
+	applyWaveCount
+)
+
+// manifestApplyWave returns the wave in which the manifest is applied.
+func manifestApplyWave(manifest workapiv1.Manifest) int {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(manifest.Raw, &typeMeta); err != nil {
+		return applyWaveDefault
+	}
+
+	gvk := typeMeta.GroupVersionKind()
+	switch {
+	case gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition":
+		return applyWavePrerequisite
+	case gvk.Group == "" && gvk.Kind == "Namespace":
+		return applyWavePrerequisite
+	default:
+		return applyWaveDefault
+	}
+}
+
 func (m *ManifestWorkController) applyOneManifest(
 	ctx context.Context,
 	index int,
 	manifest workapiv1.Manifest,
 	workSpec workapiv1.ManifestWorkSpec,
+	transformers transformer.Transformers,
 	recorder events.Recorder,
-	owner metav1.OwnerReference) applyResult {
+	owner metav1.OwnerReference,
+	sourceAnnotations map[string]string) applyResult {
 
 	result := applyResult{}
 
@@ -298,13 +595,38 @@ func (m *ManifestWorkController) applyOneManifest(
 		return result
 	}
 
+	// the resource meta is built before the transform, so it keeps the identity in the manifest for the
+	// manifestConfigs, the orphaning rules and the executor permissions even if the name is transformed.
 	resMeta, gvr, err := helper.BuildResourceMeta(index, required, m.restMapper)
 	result.resourceMeta = resMeta
+	if meta.IsNoMatchError(err) {
+		result.Error = m.newAPINotAvailableError(required.GroupVersionKind(), err)
+		return result
+	}
 	if err != nil {
 		result.Error = err
 		return result
 	}
 
+	// transform the required before it is applied, so the appliers compare the existing with the transformed one.
+	if err := transformers.Transform(required); err != nil {
+		result.Error = err
+		return result
+	}
+
+	// the scope of a CRD could be changed across versions, check the namespace of the manifest against the
+	// current scope instead of failing to apply it with the stale scope.
+	if err := checkScope(m.restMapper, required.GroupVersionKind(), resMeta.Namespace); err != nil {
+		result.Error = err
+		return result
+	}
+
+	// the manifests in the protected namespaces are rejected regardless of the permission of the executor
+	if m.protectedNamespaces.Match(resMeta.Namespace) {
+		result.Error = &protectedNamespaceError{namespace: resMeta.Namespace}
+		return result
+	}
+
 	// check if the resource to be applied should be owned by the manifest work
 	ownedByTheWork := helper.OwnedByTheWork(gvr, resMeta.Namespace, resMeta.Name, workSpec.DeleteOption)
 
@@ -327,16 +649,56 @@ func (m *ManifestWorkController) applyOneManifest(
 	}
 
 	applier := m.appliers.GetApplier(strategy.Type)
-	result.Result, result.Error = applier.Apply(ctx, gvr, required, requiredOwner, option, recorder)
+	result.Result, result.changed, result.Error = applier.Apply(ctx, gvr, required, requiredOwner, option, recorder)
+	result.Error = classifyApplyError(result.Error)
 
-	// patch the ownerref
+	// patch the ownerref and the source annotations
 	if result.Error == nil {
-		result.Error = helper.ApplyOwnerReferences(ctx, m.spokeDynamicClient, gvr, result.Result, requiredOwner)
+		result.Error = helper.ApplyOwnerReferencesAndAnnotations(ctx, m.spokeDynamicClient, gvr, result.Result, requiredOwner,
+			manageSourceAnnotations(ownedByTheWork, sourceAnnotations, result.Result))
 	}
 
 	return result
 }
 
+// sourceAnnotations returns the annotations stamped on the resources applied by the manifestwork, it returns nil
+// if the stamping is disabled.
+func (m *ManifestWorkController) sourceAnnotations(manifestWork *workapiv1.ManifestWork) map[string]string {
+	if !m.stampSourceAnnotations {
+		return nil
+	}
+	return map[string]string{
+		helper.SourceHubHashAnnotation:      m.hubHash,
+		helper.SourceManifestWorkAnnotation: fmt.Sprintf("%s/%s", manifestWork.Namespace, manifestWork.Name),
+		helper.SourceAgentIDAnnotation:      m.agentID,
+	}
+}
+
+// manageSourceAnnotations returns the source annotations to be applied on the resource. The source annotations
+// are overridden if the resource is owned by the work, so they are transferred to the work adopting the resource.
+// If the resource is orphaned, the source annotations are removed only if they are stamped by this work.
+func manageSourceAnnotations(ownedByTheWork bool, sourceAnnotations map[string]string, existing runtime.Object) map[string]string {
+	if len(sourceAnnotations) == 0 || ownedByTheWork {
+		return sourceAnnotations
+	}
+
+	accessor, err := meta.Accessor(existing)
+	if err != nil {
+		return nil
+	}
+	existingAnnotations := accessor.GetAnnotations()
+	if existingAnnotations[helper.SourceHubHashAnnotation] != sourceAnnotations[helper.SourceHubHashAnnotation] ||
+		existingAnnotations[helper.SourceManifestWorkAnnotation] != sourceAnnotations[helper.SourceManifestWorkAnnotation] {
+		return nil
+	}
+
+	removal := map[string]string{}
+	for key := range sourceAnnotations {
+		removal[key] = ""
+	}
+	return removal
+}
+
 // manageOwnerRef return a ownerref based on the resource and the ownedByTheWork indicating whether the owneref
 // should be removed or added. If the resource is not owned by the work, the owner's UID is updated for removal.
 func manageOwnerRef(
@@ -369,7 +731,117 @@ func allInCondition(conditionType string, manifests []workapiv1.ManifestConditio
 	return exists, exists
 }
 
+// apiNotAvailableError indicates the api of a manifest is not served by the managed cluster.
+type apiNotAvailableError struct {
+	gvk               schema.GroupVersionKind
+	availableVersions []string
+	err               error
+}
+
+func (e *apiNotAvailableError) Error() string {
+	msg := fmt.Sprintf("the api %s is not available on the managed cluster", e.gvk.String())
+	if len(e.availableVersions) > 0 {
+		msg = fmt.Sprintf("%s, available versions of %s: %s",
+			msg, e.gvk.GroupKind().String(), strings.Join(e.availableVersions, ","))
+	}
+	return msg
+}
+
+func (e *apiNotAvailableError) Unwrap() error {
+	return e.err
+}
+
+// newAPINotAvailableError builds an apiNotAvailableError with the versions of the same group kind
+// served by the managed cluster, the preferred version is listed first.
+func (m *ManifestWorkController) newAPINotAvailableError(gvk schema.GroupVersionKind, err error) error {
+	apiErr := &apiNotAvailableError{gvk: gvk, err: err}
+	mappings, mappingErr := m.restMapper.RESTMappings(gvk.GroupKind())
+	if mappingErr != nil {
+		return apiErr
+	}
+	for _, mapping := range mappings {
+		if mapping.GroupVersionKind.Version == gvk.Version {
+			continue
+		}
+		apiErr.availableVersions = append(apiErr.availableVersions, mapping.GroupVersionKind.Version)
+	}
+	return apiErr
+}
+
+// apiNotAvailableWorksQueueKeysFunc returns the works which have manifests failed to apply due to the api is
+// not available or the scope mismatch when a crd is changed on the managed cluster, so the works are reconciled
+// right after the crd is installed or its scope is changed.
+func (m *ManifestWorkController) apiNotAvailableWorksQueueKeysFunc(obj runtime.Object) []string {
+	works, err := m.manifestWorkLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	var keys []string
+	for _, work := range works {
+		for _, manifest := range work.Status.ResourceStatus.Manifests {
+			cond := meta.FindStatusCondition(manifest.Conditions, string(workapiv1.ManifestApplied))
+			if cond != nil && (cond.Reason == ApiNotAvailableReason || cond.Reason == ScopeMismatchReason) {
+				keys = append(keys, work.Name)
+				break
+			}
+		}
+	}
+	return keys
+}
+
 func buildAppliedStatusCondition(result applyResult) metav1.Condition {
+	var apiErr *apiNotAvailableError
+	if errors.As(result.Error, &apiErr) {
+		return metav1.Condition{
+			Type:    string(workapiv1.ManifestApplied),
+			Status:  metav1.ConditionFalse,
+			Reason:  ApiNotAvailableReason,
+			Message: fmt.Sprintf("Failed to apply manifest: %v", result.Error),
+		}
+	}
+
+	var scopeErr *scopeMismatchError
+	if errors.As(result.Error, &scopeErr) {
+		return metav1.Condition{
+			Type:    string(workapiv1.ManifestApplied),
+			Status:  metav1.ConditionFalse,
+			Reason:  ScopeMismatchReason,
+			Message: fmt.Sprintf("Failed to apply manifest: %v", result.Error),
+		}
+	}
+
+	var protectedErr *protectedNamespaceError
+	if errors.As(result.Error, &protectedErr) {
+		return metav1.Condition{
+			Type:    string(workapiv1.ManifestApplied),
+			Status:  metav1.ConditionFalse,
+			Reason:  ForbiddenReason,
+			Message: fmt.Sprintf("Failed to apply manifest: %v", result.Error),
+		}
+	}
+
+	var quotaErr *quotaExceededError
+	if errors.As(result.Error, &quotaErr) {
+		return metav1.Condition{
+			Type:    string(workapiv1.ManifestApplied),
+			Status:  metav1.ConditionFalse,
+			Reason:  QuotaExceededReason,
+			Message: fmt.Sprintf("Failed to apply manifest: %v", result.Error),
+		}
+	}
+
+	var limitRangeErr *limitRangeViolatedError
+	if errors.As(result.Error, &limitRangeErr) {
+		return metav1.Condition{
+			Type:    string(workapiv1.ManifestApplied),
+			Status:  metav1.ConditionFalse,
+			Reason:  LimitRangeViolatedReason,
+			Message: fmt.Sprintf("Failed to apply manifest: %v", result.Error),
+		}
+	}
+
 	if result.Error != nil {
 		return metav1.Condition{
 			Type:    string(workapiv1.ManifestApplied),
@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/openshift/library-go/pkg/operator/events"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -19,11 +24,13 @@ import (
 	fakedynamic "k8s.io/client-go/dynamic/fake"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/flowcontrol"
 
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/namespaces"
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/work/helper"
@@ -31,6 +38,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth/basic"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
 	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
 )
 
 type testController struct {
@@ -48,6 +56,7 @@ func newController(t *testing.T, work *workapiv1.ManifestWork, appliedWork *work
 		manifestWorkPatcher: patcher.NewPatcher[
 			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
 			fakeWorkClient.WorkV1().ManifestWorks("cluster1")),
+		manifestWorkClient: fakeWorkClient.WorkV1().ManifestWorks("cluster1"),
 		manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks("cluster1"),
 		appliedManifestWorkPatcher: patcher.NewPatcher[
 			*workapiv1.AppliedManifestWork, workapiv1.AppliedManifestWorkSpec, workapiv1.AppliedManifestWorkStatus](
@@ -56,6 +65,7 @@ func newController(t *testing.T, work *workapiv1.ManifestWork, appliedWork *work
 		appliedManifestWorkLister: workInformerFactory.Work().V1().AppliedManifestWorks().Lister(),
 		restMapper:                mapper,
 		validator:                 basic.NewSARValidator(nil, spokeKubeClient),
+		quotaBackoff:              flowcontrol.NewBackOff(QuotaRejectionInitialBackoff, QuotaRejectionMaxBackoff),
 	}
 
 	if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
@@ -219,9 +229,15 @@ func (t *testCase) validate(
 	spokeKubeActions := kubeClient.Actions()
 	testingcommon.AssertActions(ts, spokeKubeActions, t.expectedKubeAction...)
 
-	actual, ok := actualWorkActions[len(actualWorkActions)-1].(clienttesting.PatchActionImpl)
-	if !ok {
-		ts.Errorf("Expected to get patch action")
+	// the status of the work is patched before the annotations
+	var actual clienttesting.PatchActionImpl
+	for _, workAction := range actualWorkActions {
+		if patchAction, ok := workAction.(clienttesting.PatchActionImpl); ok && patchAction.GetSubresource() == "status" {
+			actual = patchAction
+		}
+	}
+	if actual.GetSubresource() != "status" {
+		ts.Errorf("Expected to get status patch action")
 	}
 	p := actual.Patch
 	actualWork := &workapiv1.ManifestWork{}
@@ -276,14 +292,14 @@ func TestSync(t *testing.T) {
 	cases := []*testCase{
 		newTestCase("create single resource").
 			withWorkManifest(spoketesting.NewUnstructured("v1", "Secret", "ns1", "test")).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedKubeAction("get", "create").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
 			withExpectedWorkCondition(expectedCondition{string(workapiv1.WorkApplied), metav1.ConditionTrue}),
 		newTestCase("create single deployment resource").
 			withWorkManifest(spoketesting.NewUnstructured("apps/v1", "Deployment", "ns1", "test")).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "create").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -291,14 +307,14 @@ func TestSync(t *testing.T) {
 		newTestCase("update single resource").
 			withWorkManifest(spoketesting.NewUnstructured("v1", "Secret", "ns1", "test")).
 			withSpokeObject(spoketesting.NewSecret("test", "ns1", "value2")).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedKubeAction("get", "delete", "create").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
 			withExpectedWorkCondition(expectedCondition{string(workapiv1.WorkApplied), metav1.ConditionTrue}),
 		newTestCase("create single unstructured resource").
 			withWorkManifest(spoketesting.NewUnstructured("v1", "NewObject", "ns1", "test")).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "create").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -306,7 +322,7 @@ func TestSync(t *testing.T) {
 		newTestCase("update single unstructured resource").
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withSpokeDynamicObject(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val2"}})).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "update").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -314,7 +330,7 @@ func TestSync(t *testing.T) {
 		newTestCase("multiple create&update resource").
 			withWorkManifest(spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"), spoketesting.NewUnstructured("v1", "Secret", "ns2", "test")).
 			withSpokeObject(spoketesting.NewSecret("test", "ns1", "value2")).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedKubeAction("get", "delete", "create", "get", "create").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}, expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -344,7 +360,7 @@ func TestFailedToApplyResource(t *testing.T) {
 	tc := newTestCase("multiple create&update resource").
 		withWorkManifest(spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"), spoketesting.NewUnstructured("v1", "Secret", "ns2", "test")).
 		withSpokeObject(spoketesting.NewSecret("test", "ns1", "value2")).
-		withExpectedWorkAction("patch").
+		withExpectedWorkAction("patch", "patch").
 		withAppliedWorkAction("create").
 		withExpectedKubeAction("get", "delete", "create", "get", "create").
 		withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}, expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionFalse}).
@@ -383,7 +399,7 @@ func TestUpdateStrategy(t *testing.T) {
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withSpokeDynamicObject(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val2"}})).
 			withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n1", nil)).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "update").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -392,7 +408,7 @@ func TestUpdateStrategy(t *testing.T) {
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withSpokeDynamicObject(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val2"}})).
 			withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n1", &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeUpdate})).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "update").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -401,7 +417,7 @@ func TestUpdateStrategy(t *testing.T) {
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withSpokeDynamicObject(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val2"}})).
 			withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n2", &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeServerSideApply})).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "update").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -409,25 +425,25 @@ func TestUpdateStrategy(t *testing.T) {
 		newTestCase("create single resource with server side apply updateStrategy").
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n1", &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeServerSideApply})).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
-			withExpectedDynamicAction("patch", "patch").
+			withExpectedDynamicAction("get", "patch", "patch").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
 			withExpectedWorkCondition(expectedCondition{string(workapiv1.WorkApplied), metav1.ConditionTrue}),
 		newTestCase("update single resource with server side apply updateStrategy").
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withSpokeDynamicObject(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val2"}})).
 			withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n1", &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeServerSideApply})).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
-			withExpectedDynamicAction("patch", "patch").
+			withExpectedDynamicAction("get", "patch", "patch").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
 			withExpectedWorkCondition(expectedCondition{string(workapiv1.WorkApplied), metav1.ConditionTrue}),
 		newTestCase("update single resource with create only updateStrategy").
 			withWorkManifest(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}})).
 			withSpokeDynamicObject(spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val2"}})).
 			withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n1", &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeCreateOnly})).
-			withExpectedWorkAction("patch").
+			withExpectedWorkAction("patch", "patch").
 			withAppliedWorkAction("create").
 			withExpectedDynamicAction("get", "patch").
 			withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionTrue}).
@@ -465,7 +481,7 @@ func TestServerSideApplyConflict(t *testing.T) {
 		withManifestConfig(newManifestConfigOption("", "newobjects", "ns1", "n1", &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeServerSideApply})).
 		withExpectedWorkAction("patch").
 		withAppliedWorkAction("create").
-		withExpectedDynamicAction("patch").
+		withExpectedDynamicAction("get", "patch").
 		withExpectedManifestCondition(expectedCondition{string(workapiv1.ManifestApplied), metav1.ConditionFalse}).
 		withExpectedWorkCondition(expectedCondition{string(workapiv1.WorkApplied), metav1.ConditionFalse})
 
@@ -489,6 +505,118 @@ func TestServerSideApplyConflict(t *testing.T) {
 	testCase.validate(t, controller.dynamicClient, controller.workClient, controller.kubeClient)
 }
 
+func TestWorkAnnotations(t *testing.T) {
+	lastAppliedTime := "2023-01-01T00:00:00Z"
+	appliedWork := &workapiv1.AppliedManifestWork{
+		ObjectMeta: metav1.ObjectMeta{Name: "-work-0", UID: "appliedwork-uid"},
+		Spec:       workapiv1.AppliedManifestWorkSpec{ManifestWorkName: "work-0"},
+	}
+	newSpokeObject := func(value string) runtime.Object {
+		obj := spoketesting.NewUnstructuredWithContent(
+			"v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": value}})
+		obj.SetOwnerReferences([]metav1.OwnerReference{*helper.NewAppliedManifestWorkOwner(appliedWork)})
+		return obj
+	}
+	cases := []struct {
+		name                      string
+		annotations               map[string]string
+		spokeDynamicObject        []runtime.Object
+		expectedLastAppliedTime   func(t *testing.T, value string)
+		expectedAppliedGeneration string
+		expectedPatched           bool
+	}{
+		{
+			name: "no-op apply does not bump the last applied time",
+			annotations: map[string]string{
+				helper.LastAppliedTimeAnnotation:   lastAppliedTime,
+				helper.AppliedGenerationAnnotation: "1",
+			},
+			spokeDynamicObject: []runtime.Object{newSpokeObject("val1")},
+			expectedPatched:    false,
+		},
+		{
+			name: "record the applied generation without changing resources",
+			annotations: map[string]string{
+				helper.LastAppliedTimeAnnotation:   lastAppliedTime,
+				helper.AppliedGenerationAnnotation: "0",
+			},
+			spokeDynamicObject: []runtime.Object{newSpokeObject("val1")},
+			expectedLastAppliedTime: func(t *testing.T, value string) {
+				if len(value) != 0 {
+					t.Errorf("expected last applied time not patched, but got %s", value)
+				}
+			},
+			expectedAppliedGeneration: "1",
+			expectedPatched:           true,
+		},
+		{
+			name: "update the resource bumps the last applied time",
+			annotations: map[string]string{
+				helper.LastAppliedTimeAnnotation:   lastAppliedTime,
+				helper.AppliedGenerationAnnotation: "1",
+			},
+			spokeDynamicObject: []runtime.Object{newSpokeObject("val2")},
+			expectedLastAppliedTime: func(t *testing.T, value string) {
+				appliedTime, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if time.Since(appliedTime) > time.Minute {
+					t.Errorf("expected last applied time is bumped, but got %s", value)
+				}
+			},
+			expectedPatched: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, workKey := spoketesting.NewManifestWork(0,
+				spoketesting.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1", map[string]interface{}{"spec": map[string]interface{}{"key1": "val1"}}))
+			work.Generation = 1
+			work.Annotations = c.annotations
+			work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+			controller := newController(t, work, appliedWork, spoketesting.NewFakeRestMapper()).
+				withKubeObject().
+				withUnstructuredObject(c.spokeDynamicObject...)
+
+			err := controller.toController().sync(context.TODO(), testingcommon.NewFakeSyncContext(t, workKey))
+			if err != nil {
+				t.Errorf("Should be success with no err: %v", err)
+			}
+
+			var annotationPatch []byte
+			for _, action := range controller.workClient.Actions() {
+				if action.GetResource().Resource == "manifestworks" && action.GetVerb() == "patch" &&
+					action.GetSubresource() == "" {
+					annotationPatch = action.(clienttesting.PatchActionImpl).Patch
+				}
+			}
+			if !c.expectedPatched {
+				if annotationPatch != nil {
+					t.Errorf("expected no annotation patch, but got %s", string(annotationPatch))
+				}
+				return
+			}
+			if annotationPatch == nil {
+				t.Fatal("expected annotation patch, but got none")
+			}
+
+			patchedWork := &workapiv1.ManifestWork{}
+			if err := json.Unmarshal(annotationPatch, patchedWork); err != nil {
+				t.Fatal(err)
+			}
+			if c.expectedLastAppliedTime != nil {
+				c.expectedLastAppliedTime(t, patchedWork.Annotations[helper.LastAppliedTimeAnnotation])
+			}
+			if patchedWork.Annotations[helper.AppliedGenerationAnnotation] != c.expectedAppliedGeneration {
+				t.Errorf("expected applied generation %q, but got %q",
+					c.expectedAppliedGeneration, patchedWork.Annotations[helper.AppliedGenerationAnnotation])
+			}
+		})
+	}
+}
+
 func newManifestConfigOption(group, resource, namespace, name string, strategy *workapiv1.UpdateStrategy) workapiv1.ManifestConfigOption {
 	return workapiv1.ManifestConfigOption{
 		ResourceIdentifier: workapiv1.ResourceIdentifier{
@@ -728,3 +856,483 @@ func TestManageOwner(t *testing.T) {
 		})
 	}
 }
+
+func TestAPINotAvailable(t *testing.T) {
+	work, workKey := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("apps/v2", "Deployment", "ns1", "test"))
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject()
+
+	syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+	// the api not available error should not be returned to avoid the work is retried repeatedly.
+	if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Should be success with no err: %v", err)
+	}
+
+	workActions := controller.workClient.Actions()
+	patchAction, ok := workActions[len(workActions)-1].(clienttesting.PatchActionImpl)
+	if !ok {
+		t.Fatalf("Expected to get patch action")
+	}
+	actualWork := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(patchAction.Patch, actualWork); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(
+		actualWork.Status.ResourceStatus.Manifests[0].Conditions, string(workapiv1.ManifestApplied))
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ApiNotAvailableReason {
+		t.Fatalf("expected ApiNotAvailable applied condition, but got %v", cond)
+	}
+	expectedMessage := "Failed to apply manifest: the api apps/v2, Kind=Deployment is not available on the managed cluster, " +
+		"available versions of Deployment.apps: v1"
+	if cond.Message != expectedMessage {
+		t.Errorf("expected message %q, but got %q", expectedMessage, cond.Message)
+	}
+}
+
+func TestAPINotAvailableWorksQueueKeys(t *testing.T) {
+	apiNotAvailableWork, _ := spoketesting.NewManifestWork(0)
+	apiNotAvailableWork.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+		newManifestCondition(0, "deployments", newCondition(
+			string(workapiv1.ManifestApplied), string(metav1.ConditionTrue), "AppliedManifestComplete", "", 1, nil)),
+		newManifestCondition(1, "guestbooks", newCondition(
+			string(workapiv1.ManifestApplied), string(metav1.ConditionFalse), ApiNotAvailableReason, "", 1, nil)),
+	}
+	appliedWork, _ := spoketesting.NewManifestWork(1)
+	appliedWork.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+		newManifestCondition(0, "deployments", newCondition(
+			string(workapiv1.ManifestApplied), string(metav1.ConditionTrue), "AppliedManifestComplete", "", 1, nil)),
+	}
+	scopeMismatchWork, _ := spoketesting.NewManifestWork(2)
+	scopeMismatchWork.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+		newManifestCondition(0, "guestbooks", newCondition(
+			string(workapiv1.ManifestApplied), string(metav1.ConditionFalse), ScopeMismatchReason, "", 1, nil)),
+	}
+
+	fakeWorkClient := fakeworkclient.NewSimpleClientset()
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeWorkClient, 5*time.Minute, workinformers.WithNamespace("cluster1"))
+	for _, work := range []*workapiv1.ManifestWork{apiNotAvailableWork, appliedWork, scopeMismatchWork} {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	controller := &ManifestWorkController{
+		manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks("cluster1"),
+	}
+	crd := spoketesting.NewUnstructured("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "guestbooks.my.domain")
+	keys := controller.apiNotAvailableWorksQueueKeysFunc(crd)
+	expectedKeys := []string{apiNotAvailableWork.Name, scopeMismatchWork.Name}
+	sort.Strings(keys)
+	sort.Strings(expectedKeys)
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("expected keys %v, but got %v", expectedKeys, keys)
+	}
+}
+
+// slowValidator slows down the apply of each manifest, and records the max number of manifests
+// applied in parallel and the order in which the manifests are applied.
+type slowValidator struct {
+	delay      time.Duration
+	failedName string
+
+	lock        sync.Mutex
+	inflight    int
+	maxInflight int
+	validated   []string
+}
+
+func (v *slowValidator) Validate(ctx context.Context, executor *workapiv1.ManifestWorkExecutor, gvr schema.GroupVersionResource,
+	namespace, name string, ownedByTheWork bool, obj *unstructured.Unstructured) error {
+	v.lock.Lock()
+	v.inflight++
+	if v.inflight > v.maxInflight {
+		v.maxInflight = v.inflight
+	}
+	v.validated = append(v.validated, name)
+	v.lock.Unlock()
+
+	time.Sleep(v.delay)
+
+	v.lock.Lock()
+	v.inflight--
+	v.lock.Unlock()
+
+	if name == v.failedName {
+		return fmt.Errorf("failed to apply %s", name)
+	}
+	return nil
+}
+
+func newSecretManifests(count int) []*unstructured.Unstructured {
+	objects := []*unstructured.Unstructured{}
+	for i := 0; i < count; i++ {
+		objects = append(objects, spoketesting.NewUnstructured("v1", "Secret", "ns1", fmt.Sprintf("secret%d", i)))
+	}
+	return objects
+}
+
+func TestApplyManifestsInParallel(t *testing.T) {
+	objects := newSecretManifests(12)
+	// the namespace is listed at last but should be applied at first
+	objects = append(objects, spoketesting.NewUnstructured("v1", "Namespace", "", "ns1"))
+
+	work, workKey := spoketesting.NewManifestWork(0, objects...)
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	testController := newController(t, work, nil, spoketesting.NewFakeRestMapper()).
+		withKubeObject().
+		withUnstructuredObject()
+	validator := &slowValidator{delay: 10 * time.Millisecond, failedName: "secret5"}
+	controller := testController.toController()
+	controller.validator = validator
+	controller.applyConcurrency = 4
+
+	err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, workKey))
+	testingcommon.AssertError(t, err, "failed to apply secret5")
+
+	if validator.maxInflight != 4 {
+		t.Errorf("expected 4 manifests applied in parallel, but got %d", validator.maxInflight)
+	}
+	if validator.validated[0] != "ns1" {
+		t.Errorf("expected the namespace to be applied at first, but got %v", validator.validated)
+	}
+
+	var actualWork *workapiv1.ManifestWork
+	for _, action := range testController.workClient.Actions() {
+		if action.GetResource().Resource != "manifestworks" || action.GetSubresource() != "status" {
+			continue
+		}
+		actualWork = &workapiv1.ManifestWork{}
+		if err := json.Unmarshal(action.(clienttesting.PatchActionImpl).Patch, actualWork); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if actualWork == nil {
+		t.Fatal("expected to patch the work status")
+	}
+
+	manifests := actualWork.Status.ResourceStatus.Manifests
+	if len(manifests) != len(objects) {
+		t.Fatalf("expected %d manifest conditions, but got %d", len(objects), len(manifests))
+	}
+	for index, object := range objects {
+		if manifests[index].ResourceMeta.Ordinal != int32(index) || manifests[index].ResourceMeta.Name != object.GetName() {
+			t.Errorf("expected manifest condition of %s at %d, but got %v", object.GetName(), index, manifests[index].ResourceMeta)
+		}
+		expectedStatus := metav1.ConditionTrue
+		if object.GetName() == "secret5" {
+			expectedStatus = metav1.ConditionFalse
+		}
+		assertManifestCondition(t, manifests, int32(index), string(workapiv1.ManifestApplied), expectedStatus)
+	}
+	assertCondition(t, actualWork.Status.Conditions, workapiv1.WorkApplied, metav1.ConditionFalse)
+}
+
+func BenchmarkApplyManifests(b *testing.B) {
+	work, _ := spoketesting.NewManifestWork(0, newSecretManifests(100)...)
+	manifests := work.Spec.Workload.Manifests
+
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			controller := &ManifestWorkController{
+				spokeDynamicClient: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme()),
+				restMapper:         spoketesting.NewFakeRestMapper(),
+				validator:          &slowValidator{delay: time.Millisecond},
+				applyConcurrency:   concurrency,
+			}
+			for i := 0; i < b.N; i++ {
+				kubeClient := fakekube.NewSimpleClientset()
+				controller.appliers = apply.NewAppliers(controller.spokeDynamicClient, kubeClient, nil)
+				results := make([]applyResult, len(manifests))
+				controller.applyManifests(
+					context.TODO(), manifests, workapiv1.ManifestWorkSpec{}, "", events.NewInMemoryRecorder(""),
+					metav1.OwnerReference{}, nil, results)
+			}
+		})
+	}
+}
+
+func TestManageSourceAnnotations(t *testing.T) {
+	sourceAnnotations := map[string]string{
+		helper.SourceHubHashAnnotation:      "hub1",
+		helper.SourceManifestWorkAnnotation: "cluster1/work1",
+		helper.SourceAgentIDAnnotation:      "agent1",
+	}
+	removal := map[string]string{
+		helper.SourceHubHashAnnotation:      "",
+		helper.SourceManifestWorkAnnotation: "",
+		helper.SourceAgentIDAnnotation:      "",
+	}
+
+	newObject := func(annotations map[string]string) runtime.Object {
+		obj := spoketesting.NewUnstructured("v1", "Secret", "ns1", "n1")
+		obj.SetAnnotations(annotations)
+		return obj
+	}
+
+	cases := []struct {
+		name              string
+		ownedByTheWork    bool
+		sourceAnnotations map[string]string
+		existing          runtime.Object
+		expected          map[string]string
+	}{
+		{
+			name:           "stamping is disabled",
+			ownedByTheWork: true,
+			existing:       newObject(nil),
+		},
+		{
+			name:              "stamp the resource owned by the work",
+			ownedByTheWork:    true,
+			sourceAnnotations: sourceAnnotations,
+			existing:          newObject(map[string]string{helper.SourceHubHashAnnotation: "hub2"}),
+			expected:          sourceAnnotations,
+		},
+		{
+			name:              "strip the orphaned resource stamped by the work",
+			sourceAnnotations: sourceAnnotations,
+			existing:          newObject(sourceAnnotations),
+			expected:          removal,
+		},
+		{
+			name:              "keep the orphaned resource stamped by other hub",
+			sourceAnnotations: sourceAnnotations,
+			existing: newObject(map[string]string{
+				helper.SourceHubHashAnnotation:      "hub2",
+				helper.SourceManifestWorkAnnotation: "cluster1/work1",
+			}),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := manageSourceAnnotations(c.ownedByTheWork, c.sourceAnnotations, c.existing)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected annotations %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTransformManifests(t *testing.T) {
+	deployment := spoketesting.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", "test", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "c1", "image": "quay.io/test/app:v1"},
+					},
+				},
+			},
+		},
+	})
+	work, workKey := spoketesting.NewManifestWork(0, deployment)
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).
+		withKubeObject().
+		withUnstructuredObject()
+	controller.controller.transformers = transformer.NewTransformers(
+		map[string]string{"quay.io": "mirror.example.com"}, []string{"secret1"})
+
+	syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+	if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := controller.dynamicClient.Actions()
+	testingcommon.AssertActions(t, actions, "get", "create")
+	obj := actions[1].(clienttesting.CreateActionImpl).Object.(*unstructured.Unstructured)
+	podSpec, _, err := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "c1", "image": "mirror.example.com/test/app:v1"},
+		},
+		"imagePullSecrets": []interface{}{
+			map[string]interface{}{"name": "secret1"},
+		},
+	}
+	if !equality.Semantic.DeepEqual(podSpec, expected) {
+		t.Errorf("unexpected pod spec: %s", diff.ObjectDiff(expected, podSpec))
+	}
+	if actual := obj.GetAnnotations()[transformer.TransformedByAnnotation]; actual != "registry-rewrite,image-pull-secrets" {
+		t.Errorf("unexpected transformed-by annotation %q", actual)
+	}
+}
+
+func TestRestartWorkloads(t *testing.T) {
+	deployment := spoketesting.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", "test", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "c1", "image": "quay.io/test/app:v1"},
+					},
+				},
+			},
+		},
+	})
+	work, workKey := spoketesting.NewManifestWork(0, deployment)
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	work.Annotations = map[string]string{}
+	appliedWork := spoketesting.NewAppliedManifestWork("", 0, "")
+	controller := newController(t, work, appliedWork, spoketesting.NewFakeRestMapper()).
+		withKubeObject().
+		withUnstructuredObject()
+
+	sync := func(restartedAt string) []clienttesting.Action {
+		work.Annotations[helper.RestartedAtAnnotation] = restartedAt
+		controller.dynamicClient.ClearActions()
+		syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+		if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+			t.Fatal(err)
+		}
+		return controller.dynamicClient.Actions()
+	}
+	podTemplateAnnotation := func(action clienttesting.Action) string {
+		obj := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		annotations, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "annotations")
+		return annotations[transformer.RestartedAtPodTemplateAnnotation]
+	}
+
+	// the annotation is injected in the pod template of the deployment
+	actions := sync("2026-10-01T00:00:00Z")
+	testingcommon.AssertActions(t, actions, "get", "create")
+	if actual := podTemplateAnnotation(actions[1]); actual != "2026-10-01T00:00:00Z" {
+		t.Errorf("expected the restartedAt annotation injected, but got %q", actual)
+	}
+
+	// the deployment is not updated if the value is not changed
+	actions = sync("2026-10-01T00:00:00Z")
+	testingcommon.AssertActions(t, actions, "get")
+
+	// the deployment is restarted once the value is changed
+	actions = sync("2026-10-16T00:00:00Z")
+	testingcommon.AssertActions(t, actions, "get", "update")
+	if actual := podTemplateAnnotation(actions[1]); actual != "2026-10-16T00:00:00Z" {
+		t.Errorf("expected the restartedAt annotation updated, but got %q", actual)
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	work, workKey := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"))
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	appliedWork := spoketesting.NewAppliedManifestWork("", 0, "")
+	controller := newController(t, work, appliedWork, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject()
+
+	// simulate the forbidden error returned by the ResourceQuota admission plugin
+	quotaExceeded := true
+	controller.kubeClient.PrependReactor("create", "secrets", func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+		if !quotaExceeded {
+			return false, nil, nil
+		}
+		return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "test",
+			fmt.Errorf("exceeded quota: object-counts, requested: count/secrets=1, used: count/secrets=10, limited: count/secrets=10"))
+	})
+
+	syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+	// the quota exceeded error should not be returned, the work is requeued with a backoff.
+	if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Should be success with no err: %v", err)
+	}
+
+	// the work status is patched before the work annotations
+	workActions := controller.workClient.Actions()
+	testingcommon.AssertActions(t, workActions, "patch", "patch")
+	patchAction := workActions[0].(clienttesting.PatchActionImpl)
+	actualWork := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(patchAction.Patch, actualWork); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(
+		actualWork.Status.ResourceStatus.Manifests[0].Conditions, string(workapiv1.ManifestApplied))
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != QuotaExceededReason {
+		t.Fatalf("expected QuotaExceeded applied condition, but got %v", cond)
+	}
+	expectedMessage := "Failed to apply manifest: the resource quota object-counts is exceeded on count/secrets: " +
+		"secrets \"test\" is forbidden: exceeded quota: object-counts, requested: count/secrets=1, " +
+		"used: count/secrets=10, limited: count/secrets=10"
+	if cond.Message != expectedMessage {
+		t.Errorf("expected message %q, but got %q", expectedMessage, cond.Message)
+	}
+	if backoff := controller.controller.quotaBackoff.Get(workKey); backoff != QuotaRejectionInitialBackoff {
+		t.Errorf("expected backoff %v, but got %v", QuotaRejectionInitialBackoff, backoff)
+	}
+
+	// the backoff is increased on the next rejection
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Should be success with no err: %v", err)
+	}
+	if backoff := controller.controller.quotaBackoff.Get(workKey); backoff != 2*QuotaRejectionInitialBackoff {
+		t.Errorf("expected backoff %v, but got %v", 2*QuotaRejectionInitialBackoff, backoff)
+	}
+
+	// the backoff is reset once the quota is released
+	quotaExceeded = false
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Should be success with no err: %v", err)
+	}
+	if backoff := controller.controller.quotaBackoff.Get(workKey); backoff != 0 {
+		t.Errorf("expected the backoff is reset, but got %v", backoff)
+	}
+}
+
+func TestProtectedNamespaces(t *testing.T) {
+	work, workKey := spoketesting.NewManifestWork(0,
+		spoketesting.NewUnstructured("v1", "Secret", "kube-system", "test"),
+		spoketesting.NewUnstructured("v1", "Secret", "openshift-config", "test"),
+		spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"),
+		spoketesting.NewUnstructured("v1", "Namespace", "", "kube-system"),
+	)
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	appliedWork := spoketesting.NewAppliedManifestWork("", 0, "")
+	controller := newController(t, work, appliedWork, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject()
+	protectedNamespaces, err := namespaces.NewMatcher([]string{"kube-system", "openshift-.*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.controller.protectedNamespaces = protectedNamespaces
+
+	syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+	// the manifests in the protected namespaces are not retried
+	if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Should be success with no err: %v", err)
+	}
+
+	// only the manifests out of the protected namespaces and the cluster scoped manifests are applied
+	var created []string
+	for _, action := range controller.kubeClient.Actions() {
+		if action.GetVerb() == "create" {
+			obj := action.(clienttesting.CreateAction).GetObject().(metav1.Object)
+			created = append(created, fmt.Sprintf("%s/%s/%s", action.GetResource().Resource, obj.GetNamespace(), obj.GetName()))
+		}
+	}
+	sort.Strings(created)
+	if expected := []string{"namespaces//kube-system", "secrets/ns1/test"}; !reflect.DeepEqual(created, expected) {
+		t.Errorf("expected %v created, but got %v", expected, created)
+	}
+
+	workActions := controller.workClient.Actions()
+	patchAction := workActions[0].(clienttesting.PatchActionImpl)
+	actualWork := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(patchAction.Patch, actualWork); err != nil {
+		t.Fatal(err)
+	}
+	manifests := actualWork.Status.ResourceStatus.Manifests
+	for i, namespace := range []string{"kube-system", "openshift-config"} {
+		cond := meta.FindStatusCondition(manifests[i].Conditions, string(workapiv1.ManifestApplied))
+		if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ForbiddenReason {
+			t.Fatalf("expected Forbidden applied condition, but got %v", cond)
+		}
+		expectedMessage := fmt.Sprintf("Failed to apply manifest: the namespace %q is protected on the managed cluster", namespace)
+		if cond.Message != expectedMessage {
+			t.Errorf("expected message %q, but got %q", expectedMessage, cond.Message)
+		}
+	}
+	for _, i := range []int{2, 3} {
+		assertCondition(t, manifests[i].Conditions, string(workapiv1.ManifestApplied), metav1.ConditionTrue)
+	}
+	assertCondition(t, actualWork.Status.Conditions, workapiv1.WorkApplied, metav1.ConditionFalse)
+}
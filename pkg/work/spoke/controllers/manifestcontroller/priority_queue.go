@@ -0,0 +1,266 @@
+package manifestcontroller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// defaultPriorityStarvationLimit is the max number of the works of higher priorities synced in a row while a
+	// work of a lower priority is pending, the pending work is synced next once the limit is reached, so the works
+	// of the low priority are not starved.
+	defaultPriorityStarvationLimit = 10
+	// priorityFeedDepth is the max number of the works pending in the controller queue, the other works are
+	// kept in the priority queue until the controller queue is drained.
+	priorityFeedDepth = 5
+)
+
+// priorityQueue buffers the names of the manifestworks changed by the informer with one FIFO queue per priority
+// class. The names are moved into the controller queue in the order of the priority, so the works of a high
+// priority are synced first when all of the works are enqueued on a resync or a reconnection to the hub.
+//
+// With a budget, the works which are not changed since they were applied are kept in another FIFO queue, and
+// moved into the controller queue at the rate of the budget once the changed works are drained, so a relist of
+// thousands of works does not burst no-op applies against the managed cluster.
+type priorityQueue struct {
+	lock sync.Mutex
+	// queues is the FIFO queue of each priority class.
+	queues [helper.NumWorkPriorities][]string
+	// queued is the priority of each queued work.
+	queued map[string]helper.WorkPriority
+	// skipped is the number of the works of higher priorities popped while the queue of the priority is not empty.
+	skipped [helper.NumWorkPriorities]int
+	// unchanged is the FIFO queue of the works not changed since they were applied.
+	unchanged []string
+	// unchangedQueued is the set of the works in the unchanged queue.
+	unchangedQueued sets.Set[string]
+	// budget limits the rate of the unchanged works moved into the controller queue, nil means the unchanged
+	// works are queued by their priorities as the changed works.
+	budget flowcontrol.PassiveRateLimiter
+	// starvationLimit is the max number of the works of higher priorities synced in a row while a work of a lower
+	// priority is pending.
+	starvationLimit int
+	// notify wakes up the feed loop once a work is queued or the controller queue is drained.
+	notify chan struct{}
+}
+
+func newPriorityQueue(budget flowcontrol.PassiveRateLimiter) *priorityQueue {
+	return &priorityQueue{
+		queued:          map[string]helper.WorkPriority{},
+		unchangedQueued: sets.New[string](),
+		budget:          budget,
+		starvationLimit: defaultPriorityStarvationLimit,
+		notify:          make(chan struct{}, 1),
+	}
+}
+
+// signal wakes up the feed loop, it never blocks.
+func (q *priorityQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// add queues a work with its priority. A queued work keeps its position if its priority is not changed,
+// otherwise it is moved to the queue of the new priority, so a lowered or removed priority takes effect as well.
+func (q *priorityQueue) add(name string, priority helper.WorkPriority) {
+	defer q.signal()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.unchangedQueued.Has(name) {
+		q.unchanged = removeName(q.unchanged, name)
+		q.unchangedQueued.Delete(name)
+	}
+	if queuedPriority, ok := q.queued[name]; ok {
+		if queuedPriority == priority {
+			return
+		}
+		q.queues[queuedPriority] = removeName(q.queues[queuedPriority], name)
+	}
+	q.queues[priority] = append(q.queues[priority], name)
+	q.queued[name] = priority
+}
+
+// addUnchanged queues a work not changed since it was applied. It is ignored if the work is queued already.
+func (q *priorityQueue) addUnchanged(name string) {
+	defer q.signal()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, ok := q.queued[name]; ok || q.unchangedQueued.Has(name) {
+		return
+	}
+	q.unchanged = append(q.unchanged, name)
+	q.unchangedQueued.Insert(name)
+}
+
+// popUnchanged returns the first unchanged work if the budget allows.
+func (q *priorityQueue) popUnchanged() (string, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.unchanged) == 0 || !q.budget.TryAccept() {
+		return "", false
+	}
+	name := q.unchanged[0]
+	q.unchanged = q.unchanged[1:]
+	q.unchangedQueued.Delete(name)
+	return name, true
+}
+
+// pop returns the work of the highest priority, unless a work of a lower priority has been skipped
+// starvationLimit times.
+func (q *priorityQueue) pop() (string, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	picked := -1
+	for priority := helper.NumWorkPriorities - 1; priority >= 0; priority-- {
+		if len(q.queues[priority]) == 0 {
+			continue
+		}
+		if picked < 0 {
+			picked = priority
+			continue
+		}
+		if q.skipped[priority] >= q.starvationLimit {
+			picked = priority
+			break
+		}
+	}
+	if picked < 0 {
+		return "", false
+	}
+
+	for priority := 0; priority < picked; priority++ {
+		if len(q.queues[priority]) > 0 {
+			q.skipped[priority]++
+		}
+	}
+	q.skipped[picked] = 0
+
+	name := q.queues[picked][0]
+	q.queues[picked] = q.queues[picked][1:]
+	delete(q.queued, name)
+	return name, true
+}
+
+func (q *priorityQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.queued) + len(q.unchanged)
+}
+
+// feed moves the works into the controller queue until the controller queue has priorityFeedDepth works pending.
+// The unchanged works are moved only after the changed works are drained. It returns the delay after which the
+// feed is retried if unchanged works are held back by the budget, or zero otherwise.
+func (q *priorityQueue) feed(queue workqueue.Interface) time.Duration {
+	for queue.Len() < priorityFeedDepth {
+		name, ok := q.pop()
+		if !ok {
+			name, ok = q.popUnchanged()
+		}
+		if !ok {
+			return q.budgetDelay()
+		}
+		queue.Add(name)
+	}
+	return 0
+}
+
+// budgetDelay returns the interval of the budget tokens if there are unchanged works pending, or zero otherwise.
+func (q *priorityQueue) budgetDelay() time.Duration {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.unchanged) == 0 || q.budget == nil || q.budget.QPS() <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / float64(q.budget.QPS()))
+}
+
+// run feeds the controller queue each time a work is queued or the controller queue is drained, see signal, until
+// the context is done.
+func (q *priorityQueue) run(ctx context.Context, queue workqueue.Interface) {
+	for {
+		var timer *time.Timer
+		var retry <-chan time.Time
+		if delay := q.feed(queue); delay > 0 {
+			timer = time.NewTimer(delay)
+			retry = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-q.notify:
+		case <-retry:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// eventHandler queues the works changed by the informer with their priorities.
+func (q *priorityQueue) eventHandler() cache.ResourceEventHandler {
+	enqueue := func(obj interface{}, unchanged bool) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return
+		}
+		if q.budget != nil && unchanged {
+			q.addUnchanged(accessor.GetName())
+			return
+		}
+		q.add(accessor.GetName(), helper.GetWorkPriority(accessor))
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { enqueue(obj, isUnchanged(nil, obj)) },
+		UpdateFunc: func(oldObj, newObj interface{}) { enqueue(newObj, isUnchanged(oldObj, newObj)) },
+		DeleteFunc: func(obj interface{}) { enqueue(obj, false) },
+	}
+}
+
+// isUnchanged returns true if the work is applied at its current generation and the event does not change it,
+// which is the case of the works added when the agent restarts, or updated on a relist or a resync of the
+// informer. The applied condition is persisted in the status of the work on the hub, so it is the last observed
+// state of the work across the restarts of the agent.
+func isUnchanged(oldObj, obj interface{}) bool {
+	work, ok := obj.(*workapiv1.ManifestWork)
+	if !ok || !work.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if oldWork, ok := oldObj.(*workapiv1.ManifestWork); ok && oldWork.ResourceVersion != work.ResourceVersion {
+		return false
+	}
+	cond := meta.FindStatusCondition(work.Status.Conditions, workapiv1.WorkApplied)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == work.Generation
+}
+
+func removeName(names []string, name string) []string {
+	for i := range names {
+		if names[i] == name {
+			return append(names[:i], names[i+1:]...)
+		}
+	}
+	return names
+}
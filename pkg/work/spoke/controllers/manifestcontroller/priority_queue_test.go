@@ -0,0 +1,260 @@
+package manifestcontroller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+	testingclock "k8s.io/utils/clock/testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+func popAll(q *priorityQueue) []string {
+	names := []string{}
+	for {
+		name, ok := q.pop()
+		if !ok {
+			return names
+		}
+		names = append(names, name)
+	}
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := newPriorityQueue(nil)
+	q.add("app1", helper.WorkPriorityNormal)
+	q.add("cleanup", helper.WorkPriorityLow)
+	q.add("cni", helper.WorkPriorityCritical)
+	q.add("app2", helper.WorkPriorityNormal)
+	q.add("monitoring", helper.WorkPriorityHigh)
+	q.add("app1", helper.WorkPriorityNormal)
+
+	expected := []string{"cni", "monitoring", "app1", "app2", "cleanup"}
+	if actual := popAll(q); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+	if q.len() != 0 {
+		t.Errorf("expected the queue drained, but got %d works", q.len())
+	}
+}
+
+func TestPriorityQueueChangePriority(t *testing.T) {
+	q := newPriorityQueue(nil)
+	q.add("app1", helper.WorkPriorityNormal)
+	q.add("app2", helper.WorkPriorityNormal)
+	q.add("app3", helper.WorkPriorityNormal)
+	q.add("app2", helper.WorkPriorityCritical)
+	// the priority of a queued work is lowered
+	q.add("app3", helper.WorkPriorityCritical)
+	q.add("app3", helper.WorkPriorityLow)
+
+	expected := []string{"app2", "app1", "app3"}
+	if actual := popAll(q); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+
+	// the priority of a queued work is removed
+	handler := q.eventHandler()
+	work := &workapiv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{
+		Name:        "app4",
+		Namespace:   "cluster1",
+		Annotations: map[string]string{helper.PriorityAnnotation: "critical"},
+	}}
+	handler.OnAdd(work, false)
+	handler.OnAdd(&workapiv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Name: "app5", Namespace: "cluster1"}}, false)
+	updated := work.DeepCopy()
+	updated.Annotations = nil
+	handler.OnUpdate(work, updated)
+
+	expected = []string{"app5", "app4"}
+	if actual := popAll(q); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestPriorityQueueStarvation(t *testing.T) {
+	q := newPriorityQueue(nil)
+	q.starvationLimit = 2
+	for _, name := range []string{"c1", "c2", "c3", "c4", "c5"} {
+		q.add(name, helper.WorkPriorityCritical)
+	}
+	q.add("l1", helper.WorkPriorityLow)
+	q.add("l2", helper.WorkPriorityLow)
+
+	expected := []string{"c1", "c2", "l1", "c3", "c4", "l2", "c5"}
+	if actual := popAll(q); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestPriorityQueueFeed(t *testing.T) {
+	q := newPriorityQueue(nil)
+	handler := q.eventHandler()
+	newWork := func(name, priority string) *workapiv1.ManifestWork {
+		work := &workapiv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cluster1"}}
+		if len(priority) > 0 {
+			work.Annotations = map[string]string{helper.PriorityAnnotation: priority}
+		}
+		return work
+	}
+
+	// the works are enqueued in an arbitrary order on a resync
+	for i := 0; i < 100; i++ {
+		handler.OnUpdate(nil, newWork(fmt.Sprintf("app%d", i), ""))
+	}
+	handler.OnAdd(newWork("cni", "critical"), false)
+	handler.OnAdd(newWork("cleanup", "low"), false)
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "cluster1/gone", Obj: newWork("gone", "invalid")})
+
+	queue := workqueue.New()
+	defer queue.ShutDown()
+	q.feed(queue)
+	if queue.Len() != priorityFeedDepth {
+		t.Errorf("expected %d works fed into the controller queue, but got %d", priorityFeedDepth, queue.Len())
+	}
+
+	// the works are synced in the order of the priorities, and the low priority work is not starved
+	synced := []string{}
+	for queue.Len() > 0 {
+		key, _ := queue.Get()
+		synced = append(synced, key.(string))
+		queue.Done(key)
+		q.feed(queue)
+	}
+	if len(synced) != 103 {
+		t.Errorf("expected all of the works synced, but got %d", len(synced))
+	}
+	if synced[0] != "cni" {
+		t.Errorf("expected the critical work synced first, but got %v", synced[0])
+	}
+	if synced[1] != "app0" || synced[len(synced)-1] != "gone" {
+		t.Errorf("expected the normal works synced in order, but got %v", synced)
+	}
+	if synced[defaultPriorityStarvationLimit] != "cleanup" {
+		t.Errorf("expected the low priority work synced after %d works, but got %v", defaultPriorityStarvationLimit, synced)
+	}
+}
+
+func TestPriorityQueueResyncBudget(t *testing.T) {
+	budget := 50
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	q := newPriorityQueue(flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(float32(budget), budget, fakeClock))
+	handler := q.eventHandler()
+	appliedWork := func(name, resourceVersion string) *workapiv1.ManifestWork {
+		return &workapiv1.ManifestWork{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cluster1", Generation: 1, ResourceVersion: resourceVersion},
+			Status: workapiv1.ManifestWorkStatus{Conditions: []metav1.Condition{
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+			}},
+		}
+	}
+
+	// the 1000 works are relisted from the hub without any change
+	for i := 0; i < 1000; i++ {
+		work := appliedWork(fmt.Sprintf("app%d", i), "1")
+		handler.OnUpdate(work, work)
+	}
+	// the spec of a work is changed, and a work is not applied at its generation yet
+	changed := appliedWork("changed", "2")
+	changed.Generation = 2
+	handler.OnUpdate(appliedWork("changed", "1"), changed)
+	handler.OnAdd(&workapiv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "cluster1"}}, true)
+	// an unchanged work is updated afterwards, and is moved before the unchanged works
+	handler.OnUpdate(appliedWork("app999", "1"), appliedWork("app999", "3"))
+
+	queue := workqueue.New()
+	defer queue.ShutDown()
+	// each synced work applies its manifests on the managed cluster, the syncs are counted per second
+	synced := []string{}
+	for second := 0; second < 25 && q.len() > 0; second++ {
+		syncs := 0
+		for q.feed(queue); queue.Len() > 0; q.feed(queue) {
+			key, _ := queue.Get()
+			synced = append(synced, key.(string))
+			queue.Done(key)
+			syncs++
+		}
+		if second == 0 {
+			syncs -= 3
+		}
+		if syncs > budget {
+			t.Fatalf("expected at most %d unchanged works synced in second %d, but got %d", budget, second, syncs)
+		}
+		fakeClock.Step(time.Second)
+	}
+
+	if len(synced) != 1002 {
+		t.Errorf("expected all of the works synced once, but got %d", len(synced))
+	}
+	if !reflect.DeepEqual(synced[:4], []string{"changed", "new", "app999", "app0"}) {
+		t.Errorf("expected the changed works synced before the unchanged works, but got %v", synced[:4])
+	}
+}
+
+func TestPriorityQueueRun(t *testing.T) {
+	budget := 50
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	q := newPriorityQueue(flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(float32(budget), 1, fakeClock))
+	queue := workqueue.New()
+	defer queue.ShutDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		q.run(ctx, queue)
+	}()
+
+	get := func() string {
+		got := make(chan string)
+		go func() {
+			key, _ := queue.Get()
+			queue.Done(key)
+			got <- key.(string)
+		}()
+		select {
+		case key := <-got:
+			return key
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatal("expected a work fed into the controller queue")
+			return ""
+		}
+	}
+
+	// the works are fed into the controller queue once they are queued
+	q.add("app1", helper.WorkPriorityNormal)
+	if key := get(); key != "app1" {
+		t.Errorf("expected app1 fed, but got %s", key)
+	}
+
+	// the unchanged works held back by the budget are fed once the budget is refilled
+	q.addUnchanged("app2")
+	q.addUnchanged("app3")
+	if key := get(); key != "app2" {
+		t.Errorf("expected app2 fed, but got %s", key)
+	}
+	if delay := q.feed(queue); delay != time.Second/time.Duration(budget) {
+		t.Errorf("expected the feed retried after %v, but got %v", time.Second/time.Duration(budget), delay)
+	}
+	fakeClock.Step(time.Second)
+	if key := get(); key != "app3" {
+		t.Errorf("expected app3 fed, but got %s", key)
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected the feed loop stopped")
+	}
+}
@@ -0,0 +1,17 @@
+package manifestcontroller
+
+import "fmt"
+
+// ForbiddenReason is the reason of the applied condition of a manifest when the namespace of the manifest is
+// protected on the managed cluster by the flag --protected-namespaces of the work agent, regardless of the
+// permission of the executor.
+const ForbiddenReason = "Forbidden"
+
+// protectedNamespaceError indicates a manifest is rejected since its namespace is protected.
+type protectedNamespaceError struct {
+	namespace string
+}
+
+func (e *protectedNamespaceError) Error() string {
+	return fmt.Sprintf("the namespace %q is protected on the managed cluster", e.namespace)
+}
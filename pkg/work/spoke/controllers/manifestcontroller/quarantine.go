@@ -0,0 +1,157 @@
+package manifestcontroller
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
+)
+
+const (
+	// WorkQuarantined is the condition type of a manifestwork which crashed the apply loop of the agent
+	// repeatedly. A quarantined manifestwork is not applied until its spec is changed, while it is still
+	// cleaned up once it is deleted.
+	// TODO move this to the api repo
+	WorkQuarantined = "Quarantined"
+
+	// ApplyPanickedReason is the reason of the quarantined condition.
+	ApplyPanickedReason = "ApplyPanicked"
+)
+
+var (
+	// QuarantineThreshold is the number of the consecutive panics of a manifestwork generation after which
+	// the manifestwork is quarantined.
+	QuarantineThreshold = 3
+
+	// QuarantinedWorks is the number of the manifestworks quarantined by the agent.
+	QuarantinedWorks = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      "work_agent",
+			Name:           "quarantined_works_total",
+			Help:           "Number of the manifestworks quarantined since they crashed the apply loop repeatedly.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of the manifestwork controller.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(QuarantinedWorks)
+	})
+}
+
+// panicError is a panic recovered when a manifestwork is applied, the stack is captured where the panic
+// is recovered.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func newPanicError(value interface{}) *panicError {
+	return &panicError{value: value, stack: debug.Stack()}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// panicTracker counts the consecutive panics of the manifestworks, the count is reset once the generation of
+// a manifestwork is changed. The zero value is ready to use.
+type panicTracker struct {
+	lock   sync.Mutex
+	panics map[string]panicRecord
+}
+
+type panicRecord struct {
+	generation int64
+	count      int
+}
+
+// record records a panic of the generation of a manifestwork, and returns the number of the panics of the
+// generation.
+func (p *panicTracker) record(name string, generation int64) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.panics == nil {
+		p.panics = map[string]panicRecord{}
+	}
+	record := p.panics[name]
+	if record.generation != generation {
+		record = panicRecord{generation: generation}
+	}
+	record.count++
+	p.panics[name] = record
+	return record.count
+}
+
+// forget resets the panics of a manifestwork.
+func (p *panicTracker) forget(name string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.panics, name)
+}
+
+// isQuarantined returns true if the current generation of the manifestwork is quarantined.
+func isQuarantined(manifestWork *workapiv1.ManifestWork) bool {
+	cond := meta.FindStatusCondition(manifestWork.Status.Conditions, WorkQuarantined)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == manifestWork.Generation
+}
+
+// handlePanic records a panic of a manifestwork, the stack is logged only for the first panic of a generation.
+// The manifestwork is quarantined once it panics QuarantineThreshold times, and nil is returned so the
+// manifestwork is not requeued, otherwise the panic is returned as an error to retry the manifestwork.
+func (m *ManifestWorkController) handlePanic(
+	ctx context.Context, controllerContext factory.SyncContext, manifestWorkName string, panicErr *panicError) error {
+	manifestWork, err := m.manifestWorkLister.Get(manifestWorkName)
+	if err != nil {
+		klog.Errorf("Observed a panic when applying manifestwork %s: %v\n%s", manifestWorkName, panicErr.value, panicErr.stack)
+		return panicErr
+	}
+
+	count := m.panics.record(manifestWorkName, manifestWork.Generation)
+	if count == 1 {
+		klog.Errorf("Observed a panic when applying manifestwork %s: %v\n%s", manifestWorkName, panicErr.value, panicErr.stack)
+	} else {
+		klog.Errorf("Observed a panic again (%d/%d) when applying manifestwork %s: %v",
+			count, QuarantineThreshold, manifestWorkName, panicErr.value)
+	}
+	if count < QuarantineThreshold {
+		return panicErr
+	}
+
+	newManifestWork := manifestWork.DeepCopy()
+	meta.SetStatusCondition(&newManifestWork.Status.Conditions, metav1.Condition{
+		Type:               WorkQuarantined,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: manifestWork.Generation,
+		Reason:             ApplyPanickedReason,
+		Message: fmt.Sprintf("The manifestwork is not applied until its spec is changed since it panicked %d times: %v",
+			count, panicErr.value),
+	})
+	if _, err := m.manifestWorkPatcher.PatchStatus(
+		ctx, newManifestWork, newManifestWork.Status, manifestWork.Status); err != nil {
+		return fmt.Errorf("failed to quarantine work %s with err %w", manifestWorkName, err)
+	}
+
+	QuarantinedWorks.Inc()
+	controllerContext.Recorder().Warningf("ManifestWorkQuarantined",
+		"manifestwork %s is quarantined since it panicked %d times: %v", manifestWorkName, count, panicErr.value)
+	m.reporter.Reportf(ctx, agentevents.CategoryWorkApplyFailed,
+		"Manifestwork %s is quarantined since it panicked %d times: %v", manifestWorkName, count, panicErr.value)
+	return nil
+}
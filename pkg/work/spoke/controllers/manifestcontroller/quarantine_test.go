@@ -0,0 +1,153 @@
+package manifestcontroller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestPanicTracker(t *testing.T) {
+	tracker := panicTracker{}
+	if count := tracker.record("work", 1); count != 1 {
+		t.Errorf("expected 1 panic, but got %d", count)
+	}
+	if count := tracker.record("work", 1); count != 2 {
+		t.Errorf("expected 2 panics, but got %d", count)
+	}
+	if count := tracker.record("other", 1); count != 1 {
+		t.Errorf("expected the panics counted by work, but got %d", count)
+	}
+	if count := tracker.record("work", 2); count != 1 {
+		t.Errorf("expected the panics reset on the new generation, but got %d", count)
+	}
+	tracker.forget("work")
+	if count := tracker.record("work", 2); count != 1 {
+		t.Errorf("expected the panics reset once forgotten, but got %d", count)
+	}
+}
+
+func TestQuarantinePanickingWork(t *testing.T) {
+	poisonWork, poisonKey := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "Secret", "ns1", "poison"))
+	poisonWork.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	work, workKey := spoketesting.NewManifestWork(1, spoketesting.NewUnstructured("v1", "Secret", "ns1", "test"))
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+
+	appliedWork := spoketesting.NewAppliedManifestWork("", 0, "")
+	controller := newController(t, poisonWork, appliedWork, spoketesting.NewFakeRestMapper()).
+		withKubeObject().withUnstructuredObject()
+	if err := controller.workClient.Tracker().Add(work); err != nil {
+		t.Fatal(err)
+	}
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(
+		controller.workClient, 5*time.Minute, workinformers.WithNamespace("cluster1"))
+	workStore := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore()
+	for _, obj := range []runtime.Object{poisonWork, work} {
+		if err := workStore.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	controller.controller.manifestWorkLister = workInformerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks("cluster1")
+
+	// the applier panics on the manifest of the poison work
+	controller.kubeClient.PrependReactor("create", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.(clienttesting.CreateAction).GetObject().(*corev1.Secret).Name == "poison" {
+			panic("poisoned manifest")
+		}
+		return false, nil, nil
+	})
+
+	ctrl := controller.toController()
+	RegisterMetrics()
+	quarantined, err := testutil.GetCounterMetricValue(QuarantinedWorks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the panic is returned as an error to retry the work until the threshold is reached
+	for i := 1; i < QuarantineThreshold; i++ {
+		controller.workClient.ClearActions()
+		err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, poisonKey))
+		var panicErr *panicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("expected the panic returned on sync %d, but got %v", i, err)
+		}
+		for _, action := range controller.workClient.Actions() {
+			if action.GetVerb() == "patch" {
+				t.Errorf("expected the work status not updated on the panic, but got %v", action)
+			}
+		}
+	}
+
+	// the other works continue to sync
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, workKey)); err != nil {
+		t.Errorf("expected the other work synced, but got %v", err)
+	}
+	syncedWork, err := controller.workClient.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), workKey, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(syncedWork.Status.Conditions, workapiv1.WorkApplied) {
+		t.Errorf("expected the other work applied, but got %v", syncedWork.Status.Conditions)
+	}
+
+	// the work is quarantined once the threshold is reached
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, poisonKey)); err != nil {
+		t.Errorf("expected the work quarantined without error, but got %v", err)
+	}
+	quarantinedWork, err := controller.workClient.WorkV1().ManifestWorks("cluster1").Get(
+		context.TODO(), poisonKey, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isQuarantined(quarantinedWork) {
+		t.Fatalf("expected the work quarantined, but got %v", quarantinedWork.Status.Conditions)
+	}
+	if cond := meta.FindStatusCondition(quarantinedWork.Status.Conditions, WorkQuarantined); cond.Reason != ApplyPanickedReason {
+		t.Errorf("expected reason %q, but got %q", ApplyPanickedReason, cond.Reason)
+	}
+	if actual, _ := testutil.GetCounterMetricValue(QuarantinedWorks); actual != quarantined+1 {
+		t.Errorf("expected the quarantined works counted, but got %v", actual)
+	}
+
+	// the quarantined work is skipped
+	if err := workStore.Update(quarantinedWork); err != nil {
+		t.Fatal(err)
+	}
+	controller.workClient.ClearActions()
+	controller.kubeClient.ClearActions()
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, poisonKey)); err != nil {
+		t.Errorf("expected the quarantined work skipped, but got %v", err)
+	}
+	testingcommon.AssertNoActions(t, controller.workClient.Actions())
+	testingcommon.AssertNoActions(t, controller.kubeClient.Actions())
+
+	// the work is applied again once its spec is changed
+	changedWork := quarantinedWork.DeepCopy()
+	changedWork.Generation++
+	if err := workStore.Update(changedWork); err != nil {
+		t.Fatal(err)
+	}
+	err = ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, poisonKey))
+	var panicErr *panicError
+	if !errors.As(err, &panicErr) {
+		t.Errorf("expected the changed work applied and panic again, but got %v", err)
+	}
+	if actual, _ := testutil.GetCounterMetricValue(QuarantinedWorks); actual != quarantined+1 {
+		t.Errorf("expected the changed work not quarantined on the first panic, but got %v", actual)
+	}
+}
@@ -0,0 +1,90 @@
+package manifestcontroller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// ScopeMismatchReason is the reason of the applied condition of a manifest when the presence of the namespace
+// in the manifest contradicts the current scope of its resource on the managed cluster, e.g. a namespace is set
+// on a manifest of a CRD which becomes cluster scoped.
+const ScopeMismatchReason = "ScopeMismatch"
+
+// scopeMismatchError indicates the namespace of a manifest contradicts the scope of its resource.
+type scopeMismatchError struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	scope     meta.RESTScopeName
+}
+
+func (e *scopeMismatchError) Error() string {
+	if e.scope == meta.RESTScopeNameRoot {
+		return fmt.Sprintf("the resource %s is cluster scoped on the managed cluster, but the manifest is in namespace %q",
+			e.gvk.String(), e.namespace)
+	}
+	return fmt.Sprintf("the resource %s is namespace scoped on the managed cluster, but the manifest has no namespace",
+		e.gvk.String())
+}
+
+// checkScope resolves the scope of the manifest from the restmapper on each apply, and returns a
+// scopeMismatchError if the namespace of the manifest contradicts the scope.
+func checkScope(restMapper meta.RESTMapper, gvk schema.GroupVersionKind, namespace string) error {
+	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+
+	scope := mapping.Scope.Name()
+	switch {
+	case scope == meta.RESTScopeNameRoot && len(namespace) > 0:
+		return &scopeMismatchError{gvk: gvk, namespace: namespace, scope: scope}
+	case scope == meta.RESTScopeNameNamespace && len(namespace) == 0:
+		return &scopeMismatchError{gvk: gvk, scope: scope}
+	}
+	return nil
+}
+
+// restMapperResetHandler resets the restmapper once the scope of a CRD is changed or a CRD is deleted, so the
+// stale mappings cached by the restmapper are not used to apply the manifests. It returns nil if the restmapper
+// cannot be reset.
+func restMapperResetHandler(restMapper meta.RESTMapper) cache.ResourceEventHandler {
+	resettable, ok := restMapper.(meta.ResettableRESTMapper)
+	if !ok {
+		return nil
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldScope, oldName := crdScope(oldObj)
+			newScope, _ := crdScope(newObj)
+			if oldScope == newScope {
+				return
+			}
+			klog.Infof("The scope of CRD %q is changed from %q to %q, reset the restmapper", oldName, oldScope, newScope)
+			resettable.Reset()
+		},
+		DeleteFunc: func(obj interface{}) {
+			_, name := crdScope(obj)
+			klog.V(4).Infof("CRD %q is deleted, reset the restmapper", name)
+			resettable.Reset()
+		},
+	}
+}
+
+// crdScope returns the scope and the name of a CRD from the dynamic informer.
+func crdScope(obj interface{}) (string, string) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	crd, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "", ""
+	}
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+	return scope, crd.GetName()
+}
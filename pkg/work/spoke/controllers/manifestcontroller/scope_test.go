@@ -0,0 +1,153 @@
+package manifestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+var guestbookGVK = schema.GroupVersionKind{Group: "my.domain", Version: "v1", Kind: "Guestbook"}
+
+// newGuestbookRESTMapper returns a resettable restmapper which discovers the guestbooks with the scope of the
+// fake CRD when it is built.
+func newGuestbookRESTMapper(t *testing.T, namespaced *bool) meta.ResettableRESTMapper {
+	mapper, err := helper.NewResettableRESTMapper(func() (meta.RESTMapper, error) {
+		return restmapper.NewDiscoveryRESTMapper([]*restmapper.APIGroupResources{
+			{
+				Group: metav1.APIGroup{
+					Name:             "my.domain",
+					Versions:         []metav1.GroupVersionForDiscovery{{Version: "v1", GroupVersion: "my.domain/v1"}},
+					PreferredVersion: metav1.GroupVersionForDiscovery{Version: "v1", GroupVersion: "my.domain/v1"},
+				},
+				VersionedResources: map[string][]metav1.APIResource{
+					"v1": {{Name: "guestbooks", Group: "my.domain", Namespaced: *namespaced, Kind: "Guestbook"}},
+				},
+			},
+		}), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mapper
+}
+
+func newGuestbookCRD(scope string) *unstructured.Unstructured {
+	crd := spoketesting.NewUnstructured("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "guestbooks.my.domain")
+	if err := unstructured.SetNestedField(crd.Object, scope, "spec", "scope"); err != nil {
+		panic(err)
+	}
+	return crd
+}
+
+func TestCheckScope(t *testing.T) {
+	namespaced := true
+	mapper := newGuestbookRESTMapper(t, &namespaced)
+
+	if err := checkScope(mapper, guestbookGVK, "ns1"); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+	var scopeErr *scopeMismatchError
+	if err := checkScope(mapper, guestbookGVK, ""); !errors.As(err, &scopeErr) {
+		t.Errorf("expected scope mismatch error, but got %v", err)
+	}
+
+	// the scope is cached until the restmapper is reset
+	namespaced = false
+	if err := checkScope(mapper, guestbookGVK, "ns1"); err != nil {
+		t.Errorf("expected no error with the cached scope, but got %v", err)
+	}
+	mapper.Reset()
+	if err := checkScope(mapper, guestbookGVK, "ns1"); !errors.As(err, &scopeErr) {
+		t.Errorf("expected scope mismatch error, but got %v", err)
+	}
+	if err := checkScope(mapper, guestbookGVK, ""); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+}
+
+func TestRESTMapperResetHandler(t *testing.T) {
+	namespaced := true
+	mapper := newGuestbookRESTMapper(t, &namespaced)
+	handler := restMapperResetHandler(mapper)
+	assertScope := func(expected meta.RESTScopeName) {
+		mapping, err := mapper.RESTMapping(guestbookGVK.GroupKind(), guestbookGVK.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mapping.Scope.Name() != expected {
+			t.Errorf("expected scope %q, but got %q", expected, mapping.Scope.Name())
+		}
+	}
+
+	// the cache is not invalidated if the scope is not changed
+	namespaced = false
+	handler.OnUpdate(newGuestbookCRD("Namespaced"), newGuestbookCRD("Namespaced"))
+	assertScope(meta.RESTScopeNameNamespace)
+
+	// the cache is invalidated once the scope is flipped
+	handler.OnUpdate(newGuestbookCRD("Namespaced"), newGuestbookCRD("Cluster"))
+	assertScope(meta.RESTScopeNameRoot)
+
+	// the cache is invalidated once the crd is deleted
+	namespaced = true
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "guestbooks.my.domain", Obj: newGuestbookCRD("Cluster")})
+	assertScope(meta.RESTScopeNameNamespace)
+}
+
+func TestScopeMismatch(t *testing.T) {
+	work, workKey := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("my.domain/v1", "Guestbook", "ns1", "test"))
+	work.Finalizers = []string{controllers.ManifestWorkFinalizer}
+
+	// the CRD becomes cluster scoped
+	namespaced := false
+	mapper := newGuestbookRESTMapper(t, &namespaced)
+	controller := newController(t, work, nil, mapper).withKubeObject().withUnstructuredObject()
+
+	syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+	if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Should be success with no err: %v", err)
+	}
+
+	// the manifest is not applied into the namespace
+	for _, action := range controller.dynamicClient.Actions() {
+		if action.GetVerb() == "create" || action.GetVerb() == "update" {
+			t.Errorf("expected the manifest not applied, but got %v", action)
+		}
+	}
+
+	workActions := controller.workClient.Actions()
+	patchAction, ok := workActions[len(workActions)-1].(clienttesting.PatchActionImpl)
+	if !ok {
+		t.Fatalf("Expected to get patch action")
+	}
+	actualWork := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(patchAction.Patch, actualWork); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(
+		actualWork.Status.ResourceStatus.Manifests[0].Conditions, string(workapiv1.ManifestApplied))
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ScopeMismatchReason {
+		t.Fatalf("expected ScopeMismatch applied condition, but got %v", cond)
+	}
+	expectedMessage := "Failed to apply manifest: the resource my.domain/v1, Kind=Guestbook is cluster scoped " +
+		"on the managed cluster, but the manifest is in namespace \"ns1\""
+	if cond.Message != expectedMessage {
+		t.Errorf("expected message %q, but got %q", expectedMessage, cond.Message)
+	}
+}
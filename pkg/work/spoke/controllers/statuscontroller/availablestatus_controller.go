@@ -3,6 +3,8 @@ package statuscontroller
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -18,6 +20,7 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
 	workinformer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
@@ -28,6 +31,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers"
 	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
 )
 
 const statusFeedbackConditionType = "StatusFeedbackSynced"
@@ -41,6 +45,15 @@ type AvailableStatusController struct {
 	manifestWorkLister worklister.ManifestWorkNamespaceLister
 	spokeDynamicClient dynamic.Interface
 	statusReader       *statusfeedback.StatusReader
+
+	// feedbackCoalesceInterval is the minimum interval between the status patches of a manifestwork if only the
+	// status feedback values are changed, so the rapid successive changes are sent to the hub together. The
+	// feedback values are not coalesced if it is 0.
+	feedbackCoalesceInterval time.Duration
+	clock                    clock.PassiveClock
+	lock                     sync.Mutex
+	// lastPatchTime is the time of the last status patch of each manifestwork.
+	lastPatchTime map[string]time.Time
 }
 
 // NewAvailableStatusController returns a AvailableStatusController
@@ -56,9 +69,12 @@ func NewAvailableStatusController(
 		patcher: patcher.NewPatcher[
 			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
 			manifestWorkClient),
-		manifestWorkLister: manifestWorkLister,
-		spokeDynamicClient: spokeDynamicClient,
-		statusReader:       statusfeedback.NewStatusReader(),
+		manifestWorkLister:       manifestWorkLister,
+		spokeDynamicClient:       spokeDynamicClient,
+		statusReader:             statusfeedback.NewStatusReader(),
+		feedbackCoalesceInterval: syncInterval,
+		clock:                    clock.RealClock{},
+		lastPatchTime:            map[string]time.Time{},
 	}
 
 	return factory.New().
@@ -76,16 +92,22 @@ func (c *AvailableStatusController) sync(ctx context.Context, controllerContext
 		manifestWork, err := c.manifestWorkLister.Get(manifestWorkName)
 		if errors.IsNotFound(err) {
 			// work not found, could have been deleted, do nothing.
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			delete(c.lastPatchTime, manifestWorkName)
 			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("unable to fetch manifestwork %q: %w", manifestWorkName, err)
 		}
 
-		err = c.syncManifestWork(ctx, manifestWork)
+		requeueAfter, err := c.syncManifestWork(ctx, manifestWork)
 		if err != nil {
 			return fmt.Errorf("unable to sync manifestwork %q: %w", manifestWork.Name, err)
 		}
+		if requeueAfter > 0 {
+			controllerContext.Queue().AddAfter(manifestWorkName, requeueAfter)
+		}
 		return nil
 	}
 
@@ -103,24 +125,30 @@ func (c *AvailableStatusController) sync(ctx context.Context, controllerContext
 	return nil
 }
 
-func (c *AvailableStatusController) syncManifestWork(ctx context.Context, originalManifestWork *workapiv1.ManifestWork) error {
+// syncManifestWork updates the status of the manifestwork. It returns the time to requeue the manifestwork if
+// the change of the status feedback values is coalesced.
+func (c *AvailableStatusController) syncManifestWork(ctx context.Context,
+	originalManifestWork *workapiv1.ManifestWork) (time.Duration, error) {
 	klog.V(4).Infof("Reconciling ManifestWork %q", originalManifestWork.Name)
 	manifestWork := originalManifestWork.DeepCopy()
 
 	// do nothing when finalizer is not added.
 	if !helper.HasFinalizer(manifestWork.Finalizers, controllers.ManifestWorkFinalizer) {
-		return nil
+		return 0, nil
 	}
 
 	// wait until work has the applied condition.
 	if cond := meta.FindStatusCondition(manifestWork.Status.Conditions, workapiv1.WorkApplied); cond == nil {
-		return nil
+		return 0, nil
 	}
 
 	// handle status condition of manifests
 	// TODO revist this controller since this might bring races when user change the manifests in spec.
 	for index, manifest := range manifestWork.Status.ResourceStatus.Manifests {
-		obj, availableStatusCondition, err := buildAvailableStatusCondition(manifest.ResourceMeta, c.spokeDynamicClient)
+		// the content hash named ConfigMaps and Secrets are applied with the hashed names
+		appliedMeta := manifest.ResourceMeta
+		appliedMeta.Name = transformer.AppliedName(manifestWork.Spec.Workload.Manifests, manifest.ResourceMeta)
+		obj, availableStatusCondition, err := buildAvailableStatusCondition(appliedMeta, c.spokeDynamicClient)
 		meta.SetStatusCondition(&manifestWork.Status.ResourceStatus.Manifests[index].Conditions, availableStatusCondition)
 		if err != nil {
 			// skip getting status values if resource is not available.
@@ -140,12 +168,54 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 	// no work if the status of manifestwork does not change
 	if equality.Semantic.DeepEqual(originalManifestWork.Status.ResourceStatus, manifestWork.Status.ResourceStatus) &&
 		equality.Semantic.DeepEqual(originalManifestWork.Status.Conditions, manifestWork.Status.Conditions) {
-		return nil
+		return 0, nil
 	}
 
-	// update status of manifestwork. if this conflicts, try again later
-	_, err := c.patcher.PatchStatus(ctx, manifestWork, manifestWork.Status, originalManifestWork.Status)
-	return err
+	if remaining := c.coalesceFeedback(originalManifestWork, manifestWork); remaining > 0 {
+		klog.V(4).Infof("Coalesce the status feedback of ManifestWork %q for %v", manifestWork.Name, remaining)
+		return remaining, nil
+	}
+
+	// update status of manifestwork with the changed entries only. if this conflicts, try again later
+	_, err := c.patcher.PatchStatusWithJSONPatch(ctx, manifestWork, manifestWork.Status, originalManifestWork.Status)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.feedbackCoalesceInterval > 0 {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.lastPatchTime[manifestWork.Name] = c.clock.Now()
+	}
+	return 0, nil
+}
+
+// coalesceFeedback returns the remaining time before the status of the manifestwork is patched again, if only the
+// status feedback values are changed since the last patch within the feedbackCoalesceInterval. The changes of the
+// conditions are patched immediately.
+func (c *AvailableStatusController) coalesceFeedback(originalManifestWork, manifestWork *workapiv1.ManifestWork) time.Duration {
+	if c.feedbackCoalesceInterval <= 0 {
+		return 0
+	}
+
+	withoutFeedback := manifestWork.Status.DeepCopy()
+	for i := range withoutFeedback.ResourceStatus.Manifests {
+		if i < len(originalManifestWork.Status.ResourceStatus.Manifests) {
+			withoutFeedback.ResourceStatus.Manifests[i].StatusFeedbacks =
+				originalManifestWork.Status.ResourceStatus.Manifests[i].StatusFeedbacks
+		}
+	}
+	if !equality.Semantic.DeepEqual(originalManifestWork.Status, *withoutFeedback) {
+		return 0
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	lastPatchTime, ok := c.lastPatchTime[manifestWork.Name]
+	if !ok {
+		return 0
+	}
+	return c.feedbackCoalesceInterval - c.clock.Since(lastPatchTime)
 }
 
 // aggregateManifestConditions aggregates status conditions of manifests and returns a status
@@ -231,6 +301,10 @@ func (c *AvailableStatusController) getFeedbackValues(
 		}
 	}
 
+	// the values are sorted by the names, so the values at the same index are compared when the status is patched,
+	// even if some values are missing.
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+
 	err := utilerrors.NewAggregate(errs)
 
 	if err != nil {
@@ -3,14 +3,19 @@ package statuscontroller
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	fakedynamic "k8s.io/client-go/dynamic/fake"
 	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
 
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
@@ -29,7 +34,7 @@ func TestSyncManifestWork(t *testing.T) {
 		existingResources []runtime.Object
 		manifests         []workapiv1.ManifestCondition
 		workConditions    []metav1.Condition
-		validateActions   func(t *testing.T, actions []clienttesting.Action)
+		validateActions   func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork)
 	}{
 		{
 			name: "unknown available status from work whose manifests become empty",
@@ -41,13 +46,8 @@ func TestSyncManifestWork(t *testing.T) {
 					Type: workapiv1.WorkAvailable,
 				},
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 
 				if !hasStatusCondition(work.Status.Conditions, workapiv1.WorkAvailable, metav1.ConditionUnknown) {
 					t.Fatal(spew.Sdump(work.Status.Conditions))
@@ -62,7 +62,7 @@ func TestSyncManifestWork(t *testing.T) {
 			manifests: []workapiv1.ManifestCondition{
 				newManifestWthCondition("", "v1", "secrets", "ns1", "n1"),
 			},
-			validateActions: testingcommon.AssertNoActions,
+			validateActions: assertNoActions,
 		},
 		{
 			name: "Do not update if existing conditions are correct",
@@ -83,7 +83,7 @@ func TestSyncManifestWork(t *testing.T) {
 					Message: "All resources are available",
 				},
 			},
-			validateActions: testingcommon.AssertNoActions,
+			validateActions: assertNoActions,
 		},
 		{
 			name: "build status with existing resource",
@@ -98,13 +98,8 @@ func TestSyncManifestWork(t *testing.T) {
 					Type: workapiv1.WorkApplied,
 				},
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 				if len(work.Status.ResourceStatus.Manifests) != 1 {
 					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests))
 				}
@@ -131,13 +126,8 @@ func TestSyncManifestWork(t *testing.T) {
 					Type: workapiv1.WorkApplied,
 				},
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 				if !hasStatusCondition(work.Status.ResourceStatus.Manifests[0].Conditions, string(workapiv1.ManifestAvailable), metav1.ConditionTrue) {
 					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests[0].Conditions))
 				}
@@ -164,13 +154,8 @@ func TestSyncManifestWork(t *testing.T) {
 					Type: workapiv1.WorkApplied,
 				},
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 				if len(work.Status.ResourceStatus.Manifests) != 2 {
 					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests))
 				}
@@ -208,11 +193,15 @@ func TestSyncManifestWork(t *testing.T) {
 					fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
 			}
 
-			err := controller.syncManifestWork(context.TODO(), testingWork)
+			if _, err := controller.syncManifestWork(context.TODO(), testingWork); err != nil {
+				t.Fatal(err)
+			}
+			actions := fakeClient.Actions()
+			work, err := fakeClient.WorkV1().ManifestWorks(testingWork.Namespace).Get(context.TODO(), testingWork.Name, metav1.GetOptions{})
 			if err != nil {
 				t.Fatal(err)
 			}
-			c.validateActions(t, fakeClient.Actions())
+			c.validateActions(t, actions, work)
 		})
 	}
 }
@@ -223,7 +212,7 @@ func TestStatusFeedback(t *testing.T) {
 		existingResources []runtime.Object
 		configOption      []workapiv1.ManifestConfigOption
 		manifests         []workapiv1.ManifestCondition
-		validateActions   func(t *testing.T, actions []clienttesting.Action)
+		validateActions   func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork)
 	}{
 		{
 			name: "resource identifer is not matched",
@@ -238,13 +227,8 @@ func TestStatusFeedback(t *testing.T) {
 			manifests: []workapiv1.ManifestCondition{
 				newManifest("", "v1", "secrets", "ns1", "n1"),
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 				if len(work.Status.ResourceStatus.Manifests) != 1 {
 					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests))
 				}
@@ -275,37 +259,32 @@ func TestStatusFeedback(t *testing.T) {
 				newManifest("", "v1", "secrets", "ns1", "n1"),
 				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 				if len(work.Status.ResourceStatus.Manifests) != 2 {
 					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests))
 				}
 
 				expectedValues := []workapiv1.FeedbackValue{
 					{
-						Name: "ReadyReplicas",
+						Name: "AvailableReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(2),
 						},
 					},
 					{
-						Name: "Replicas",
+						Name: "ReadyReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(3),
+							Integer: pointer.Int64(2),
 						},
 					},
 					{
-						Name: "AvailableReplicas",
+						Name: "Replicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(2),
+							Integer: pointer.Int64(3),
 						},
 					},
 				}
@@ -350,13 +329,8 @@ func TestStatusFeedback(t *testing.T) {
 			manifests: []workapiv1.ManifestCondition{
 				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
 			},
-			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+			validateActions: func(t *testing.T, actions []clienttesting.Action, work *workapiv1.ManifestWork) {
 				testingcommon.AssertActions(t, actions, "patch")
-				p := actions[0].(clienttesting.PatchActionImpl).Patch
-				work := &workapiv1.ManifestWork{}
-				if err := json.Unmarshal(p, work); err != nil {
-					t.Fatal(err)
-				}
 				if len(work.Status.ResourceStatus.Manifests) != 1 {
 					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests))
 				}
@@ -404,15 +378,23 @@ func TestStatusFeedback(t *testing.T) {
 					fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
 			}
 
-			err := controller.syncManifestWork(context.TODO(), testingWork)
+			if _, err := controller.syncManifestWork(context.TODO(), testingWork); err != nil {
+				t.Fatal(err)
+			}
+			actions := fakeClient.Actions()
+			work, err := fakeClient.WorkV1().ManifestWorks(testingWork.Namespace).Get(context.TODO(), testingWork.Name, metav1.GetOptions{})
 			if err != nil {
 				t.Fatal(err)
 			}
-			c.validateActions(t, fakeClient.Actions())
+			c.validateActions(t, actions, work)
 		})
 	}
 }
 
+func assertNoActions(t *testing.T, actions []clienttesting.Action, _ *workapiv1.ManifestWork) {
+	testingcommon.AssertNoActions(t, actions)
+}
+
 func newManifest(group, version, resource, namespace, name string) workapiv1.ManifestCondition {
 	return workapiv1.ManifestCondition{
 		ResourceMeta: workapiv1.ManifestResourceMeta{
@@ -454,3 +436,119 @@ func hasStatusCondition(conditions []metav1.Condition, conditionType string, sta
 
 	return false
 }
+
+func TestDifferentialStatusFeedback(t *testing.T) {
+	newDeployment := func(name string, replicas int64) *unstructured.Unstructured {
+		return spoketesting.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", name,
+			map[string]interface{}{
+				"status": map[string]interface{}{
+					"readyReplicas": replicas, "replicas": replicas, "availableReplicas": replicas,
+				},
+			})
+	}
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	testingWork, _ := spoketesting.NewManifestWork(0)
+	testingWork.Finalizers = []string{controllers.ManifestWorkFinalizer}
+	for _, name := range []string{"deploy1", "deploy2"} {
+		testingWork.Spec.ManifestConfigs = append(testingWork.Spec.ManifestConfigs, workapiv1.ManifestConfigOption{
+			ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "apps", Resource: "deployments", Name: name, Namespace: "ns1"},
+			FeedbackRules:      []workapiv1.FeedbackRule{{Type: workapiv1.WellKnownStatusType}},
+		})
+	}
+	testingWork.Status = workapiv1.ManifestWorkStatus{
+		ResourceStatus: workapiv1.ManifestResourceStatus{
+			Manifests: []workapiv1.ManifestCondition{
+				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
+				newManifest("apps", "v1", "deployments", "ns1", "deploy2"),
+			},
+		},
+		Conditions: []metav1.Condition{{Type: workapiv1.WorkApplied}},
+	}
+
+	fakeClient := fakeworkclient.NewSimpleClientset(testingWork)
+	fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(),
+		newDeployment("deploy1", 1), newDeployment("deploy2", 1))
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	controller := AvailableStatusController{
+		spokeDynamicClient: fakeDynamicClient,
+		statusReader:       statusfeedback.NewStatusReader(),
+		patcher: patcher.NewPatcher[
+			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+			fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
+		feedbackCoalesceInterval: 10 * time.Second,
+		clock:                    fakeClock,
+		lastPatchTime:            map[string]time.Time{},
+	}
+
+	sync := func(expectedRequeue time.Duration) *workapiv1.ManifestWork {
+		work, err := fakeClient.WorkV1().ManifestWorks(testingWork.Namespace).Get(context.TODO(), testingWork.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeClient.ClearActions()
+		requeueAfter, err := controller.syncManifestWork(context.TODO(), work)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requeueAfter != expectedRequeue {
+			t.Errorf("expected requeue after %v, but got %v", expectedRequeue, requeueAfter)
+		}
+		return work
+	}
+	patchPaths := func() []string {
+		testingcommon.AssertActions(t, fakeClient.Actions(), "patch")
+		operations := []map[string]interface{}{}
+		if err := json.Unmarshal(fakeClient.Actions()[0].(clienttesting.PatchActionImpl).Patch, &operations); err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, operation := range operations {
+			paths = append(paths, operation["path"].(string))
+		}
+		return paths
+	}
+
+	// the feedback values of all the manifests are synced at first
+	sync(0)
+	testingcommon.AssertActions(t, fakeClient.Actions(), "patch")
+
+	// the feedback values are in the order of the names across the syncs
+	work := sync(0)
+	testingcommon.AssertNoActions(t, fakeClient.Actions())
+	var names []string
+	for _, value := range work.Status.ResourceStatus.Manifests[1].StatusFeedbacks.Values {
+		names = append(names, value.Name)
+	}
+	if strings.Join(names, ",") != "AvailableReplicas,ReadyReplicas,Replicas" {
+		t.Errorf("unexpected order of the feedback values %v", names)
+	}
+
+	// the change of the feedback values within the interval is coalesced
+	if _, err := fakeDynamicClient.Resource(gvr).Namespace("ns1").Update(
+		context.TODO(), newDeployment("deploy2", 2), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	fakeClock.Step(4 * time.Second)
+	sync(6 * time.Second)
+	testingcommon.AssertNoActions(t, fakeClient.Actions())
+
+	// only the changed feedback values of the changed manifest are patched
+	fakeClock.Step(6 * time.Second)
+	sync(0)
+	expectedPaths := []string{
+		"/status/resourceStatus/manifests/1/statusFeedback/values/0/fieldValue/integer",
+		"/status/resourceStatus/manifests/1/statusFeedback/values/1/fieldValue/integer",
+		"/status/resourceStatus/manifests/1/statusFeedback/values/2/fieldValue/integer",
+	}
+	if paths := patchPaths(); !equality.Semantic.DeepEqual(paths, expectedPaths) {
+		t.Errorf("expected patch paths %v, but got %v", expectedPaths, paths)
+	}
+
+	// the change of the conditions is not coalesced
+	if err := fakeDynamicClient.Resource(gvr).Namespace("ns1").Delete(context.TODO(), "deploy1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	sync(0)
+	testingcommon.AssertActions(t, fakeClient.Actions(), "patch")
+}
@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
@@ -16,7 +27,11 @@ import (
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 	ocmfeature "open-cluster-management.io/api/feature"
+	workv1 "open-cluster-management.io/api/work/v1"
 
+	"open-cluster-management.io/ocm/pkg/common/agentevents"
+	"open-cluster-management.io/ocm/pkg/common/loglevel"
+	"open-cluster-management.io/ocm/pkg/common/namespaces"
 	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/work/helper"
@@ -25,6 +40,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/finalizercontroller"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/manifestcontroller"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/statuscontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/transformer"
 )
 
 const (
@@ -39,6 +55,27 @@ const (
 	availableStatusControllerWorkers             = 10
 )
 
+// workSpokeAccesses are the permissions on the managed cluster required by the features of the work agent.
+var workSpokeAccesses = []commonoptions.SpokeAccess{
+	{
+		Feature:    "manifest apply",
+		Attributes: authorizationv1.ResourceAttributes{Verb: "create", Group: "*", Resource: "*"},
+	},
+	{
+		Feature:    "manifest apply",
+		Attributes: authorizationv1.ResourceAttributes{Verb: "update", Group: "*", Resource: "*"},
+	},
+	{
+		Feature:    "manifest deletion",
+		Attributes: authorizationv1.ResourceAttributes{Verb: "delete", Group: "*", Resource: "*"},
+	},
+	{
+		Feature: "appliedmanifestwork tracking",
+		Attributes: authorizationv1.ResourceAttributes{
+			Verb: "create", Group: workv1.GroupName, Resource: "appliedmanifestworks"},
+	},
+}
+
 // WorkloadAgentOptions defines the flags for workload agent
 type WorkloadAgentOptions struct {
 	AgentOptions                           *commonoptions.AgentOptions
@@ -46,6 +83,15 @@ type WorkloadAgentOptions struct {
 	AgentID                                string
 	StatusSyncInterval                     time.Duration
 	AppliedManifestWorkEvictionGracePeriod time.Duration
+	ManifestApplyConcurrency               int
+	ResyncBudget                           int
+	ContentHashPruneTTL                    time.Duration
+	ContentHashPruneInterval               time.Duration
+	DisableSourceAnnotations               bool
+	ImageRegistryMapping                   map[string]string
+	ImagePullSecrets                       []string
+	MigrateHubHashes                       []string
+	ProtectedNamespaces                    []string
 }
 
 // NewWorkloadAgentOptions returns the flags with default value set
@@ -54,6 +100,10 @@ func NewWorkloadAgentOptions() *WorkloadAgentOptions {
 		AgentOptions:                           commonoptions.NewAgentOptions(),
 		StatusSyncInterval:                     10 * time.Second,
 		AppliedManifestWorkEvictionGracePeriod: 10 * time.Minute,
+		ManifestApplyConcurrency:               4,
+		ResyncBudget:                           20,
+		ContentHashPruneTTL:                    24 * time.Hour,
+		ContentHashPruneInterval:               appliedmanifestcontroller.DefaultContentHashPruneInterval,
 	}
 }
 
@@ -64,10 +114,42 @@ func (o *WorkloadAgentOptions) AddFlags(cmd *cobra.Command) {
 	features.DefaultSpokeWorkMutableFeatureGate.AddFlag(flags)
 	// This command only supports reading from config
 	flags.StringVar(&o.HubKubeconfigFile, "hub-kubeconfig", o.HubKubeconfigFile, "Location of kubeconfig file to connect to hub cluster.")
+	o.AddWorkFlags(flags)
+}
+
+// AddWorkFlags registers the flags of the work controllers only, so they can be bound together with the flags
+// of the registration agent in the singleton agent.
+func (o *WorkloadAgentOptions) AddWorkFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.AgentID, "agent-id", o.AgentID, "ID of the work agent to identify the work this agent should handle after restart/recovery.")
 	flags.DurationVar(&o.StatusSyncInterval, "status-sync-interval", o.StatusSyncInterval, "Interval to sync resource status to hub.")
 	flags.DurationVar(&o.AppliedManifestWorkEvictionGracePeriod, "appliedmanifestwork-eviction-grace-period",
 		o.AppliedManifestWorkEvictionGracePeriod, "Grace period for appliedmanifestwork eviction")
+	flags.IntVar(&o.ManifestApplyConcurrency, "manifest-apply-concurrency", o.ManifestApplyConcurrency,
+		"The max number of manifests in a manifestwork applied in parallel.")
+	flags.IntVar(&o.ResyncBudget, "resync-budget", o.ResyncBudget,
+		"The max number of the manifestworks not changed since they were applied synced per second, e.g. when "+
+			"the manifestworks are relisted from the hub. The changed manifestworks are synced first. 0 means no limit.")
+	flags.DurationVar(&o.ContentHashPruneTTL, "content-hash-prune-ttl", o.ContentHashPruneTTL,
+		"The max duration the superseded instances of the content hash named ConfigMaps and Secrets are kept "+
+			"while pods still reference them. Set it to 0 to prune them right away without watching the pods, "+
+			"ConfigMaps and Secrets.")
+	flags.DurationVar(&o.ContentHashPruneInterval, "content-hash-prune-interval", o.ContentHashPruneInterval,
+		"The interval to check whether the kept superseded instances of the content hash named ConfigMaps and "+
+			"Secrets are still referenced by pods.")
+	flags.BoolVar(&o.DisableSourceAnnotations, "disable-source-annotations", o.DisableSourceAnnotations,
+		"Disable stamping the applied resources with the annotations of the hub hash, manifestwork and agent id.")
+	flags.StringToStringVar(&o.ImageRegistryMapping, "image-registry-mapping", o.ImageRegistryMapping,
+		"The mapping from the source registries to the mirror registries to rewrite the images of the applied "+
+			"workloads, e.g. quay.io=mirror.example.com.")
+	flags.StringSliceVar(&o.ImagePullSecrets, "image-pull-secrets", o.ImagePullSecrets,
+		"The names of the imagePullSecrets injected to the applied workloads.")
+	flags.StringSliceVar(&o.MigrateHubHashes, "migrate-hub-hashes", o.MigrateHubHashes,
+		"The previous hub hashes of the appliedmanifestworks migrated to the current hub hash instead of being "+
+			"evicted, e.g. after the hub apiserver url is renamed.")
+	flags.StringArrayVar(&o.ProtectedNamespaces, "protected-namespaces", o.ProtectedNamespaces,
+		"The name or the regular expression of the namespaces the manifests are never applied into regardless "+
+			"of the permission of the executor, e.g. openshift-.*. The flag is repeated for each pattern, and the "+
+			"cluster scoped manifests are not affected.")
 }
 
 // RunWorkloadAgent starts the controllers on agent to process work from hub.
@@ -77,8 +159,16 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 	if err != nil {
 		return err
 	}
+	if err := o.AgentOptions.TLSOptions.ApplyToRestConfig(hubRestConfig); err != nil {
+		return err
+	}
 	hubhash := helper.HubHash(hubRestConfig.Host)
 
+	protectedNamespaces, err := namespaces.NewMatcher(o.ProtectedNamespaces)
+	if err != nil {
+		return err
+	}
+
 	agentID := o.AgentID
 	if len(agentID) == 0 {
 		agentID = hubhash
@@ -88,6 +178,11 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 	if err != nil {
 		return err
 	}
+	// the critical failures of the agent are reported as events in the cluster namespace on hub
+	hubKubeClient, err := kubernetes.NewForConfig(hubRestConfig)
+	if err != nil {
+		return err
+	}
 	// Only watch the cluster namespace on hub
 	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(hubWorkClient, 5*time.Minute,
 		workinformers.WithNamespace(o.AgentOptions.SpokeClusterName))
@@ -116,16 +211,32 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 		return err
 	}
 	spokeWorkInformerFactory := workinformers.NewSharedInformerFactory(spokeWorkClient, 5*time.Minute)
+	spokeDynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(spokeDynamicClient, 5*time.Minute)
+	crdInformer := spokeDynamicInformerFactory.ForResource(schema.GroupVersionResource{
+		Group:    "apiextensions.k8s.io",
+		Version:  "v1",
+		Resource: "customresourcedefinitions",
+	}).Informer()
 
 	httpClient, err := rest.HTTPClientFor(spokeRestConfig)
 	if err != nil {
 		return err
 	}
-	restMapper, err := apiutil.NewDynamicRESTMapper(spokeRestConfig, httpClient)
+	// the restmapper is reset once the scope of a crd is changed, since the lazy restmapper caches the mappings.
+	restMapper, err := helper.NewResettableRESTMapper(func() (meta.RESTMapper, error) {
+		return apiutil.NewDynamicRESTMapper(spokeRestConfig, httpClient)
+	})
 	if err != nil {
 		return err
 	}
 
+	// the spoke kubeconfig of the agent running outside of the managed cluster could be restricted, e.g.
+	// read-only, report the features which cannot work with it.
+	if len(o.AgentOptions.SpokeKubeconfigFile) > 0 {
+		commonoptions.ReportSpokeAccess(ctx, spokeKubeClient.AuthorizationV1(), workSpokeAccesses,
+			controllerContext.EventRecorder)
+	}
+
 	validator := auth.NewFactory(
 		spokeRestConfig,
 		spokeKubeClient,
@@ -145,9 +256,16 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 		workInformerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks(o.AgentOptions.SpokeClusterName),
 		spokeWorkClient.WorkV1().AppliedManifestWorks(),
 		spokeWorkInformerFactory.Work().V1().AppliedManifestWorks(),
+		crdInformer,
 		hubhash, agentID,
 		restMapper,
 		validator,
+		o.ManifestApplyConcurrency,
+		o.ResyncBudget,
+		!o.DisableSourceAnnotations,
+		transformer.NewTransformers(o.ImageRegistryMapping, o.ImagePullSecrets),
+		protectedNamespaces,
+		agentevents.NewReporter(hubKubeClient.CoreV1(), o.AgentOptions.SpokeClusterName, "work-agent"),
 	)
 	addFinalizerController := finalizercontroller.NewAddFinalizerController(
 		controllerContext.EventRecorder,
@@ -180,6 +298,23 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 		o.AppliedManifestWorkEvictionGracePeriod,
 		hubhash, agentID,
 	)
+	// the superseded instances of the content hash named ConfigMaps and Secrets are checked against the cached
+	// pods, only the metadata of the ConfigMaps and Secrets are cached.
+	var podInformer corev1informers.PodInformer
+	var configMapInformer, secretInformer informers.GenericInformer
+	var spokeKubeInformerFactory informers.SharedInformerFactory
+	var spokeMetadataInformerFactory metadatainformer.SharedInformerFactory
+	if o.ContentHashPruneTTL > 0 {
+		spokeMetadataClient, err := metadata.NewForConfig(spokeRestConfig)
+		if err != nil {
+			return err
+		}
+		spokeKubeInformerFactory = informers.NewSharedInformerFactory(spokeKubeClient, 10*time.Minute)
+		spokeMetadataInformerFactory = metadatainformer.NewSharedInformerFactory(spokeMetadataClient, 10*time.Minute)
+		podInformer = spokeKubeInformerFactory.Core().V1().Pods()
+		configMapInformer = spokeMetadataInformerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("configmaps"))
+		secretInformer = spokeMetadataInformerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("secrets"))
+	}
 	appliedManifestWorkController := appliedmanifestcontroller.NewAppliedManifestWorkController(
 		controllerContext.EventRecorder,
 		spokeDynamicClient,
@@ -188,7 +323,25 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 		spokeWorkClient.WorkV1().AppliedManifestWorks(),
 		spokeWorkInformerFactory.Work().V1().AppliedManifestWorks(),
 		hubhash,
+		o.ContentHashPruneTTL,
+		o.ContentHashPruneInterval,
+		podInformer,
+		configMapInformer,
+		secretInformer,
 	)
+	var appliedManifestWorkMigrationController factory.Controller
+	if len(o.MigrateHubHashes) > 0 {
+		appliedManifestWorkMigrationController = appliedmanifestcontroller.NewAppliedManifestWorkMigrationController(
+			controllerContext.EventRecorder,
+			spokeDynamicClient,
+			workInformerFactory.Work().V1().ManifestWorks(),
+			workInformerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks(o.AgentOptions.SpokeClusterName),
+			spokeWorkClient.WorkV1().AppliedManifestWorks(),
+			spokeWorkInformerFactory.Work().V1().AppliedManifestWorks(),
+			o.MigrateHubHashes,
+			hubhash, agentID,
+		)
+	}
 	availableStatusController := statuscontroller.NewAvailableStatusController(
 		controllerContext.EventRecorder,
 		spokeDynamicClient,
@@ -198,12 +351,35 @@ func (o *WorkloadAgentOptions) RunWorkloadAgent(ctx context.Context, controllerC
 		o.StatusSyncInterval,
 	)
 
+	// change the log verbosity at runtime once the log level configmap is changed
+	if len(o.AgentOptions.LogLevelConfigMap) > 0 {
+		managementKubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+		if err != nil {
+			return err
+		}
+		agentKubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			managementKubeClient, 10*time.Minute, informers.WithNamespace(controllerContext.OperatorNamespace))
+		logLevelController := loglevel.NewLogLevelController(
+			controllerContext.OperatorNamespace, o.AgentOptions.LogLevelConfigMap,
+			agentKubeInformerFactory.Core().V1().ConfigMaps(), controllerContext.EventRecorder)
+		go agentKubeInformerFactory.Start(ctx.Done())
+		go logLevelController.Run(ctx, 1)
+	}
+
 	go workInformerFactory.Start(ctx.Done())
 	go spokeWorkInformerFactory.Start(ctx.Done())
+	go spokeDynamicInformerFactory.Start(ctx.Done())
+	if spokeKubeInformerFactory != nil {
+		go spokeKubeInformerFactory.Start(ctx.Done())
+		go spokeMetadataInformerFactory.Start(ctx.Done())
+	}
 	go addFinalizerController.Run(ctx, 1)
 	go appliedManifestWorkFinalizeController.Run(ctx, appliedManifestWorkFinalizeControllerWorkers)
 	go unmanagedAppliedManifestWorkController.Run(ctx, 1)
 	go appliedManifestWorkController.Run(ctx, 1)
+	if appliedManifestWorkMigrationController != nil {
+		go appliedManifestWorkMigrationController.Run(ctx, 1)
+	}
 	go manifestWorkController.Run(ctx, 1)
 	go manifestWorkFinalizeController.Run(ctx, manifestWorkFinalizeControllerWorkers)
 	go availableStatusController.Run(ctx, availableStatusControllerWorkers)
@@ -125,6 +125,7 @@ func NewFakeRestMapper() meta.RESTMapper {
 			VersionedResources: map[string][]metav1.APIResource{
 				"v1": {
 					{Name: "secrets", Namespaced: true, Kind: "Secret"},
+					{Name: "namespaces", Namespaced: false, Kind: "Namespace"},
 					{Name: "pods", Namespaced: true, Kind: "Pod"},
 					{Name: "newobjects", Namespaced: true, Kind: "NewObject"},
 				},
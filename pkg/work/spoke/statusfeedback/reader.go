@@ -41,7 +41,11 @@ func (s *StatusReader) GetValuesByRule(obj *unstructured.Unstructured, rule work
 		}
 
 		for _, path := range paths {
-			value, err := getValueByJsonPath(path.Name, path.Path, obj)
+			getValue := getValueByJsonPath
+			if s.wellKnownStatus.IsCountPath(obj.GroupVersionKind(), path.Name) {
+				getValue = getCountByJsonPath
+			}
+			value, err := getValue(path.Name, path.Path, obj)
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -74,7 +78,7 @@ func (s *StatusReader) GetValuesByRule(obj *unstructured.Unstructured, rule work
 	return values, utilerrors.NewAggregate(errs)
 }
 
-func getValueByJsonPath(name, path string, obj *unstructured.Unstructured) (*workapiv1.FeedbackValue, error) {
+func findResultsByJsonPath(name, path string, obj *unstructured.Unstructured) ([][]reflect.Value, error) {
 	j := jsonpath.New(name).AllowMissingKeys(true)
 	err := j.Parse(fmt.Sprintf("{%s}", path))
 	if err != nil {
@@ -82,10 +86,38 @@ func getValueByJsonPath(name, path string, obj *unstructured.Unstructured) (*wor
 	}
 
 	results, err := j.FindResults(obj.UnstructuredContent())
-
 	if err != nil {
 		return nil, fmt.Errorf("failed to find value for %s with error: %v", name, err)
 	}
+	return results, nil
+}
+
+// getCountByJsonPath returns the number of the items found by the path as an integer, it is 0 if the path is
+// missing.
+func getCountByJsonPath(name, path string, obj *unstructured.Unstructured) (*workapiv1.FeedbackValue, error) {
+	results, err := findResultsByJsonPath(name, path, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	for _, result := range results {
+		count += int64(len(result))
+	}
+	return &workapiv1.FeedbackValue{
+		Name: name,
+		Value: workapiv1.FieldValue{
+			Type:    workapiv1.Integer,
+			Integer: &count,
+		},
+	}, nil
+}
+
+func getValueByJsonPath(name, path string, obj *unstructured.Unstructured) (*workapiv1.FeedbackValue, error) {
+	results, err := findResultsByJsonPath(name, path, obj)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(results) == 0 || len(results[0]) == 0 {
 		// no results are found here.
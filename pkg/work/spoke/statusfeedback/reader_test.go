@@ -90,6 +90,64 @@ const (
 		}
 	}
 `
+	daemonSetJson = `
+	{
+		"apiVersion": "apps/v1",
+		"kind": "DaemonSet",
+		"metadata": {
+			"name": "test"
+		},
+		"status": {
+			"currentNumberScheduled": 3,
+			"desiredNumberScheduled": 3,
+			"numberAvailable": 2,
+			"numberMisscheduled": 0,
+			"numberReady": 2
+		}
+	}
+	`
+	statefulSetJson = `
+	{
+		"apiVersion": "apps/v1",
+		"kind": "StatefulSet",
+		"metadata": {
+			"name": "test"
+		},
+		"status": {
+			"availableReplicas": 1,
+			"currentReplicas": 2,
+			"readyReplicas": 1,
+			"replicas": 2,
+			"updatedReplicas": 2
+		}
+	}
+	`
+	cronJobJson = `
+	{
+		"apiVersion": "batch/v1",
+		"kind": "CronJob",
+		"metadata": {
+			"name": "test"
+		},
+		"status": {
+			"active": [
+				{"apiVersion": "batch/v1", "kind": "Job", "name": "test-1", "namespace": "default"},
+				{"apiVersion": "batch/v1", "kind": "Job", "name": "test-2", "namespace": "default"}
+			],
+			"lastScheduleTime": "2026-10-16T12:00:00Z"
+		}
+	}
+	`
+	cronJobNotActiveJson = `
+	{
+		"apiVersion": "batch/v1",
+		"kind": "CronJob",
+		"metadata": {
+			"name": "test"
+		},
+		"status": {}
+	}
+	`
 )
 
 func unstrctureObject(data string) *unstructured.Unstructured {
@@ -258,6 +316,97 @@ func TestStatusReader(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "DaemonSet values",
+			object: unstrctureObject(daemonSetJson),
+			rule:   workapiv1.FeedbackRule{Type: workapiv1.WellKnownStatusType},
+			expectedValue: []workapiv1.FeedbackValue{
+				{
+					Name: "DesiredNumberScheduled",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(3),
+					},
+				},
+				{
+					Name: "NumberReady",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(2),
+					},
+				},
+				{
+					Name: "NumberAvailable",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(2),
+					},
+				},
+			},
+		},
+		{
+			name:   "StatefulSet values",
+			object: unstrctureObject(statefulSetJson),
+			rule:   workapiv1.FeedbackRule{Type: workapiv1.WellKnownStatusType},
+			expectedValue: []workapiv1.FeedbackValue{
+				{
+					Name: "Replicas",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(2),
+					},
+				},
+				{
+					Name: "ReadyReplicas",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(1),
+					},
+				},
+				{
+					Name: "UpdatedReplicas",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(2),
+					},
+				},
+			},
+		},
+		{
+			name:   "CronJob values",
+			object: unstrctureObject(cronJobJson),
+			rule:   workapiv1.FeedbackRule{Type: workapiv1.WellKnownStatusType},
+			expectedValue: []workapiv1.FeedbackValue{
+				{
+					Name: "LastScheduleTime",
+					Value: workapiv1.FieldValue{
+						Type:   workapiv1.String,
+						String: pointer.String("2026-10-16T12:00:00Z"),
+					},
+				},
+				{
+					Name: "ActiveJobs",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(2),
+					},
+				},
+			},
+		},
+		{
+			name:   "CronJob values without active jobs",
+			object: unstrctureObject(cronJobNotActiveJson),
+			rule:   workapiv1.FeedbackRule{Type: workapiv1.WellKnownStatusType},
+			expectedValue: []workapiv1.FeedbackValue{
+				{
+					Name: "ActiveJobs",
+					Value: workapiv1.FieldValue{
+						Type:    workapiv1.Integer,
+						Integer: pointer.Int64(0),
+					},
+				},
+			},
+		},
 		{
 			name:      "rawjson value format",
 			object:    unstrctureObject(podJson),
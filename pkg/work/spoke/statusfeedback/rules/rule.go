@@ -2,16 +2,21 @@ package rules
 
 import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	workapiv1 "open-cluster-management.io/api/work/v1"
 )
 
 type WellKnownStatusRuleResolver interface {
 	GetPathsByKind(schema.GroupVersionKind) []workapiv1.JsonPath
+	// IsCountPath returns true if the value of the path is the number of the items found by the path instead of
+	// the first item found, e.g. the number of the active jobs of a CronJob.
+	IsCountPath(gvk schema.GroupVersionKind, name string) bool
 }
 
 type DefaultWellKnownStatusResolver struct {
-	rules map[schema.GroupVersionKind][]workapiv1.JsonPath
+	rules      map[schema.GroupVersionKind][]workapiv1.JsonPath
+	countPaths map[schema.GroupVersionKind]sets.Set[string]
 }
 
 var deploymentRule = []workapiv1.JsonPath{
@@ -51,12 +56,62 @@ var podRule = []workapiv1.JsonPath{
 	},
 }
 
+var daemonSetRule = []workapiv1.JsonPath{
+	{
+		Name: "DesiredNumberScheduled",
+		Path: ".status.desiredNumberScheduled",
+	},
+	{
+		Name: "NumberReady",
+		Path: ".status.numberReady",
+	},
+	{
+		Name: "NumberAvailable",
+		Path: ".status.numberAvailable",
+	},
+}
+
+var statefulSetRule = []workapiv1.JsonPath{
+	{
+		Name: "Replicas",
+		Path: ".status.replicas",
+	},
+	{
+		Name: "ReadyReplicas",
+		Path: ".status.readyReplicas",
+	},
+	{
+		Name: "UpdatedReplicas",
+		Path: ".status.updatedReplicas",
+	},
+}
+
+var cronJobRule = []workapiv1.JsonPath{
+	{
+		Name: "LastScheduleTime",
+		Path: ".status.lastScheduleTime",
+	},
+	{
+		// the number of the active jobs, the active list is omitted if there is no active job.
+		Name: "ActiveJobs",
+		Path: ".status.active[*]",
+	},
+}
+
+// DefaultWellKnownStatusRule returns the resolver of the well-known statuses of the apps/v1 Deployments,
+// DaemonSets and StatefulSets, the batch/v1 Jobs and CronJobs, and the v1 Pods.
 func DefaultWellKnownStatusRule() WellKnownStatusRuleResolver {
 	return &DefaultWellKnownStatusResolver{
 		rules: map[schema.GroupVersionKind][]workapiv1.JsonPath{
-			{Group: "apps", Version: "v1", Kind: "Deployment"}: deploymentRule,
-			{Group: "batch", Version: "v1", Kind: "Job"}:       jobRule,
-			{Group: "", Version: "v1", Kind: "Pod"}:            podRule,
+			{Group: "apps", Version: "v1", Kind: "Deployment"}:  deploymentRule,
+			{Group: "apps", Version: "v1", Kind: "DaemonSet"}:   daemonSetRule,
+			{Group: "apps", Version: "v1", Kind: "StatefulSet"}: statefulSetRule,
+			{Group: "batch", Version: "v1", Kind: "Job"}:        jobRule,
+			{Group: "batch", Version: "v1", Kind: "CronJob"}:    cronJobRule,
+			{Group: "", Version: "v1", Kind: "Pod"}:             podRule,
+		},
+		countPaths: map[schema.GroupVersionKind]sets.Set[string]{
+			{Group: "batch", Version: "v1", Kind: "CronJob"}: sets.New[string]("ActiveJobs"),
 		},
 	}
 }
@@ -64,3 +119,7 @@ func DefaultWellKnownStatusRule() WellKnownStatusRuleResolver {
 func (w *DefaultWellKnownStatusResolver) GetPathsByKind(gvk schema.GroupVersionKind) []workapiv1.JsonPath {
 	return w.rules[gvk]
 }
+
+func (w *DefaultWellKnownStatusResolver) IsCountPath(gvk schema.GroupVersionKind, name string) bool {
+	return w.countPaths[gvk].Has(name)
+}
@@ -0,0 +1,251 @@
+package transformer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// ContentHashNameAnnotation is the annotation on a ConfigMap or Secret manifest to append the hash of its
+	// content to its name when it is applied, so a new instance is created once the content is changed. It is
+	// meant for the immutable ConfigMaps and Secrets. The references to it in the pod specs of the other manifests
+	// of the same work are rewritten to the hashed name.
+	// TODO move this to the api repo
+	ContentHashNameAnnotation = "work.open-cluster-management.io/content-hash-name"
+
+	// ContentHashBaseNameAnnotation is the annotation set on the hashed instances with the name in the manifest,
+	// the superseded instances are pruned by the agent once no pod references them.
+	// TODO move this to the api repo
+	ContentHashBaseNameAnnotation = "work.open-cluster-management.io/content-hash-base-name"
+
+	// contentHashLength is the length of the hash appended to the name.
+	contentHashLength = 10
+)
+
+// contentHashKey is the kind, namespace and name of a ConfigMap or Secret in the manifests.
+type contentHashKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// contentHashTransformer renames the ConfigMaps and Secrets with the ContentHashNameAnnotation annotation, and
+// rewrites the references to them in the pod specs of the workloads in the same namespace. The ones whose hashed
+// names are invalid fail to be transformed.
+type contentHashTransformer struct {
+	names   map[contentHashKey]string
+	invalid map[contentHashKey]error
+}
+
+// NewContentHashTransformer returns a transformer appending the content hash to the names of the ConfigMaps and
+// Secrets with the ContentHashNameAnnotation annotation in the manifests of a work. It returns nil if there is no
+// such manifest. The invalid manifests are skipped, they fail to be applied anyway.
+func NewContentHashTransformer(manifests []workapiv1.Manifest) Transformer {
+	names := map[contentHashKey]string{}
+	invalid := map[contentHashKey]error{}
+	for _, manifest := range manifests {
+		obj, ok := contentHashObject(manifest)
+		if !ok {
+			continue
+		}
+		key := contentHashKey{kind: obj.GetKind(), namespace: obj.GetNamespace(), name: obj.GetName()}
+		name, err := contentHashName(obj)
+		if err != nil {
+			invalid[key] = err
+			continue
+		}
+		names[key] = name
+	}
+
+	if len(names) == 0 && len(invalid) == 0 {
+		return nil
+	}
+	return &contentHashTransformer{names: names, invalid: invalid}
+}
+
+// AppliedName returns the name the resource of a manifest condition is applied with. The resource meta in the
+// manifest conditions keeps the name in the manifest, so the manifestConfigs, the orphaning rules and the executor
+// permissions match it. The name of a content hash named ConfigMap or Secret is resolved from its manifest by the
+// ordinal, otherwise the name in the resource meta is returned.
+func AppliedName(manifests []workapiv1.Manifest, resourceMeta workapiv1.ManifestResourceMeta) string {
+	if resourceMeta.Group != "" || (resourceMeta.Resource != "configmaps" && resourceMeta.Resource != "secrets") ||
+		resourceMeta.Ordinal < 0 || int(resourceMeta.Ordinal) >= len(manifests) {
+		return resourceMeta.Name
+	}
+	obj, ok := contentHashObject(manifests[resourceMeta.Ordinal])
+	if !ok || obj.GetNamespace() != resourceMeta.Namespace || obj.GetName() != resourceMeta.Name {
+		return resourceMeta.Name
+	}
+	name, err := contentHashName(obj)
+	if err != nil {
+		return resourceMeta.Name
+	}
+	return name
+}
+
+// contentHashObject returns the ConfigMap or Secret in the manifest if it has the ContentHashNameAnnotation.
+func contentHashObject(manifest workapiv1.Manifest) (*unstructured.Unstructured, bool) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+		return nil, false
+	}
+	if obj.GetAnnotations()[ContentHashNameAnnotation] != "true" || !isConfigMapOrSecret(obj) {
+		return nil, false
+	}
+	return obj, true
+}
+
+// contentHashName returns the name of the ConfigMap or Secret with the hash of its content appended. It fails if
+// the hashed name exceeds the max length of a name.
+func contentHashName(obj *unstructured.Unstructured) (string, error) {
+	hash, err := contentHash(obj)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s", obj.GetName(), hash)
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		return "", fmt.Errorf("the name %q of the %s is too long to append the content hash, it must be no more "+
+			"than %d characters", obj.GetName(), obj.GetKind(), validation.DNS1123SubdomainMaxLength-contentHashLength-1)
+	}
+	return name, nil
+}
+
+func (c *contentHashTransformer) Name() string {
+	return "content-hash"
+}
+
+func (c *contentHashTransformer) Transform(obj *unstructured.Unstructured) (bool, error) {
+	if isConfigMapOrSecret(obj) {
+		key := contentHashKey{kind: obj.GetKind(), namespace: obj.GetNamespace(), name: obj.GetName()}
+		if err := c.invalid[key]; err != nil {
+			return false, err
+		}
+		hashedName, ok := c.names[key]
+		if !ok {
+			return false, nil
+		}
+		annotations := obj.GetAnnotations()
+		annotations[ContentHashBaseNameAnnotation] = obj.GetName()
+		obj.SetAnnotations(annotations)
+		obj.SetName(hashedName)
+		return true, nil
+	}
+
+	podSpec, path, err := podSpecOf(obj)
+	if podSpec == nil || err != nil {
+		return false, err
+	}
+
+	changed := false
+	visitPodSpecReferences(podSpec, func(kind string, ref map[string]interface{}, field string) {
+		name, _ := ref[field].(string)
+		if hashedName, ok := c.names[contentHashKey{kind: kind, namespace: obj.GetNamespace(), name: name}]; ok {
+			ref[field] = hashedName
+			changed = true
+		}
+	})
+
+	if !changed {
+		return false, nil
+	}
+	return true, unstructured.SetNestedField(obj.Object, podSpec, path...)
+}
+
+// PodSpecReferences returns the names of the ConfigMaps and Secrets referenced by the pod spec in the volumes,
+// the environment variables and the imagePullSecrets.
+func PodSpecReferences(podSpec map[string]interface{}) (configMaps, secrets sets.Set[string]) {
+	configMaps, secrets = sets.New[string](), sets.New[string]()
+	visitPodSpecReferences(podSpec, func(kind string, ref map[string]interface{}, field string) {
+		name, ok := ref[field].(string)
+		if !ok {
+			return
+		}
+		if kind == "ConfigMap" {
+			configMaps.Insert(name)
+		} else {
+			secrets.Insert(name)
+		}
+	})
+	return configMaps, secrets
+}
+
+// visitPodSpecReferences calls visit with each reference to a ConfigMap or Secret in the pod spec, the name of
+// the referenced object is in the field of the ref.
+func visitPodSpecReferences(podSpec map[string]interface{}, visit func(kind string, ref map[string]interface{}, field string)) {
+	visitNested := func(obj map[string]interface{}, kind, field string, path ...string) {
+		ref, found, err := unstructured.NestedFieldNoCopy(obj, path...)
+		if !found || err != nil {
+			return
+		}
+		if refMap, ok := ref.(map[string]interface{}); ok {
+			visit(kind, refMap, field)
+		}
+	}
+
+	for _, v := range sliceOfMaps(podSpec["volumes"]) {
+		visitNested(v, "ConfigMap", "name", "configMap")
+		visitNested(v, "Secret", "secretName", "secret")
+		projected, _, _ := unstructured.NestedFieldNoCopy(v, "projected", "sources")
+		for _, source := range sliceOfMaps(projected) {
+			visitNested(source, "ConfigMap", "name", "configMap")
+			visitNested(source, "Secret", "name", "secret")
+		}
+	}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		for _, container := range sliceOfMaps(podSpec[field]) {
+			for _, env := range sliceOfMaps(container["env"]) {
+				visitNested(env, "ConfigMap", "name", "valueFrom", "configMapKeyRef")
+				visitNested(env, "Secret", "name", "valueFrom", "secretKeyRef")
+			}
+			for _, envFrom := range sliceOfMaps(container["envFrom"]) {
+				visitNested(envFrom, "ConfigMap", "name", "configMapRef")
+				visitNested(envFrom, "Secret", "name", "secretRef")
+			}
+		}
+	}
+
+	for _, pullSecret := range sliceOfMaps(podSpec["imagePullSecrets"]) {
+		visit("Secret", pullSecret, "name")
+	}
+}
+
+func sliceOfMaps(value interface{}) []map[string]interface{} {
+	items, _ := value.([]interface{})
+	var maps []map[string]interface{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+func isConfigMapOrSecret(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == "" && (gvk.Kind == "ConfigMap" || gvk.Kind == "Secret")
+}
+
+// contentHash returns the hash of the data of the ConfigMap or Secret.
+func contentHash(obj *unstructured.Unstructured) (string, error) {
+	content := map[string]interface{}{}
+	for _, field := range []string{"type", "data", "binaryData", "stringData"} {
+		if value, ok := obj.Object[field]; ok {
+			content[field] = value
+		}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:contentHashLength], nil
+}
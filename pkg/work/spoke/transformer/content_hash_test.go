@@ -0,0 +1,167 @@
+package transformer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func newManifests(t *testing.T, objs ...*unstructured.Unstructured) []workapiv1.Manifest {
+	var manifests []workapiv1.Manifest
+	for _, obj := range objs {
+		raw, err := obj.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifests = append(manifests, workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}})
+	}
+	return manifests
+}
+
+func newHashedConfigMap(data string) *unstructured.Unstructured {
+	cm := newConfigMap()
+	cm.SetAnnotations(map[string]string{ContentHashNameAnnotation: "true"})
+	cm.Object["data"] = map[string]interface{}{"config": data}
+	return cm
+}
+
+func newDeploymentWithReferences(t *testing.T) *unstructured.Unstructured {
+	deployment := newDeployment("quay.io/test")
+	podSpec := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(`{
+		"volumes": [
+			{"name": "v1", "configMap": {"name": "test"}},
+			{"name": "v2", "projected": {"sources": [{"configMap": {"name": "test"}}, {"secret": {"name": "test"}}]}}
+		],
+		"containers": [{
+			"name": "c",
+			"image": "quay.io/test",
+			"env": [{"name": "e", "valueFrom": {"configMapKeyRef": {"name": "test", "key": "config"}}}],
+			"envFrom": [{"configMapRef": {"name": "other"}}]
+		}]
+	}`), &podSpec); err != nil {
+		t.Fatal(err)
+	}
+	if err := unstructured.SetNestedMap(deployment.Object, podSpec, "spec", "template", "spec"); err != nil {
+		t.Fatal(err)
+	}
+	return deployment
+}
+
+func transformedName(t *testing.T, obj *unstructured.Unstructured) string {
+	if _, err := NewContentHashTransformer(newManifests(t, obj)).Transform(obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj.GetName()
+}
+
+func TestContentHashTransformer(t *testing.T) {
+	if NewContentHashTransformer(newManifests(t, newConfigMap(), newDeployment("quay.io/test"))) != nil {
+		t.Errorf("expected no transformer without the content hash named manifests")
+	}
+
+	cm := newHashedConfigMap("v1")
+	transformer := NewContentHashTransformer(newManifests(t, cm))
+	if changed, err := transformer.Transform(cm); err != nil || !changed {
+		t.Fatalf("expected the configmap renamed, but got %v %v", changed, err)
+	}
+	hashedName := cm.GetName()
+	if !strings.HasPrefix(hashedName, "test-") || len(hashedName) != len("test-")+contentHashLength {
+		t.Errorf("expected the name with the content hash, but got %s", hashedName)
+	}
+	if cm.GetAnnotations()[ContentHashBaseNameAnnotation] != "test" {
+		t.Errorf("expected the base name annotation, but got %v", cm.GetAnnotations())
+	}
+
+	// the name is stable with the same content, and changed with the content
+	if name := transformedName(t, newHashedConfigMap("v1")); name != hashedName {
+		t.Errorf("expected the same name %s with the same content, but got %s", hashedName, name)
+	}
+	if name := transformedName(t, newHashedConfigMap("v2")); name == hashedName {
+		t.Errorf("expected a new name with the changed content, but got %s", name)
+	}
+
+	// the references in the workloads of the same namespace are rewritten
+	deployment := newDeploymentWithReferences(t)
+	transformer = NewContentHashTransformer(newManifests(t, newHashedConfigMap("v1"), deployment))
+	if changed, err := transformer.Transform(deployment); err != nil || !changed {
+		t.Fatalf("expected the references rewritten, but got %v %v", changed, err)
+	}
+	podSpec, _, _ := unstructured.NestedMap(deployment.Object, "spec", "template", "spec")
+	configMaps, secrets := PodSpecReferences(podSpec)
+	if !configMaps.Has(hashedName) || !configMaps.Has("other") || configMaps.Has("test") || configMaps.Len() != 2 {
+		t.Errorf("expected the configmap references rewritten, but got %v", configMaps.UnsortedList())
+	}
+	if !secrets.Has("test") || secrets.Len() != 1 {
+		t.Errorf("expected the secret reference kept, but got %v", secrets.UnsortedList())
+	}
+
+	// the workloads in other namespaces are not changed
+	deployment = newDeploymentWithReferences(t)
+	deployment.SetNamespace("other")
+	if changed, err := transformer.Transform(deployment); err != nil || changed {
+		t.Errorf("expected the workload in other namespace unchanged, but got %v %v", changed, err)
+	}
+}
+
+func TestContentHashTransformerNameTooLong(t *testing.T) {
+	cm := newHashedConfigMap("v1")
+	cm.SetName(strings.Repeat("a", 253-contentHashLength))
+	if _, err := NewContentHashTransformer(newManifests(t, cm)).Transform(cm); err == nil {
+		t.Errorf("expected an error with the hashed name too long")
+	}
+
+	cm.SetName(strings.Repeat("a", 253-contentHashLength-1))
+	if name := transformedName(t, cm); len(name) != 253 {
+		t.Errorf("expected the hashed name of the max length, but got %s", name)
+	}
+}
+
+func TestAppliedName(t *testing.T) {
+	manifests := newManifests(t, newHashedConfigMap("v1"), newConfigMap())
+	hashedName := transformedName(t, newHashedConfigMap("v1"))
+
+	cases := []struct {
+		name         string
+		resourceMeta workapiv1.ManifestResourceMeta
+		expected     string
+	}{
+		{
+			name: "content hash named configmap",
+			resourceMeta: workapiv1.ManifestResourceMeta{
+				Ordinal: 0, Version: "v1", Resource: "configmaps", Namespace: "default", Name: "test"},
+			expected: hashedName,
+		},
+		{
+			name: "configmap without the annotation",
+			resourceMeta: workapiv1.ManifestResourceMeta{
+				Ordinal: 1, Version: "v1", Resource: "configmaps", Namespace: "default", Name: "test"},
+			expected: "test",
+		},
+		{
+			name: "stale resource meta of another manifest",
+			resourceMeta: workapiv1.ManifestResourceMeta{
+				Ordinal: 0, Version: "v1", Resource: "configmaps", Namespace: "default", Name: "other"},
+			expected: "other",
+		},
+		{
+			name: "ordinal out of range",
+			resourceMeta: workapiv1.ManifestResourceMeta{
+				Ordinal: 2, Version: "v1", Resource: "configmaps", Namespace: "default", Name: "test"},
+			expected: "test",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := AppliedName(manifests, c.resourceMeta); actual != c.expected {
+				t.Errorf("expected %s, but got %s", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package transformer
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RestartedAtPodTemplateAnnotation is the annotation set by kubectl rollout restart on the pod template of a
+// workload, the change of its value triggers a rolling restart of the pods.
+const RestartedAtPodTemplateAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// restartTransformer sets the restartedAt annotation on the pod templates of the Deployments, DaemonSets and
+// StatefulSets, which are the kinds kubectl rollout restart supports.
+type restartTransformer struct {
+	restartedAt string
+}
+
+// NewRestartTransformer returns a transformer setting the restartedAt annotation with the value on the pod
+// templates of the workloads. It returns nil if the value is empty. Since the transformation is deterministic,
+// the workloads are restarted only once the value is changed.
+func NewRestartTransformer(restartedAt string) Transformer {
+	if restartedAt == "" {
+		return nil
+	}
+	return &restartTransformer{restartedAt: restartedAt}
+}
+
+func (r *restartTransformer) Name() string {
+	return "restart"
+}
+
+func (r *restartTransformer) Transform(obj *unstructured.Unstructured) (bool, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != "apps" || (gvk.Kind != "Deployment" && gvk.Kind != "DaemonSet" && gvk.Kind != "StatefulSet") {
+		return false, nil
+	}
+
+	path := []string{"spec", "template", "metadata", "annotations"}
+	annotations, _, err := unstructured.NestedStringMap(obj.Object, path...)
+	if err != nil {
+		return false, err
+	}
+	if annotations[RestartedAtPodTemplateAnnotation] == r.restartedAt {
+		return false, nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[RestartedAtPodTemplateAnnotation] = r.restartedAt
+	return true, unstructured.SetNestedStringMap(obj.Object, annotations, path...)
+}
@@ -0,0 +1,128 @@
+package transformer
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newWorkload(apiVersion, kind string) *unstructured.Unstructured {
+	obj := newDeployment("quay.io/test")
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	return obj
+}
+
+func TestRestartTransformer(t *testing.T) {
+	if NewRestartTransformer("") != nil {
+		t.Errorf("expected no transformer without the restartedAt value")
+	}
+
+	restarted := newDeployment("quay.io/test")
+	if err := unstructured.SetNestedStringMap(restarted.Object, map[string]string{
+		"foo":                            "bar",
+		RestartedAtPodTemplateAnnotation: "2026-10-01T00:00:00Z",
+	}, "spec", "template", "metadata", "annotations"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                string
+		obj                 *unstructured.Unstructured
+		restartedAt         string
+		expectedChanged     bool
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:                "restart a deployment",
+			obj:                 newWorkload("apps/v1", "Deployment"),
+			restartedAt:         "2026-10-16T00:00:00Z",
+			expectedChanged:     true,
+			expectedAnnotations: map[string]string{RestartedAtPodTemplateAnnotation: "2026-10-16T00:00:00Z"},
+		},
+		{
+			name:                "restart a daemonset",
+			obj:                 newWorkload("apps/v1", "DaemonSet"),
+			restartedAt:         "2026-10-16T00:00:00Z",
+			expectedChanged:     true,
+			expectedAnnotations: map[string]string{RestartedAtPodTemplateAnnotation: "2026-10-16T00:00:00Z"},
+		},
+		{
+			name:                "restart a statefulset",
+			obj:                 newWorkload("apps/v1", "StatefulSet"),
+			restartedAt:         "2026-10-16T00:00:00Z",
+			expectedChanged:     true,
+			expectedAnnotations: map[string]string{RestartedAtPodTemplateAnnotation: "2026-10-16T00:00:00Z"},
+		},
+		{
+			name:            "restart a restarted deployment",
+			obj:             restarted.DeepCopy(),
+			restartedAt:     "2026-10-16T00:00:00Z",
+			expectedChanged: true,
+			expectedAnnotations: map[string]string{
+				"foo":                            "bar",
+				RestartedAtPodTemplateAnnotation: "2026-10-16T00:00:00Z",
+			},
+		},
+		{
+			name:        "value not changed",
+			obj:         restarted.DeepCopy(),
+			restartedAt: "2026-10-01T00:00:00Z",
+			expectedAnnotations: map[string]string{
+				"foo":                            "bar",
+				RestartedAtPodTemplateAnnotation: "2026-10-01T00:00:00Z",
+			},
+		},
+		{
+			name:        "replicaset is not restarted",
+			obj:         newWorkload("apps/v1", "ReplicaSet"),
+			restartedAt: "2026-10-16T00:00:00Z",
+		},
+		{
+			name:        "job is not restarted",
+			obj:         newWorkload("batch/v1", "Job"),
+			restartedAt: "2026-10-16T00:00:00Z",
+		},
+		{
+			name:        "cronjob is not restarted",
+			obj:         newCronJob("quay.io/test"),
+			restartedAt: "2026-10-16T00:00:00Z",
+		},
+		{
+			name:        "not a workload",
+			obj:         newConfigMap(),
+			restartedAt: "2026-10-16T00:00:00Z",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			transformer := NewRestartTransformer(c.restartedAt)
+			changed, err := transformer.Transform(c.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != c.expectedChanged {
+				t.Errorf("expected changed %v, but got %v", c.expectedChanged, changed)
+			}
+			annotations, _, _ := unstructured.NestedStringMap(c.obj.Object, "spec", "template", "metadata", "annotations")
+			if len(annotations) != len(c.expectedAnnotations) {
+				t.Errorf("expected pod template annotations %v, but got %v", c.expectedAnnotations, annotations)
+			}
+			for key, value := range c.expectedAnnotations {
+				if annotations[key] != value {
+					t.Errorf("expected pod template annotations %v, but got %v", c.expectedAnnotations, annotations)
+				}
+			}
+
+			// the transformation is idempotent
+			changed, err = transformer.Transform(c.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed {
+				t.Errorf("expected the transformation to be idempotent")
+			}
+		})
+	}
+}
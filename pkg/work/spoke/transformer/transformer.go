@@ -0,0 +1,215 @@
+package transformer
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// TransformedByAnnotation is the annotation set on the transformed manifests with the comma separated names
+// of the transformers which changed the manifest, in the order they are applied.
+// TODO move this to the api repo
+const TransformedByAnnotation = "work.open-cluster-management.io/transformed-by"
+
+// Transformer mutates a manifest after it is decoded and before it is applied on the managed cluster. The
+// transformation must be deterministic, otherwise the manifest would be updated in every reconcile.
+type Transformer interface {
+	// Name returns the name of the transformer recorded in the TransformedByAnnotation.
+	Name() string
+	// Transform mutates the manifest and returns true if the manifest is changed.
+	Transform(obj *unstructured.Unstructured) (bool, error)
+}
+
+// Transformers is a list of transformers applied in order.
+type Transformers []Transformer
+
+// Transform applies the transformers on the manifest in order, and records the names of the transformers
+// which changed the manifest in the TransformedByAnnotation.
+func (t Transformers) Transform(obj *unstructured.Unstructured) error {
+	var applied []string
+	for _, transformer := range t {
+		changed, err := transformer.Transform(obj)
+		if err != nil {
+			return err
+		}
+		if changed {
+			applied = append(applied, transformer.Name())
+		}
+	}
+
+	if len(applied) == 0 {
+		return nil
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TransformedByAnnotation] = strings.Join(applied, ",")
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// NewTransformers returns the built-in transformers enabled by the agent flags in a fixed order: the
+// registry rewrite is applied before the imagePullSecrets injection.
+func NewTransformers(registryMapping map[string]string, imagePullSecrets []string) Transformers {
+	var transformers Transformers
+	if len(registryMapping) > 0 {
+		transformers = append(transformers, NewRegistryRewriteTransformer(registryMapping))
+	}
+	if len(imagePullSecrets) > 0 {
+		transformers = append(transformers, NewImagePullSecretsTransformer(imagePullSecrets))
+	}
+	return transformers
+}
+
+// registryRewriteTransformer rewrites the registry of the container images in the workloads with a mapping
+// from the source registry to the mirror registry.
+type registryRewriteTransformer struct {
+	// sources are sorted from the longest, so the most specific source takes precedence.
+	sources []string
+	mapping map[string]string
+}
+
+// NewRegistryRewriteTransformer returns a transformer rewriting the image registries. The key of the mapping is
+// the source registry, with an optional repository path, e.g. quay.io or quay.io/open-cluster-management, and the
+// value is the mirror replacing it.
+func NewRegistryRewriteTransformer(mapping map[string]string) Transformer {
+	sources := []string{}
+	for source := range mapping {
+		sources = append(sources, strings.TrimSuffix(source, "/"))
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if len(sources[i]) != len(sources[j]) {
+			return len(sources[i]) > len(sources[j])
+		}
+		return sources[i] < sources[j]
+	})
+
+	trimmed := map[string]string{}
+	for source, mirror := range mapping {
+		trimmed[strings.TrimSuffix(source, "/")] = strings.TrimSuffix(mirror, "/")
+	}
+	return &registryRewriteTransformer{sources: sources, mapping: trimmed}
+}
+
+func (r *registryRewriteTransformer) Name() string {
+	return "registry-rewrite"
+}
+
+func (r *registryRewriteTransformer) Transform(obj *unstructured.Unstructured) (bool, error) {
+	podSpec, path, err := podSpecOf(obj)
+	if podSpec == nil || err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, ok := podSpec[field].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, ok := container["image"].(string)
+			if !ok {
+				continue
+			}
+			if rewritten := r.rewrite(image); rewritten != image {
+				container["image"] = rewritten
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+	return true, unstructured.SetNestedField(obj.Object, podSpec, path...)
+}
+
+func (r *registryRewriteTransformer) rewrite(image string) string {
+	for _, source := range r.sources {
+		if strings.HasPrefix(image, source+"/") {
+			return r.mapping[source] + strings.TrimPrefix(image, source)
+		}
+	}
+	return image
+}
+
+// imagePullSecretsTransformer adds the imagePullSecrets to the pod spec of the workloads.
+type imagePullSecretsTransformer struct {
+	secrets []string
+}
+
+// NewImagePullSecretsTransformer returns a transformer adding the imagePullSecrets to the workloads.
+func NewImagePullSecretsTransformer(secrets []string) Transformer {
+	return &imagePullSecretsTransformer{secrets: secrets}
+}
+
+func (p *imagePullSecretsTransformer) Name() string {
+	return "image-pull-secrets"
+}
+
+func (p *imagePullSecretsTransformer) Transform(obj *unstructured.Unstructured) (bool, error) {
+	podSpec, path, err := podSpecOf(obj)
+	if podSpec == nil || err != nil {
+		return false, err
+	}
+
+	pullSecrets, _ := podSpec["imagePullSecrets"].([]interface{})
+	existing := sets.New[string]()
+	for _, s := range pullSecrets {
+		if secret, ok := s.(map[string]interface{}); ok {
+			if name, ok := secret["name"].(string); ok {
+				existing.Insert(name)
+			}
+		}
+	}
+
+	changed := false
+	for _, name := range p.secrets {
+		if existing.Has(name) {
+			continue
+		}
+		pullSecrets = append(pullSecrets, map[string]interface{}{"name": name})
+		existing.Insert(name)
+		changed = true
+	}
+
+	if !changed {
+		return false, nil
+	}
+	podSpec["imagePullSecrets"] = pullSecrets
+	return true, unstructured.SetNestedField(obj.Object, podSpec, path...)
+}
+
+// podSpecOf returns a copy of the pod spec of the workload and its path, it returns nil if the manifest is
+// not a workload.
+func podSpecOf(obj *unstructured.Unstructured) (map[string]interface{}, []string, error) {
+	var path []string
+	gvk := obj.GroupVersionKind()
+	switch {
+	case gvk.Group == "" && gvk.Kind == "Pod":
+		path = []string{"spec"}
+	case gvk.Group == "batch" && gvk.Kind == "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case gvk.Group == "apps" && (gvk.Kind == "Deployment" || gvk.Kind == "StatefulSet" ||
+		gvk.Kind == "DaemonSet" || gvk.Kind == "ReplicaSet"),
+		gvk.Group == "batch" && gvk.Kind == "Job",
+		gvk.Group == "" && gvk.Kind == "ReplicationController":
+		path = []string{"spec", "template", "spec"}
+	default:
+		return nil, nil, nil
+	}
+
+	podSpec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if !found || err != nil {
+		return nil, nil, err
+	}
+	return podSpec, path, nil
+}
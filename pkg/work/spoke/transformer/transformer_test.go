@@ -0,0 +1,286 @@
+package transformer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newDeployment(images ...string) *unstructured.Unstructured {
+	containers := []interface{}{}
+	for _, image := range images {
+		containers = append(containers, map[string]interface{}{"name": "c", "image": image})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": containers,
+				},
+			},
+		},
+	}}
+}
+
+func newCronJob(image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"initContainers": []interface{}{map[string]interface{}{"name": "c", "image": image}},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func newConfigMap() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"data":       map[string]interface{}{"image": "quay.io/test"},
+	}}
+}
+
+func images(t *testing.T, obj *unstructured.Unstructured, path ...string) []string {
+	containers, _, err := unstructured.NestedSlice(obj.Object, path...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := []string{}
+	for _, c := range containers {
+		result = append(result, c.(map[string]interface{})["image"].(string))
+	}
+	return result
+}
+
+func TestRegistryRewriteTransformer(t *testing.T) {
+	transformer := NewRegistryRewriteTransformer(map[string]string{
+		"quay.io":                          "mirror.example.com/quay",
+		"quay.io/open-cluster-management/": "mirror.example.com/ocm",
+	})
+
+	cases := []struct {
+		name            string
+		obj             *unstructured.Unstructured
+		path            []string
+		expectedChanged bool
+		expectedImages  []string
+	}{
+		{
+			name:            "rewrite the images of a deployment",
+			obj:             newDeployment("quay.io/test/app:v1", "quay.io/open-cluster-management/work:latest", "docker.io/nginx"),
+			path:            []string{"spec", "template", "spec", "containers"},
+			expectedChanged: true,
+			expectedImages:  []string{"mirror.example.com/quay/test/app:v1", "mirror.example.com/ocm/work:latest", "docker.io/nginx"},
+		},
+		{
+			name:            "rewrite the init containers of a cronjob",
+			obj:             newCronJob("quay.io/test/app:v1"),
+			path:            []string{"spec", "jobTemplate", "spec", "template", "spec", "initContainers"},
+			expectedChanged: true,
+			expectedImages:  []string{"mirror.example.com/quay/test/app:v1"},
+		},
+		{
+			name:           "the registry matches by the whole path segment",
+			obj:            newDeployment("quay.io.example.com/test/app:v1"),
+			path:           []string{"spec", "template", "spec", "containers"},
+			expectedImages: []string{"quay.io.example.com/test/app:v1"},
+		},
+		{
+			name: "not a workload",
+			obj:  newConfigMap(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			changed, err := transformer.Transform(c.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != c.expectedChanged {
+				t.Errorf("expected changed %v, but got %v", c.expectedChanged, changed)
+			}
+			if c.path == nil {
+				return
+			}
+			if actual := images(t, c.obj, c.path...); !reflect.DeepEqual(actual, c.expectedImages) {
+				t.Errorf("expected images %v, but got %v", c.expectedImages, actual)
+			}
+		})
+	}
+}
+
+func TestImagePullSecretsTransformer(t *testing.T) {
+	transformer := NewImagePullSecretsTransformer([]string{"secret1", "secret2"})
+
+	existing := newDeployment("quay.io/test/app:v1")
+	if err := unstructured.SetNestedSlice(existing.Object, []interface{}{
+		map[string]interface{}{"name": "secret0"},
+		map[string]interface{}{"name": "secret2"},
+	}, "spec", "template", "spec", "imagePullSecrets"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name            string
+		obj             *unstructured.Unstructured
+		expectedChanged bool
+		expectedSecrets []interface{}
+	}{
+		{
+			name:            "inject the secrets",
+			obj:             newDeployment("quay.io/test/app:v1"),
+			expectedChanged: true,
+			expectedSecrets: []interface{}{
+				map[string]interface{}{"name": "secret1"},
+				map[string]interface{}{"name": "secret2"},
+			},
+		},
+		{
+			name:            "keep the existing secrets",
+			obj:             existing,
+			expectedChanged: true,
+			expectedSecrets: []interface{}{
+				map[string]interface{}{"name": "secret0"},
+				map[string]interface{}{"name": "secret2"},
+				map[string]interface{}{"name": "secret1"},
+			},
+		},
+		{
+			name: "not a workload",
+			obj:  newConfigMap(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			changed, err := transformer.Transform(c.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != c.expectedChanged {
+				t.Errorf("expected changed %v, but got %v", c.expectedChanged, changed)
+			}
+			secrets, _, _ := unstructured.NestedSlice(c.obj.Object, "spec", "template", "spec", "imagePullSecrets")
+			if !reflect.DeepEqual(secrets, c.expectedSecrets) {
+				t.Errorf("expected secrets %v, but got %v", c.expectedSecrets, secrets)
+			}
+
+			// the transformation is idempotent
+			changed, err = transformer.Transform(c.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed {
+				t.Errorf("expected the transformation to be idempotent")
+			}
+		})
+	}
+}
+
+type fakeTransformer struct {
+	name string
+	fn   func(obj *unstructured.Unstructured) (bool, error)
+}
+
+func (f *fakeTransformer) Name() string {
+	return f.name
+}
+
+func (f *fakeTransformer) Transform(obj *unstructured.Unstructured) (bool, error) {
+	return f.fn(obj)
+}
+
+func TestTransformers(t *testing.T) {
+	setImage := func(image string) func(obj *unstructured.Unstructured) (bool, error) {
+		return func(obj *unstructured.Unstructured) (bool, error) {
+			return true, unstructured.SetNestedField(obj.Object, image, "spec", "image")
+		}
+	}
+	noop := func(obj *unstructured.Unstructured) (bool, error) {
+		return false, nil
+	}
+
+	cases := []struct {
+		name               string
+		transformers       Transformers
+		expectedImage      string
+		expectedAnnotation string
+		expectedErr        bool
+	}{
+		{
+			name: "no transformers",
+		},
+		{
+			name: "transformers are applied in order",
+			transformers: Transformers{
+				&fakeTransformer{name: "first", fn: setImage("first")},
+				&fakeTransformer{name: "noop", fn: noop},
+				&fakeTransformer{name: "second", fn: setImage("second")},
+			},
+			expectedImage:      "second",
+			expectedAnnotation: "first,second",
+		},
+		{
+			name: "transformer fails",
+			transformers: Transformers{
+				&fakeTransformer{name: "failed", fn: func(obj *unstructured.Unstructured) (bool, error) {
+					return false, errors.New("failed")
+				}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			obj := newConfigMap()
+			err := c.transformers.Transform(obj)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, but got %v", c.expectedErr, err)
+			}
+			image, _, _ := unstructured.NestedString(obj.Object, "spec", "image")
+			if image != c.expectedImage {
+				t.Errorf("expected image %q, but got %q", c.expectedImage, image)
+			}
+			if actual := obj.GetAnnotations()[TransformedByAnnotation]; actual != c.expectedAnnotation {
+				t.Errorf("expected annotation %q, but got %q", c.expectedAnnotation, actual)
+			}
+		})
+	}
+}
+
+func TestNewTransformers(t *testing.T) {
+	obj := newDeployment("quay.io/test/app:v1")
+	transformers := NewTransformers(map[string]string{"quay.io": "mirror.example.com"}, []string{"secret1"})
+	if err := transformers.Transform(obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := images(t, obj, "spec", "template", "spec", "containers"); actual[0] != "mirror.example.com/test/app:v1" {
+		t.Errorf("unexpected image %v", actual)
+	}
+	expected := "registry-rewrite,image-pull-secrets"
+	if actual := obj.GetAnnotations()[TransformedByAnnotation]; actual != expected {
+		t.Errorf("expected annotation %q, but got %q", expected, actual)
+	}
+
+	if transformers := NewTransformers(nil, nil); len(transformers) != 0 {
+		t.Errorf("expected no transformers, but got %v", transformers)
+	}
+}
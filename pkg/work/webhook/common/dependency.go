@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/metadata/metadatalister"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+// DependencyLister finds the cycles of the dependencies from the metadata of a resource cached by an informer,
+// so the admissions neither list the objects from the apiserver nor cache their specs.
+type DependencyLister struct {
+	resource string
+	lister   metadatalister.Lister
+	synced   cache.InformerSynced
+}
+
+// NewDependencyLister returns a DependencyLister of the objects in the lister.
+func NewDependencyLister(resource string, lister metadatalister.Lister, synced cache.InformerSynced) *DependencyLister {
+	return &DependencyLister{resource: resource, lister: lister, synced: synced}
+}
+
+// StartDependencyLister returns a DependencyLister of the resource whose informer is started with the manager,
+// the webhook server is not ready until the informer is synced.
+func StartDependencyLister(mgr ctrl.Manager, gvr schema.GroupVersionResource) (*DependencyLister, error) {
+	client, err := metadata.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	informer := metadatainformer.NewFilteredMetadataInformer(
+		client, gvr, metav1.NamespaceAll, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil)
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		informer.Informer().Run(ctx.Done())
+		return nil
+	})); err != nil {
+		return nil, err
+	}
+
+	l := NewDependencyLister(gvr.Resource,
+		metadatalister.New(informer.Informer().GetIndexer(), gvr), informer.Informer().HasSynced)
+	if err := mgr.AddReadyzCheck(gvr.Resource+"-synced", func(_ *http.Request) error {
+		if !l.synced() {
+			return fmt.Errorf("the %s are not synced", gvr.Resource)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ValidateNoCycle rejects the object if its dependencies form a cycle with the other objects in its namespace.
+// Only the objects on the path of the dependencies are read from the cache.
+func (l *DependencyLister) ValidateNoCycle(obj metav1.Object) error {
+	if !l.synced() {
+		return apierrors.NewServiceUnavailable(fmt.Sprintf("the %s are not synced", l.resource))
+	}
+
+	lister := l.lister.Namespace(obj.GetNamespace())
+	cycle := helper.FindDependencyCycle(obj.GetName(), helper.GetDependencies(obj), func(name string) []string {
+		dependency, err := lister.Get(name)
+		if err != nil {
+			// the dependency is not created yet
+			return nil
+		}
+		return helper.GetDependencies(dependency)
+	})
+	if len(cycle) > 0 {
+		return apierrors.NewBadRequest(fmt.Sprintf("annotation %s: the dependencies form a cycle %s",
+			helper.DependsOnAnnotation, strings.Join(cycle, " -> ")))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+// serverSetFields are the fields set by the api server on the resources. They are usually copied into the
+// manifests together with the resources fetched from a cluster, and make the apply on the managed cluster fail.
+var serverSetFields = [][]string{
+	{"status"},
+	{"metadata", "managedFields"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "uid"},
+	{"metadata", "resourceVersion"},
+}
+
+// StripManifests removes the fields set by the api server from the manifests, and returns the warnings listing
+// the fields stripped from each manifest. The manifests which cannot be decoded are kept as they are and left to
+// the validation.
+func StripManifests(manifests []workv1.Manifest) ([]workv1.Manifest, []string) {
+	var warnings []string
+	stripped := make([]workv1.Manifest, 0, len(manifests))
+	for _, manifest := range manifests {
+		unstructuredObj := &unstructured.Unstructured{}
+		if err := unstructuredObj.UnmarshalJSON(manifest.Raw); err != nil {
+			stripped = append(stripped, manifest)
+			continue
+		}
+
+		var fields []string
+		for _, field := range serverSetFields {
+			if _, found, _ := unstructured.NestedFieldNoCopy(unstructuredObj.Object, field...); !found {
+				continue
+			}
+			unstructured.RemoveNestedField(unstructuredObj.Object, field...)
+			fields = append(fields, strings.Join(field, "."))
+		}
+		if len(fields) == 0 {
+			stripped = append(stripped, manifest)
+			continue
+		}
+
+		raw, err := unstructuredObj.MarshalJSON()
+		if err != nil {
+			stripped = append(stripped, manifest)
+			continue
+		}
+		stripped = append(stripped, workv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}})
+		warnings = append(warnings, fmt.Sprintf("%s are stripped from the manifest %s %s",
+			strings.Join(fields, ", "), unstructuredObj.GetKind(), manifestName(unstructuredObj)))
+	}
+	return stripped, warnings
+}
+
+func manifestName(obj *unstructured.Unstructured) string {
+	if len(obj.GetNamespace()) == 0 {
+		return obj.GetName()
+	}
+	return fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+}
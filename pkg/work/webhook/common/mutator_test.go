@@ -0,0 +1,93 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+func toManifest(t *testing.T, object map[string]interface{}) workv1.Manifest {
+	raw, err := (&unstructured.Unstructured{Object: object}).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := workv1.Manifest{}
+	manifest.Raw = raw
+	return manifest
+}
+
+func TestStripManifests(t *testing.T) {
+	cleanConfigMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"namespace": "test",
+			"name":      "test",
+		},
+		"data": map[string]interface{}{"a": "b"},
+	}
+	pollutedConfigMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"namespace":         "test",
+			"name":              "test",
+			"uid":               "d0b9a3b4-7a6c-4d6f-9f0a-3c1b2a4e5f60",
+			"resourceVersion":   "12345",
+			"creationTimestamp": "2023-01-01T00:00:00Z",
+			"managedFields":     []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"data":   map[string]interface{}{"a": "b"},
+		"status": map[string]interface{}{},
+	}
+
+	cases := []struct {
+		name              string
+		manifests         []workv1.Manifest
+		expectedManifests []workv1.Manifest
+		expectedWarnings  []string
+	}{
+		{
+			name:              "clean manifests",
+			manifests:         []workv1.Manifest{toManifest(t, cleanConfigMap)},
+			expectedManifests: []workv1.Manifest{toManifest(t, cleanConfigMap)},
+		},
+		{
+			name:              "polluted manifests",
+			manifests:         []workv1.Manifest{toManifest(t, pollutedConfigMap), toManifest(t, cleanConfigMap)},
+			expectedManifests: []workv1.Manifest{toManifest(t, cleanConfigMap), toManifest(t, cleanConfigMap)},
+			expectedWarnings: []string{
+				"status, metadata.managedFields, metadata.creationTimestamp, metadata.uid, metadata.resourceVersion " +
+					"are stripped from the manifest ConfigMap test/test",
+			},
+		},
+		{
+			name: "invalid manifests are left to the validation",
+			manifests: []workv1.Manifest{func() workv1.Manifest {
+				manifest := workv1.Manifest{}
+				manifest.Raw = []byte("invalid")
+				return manifest
+			}()},
+			expectedManifests: []workv1.Manifest{func() workv1.Manifest {
+				manifest := workv1.Manifest{}
+				manifest.Raw = []byte("invalid")
+				return manifest
+			}()},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			manifests, warnings := StripManifests(c.manifests)
+			if !reflect.DeepEqual(manifests, c.expectedManifests) {
+				t.Errorf("expected manifests %s, but got %s", c.expectedManifests, manifests)
+			}
+			if !reflect.DeepEqual(warnings, c.expectedWarnings) {
+				t.Errorf("expected warnings %v, but got %v", c.expectedWarnings, warnings)
+			}
+		})
+	}
+}
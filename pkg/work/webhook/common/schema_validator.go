@@ -0,0 +1,291 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/managedfields"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/metadata/metadatalister"
+	"k8s.io/client-go/openapi"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// ManifestSchemaValidationAnnotation is the annotation on a namespace to validate the manifests of the
+	// manifestworks and manifestworkreplicasets in the namespace against the OpenAPI schemas served by the hub.
+	// With the value "warn", the schema violations are returned as the admission warnings, and with the value
+	// "enforce", the request is rejected. The manifests are not validated if the annotation is not set.
+	// TODO move this to the api repo
+	ManifestSchemaValidationAnnotation = "work.open-cluster-management.io/manifest-schema-validation"
+
+	SchemaValidationModeWarn    = "warn"
+	SchemaValidationModeEnforce = "enforce"
+)
+
+// SchemaCacheTTL is how long the OpenAPI schemas of the hub are cached, so the schemas of the CRDs installed on
+// the hub are used after at most SchemaCacheTTL.
+var SchemaCacheTTL = 10 * time.Minute
+
+// SchemaValidationMode returns the schema validation mode of the namespace, it is empty if the manifests in the
+// namespace are not validated.
+func SchemaValidationMode(namespace metav1.Object) (string, error) {
+	mode, ok := namespace.GetAnnotations()[ManifestSchemaValidationAnnotation]
+	switch {
+	case !ok:
+		return "", nil
+	case mode == SchemaValidationModeWarn, mode == SchemaValidationModeEnforce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid value %q of annotation %s on namespace %s, it should be %s or %s",
+			mode, ManifestSchemaValidationAnnotation, namespace.GetName(), SchemaValidationModeWarn, SchemaValidationModeEnforce)
+	}
+}
+
+// SchemaValidator validates the manifests against the OpenAPI v3 schemas served by the hub. The unknown fields
+// and the fields not matching the structural schemas are reported, and the manifests of the GVKs unknown to the
+// hub are skipped since they may be served by the managed clusters only.
+//
+// The schema validation modes are read from the metadata of the namespaces cached by an informer, so the
+// admissions do not get the namespaces from the apiserver.
+type SchemaValidator struct {
+	client           openapi.Client
+	clock            clock.Clock
+	namespaceLister  metadatalister.Lister
+	namespacesSynced cache.InformerSynced
+
+	// lock guards the cached schemas only, the schemas are fetched from the hub without holding it.
+	lock    sync.Mutex
+	paths   map[string]openapi.GroupVersion
+	expiry  time.Time
+	schemas map[string]*groupVersionSchema
+}
+
+// groupVersionSchema is the type converter built from the OpenAPI schema of a group version, and the kinds
+// defined in the schema.
+type groupVersionSchema struct {
+	converter managedfields.TypeConverter
+	kinds     sets.Set[schema.GroupVersionKind]
+}
+
+// NewSchemaValidator returns a SchemaValidator with the OpenAPI v3 client of the hub and the lister of the
+// namespace metadata.
+func NewSchemaValidator(client openapi.Client, namespaceLister metadatalister.Lister,
+	namespacesSynced cache.InformerSynced) *SchemaValidator {
+	return &SchemaValidator{
+		client:           client,
+		clock:            clock.RealClock{},
+		namespaceLister:  namespaceLister,
+		namespacesSynced: namespacesSynced,
+	}
+}
+
+// StartSchemaValidator returns a SchemaValidator whose namespace informer is started with the manager.
+func StartSchemaValidator(mgr ctrl.Manager, client openapi.Client) (*SchemaValidator, error) {
+	metadataClient, err := metadata.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	gvr := corev1.SchemeGroupVersion.WithResource("namespaces")
+	informer := metadatainformer.NewFilteredMetadataInformer(
+		metadataClient, gvr, metav1.NamespaceAll, 0, cache.Indexers{}, nil)
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		informer.Informer().Run(ctx.Done())
+		return nil
+	})); err != nil {
+		return nil, err
+	}
+
+	return NewSchemaValidator(client,
+		metadatalister.New(informer.Informer().GetIndexer(), gvr), informer.Informer().HasSynced), nil
+}
+
+// ValidateManifests returns the schema violations of the manifests, each of them is prefixed with the index,
+// the kind and the name of the manifest and the path of the field.
+func (v *SchemaValidator) ValidateManifests(manifests []workv1.Manifest) ([]string, error) {
+	var violations []string
+	for index, manifest := range manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			return nil, err
+		}
+
+		converter, err := v.typeConverter(obj.GroupVersionKind())
+		if err != nil {
+			return nil, err
+		}
+		if converter == nil {
+			// the kind is unknown to the hub
+			continue
+		}
+
+		_, err = converter.ObjectToTyped(obj)
+		var validationErrs typed.ValidationErrors
+		switch {
+		case err == nil:
+			continue
+		case errors.As(err, &validationErrs):
+			for _, validationErr := range validationErrs {
+				violations = append(violations, fmt.Sprintf("manifests[%d] %s %s: %s",
+					index, obj.GetKind(), obj.GetName(), validationErr.Error()))
+			}
+		default:
+			violations = append(violations, fmt.Sprintf("manifests[%d] %s %s: %v",
+				index, obj.GetKind(), obj.GetName(), err))
+		}
+	}
+	return violations, nil
+}
+
+// typeConverter returns the type converter of the kind, it returns nil if the kind is not served by the hub.
+func (v *SchemaValidator) typeConverter(gvk schema.GroupVersionKind) (managedfields.TypeConverter, error) {
+	paths, schemas, err := v.groupVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	path := "apis/" + gvk.GroupVersion().String()
+	if len(gvk.Group) == 0 {
+		path = "api/" + gvk.Version
+	}
+	v.lock.Lock()
+	gvSchema, ok := schemas[path]
+	v.lock.Unlock()
+	if !ok {
+		groupVersion, ok := paths[path]
+		if !ok {
+			return nil, nil
+		}
+		if gvSchema, err = newGroupVersionSchema(path, groupVersion); err != nil {
+			return nil, err
+		}
+		v.lock.Lock()
+		schemas[path] = gvSchema
+		v.lock.Unlock()
+	}
+
+	if !gvSchema.kinds.Has(gvk) {
+		return nil, nil
+	}
+	return gvSchema.converter, nil
+}
+
+// groupVersions returns the cached openapi paths of the hub and the schemas built from them, the paths are
+// fetched again once they expire.
+func (v *SchemaValidator) groupVersions() (map[string]openapi.GroupVersion, map[string]*groupVersionSchema, error) {
+	v.lock.Lock()
+	if v.clock.Now().Before(v.expiry) {
+		defer v.lock.Unlock()
+		return v.paths, v.schemas, nil
+	}
+	v.lock.Unlock()
+
+	paths, err := v.client.Paths()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the openapi paths: %w", err)
+	}
+	schemas := map[string]*groupVersionSchema{}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.paths, v.schemas, v.expiry = paths, schemas, v.clock.Now().Add(SchemaCacheTTL)
+	return paths, schemas, nil
+}
+
+// newGroupVersionSchema fetches the OpenAPI schema of a group version and builds the type converter from it.
+func newGroupVersionSchema(path string, groupVersion openapi.GroupVersion) (*groupVersionSchema, error) {
+	data, err := groupVersion.Schema("application/json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the openapi schema of %s: %w", path, err)
+	}
+	doc := &spec3.OpenAPI{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("failed to decode the openapi schema of %s: %w", path, err)
+	}
+	gvSchema := &groupVersionSchema{kinds: sets.New[schema.GroupVersionKind]()}
+	if doc.Components == nil {
+		return gvSchema, nil
+	}
+
+	for _, s := range doc.Components.Schemas {
+		gvks, _ := s.Extensions["x-kubernetes-group-version-kind"].([]interface{})
+		for _, gvk := range gvks {
+			gvkMap, ok := gvk.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			group, _ := gvkMap["group"].(string)
+			version, _ := gvkMap["version"].(string)
+			kind, _ := gvkMap["kind"].(string)
+			gvSchema.kinds.Insert(schema.GroupVersionKind{Group: group, Version: version, Kind: kind})
+		}
+	}
+
+	converter, err := managedfields.NewTypeConverter(doc.Components.Schemas, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the type converter of %s: %w", path, err)
+	}
+	gvSchema.converter = converter
+	return gvSchema, nil
+}
+
+// ValidateManifestSchemas validates the manifests with the schema validation mode of the namespace. The schema
+// violations are returned as the warnings in the warn mode, or as the error in the enforce mode. The manifests
+// are admitted with a warning if they cannot be validated, since the validation is optional.
+func ValidateManifestSchemas(validator *SchemaValidator, namespace string,
+	manifests []workv1.Manifest) (admission.Warnings, error) {
+	if validator == nil {
+		return nil, nil
+	}
+	if !validator.namespacesSynced() {
+		return admission.Warnings{"skip validating the manifest schemas: the namespaces are not synced"}, nil
+	}
+
+	ns, err := validator.namespaceLister.Get(namespace)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, nil
+	case err != nil:
+		return admission.Warnings{fmt.Sprintf("skip validating the manifest schemas: %v", err)}, nil
+	}
+	mode, err := SchemaValidationMode(ns)
+	if err != nil {
+		return admission.Warnings{fmt.Sprintf("skip validating the manifest schemas: %v", err)}, nil
+	}
+	if len(mode) == 0 {
+		return nil, nil
+	}
+
+	violations, err := validator.ValidateManifests(manifests)
+	switch {
+	case err != nil:
+		return admission.Warnings{fmt.Sprintf("skip validating the manifest schemas: %v", err)}, nil
+	case len(violations) == 0:
+		return nil, nil
+	case mode == SchemaValidationModeEnforce:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the manifests do not match the schemas: %s",
+			strings.Join(violations, "; ")))
+	default:
+		return violations, nil
+	}
+}
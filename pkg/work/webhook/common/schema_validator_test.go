@@ -0,0 +1,255 @@
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/metadata/metadatalister"
+	"k8s.io/client-go/openapi"
+	"k8s.io/client-go/tools/cache"
+	testingclock "k8s.io/utils/clock/testing"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+const appsV1Schema = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Kubernetes", "version": "v1.27.2"},
+  "paths": {},
+  "components": {
+    "schemas": {
+      "io.k8s.api.apps.v1.Deployment": {
+        "type": "object",
+        "properties": {
+          "apiVersion": {"type": "string"},
+          "kind": {"type": "string"},
+          "metadata": {"allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}]},
+          "spec": {"allOf": [{"$ref": "#/components/schemas/io.k8s.api.apps.v1.DeploymentSpec"}]}
+        },
+        "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1"}]
+      },
+      "io.k8s.api.apps.v1.DeploymentSpec": {
+        "type": "object",
+        "properties": {
+          "replicas": {"type": "integer", "format": "int32"}
+        }
+      },
+      "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "namespace": {"type": "string"}
+        }
+      }
+    }
+  }
+}`
+
+type fakeGroupVersion struct {
+	schema string
+}
+
+func (f *fakeGroupVersion) Schema(contentType string) ([]byte, error) {
+	return []byte(f.schema), nil
+}
+
+type fakeOpenAPIClient struct {
+	calls int
+}
+
+func (f *fakeOpenAPIClient) Paths() (map[string]openapi.GroupVersion, error) {
+	f.calls++
+	return map[string]openapi.GroupVersion{
+		"apis/apps/v1": &fakeGroupVersion{schema: appsV1Schema},
+	}, nil
+}
+
+func newSpecManifest(apiVersion, kind, name string, spec map[string]interface{}) workv1.Manifest {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		"spec":       spec,
+	}}
+	raw, _ := obj.MarshalJSON()
+	return workv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}}
+}
+
+func TestSchemaValidatorValidateManifests(t *testing.T) {
+	cases := []struct {
+		name               string
+		manifests          []workv1.Manifest
+		expectedViolations []string
+	}{
+		{
+			name: "valid manifest",
+			manifests: []workv1.Manifest{
+				newSpecManifest("apps/v1", "Deployment", "test", map[string]interface{}{"replicas": int64(1)}),
+			},
+		},
+		{
+			name: "unknown field",
+			manifests: []workv1.Manifest{
+				newSpecManifest("apps/v1", "Deployment", "test", map[string]interface{}{"replicas": int64(1)}),
+				newSpecManifest("apps/v1", "Deployment", "typo", map[string]interface{}{"replica": int64(1)}),
+			},
+			expectedViolations: []string{"manifests[1] Deployment typo: .spec.replica: field not declared in schema"},
+		},
+		{
+			name: "invalid field type",
+			manifests: []workv1.Manifest{
+				newSpecManifest("apps/v1", "Deployment", "test", map[string]interface{}{"replicas": "one"}),
+			},
+			expectedViolations: []string{"manifests[0] Deployment test: .spec.replicas: expected numeric (int or float), got string"},
+		},
+		{
+			name: "unknown kind of a known group version",
+			manifests: []workv1.Manifest{
+				newSpecManifest("apps/v1", "StatefulSet", "test", map[string]interface{}{"replica": int64(1)}),
+			},
+		},
+		{
+			name: "unknown group version",
+			manifests: []workv1.Manifest{
+				newSpecManifest("example.com/v1", "Foo", "test", map[string]interface{}{"bar": "baz"}),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validator := newTestSchemaValidator(&fakeOpenAPIClient{})
+			violations, err := validator.ValidateManifests(c.manifests)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(violations, "\n") != strings.Join(c.expectedViolations, "\n") {
+				t.Errorf("expected violations %q, but got %q", c.expectedViolations, violations)
+			}
+		})
+	}
+}
+
+func TestSchemaValidatorCache(t *testing.T) {
+	client := &fakeOpenAPIClient{}
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	validator := newTestSchemaValidator(client)
+	validator.clock = fakeClock
+
+	manifests := []workv1.Manifest{newSpecManifest("apps/v1", "Deployment", "test", nil)}
+	for i := 0; i < 2; i++ {
+		if _, err := validator.ValidateManifests(manifests); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.calls != 1 {
+		t.Errorf("expected the schemas cached, but got %d calls", client.calls)
+	}
+
+	fakeClock.Step(SchemaCacheTTL + time.Second)
+	if _, err := validator.ValidateManifests(manifests); err != nil {
+		t.Fatal(err)
+	}
+	if client.calls != 2 {
+		t.Errorf("expected the schemas refreshed, but got %d calls", client.calls)
+	}
+}
+
+func TestValidateManifestSchemas(t *testing.T) {
+	manifests := []workv1.Manifest{
+		newSpecManifest("apps/v1", "Deployment", "typo", map[string]interface{}{"replica": int64(1)}),
+	}
+	violation := "manifests[0] Deployment typo: .spec.replica: field not declared in schema"
+
+	cases := []struct {
+		name             string
+		annotations      map[string]string
+		validator        *SchemaValidator
+		expectedWarnings []string
+		expectedErr      bool
+	}{
+		{
+			name:      "validation is not enabled",
+			validator: newTestSchemaValidator(&fakeOpenAPIClient{}),
+		},
+		{
+			name:        "validator is not set",
+			annotations: map[string]string{ManifestSchemaValidationAnnotation: SchemaValidationModeEnforce},
+		},
+		{
+			name:             "warn mode",
+			annotations:      map[string]string{ManifestSchemaValidationAnnotation: SchemaValidationModeWarn},
+			validator:        newTestSchemaValidator(&fakeOpenAPIClient{}),
+			expectedWarnings: []string{violation},
+		},
+		{
+			name:        "enforce mode",
+			annotations: map[string]string{ManifestSchemaValidationAnnotation: SchemaValidationModeEnforce},
+			validator:   newTestSchemaValidator(&fakeOpenAPIClient{}),
+			expectedErr: true,
+		},
+		{
+			name:        "namespaces not synced",
+			annotations: map[string]string{ManifestSchemaValidationAnnotation: SchemaValidationModeEnforce},
+			validator: func() *SchemaValidator {
+				validator := newTestSchemaValidator(&fakeOpenAPIClient{})
+				validator.namespacesSynced = func() bool { return false }
+				return validator
+			}(),
+			expectedWarnings: []string{"skip validating the manifest schemas: the namespaces are not synced"},
+		},
+		{
+			name:        "invalid mode",
+			annotations: map[string]string{ManifestSchemaValidationAnnotation: "strict"},
+			validator:   newTestSchemaValidator(&fakeOpenAPIClient{}),
+			expectedWarnings: []string{"skip validating the manifest schemas: invalid value \"strict\" of annotation " +
+				"work.open-cluster-management.io/manifest-schema-validation on namespace cluster1, it should be warn or enforce"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.validator != nil {
+				if err := c.validator.namespaceLister.(*fakeNamespaceLister).indexer.Add(&metav1.PartialObjectMetadata{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Annotations: c.annotations},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			warnings, err := ValidateManifestSchemas(c.validator, "cluster1", manifests)
+			if c.expectedErr {
+				if !apierrors.IsBadRequest(err) || !strings.Contains(err.Error(), violation) {
+					t.Errorf("expected bad request with the violation, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(c.expectedWarnings, "\n") {
+				t.Errorf("expected warnings %q, but got %q", c.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+// fakeNamespaceLister lists the namespace metadata from an indexer.
+type fakeNamespaceLister struct {
+	metadatalister.Lister
+	indexer cache.Indexer
+}
+
+func newTestSchemaValidator(client openapi.Client) *SchemaValidator {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	lister := &fakeNamespaceLister{
+		Lister:  metadatalister.New(indexer, corev1.SchemeGroupVersion.WithResource("namespaces")),
+		indexer: indexer,
+	}
+	return NewSchemaValidator(client, lister, func() bool { return true })
+}
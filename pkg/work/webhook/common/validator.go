@@ -20,6 +20,12 @@ func (m *Validator) WithLimit(limit int) {
 }
 
 func (m *Validator) ValidateManifests(manifests []workv1.Manifest) error {
+	return m.ValidateManifestsWithHeadroom(manifests, 0)
+}
+
+// ValidateManifestsWithHeadroom validates the manifests, whose total size must leave the headroom within the limit
+// for the metadata added to the manifestWork later, e.g. by the ManifestWorkReplicaSet controller.
+func (m *Validator) ValidateManifestsWithHeadroom(manifests []workv1.Manifest, headroom int) error {
 	if len(manifests) == 0 {
 		return apierrors.NewBadRequest("Workload manifests should not be empty")
 	}
@@ -29,7 +35,11 @@ func (m *Validator) ValidateManifests(manifests []workv1.Manifest) error {
 		totalSize = totalSize + manifest.Size()
 	}
 
-	if totalSize > m.limit {
+	if totalSize > m.limit-headroom {
+		if headroom > 0 {
+			return fmt.Errorf("the size of manifests is %v bytes which exceeds the %v limit with %v bytes reserved "+
+				"for the manifestwork metadata", totalSize, m.limit, headroom)
+		}
 		return fmt.Errorf("the size of manifests is %v bytes which exceeds the %v limit", totalSize, m.limit)
 	}
 
@@ -60,5 +70,10 @@ func validateManifest(manifest []byte) error {
 		return fmt.Errorf("generateName must not be set in manifest")
 	}
 
+	// The owners of the resource on the managed cluster are set by the work agent
+	if len(unstructuredObj.GetOwnerReferences()) > 0 {
+		return fmt.Errorf("ownerReferences must not be set in manifest %s", unstructuredObj.GetName())
+	}
+
 	return nil
 }
@@ -48,6 +48,29 @@ func Test_Validator(t *testing.T) {
 			manifests:     []workv1.Manifest{newManifest(300 * 1024), newManifest(200 * 1024)},
 			expectedError: fmt.Errorf("the size of manifests is 512192 bytes which exceeds the 512000 limit"),
 		},
+		{
+			name: "ownerReferences is set",
+			manifests: []workv1.Manifest{func() workv1.Manifest {
+				obj := &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Secret",
+						"metadata": map[string]interface{}{
+							"namespace": "test",
+							"name":      "test",
+							"ownerReferences": []interface{}{
+								map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "name": "owner", "uid": "1"},
+							},
+						},
+					},
+				}
+				objectStr, _ := obj.MarshalJSON()
+				manifest := workv1.Manifest{}
+				manifest.Raw = objectStr
+				return manifest
+			}()},
+			expectedError: fmt.Errorf("ownerReferences must not be set in manifest test"),
+		},
 	}
 
 	for _, c := range cases {
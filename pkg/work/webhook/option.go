@@ -1,12 +1,17 @@
 package webhook
 
-import "github.com/spf13/pflag"
+import (
+	"github.com/spf13/pflag"
+
+	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
+)
 
 // Config contains the server (the webhook) cert and key.
 type Options struct {
 	Port          int
 	CertDir       string
 	ManifestLimit int
+	TLSOptions    commonoptions.TLSOptions
 }
 
 // NewOptions constructs a new set of default options for webhook.
@@ -25,4 +30,5 @@ func (c *Options) AddFlags(fs *pflag.FlagSet) {
 			"webhook server would look up the server key and certificate in {TempDir}/k8s-webhook-server/serving-certs")
 	fs.IntVar(&c.ManifestLimit, "manifestLimit", c.ManifestLimit,
 		"ManifestLimit is the max size of manifests in a manifestWork. If not set, the default is 500k.")
+	c.TLSOptions.AddFlags(fs)
 }
@@ -1,6 +1,8 @@
 package webhook
 
 import (
+	"crypto/tls"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -13,9 +15,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
 	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 	webhookv1 "open-cluster-management.io/ocm/pkg/work/webhook/v1"
+	webhookv1alpha1 "open-cluster-management.io/ocm/pkg/work/webhook/v1alpha1"
 )
 
 var (
@@ -25,15 +29,28 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(workv1.Install(scheme))
+	utilruntime.Must(workv1alpha1.Install(scheme))
 }
 
 func (c *Options) RunWebhookServer() error {
+	if err := c.TLSOptions.Validate(); err != nil {
+		return err
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		Port:                   c.Port,
 		HealthProbeBindAddress: ":8000",
 		CertDir:                c.CertDir,
-		WebhookServer:          webhook.NewServer(webhook.Options{TLSMinVersion: "1.3"}),
+		WebhookServer: webhook.NewServer(webhook.Options{
+			TLSMinVersion: "1.3",
+			TLSOpts: []func(*tls.Config){
+				func(config *tls.Config) {
+					// the options are validated already
+					_ = c.TLSOptions.ApplyToTLSConfig(config)
+				},
+			},
+		}),
 	})
 
 	if err != nil {
@@ -55,7 +72,12 @@ func (c *Options) RunWebhookServer() error {
 	common.ManifestValidator.WithLimit(c.ManifestLimit)
 
 	if err = (&webhookv1.ManifestWorkWebhook{}).Init(mgr); err != nil {
-		klog.Error(err, "unable to create ManagedCluster webhook")
+		klog.Error(err, "unable to create ManifestWork webhook")
+		return err
+	}
+
+	if err = (&webhookv1alpha1.ManifestWorkReplicaSetWebhook{}).Init(mgr); err != nil {
+		klog.Error(err, "unable to create ManifestWorkReplicaSet webhook")
 		return err
 	}
 
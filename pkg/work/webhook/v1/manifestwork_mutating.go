@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
+)
+
+// ManifestWorkMutatingPath is the path of the mutating webhook of the manifestwork.
+const ManifestWorkMutatingPath = "/mutate-work-open-cluster-management-io-v1-manifestwork"
+
+// ManifestWorkMutator strips the fields set by the api server from the manifests of the manifestwork, and warns
+// the user with the stripped fields. It is a raw admission handler instead of a CustomDefaulter since the
+// defaulter cannot return the admission warnings.
+type ManifestWorkMutator struct {
+	decoder *admission.Decoder
+}
+
+func NewManifestWorkMutator(decoder *admission.Decoder) *ManifestWorkMutator {
+	return &ManifestWorkMutator{decoder: decoder}
+}
+
+var _ admission.Handler = &ManifestWorkMutator{}
+
+// Handle implements admission.Handler
+func (m *ManifestWorkMutator) Handle(_ context.Context, req admission.Request) admission.Response {
+	work := &workv1.ManifestWork{}
+	if err := m.decoder.Decode(req, work); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	manifests, warnings := common.StripManifests(work.Spec.Workload.Manifests)
+	if len(warnings) == 0 {
+		return admission.Allowed("")
+	}
+	work.Spec.Workload.Manifests = manifests
+
+	marshaled, err := json.Marshal(work)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled).WithWarnings(warnings...)
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestManifestWorkMutate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(workv1.Install(scheme))
+	mutator := NewManifestWorkMutator(admission.NewDecoder(scheme))
+
+	polluted := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test")
+	polluted.SetUID("d0b9a3b4-7a6c-4d6f-9f0a-3c1b2a4e5f60")
+	polluted.SetResourceVersion("12345")
+	polluted.SetCreationTimestamp(metav1.Now())
+	polluted.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}})
+	if err := unstructured.SetNestedField(polluted.Object, map[string]interface{}{"phase": "Active"}, "status"); err != nil {
+		t.Fatal(err)
+	}
+	cleaned := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test")
+
+	cases := []struct {
+		name             string
+		manifest         *unstructured.Unstructured
+		expectedPatched  bool
+		expectedWarnings []string
+	}{
+		{
+			name:     "clean manifest",
+			manifest: cleaned,
+		},
+		{
+			name:            "polluted manifest",
+			manifest:        polluted,
+			expectedPatched: true,
+			expectedWarnings: []string{
+				"status, metadata.managedFields, metadata.creationTimestamp, metadata.uid, metadata.resourceVersion " +
+					"are stripped from the manifest ConfigMap ns1/test",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, _ := spoketesting.NewManifestWork(0, c.manifest)
+			raw, err := json.Marshal(work)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp := mutator.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:  manifestWorkSchema,
+					Operation: admissionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+			if !resp.Allowed {
+				t.Fatalf("expected the request is allowed, but got %v", resp.Result)
+			}
+			if !reflect.DeepEqual(resp.Warnings, c.expectedWarnings) {
+				t.Errorf("expected warnings %v, but got %v", c.expectedWarnings, resp.Warnings)
+			}
+			if (len(resp.Patches) > 0) != c.expectedPatched {
+				t.Fatalf("expected patched %v, but got patches %v", c.expectedPatched, resp.Patches)
+			}
+
+			// apply the patches to the request and check the stored manifestwork
+			patchData, err := json.Marshal(resp.Patches)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch, err := jsonpatch.DecodePatch(patchData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			storedRaw, err := patch.Apply(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := &workv1.ManifestWork{}
+			if err := json.Unmarshal(storedRaw, stored); err != nil {
+				t.Fatal(err)
+			}
+
+			storedManifest := &unstructured.Unstructured{}
+			if err := storedManifest.UnmarshalJSON(stored.Spec.Workload.Manifests[0].Raw); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(storedManifest.Object, cleaned.Object) {
+				t.Errorf("expected the stored manifest %v, but got %v", cleaned.Object, storedManifest.Object)
+			}
+		})
+	}
+}
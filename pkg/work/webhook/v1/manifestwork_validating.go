@@ -6,9 +6,7 @@ import (
 	"reflect"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
-	authorizationv1 "k8s.io/api/authorization/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -18,6 +16,7 @@ import (
 	workv1 "open-cluster-management.io/api/work/v1"
 
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
@@ -29,7 +28,7 @@ func (r *ManifestWorkWebhook) ValidateCreate(ctx context.Context, obj runtime.Ob
 	if !ok {
 		return nil, apierrors.NewBadRequest("Request manifestwork obj format is not right")
 	}
-	return nil, r.validateRequest(work, nil, ctx)
+	return r.validateRequest(work, nil, ctx)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -45,7 +44,7 @@ func (r *ManifestWorkWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj
 		return nil, apierrors.NewBadRequest("Request manifestwork obj format is not right")
 	}
 
-	return nil, r.validateRequest(newWork, oldWork, ctx)
+	return r.validateRequest(newWork, oldWork, ctx)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -53,25 +52,54 @@ func (r *ManifestWorkWebhook) ValidateDelete(_ context.Context, obj runtime.Obje
 	return nil, nil
 }
 
-func (r *ManifestWorkWebhook) validateRequest(newWork, oldWork *workv1.ManifestWork, ctx context.Context) error {
+func (r *ManifestWorkWebhook) validateRequest(newWork, oldWork *workv1.ManifestWork, ctx context.Context) (
+	admission.Warnings, error) {
 	if len(newWork.Spec.Workload.Manifests) == 0 {
-		return apierrors.NewBadRequest("manifests should not be empty")
+		return nil, apierrors.NewBadRequest("manifests should not be empty")
 	}
 
 	if err := common.ManifestValidator.ValidateManifests(newWork.Spec.Workload.Manifests); err != nil {
-		return apierrors.NewBadRequest(err.Error())
+		return nil, apierrors.NewBadRequest(err.Error())
 	}
 
 	req, err := admission.RequestFromContext(ctx)
 	if err != nil {
-		return apierrors.NewBadRequest(err.Error())
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	warnings, err := common.ValidateManifestSchemas(
+		r.schemaValidator, newWork.Namespace, newWork.Spec.Workload.Manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.validateDependencies(newWork, oldWork); err != nil {
+		return nil, err
 	}
 
 	// do not need to check the executor when it is not changed
 	if oldWork != nil && reflect.DeepEqual(oldWork.Spec.Executor, newWork.Spec.Executor) {
+		return warnings, nil
+	}
+	return warnings, validateExecutor(r.kubeClient, newWork, req.UserInfo)
+}
+
+// validateDependencies validates the dependency annotations of the work, and rejects the work if its dependencies
+// form a cycle with the other works in the namespace.
+func (r *ManifestWorkWebhook) validateDependencies(newWork, oldWork *workv1.ManifestWork) error {
+	if err := helper.ValidateDependencies(newWork); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
+	dependencies := helper.GetDependencies(newWork)
+	if len(dependencies) == 0 || r.dependencyLister == nil {
 		return nil
 	}
-	return validateExecutor(r.kubeClient, newWork, req.UserInfo)
+	// do not need to check the cycles when the dependencies are not changed
+	if oldWork != nil && reflect.DeepEqual(helper.GetDependencies(oldWork), dependencies) {
+		return nil
+	}
+	return r.dependencyLister.ValidateNoCycle(newWork)
 }
 
 func validateExecutor(kubeClient kubernetes.Interface, work *workv1.ManifestWork, userInfo authenticationv1.UserInfo) error {
@@ -99,27 +127,13 @@ func validateExecutor(kubeClient kubernetes.Interface, work *workv1.ManifestWork
 		return apierrors.NewBadRequest("executor service account can not be nil")
 	}
 
-	sar := &authorizationv1.SubjectAccessReview{
-		Spec: authorizationv1.SubjectAccessReviewSpec{
-			User:   userInfo.Username,
-			UID:    userInfo.UID,
-			Groups: userInfo.Groups,
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:     "work.open-cluster-management.io",
-				Resource:  "manifestworks",
-				Verb:      "execute-as",
-				Namespace: work.Namespace,
-				Name: fmt.Sprintf("system:serviceaccount:%s:%s",
-					executor.Subject.ServiceAccount.Namespace, executor.Subject.ServiceAccount.Name),
-			},
-		},
-	}
-	sar, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	allowed, err := helper.CanExecuteAs(context.TODO(), kubeClient.AuthorizationV1(), userInfo, work.Namespace,
+		executor.Subject.ServiceAccount)
 	if err != nil {
 		return apierrors.NewBadRequest(err.Error())
 	}
 
-	if !sar.Status.Allowed {
+	if !allowed {
 		return apierrors.NewBadRequest(fmt.Sprintf("user %s cannot manipulate the Manifestwork with executor %s/%s in namespace %s",
 			userInfo.Username, executor.Subject.ServiceAccount.Namespace, executor.Subject.ServiceAccount.Name, work.Namespace))
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	admissionv1 "k8s.io/api/admission/v1"
@@ -15,14 +16,18 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/metadata/metadatalister"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	ocmfeature "open-cluster-management.io/api/feature"
 	workv1 "open-cluster-management.io/api/work/v1"
 
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
 var manifestWorkSchema = metav1.GroupVersionResource{
@@ -311,10 +316,94 @@ func TestManifestWorkExecutorValidate(t *testing.T) {
 				oldWork.Spec.Executor = c.oldExecutor
 			}
 			newWork.Spec.Executor = c.executor
-			err := mw.validateRequest(newWork, oldWork, ctx)
+			_, err := mw.validateRequest(newWork, oldWork, ctx)
 			if !reflect.DeepEqual(err, c.expectErr) {
 				t.Errorf("case: %v, expected %v but got: %v", c.name, c.expectErr, err)
 			}
 		})
 	}
 }
+
+func TestManifestWorkDependenciesValidate(t *testing.T) {
+	newWork := func(name, dependsOn string) *workv1.ManifestWork {
+		work, _ := spoketesting.NewManifestWork(0, spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test"))
+		work.Name = name
+		if len(dependsOn) > 0 {
+			work.Annotations = map[string]string{helper.DependsOnAnnotation: dependsOn}
+		}
+		return work
+	}
+
+	invalidTimeout := newWork("app", "db")
+	invalidTimeout.Annotations[helper.DependencyTimeoutAnnotation] = "10"
+
+	cases := []struct {
+		name          string
+		works         []*workv1.ManifestWork
+		oldWork       *workv1.ManifestWork
+		work          *workv1.ManifestWork
+		expectedError string
+	}{
+		{
+			name: "no dependencies",
+			work: newWork("app", ""),
+		},
+		{
+			name:  "chain",
+			works: []*workv1.ManifestWork{newWork("db", "storage"), newWork("storage", "")},
+			work:  newWork("app", "db"),
+		},
+		{
+			name:  "dependency not created yet",
+			works: []*workv1.ManifestWork{newWork("storage", "")},
+			work:  newWork("app", "db"),
+		},
+		{
+			name:          "depends on itself",
+			work:          newWork("app", "app"),
+			expectedError: "the work should not depend on itself",
+		},
+		{
+			name:          "cycle",
+			works:         []*workv1.ManifestWork{newWork("db", "storage"), newWork("storage", "app")},
+			work:          newWork("app", "db"),
+			expectedError: "the dependencies form a cycle app -> db -> storage -> app",
+		},
+		{
+			name:          "cycle by update",
+			works:         []*workv1.ManifestWork{newWork("db", "app"), newWork("app", "")},
+			oldWork:       newWork("app", ""),
+			work:          newWork("app", "db"),
+			expectedError: "the dependencies form a cycle app -> db -> app",
+		},
+		{
+			name:          "invalid timeout",
+			work:          invalidTimeout,
+			expectedError: "annotation work.open-cluster-management.io/dependency-timeout",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, work := range c.works {
+				if err := indexer.Add(&metav1.PartialObjectMetadata{ObjectMeta: work.ObjectMeta}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mw := ManifestWorkWebhook{
+				kubeClient: fakekube.NewSimpleClientset(),
+				dependencyLister: common.NewDependencyLister("manifestworks",
+					metadatalister.New(indexer, workv1.GroupVersion.WithResource("manifestworks")),
+					func() bool { return true }),
+			}
+			err := mw.validateDependencies(c.work, c.oldWork)
+			switch {
+			case len(c.expectedError) == 0 && err != nil:
+				t.Errorf("expected no error, but got %v", err)
+			case len(c.expectedError) > 0 && (!apierrors.IsBadRequest(err) || !strings.Contains(err.Error(), c.expectedError)):
+				t.Errorf("expected bad request %q, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
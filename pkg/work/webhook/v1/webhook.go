@@ -3,12 +3,20 @@ package v1
 import (
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	v1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
 type ManifestWorkWebhook struct {
 	kubeClient kubernetes.Interface
+	// schemaValidator validates the manifests against the OpenAPI schemas of the hub.
+	schemaValidator *common.SchemaValidator
+	// dependencyLister detects the cycles of the dependencies from the cached manifestworks.
+	dependencyLister *common.DependencyLister
 }
 
 func (r *ManifestWorkWebhook) Init(mgr ctrl.Manager) error {
@@ -17,6 +25,14 @@ func (r *ManifestWorkWebhook) Init(mgr ctrl.Manager) error {
 		return err
 	}
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.schemaValidator, err = common.StartSchemaValidator(mgr, r.kubeClient.Discovery().OpenAPIV3())
+	if err != nil {
+		return err
+	}
+	r.dependencyLister, err = common.StartDependencyLister(mgr, v1.GroupVersion.WithResource("manifestworks"))
 	return err
 }
 
@@ -26,6 +42,10 @@ func (r *ManifestWorkWebhook) SetExternalKubeClientSet(client kubernetes.Interfa
 }
 
 func (r *ManifestWorkWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(ManifestWorkMutatingPath, &webhook.Admission{
+		Handler: NewManifestWorkMutator(admission.NewDecoder(mgr.GetScheme())),
+	})
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		WithValidator(r).
 		For(&v1.ManifestWork{}).
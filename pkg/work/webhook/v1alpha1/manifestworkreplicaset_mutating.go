@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
+)
+
+// ManifestWorkReplicaSetMutatingPath is the path of the mutating webhook of the manifestWorkReplicaSet.
+const ManifestWorkReplicaSetMutatingPath = "/mutate-work-open-cluster-management-io-v1alpha1-manifestworkreplicaset"
+
+// ManifestWorkReplicaSetMutator strips the fields set by the api server from the manifests of the
+// manifestWork template, and warns the user with the stripped fields. It also records the user who sets the
+// executor of the manifestWork template, so the controller checks the permission of the user on the executor
+// of each cluster, and defaults the missing rollout annotations of a progressive rollout.
+type ManifestWorkReplicaSetMutator struct {
+	decoder *admission.Decoder
+}
+
+func NewManifestWorkReplicaSetMutator(decoder *admission.Decoder) *ManifestWorkReplicaSetMutator {
+	return &ManifestWorkReplicaSetMutator{decoder: decoder}
+}
+
+var _ admission.Handler = &ManifestWorkReplicaSetMutator{}
+
+// Handle implements admission.Handler
+func (m *ManifestWorkReplicaSetMutator) Handle(_ context.Context, req admission.Request) admission.Response {
+	mwrSet := &workv1alpha1.ManifestWorkReplicaSet{}
+	if err := m.decoder.Decode(req, mwrSet); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var oldMWRSet *workv1alpha1.ManifestWorkReplicaSet
+	if req.Operation == admissionv1.Update {
+		oldMWRSet = &workv1alpha1.ManifestWorkReplicaSet{}
+		if err := m.decoder.DecodeRaw(req.OldObject, oldMWRSet); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	creatorChanged, err := mutateExecutorCreator(mwrSet, oldMWRSet, req.UserInfo)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// the annotations map is not nil once there is any rollout annotation to default
+	rolloutDefaulted := helper.DefaultRolloutStrategy(mwrSet.Annotations)
+
+	manifests, warnings := common.StripManifests(mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests)
+	if len(warnings) == 0 && !creatorChanged && !rolloutDefaulted {
+		return admission.Allowed("")
+	}
+	mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests = manifests
+
+	marshaled, err := json.Marshal(mwrSet)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled).WithWarnings(warnings...)
+}
+
+// mutateExecutorCreator sets the ExecutorCreatorAnnotation to the user once the executor is set or changed, and
+// keeps the annotation of the old manifestWorkReplicaSet otherwise, so the annotation cannot be set by the users
+// directly. The annotation is removed if there is no executor, or the executor is unchanged on a
+// manifestWorkReplicaSet created without the annotation. It returns true if the annotation is changed.
+func mutateExecutorCreator(mwrSet, oldMWRSet *workv1alpha1.ManifestWorkReplicaSet,
+	userInfo authenticationv1.UserInfo) (bool, error) {
+	current, ok := mwrSet.Annotations[helper.ExecutorCreatorAnnotation]
+	expected, expectedOk, err := expectedExecutorCreator(mwrSet, oldMWRSet, userInfo)
+	if err != nil {
+		return false, err
+	}
+
+	if ok == expectedOk && current == expected {
+		return false, nil
+	}
+	if !expectedOk {
+		delete(mwrSet.Annotations, helper.ExecutorCreatorAnnotation)
+		return true, nil
+	}
+	if mwrSet.Annotations == nil {
+		mwrSet.Annotations = map[string]string{}
+	}
+	mwrSet.Annotations[helper.ExecutorCreatorAnnotation] = expected
+	return true, nil
+}
+
+// expectedExecutorCreator returns the ExecutorCreatorAnnotation the manifestWorkReplicaSet should have, and false
+// if it should have none.
+func expectedExecutorCreator(mwrSet, oldMWRSet *workv1alpha1.ManifestWorkReplicaSet,
+	userInfo authenticationv1.UserInfo) (string, bool, error) {
+	executor := mwrSet.Spec.ManifestWorkTemplate.Executor
+	switch {
+	case executor == nil:
+		return "", false, nil
+	case oldMWRSet != nil && apiequality.Semantic.DeepEqual(oldMWRSet.Spec.ManifestWorkTemplate.Executor, executor):
+		creator, ok := oldMWRSet.Annotations[helper.ExecutorCreatorAnnotation]
+		return creator, ok, nil
+	}
+	creator, err := helper.ExecutorCreator(userInfo)
+	if err != nil {
+		return "", false, err
+	}
+	return creator, true, nil
+}
@@ -0,0 +1,277 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestManifestWorkReplicaSetMutate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(workv1alpha1.Install(scheme))
+	mutator := NewManifestWorkReplicaSetMutator(admission.NewDecoder(scheme))
+
+	polluted := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test")
+	polluted.SetUID("d0b9a3b4-7a6c-4d6f-9f0a-3c1b2a4e5f60")
+	polluted.SetResourceVersion("12345")
+	if err := unstructured.SetNestedField(polluted.Object, map[string]interface{}{"phase": "Active"}, "status"); err != nil {
+		t.Fatal(err)
+	}
+	pollutedWork, _ := spoketesting.NewManifestWork(0, polluted)
+
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Spec.ManifestWorkTemplate = pollutedWork.Spec
+	raw, err := json.Marshal(mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := mutator.Handle(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Resource:  manifestWorkReplicaSetSchema,
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if !resp.Allowed {
+		t.Fatalf("expected the request is allowed, but got %v", resp.Result)
+	}
+	expectedWarnings := []string{"status, metadata.uid, metadata.resourceVersion are stripped from the manifest ConfigMap ns1/test"}
+	if !reflect.DeepEqual(resp.Warnings, expectedWarnings) {
+		t.Errorf("expected warnings %v, but got %v", expectedWarnings, resp.Warnings)
+	}
+
+	// apply the patches to the request and check the stored manifestWorkReplicaSet
+	patchData, err := json.Marshal(resp.Patches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := jsonpatch.DecodePatch(patchData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedRaw, err := patch.Apply(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := &workv1alpha1.ManifestWorkReplicaSet{}
+	if err := json.Unmarshal(storedRaw, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	storedManifest := &unstructured.Unstructured{}
+	if err := storedManifest.UnmarshalJSON(stored.Spec.ManifestWorkTemplate.Workload.Manifests[0].Raw); err != nil {
+		t.Fatal(err)
+	}
+	cleaned := spoketesting.NewUnstructured("v1", "ConfigMap", "ns1", "test")
+	if !reflect.DeepEqual(storedManifest.Object, cleaned.Object) {
+		t.Errorf("expected the stored manifest %v, but got %v", cleaned.Object, storedManifest.Object)
+	}
+}
+
+func TestManifestWorkReplicaSetMutateRolloutDefaults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(workv1alpha1.Install(scheme))
+	mutator := NewManifestWorkReplicaSetMutator(admission.NewDecoder(scheme))
+
+	cases := []struct {
+		name                string
+		annotations         map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "no rollout",
+		},
+		{
+			name:        "max concurrency only",
+			annotations: map[string]string{helper.RolloutMaxConcurrencyAnnotationKey: "2"},
+			expectedAnnotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:       helper.RolloutStrategyProgressive,
+				helper.RolloutMaxConcurrencyAnnotationKey: "2",
+			},
+		},
+		{
+			name: "progressive without max concurrency",
+			annotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey: helper.RolloutStrategyProgressive,
+			},
+			expectedAnnotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:       helper.RolloutStrategyProgressive,
+				helper.RolloutMaxConcurrencyAnnotationKey: "100%",
+			},
+		},
+		{
+			name: "progressive per group without max failures",
+			annotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey: helper.RolloutStrategyProgressivePerGroup,
+			},
+			expectedAnnotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:    helper.RolloutStrategyProgressivePerGroup,
+				helper.RolloutMaxFailuresAnnotationKey: "0",
+			},
+		},
+		{
+			name: "progressive per group with max failures",
+			annotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:    helper.RolloutStrategyProgressivePerGroup,
+				helper.RolloutMaxFailuresAnnotationKey: "10%",
+			},
+			expectedAnnotations: map[string]string{
+				helper.RolloutStrategyAnnotationKey:    helper.RolloutStrategyProgressivePerGroup,
+				helper.RolloutMaxFailuresAnnotationKey: "10%",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			mwrSet.Annotations = c.annotations
+			raw, err := json.Marshal(mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp := mutator.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:  manifestWorkReplicaSetSchema,
+					Operation: admissionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+			if !resp.Allowed {
+				t.Fatalf("expected the request is allowed, but got %v", resp.Result)
+			}
+
+			patchData, err := json.Marshal(resp.Patches)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch, err := jsonpatch.DecodePatch(patchData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			storedRaw, err := patch.Apply(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := &workv1alpha1.ManifestWorkReplicaSet{}
+			if err := json.Unmarshal(storedRaw, stored); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stored.Annotations, c.expectedAnnotations) {
+				t.Errorf("expected annotations %v, but got %v", c.expectedAnnotations, stored.Annotations)
+			}
+		})
+	}
+}
+
+func TestMutateExecutorCreator(t *testing.T) {
+	user1 := authenticationv1.UserInfo{Username: "user1", Groups: []string{"group1"}, Extra: map[string]authenticationv1.ExtraValue{"key": {"value"}}}
+	user2 := authenticationv1.UserInfo{Username: "user2"}
+	creator1, _ := helper.ExecutorCreator(user1)
+	creator2, _ := helper.ExecutorCreator(user2)
+
+	newMWRSet := func(executorName string, annotations map[string]string) *workv1alpha1.ManifestWorkReplicaSet {
+		mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+		mwrSet.Annotations = annotations
+		if len(executorName) > 0 {
+			mwrSet.Spec.ManifestWorkTemplate.Executor = &workv1.ManifestWorkExecutor{
+				Subject: workv1.ManifestWorkExecutorSubject{
+					Type: workv1.ExecutorSubjectTypeServiceAccount,
+					ServiceAccount: &workv1.ManifestWorkSubjectServiceAccount{
+						Namespace: "{{ .ClusterName }}",
+						Name:      executorName,
+					},
+				},
+			}
+		}
+		return mwrSet
+	}
+
+	cases := []struct {
+		name              string
+		mwrSet            *workv1alpha1.ManifestWorkReplicaSet
+		oldMWRSet         *workv1alpha1.ManifestWorkReplicaSet
+		user              authenticationv1.UserInfo
+		expectedChanged   bool
+		expectedCreator   string
+		expectedCreatorOk bool
+	}{
+		{
+			name:   "no executor",
+			mwrSet: newMWRSet("", nil),
+			user:   user1,
+		},
+		{
+			name:            "forged annotation without executor",
+			mwrSet:          newMWRSet("", map[string]string{helper.ExecutorCreatorAnnotation: creator2}),
+			user:            user1,
+			expectedChanged: true,
+		},
+		{
+			name:              "create with executor",
+			mwrSet:            newMWRSet("sa", map[string]string{helper.ExecutorCreatorAnnotation: creator2}),
+			user:              user1,
+			expectedChanged:   true,
+			expectedCreator:   creator1,
+			expectedCreatorOk: true,
+		},
+		{
+			name:              "executor unchanged",
+			mwrSet:            newMWRSet("sa", map[string]string{helper.ExecutorCreatorAnnotation: creator2}),
+			oldMWRSet:         newMWRSet("sa", map[string]string{helper.ExecutorCreatorAnnotation: creator1}),
+			user:              user2,
+			expectedChanged:   true,
+			expectedCreator:   creator1,
+			expectedCreatorOk: true,
+		},
+		{
+			name:              "executor changed",
+			mwrSet:            newMWRSet("sa2", map[string]string{helper.ExecutorCreatorAnnotation: creator1}),
+			oldMWRSet:         newMWRSet("sa", map[string]string{helper.ExecutorCreatorAnnotation: creator1}),
+			user:              user2,
+			expectedChanged:   true,
+			expectedCreator:   creator2,
+			expectedCreatorOk: true,
+		},
+		{
+			name:      "executor unchanged without annotation",
+			mwrSet:    newMWRSet("sa", nil),
+			oldMWRSet: newMWRSet("sa", nil),
+			user:      user2,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			changed, err := mutateExecutorCreator(c.mwrSet, c.oldMWRSet, c.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != c.expectedChanged {
+				t.Errorf("expected changed %v, but got %v", c.expectedChanged, changed)
+			}
+			creator, ok := c.mwrSet.Annotations[helper.ExecutorCreatorAnnotation]
+			if ok != c.expectedCreatorOk || creator != c.expectedCreator {
+				t.Errorf("expected creator %q, but got %q", c.expectedCreator, creator)
+			}
+		})
+	}
+}
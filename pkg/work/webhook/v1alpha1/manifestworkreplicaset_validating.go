@@ -2,17 +2,30 @@ package v1alpha1
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	ocmfeature "open-cluster-management.io/api/feature"
 	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
@@ -25,7 +38,7 @@ func (r *ManifestWorkReplicaSetWebhook) ValidateCreate(ctx context.Context, obj
 	if !ok {
 		return nil, apierrors.NewBadRequest("Request manifestWorkReplicaSet obj format is not right")
 	}
-	return nil, r.validateRequest(mwrSet, nil, ctx)
+	return r.validateRequest(mwrSet, nil, ctx)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -41,7 +54,7 @@ func (r *ManifestWorkReplicaSetWebhook) ValidateUpdate(ctx context.Context, oldO
 		return nil, apierrors.NewBadRequest("Request manifestWorkReplicaSet obj format is not right")
 	}
 
-	return nil, r.validateRequest(newmwrSet, oldmwrSet, ctx)
+	return r.validateRequest(newmwrSet, oldmwrSet, ctx)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -56,25 +69,207 @@ func (r *ManifestWorkReplicaSetWebhook) ValidateDelete(_ context.Context, obj ru
 
 func (r *ManifestWorkReplicaSetWebhook) validateRequest(
 	newmwrSet *workv1alpha1.ManifestWorkReplicaSet, oldmwrSet *workv1alpha1.ManifestWorkReplicaSet,
-	ctx context.Context) error {
+	ctx context.Context) (admission.Warnings, error) {
 	if err := checkFeatureEnabled(); err != nil {
-		return err
+		return nil, err
+	}
+
+	// the manifestWorkReplicaSet being deleted, e.g. when its finalizer is removed, and the updates changing
+	// nothing validated here are not blocked, so the manifestWorkReplicaSets created before a validation is
+	// added can still be cleaned up.
+	if oldmwrSet != nil && (newmwrSet.DeletionTimestamp != nil || !validatedFieldsChanged(oldmwrSet, newmwrSet)) {
+		return nil, nil
 	}
 
 	if err := validatePlaceManifests(newmwrSet); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	if err := validatePlacementRefNames(newmwrSet); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	// the rollout annotations of an existing manifestWorkReplicaSet are validated only once they are changed, so
+	// the manifestWorkReplicaSets created before the validation are not blocked.
+	if oldmwrSet == nil || helper.RolloutStrategyChanged(oldmwrSet.Annotations, newmwrSet.Annotations) {
+		if err := helper.ValidateRolloutStrategy(newmwrSet.Annotations); err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+
+	if err := r.validateDependencies(newmwrSet, oldmwrSet); err != nil {
+		return nil, err
+	}
+
+	// the executor is checked with a sample cluster name here, the permission of the creator on the executor is
+	// checked by the controller for each cluster once the clusters are selected.
+	if err := helper.ValidateExecutorTemplate(newmwrSet.Spec.ManifestWorkTemplate.Executor); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	if err := validateExecutorCreator(newmwrSet, oldmwrSet, req.UserInfo); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	warnings, err := common.ValidateManifestSchemas(r.schemaValidator, newmwrSet.Namespace,
+		newmwrSet.Spec.ManifestWorkTemplate.Workload.Manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	// do not need to check the placement refs when they are not changed
+	if oldmwrSet != nil && reflect.DeepEqual(oldmwrSet.Spec.PlacementRefs, newmwrSet.Spec.PlacementRefs) &&
+		oldmwrSet.Annotations[helper.PlacementRefNamespacesAnnotation] == newmwrSet.Annotations[helper.PlacementRefNamespacesAnnotation] {
+		return warnings, nil
+	}
+	return warnings, validatePlacementRefs(r.kubeClient, newmwrSet, req.UserInfo)
+}
+
+// validateDependencies validates the dependency annotations of the manifestWorkReplicaSet, and rejects it if its
+// dependencies form a cycle with the other manifestWorkReplicaSets in the namespace, since its manifestWorks would
+// wait for each other in every cluster.
+func (r *ManifestWorkReplicaSetWebhook) validateDependencies(
+	newmwrSet, oldmwrSet *workv1alpha1.ManifestWorkReplicaSet) error {
+	if err := helper.ValidateDependencies(newmwrSet); err != nil {
 		return apierrors.NewBadRequest(err.Error())
 	}
 
-	_, err := admission.RequestFromContext(ctx)
+	dependencies := helper.GetDependencies(newmwrSet)
+	if len(dependencies) == 0 || r.dependencyLister == nil {
+		return nil
+	}
+	// do not need to check the cycles when the dependencies are not changed
+	if oldmwrSet != nil && reflect.DeepEqual(helper.GetDependencies(oldmwrSet), dependencies) {
+		return nil
+	}
+	return r.dependencyLister.ValidateNoCycle(newmwrSet)
+}
+
+// validatedFieldsChanged returns true if the spec or any annotation validated by the webhook is changed.
+func validatedFieldsChanged(oldmwrSet, newmwrSet *workv1alpha1.ManifestWorkReplicaSet) bool {
+	if !equality.Semantic.DeepEqual(oldmwrSet.Spec, newmwrSet.Spec) ||
+		helper.RolloutStrategyChanged(oldmwrSet.Annotations, newmwrSet.Annotations) {
+		return true
+	}
+	for _, key := range []string{
+		helper.PlacementRefNamespacesAnnotation,
+		helper.PriorityAnnotation,
+		helper.RestartedAtAnnotation,
+		helper.DependsOnAnnotation,
+		helper.DependencyTimeoutAnnotation,
+		helper.DependencyTimeoutPolicyAnnotation,
+		helper.ExecutorCreatorAnnotation,
+	} {
+		oldValue, oldOk := oldmwrSet.Annotations[key]
+		newValue, newOk := newmwrSet.Annotations[key]
+		if oldOk != newOk || oldValue != newValue {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExecutorCreator checks the ExecutorCreatorAnnotation is the user of the request once the executor is
+// set or changed, and is unchanged otherwise. The controller checks the permission of this user on the executor,
+// so the annotation must not be set by the users directly even if the mutating webhook is bypassed.
+func validateExecutorCreator(newmwrSet, oldmwrSet *workv1alpha1.ManifestWorkReplicaSet,
+	userInfo authenticationv1.UserInfo) error {
+	expected, expectedOk, err := expectedExecutorCreator(newmwrSet, oldmwrSet, userInfo)
+	if err != nil {
+		return err
+	}
+	current, ok := newmwrSet.Annotations[helper.ExecutorCreatorAnnotation]
+	if ok != expectedOk || current != expected {
+		return fmt.Errorf("the annotation %s must be the user who sets the executor", helper.ExecutorCreatorAnnotation)
+	}
+	return nil
+}
+
+// validatePlacementRefs checks the user has the permission to get the placements referenced by the
+// manifestWorkReplicaSet in other namespaces.
+func validatePlacementRefs(kubeClient kubernetes.Interface, mwrSet *workv1alpha1.ManifestWorkReplicaSet,
+	userInfo authenticationv1.UserInfo) error {
+	namespaces, err := helper.GetPlacementRefNamespaces(mwrSet)
 	if err != nil {
 		return apierrors.NewBadRequest(err.Error())
 	}
 
+	for _, placementRef := range mwrSet.Spec.PlacementRefs {
+		namespace, ok := namespaces[placementRef.Name]
+		if !ok || namespace == mwrSet.Namespace {
+			continue
+		}
+		if errs := validation.ValidateNamespaceName(namespace, false); len(errs) > 0 {
+			return apierrors.NewBadRequest(fmt.Sprintf("invalid namespace %q of placement %s: %s",
+				namespace, placementRef.Name, strings.Join(errs, ", ")))
+		}
+
+		sar := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   userInfo.Username,
+				UID:    userInfo.UID,
+				Groups: userInfo.Groups,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:     "cluster.open-cluster-management.io",
+					Resource:  "placements",
+					Verb:      "get",
+					Namespace: namespace,
+					Name:      placementRef.Name,
+				},
+			},
+		}
+		sar, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+		if err != nil {
+			return apierrors.NewBadRequest(err.Error())
+		}
+
+		if !sar.Status.Allowed {
+			return apierrors.NewBadRequest(fmt.Sprintf("user %s cannot get the placement %s in namespace %s",
+				userInfo.Username, placementRef.Name, namespace))
+		}
+	}
+
 	return nil
 }
 
+// validatePlaceManifests validates the manifests of the manifestWork template, whose size must leave the headroom
+// for the labels and annotations added by the controller to the manifestWorks.
 func validatePlaceManifests(mwrSet *workv1alpha1.ManifestWorkReplicaSet) error {
-	return common.ManifestValidator.ValidateManifests(mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests)
+	// the template hash is a hex sha256 sum.
+	headroom := len(helper.TemplateHashAnnotationKey) + hex.EncodedLen(sha256.Size)
+	labels, annotations := helper.ManifestWorkReplicaSetMetadata(mwrSet)
+	for key, value := range labels {
+		headroom += len(key) + len(value)
+	}
+	for key, value := range annotations {
+		headroom += len(key) + len(value)
+	}
+	return common.ManifestValidator.ValidateManifestsWithHeadroom(
+		mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests, headroom)
+}
+
+// validatePlacementRefNames checks the placementRefs are not empty, and the names are valid and unique.
+func validatePlacementRefNames(mwrSet *workv1alpha1.ManifestWorkReplicaSet) error {
+	if len(mwrSet.Spec.PlacementRefs) == 0 {
+		return errors.New("placementRefs should not be empty")
+	}
+
+	names := sets.New[string]()
+	for _, placementRef := range mwrSet.Spec.PlacementRefs {
+		if errs := validation.NameIsDNSSubdomain(placementRef.Name, false); len(errs) > 0 {
+			return fmt.Errorf("invalid placementRef name %q: %s", placementRef.Name, strings.Join(errs, ", "))
+		}
+		if names.Has(placementRef.Name) {
+			return fmt.Errorf("duplicated placementRef name %q", placementRef.Name)
+		}
+		names.Insert(placementRef.Name)
+	}
+	return nil
 }
 
 func checkFeatureEnabled() error {
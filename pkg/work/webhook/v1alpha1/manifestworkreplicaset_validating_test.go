@@ -3,18 +3,30 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/metadata/metadatalister"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	ocmfeature "open-cluster-management.io/api/feature"
+	workv1 "open-cluster-management.io/api/work/v1"
 	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
 var manifestWorkReplicaSetSchema = metav1.GroupVersionResource{
@@ -45,7 +57,7 @@ func TestWebHookValidateRequest(t *testing.T) {
 		},
 	}
 
-	err := webHook.validateRequest(mwrSet, nil, ctx)
+	_, err := webHook.validateRequest(mwrSet, nil, ctx)
 	if err == nil {
 		t.Fatal("Expecting error for empty ManifestWorkTemplate")
 	}
@@ -54,10 +66,59 @@ func TestWebHookValidateRequest(t *testing.T) {
 	}
 
 	mwrSet = helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
-	err = webHook.validateRequest(mwrSet, nil, ctx)
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	mwrSet.Annotations = map[string]string{helper.DependsOnAnnotation: "database"}
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mwrSet.Annotations[helper.DependencyTimeoutPolicyAnnotation] = "Retry"
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("Expecting bad request error for the invalid dependency timeout policy, but got %v", err)
+	}
+
+	mwrSet = helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Spec.ManifestWorkTemplate.Executor = &workv1.ManifestWorkExecutor{
+		Subject: workv1.ManifestWorkExecutorSubject{
+			Type: workv1.ExecutorSubjectTypeServiceAccount,
+			ServiceAccount: &workv1.ManifestWorkSubjectServiceAccount{
+				Namespace: "{{ .ClusterName }}-apps",
+				Name:      "deployer",
+			},
+		},
+	}
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("Expecting bad request error for the executor without the creator, but got %v", err)
+	}
+
+	mwrSet.Annotations = map[string]string{helper.ExecutorCreatorAnnotation: `{"username":"admin"}`}
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("Expecting bad request error for the forged executor creator, but got %v", err)
+	}
+
+	creator, err := helper.ExecutorCreator(request.UserInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mwrSet.Annotations[helper.ExecutorCreatorAnnotation] = creator
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mwrSet.Spec.ManifestWorkTemplate.Executor.Subject.ServiceAccount.Name = "{{ .Values.sa }}"
+	_, err = webHook.validateRequest(mwrSet, nil, ctx)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("Expecting bad request error for the unsupported executor placeholder, but got %v", err)
+	}
 }
 
 func TestWebHookCreateRequest(t *testing.T) {
@@ -118,6 +179,349 @@ func TestWebHookUpdateRequest(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// an invalid manifestWorkReplicaSet created before the validation is not blocked on the metadata updates
+	// and the deletion.
+	invalid := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	invalid.Spec.PlacementRefs = nil
+	updated := invalid.DeepCopy()
+	updated.Labels = map[string]string{"app": "test"}
+	updated.Finalizers = nil
+	_, err = webHook.ValidateUpdate(ctx, invalid, updated)
+	if err != nil {
+		t.Fatalf("Expecting no error for the metadata update, but got %v", err)
+	}
+
+	deleting := invalid.DeepCopy()
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	deleting.Spec.ManifestWorkTemplate.Workload.Manifests = nil
+	_, err = webHook.ValidateUpdate(ctx, invalid, deleting)
+	if err != nil {
+		t.Fatalf("Expecting no error for the deleting manifestWorkReplicaSet, but got %v", err)
+	}
+
+	updated.Annotations = map[string]string{helper.PriorityAnnotation: "high"}
+	_, err = webHook.ValidateUpdate(ctx, invalid, updated)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("Expecting bad request error for the annotation update, but got %v", err)
+	}
+
+	updated = invalid.DeepCopy()
+	updated.Spec.ManifestWorkTemplate.Workload.Manifests = nil
+	_, err = webHook.ValidateUpdate(ctx, invalid, updated)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("Expecting bad request error for the spec update, but got %v", err)
+	}
+}
+
+func TestWebHookValidatePlacementRefNamespaces(t *testing.T) {
+	setupFeatureGate(t)
+
+	cases := []struct {
+		name       string
+		namespaces string
+		expectErr  bool
+	}{
+		{
+			name: "placement in the same namespace",
+		},
+		{
+			name:       "placement in the namespace of the manifestWorkReplicaSet",
+			namespaces: `{"place-test": "default"}`,
+		},
+		{
+			name:       "permission to get the placement in the shared namespace",
+			namespaces: `{"place-test": "shared"}`,
+		},
+		{
+			name:       "no permission to get the placement in the other namespace",
+			namespaces: `{"place-test": "other"}`,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid annotation",
+			namespaces: `["shared"]`,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid namespace",
+			namespaces: `{"place-test": "Shared/ns"}`,
+			expectErr:  true,
+		},
+	}
+
+	kubeClient := fakekube.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "subjectaccessreviews",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			obj := action.(clienttesting.CreateActionImpl).Object.(*authorizationv1.SubjectAccessReview)
+			allowed := obj.Spec.User == "test1" &&
+				reflect.DeepEqual(obj.Spec.ResourceAttributes, &authorizationv1.ResourceAttributes{
+					Group:     "cluster.open-cluster-management.io",
+					Resource:  "placements",
+					Verb:      "get",
+					Namespace: "shared",
+					Name:      "place-test",
+				})
+			return true, &authorizationv1.SubjectAccessReview{
+				Status: authorizationv1.SubjectAccessReviewStatus{
+					Allowed: allowed,
+				},
+			}, nil
+		},
+	)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webHook := ManifestWorkReplicaSetWebhook{kubeClient: kubeClient}
+			request := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:  manifestWorkReplicaSetSchema,
+					Operation: admissionv1.Create,
+					UserInfo:  authenticationv1.UserInfo{Username: "test1"},
+				},
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), request)
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			if len(c.namespaces) > 0 {
+				mwrSet.Annotations = map[string]string{helper.PlacementRefNamespacesAnnotation: c.namespaces}
+			}
+
+			_, err := webHook.validateRequest(mwrSet, nil, ctx)
+			if c.expectErr && !apierrors.IsBadRequest(err) {
+				t.Errorf("expected bad request error, but got %v", err)
+			}
+			if !c.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebHookValidateManifestWorkReplicaSetSpec(t *testing.T) {
+	setupFeatureGate(t)
+
+	cases := []struct {
+		name        string
+		mutate      func(mwrSet *workv1alpha1.ManifestWorkReplicaSet)
+		oldMutate   func(mwrSet *workv1alpha1.ManifestWorkReplicaSet)
+		expectedErr bool
+	}{
+		{
+			name: "valid manifestWorkReplicaSet",
+		},
+		{
+			name: "empty placementRefs",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Spec.PlacementRefs = nil
+			},
+			expectedErr: true,
+		},
+		{
+			name: "empty placementRef name",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Spec.PlacementRefs = []workv1alpha1.LocalPlacementReference{{Name: ""}}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid placementRef name",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Spec.PlacementRefs = []workv1alpha1.LocalPlacementReference{{Name: "Place_Test"}}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "duplicated placementRef names",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Spec.PlacementRefs = append(mwrSet.Spec.PlacementRefs, mwrSet.Spec.PlacementRefs...)
+			},
+			expectedErr: true,
+		},
+		{
+			name: "manifests without headroom for the controller label",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				common.ManifestValidator.WithLimit(mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests[0].Size() + 10)
+			},
+			expectedErr: true,
+		},
+		{
+			name: "manifests with headroom for the controller label",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				common.ManifestValidator.WithLimit(mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests[0].Size() + 1024)
+			},
+		},
+		{
+			name: "progressive rollout",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutStrategyAnnotationKey:         helper.RolloutStrategyProgressive,
+					helper.RolloutMaxConcurrencyAnnotationKey:   "20%",
+					helper.RolloutProgressDeadlineAnnotationKey: "10m",
+				}
+			},
+		},
+		{
+			name: "invalid max concurrency",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutMaxConcurrencyAnnotationKey: "-1",
+				}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "max concurrency with the progressive per group rollout",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutStrategyAnnotationKey:       helper.RolloutStrategyProgressivePerGroup,
+					helper.RolloutMaxConcurrencyAnnotationKey: "2",
+				}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "max failures with the progressive rollout",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutMaxConcurrencyAnnotationKey: "2",
+					helper.RolloutMaxFailuresAnnotationKey:    "1",
+				}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "progress deadline without rollout",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutProgressDeadlineAnnotationKey: "10m",
+				}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "unchanged inconsistent rollout on update",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutProgressDeadlineAnnotationKey: "10m",
+				}
+			},
+			oldMutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutProgressDeadlineAnnotationKey: "10m",
+				}
+			},
+		},
+		{
+			name: "changed inconsistent rollout on update",
+			mutate: func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {
+				mwrSet.Annotations = map[string]string{
+					helper.RolloutProgressDeadlineAnnotationKey: "10m",
+				}
+			},
+			oldMutate:   func(mwrSet *workv1alpha1.ManifestWorkReplicaSet) {},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer common.ManifestValidator.WithLimit(500 * 1024)
+
+			request := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:  manifestWorkReplicaSetSchema,
+					Operation: admissionv1.Create,
+				},
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), request)
+			webHook := ManifestWorkReplicaSetWebhook{}
+
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			if c.mutate != nil {
+				c.mutate(mwrSet)
+			}
+			var oldMWRSet *workv1alpha1.ManifestWorkReplicaSet
+			if c.oldMutate != nil {
+				oldMWRSet = helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+				c.oldMutate(oldMWRSet)
+			}
+
+			_, err := webHook.validateRequest(mwrSet, oldMWRSet, ctx)
+			if c.expectedErr && !apierrors.IsBadRequest(err) {
+				t.Errorf("expected bad request error, but got %v", err)
+			}
+			if !c.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebHookValidateDependencies(t *testing.T) {
+	newMWRSet := func(name, dependsOn string) *workv1alpha1.ManifestWorkReplicaSet {
+		mwrSet := helpertest.CreateTestManifestWorkReplicaSet(name, "default", "place-test")
+		if len(dependsOn) > 0 {
+			mwrSet.Annotations = map[string]string{helper.DependsOnAnnotation: dependsOn}
+		}
+		return mwrSet
+	}
+
+	cases := []struct {
+		name          string
+		mwrSets       []*workv1alpha1.ManifestWorkReplicaSet
+		oldMWRSet     *workv1alpha1.ManifestWorkReplicaSet
+		mwrSet        *workv1alpha1.ManifestWorkReplicaSet
+		notSynced     bool
+		expectedError func(error) bool
+	}{
+		{
+			name:    "chain",
+			mwrSets: []*workv1alpha1.ManifestWorkReplicaSet{newMWRSet("db", "storage"), newMWRSet("storage", "")},
+			mwrSet:  newMWRSet("app", "db"),
+		},
+		{
+			name:          "cycle",
+			mwrSets:       []*workv1alpha1.ManifestWorkReplicaSet{newMWRSet("db", "storage"), newMWRSet("storage", "app")},
+			mwrSet:        newMWRSet("app", "db"),
+			expectedError: apierrors.IsBadRequest,
+		},
+		{
+			name:      "unchanged cycle on update",
+			mwrSets:   []*workv1alpha1.ManifestWorkReplicaSet{newMWRSet("db", "app")},
+			oldMWRSet: newMWRSet("app", "db"),
+			mwrSet:    newMWRSet("app", "db"),
+		},
+		{
+			name:          "cache not synced",
+			mwrSet:        newMWRSet("app", "db"),
+			notSynced:     true,
+			expectedError: apierrors.IsServiceUnavailable,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, mwrSet := range c.mwrSets {
+				if err := indexer.Add(&metav1.PartialObjectMetadata{ObjectMeta: mwrSet.ObjectMeta}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			webHook := ManifestWorkReplicaSetWebhook{
+				dependencyLister: common.NewDependencyLister("manifestworkreplicasets",
+					metadatalister.New(indexer, workv1alpha1.GroupVersion.WithResource("manifestworkreplicasets")),
+					func() bool { return !c.notSynced }),
+			}
+
+			err := webHook.validateDependencies(c.mwrSet, c.oldMWRSet)
+			switch {
+			case c.expectedError == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case c.expectedError != nil && !c.expectedError(err):
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
 }
 
 func setupFeatureGate(t *testing.T) {
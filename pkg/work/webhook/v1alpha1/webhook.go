@@ -3,12 +3,20 @@ package v1alpha1
 import (
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	v1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
 type ManifestWorkReplicaSetWebhook struct {
 	kubeClient kubernetes.Interface
+	// schemaValidator validates the manifests against the OpenAPI schemas of the hub.
+	schemaValidator *common.SchemaValidator
+	// dependencyLister detects the cycles of the dependencies from the cached manifestWorkReplicaSets.
+	dependencyLister *common.DependencyLister
 }
 
 func (r *ManifestWorkReplicaSetWebhook) Init(mgr ctrl.Manager) error {
@@ -17,6 +25,15 @@ func (r *ManifestWorkReplicaSetWebhook) Init(mgr ctrl.Manager) error {
 		return err
 	}
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.schemaValidator, err = common.StartSchemaValidator(mgr, r.kubeClient.Discovery().OpenAPIV3())
+	if err != nil {
+		return err
+	}
+	r.dependencyLister, err = common.StartDependencyLister(mgr,
+		v1alpha1.GroupVersion.WithResource("manifestworkreplicasets"))
 	return err
 }
 
@@ -26,6 +43,10 @@ func (r *ManifestWorkReplicaSetWebhook) SetExternalKubeClientSet(client kubernet
 }
 
 func (r *ManifestWorkReplicaSetWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(ManifestWorkReplicaSetMutatingPath, &webhook.Admission{
+		Handler: NewManifestWorkReplicaSetMutator(admission.NewDecoder(mgr.GetScheme())),
+	})
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		WithValidator(r).
 		For(&v1alpha1.ManifestWorkReplicaSet{}).
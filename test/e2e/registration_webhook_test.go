@@ -159,8 +159,8 @@ var _ = ginkgo.Describe("Admission webhook", func() {
 				_, err := t.ClusterClient.ClusterV1().ManagedClusters().Create(context.TODO(), managedCluster, metav1.CreateOptions{})
 				gomega.Expect(err).To(gomega.HaveOccurred())
 				gomega.Expect(errors.IsBadRequest(err)).Should(gomega.BeTrue())
-				gomega.Expect(err.Error()).Should(gomega.Equal(fmt.Sprintf(
-					"admission webhook \"%s\" denied the request: url \"%s\" is invalid in client configs",
+				gomega.Expect(err.Error()).Should(gomega.HavePrefix(fmt.Sprintf(
+					"admission webhook \"%s\" denied the request: url \"%s\" is invalid in client configs: ",
 					admissionName,
 					invalidURL,
 				)))
@@ -591,31 +591,31 @@ var _ = ginkgo.Describe("Work agent", ginkgo.Label("work-agent", "sanity-check")
 
 				expectedValues := []workapiv1.FeedbackValue{
 					{
-						Name: "ReadyReplicas",
+						Name: "AvailableCondition",
 						Value: workapiv1.FieldValue{
-							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(1),
+							Type:   workapiv1.String,
+							String: pointer.String("True"),
 						},
 					},
 					{
-						Name: "Replicas",
+						Name: "AvailableReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(1),
 						},
 					},
 					{
-						Name: "AvailableReplicas",
+						Name: "ReadyReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(1),
 						},
 					},
 					{
-						Name: "AvailableCondition",
+						Name: "Replicas",
 						Value: workapiv1.FieldValue{
-							Type:   workapiv1.String,
-							String: pointer.String("True"),
+							Type:    workapiv1.Integer,
+							Integer: pointer.Int64(1),
 						},
 					},
 				}
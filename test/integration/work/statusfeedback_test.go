@@ -130,24 +130,24 @@ var _ = ginkgo.Describe("ManifestWork Status Feedback", func() {
 
 				expectedValues := []workapiv1.FeedbackValue{
 					{
-						Name: "ReadyReplicas",
+						Name: "AvailableReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(2),
 						},
 					},
 					{
-						Name: "Replicas",
+						Name: "ReadyReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(3),
+							Integer: pointer.Int64(2),
 						},
 					},
 					{
-						Name: "AvailableReplicas",
+						Name: "Replicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(2),
+							Integer: pointer.Int64(3),
 						},
 					},
 				}
@@ -192,21 +192,21 @@ var _ = ginkgo.Describe("ManifestWork Status Feedback", func() {
 
 				expectedValues := []workapiv1.FeedbackValue{
 					{
-						Name: "ReadyReplicas",
+						Name: "AvailableReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(3),
 						},
 					},
 					{
-						Name: "Replicas",
+						Name: "ReadyReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(3),
 						},
 					},
 					{
-						Name: "AvailableReplicas",
+						Name: "Replicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(3),
@@ -382,24 +382,24 @@ var _ = ginkgo.Describe("ManifestWork Status Feedback", func() {
 
 				expectedValues := []workapiv1.FeedbackValue{
 					{
-						Name: "ReadyReplicas",
+						Name: "AvailableReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
 							Integer: pointer.Int64(2),
 						},
 					},
 					{
-						Name: "Replicas",
+						Name: "ReadyReplicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(3),
+							Integer: pointer.Int64(2),
 						},
 					},
 					{
-						Name: "AvailableReplicas",
+						Name: "Replicas",
 						Value: workapiv1.FieldValue{
 							Type:    workapiv1.Integer,
-							Integer: pointer.Int64(2),
+							Integer: pointer.Int64(3),
 						},
 					},
 				}
@@ -97,7 +97,7 @@ var _ = ginkgo.BeforeSuite(func() {
 
 	// start hub controller
 	go func() {
-		err := hub.RunWorkHubManager(envCtx, &controllercmd.ControllerContext{
+		err := hub.NewWorkHubManagerOptions().RunWorkHubManager(envCtx, &controllercmd.ControllerContext{
 			KubeConfig:    cfg,
 			EventRecorder: util.NewIntegrationTestEventRecorder("hub"),
 		})
@@ -10,6 +10,7 @@ import (
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -436,6 +437,55 @@ var _ = ginkgo.Describe("ManifestWork", func() {
 		})
 	})
 
+	ginkgo.Context("With CR in manifests whose CRD is not installed", func() {
+		var spokeDynamicClient dynamic.Interface
+		var crd *unstructured.Unstructured
+		var crdGVR schema.GroupVersionResource
+
+		ginkgo.BeforeEach(func() {
+			spokeDynamicClient, err = dynamic.NewForConfig(spokeRestConfig)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+			crd, crdGVR, err = util.GuestbookCrd()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+			cr, _, err := util.GuestbookCr(o.AgentOptions.SpokeClusterName, "guestbook1")
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			manifests = append(manifests, util.ToManifest(cr))
+		})
+
+		ginkgo.AfterEach(func() {
+			err := spokeDynamicClient.Resource(crdGVR).Delete(context.Background(), crd.GetName(), metav1.DeleteOptions{})
+			if !errors.IsNotFound(err) {
+				gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			}
+		})
+
+		ginkgo.It("should apply the CR right after the CRD is installed", func() {
+			gomega.Eventually(func() error {
+				work, err := hubWorkClient.WorkV1().ManifestWorks(work.Namespace).Get(context.Background(), work.Name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if len(work.Status.ResourceStatus.Manifests) != 1 {
+					return fmt.Errorf("expect 1 manifest condition, but got %v", work.Status.ResourceStatus.Manifests)
+				}
+				cond := meta.FindStatusCondition(work.Status.ResourceStatus.Manifests[0].Conditions, string(workapiv1.ManifestApplied))
+				if cond == nil || cond.Reason != "ApiNotAvailable" {
+					return fmt.Errorf("expect reason ApiNotAvailable, but got %v", cond)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(gomega.Succeed())
+
+			// install the crd, the work should be applied without waiting for the resync
+			_, err = spokeDynamicClient.Resource(crdGVR).Create(context.Background(), crd, metav1.CreateOptions{})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+			util.AssertWorkCondition(work.Namespace, work.Name, hubWorkClient, string(workapiv1.WorkApplied), metav1.ConditionTrue,
+				[]metav1.ConditionStatus{metav1.ConditionTrue}, eventuallyTimeout, eventuallyInterval)
+		})
+	})
+
 	ginkgo.Context("With Service Account, Role, RoleBinding and Deployment in manifests", func() {
 		var spokeDynamicClient dynamic.Interface
 		var gvrs []schema.GroupVersionResource